@@ -0,0 +1,85 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Event represents a single Server-Sent Event.
+type Event struct {
+	Name string
+	Data []byte
+}
+
+// StatusError is returned when the server rejects a stream request.
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("API error (status %d): %s", e.StatusCode, e.Body)
+}
+
+// Stream opens a Server-Sent Events stream and calls handle for each event
+// until the context is cancelled or the server closes the connection.
+func (c *Client) Stream(ctx context.Context, path string, handle func(Event) error) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("User-Agent", "gwo-cli/0.1.0")
+
+	// Streams are long-lived, so don't apply the client timeout
+	httpClient := &http.Client{Transport: c.httpClient.Transport}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		data, _ := io.ReadAll(resp.Body)
+		return &StatusError{StatusCode: resp.StatusCode, Body: string(data)}
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var event Event
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			// Blank line dispatches the event
+			if len(data) > 0 {
+				event.Data = []byte(strings.Join(data, "\n"))
+				if err := handle(event); err != nil {
+					return err
+				}
+			}
+			event = Event{}
+			data = nil
+		case strings.HasPrefix(line, ":"):
+			// Comment (keepalive)
+		case strings.HasPrefix(line, "event:"):
+			event.Name = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("stream interrupted: %w", err)
+	}
+	return io.EOF
+}
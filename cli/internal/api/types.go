@@ -119,3 +119,12 @@ type APIKey struct {
 	LastUsedAt   *time.Time `json:"lastUsedAt,omitempty"`
 	ExpiresAt    *time.Time `json:"expiresAt,omitempty"`
 }
+
+// ToolClassification represents the risk classification of an MCP tool.
+type ToolClassification struct {
+	MCPServer        string `json:"mcp_server" yaml:"server"`
+	ToolName         string `json:"tool_name" yaml:"tool"`
+	Classification   string `json:"classification" yaml:"classification"`
+	RequiresApproval bool   `json:"requires_approval" yaml:"requires_approval"`
+	Description      string `json:"description,omitempty" yaml:"description,omitempty"`
+}
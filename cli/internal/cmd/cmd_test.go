@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+)
+
+// runCLI runs the CLI with args and returns what it wrote to stdout and
+// stderr.
+func runCLI(t *testing.T, args ...string) (string, string, error) {
+	t.Helper()
+	t.Cleanup(func() {
+		output, baseURL = "table", "https://api.gatewayops.com"
+		rootCmd.SetOut(nil)
+		rootCmd.SetErr(nil)
+	})
+	var stdout, stderr bytes.Buffer
+	rootCmd.SetOut(&stdout)
+	rootCmd.SetErr(&stderr)
+	rootCmd.SetArgs(args)
+	err := rootCmd.Execute()
+	return stdout.String(), stderr.String(), err
+}
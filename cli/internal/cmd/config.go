@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/akz4ol/gatewayops/cli/internal/api"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage gateway configuration",
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validate a gateway environment file",
+	Long: `Validate a gateway configuration before deploying it. The file uses
+KEY=VALUE lines, as in a .env file, and is checked by the gateway without
+being applied.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client := api.NewClient(getBaseURL(), getAPIKey())
+
+		file, _ := cmd.Flags().GetString("file")
+		env, err := readEnvFile(file)
+		if err != nil {
+			return err
+		}
+
+		data, err := client.Post("/v1/admin/config/validate", map[string]interface{}{
+			"env": env,
+		})
+		if err != nil {
+			return err
+		}
+
+		var result struct {
+			Valid    bool     `json:"valid"`
+			Problems []string `json:"problems"`
+		}
+		if err := json.Unmarshal(data, &result); err != nil {
+			return fmt.Errorf("failed to parse response: %w", err)
+		}
+
+		if output == "json" {
+			fmt.Println(string(data))
+		} else if result.Valid {
+			fmt.Printf("%s %s is valid\n", color.GreenString("✓"), file)
+		} else {
+			fmt.Printf("%s %s has %d problem(s):\n", color.RedString("✗"), file, len(result.Problems))
+			for _, p := range result.Problems {
+				fmt.Printf("  - %s\n", p)
+			}
+		}
+
+		if !result.Valid {
+			return fmt.Errorf("configuration is invalid")
+		}
+		return nil
+	},
+}
+
+// readEnvFile parses KEY=VALUE lines, ignoring blank lines and comments.
+func readEnvFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	defer f.Close()
+
+	env := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", path, lineNum)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		env[strings.TrimSpace(key)] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	return env, nil
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configValidateCmd)
+
+	configValidateCmd.Flags().StringP("file", "f", "", "Environment file to validate")
+	configValidateCmd.MarkFlagRequired("file")
+}
@@ -3,9 +3,14 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/akz4ol/gatewayops/cli/internal/api"
+	"github.com/fatih/color"
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
 )
@@ -164,11 +169,242 @@ var mcpResourcesCmd = &cobra.Command{
 	},
 }
 
+var mcpTestCmd = &cobra.Command{
+	Use:   "test [server]",
+	Short: "Test connectivity to an MCP server",
+	Long: `Verify that an MCP server is reachable through the gateway, list its tools
+with their classifications and approval requirements, and report latency.
+Use --call to smoke-test a single tool.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client := api.NewClient(getBaseURL(), getAPIKey())
+		server := args[0]
+
+		callTool, _ := cmd.Flags().GetString("call")
+		callArgs, _ := cmd.Flags().GetStringArray("arg")
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+		if output == "json" {
+			jsonOutput = true
+		}
+
+		toolArgs, err := parseToolArgs(callArgs)
+		if err != nil {
+			return err
+		}
+
+		out := cmd.OutOrStdout()
+		report := mcpTestReport{Server: server}
+
+		start := time.Now()
+		data, err := client.Post(fmt.Sprintf("/v1/mcp/%s/tools/list", server), nil)
+		report.ListLatencyMs = time.Since(start).Milliseconds()
+		if err != nil {
+			report.Error = err.Error()
+			if jsonOutput {
+				writeJSON(out, report)
+			} else {
+				fmt.Fprintf(out, "%s %s unreachable after %dms: %v\n", color.RedString("✗"), server, report.ListLatencyMs, err)
+			}
+			return fmt.Errorf("MCP server %s failed connectivity test", server)
+		}
+		report.Reachable = true
+
+		var result struct {
+			Tools []struct {
+				Name        string `json:"name"`
+				Description string `json:"description"`
+			} `json:"tools"`
+		}
+		if err := json.Unmarshal(data, &result); err != nil {
+			return fmt.Errorf("failed to parse response: %w", err)
+		}
+
+		for _, t := range result.Tools {
+			tool := mcpTestTool{Name: t.Name, Description: t.Description}
+			if c, err := fetchToolClassification(client, server, t.Name); err == nil {
+				tool.Classification = c.Classification
+				tool.RequiresApproval = c.RequiresApproval
+				tool.IsDefault = c.IsDefault
+			}
+			report.Tools = append(report.Tools, tool)
+		}
+
+		if callTool != "" {
+			report.Call = runTestCall(client, server, callTool, toolArgs)
+		}
+
+		if jsonOutput {
+			writeJSON(out, report)
+		} else {
+			printMCPTestReport(out, report)
+		}
+
+		if report.Call != nil && !report.Call.Success {
+			return fmt.Errorf("tool call %s failed", callTool)
+		}
+		return nil
+	},
+}
+
+// mcpTestReport is the result of an MCP server connectivity test.
+type mcpTestReport struct {
+	Server        string        `json:"server"`
+	Reachable     bool          `json:"reachable"`
+	ListLatencyMs int64         `json:"list_latency_ms"`
+	Error         string        `json:"error,omitempty"`
+	Tools         []mcpTestTool `json:"tools"`
+	Call          *mcpTestCall  `json:"call,omitempty"`
+}
+
+// mcpTestTool describes a tool discovered during a connectivity test.
+type mcpTestTool struct {
+	Name             string `json:"name"`
+	Description      string `json:"description,omitempty"`
+	Classification   string `json:"classification,omitempty"`
+	RequiresApproval bool   `json:"requires_approval"`
+	IsDefault        bool   `json:"is_default"`
+}
+
+// mcpTestCall is the result of a smoke-test tool call.
+type mcpTestCall struct {
+	Tool      string      `json:"tool"`
+	Success   bool        `json:"success"`
+	LatencyMs int64       `json:"latency_ms"`
+	Error     string      `json:"error,omitempty"`
+	Result    interface{} `json:"result,omitempty"`
+}
+
+// fetchToolClassification returns the effective classification of a tool,
+// including the server's default for unclassified tools.
+func fetchToolClassification(client *api.Client, server, tool string) (*mcpTestTool, error) {
+	data, err := client.Get(fmt.Sprintf("/v1/tool-classifications/%s/%s", url.PathEscape(server), url.PathEscape(tool)))
+	if err != nil {
+		return nil, err
+	}
+
+	var result mcpTestTool
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return &result, nil
+}
+
+// runTestCall invokes a single tool and records the outcome.
+func runTestCall(client *api.Client, server, tool string, toolArgs map[string]interface{}) *mcpTestCall {
+	call := &mcpTestCall{Tool: tool}
+
+	start := time.Now()
+	data, err := client.Post(fmt.Sprintf("/v1/mcp/%s/tools/call", server), map[string]interface{}{
+		"tool":      tool,
+		"arguments": toolArgs,
+	})
+	call.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		call.Error = err.Error()
+		return call
+	}
+
+	var result struct {
+		IsError bool `json:"isError"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		call.Error = fmt.Sprintf("failed to parse response: %v", err)
+		return call
+	}
+	call.Result = json.RawMessage(data)
+	call.Success = !result.IsError
+	if result.IsError {
+		call.Error = "tool returned an error result"
+	}
+	return call
+}
+
+// parseToolArgs converts key=value pairs into tool arguments. Values that are
+// valid JSON (numbers, booleans, objects) are decoded; anything else is a string.
+func parseToolArgs(pairs []string) (map[string]interface{}, error) {
+	toolArgs := make(map[string]interface{}, len(pairs))
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --arg %q, expected key=value", pair)
+		}
+		var decoded interface{}
+		if err := json.Unmarshal([]byte(value), &decoded); err == nil {
+			toolArgs[key] = decoded
+		} else {
+			toolArgs[key] = value
+		}
+	}
+	return toolArgs, nil
+}
+
+// printMCPTestReport writes a human-readable connectivity report to w.
+func printMCPTestReport(w io.Writer, report mcpTestReport) {
+	green := color.New(color.FgGreen).SprintFunc()
+	red := color.New(color.FgRed).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
+
+	fmt.Fprintf(w, "%s %s reachable (%dms, %d tools)\n\n", green("✓"), report.Server, report.ListLatencyMs, len(report.Tools))
+
+	if len(report.Tools) > 0 {
+		table := tablewriter.NewWriter(w)
+		table.SetHeader([]string{"Tool", "Classification", "Approval", "Description"})
+		table.SetBorder(false)
+
+		for _, t := range report.Tools {
+			classification := t.Classification
+			switch t.Classification {
+			case "safe":
+				classification = green(t.Classification)
+			case "sensitive":
+				classification = yellow(t.Classification)
+			case "dangerous":
+				classification = red(t.Classification)
+			}
+			if t.IsDefault {
+				classification += " (default)"
+			}
+
+			approval := "no"
+			if t.RequiresApproval {
+				approval = "required"
+			}
+
+			desc := t.Description
+			if len(desc) > 50 {
+				desc = desc[:47] + "..."
+			}
+			table.Append([]string{t.Name, classification, approval, desc})
+		}
+
+		table.Render()
+	}
+
+	if report.Call != nil {
+		if report.Call.Success {
+			fmt.Fprintf(w, "\n%s call %s succeeded (%dms)\n", green("✓"), report.Call.Tool, report.Call.LatencyMs)
+		} else {
+			fmt.Fprintf(w, "\n%s call %s failed (%dms): %s\n", red("✗"), report.Call.Tool, report.Call.LatencyMs, report.Call.Error)
+		}
+	}
+}
+
+// writeJSON writes a value to w as indented JSON.
+func writeJSON(w io.Writer, v interface{}) {
+	data, _ := json.MarshalIndent(v, "", "  ")
+	fmt.Fprintln(w, string(data))
+}
+
 func init() {
 	rootCmd.AddCommand(mcpCmd)
 	mcpCmd.AddCommand(mcpToolsCmd)
 	mcpCmd.AddCommand(mcpCallCmd)
 	mcpCmd.AddCommand(mcpResourcesCmd)
+	mcpCmd.AddCommand(mcpTestCmd)
 
 	mcpCallCmd.Flags().StringP("args", "a", "", "Tool arguments as JSON")
+
+	mcpTestCmd.Flags().String("call", "", "Invoke a single tool as a smoke test")
+	mcpTestCmd.Flags().StringArray("arg", nil, "Tool argument as key=value (repeatable)")
+	mcpTestCmd.Flags().Bool("json", false, "Print the report as JSON")
 }
@@ -0,0 +1,241 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+// mockMCPGateway serves the gateway's MCP and classification endpoints in
+// front of a server that answers like test/mock-mcp: read_file returns its
+// path and an unknown tool is rejected. execute_command reports an error
+// result, as a server refusing the command would. It records the arguments
+// of each call.
+func mockMCPGateway(t *testing.T) (*httptest.Server, *[]map[string]interface{}) {
+	t.Helper()
+	var calls []map[string]interface{}
+	classifications := map[string]string{
+		"read_file":       `{"classification":"safe","requires_approval":false,"is_default":false}`,
+		"execute_command": `{"classification":"dangerous","requires_approval":true,"is_default":false}`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/mcp/filesystem/tools/list":
+			w.Write([]byte(`{"tools":[
+				{"name":"read_file","description":"Read the contents of a file"},
+				{"name":"execute_command","description":"Execute a shell command"},
+				{"name":"search","description":"Search for files or content"}]}`))
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/v1/tool-classifications/filesystem/"):
+			c, ok := classifications[strings.TrimPrefix(r.URL.Path, "/v1/tool-classifications/filesystem/")]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write([]byte(c))
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/mcp/filesystem/tools/call":
+			var req struct {
+				Tool      string                 `json:"tool"`
+				Arguments map[string]interface{} `json:"arguments"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, "Invalid request body", http.StatusBadRequest)
+				return
+			}
+			calls = append(calls, req.Arguments)
+			switch req.Tool {
+			case "read_file":
+				fmt.Fprintf(w, `{"result":{"content":"Mock file content for %s","type":"text"}}`, req.Arguments["path"])
+			case "execute_command":
+				w.Write([]byte(`{"isError":true,"content":[{"type":"text","text":"command not allowed"}]}`))
+			default:
+				http.Error(w, `{"error":"Unknown tool: `+req.Tool+`"}`, http.StatusBadRequest)
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+// resetMCPTestFlags restores the test command's flags after a run.
+func resetMCPTestFlags(t *testing.T) {
+	t.Cleanup(func() {
+		flags := mcpTestCmd.Flags()
+		flags.Set("call", "")
+		flags.Set("json", "false")
+		if arg, ok := flags.Lookup("arg").Value.(interface{ Replace([]string) error }); ok {
+			arg.Replace(nil)
+		}
+	})
+}
+
+func TestMCPTestListsToolsWithClassifications(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = true
+	t.Cleanup(func() { color.NoColor = noColor })
+	resetMCPTestFlags(t)
+	srv, calls := mockMCPGateway(t)
+
+	stdout, _, err := runCLI(t, "mcp", "test", "filesystem", "--base-url", srv.URL)
+	if err != nil {
+		t.Fatalf("mcp test: %v", err)
+	}
+	if !strings.Contains(stdout, "✓ filesystem reachable") || !strings.Contains(stdout, "3 tools)") {
+		t.Errorf("stdout = %q, want the reachability line", stdout)
+	}
+	for _, row := range [][]string{
+		{"read_file", "safe", "no"},
+		{"execute_command", "dangerous", "required"},
+	} {
+		if !containsRow(stdout, row) {
+			t.Errorf("stdout = %q, want a row with %v", stdout, row)
+		}
+	}
+	if len(*calls) != 0 {
+		t.Errorf("%d tools called without --call", len(*calls))
+	}
+
+	stdout, _, err = runCLI(t, "mcp", "test", "filesystem", "--json", "--base-url", srv.URL)
+	if err != nil {
+		t.Fatalf("mcp test --json: %v", err)
+	}
+	var report mcpTestReport
+	if err := json.Unmarshal([]byte(stdout), &report); err != nil {
+		t.Fatalf("stdout is not JSON: %v\n%s", err, stdout)
+	}
+	want := []mcpTestTool{
+		{Name: "read_file", Description: "Read the contents of a file", Classification: "safe"},
+		{Name: "execute_command", Description: "Execute a shell command", Classification: "dangerous", RequiresApproval: true},
+		{Name: "search", Description: "Search for files or content"},
+	}
+	if !report.Reachable || !reflect.DeepEqual(report.Tools, want) || report.Call != nil {
+		t.Errorf("report = %+v, want the three tools, unclassified search left blank", report)
+	}
+}
+
+// containsRow reports whether a line of out holds every cell, in order.
+func containsRow(out string, cells []string) bool {
+	for _, line := range strings.Split(out, "\n") {
+		rest, found := line, true
+		for _, cell := range cells {
+			i := strings.Index(rest, cell)
+			if i < 0 {
+				found = false
+				break
+			}
+			rest = rest[i+len(cell):]
+		}
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
+func TestMCPTestCall(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		success bool
+		error   string
+	}{
+		{
+			name:    "success",
+			args:    []string{"--call", "read_file", "--arg", "path=/tmp/notes.txt"},
+			success: true,
+		},
+		{
+			name:  "error result",
+			args:  []string{"--call", "execute_command", "--arg", "command=rm -rf /"},
+			error: "tool returned an error result",
+		},
+		{
+			name:  "rejected call",
+			args:  []string{"--call", "delete_everything"},
+			error: "Unknown tool",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetMCPTestFlags(t)
+			srv, calls := mockMCPGateway(t)
+
+			args := append([]string{"mcp", "test", "filesystem", "--json", "--base-url", srv.URL}, tt.args...)
+			stdout, _, err := runCLI(t, args...)
+			if tt.success != (err == nil) {
+				t.Fatalf("err = %v, want success %v", err, tt.success)
+			}
+
+			report, _, _ := strings.Cut(stdout, "Usage:")
+			var got mcpTestReport
+			if err := json.Unmarshal([]byte(report), &got); err != nil {
+				t.Fatalf("stdout is not JSON: %v\n%s", err, stdout)
+			}
+			if got.Call == nil || got.Call.Tool != tt.args[1] || got.Call.Success != tt.success || !strings.Contains(got.Call.Error, tt.error) {
+				t.Fatalf("call = %+v, want success %v with error %q", got.Call, tt.success, tt.error)
+			}
+			if len(*calls) != 1 {
+				t.Fatalf("%d calls, want 1", len(*calls))
+			}
+		})
+	}
+
+	resetMCPTestFlags(t)
+	srv, calls := mockMCPGateway(t)
+	if _, _, err := runCLI(t, "mcp", "test", "filesystem", "--base-url", srv.URL,
+		"--call", "read_file", "--arg", "path=/tmp/notes.txt", "--arg", "limit=10"); err != nil {
+		t.Fatalf("mcp test --call: %v", err)
+	}
+	want := map[string]interface{}{"path": "/tmp/notes.txt", "limit": float64(10)}
+	if len(*calls) != 1 || !reflect.DeepEqual((*calls)[0], want) {
+		t.Errorf("call arguments = %v, want %v", *calls, want)
+	}
+}
+
+func TestMCPTestUnreachableServer(t *testing.T) {
+	resetMCPTestFlags(t)
+	srv, _ := mockMCPGateway(t)
+
+	stdout, _, err := runCLI(t, "mcp", "test", "github", "--json", "--base-url", srv.URL)
+	if err == nil || !strings.Contains(err.Error(), "failed connectivity test") {
+		t.Fatalf("err = %v, want the connectivity failure", err)
+	}
+	report, _, _ := strings.Cut(stdout, "Usage:")
+	var got mcpTestReport
+	if err := json.Unmarshal([]byte(report), &got); err != nil {
+		t.Fatalf("stdout is not JSON: %v\n%s", err, stdout)
+	}
+	if got.Reachable || got.Error == "" || len(got.Tools) != 0 {
+		t.Errorf("report = %+v, want an unreachable server with its error", got)
+	}
+}
+
+func TestParseToolArgs(t *testing.T) {
+	got, err := parseToolArgs([]string{"path=/tmp/a=b", "limit=10", "recursive=true", `filter={"ext":"go"}`, "name=plain"})
+	if err != nil {
+		t.Fatalf("parseToolArgs: %v", err)
+	}
+	want := map[string]interface{}{
+		"path":      "/tmp/a=b",
+		"limit":     float64(10),
+		"recursive": true,
+		"filter":    map[string]interface{}{"ext": "go"},
+		"name":      "plain",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("args = %v, want %v", got, want)
+	}
+
+	for _, bad := range []string{"novalue", "=value"} {
+		if _, err := parseToolArgs([]string{bad}); err == nil {
+			t.Errorf("%q was accepted", bad)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/akz4ol/gatewayops/cli/internal/api"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// classificationFile is the declarative YAML format for tool classifications.
+type classificationFile struct {
+	Classifications []api.ToolClassification `yaml:"classifications"`
+}
+
+// classificationAction describes how a local classification differs from the server.
+type classificationAction string
+
+const (
+	actionCreate    classificationAction = "create"
+	actionUpdate    classificationAction = "update"
+	actionUnchanged classificationAction = "no-change"
+)
+
+// classificationChange is a single entry in a classification diff.
+type classificationChange struct {
+	Action  classificationAction    `json:"action"`
+	Local   api.ToolClassification  `json:"local"`
+	Remote  *api.ToolClassification `json:"remote,omitempty"`
+	Changes []string                `json:"changes,omitempty"`
+}
+
+var toolsCmd = &cobra.Command{
+	Use:   "tools",
+	Short: "Manage tool classifications",
+	Long: `Manage tool risk classifications and approval requirements declaratively.
+
+Classifications are described in YAML:
+
+  classifications:
+    - server: filesystem
+      tool: write_file
+      classification: sensitive
+      requires_approval: true`,
+}
+
+var toolsApplyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Apply tool classifications from a YAML file",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client := api.NewClient(getBaseURL(), getAPIKey())
+
+		file, _ := cmd.Flags().GetString("file")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		local, err := readClassificationFile(file)
+		if err != nil {
+			return err
+		}
+
+		remote, err := fetchClassifications(client)
+		if err != nil {
+			return err
+		}
+
+		changes, err := diffClassifications(remote, local)
+		if err != nil {
+			return err
+		}
+
+		var pending []api.ToolClassification
+		for _, c := range changes {
+			if c.Action != actionUnchanged {
+				pending = append(pending, c.Local)
+			}
+		}
+
+		// Keep stdout parseable in JSON mode: the summary goes to stderr
+		status := cmd.OutOrStdout()
+		if output == "json" {
+			data, _ := json.MarshalIndent(changes, "", "  ")
+			fmt.Fprintln(cmd.OutOrStdout(), string(data))
+			status = cmd.ErrOrStderr()
+		} else {
+			printClassificationDiff(changes)
+		}
+
+		if len(pending) == 0 {
+			fmt.Fprintln(status, "\nNo changes to apply")
+			return nil
+		}
+		if dryRun {
+			fmt.Fprintf(status, "\nDry run: %d change(s) not applied\n", len(pending))
+			return nil
+		}
+
+		if _, err := client.Post("/v1/tool-classifications/bulk", map[string]interface{}{
+			"classifications": pending,
+		}); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(status, "\nApplied %d change(s)\n", len(pending))
+		return nil
+	},
+}
+
+var toolsExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export tool classifications as YAML",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client := api.NewClient(getBaseURL(), getAPIKey())
+
+		file, _ := cmd.Flags().GetString("file")
+
+		classifications, err := fetchClassifications(client)
+		if err != nil {
+			return err
+		}
+		sortClassifications(classifications)
+
+		data, err := yaml.Marshal(classificationFile{Classifications: classifications})
+		if err != nil {
+			return fmt.Errorf("failed to encode classifications: %w", err)
+		}
+
+		if file == "" || file == "-" {
+			fmt.Print(string(data))
+			return nil
+		}
+		if err := os.WriteFile(file, data, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", file, err)
+		}
+		fmt.Printf("Exported %d classifications to %s\n", len(classifications), file)
+		return nil
+	},
+}
+
+// readClassificationFile loads and validates a classification YAML file.
+func readClassificationFile(path string) ([]api.ToolClassification, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var file classificationFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	for i, c := range file.Classifications {
+		if c.MCPServer == "" || c.ToolName == "" {
+			return nil, fmt.Errorf("classifications[%d]: server and tool are required", i)
+		}
+		switch c.Classification {
+		case "safe", "sensitive", "dangerous":
+		default:
+			return nil, fmt.Errorf("classifications[%d]: classification must be one of safe, sensitive, dangerous", i)
+		}
+	}
+
+	return file.Classifications, nil
+}
+
+// fetchClassifications returns the classifications currently set on the server.
+func fetchClassifications(client *api.Client) ([]api.ToolClassification, error) {
+	data, err := client.Get("/v1/tool-classifications")
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Classifications []api.ToolClassification `json:"classifications"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return result.Classifications, nil
+}
+
+// diffClassifications compares local classifications against the remote
+// state. Remote classifications absent from the local file are left untouched.
+func diffClassifications(remote, local []api.ToolClassification) ([]classificationChange, error) {
+	existing := make(map[string]api.ToolClassification, len(remote))
+	for _, c := range remote {
+		existing[classificationKey(c)] = c
+	}
+
+	seen := make(map[string]bool, len(local))
+	changes := make([]classificationChange, 0, len(local))
+	for _, c := range local {
+		key := classificationKey(c)
+		if seen[key] {
+			return nil, fmt.Errorf("duplicate classification for %s", key)
+		}
+		seen[key] = true
+
+		r, ok := existing[key]
+		if !ok {
+			changes = append(changes, classificationChange{Action: actionCreate, Local: c})
+			continue
+		}
+
+		var diffs []string
+		if r.Classification != c.Classification {
+			diffs = append(diffs, fmt.Sprintf("classification: %s -> %s", r.Classification, c.Classification))
+		}
+		if r.RequiresApproval != c.RequiresApproval {
+			diffs = append(diffs, fmt.Sprintf("requires_approval: %t -> %t", r.RequiresApproval, c.RequiresApproval))
+		}
+		if r.Description != c.Description {
+			diffs = append(diffs, fmt.Sprintf("description: %q -> %q", r.Description, c.Description))
+		}
+
+		action := actionUnchanged
+		if len(diffs) > 0 {
+			action = actionUpdate
+		}
+		changes = append(changes, classificationChange{Action: action, Local: c, Remote: &r, Changes: diffs})
+	}
+
+	return changes, nil
+}
+
+// printClassificationDiff prints a human-readable classification diff.
+func printClassificationDiff(changes []classificationChange) {
+	green := color.New(color.FgGreen).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
+
+	for _, c := range changes {
+		key := classificationKey(c.Local)
+		switch c.Action {
+		case actionCreate:
+			fmt.Printf("%s %s (%s, requires_approval=%t)\n", green("+"), key, c.Local.Classification, c.Local.RequiresApproval)
+		case actionUpdate:
+			fmt.Printf("%s %s\n", yellow("~"), key)
+			for _, d := range c.Changes {
+				fmt.Printf("    %s\n", d)
+			}
+		default:
+			fmt.Printf("  %s (no change)\n", key)
+		}
+	}
+}
+
+// sortClassifications orders classifications by server and tool.
+func sortClassifications(classifications []api.ToolClassification) {
+	sort.Slice(classifications, func(i, j int) bool {
+		return classificationKey(classifications[i]) < classificationKey(classifications[j])
+	})
+}
+
+func classificationKey(c api.ToolClassification) string {
+	return strings.Join([]string{c.MCPServer, c.ToolName}, ".")
+}
+
+func init() {
+	rootCmd.AddCommand(toolsCmd)
+	toolsCmd.AddCommand(toolsApplyCmd)
+	toolsCmd.AddCommand(toolsExportCmd)
+
+	toolsApplyCmd.Flags().StringP("file", "f", "", "Classification YAML file (- for stdin)")
+	toolsApplyCmd.Flags().Bool("dry-run", false, "Show the diff without applying changes")
+	toolsApplyCmd.MarkFlagRequired("file")
+
+	toolsExportCmd.Flags().StringP("file", "f", "", "Write YAML to a file instead of stdout")
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/akz4ol/gatewayops/cli/internal/api"
+)
+
+func TestDiffClassifications(t *testing.T) {
+	remote := []api.ToolClassification{
+		{MCPServer: "filesystem", ToolName: "read_file", Classification: "safe"},
+		{MCPServer: "filesystem", ToolName: "write_file", Classification: "sensitive", RequiresApproval: false},
+		{MCPServer: "shell", ToolName: "execute_command", Classification: "dangerous"},
+	}
+	local := []api.ToolClassification{
+		{MCPServer: "filesystem", ToolName: "read_file", Classification: "safe"},
+		{MCPServer: "filesystem", ToolName: "write_file", Classification: "sensitive", RequiresApproval: true, Description: "Writes files"},
+		{MCPServer: "github", ToolName: "create_issue", Classification: "sensitive"},
+	}
+
+	changes, err := diffClassifications(remote, local)
+	if err != nil {
+		t.Fatalf("diffClassifications: %v", err)
+	}
+	want := []struct {
+		key     string
+		action  classificationAction
+		changes []string
+	}{
+		{"filesystem.read_file", actionUnchanged, nil},
+		{"filesystem.write_file", actionUpdate, []string{"requires_approval: false -> true", `description: "" -> "Writes files"`}},
+		{"github.create_issue", actionCreate, nil},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("%d changes, want %d: a remote classification missing locally is left alone", len(changes), len(want))
+	}
+	for i, w := range want {
+		c := changes[i]
+		if classificationKey(c.Local) != w.key || c.Action != w.action || strings.Join(c.Changes, "; ") != strings.Join(w.changes, "; ") {
+			t.Errorf("change %d = %s %s %v, want %s %s %v", i, classificationKey(c.Local), c.Action, c.Changes, w.key, w.action, w.changes)
+		}
+	}
+
+	if _, err := diffClassifications(remote, append(local, local[0])); err == nil {
+		t.Error("a file classifying the same tool twice was accepted")
+	}
+}
+
+// classificationAPI serves the classification endpoints over remote and
+// counts bulk updates.
+func classificationAPI(t *testing.T, remote []api.ToolClassification) (*httptest.Server, *int) {
+	t.Helper()
+	applied := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/tool-classifications":
+			json.NewEncoder(w).Encode(map[string]interface{}{"classifications": remote})
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/tool-classifications/bulk":
+			applied++
+			io.Copy(io.Discard, r.Body)
+			w.Write([]byte(`{}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &applied
+}
+
+func TestToolsApplyJSONKeepsStdoutParseable(t *testing.T) {
+	srv, applied := classificationAPI(t, []api.ToolClassification{
+		{MCPServer: "filesystem", ToolName: "read_file", Classification: "safe"},
+	})
+	file := filepath.Join(t.TempDir(), "classifications.yaml")
+	yaml := "classifications:\n  - server: filesystem\n    tool: write_file\n    classification: sensitive\n    requires_approval: true\n"
+	if err := os.WriteFile(file, []byte(yaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, stderr, err := runCLI(t, "tools", "apply", "-f", file, "--base-url", srv.URL, "--output", "json")
+	if err != nil {
+		t.Fatalf("tools apply: %v", err)
+	}
+	var changes []classificationChange
+	if err := json.Unmarshal([]byte(stdout), &changes); err != nil {
+		t.Fatalf("stdout is not JSON: %v\n%s", err, stdout)
+	}
+	if len(changes) != 1 || changes[0].Action != actionCreate {
+		t.Errorf("changes = %+v, want one create", changes)
+	}
+	if !strings.Contains(stderr, "Applied 1 change(s)") {
+		t.Errorf("stderr = %q, want the summary", stderr)
+	}
+	if *applied != 1 {
+		t.Errorf("applied %d times, want 1", *applied)
+	}
+}
@@ -0,0 +1,214 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/akz4ol/gatewayops/cli/internal/api"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+const (
+	watchMinBackoff = 1 * time.Second
+	watchMaxBackoff = 30 * time.Second
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Tail live alerts and detections",
+	Long: `Stream alerts and safety detections from your MCP Gateway as they happen.
+The stream reconnects automatically if the connection drops.`,
+}
+
+var watchAlertsCmd = &cobra.Command{
+	Use:   "alerts",
+	Short: "Tail live alerts",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runWatch(cmd, "/v1/alerts/stream", formatAlert)
+	},
+}
+
+var watchDetectionsCmd = &cobra.Command{
+	Use:   "detections",
+	Short: "Tail live safety detections",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runWatch(cmd, "/v1/safety/detections/stream", formatDetection)
+	},
+}
+
+// formatAlert renders an alert event as one line.
+func formatAlert(data []byte) (string, error) {
+	var alert struct {
+		ID        string            `json:"id"`
+		Status    string            `json:"status"`
+		Severity  string            `json:"severity"`
+		Message   string            `json:"message"`
+		Value     float64           `json:"value"`
+		Threshold float64           `json:"threshold"`
+		Labels    map[string]string `json:"labels"`
+		StartedAt time.Time         `json:"started_at"`
+	}
+	if err := json.Unmarshal(data, &alert); err != nil {
+		return "", err
+	}
+
+	line := fmt.Sprintf("%s %s %-9s %s (value=%.2f threshold=%.2f)",
+		alert.StartedAt.Local().Format("15:04:05"),
+		colorSeverity(alert.Severity),
+		alert.Status,
+		alert.Message,
+		alert.Value,
+		alert.Threshold,
+	)
+	if server := alert.Labels["mcp_server"]; server != "" {
+		line += " server=" + server
+	}
+	return line, nil
+}
+
+// formatDetection renders a safety detection event as one line.
+func formatDetection(data []byte) (string, error) {
+	var detection struct {
+		ID             string    `json:"id"`
+		Type           string    `json:"type"`
+		Severity       string    `json:"severity"`
+		PatternMatched string    `json:"pattern_matched"`
+		ActionTaken    string    `json:"action_taken"`
+		MCPServer      string    `json:"mcp_server"`
+		ToolName       string    `json:"tool_name"`
+		CreatedAt      time.Time `json:"created_at"`
+	}
+	if err := json.Unmarshal(data, &detection); err != nil {
+		return "", err
+	}
+
+	target := detection.MCPServer
+	if detection.ToolName != "" {
+		target += "." + detection.ToolName
+	}
+	return fmt.Sprintf("%s %s %-8s %s %s pattern=%q",
+		detection.CreatedAt.Local().Format("15:04:05"),
+		colorSeverity(detection.Severity),
+		detection.ActionTaken,
+		detection.Type,
+		target,
+		detection.PatternMatched,
+	), nil
+}
+
+// runWatch streams events from path, printing each one, and reconnects with
+// exponential backoff until interrupted.
+func runWatch(cmd *cobra.Command, path string, format func([]byte) (string, error)) error {
+	client := api.NewClient(getBaseURL(), getAPIKey())
+
+	severity, _ := cmd.Flags().GetString("severity")
+	server, _ := cmd.Flags().GetString("server")
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+	if output == "json" {
+		jsonOutput = true
+	}
+
+	query := url.Values{}
+	if severity != "" {
+		query.Set("severity", severity)
+	}
+	if server != "" {
+		query.Set("mcp_server", server)
+	}
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	yellow := color.New(color.FgYellow).SprintFunc()
+	stdout, stderr := cmd.OutOrStdout(), cmd.ErrOrStderr()
+
+	backoff := watchMinBackoff
+	for {
+		connected := false
+		err := client.Stream(ctx, path, func(event api.Event) error {
+			connected = true
+			if jsonOutput {
+				fmt.Fprintln(stdout, string(event.Data))
+				return nil
+			}
+
+			line, err := format(event.Data)
+			if err != nil {
+				fmt.Fprintf(stderr, "%s failed to parse %s event: %v\n", yellow("warning:"), event.Name, err)
+				return nil
+			}
+			fmt.Fprintln(stdout, line)
+			return nil
+		})
+
+		if ctx.Err() != nil {
+			return nil
+		}
+		// Authentication and other client errors won't fix themselves
+		var statusErr *api.StatusError
+		if errors.As(err, &statusErr) && statusErr.StatusCode < 500 {
+			return err
+		}
+		if connected {
+			backoff = watchMinBackoff
+		}
+
+		if err != nil && !errors.Is(err, io.EOF) {
+			fmt.Fprintf(stderr, "%s %v, reconnecting in %s\n", yellow("warning:"), err, backoff)
+		} else {
+			fmt.Fprintf(stderr, "%s stream closed, reconnecting in %s\n", yellow("warning:"), backoff)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > watchMaxBackoff {
+			backoff = watchMaxBackoff
+		}
+	}
+}
+
+// colorSeverity renders a severity label colored by level.
+func colorSeverity(severity string) string {
+	label := fmt.Sprintf("%-8s", strings.ToUpper(severity))
+	switch severity {
+	case "critical":
+		return color.New(color.FgRed, color.Bold).Sprint(label)
+	case "high":
+		return color.New(color.FgRed).Sprint(label)
+	case "medium", "warning":
+		return color.New(color.FgYellow).Sprint(label)
+	case "low", "info":
+		return color.New(color.FgCyan).Sprint(label)
+	default:
+		return label
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+	watchCmd.AddCommand(watchAlertsCmd)
+	watchCmd.AddCommand(watchDetectionsCmd)
+
+	for _, c := range []*cobra.Command{watchAlertsCmd, watchDetectionsCmd} {
+		c.Flags().String("severity", "", "Only show events with these severities (comma-separated)")
+		c.Flags().StringP("server", "s", "", "Only show events for these MCP servers (comma-separated)")
+		c.Flags().Bool("json", false, "Print raw JSON events, one per line")
+	}
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+func TestFormatEvents(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = true
+	t.Cleanup(func() { color.NoColor = noColor })
+
+	at := time.Date(2026, 1, 1, 12, 30, 5, 0, time.UTC)
+	clock := at.Local().Format("15:04:05")
+
+	tests := []struct {
+		name   string
+		format func([]byte) (string, error)
+		data   string
+		want   string
+	}{
+		{
+			name:   "alert with server",
+			format: formatAlert,
+			data:   `{"status":"firing","severity":"critical","message":"Error rate high","value":0.12,"threshold":0.05,"labels":{"mcp_server":"shell"},"started_at":"2026-01-01T12:30:05Z"}`,
+			want:   clock + " CRITICAL firing    Error rate high (value=0.12 threshold=0.05) server=shell",
+		},
+		{
+			name:   "alert without server",
+			format: formatAlert,
+			data:   `{"status":"resolved","severity":"warning","message":"Latency","value":1,"threshold":2,"started_at":"2026-01-01T12:30:05Z"}`,
+			want:   clock + " WARNING  resolved  Latency (value=1.00 threshold=2.00)",
+		},
+		{
+			name:   "detection",
+			format: formatDetection,
+			data:   `{"type":"prompt_injection","severity":"high","pattern_matched":"ignore previous","action_taken":"blocked","mcp_server":"filesystem","tool_name":"read_file","created_at":"2026-01-01T12:30:05Z"}`,
+			want:   clock + ` HIGH     blocked  prompt_injection filesystem.read_file pattern="ignore previous"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.format([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("format: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("line =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := formatAlert([]byte("not json")); err == nil {
+		t.Error("a malformed event was formatted")
+	}
+}
+
+// sseSource serves one stream of events, then refuses to reconnect, which
+// ends the watch. It records the query of the first request.
+func sseSource(t *testing.T, events ...string) (*httptest.Server, *atomic.Value) {
+	t.Helper()
+	var requests atomic.Int32
+	var query atomic.Value
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) > 1 {
+			http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+		query.Store(r.URL.RawQuery)
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, ": keepalive\n\n")
+		for _, event := range events {
+			fmt.Fprint(w, event)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &query
+}
+
+func TestWatchAlerts(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = true
+	t.Cleanup(func() {
+		color.NoColor = noColor
+		for _, name := range []string{"severity", "server", "json"} {
+			watchAlertsCmd.Flags().Lookup(name).Value.Set(watchAlertsCmd.Flags().Lookup(name).DefValue)
+		}
+	})
+
+	alert := `{"status":"firing","severity":"critical","message":"Error rate high","value":0.12,"threshold":0.05,"started_at":"2026-01-01T12:30:05Z"}`
+	srv, query := sseSource(t,
+		"event: alert\ndata: "+alert+"\n\n",
+		"event: alert\ndata: not json\n\n",
+	)
+
+	stdout, stderr, err := runCLI(t, "watch", "alerts", "--severity", "critical", "--server", "shell", "--base-url", srv.URL)
+	if err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("err = %v, want the refused reconnection to end the watch", err)
+	}
+	if got := query.Load(); got != "mcp_server=shell&severity=critical" {
+		t.Errorf("stream query = %q, want the severity and server filters", got)
+	}
+	// Cobra follows the error with the usage
+	events, _, _ := strings.Cut(stdout, "Usage:")
+	if !strings.Contains(events, "CRITICAL firing    Error rate high") || strings.Count(events, "\n") != 1 {
+		t.Errorf("stdout = %q, want the one well-formed alert", events)
+	}
+	if !strings.Contains(stderr, "failed to parse alert event") || !strings.Contains(stderr, "stream closed, reconnecting in 1s") {
+		t.Errorf("stderr = %q, want the parse warning and the reconnection", stderr)
+	}
+}
+
+func TestWatchDetectionsJSON(t *testing.T) {
+	t.Cleanup(func() { watchDetectionsCmd.Flags().Set("json", "false") })
+
+	detection := `{"type":"prompt_injection","severity":"high"}`
+	srv, _ := sseSource(t, "event: detection\ndata: "+detection+"\n\n")
+
+	stdout, _, _ := runCLI(t, "watch", "detections", "--json", "--base-url", srv.URL)
+	if events, _, _ := strings.Cut(stdout, "Usage:"); events != detection+"\n" {
+		t.Errorf("stdout = %q, want the raw event", events)
+	}
+}
@@ -83,6 +83,10 @@ export class ApiClient {
     return this.get<{ traces: RecentTrace[] }>('/metrics/recent-traces');
   }
 
+  async getStats() {
+    return this.get<GatewayStats>('/stats');
+  }
+
   // Traces
   async listTraces(params?: { limit?: number; offset?: number; server?: string; status?: string }) {
     const queryParams: Record<string, string> = {};
@@ -233,10 +237,32 @@ export class ApiClient {
     return this.delete<void>(`/safety-policies/${policyId}`);
   }
 
+  // Pattern Libraries
+  async listPatternLibraries() {
+    return this.get<PatternLibrariesResponse>('/safety/patterns');
+  }
+
+  async getPatternLibrary(libraryId: string) {
+    return this.get<PatternLibrary>(`/safety/patterns/${libraryId}`);
+  }
+
+  async createPatternLibrary(data: PatternLibraryRequest) {
+    return this.post<PatternLibrary>('/safety/patterns', data);
+  }
+
+  async updatePatternLibrary(libraryId: string, data: PatternLibraryRequest) {
+    return this.put<PatternLibrary>(`/safety/patterns/${libraryId}`, data);
+  }
+
+  async deletePatternLibrary(libraryId: string) {
+    return this.delete<void>(`/safety/patterns/${libraryId}`);
+  }
+
   // Safety Detections
   async listDetections(params?: DetectionsFilterParams) {
     const queryParams: Record<string, string> = {};
     if (params?.mcp_server) queryParams.mcp_server = params.mcp_server;
+    if (params?.action) queryParams.action = params.action;
     if (params?.limit) queryParams.limit = String(params.limit);
     if (params?.offset) queryParams.offset = String(params.offset);
     return this.get<DetectionsResponse>('/safety/detections', queryParams);
@@ -246,6 +272,10 @@ export class ApiClient {
     return this.get<SafetySummary>('/safety/summary');
   }
 
+  async listSafetyOffenders() {
+    return this.get<SafetyOffendersResponse>('/safety/offenders');
+  }
+
   async testSafetyInput(data: SafetyTestRequest) {
     return this.post<SafetyTestResponse>('/safety/test', data);
   }
@@ -362,6 +392,31 @@ export interface OverviewMetrics {
   error_rate: { value: number; change: number; period: string; formatted: string };
 }
 
+export interface GatewayStats {
+  sso?: {
+    total_providers: number;
+    enabled_providers: number;
+    by_type: Record<string, number>;
+    active_sessions: number;
+    total_users: number;
+  };
+  safety?: SafetySummary;
+  approvals?: { pending_count: number };
+  alerts?: { active_count: number };
+  telemetry?: {
+    traces_exported: number;
+    metrics_exported: number;
+    logs_exported: number;
+    export_errors: number;
+    last_export_at: string;
+    bytes_sent: number;
+    avg_latency_ms: number;
+  };
+  errors?: Record<string, string>;
+  partial: boolean;
+  generated_at: string;
+}
+
 export interface ChartDataPoint {
   date: string;
   requests: number;
@@ -412,6 +467,16 @@ export interface Trace {
 export interface TraceDetail {
   trace: Trace;
   spans: TraceSpan[];
+  timeline: TraceEvent[];
+}
+
+export interface TraceEvent {
+  type: 'call' | 'detection' | 'approval';
+  time: string;
+  span_id?: string;
+  call?: Trace;
+  detection?: InjectionDetection;
+  approval?: Record<string, unknown>;
 }
 
 export interface TraceSpan {
@@ -495,6 +560,7 @@ export interface ApiKey {
   environment: string;
   permissions: string[];
   rate_limit: number;
+  allowed_cidrs?: string[];
   last_used_at?: string;
   expires_at?: string;
   created_at: string;
@@ -508,6 +574,7 @@ export interface CreateApiKeyRequest {
   environment?: string;
   permissions?: string[];
   rate_limit?: number;
+  allowed_cidrs?: string[];
   team_id?: string;
   expires_at?: string;
 }
@@ -537,16 +604,24 @@ export interface ApiKeyDetail {
 // Alert Types
 export type AlertSeverity = 'info' | 'warning' | 'critical';
 export type AlertStatus = 'firing' | 'resolved' | 'acknowledged';
-export type AlertMetric = 'error_rate' | 'latency_p50' | 'latency_p95' | 'latency_p99' | 'request_rate' | 'cost_per_hour' | 'cost_per_day' | 'rate_limit_hit' | 'injection_detected';
+export type AlertMetric = 'error_rate' | 'latency_p50' | 'latency_p90' | 'latency_p95' | 'latency_p99' | 'request_rate' | 'cost_per_hour' | 'cost_per_day' | 'rate_limit_hit' | 'injection_detected';
 export type AlertCondition = 'gt' | 'lt' | 'gte' | 'lte' | 'eq' | 'neq';
 export type AlertChannelType = 'slack' | 'pagerduty' | 'opsgenie' | 'webhook' | 'email' | 'teams';
 
 export interface AlertFilters {
   mcp_servers?: string[];
+  tools?: string[];
   teams?: string[];
   environments?: string[];
 }
 
+export interface AlertTemplates {
+  default?: string;
+  slack?: string;
+  pagerduty?: string;
+  webhook?: string;
+}
+
 export interface AlertRule {
   id: string;
   org_id: string;
@@ -559,10 +634,12 @@ export interface AlertRule {
   severity: AlertSeverity;
   channels: string[];
   filters?: AlertFilters;
+  templates?: AlertTemplates;
   enabled: boolean;
   created_at: string;
   updated_at: string;
   created_by: string;
+  deleted_at?: string;
 }
 
 export interface AlertRulesResponse {
@@ -580,6 +657,7 @@ export interface CreateAlertRuleRequest {
   severity?: AlertSeverity;
   channels?: string[];
   filters?: AlertFilters;
+  templates?: AlertTemplates;
   enabled?: boolean;
 }
 
@@ -593,6 +671,7 @@ export interface UpdateAlertRuleRequest {
   severity?: AlertSeverity;
   channels?: string[];
   filters?: AlertFilters;
+  templates?: AlertTemplates;
   enabled?: boolean;
 }
 
@@ -666,7 +745,7 @@ export interface AlertsFilterParams {
 }
 
 // Safety Types
-export type SafetyMode = 'block' | 'warn' | 'log';
+export type SafetyMode = 'block' | 'warn' | 'log' | 'shadow';
 export type SafetySensitivity = 'strict' | 'moderate' | 'permissive';
 export type DetectionSeverity = 'low' | 'medium' | 'high' | 'critical';
 export type DetectionType = 'prompt_injection' | 'pii' | 'secret' | 'malicious';
@@ -674,6 +753,33 @@ export type DetectionType = 'prompt_injection' | 'pii' | 'secret' | 'malicious';
 export interface SafetyPatterns {
   block?: string[];
   allow?: string[];
+  libraries?: string[];
+}
+
+export type PatternCategory = 'injection' | 'jailbreak' | 'pii' | 'secrets';
+
+export interface PatternLibrary {
+  id: string;
+  org_id: string;
+  name: string;
+  description?: string;
+  category: PatternCategory;
+  patterns: SafetyPatterns;
+  version: number;
+  created_at: string;
+  updated_at: string;
+  created_by: string;
+}
+
+export interface PatternLibrariesResponse {
+  libraries: PatternLibrary[];
+}
+
+export interface PatternLibraryRequest {
+  name: string;
+  description?: string;
+  category: PatternCategory;
+  patterns: SafetyPatterns;
 }
 
 export interface SafetyPolicy {
@@ -689,6 +795,7 @@ export interface SafetyPolicy {
   created_at: string;
   updated_at: string;
   created_by: string;
+  deleted_at?: string;
 }
 
 export interface SafetyPoliciesResponse {
@@ -743,6 +850,7 @@ export interface DetectionsResponse {
 
 export interface DetectionsFilterParams {
   mcp_server?: string;
+  action?: SafetyMode;
   limit?: number;
   offset?: number;
 }
@@ -772,6 +880,17 @@ export interface PatternCount {
   count: number;
 }
 
+export interface SafetyOffender {
+  principal: string;
+  detections: number;
+  escalated: boolean;
+  last_seen: string;
+}
+
+export interface SafetyOffendersResponse {
+  offenders: SafetyOffender[];
+}
+
 export interface SafetySummary {
   total_detections: number;
   by_type: Record<string, number>;
@@ -844,12 +963,15 @@ export interface RateLimitConfig {
   sandbox_rpm: number;
 }
 
+export type UnknownToolPolicy = 'default' | 'deny' | 'approval' | 'allow';
+
 export interface OrgSettings {
   id: string;
   org_id: string;
   org_name: string;
   billing_email: string;
   rate_limits: RateLimitConfig;
+  unknown_tool_policy: UnknownToolPolicy;
   updated_at: string;
 }
 
@@ -857,6 +979,7 @@ export interface UpdateSettingsRequest {
   org_name?: string;
   billing_email?: string;
   rate_limits?: Partial<RateLimitConfig>;
+  unknown_tool_policy?: UnknownToolPolicy;
 }
 
 // SSO Provider Types
@@ -17,6 +17,13 @@ const AuthContext = createContext<AuthContextType | undefined>(undefined);
 
 const PUBLIC_PATHS = ['/login', '/auth/callback'];
 
+// The gateway takes SSO session tokens in X-Session-Token; Authorization is
+// for API keys. Demo logins have no gateway session, and the gateway serves
+// requests without one as the demo org in demo mode.
+function sessionHeaders(token: string): Record<string, string> {
+  return token.startsWith('demo_') ? {} : { 'X-Session-Token': token };
+}
+
 export function AuthProvider({ children }: { children: React.ReactNode }) {
   const [state, setState] = useState<AuthState>(initialState);
   const router = useRouter();
@@ -51,7 +58,7 @@ export function AuthProvider({ children }: { children: React.ReactNode }) {
       // Validate session with backend
       const response = await fetch(`${API_BASE}/v1/rbac/me`, {
         headers: {
-          'Authorization': `Bearer ${token}`,
+          ...sessionHeaders(token),
           'Accept': 'application/json',
         },
       });
@@ -135,9 +142,7 @@ export function AuthProvider({ children }: { children: React.ReactNode }) {
         // Call logout endpoint
         await fetch(`${API_BASE}/v1/sso/logout`, {
           method: 'POST',
-          headers: {
-            'Authorization': `Bearer ${token}`,
-          },
+          headers: sessionHeaders(token),
         }).catch(() => {}); // Ignore errors
       }
     } finally {
@@ -4,7 +4,9 @@ package main
 import (
 	"context"
 	_ "embed"
+	"fmt"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/akz4ol/gatewayops/gateway/internal/agent"
@@ -12,16 +14,34 @@ import (
 	"github.com/akz4ol/gatewayops/gateway/internal/approval"
 	"github.com/akz4ol/gatewayops/gateway/internal/audit"
 	"github.com/akz4ol/gatewayops/gateway/internal/auth"
+	"github.com/akz4ol/gatewayops/gateway/internal/budget"
+	"github.com/akz4ol/gatewayops/gateway/internal/cleanup"
+	"github.com/akz4ol/gatewayops/gateway/internal/clock"
+	"github.com/akz4ol/gatewayops/gateway/internal/compliance"
 	"github.com/akz4ol/gatewayops/gateway/internal/config"
 	"github.com/akz4ol/gatewayops/gateway/internal/database"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/egress"
+	"github.com/akz4ol/gatewayops/gateway/internal/email"
 	"github.com/akz4ol/gatewayops/gateway/internal/handler"
+	"github.com/akz4ol/gatewayops/gateway/internal/idempotency"
+	"github.com/akz4ol/gatewayops/gateway/internal/logging"
+	"github.com/akz4ol/gatewayops/gateway/internal/loopguard"
+	"github.com/akz4ol/gatewayops/gateway/internal/mcpserver"
+	"github.com/akz4ol/gatewayops/gateway/internal/metrics"
+	"github.com/akz4ol/gatewayops/gateway/internal/middleware"
+	"github.com/akz4ol/gatewayops/gateway/internal/orgsettings"
 	"github.com/akz4ol/gatewayops/gateway/internal/otel"
+	"github.com/akz4ol/gatewayops/gateway/internal/pricing"
 	"github.com/akz4ol/gatewayops/gateway/internal/ratelimit"
 	"github.com/akz4ol/gatewayops/gateway/internal/rbac"
+	"github.com/akz4ol/gatewayops/gateway/internal/redact"
 	"github.com/akz4ol/gatewayops/gateway/internal/repository"
 	"github.com/akz4ol/gatewayops/gateway/internal/router"
 	"github.com/akz4ol/gatewayops/gateway/internal/safety"
+	"github.com/akz4ol/gatewayops/gateway/internal/secretbox"
 	"github.com/akz4ol/gatewayops/gateway/internal/server"
+	"github.com/akz4ol/gatewayops/gateway/internal/siem"
 	"github.com/akz4ol/gatewayops/gateway/internal/sso"
 	"github.com/rs/zerolog"
 )
@@ -33,7 +53,12 @@ func main() {
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
-		panic("Failed to load config: " + err.Error())
+		fmt.Fprintln(os.Stderr, "Failed to load config:", err)
+		os.Exit(1)
+	}
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
 
 	// Setup logger
@@ -44,19 +69,23 @@ func main() {
 		Str("port", cfg.Server.Port).
 		Msg("Starting GatewayOps Gateway")
 
-	// Connect to PostgreSQL
-	postgres, err := database.NewPostgres(cfg.Database, logger)
-	if err != nil {
-		logger.Fatal().Err(err).Msg("Failed to connect to PostgreSQL")
-	}
-	defer postgres.Close()
+	// Connect to PostgreSQL and Redis, unless state is kept in memory
+	postgres, redis := &database.Postgres{}, &database.Redis{}
+	if cfg.InMemory() {
+		logger.Warn().Msg("Storage is in memory; state is lost on restart and not shared between instances")
+	} else {
+		postgres, err = database.NewPostgres(cfg.Database, logger)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Failed to connect to PostgreSQL")
+		}
+		defer postgres.Close()
 
-	// Connect to Redis
-	redis, err := database.NewRedis(cfg.Redis, logger)
-	if err != nil {
-		logger.Fatal().Err(err).Msg("Failed to connect to Redis")
+		redis, err = database.NewRedis(cfg.Redis, logger)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Failed to connect to Redis")
+		}
+		defer redis.Close()
 	}
-	defer redis.Close()
 
 	// Run database migrations
 	if postgres.DB != nil {
@@ -67,76 +96,276 @@ func main() {
 		}
 	}
 
-	// Initialize repositories
+	// Initialize repositories. Without a database, the services that would
+	// persist through the alert, safety, tool and settings repositories keep
+	// their state in memory instead.
 	traceRepo := repository.NewTraceRepository(postgres.DB)
 	costRepo := repository.NewCostRepository(postgres.DB)
-	alertRepo := repository.NewAlertRepository(postgres.DB)
-	safetyRepo := repository.NewSafetyRepository(postgres.DB)
-	toolRepo := repository.NewToolRepository(postgres.DB)
+	// The stores stay nil interfaces rather than holding nil repositories,
+	// which the services would take for a database.
+	var alertStore repository.AlertStore
+	var safetyStore repository.SafetyStore
+	var toolStore repository.ToolStore
+	var settingsStore repository.SettingsStore
+	if postgres.DB != nil {
+		alertRepo := repository.NewAlertRepository(postgres.DB)
+		safetyRepo := repository.NewSafetyRepository(postgres.DB)
+		toolRepo := repository.NewToolRepository(postgres.DB)
+		defer alertRepo.Close()
+		defer safetyRepo.Close()
+		defer toolRepo.Close()
+		alertStore, safetyStore, toolStore = alertRepo, safetyRepo, toolRepo
+		settingsStore = repository.NewSettingsRepository(postgres.DB)
+	}
 	apiKeyRepo := repository.NewAPIKeyRepository(postgres.DB)
 
-	// Initialize auth store
-	authStore := auth.NewStore(postgres.DB, logger)
-
 	// Initialize rate limiter
-	rateLimiter := ratelimit.NewLimiter(redis, logger)
+	rateLimiter := ratelimit.NewLimiter(redis, logger, clock.Real)
 
-	// Initialize injection detector (with repository for persistence)
-	injectionDetector := safety.NewDetector(logger, safetyRepo)
-
-	// Initialize audit logger
-	auditLogger := audit.NewLogger(logger)
+	// Initialize audit logger. With a database the hash chain is persisted, so
+	// it carries across restarts and is shared by every replica.
+	var auditStore repository.AuditStore
+	if postgres.DB != nil {
+		auditStore = repository.NewAuditRepository(postgres.DB)
+	}
+	auditLogger := audit.NewLogger(logger, auditStore)
 
 	// Initialize alerting service (with repository for persistence)
-	alertService := alerting.NewService(logger, alertRepo)
+	metricRepo := repository.NewMetricRepository(postgres.DB)
+	alertService := alerting.NewService(logger, alertStore, metricRepo, cfg.Buffers.Alerts, clock.Real)
+
+	// Sensitive tool arguments are redacted before approvals and detections are stored
+	redactor, err := redact.New(cfg.Redaction)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Invalid argument redaction rules")
+	}
+
+	// Initialize injection detector (with repository for persistence);
+	// repeat offenders raise alerts
+	escalation := safety.EscalationPolicy{
+		Threshold: cfg.Safety.EscalationThreshold,
+		Window:    cfg.Safety.EscalationWindow,
+	}
+	injectionDetector := safety.NewDetector(logger, safetyStore, cfg.Buffers.Detections, escalation, alertService, redactor)
+
+	// Forward detections to the SIEM when a collector is configured
+	var siemForwarder *siem.Forwarder
+	if cfg.SIEM.Address != "" {
+		siemForwarder = siem.NewForwarder(cfg.SIEM, logger)
+		siemForwarder.Start(injectionDetector.Subscribe())
+	}
+
+	// Initialize loop detection for agents stuck repeating a tool call
+	toolLoops := loopguard.New(loopguard.Policy{
+		Threshold: cfg.Safety.LoopThreshold,
+		Window:    cfg.Safety.LoopWindow,
+		Block:     cfg.Safety.LoopAction == "block",
+	}, logger, alertService)
+
+	// Initialize per-organization settings, read by the services below
+	orgSettings := orgsettings.NewService(settingsStore, logger, clock.Real)
+
+	// Initialize budget service (soft-threshold warnings use alert channels)
+	budgetService := budget.NewService(logger, alertService, orgSettings)
 
 	// Initialize OpenTelemetry exporter
 	otelExporter := otel.NewExporter(logger)
 
-	// Initialize tool approval service (with repository for persistence)
-	approvalService := approval.NewService(logger, toolRepo)
+	// Email approval reviewers when SMTP is configured
+	var reviewerNotifier approval.ReviewerNotifier
+	if cfg.SMTP.Host != "" && len(cfg.Approvals.ReviewerEmails) > 0 {
+		reviewerNotifier = email.NewApprovalNotifier(logger, email.NewSender(), email.Config{
+			Host:     cfg.SMTP.Host,
+			Port:     cfg.SMTP.Port,
+			Username: cfg.SMTP.Username,
+			Password: cfg.SMTP.Password,
+			From:     cfg.SMTP.From,
+			To:       cfg.Approvals.ReviewerEmails,
+			ReplyTo:  cfg.SMTP.ReplyTo,
+			TLS:      email.TLSMode(cfg.SMTP.TLS),
+		})
+	}
+
+	// Stored credentials are encrypted with SECRETS_ENCRYPTION_KEY, validated with the configuration;
+	// retired keys still decrypt what they encrypted until it is re-encrypted
+	var secrets *secretbox.Keyring
+	if cfg.Auth.SecretsKey != "" {
+		if secrets, err = secretsKeyring(cfg.Auth); err != nil {
+			logger.Fatal().Err(err).Msg("Invalid SECRETS_ENCRYPTION_KEY")
+		}
+	} else {
+		logger.Warn().Msg("SECRETS_ENCRYPTION_KEY is not set; MCP servers cannot be registered with credentials and SSO client secrets are stored unencrypted")
+	}
+
+	// Outbound requests to MCP servers only reach destinations the egress policy allows
+	egressPolicy, err := egress.New(cfg.Egress.Allow, cfg.Egress.Deny)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Invalid MCP egress policy")
+	}
+
+	// Initialize MCP server registry (configured servers plus those registered at runtime)
+	mcpServers := mcpserver.NewRegistry(cfg, repository.NewMCPServerRepository(postgres.DB), secrets, egressPolicy, logger)
+
+	// Initialize tool approval service (with repository for persistence);
+	// requests matching an auto-approval rule skip review
+	autoApprover, err := approval.NewAutoApprover(cfg.Approvals.AutoRules)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Invalid auto-approval rules")
+	}
+	approvalService := approval.NewService(logger, toolStore, reviewerNotifier, mcpServers, redactor, autoApprover, cfg.Buffers.Approvals, clock.Real)
 
 	// Initialize RBAC service
 	rbacService := rbac.NewService(logger)
 
+	// Client secrets of SSO providers are sealed under a key per organization,
+	// wrapped by SECRETS_ENCRYPTION_KEY. The keys are kept in memory, like
+	// the providers themselves.
+	var orgSecrets *secretbox.Envelope
+	if secrets != nil {
+		orgSecrets = secretbox.NewEnvelope(secrets, secretbox.NewMemoryKeyStore())
+	}
+
 	// Initialize SSO service
-	ssoService := sso.NewService(logger)
+	ssoService := sso.NewService(logger, []byte(cfg.Auth.SessionSigningKey), clock.Real, redis, orgSecrets, cfg.Server.DemoMode)
+
+	// Initialize auth store, resolving API keys and SSO sessions to the org,
+	// user and permissions requests act with
+	authStore := auth.NewStore(apiKeyRepo, apiKeyRepo, ssoService, rbacService, cfg.Server.DemoMode, logger)
+
+	// Initialize load shedding
+	concurrencyLimiter := middleware.NewConcurrencyLimiter(cfg.Server.MaxConcurrency, cfg.Server.RouteConcurrency, cfg.Server.ShedRetryAfter)
+
+	// Initialize Prometheus metrics
+	metricsRegistry := metrics.NewRegistry(metrics.Sources{
+		Telemetry:   otelExporter,
+		Safety:      injectionDetector,
+		Alerts:      alertService,
+		Approvals:   approvalService,
+		Concurrency: concurrencyLimiter,
+	})
 
 	// Initialize handlers
-	healthHandler := handler.NewHealthHandler(postgres, redis, rateLimiter)
-	mcpHandler := handler.NewMCPHandler(cfg, logger, traceRepo)
-	traceHandler := handler.NewTraceHandler(logger, traceRepo, cfg.Server.DemoMode)
+	healthCheckers := []handler.HealthChecker{rateLimiter, mcpServers}
+	if !cfg.InMemory() {
+		healthCheckers = append([]handler.HealthChecker{postgres, redis}, healthCheckers...)
+	}
+	healthHandler := handler.NewHealthHandler(healthCheckers...)
+	costEstimator := pricing.NewCostEstimator(cfg)
+	toolCallSimulator := handler.NewToolCallSimulator(approvalService, injectionDetector, costEstimator, orgSettings)
+	mcpHandler := handler.NewMCPHandler(mcpServers, logger, traceRepo, costRepo, toolCallSimulator, budgetService, costEstimator, alertService, metricsRegistry, toolLoops, egressPolicy, auditLogger)
+	traceHandler := handler.NewTraceHandler(logger, traceRepo, injectionDetector, approvalService, cfg.Server.DemoMode)
 	costHandler := handler.NewCostHandler(logger, costRepo, cfg.Server.DemoMode)
 	apiKeyHandler := handler.NewAPIKeyHandler(logger, apiKeyRepo, cfg.Server.DemoMode)
-	metricsHandler := handler.NewMetricsHandler(logger)
+	metricsHandler := handler.NewMetricsHandler(logger, alertService)
 	docsHandler := handler.NewDocsHandler(logger, openAPISpec)
-	safetyHandler := handler.NewSafetyHandler(logger, injectionDetector)
+	safetyHandler := handler.NewSafetyHandler(logger, injectionDetector, auditLogger)
 	auditHandler := handler.NewAuditHandler(logger, auditLogger)
-	alertHandler := handler.NewAlertHandler(logger, alertService)
+	alertHandler := handler.NewAlertHandler(logger, alertService, auditLogger)
+	budgetHandler := handler.NewBudgetHandler(logger, budgetService)
 	telemetryHandler := handler.NewTelemetryHandler(logger, otelExporter)
-	approvalHandler := handler.NewApprovalHandler(logger, approvalService)
-	rbacHandler := handler.NewRBACHandler(logger, rbacService)
-	ssoHandler := handler.NewSSOHandler(logger, ssoService, "https://gatewayops-api.fly.dev")
+	stepUp := handler.StepUpPolicy{
+		RiskLevel: domain.ToolRiskLevel(cfg.Approvals.StepUpRiskLevel),
+		MaxAge:    cfg.Approvals.StepUpMaxAge,
+		ACRValues: cfg.Approvals.StepUpACRValues,
+	}
+	if cfg.Approvals.StepUpRiskLevel == "none" {
+		stepUp.RiskLevel = ""
+	}
+	approvalHandler := handler.NewApprovalHandler(logger, approvalService, auditLogger, mcpHandler, ssoService, stepUp, costEstimator)
+	rbacHandler := handler.NewRBACHandler(logger, rbacService, auditLogger)
+	loginLockout := ratelimit.NewLockout(redis, logger, clock.Real, cfg.Auth.LockoutThreshold, cfg.Auth.LockoutWindow, cfg.Auth.LockoutDuration)
+	geoTable := make(map[string]sso.Location, len(cfg.Auth.GeoLocations))
+	for cidr, point := range cfg.Auth.GeoLocations {
+		geoTable[cidr] = sso.Location{Latitude: point.Latitude, Longitude: point.Longitude}
+	}
+	geoResolver, err := sso.NewCIDRGeoResolver(geoTable)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Invalid geo locations")
+	}
+	loginAnomalies := sso.NewAnomalyDetector(sso.AnomalyPolicy{
+		MaxTravelSpeed:  cfg.Auth.AnomalyTravelSpeed,
+		NewDevice:       cfg.Auth.AnomalyNewDevice,
+		MaxSessions:     cfg.Auth.AnomalyMaxSessions,
+		StepUp:          cfg.Auth.AnomalyStepUp,
+		StepUpACRValues: cfg.Approvals.StepUpACRValues,
+		History:         cfg.Auth.AnomalyHistory,
+	}, geoResolver, logger, alertService, clock.Real)
+	ssoHandler := handler.NewSSOHandler(logger, ssoService, "https://gatewayops-api.fly.dev", auditLogger, loginLockout, loginAnomalies)
 
 	// Initialize user handler
 	userRepo := repository.NewUserRepository(postgres.DB)
+	defer userRepo.Close()
 	userHandler := handler.NewUserHandler(logger, userRepo, rbacService)
+	complianceGenerator := compliance.NewGenerator(approvalService, rbacService, injectionDetector, userRepo, clock.Real)
+	complianceHandler := handler.NewComplianceHandler(logger, complianceGenerator, cfg.Auth.ReportSigningKey)
+
+	// Periodically clean up expired approvals, permissions, sessions and
+	// SSO login states
+	cleanupScheduler := cleanup.NewScheduler(logger,
+		cleanup.Task{
+			Name:     "approvals",
+			Interval: cfg.Cleanup.ApprovalInterval,
+			Run:      approvalService.ExpireApprovals,
+		},
+		cleanup.Task{
+			Name:     "permissions",
+			Interval: cfg.Cleanup.PermissionInterval,
+			Run: func(context.Context) (int64, error) {
+				return approvalService.PurgeExpiredPermissions(), nil
+			},
+		},
+		cleanup.Task{
+			Name:     "sessions",
+			Interval: cfg.Cleanup.SessionInterval,
+			Run:      userRepo.DeleteExpiredSessions,
+		},
+		cleanup.Task{
+			Name:     "auth_states",
+			Interval: cfg.Cleanup.AuthStateInterval,
+			Run: func(context.Context) (int64, error) {
+				return ssoService.PurgeExpiredStates(), nil
+			},
+		},
+	)
+	cleanupScheduler.Start()
 
 	// Initialize settings handler
-	settingsHandler := handler.NewSettingsHandler(logger)
+	settingsHandler := handler.NewSettingsHandler(logger, approvalService, orgSettings, auditLogger)
+
+	// Initialize config reloader and handler
+	configReloader := config.NewReloader(cfg, config.Load, logger)
+	configReloader.OnReload(func(c *config.Config) {
+		zerolog.SetGlobalLevel(parseLogLevel(c.LogLevel()))
+	})
+	configHandler := handler.NewConfigHandler(logger, configReloader)
+	secretStores := map[string]handler.SecretStore{"mcp_servers": mcpServers}
+	if orgSecrets != nil {
+		secretStores["sso_data_keys"] = orgSecrets
+	}
+	secretsHandler := handler.NewSecretsHandler(logger, secrets, secretStores, auditLogger)
+	mcpServerHandler := handler.NewMCPServerHandler(logger, mcpServers, auditLogger)
 
 	// Initialize agent manager and handler
-	agentManager := agent.NewManager(logger)
-	agentHandler := handler.NewAgentHandler(logger, agentManager, "gatewayops-api.fly.dev")
+	statsHandler := handler.NewStatsHandler(logger, ssoService, injectionDetector, approvalService, alertService, otelExporter, concurrencyLimiter)
+	quotaHandler := handler.NewQuotaHandler(logger, rateLimiter, cfg.DefaultRateLimit, budgetService, approvalService)
+	agentManager := agent.NewManager(logger, mcpHandler, cfg.Agents.ResourcePollInterval, cfg.Agents.ResumeGracePeriod, agent.CostPolicy{
+		ConnectionCap: cfg.Agents.ConnectionCostCap,
+		ToolCaps:      cfg.Agents.ToolCostCaps,
+	})
+	agentHandler := handler.NewAgentHandler(logger, agentManager, toolCallSimulator, mcpHandler, mcpHandler, mcpServers, budgetService, cfg.DefaultRateLimit, "gatewayops-api.fly.dev", cfg.Agents)
+	openAIHandler := handler.NewOpenAIHandler(logger, toolCallSimulator, mcpHandler, rateLimiter, cfg.DefaultRateLimit)
 
 	// Create router with dependencies
 	deps := router.Dependencies{
 		Config:            cfg,
 		Logger:            logger,
 		AuthStore:         authStore,
-		RateLimiter:       rateLimiter,
+		RateLimiter:       metricsRegistry.InstrumentRateLimiter(rateLimiter),
 		InjectionDetector: injectionDetector,
 		AuditLogger:       auditLogger,
+		IdempotencyStore:  idempotency.NewStore(redis, logger, cfg.Server.IdempotencyTTL),
+		Concurrency:       concurrencyLimiter,
+		Metrics:           metricsRegistry,
 		MCPHandler:        mcpHandler,
 		HealthHandler:     healthHandler,
 		TraceHandler:      traceHandler,
@@ -146,6 +375,7 @@ func main() {
 		DocsHandler:       docsHandler,
 		SafetyHandler:     safetyHandler,
 		AuditHandler:      auditHandler,
+		ComplianceHandler: complianceHandler,
 		AlertHandler:      alertHandler,
 		TelemetryHandler:  telemetryHandler,
 		ApprovalHandler:   approvalHandler,
@@ -153,17 +383,33 @@ func main() {
 		SSOHandler:        ssoHandler,
 		UserHandler:       userHandler,
 		SettingsHandler:   settingsHandler,
+		ConfigHandler:     configHandler,
+		SecretsHandler:    secretsHandler,
+		MCPServerHandler:  mcpServerHandler,
 		AgentHandler:      agentHandler,
+		OpenAIHandler:     openAIHandler,
+		BudgetHandler:     budgetHandler,
+		StatsHandler:      statsHandler,
+		QuotaHandler:      quotaHandler,
 	}
 
 	r := router.New(deps)
 
 	// Create and start server
 	srv := server.New(cfg, r, logger)
+	srv.OnReload(func() { configReloader.Reload() })
+	srv.OnShutdown("agent_connections", agentManager.Shutdown)
+	srv.OnShutdown("mcp_upstreams", mcpHandler.Shutdown)
+	srv.OnShutdown("otel_exporter", otelExporter.Shutdown)
+	srv.OnShutdown("alert_evaluator", alertService.Shutdown)
+	srv.OnShutdown("cleanup_scheduler", cleanupScheduler.Shutdown)
+	if siemForwarder != nil {
+		srv.OnShutdown("siem_forwarder", siemForwarder.Shutdown)
+	}
 
 	logger.Info().
 		Str("addr", srv.Addr()).
-		Int("mcp_servers", len(cfg.MCPServers)).
+		Int("mcp_servers", cfg.MCPServerCount()).
 		Msg("Gateway ready to accept connections")
 
 	if err := srv.Start(); err != nil {
@@ -174,28 +420,60 @@ func main() {
 }
 
 // setupLogger configures zerolog based on environment.
-func setupLogger(cfg *config.Config) zerolog.Logger {
-	// Set log level
-	level, err := zerolog.ParseLevel(cfg.Logging.Level)
+// secretsKeyring builds the keyring of the current secrets encryption key and
+// the retired ones.
+func secretsKeyring(auth config.AuthConfig) (*secretbox.Keyring, error) {
+	keys := make(map[uint32]*secretbox.Box, len(auth.RetiredSecretsKeys)+1)
+	current, err := secretbox.NewFromHex(auth.SecretsKey)
 	if err != nil {
-		level = zerolog.InfoLevel
+		return nil, err
+	}
+	keys[uint32(auth.SecretsKeyVersion)] = current
+	for version, key := range auth.RetiredSecretsKeys {
+		n, err := strconv.ParseUint(version, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("retired key version %q: %w", version, err)
+		}
+		if keys[uint32(n)], err = secretbox.NewFromHex(key); err != nil {
+			return nil, fmt.Errorf("retired key %q: %w", version, err)
+		}
 	}
-	zerolog.SetGlobalLevel(level)
+	return secretbox.NewKeyring(uint32(auth.SecretsKeyVersion), keys)
+}
 
-	// Configure output format
+func setupLogger(cfg *config.Config) zerolog.Logger {
+	// Set log level
+	zerolog.SetGlobalLevel(parseLogLevel(cfg.Logging.Level))
+
+	// Configure output format. Lines are redacted and sampled before
+	// formatting.
+	opts := logging.Options{
+		RedactKeys:   cfg.Logging.RedactKeys,
+		SampleBurst:  cfg.Logging.SampleBurst,
+		SamplePeriod: cfg.Logging.SamplePeriod,
+	}
 	var logger zerolog.Logger
 	if cfg.Logging.Format == "console" || cfg.IsDevelopment() {
-		logger = zerolog.New(zerolog.ConsoleWriter{
+		logger = zerolog.New(logging.NewWriter(zerolog.ConsoleWriter{
 			Out:        os.Stdout,
 			TimeFormat: time.RFC3339,
-		}).With().Timestamp().Caller().Logger()
+		}, opts)).With().Timestamp().Caller().Logger()
 	} else {
-		logger = zerolog.New(os.Stdout).With().Timestamp().Logger()
+		logger = zerolog.New(logging.NewWriter(os.Stdout, opts)).With().Timestamp().Logger()
 	}
 
 	return logger
 }
 
+// parseLogLevel parses a log level, defaulting to info.
+func parseLogLevel(value string) zerolog.Level {
+	level, err := zerolog.ParseLevel(value)
+	if err != nil {
+		return zerolog.InfoLevel
+	}
+	return level
+}
+
 // getMigrations returns the database migrations as a map.
 func getMigrations() map[string]string {
 	return map[string]string{
@@ -559,6 +837,179 @@ INSERT INTO users (id, org_id, email, name, role) VALUES
     ('00000000-0000-0000-0000-000000000001', '00000000-0000-0000-0000-000000000001', 'sarah@acme.com', 'Sarah Chen', 'admin'),
     ('00000000-0000-0000-0000-000000000002', '00000000-0000-0000-0000-000000000001', 'demo@acme.com', 'Demo User', 'developer')
 ON CONFLICT DO NOTHING;
+`,
+		"004_add_cost_events.sql": `
+-- Migration 004: Per-call cost events for attribution reports
+CREATE TABLE IF NOT EXISTS cost_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    team_id UUID REFERENCES teams(id) ON DELETE SET NULL,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    api_key_id UUID REFERENCES api_keys(id) ON DELETE SET NULL,
+    trace_id VARCHAR(64) NOT NULL,
+    mcp_server VARCHAR(100) NOT NULL,
+    tool_name VARCHAR(255),
+    operation VARCHAR(100) NOT NULL,
+    cost DECIMAL(12, 6) NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_cost_events_org_created ON cost_events(org_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_cost_events_team ON cost_events(team_id);
+CREATE INDEX IF NOT EXISTS idx_cost_events_server_tool ON cost_events(mcp_server, tool_name);
+`,
+		"005_add_search_indexes.sql": `
+-- Migration 005: Trigram indexes backing ?q= search on detections and approvals
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_injection_detections_input_trgm ON injection_detections USING GIN (input gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_injection_detections_pattern_trgm ON injection_detections USING GIN (pattern_matched gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_injection_detections_tool_trgm ON injection_detections USING GIN (tool_name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_injection_detections_server_trgm ON injection_detections USING GIN (mcp_server gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_tool_approvals_reason_trgm ON tool_approvals USING GIN (reason gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_tool_approvals_tool_trgm ON tool_approvals USING GIN (tool_name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_tool_approvals_server_trgm ON tool_approvals USING GIN (mcp_server gin_trgm_ops);
+`,
+		"006_add_audit_hash_chain.sql": `
+-- Migration 006: Per-org hash chain over audit logs for tamper evidence,
+-- following their insertion order
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS prev_hash VARCHAR(64);
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS hash VARCHAR(64);
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS seq BIGSERIAL;
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_org_seq ON audit_logs(org_id, seq);
+`,
+		"007_add_call_samples.sql": `
+-- Migration 007: Per-call samples backing alert rule evaluation
+CREATE TABLE IF NOT EXISTS call_samples (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    team_id UUID REFERENCES teams(id) ON DELETE SET NULL,
+    mcp_server VARCHAR(100) NOT NULL,
+    tool_name VARCHAR(255),
+    is_error BOOLEAN NOT NULL DEFAULT FALSE,
+    duration_ms BIGINT NOT NULL DEFAULT 0,
+    cost DECIMAL(12, 6) NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_call_samples_created ON call_samples(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_call_samples_org_created ON call_samples(org_id, created_at DESC);
+`,
+		"008_add_call_sample_environment.sql": `
+-- Migration 008: Environment dimension for alert rule filters
+ALTER TABLE call_samples ADD COLUMN IF NOT EXISTS environment VARCHAR(50);
+`,
+		"009_add_alert_rule_templates.sql": `
+-- Migration 009: Per-rule alert message templates
+ALTER TABLE alert_rules ADD COLUMN IF NOT EXISTS templates JSONB DEFAULT '{}';
+`,
+		"010_add_soft_delete.sql": `
+-- Migration 010: Soft delete for alert rules and safety policies
+ALTER TABLE alert_rules ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE safety_policies ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+`,
+		"011_add_ip_allowlists.sql": `
+-- Migration 011: Client IP allowlists for API keys and organizations
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS allowed_cidrs JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS allowed_cidrs JSONB NOT NULL DEFAULT '[]';
+`,
+		"012_add_trace_lookup_indexes.sql": `
+-- Migration 012: Look up detections and approvals by trace for trace timelines
+CREATE INDEX IF NOT EXISTS idx_injection_detections_trace_id ON injection_detections(org_id, trace_id);
+CREATE INDEX IF NOT EXISTS idx_tool_approvals_trace_id ON tool_approvals(org_id, trace_id);
+`,
+		"013_add_safety_pattern_libraries.sql": `
+-- Migration 013: Shared pattern libraries that safety policies reference by ID
+CREATE TABLE IF NOT EXISTS safety_pattern_libraries (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    category VARCHAR(20) NOT NULL,
+    patterns JSONB NOT NULL DEFAULT '{}',
+    version INTEGER NOT NULL DEFAULT 1,
+    created_by UUID,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_safety_pattern_libraries_org ON safety_pattern_libraries(org_id);
+`,
+		"014_add_mcp_servers.sql": `
+-- Migration 014: MCP servers registered at runtime, alongside configured ones
+CREATE TABLE IF NOT EXISTS mcp_servers (
+    name VARCHAR(100) PRIMARY KEY,
+    url TEXT NOT NULL,
+    timeout_seconds INTEGER NOT NULL DEFAULT 30,
+    default_classification VARCHAR(20),
+    created_by UUID,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+`,
+		"015_add_mcp_server_auth.sql": `
+-- Migration 015: Outbound credentials of registered MCP servers, encrypted with SECRETS_ENCRYPTION_KEY
+ALTER TABLE mcp_servers ADD COLUMN IF NOT EXISTS auth_type VARCHAR(20);
+ALTER TABLE mcp_servers ADD COLUMN IF NOT EXISTS auth_encrypted BYTEA;
+`,
+		"016_create_org_settings.sql": `
+-- Migration 016: Settings organizations have changed from their defaults
+CREATE TABLE IF NOT EXISTS org_settings (
+    org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    key VARCHAR(100) NOT NULL,
+    value JSONB NOT NULL,
+    updated_by UUID,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (org_id, key)
+);
+`,
+		"017_add_alert_channel_grouping.sql": `
+-- Migration 017: Per-channel alert grouping
+ALTER TABLE alert_channels ADD COLUMN IF NOT EXISTS grouping JSONB DEFAULT '{}';
+`,
+		"018_create_alert_inhibit_rules.sql": `
+-- Migration 018: Inhibit rules, and the alert holding back an alert's notifications
+CREATE TABLE IF NOT EXISTS alert_inhibit_rules (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    source_match JSONB NOT NULL,
+    target_match JSONB NOT NULL,
+    equal JSONB NOT NULL DEFAULT '[]',
+    enabled BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_inhibit_rules_org ON alert_inhibit_rules(org_id);
+
+ALTER TABLE alerts ADD COLUMN IF NOT EXISTS inhibited_by UUID;
+`,
+		"019_add_tool_approval_arguments_redacted.sql": `
+-- Migration 019: Whether an approval's arguments were redacted before storing
+ALTER TABLE tool_approvals ADD COLUMN IF NOT EXISTS arguments_redacted BOOLEAN NOT NULL DEFAULT FALSE;
+`,
+		"020_add_tool_approval_auto_approval_rule.sql": `
+-- Migration 020: The rule that approved a request without review
+ALTER TABLE tool_approvals ADD COLUMN IF NOT EXISTS auto_approval_rule VARCHAR(255) NOT NULL DEFAULT '';
+`,
+		"021_create_alert_events.sql": `
+-- Migration 021: Append-only history of each alert's lifecycle
+CREATE TABLE IF NOT EXISTS alert_events (
+    seq BIGSERIAL,
+    id UUID PRIMARY KEY,
+    alert_id UUID NOT NULL,
+    org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    type VARCHAR(20) NOT NULL,
+    actor_type VARCHAR(20) NOT NULL,
+    actor_user_id UUID,
+    actor_integration VARCHAR(100) NOT NULL DEFAULT '',
+    details JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_events_alert ON alert_events(alert_id, created_at, seq);
 `,
 	}
 }
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMain runs the gateway itself when re-executed by startGateway.
+func TestMain(m *testing.M) {
+	if os.Getenv("GATEWAY_TEST_MAIN") == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// startGateway runs the gateway in a child process with env and returns its
+// base URL once it answers health checks.
+func startGateway(t *testing.T, env ...string) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("pick a port: %v", err)
+	}
+	port := strconv.Itoa(l.Addr().(*net.TCPAddr).Port)
+	l.Close()
+
+	cmd := exec.Command(os.Args[0])
+	cmd.Env = append(os.Environ(), append(env, "GATEWAY_TEST_MAIN=1", "PORT="+port)...)
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("start gateway: %v", err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+
+	baseURL := "http://127.0.0.1:" + port
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		resp, err := http.Get(baseURL + "/health")
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return baseURL
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("gateway on port %s did not become healthy: %v", port, err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestGatewayServesAlertRulesInMemory(t *testing.T) {
+	// No database or Redis is reachable at these addresses
+	baseURL := startGateway(t,
+		"STORAGE=memory",
+		"ENV=development",
+		"DEMO_MODE=true",
+		"DATABASE_URL=postgres://nobody@127.0.0.1:1/none",
+		"REDIS_URL=redis://127.0.0.1:1",
+	)
+
+	do := func(method, path, body string) (int, map[string]any) {
+		t.Helper()
+		req, err := http.NewRequest(method, baseURL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+		defer resp.Body.Close()
+		var decoded map[string]any
+		json.NewDecoder(resp.Body).Decode(&decoded)
+		return resp.StatusCode, decoded
+	}
+
+	status, created := do(http.MethodPost, "/v1/alerts/rules",
+		`{"name":"Slow reads","metric":"latency_p95","condition":"gt","threshold":500,"window_minutes":5,"severity":"warning","enabled":true}`)
+	if status != http.StatusCreated {
+		t.Fatalf("create: status %d: %v", status, created)
+	}
+	id, _ := created["id"].(string)
+	path := "/v1/alerts/rules/" + id
+
+	if status, rule := do(http.MethodGet, path, ""); status != http.StatusOK || rule["name"] != "Slow reads" {
+		t.Errorf("get: status %d: %v", status, rule)
+	}
+	status, updated := do(http.MethodPut, path,
+		`{"name":"Very slow reads","metric":"latency_p95","condition":"gt","threshold":2000,"window_minutes":5,"severity":"critical","enabled":true}`)
+	if status != http.StatusOK || updated["name"] != "Very slow reads" || updated["threshold"] != float64(2000) {
+		t.Errorf("update: status %d: %v", status, updated)
+	}
+	if status, body := do(http.MethodDelete, path, ""); status >= 300 {
+		t.Errorf("delete: status %d: %v", status, body)
+	}
+	if status, _ := do(http.MethodGet, path, ""); status != http.StatusNotFound {
+		t.Errorf("get after delete: status %d, want %d", status, http.StatusNotFound)
+	}
+}
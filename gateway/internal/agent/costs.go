@@ -0,0 +1,208 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// CostPolicy caps what a single connection may spend on tool calls. A call
+// whose estimated cost would take the connection past a cap is rejected, so
+// once a cap is reached, further calls it covers are rejected for the rest
+// of the connection's life.
+type CostPolicy struct {
+	ConnectionCap float64            // USD per connection; 0 for no cap
+	ToolCaps      map[string]float64 // USD per connection on each "server.tool"
+}
+
+// CostCapError is returned for a tool call that a connection's spending cap
+// no longer allows.
+type CostCapError struct {
+	Tool     string // "server.tool" for a per-tool cap, empty for the connection's
+	SpentUSD float64
+	CapUSD   float64
+}
+
+func (e *CostCapError) Error() string {
+	if e.Tool != "" {
+		return fmt.Sprintf("Connection has spent $%g on %s, leaving too little of its cap of $%g", e.SpentUSD, e.Tool, e.CapUSD)
+	}
+	return fmt.Sprintf("Connection has spent $%g, leaving too little of its cap of $%g", e.SpentUSD, e.CapUSD)
+}
+
+// ConnectionCost is what a connection has spent against its caps.
+type ConnectionCost struct {
+	SpentUSD     float64             `json:"spent_usd"`
+	CapUSD       float64             `json:"cap_usd,omitempty"`       // 0 when uncapped
+	RemainingUSD *float64            `json:"remaining_usd,omitempty"` // Absent when uncapped
+	Tools        map[string]ToolCost `json:"tools,omitempty"`         // By "server.tool"
+}
+
+// ToolCost is what a connection has spent on one tool against its cap.
+type ToolCost struct {
+	SpentUSD     float64  `json:"spent_usd"`
+	CapUSD       float64  `json:"cap_usd,omitempty"`
+	RemainingUSD *float64 `json:"remaining_usd,omitempty"`
+}
+
+// ConnectionDetail is a connection along with its spending.
+type ConnectionDetail struct {
+	*Connection
+	Cost ConnectionCost `json:"cost"`
+}
+
+// connectionCap returns the cap of a new connection: the policy's, lowered
+// to the one the agent asked for when that is lower.
+func (p CostPolicy) connectionCap(requested float64) float64 {
+	if requested > 0 && (p.ConnectionCap <= 0 || requested < p.ConnectionCap) {
+		return requested
+	}
+	return p.ConnectionCap
+}
+
+// ErrConnectionRequired is returned for a tool call made without a
+// connection while cost caps are configured, as its spending could not be
+// capped.
+var ErrConnectionRequired = errors.New("a connection is required while cost caps are configured")
+
+// Capped reports whether the policy caps spending at all.
+func (p CostPolicy) Capped() bool {
+	return p.ConnectionCap > 0 || len(p.ToolCaps) > 0
+}
+
+// CostCapped reports whether connections' spending is capped, so tool calls
+// must be made through a connection.
+func (m *Manager) CostCapped() bool {
+	return m.costs.Capped()
+}
+
+// CostReservation holds a tool call's estimated cost against its
+// connection's caps while the call runs. Settle replaces it with what the
+// call actually cost.
+type CostReservation struct {
+	m        *Manager
+	conn     *Connection
+	key      string // "server.tool"
+	estimate float64
+	once     sync.Once
+}
+
+// ReserveCost reserves the estimated cost of a call to server's tool against
+// a connection's caps. It returns a *CostCapError when the estimate, on top
+// of what the connection has spent and reserved for calls still running,
+// would take it past its cap or its cap on the tool. The check and the
+// reservation are one step, so calls made at once cannot together overshoot
+// a cap. Calls made without a connection are not charged, and are refused
+// with ErrConnectionRequired while caps are configured; a nil reservation is
+// returned for them.
+func (m *Manager) ReserveCost(connID uuid.UUID, server, tool string, estimate float64) (*CostReservation, error) {
+	if connID == uuid.Nil {
+		if m.costs.Capped() {
+			return nil, ErrConnectionRequired
+		}
+		return nil, nil
+	}
+	conn, ok := m.GetConnection(connID)
+	if !ok {
+		return nil, ErrConnectionNotFound
+	}
+	estimate = math.Max(estimate, 0)
+
+	key := server + "." + tool
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	if committed := conn.costSpent + conn.costReserved; conn.costCap > 0 && committed+estimate > conn.costCap {
+		return nil, &CostCapError{SpentUSD: committed, CapUSD: conn.costCap}
+	}
+	if limit, ok := m.costs.ToolCaps[key]; ok {
+		if committed := conn.toolSpent[key] + conn.toolReserved[key]; committed+estimate > limit {
+			return nil, &CostCapError{Tool: key, SpentUSD: committed, CapUSD: limit}
+		}
+	}
+	conn.costReserved += estimate
+	conn.toolReserved[key] += estimate
+	return &CostReservation{m: m, conn: conn, key: key, estimate: estimate}, nil
+}
+
+// Settle releases the reservation and adds what the call cost to its
+// connection's spending; only the first call has any effect. The connection
+// is sent a cost_cap_reached message when the call takes it to one of its
+// caps. It does nothing on a nil reservation.
+func (r *CostReservation) Settle(cost float64) {
+	if r == nil {
+		return
+	}
+	r.once.Do(func() { r.settle(math.Max(cost, 0)) })
+}
+
+func (r *CostReservation) settle(cost float64) {
+	conn, key := r.conn, r.key
+	var reached []*CostCapError
+	conn.mu.Lock()
+	conn.costReserved = math.Max(conn.costReserved-r.estimate, 0)
+	if conn.toolReserved[key] = math.Max(conn.toolReserved[key]-r.estimate, 0); conn.toolReserved[key] == 0 {
+		delete(conn.toolReserved, key)
+	}
+	if cost > 0 {
+		before := conn.costSpent
+		conn.costSpent += cost
+		if conn.costCap > 0 && before < conn.costCap && conn.costSpent >= conn.costCap {
+			reached = append(reached, &CostCapError{SpentUSD: conn.costSpent, CapUSD: conn.costCap})
+		}
+		toolBefore := conn.toolSpent[key]
+		conn.toolSpent[key] += cost
+		if limit, ok := r.m.costs.ToolCaps[key]; ok && toolBefore < limit && conn.toolSpent[key] >= limit {
+			reached = append(reached, &CostCapError{Tool: key, SpentUSD: conn.toolSpent[key], CapUSD: limit})
+		}
+	}
+	conn.mu.Unlock()
+
+	for _, hit := range reached {
+		r.m.logger.Warn().
+			Str("connection_id", conn.ID.String()).
+			Str("org_id", conn.OrgID.String()).
+			Str("tool", hit.Tool).
+			Float64("spent_usd", hit.SpentUSD).
+			Float64("cap_usd", hit.CapUSD).
+			Msg("Agent connection reached its cost cap")
+		r.m.send(conn, WSMessage{
+			Type: WSTypeCostCapReached,
+			Payload: map[string]any{
+				"tool":      hit.Tool,
+				"spent_usd": hit.SpentUSD,
+				"cap_usd":   hit.CapUSD,
+				"message":   hit.Error(),
+			},
+		})
+	}
+}
+
+// Cost returns what a connection has spent against its caps.
+func (m *Manager) Cost(conn *Connection) ConnectionCost {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+
+	cost := ConnectionCost{SpentUSD: conn.costSpent, CapUSD: conn.costCap}
+	if conn.costCap > 0 {
+		cost.RemainingUSD = remainingCost(conn.costCap, conn.costSpent)
+	}
+	if len(conn.toolSpent) > 0 || len(m.costs.ToolCaps) > 0 {
+		cost.Tools = make(map[string]ToolCost)
+	}
+	for key, spent := range conn.toolSpent {
+		cost.Tools[key] = ToolCost{SpentUSD: spent}
+	}
+	for key, limit := range m.costs.ToolCaps {
+		spent := conn.toolSpent[key]
+		cost.Tools[key] = ToolCost{SpentUSD: spent, CapUSD: limit, RemainingUSD: remainingCost(limit, spent)}
+	}
+	return cost
+}
+
+func remainingCost(limit, spent float64) *float64 {
+	remaining := math.Max(limit-spent, 0)
+	return &remaining
+}
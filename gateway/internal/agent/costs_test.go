@@ -0,0 +1,174 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+func newTestManager(t *testing.T, costs CostPolicy) *Manager {
+	t.Helper()
+	m := NewManager(zerolog.Nop(), nil, 0, time.Minute, costs)
+	t.Cleanup(func() { m.Shutdown(context.Background()) })
+	return m
+}
+
+func newTestConnection(t *testing.T, m *Manager, capUSD float64) *Connection {
+	t.Helper()
+	conn, err := m.Connect(context.Background(), ConnectRequest{Platform: "test", CostCapUSD: capUSD}, uuid.New(), uuid.New())
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	return conn
+}
+
+// nextMessage returns the next message queued for the connection.
+func nextMessage(t *testing.T, conn *Connection) WSMessage {
+	t.Helper()
+	select {
+	case raw := <-conn.Outbox():
+		var msg WSMessage
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			t.Fatalf("decode message: %v", err)
+		}
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("no message was sent")
+		return WSMessage{}
+	}
+}
+
+func TestReserveCostConcurrentCallsCannotOvershoot(t *testing.T) {
+	m := newTestManager(t, CostPolicy{ConnectionCap: 1.0})
+	conn := newTestConnection(t, m, 0)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var reservations []*CostReservation
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if r, err := m.ReserveCost(conn.ID, "fs", "read", 0.3); err == nil {
+				mu.Lock()
+				reservations = append(reservations, r)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(reservations) != 3 {
+		t.Fatalf("%d calls of $0.30 were allowed under a $1 cap, want 3", len(reservations))
+	}
+	for _, r := range reservations {
+		r.Settle(0.3)
+	}
+	if cost := m.Cost(conn); cost.SpentUSD > 1.0 {
+		t.Errorf("spent $%g, past the $1 cap", cost.SpentUSD)
+	}
+}
+
+func TestReserveCostSettlesActualCost(t *testing.T) {
+	m := newTestManager(t, CostPolicy{ConnectionCap: 1.0})
+	conn := newTestConnection(t, m, 0)
+
+	r, err := m.ReserveCost(conn.ID, "fs", "read", 0.9)
+	if err != nil {
+		t.Fatalf("ReserveCost: %v", err)
+	}
+	// The estimate is held while the call runs
+	var capErr *CostCapError
+	if _, err := m.ReserveCost(conn.ID, "fs", "read", 0.2); !errors.As(err, &capErr) {
+		t.Fatalf("second reservation: err = %v, want a *CostCapError", err)
+	}
+
+	// The call cost less than estimated, which frees the difference
+	r.Settle(0.1)
+	r.Settle(0.1) // Settling twice has no effect
+	if cost := m.Cost(conn); cost.SpentUSD != 0.1 {
+		t.Errorf("spent $%g, want $0.1", cost.SpentUSD)
+	}
+	if _, err := m.ReserveCost(conn.ID, "fs", "read", 0.2); err != nil {
+		t.Errorf("reservation after settling: %v", err)
+	}
+}
+
+func TestReserveCostToolCapAndCapReachedMessage(t *testing.T) {
+	m := newTestManager(t, CostPolicy{ToolCaps: map[string]float64{"shell.exec": 0.5}})
+	conn := newTestConnection(t, m, 0)
+
+	r, err := m.ReserveCost(conn.ID, "shell", "exec", 0.25)
+	if err != nil {
+		t.Fatalf("ReserveCost: %v", err)
+	}
+	r.Settle(0.5)
+
+	msg := nextMessage(t, conn)
+	if msg.Type != WSTypeCostCapReached {
+		t.Fatalf("sent %q, want %q", msg.Type, WSTypeCostCapReached)
+	}
+
+	var capErr *CostCapError
+	if _, err := m.ReserveCost(conn.ID, "shell", "exec", 0.01); !errors.As(err, &capErr) || capErr.Tool != "shell.exec" {
+		t.Errorf("call past the tool cap: err = %v, want a *CostCapError for shell.exec", err)
+	}
+	if _, err := m.ReserveCost(conn.ID, "fs", "read", 10); err != nil {
+		t.Errorf("call to an uncapped tool: %v", err)
+	}
+}
+
+func TestReserveCostRequiresConnectionWhenCapped(t *testing.T) {
+	capped := newTestManager(t, CostPolicy{ConnectionCap: 1.0})
+	if _, err := capped.ReserveCost(uuid.Nil, "fs", "read", 0.1); !errors.Is(err, ErrConnectionRequired) {
+		t.Errorf("call without a connection: err = %v, want ErrConnectionRequired", err)
+	}
+	if _, err := capped.ReserveCost(uuid.New(), "fs", "read", 0.1); !errors.Is(err, ErrConnectionNotFound) {
+		t.Errorf("call with an unknown connection: err = %v, want ErrConnectionNotFound", err)
+	}
+
+	uncapped := newTestManager(t, CostPolicy{})
+	r, err := uncapped.ReserveCost(uuid.Nil, "fs", "read", 0.1)
+	if err != nil || r != nil {
+		t.Errorf("uncapped call without a connection: reservation %v, err %v", r, err)
+	}
+	r.Settle(0.1) // A nil reservation settles to nothing
+}
+
+func TestHandleToolCallUsesExecutor(t *testing.T) {
+	m := newTestManager(t, CostPolicy{})
+	conn := newTestConnection(t, m, 0)
+
+	var got ToolCall
+	m.SetToolExecutor(func(ctx context.Context, c *Connection, call ToolCall, progress func(map[string]any)) ToolResult {
+		got = call
+		progress(map[string]any{"call_id": call.ID})
+		return ToolResult{Status: "success", Cost: 0.02}
+	})
+	m.handleToolCall(conn, WSMessage{
+		Type:    WSTypeToolCall,
+		ID:      "msg_1",
+		Payload: map[string]any{"server": "fs", "tool": "read"},
+	})
+
+	if got.ID != "msg_1" || got.Server != "fs" || got.Tool != "read" {
+		t.Fatalf("executor got %+v", got)
+	}
+	if msg := nextMessage(t, conn); msg.Type != WSTypeProgress || msg.ID != "msg_1" {
+		t.Errorf("first message = %s %s, want progress for msg_1", msg.Type, msg.ID)
+	}
+	msg := nextMessage(t, conn)
+	if msg.Type != WSTypeToolResult || msg.ID != "msg_1" {
+		t.Fatalf("second message = %s %s, want tool_result for msg_1", msg.Type, msg.ID)
+	}
+	payload, _ := msg.Payload.(map[string]any)
+	if payload["status"] != "success" || payload["id"] != "msg_1" {
+		t.Errorf("result payload = %v", payload)
+	}
+}
@@ -2,7 +2,11 @@ package agent
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"sync"
@@ -13,6 +17,13 @@ import (
 	"github.com/rs/zerolog"
 )
 
+// Errors returned by UpgradeToWebSocket, along with ErrConnectionNotFound,
+// before the upgrade while an HTTP error response can still be written.
+var (
+	ErrInvalidResumeToken = errors.New("invalid resume token")
+	ErrAlreadyConnected   = errors.New("connection already has a WebSocket")
+)
+
 // Manager handles agent connections and message routing.
 type Manager struct {
 	logger      zerolog.Logger
@@ -25,14 +36,38 @@ type Manager struct {
 	activeConnections   int64
 	totalMessages       int64
 	connectionsByPlatform map[string]int
+
+	// Resource subscriptions by connection ID, then subscription ID
+	resources     ResourceReader
+	subscriptions map[uuid.UUID]map[uuid.UUID]*ResourceSubscription
+	subMu         sync.Mutex
+
+	// How long a connection whose WebSocket dropped is kept for resumption
+	resumeGrace time.Duration
+
+	// Caps on what each connection may spend
+	costs CostPolicy
+
+	// Executes tool calls made over WebSockets
+	executor ToolExecutor
+
+	stop     chan struct{}
+	stopOnce sync.Once
 }
 
-// NewManager creates a new agent connection manager.
-func NewManager(logger zerolog.Logger) *Manager {
-	return &Manager{
+// NewManager creates a new agent connection manager. When resources is set,
+// subscribed resources are re-read every pollInterval and subscribers are
+// notified of changes. A connection whose WebSocket drops is kept, buffering
+// its messages, for resumeGrace before it is disconnected. Each connection's
+// spending on tool calls is capped by costs.
+func NewManager(logger zerolog.Logger, resources ResourceReader, pollInterval, resumeGrace time.Duration, costs CostPolicy) *Manager {
+	m := &Manager{
 		logger:      logger,
 		connections: make(map[uuid.UUID]*Connection),
 		connectionsByPlatform: make(map[string]int),
+		resources:     resources,
+		subscriptions: make(map[uuid.UUID]map[uuid.UUID]*ResourceSubscription),
+		stop:          make(chan struct{}),
 		upgrader: websocket.Upgrader{
 			ReadBufferSize:  1024,
 			WriteBufferSize: 1024,
@@ -41,11 +76,24 @@ func NewManager(logger zerolog.Logger) *Manager {
 				return true
 			},
 		},
+		resumeGrace: resumeGrace,
+		costs:       costs,
+	}
+
+	if resources != nil && pollInterval > 0 {
+		go m.pollLoop(pollInterval)
 	}
+
+	return m
 }
 
 // Connect establishes a new agent connection.
 func (m *Manager) Connect(ctx context.Context, req ConnectRequest, orgID, userID uuid.UUID) (*Connection, error) {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return nil, fmt.Errorf("generate resume token: %w", err)
+	}
+
 	conn := &Connection{
 		ID:           uuid.New(),
 		AgentID:      req.AgentID,
@@ -61,6 +109,10 @@ func (m *Manager) Connect(ctx context.Context, req ConnectRequest, orgID, userID
 		LastActiveAt: time.Now(),
 		sendCh:       make(chan []byte, 256),
 		done:         make(chan struct{}),
+		resumeToken:  hex.EncodeToString(token),
+		costCap:      m.costs.connectionCap(req.CostCapUSD),
+		toolSpent:    make(map[string]float64),
+		toolReserved: make(map[string]float64),
 	}
 
 	m.mu.Lock()
@@ -80,14 +132,29 @@ func (m *Manager) Connect(ctx context.Context, req ConnectRequest, orgID, userID
 	return conn, nil
 }
 
-// UpgradeToWebSocket upgrades an HTTP connection to WebSocket.
-func (m *Manager) UpgradeToWebSocket(w http.ResponseWriter, r *http.Request, connID uuid.UUID) error {
+// UpgradeToWebSocket upgrades an HTTP connection to WebSocket. A suspended
+// connection, whose WebSocket dropped within the grace period, is resumed
+// when resumeToken matches: the client is sent a resumed message followed by
+// the messages buffered while it was away.
+func (m *Manager) UpgradeToWebSocket(w http.ResponseWriter, r *http.Request, connID uuid.UUID, resumeToken string) error {
 	m.mu.Lock()
 	conn, exists := m.connections[connID]
 	m.mu.Unlock()
 
 	if !exists {
-		return fmt.Errorf("connection not found: %s", connID)
+		return ErrConnectionNotFound
+	}
+
+	conn.mu.Lock()
+	resuming := conn.State == StateSuspended
+	busy := conn.ws != nil
+	conn.mu.Unlock()
+
+	if busy {
+		return ErrAlreadyConnected
+	}
+	if resuming && subtle.ConstantTimeCompare([]byte(resumeToken), []byte(conn.resumeToken)) != 1 {
+		return ErrInvalidResumeToken
 	}
 
 	ws, err := m.upgrader.Upgrade(w, r, nil)
@@ -96,40 +163,71 @@ func (m *Manager) UpgradeToWebSocket(w http.ResponseWriter, r *http.Request, con
 	}
 
 	conn.mu.Lock()
+	// Another upgrade may have won the race, or the connection expired
+	if conn.ws != nil || conn.State == StateDisconnected {
+		conn.mu.Unlock()
+		ws.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "connection unavailable"))
+		ws.Close()
+		return fmt.Errorf("connection %s no longer available for WebSocket", connID)
+	}
+	if conn.graceTimer != nil {
+		conn.graceTimer.Stop()
+		conn.graceTimer = nil
+	}
 	conn.ws = ws
+	conn.wsDone = make(chan struct{})
 	conn.State = StateConnected
+	conn.callCtx = context.WithoutCancel(r.Context())
+	unsent := conn.unsent
+	conn.unsent = nil
+	wsDone := conn.wsDone
 	conn.mu.Unlock()
 
 	// Start read and write pumps
-	go m.readPump(conn)
-	go m.writePump(conn)
-
-	m.logger.Info().
-		Str("connection_id", conn.ID.String()).
-		Msg("WebSocket connection established")
+	go m.readPump(conn, ws)
+	go m.writePump(conn, ws, wsDone, resuming, unsent)
+
+	if resuming {
+		m.logger.Info().
+			Str("connection_id", conn.ID.String()).
+			Int("buffered", len(unsent)+len(conn.sendCh)).
+			Msg("WebSocket connection resumed")
+	} else {
+		m.logger.Info().
+			Str("connection_id", conn.ID.String()).
+			Msg("WebSocket connection established")
+	}
 
 	return nil
 }
 
-// readPump reads messages from the WebSocket connection.
-func (m *Manager) readPump(conn *Connection) {
+// readPump reads messages from the WebSocket connection. When the client
+// closes the WebSocket normally the connection is disconnected; when it
+// drops, the connection is suspended so the client can resume it.
+func (m *Manager) readPump(conn *Connection, ws *websocket.Conn) {
+	var readErr error
 	defer func() {
-		m.Disconnect(conn.ID)
+		if websocket.IsCloseError(readErr, websocket.CloseNormalClosure) {
+			m.Disconnect(conn.ID)
+			return
+		}
+		m.suspend(conn, ws)
 	}()
 
-	conn.ws.SetReadLimit(512 * 1024) // 512KB max message size
-	conn.ws.SetReadDeadline(time.Now().Add(60 * time.Second))
-	conn.ws.SetPongHandler(func(string) error {
-		conn.ws.SetReadDeadline(time.Now().Add(60 * time.Second))
+	ws.SetReadLimit(512 * 1024) // 512KB max message size
+	ws.SetReadDeadline(time.Now().Add(60 * time.Second))
+	ws.SetPongHandler(func(string) error {
+		ws.SetReadDeadline(time.Now().Add(60 * time.Second))
 		return nil
 	})
 
 	for {
-		_, message, err := conn.ws.ReadMessage()
+		_, message, err := ws.ReadMessage()
 		if err != nil {
-			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 				m.logger.Warn().Err(err).Str("connection_id", conn.ID.String()).Msg("WebSocket read error")
 			}
+			readErr = err
 			break
 		}
 
@@ -146,40 +244,129 @@ func (m *Manager) readPump(conn *Connection) {
 	}
 }
 
-// writePump writes messages to the WebSocket connection.
-func (m *Manager) writePump(conn *Connection) {
+// writePump writes messages to the WebSocket connection until it is
+// detached. On a resumed connection it first sends a resumed message and the
+// messages left undelivered by the previous WebSocket. A message that fails
+// to send is kept for the next resumption.
+func (m *Manager) writePump(conn *Connection, ws *websocket.Conn, wsDone chan struct{}, resumed bool, unsent [][]byte) {
 	ticker := time.NewTicker(30 * time.Second)
 	defer func() {
 		ticker.Stop()
-		conn.ws.Close()
+		ws.Close()
 	}()
 
+	if resumed {
+		notice, err := json.Marshal(WSMessage{
+			Type:    WSTypeResumed,
+			Payload: ResumedPayload{ConnectionID: conn.ID, Buffered: len(unsent) + len(conn.sendCh)},
+		})
+		if err == nil {
+			unsent = append([][]byte{notice}, unsent...)
+		}
+	}
+	for i, message := range unsent {
+		ws.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		if err := ws.WriteMessage(websocket.TextMessage, message); err != nil {
+			m.logger.Warn().Err(err).Str("connection_id", conn.ID.String()).Msg("WebSocket write error")
+			m.keepUnsent(conn, wsDone, unsent[i:])
+			return
+		}
+	}
+
 	for {
 		select {
 		case message, ok := <-conn.sendCh:
-			conn.ws.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			ws.SetWriteDeadline(time.Now().Add(10 * time.Second))
 			if !ok {
-				conn.ws.WriteMessage(websocket.CloseMessage, []byte{})
+				ws.WriteMessage(websocket.CloseMessage, []byte{})
 				return
 			}
 
-			if err := conn.ws.WriteMessage(websocket.TextMessage, message); err != nil {
+			if err := ws.WriteMessage(websocket.TextMessage, message); err != nil {
 				m.logger.Warn().Err(err).Str("connection_id", conn.ID.String()).Msg("WebSocket write error")
+				m.keepUnsent(conn, wsDone, [][]byte{message})
 				return
 			}
 
 		case <-ticker.C:
-			conn.ws.SetWriteDeadline(time.Now().Add(10 * time.Second))
-			if err := conn.ws.WriteMessage(websocket.PingMessage, nil); err != nil {
+			ws.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			if err := ws.WriteMessage(websocket.PingMessage, nil); err != nil {
 				return
 			}
 
+		case <-wsDone:
+			return
+
 		case <-conn.done:
 			return
 		}
 	}
 }
 
+// keepUnsent holds messages a WebSocket failed to deliver so they are sent
+// first when the connection is resumed.
+func (m *Manager) keepUnsent(conn *Connection, wsDone chan struct{}, messages [][]byte) {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+
+	if conn.State == StateDisconnected {
+		return
+	}
+	if conn.wsDone == wsDone {
+		conn.unsent = append(conn.unsent, messages...)
+		return
+	}
+	// A new WebSocket has already taken over; queue behind what it is sending
+	for _, message := range messages {
+		select {
+		case conn.sendCh <- message:
+		default:
+			m.logger.Warn().Str("connection_id", conn.ID.String()).Msg("Send channel full, dropping message")
+		}
+	}
+}
+
+// suspend detaches a dropped WebSocket from its connection. Messages for the
+// connection are buffered until a client resumes it with the resume token;
+// if none does within the grace period, the connection is disconnected and
+// its buffer discarded.
+func (m *Manager) suspend(conn *Connection, ws *websocket.Conn) {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+
+	if conn.ws != ws || conn.State == StateDisconnected {
+		return
+	}
+	conn.ws = nil
+	close(conn.wsDone)
+	ws.Close()
+
+	if m.resumeGrace <= 0 {
+		go m.Disconnect(conn.ID)
+		return
+	}
+	conn.State = StateSuspended
+
+	var timer *time.Timer
+	timer = time.AfterFunc(m.resumeGrace, func() {
+		conn.mu.Lock()
+		expired := conn.State == StateSuspended && conn.graceTimer == timer
+		conn.mu.Unlock()
+		if expired {
+			m.logger.Info().
+				Str("connection_id", conn.ID.String()).
+				Msg("Suspended agent connection was not resumed")
+			m.Disconnect(conn.ID)
+		}
+	})
+	conn.graceTimer = timer
+
+	m.logger.Info().
+		Str("connection_id", conn.ID.String()).
+		Dur("grace_period", m.resumeGrace).
+		Msg("WebSocket dropped; connection suspended for resumption")
+}
+
 // handleMessage processes an incoming WebSocket message.
 func (m *Manager) handleMessage(conn *Connection, data []byte) {
 	var msg WSMessage
@@ -198,12 +385,30 @@ func (m *Manager) handleMessage(conn *Connection, data []byte) {
 	case WSTypeCancel:
 		m.handleCancel(conn, msg)
 
+	case WSTypeResourceSubscribe:
+		m.handleResourceSubscribe(conn, msg)
+
+	case WSTypeResourceUnsubscribe:
+		m.handleResourceUnsubscribe(conn, msg)
+
 	default:
 		m.sendError(conn, msg.ID, "unknown_message_type", fmt.Sprintf("Unknown message type: %s", msg.Type))
 	}
 }
 
-// handleToolCall processes a tool call request.
+// ToolExecutor executes a tool call made over a connection's WebSocket,
+// charging it to the connection and passing progress events to progress.
+// ctx carries the principal that opened the WebSocket and is cancelled when
+// the connection ends.
+type ToolExecutor func(ctx context.Context, conn *Connection, call ToolCall, progress func(map[string]any)) ToolResult
+
+// SetToolExecutor sets how tool calls made over WebSockets are executed.
+// It must be called before connections are upgraded.
+func (m *Manager) SetToolExecutor(executor ToolExecutor) {
+	m.executor = executor
+}
+
+// handleToolCall executes a tool call request and sends its result.
 func (m *Manager) handleToolCall(conn *Connection, msg WSMessage) {
 	// Extract tool call from payload
 	payloadBytes, err := json.Marshal(msg.Payload)
@@ -219,21 +424,32 @@ func (m *Manager) handleToolCall(conn *Connection, msg WSMessage) {
 	}
 	call.ID = msg.ID
 
-	// TODO: Execute tool call through MCP handler
-	// For now, send a mock response
-	m.send(conn, WSMessage{
-		Type: WSTypeToolResult,
-		ID:   msg.ID,
-		Payload: ToolResult{
-			ID:     msg.ID,
-			Status: "success",
-			Content: []ContentBlock{
-				{Type: "text", Text: fmt.Sprintf("Tool %s.%s executed (mock)", call.Server, call.Tool)},
-			},
-			DurationMs: 50,
-			Cost:       0.0001,
-		},
+	if m.executor == nil {
+		m.sendError(conn, msg.ID, "tool_execution_unavailable", "Tool calls cannot be executed over this connection")
+		return
+	}
+
+	conn.mu.Lock()
+	base := conn.callCtx
+	conn.mu.Unlock()
+	if base == nil {
+		base = context.Background()
+	}
+	ctx, cancel := context.WithCancel(base)
+	defer cancel()
+	go func() {
+		select {
+		case <-conn.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	result := m.executor(ctx, conn, call, func(event map[string]any) {
+		m.send(conn, WSMessage{Type: WSTypeProgress, ID: msg.ID, Payload: event})
 	})
+	result.ID = msg.ID
+	m.send(conn, WSMessage{Type: WSTypeToolResult, ID: msg.ID, Payload: result})
 }
 
 // handleCancel processes a cancel request.
@@ -245,6 +461,48 @@ func (m *Manager) handleCancel(conn *Connection, msg WSMessage) {
 		Msg("Cancel request received")
 }
 
+// handleResourceSubscribe subscribes the connection to a resource.
+func (m *Manager) handleResourceSubscribe(conn *Connection, msg WSMessage) {
+	var req SubscribeRequest
+	if err := decodePayload(msg.Payload, &req); err != nil || req.Server == "" || req.URI == "" {
+		m.sendError(conn, msg.ID, "invalid_subscription", "server and uri are required")
+		return
+	}
+
+	sub, err := m.Subscribe(context.Background(), conn.ID, req)
+	if err != nil {
+		m.sendError(conn, msg.ID, "subscription_failed", err.Error())
+		return
+	}
+	m.send(conn, WSMessage{Type: WSTypeResourceSubscribed, ID: msg.ID, Payload: sub})
+}
+
+// handleResourceUnsubscribe removes one of the connection's subscriptions.
+func (m *Manager) handleResourceUnsubscribe(conn *Connection, msg WSMessage) {
+	var req struct {
+		SubscriptionID uuid.UUID `json:"subscription_id"`
+	}
+	if err := decodePayload(msg.Payload, &req); err != nil {
+		m.sendError(conn, msg.ID, "invalid_subscription", "subscription_id is required")
+		return
+	}
+
+	if err := m.Unsubscribe(conn.ID, req.SubscriptionID); err != nil {
+		m.sendError(conn, msg.ID, "subscription_not_found", err.Error())
+		return
+	}
+	m.send(conn, WSMessage{Type: WSTypeResourceUnsubscribed, ID: msg.ID, Payload: req})
+}
+
+// decodePayload converts a generic message payload into v.
+func decodePayload(payload any, v any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
 // send sends a message to the connection.
 func (m *Manager) send(conn *Connection, msg WSMessage) {
 	data, err := json.Marshal(msg)
@@ -286,12 +544,19 @@ func (m *Manager) Disconnect(connID uuid.UUID) {
 	m.connectionsByPlatform[conn.Platform]--
 	m.mu.Unlock()
 
+	m.removeSubscriptions(connID)
+
 	conn.mu.Lock()
 	conn.State = StateDisconnected
 	close(conn.done)
 	if conn.ws != nil {
 		conn.ws.Close()
 	}
+	if conn.graceTimer != nil {
+		conn.graceTimer.Stop()
+		conn.graceTimer = nil
+	}
+	conn.unsent = nil
 	conn.mu.Unlock()
 
 	m.logger.Info().
@@ -299,6 +564,48 @@ func (m *Manager) Disconnect(connID uuid.UUID) {
 		Msg("Agent connection closed")
 }
 
+// Shutdown sends a close frame to every WebSocket connection and closes all
+// agent connections.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.stopOnce.Do(func() { close(m.stop) })
+
+	m.mu.RLock()
+	connIDs := make([]uuid.UUID, 0, len(m.connections))
+	for id := range m.connections {
+		connIDs = append(connIDs, id)
+	}
+	m.mu.RUnlock()
+
+	deadline := time.Now().Add(time.Second)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+
+	closeMsg := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+	for _, id := range connIDs {
+		m.mu.RLock()
+		conn, exists := m.connections[id]
+		m.mu.RUnlock()
+		if !exists {
+			continue
+		}
+
+		conn.mu.Lock()
+		ws := conn.ws
+		conn.mu.Unlock()
+		if ws != nil {
+			if err := ws.WriteControl(websocket.CloseMessage, closeMsg, deadline); err != nil {
+				m.logger.Warn().Err(err).Str("connection_id", id.String()).Msg("Failed to send close frame")
+			}
+		}
+
+		m.Disconnect(id)
+	}
+
+	m.logger.Info().Int("connections", len(connIDs)).Msg("Agent connections closed")
+	return nil
+}
+
 // GetConnection returns a connection by ID.
 func (m *Manager) GetConnection(connID uuid.UUID) (*Connection, bool) {
 	m.mu.RLock()
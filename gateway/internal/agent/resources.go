@@ -0,0 +1,274 @@
+package agent
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxSubscriptionsPerConnection bounds how many resources one connection may
+// watch.
+const maxSubscriptionsPerConnection = 100
+
+// resourceReadTimeout bounds a single poll of a subscribed resource.
+const resourceReadTimeout = 10 * time.Second
+
+// ResourceReader reads MCP resource contents for change detection, from the
+// servers orgID can call.
+type ResourceReader interface {
+	ReadResource(ctx context.Context, orgID uuid.UUID, server, uri string) ([]byte, error)
+}
+
+// Errors returned when managing resource subscriptions.
+var (
+	ErrConnectionNotFound   = errors.New("connection not found")
+	ErrSubscriptionNotFound = errors.New("subscription not found")
+	ErrTooManySubscriptions = fmt.Errorf("connection already has %d resource subscriptions", maxSubscriptionsPerConnection)
+	ErrResourcesUnavailable = errors.New("resource subscriptions are not enabled")
+)
+
+// ResourceSubscription is a connection's interest in changes to one resource.
+type ResourceSubscription struct {
+	ID           uuid.UUID `json:"id"`
+	ConnectionID uuid.UUID `json:"connection_id"`
+	Server       string    `json:"server"`
+	URI          string    `json:"uri"`
+	Hash         string    `json:"hash"`
+	CreatedAt    time.Time `json:"created_at"`
+	CheckedAt    time.Time `json:"checked_at"`
+
+	orgID uuid.UUID // The connection's organization, whose servers are read
+}
+
+// SubscribeRequest identifies a resource to watch.
+type SubscribeRequest struct {
+	Server string `json:"server"`
+	URI    string `json:"uri"`
+}
+
+// ResourceUpdate notifies a subscriber that a resource's content changed.
+type ResourceUpdate struct {
+	SubscriptionID uuid.UUID `json:"subscription_id"`
+	Server         string    `json:"server"`
+	URI            string    `json:"uri"`
+	Hash           string    `json:"hash"`
+	PreviousHash   string    `json:"previous_hash"`
+	DetectedAt     time.Time `json:"detected_at"`
+}
+
+// hashContent returns the hex SHA-256 of resource content.
+func hashContent(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// Subscribe starts watching a resource for a connection. The resource is read
+// once immediately to validate it and record the baseline hash, so the first
+// notification reflects a real change.
+func (m *Manager) Subscribe(ctx context.Context, connID uuid.UUID, req SubscribeRequest) (*ResourceSubscription, error) {
+	if m.resources == nil {
+		return nil, ErrResourcesUnavailable
+	}
+
+	m.mu.RLock()
+	conn, exists := m.connections[connID]
+	m.mu.RUnlock()
+	if !exists {
+		return nil, ErrConnectionNotFound
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, resourceReadTimeout)
+	defer cancel()
+	content, err := m.resources.ReadResource(readCtx, conn.OrgID, req.Server, req.URI)
+	if err != nil {
+		return nil, fmt.Errorf("read resource: %w", err)
+	}
+
+	now := time.Now()
+	sub := &ResourceSubscription{
+		ID:           uuid.New(),
+		ConnectionID: connID,
+		orgID:        conn.OrgID,
+		Server:       req.Server,
+		URI:          req.URI,
+		Hash:         hashContent(content),
+		CreatedAt:    now,
+		CheckedAt:    now,
+	}
+
+	m.subMu.Lock()
+	defer m.subMu.Unlock()
+
+	// The connection may have closed while the resource was read
+	m.mu.RLock()
+	_, exists = m.connections[connID]
+	m.mu.RUnlock()
+	if !exists {
+		return nil, ErrConnectionNotFound
+	}
+
+	subs := m.subscriptions[connID]
+	for _, existing := range subs {
+		if existing.Server == req.Server && existing.URI == req.URI {
+			copied := *existing
+			return &copied, nil
+		}
+	}
+	if len(subs) >= maxSubscriptionsPerConnection {
+		return nil, ErrTooManySubscriptions
+	}
+	if subs == nil {
+		subs = make(map[uuid.UUID]*ResourceSubscription)
+		m.subscriptions[connID] = subs
+	}
+	subs[sub.ID] = sub
+
+	m.logger.Info().
+		Str("connection_id", connID.String()).
+		Str("subscription_id", sub.ID.String()).
+		Str("server", sub.Server).
+		Str("uri", sub.URI).
+		Msg("Resource subscription created")
+
+	copied := *sub
+	return &copied, nil
+}
+
+// Unsubscribe stops watching a resource.
+func (m *Manager) Unsubscribe(connID, subID uuid.UUID) error {
+	m.subMu.Lock()
+	defer m.subMu.Unlock()
+
+	subs := m.subscriptions[connID]
+	if _, ok := subs[subID]; !ok {
+		return ErrSubscriptionNotFound
+	}
+	delete(subs, subID)
+	if len(subs) == 0 {
+		delete(m.subscriptions, connID)
+	}
+	return nil
+}
+
+// ListSubscriptions returns a connection's resource subscriptions.
+func (m *Manager) ListSubscriptions(connID uuid.UUID) []ResourceSubscription {
+	m.subMu.Lock()
+	defer m.subMu.Unlock()
+
+	subs := make([]ResourceSubscription, 0, len(m.subscriptions[connID]))
+	for _, sub := range m.subscriptions[connID] {
+		subs = append(subs, *sub)
+	}
+	return subs
+}
+
+// removeSubscriptions drops every subscription held by a connection.
+func (m *Manager) removeSubscriptions(connID uuid.UUID) {
+	m.subMu.Lock()
+	defer m.subMu.Unlock()
+	delete(m.subscriptions, connID)
+}
+
+// pollLoop periodically re-reads subscribed resources until Shutdown.
+func (m *Manager) pollLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.PollResources(context.Background())
+		case <-m.stop:
+			return
+		}
+	}
+}
+
+// resourceKey identifies a resource across subscriptions. Servers are
+// looked up per organization.
+type resourceKey struct {
+	orgID  uuid.UUID
+	server string
+	uri    string
+}
+
+// pendingUpdate is a notification collected under subMu and sent after it is
+// released.
+type pendingUpdate struct {
+	connID uuid.UUID
+	update ResourceUpdate
+}
+
+// PollResources reads every subscribed resource once and notifies the
+// subscribers of each one whose content hash changed. A resource watched by
+// several connections is read only once per poll.
+func (m *Manager) PollResources(ctx context.Context) {
+	if m.resources == nil {
+		return
+	}
+
+	m.subMu.Lock()
+	keys := make(map[resourceKey]bool)
+	for _, subs := range m.subscriptions {
+		for _, sub := range subs {
+			keys[resourceKey{orgID: sub.orgID, server: sub.Server, uri: sub.URI}] = true
+		}
+	}
+	m.subMu.Unlock()
+
+	hashes := make(map[resourceKey]string, len(keys))
+	for key := range keys {
+		readCtx, cancel := context.WithTimeout(ctx, resourceReadTimeout)
+		content, err := m.resources.ReadResource(readCtx, key.orgID, key.server, key.uri)
+		cancel()
+		if err != nil {
+			m.logger.Warn().
+				Err(err).
+				Str("server", key.server).
+				Str("uri", key.uri).
+				Msg("Failed to poll subscribed resource")
+			continue
+		}
+		hashes[key] = hashContent(content)
+	}
+
+	now := time.Now()
+	var updates []pendingUpdate
+
+	m.subMu.Lock()
+	for connID, subs := range m.subscriptions {
+		for _, sub := range subs {
+			hash, ok := hashes[resourceKey{orgID: sub.orgID, server: sub.Server, uri: sub.URI}]
+			if !ok {
+				continue
+			}
+			sub.CheckedAt = now
+			if hash == sub.Hash {
+				continue
+			}
+			updates = append(updates, pendingUpdate{connID: connID, update: ResourceUpdate{
+				SubscriptionID: sub.ID,
+				Server:         sub.Server,
+				URI:            sub.URI,
+				Hash:           hash,
+				PreviousHash:   sub.Hash,
+				DetectedAt:     now,
+			}})
+			sub.Hash = hash
+		}
+	}
+	m.subMu.Unlock()
+
+	for _, u := range updates {
+		conn, exists := m.GetConnection(u.connID)
+		if !exists {
+			continue
+		}
+		m.send(conn, WSMessage{Type: WSTypeResourceUpdated, Payload: u.update})
+	}
+}
@@ -0,0 +1,119 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// mockResources serves resource contents like test/mock-mcp's resources/read,
+// letting a test change them between polls.
+type mockResources struct {
+	mu       sync.Mutex
+	contents map[string]string
+	reads    int
+}
+
+func (r *mockResources) ReadResource(ctx context.Context, orgID uuid.UUID, server, uri string) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reads++
+	content, ok := r.contents[server+"|"+uri]
+	if !ok {
+		return nil, errors.New("Resource not found: " + uri)
+	}
+	return []byte(`{"contents":[{"uri":"` + uri + `","text":"` + content + `"}]}`), nil
+}
+
+func (r *mockResources) set(server, uri, content string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.contents[server+"|"+uri] = content
+}
+
+// sent returns the messages queued for conn.
+func sent(t *testing.T, conn *Connection) []WSMessage {
+	t.Helper()
+	var msgs []WSMessage
+	for {
+		select {
+		case data := <-conn.sendCh:
+			var msg WSMessage
+			if err := json.Unmarshal(data, &msg); err != nil {
+				t.Fatalf("sent message: %v", err)
+			}
+			msgs = append(msgs, msg)
+		default:
+			return msgs
+		}
+	}
+}
+
+func TestResourceChangeNotifiesSubscribers(t *testing.T) {
+	resources := &mockResources{contents: map[string]string{"filesystem|file:///config.json": `v1`}}
+	m := NewManager(zerolog.Nop(), resources, 0, time.Minute, CostPolicy{})
+	ctx := context.Background()
+	orgID := uuid.New()
+
+	subscriber, err := m.Connect(ctx, ConnectRequest{Platform: "test", Transport: TransportWebSocket}, orgID, uuid.New())
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	other, err := m.Connect(ctx, ConnectRequest{Platform: "test", Transport: TransportWebSocket}, orgID, uuid.New())
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+
+	sub, err := m.Subscribe(ctx, subscriber.ID, SubscribeRequest{Server: "filesystem", URI: "file:///config.json"})
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if _, err := m.Subscribe(ctx, subscriber.ID, SubscribeRequest{Server: "filesystem", URI: "file:///missing"}); err == nil {
+		t.Error("subscribed to a resource that cannot be read")
+	}
+
+	// Unchanged content is not reported
+	m.PollResources(ctx)
+	if msgs := sent(t, subscriber); len(msgs) != 0 {
+		t.Fatalf("notified of unchanged content: %+v", msgs)
+	}
+
+	resources.set("filesystem", "file:///config.json", `v2`)
+	m.PollResources(ctx)
+	msgs := sent(t, subscriber)
+	if len(msgs) != 1 || msgs[0].Type != WSTypeResourceUpdated {
+		t.Fatalf("sent %+v, want one resource update", msgs)
+	}
+	payload, _ := json.Marshal(msgs[0].Payload)
+	var update ResourceUpdate
+	json.Unmarshal(payload, &update)
+	if update.SubscriptionID != sub.ID || update.URI != "file:///config.json" || update.PreviousHash != sub.Hash || update.Hash == sub.Hash {
+		t.Errorf("update = %+v, want the change from %s", update, sub.Hash)
+	}
+	if msgs := sent(t, other); len(msgs) != 0 {
+		t.Errorf("a connection without a subscription was notified: %+v", msgs)
+	}
+
+	// The new content is the baseline for the next poll
+	m.PollResources(ctx)
+	if msgs := sent(t, subscriber); len(msgs) != 0 {
+		t.Errorf("notified twice of one change: %+v", msgs)
+	}
+
+	// Disconnecting drops the subscriptions, so nothing is read
+	m.Disconnect(subscriber.ID)
+	if subs := m.ListSubscriptions(subscriber.ID); len(subs) != 0 {
+		t.Errorf("%d subscriptions left after disconnect", len(subs))
+	}
+	reads := resources.reads
+	m.PollResources(ctx)
+	if resources.reads != reads {
+		t.Errorf("polled %d resources with no subscribers", resources.reads-reads)
+	}
+}
@@ -0,0 +1,142 @@
+package agent
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog"
+)
+
+// serveWebSockets upgrades requests to /ws/{connection ID}, resuming with
+// the resume_token query parameter.
+func serveWebSockets(t *testing.T, m *Manager) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		connID, err := uuid.Parse(strings.TrimPrefix(r.URL.Path, "/ws/"))
+		if err != nil {
+			http.Error(w, "bad connection ID", http.StatusBadRequest)
+			return
+		}
+		switch err := m.UpgradeToWebSocket(w, r, connID, r.URL.Query().Get("resume_token")); err {
+		case nil:
+		case ErrConnectionNotFound:
+			http.Error(w, err.Error(), http.StatusNotFound)
+		default:
+			http.Error(w, err.Error(), http.StatusConflict)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func dial(t *testing.T, srv *httptest.Server, conn *Connection, token string) (*websocket.Conn, int) {
+	t.Helper()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws/" + conn.ID.String() + "?resume_token=" + token
+	ws, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		if resp == nil {
+			t.Fatalf("dial: %v", err)
+		}
+		return nil, resp.StatusCode
+	}
+	t.Cleanup(func() { ws.Close() })
+	return ws, resp.StatusCode
+}
+
+// waitForState waits until conn reaches state.
+func waitForState(t *testing.T, conn *Connection, state ConnectionState) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		conn.mu.Lock()
+		current := conn.State
+		conn.mu.Unlock()
+		if current == state {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("connection is %s, want %s", current, state)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func readMessage(t *testing.T, ws *websocket.Conn) WSMessage {
+	t.Helper()
+	ws.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var msg WSMessage
+	if err := ws.ReadJSON(&msg); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	return msg
+}
+
+func TestResultsSentDuringADisconnectArriveAfterResume(t *testing.T) {
+	m := NewManager(zerolog.Nop(), nil, 0, time.Minute, CostPolicy{})
+	t.Cleanup(func() { m.Shutdown(context.Background()) })
+	srv := serveWebSockets(t, m)
+	conn, err := m.Connect(context.Background(), ConnectRequest{Platform: "test", Transport: TransportWebSocket}, uuid.New(), uuid.New())
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+
+	ws, _ := dial(t, srv, conn, "")
+	waitForState(t, conn, StateConnected)
+	m.send(conn, WSMessage{Type: WSTypeToolResult, ID: "call-1"})
+	if msg := readMessage(t, ws); msg.ID != "call-1" {
+		t.Fatalf("got %+v, want call-1's result", msg)
+	}
+
+	// Drop the socket without a close frame, as a lost network would
+	ws.UnderlyingConn().Close()
+	waitForState(t, conn, StateSuspended)
+	m.send(conn, WSMessage{Type: WSTypeToolResult, ID: "call-2"})
+	m.send(conn, WSMessage{Type: WSTypeToolResult, ID: "call-3"})
+
+	if _, status := dial(t, srv, conn, "wrong-token"); status != http.StatusConflict {
+		t.Errorf("resume with a wrong token: status %d, want %d", status, http.StatusConflict)
+	}
+
+	ws, _ = dial(t, srv, conn, conn.resumeToken)
+	if msg := readMessage(t, ws); msg.Type != WSTypeResumed {
+		t.Fatalf("first message after resume = %+v, want resumed", msg)
+	}
+	for _, want := range []string{"call-2", "call-3"} {
+		if msg := readMessage(t, ws); msg.Type != WSTypeToolResult || msg.ID != want {
+			t.Errorf("got %+v, want %s's result", msg, want)
+		}
+	}
+	if _, ok := m.GetConnection(conn.ID); !ok {
+		t.Error("resumed connection was removed")
+	}
+}
+
+func TestSuspendedConnectionExpiresAfterTheGracePeriod(t *testing.T) {
+	m := NewManager(zerolog.Nop(), nil, 0, 50*time.Millisecond, CostPolicy{})
+	t.Cleanup(func() { m.Shutdown(context.Background()) })
+	srv := serveWebSockets(t, m)
+	conn, err := m.Connect(context.Background(), ConnectRequest{Platform: "test", Transport: TransportWebSocket}, uuid.New(), uuid.New())
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+
+	ws, _ := dial(t, srv, conn, "")
+	waitForState(t, conn, StateConnected)
+	ws.UnderlyingConn().Close()
+	waitForState(t, conn, StateSuspended)
+	m.send(conn, WSMessage{Type: WSTypeToolResult, ID: "call-1"})
+
+	waitForState(t, conn, StateDisconnected)
+	if _, ok := m.GetConnection(conn.ID); ok {
+		t.Error("expired connection is still registered")
+	}
+	if _, status := dial(t, srv, conn, conn.resumeToken); status != http.StatusNotFound {
+		t.Errorf("resume after expiry: status %d, want %d", status, http.StatusNotFound)
+	}
+}
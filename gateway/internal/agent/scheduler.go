@@ -0,0 +1,131 @@
+package agent
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/clock"
+)
+
+// Scheduler hands out a fixed number of tool execution slots. When every
+// slot is taken, calls wait and freed slots go to the waiting call with the
+// highest priority, earliest first among equals. A waiting call's priority
+// rises by one for each aging interval it has waited, so low-priority calls
+// are not starved by a steady stream of higher-priority ones. It is safe for
+// concurrent use.
+type Scheduler struct {
+	slots int
+	aging time.Duration
+	clock clock.Clock
+
+	mu      sync.Mutex
+	inUse   int
+	waiting []*slotWaiter
+	seq     uint64
+}
+
+// slotWaiter is a call waiting for a slot. ready is closed once the slot is
+// handed to it.
+type slotWaiter struct {
+	priority int
+	queuedAt time.Time
+	seq      uint64
+	ready    chan struct{}
+}
+
+// NewScheduler creates a scheduler with slots execution slots; with 0 calls
+// never wait. Waiting calls gain a priority level every aging interval; 0
+// disables aging.
+func NewScheduler(slots int, aging time.Duration, clk clock.Clock) *Scheduler {
+	return &Scheduler{slots: slots, aging: aging, clock: clk}
+}
+
+// Acquire waits for an execution slot for a call of the given priority,
+// higher running first. The returned func frees the slot and must be called
+// once the call finishes. It returns ctx's error if ctx is done first.
+func (s *Scheduler) Acquire(ctx context.Context, priority int) (func(), error) {
+	if s == nil || s.slots <= 0 {
+		return func() {}, nil
+	}
+
+	s.mu.Lock()
+	if s.inUse < s.slots && len(s.waiting) == 0 {
+		s.inUse++
+		s.mu.Unlock()
+		return s.releaser(), nil
+	}
+	s.seq++
+	w := &slotWaiter{priority: priority, queuedAt: s.clock.Now(), seq: s.seq, ready: make(chan struct{})}
+	s.waiting = append(s.waiting, w)
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return s.releaser(), nil
+	case <-ctx.Done():
+	}
+
+	s.mu.Lock()
+	select {
+	case <-w.ready:
+		// The slot was handed over as ctx ended; pass it on
+		s.mu.Unlock()
+		s.release()
+	default:
+		for i, waiting := range s.waiting {
+			if waiting == w {
+				s.waiting = append(s.waiting[:i], s.waiting[i+1:]...)
+				break
+			}
+		}
+		s.mu.Unlock()
+	}
+	return nil, ctx.Err()
+}
+
+// releaser returns a func that frees a slot the first time it is called.
+func (s *Scheduler) releaser() func() {
+	var once sync.Once
+	return func() { once.Do(s.release) }
+}
+
+// release hands a freed slot to the next waiting call, or returns it to the
+// pool when none is waiting.
+func (s *Scheduler) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.waiting) == 0 {
+		s.inUse--
+		return
+	}
+	now := s.clock.Now()
+	next := 0
+	for i, w := range s.waiting[1:] {
+		if s.before(w, s.waiting[next], now) {
+			next = i + 1
+		}
+	}
+	w := s.waiting[next]
+	s.waiting = append(s.waiting[:next], s.waiting[next+1:]...)
+	close(w.ready)
+}
+
+// before reports whether a should be given a slot before b.
+func (s *Scheduler) before(a, b *slotWaiter, now time.Time) bool {
+	pa, pb := s.effectivePriority(a, now), s.effectivePriority(b, now)
+	if pa != pb {
+		return pa > pb
+	}
+	return a.seq < b.seq
+}
+
+// effectivePriority returns a waiting call's priority raised by the aging
+// intervals it has waited.
+func (s *Scheduler) effectivePriority(w *slotWaiter, now time.Time) int {
+	if s.aging <= 0 {
+		return w.priority
+	}
+	return w.priority + int(now.Sub(w.queuedAt)/s.aging)
+}
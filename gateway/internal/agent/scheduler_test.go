@@ -0,0 +1,138 @@
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/clock"
+)
+
+// enqueue starts a call of the given priority waiting on s, and waits until
+// it is queued. The call's name is sent on order once it gets a slot, and
+// it then waits for done before releasing the slot.
+func enqueue(t *testing.T, s *Scheduler, name string, priority int, order chan<- string, done <-chan struct{}) {
+	t.Helper()
+	s.mu.Lock()
+	queued := len(s.waiting)
+	s.mu.Unlock()
+
+	go func() {
+		release, err := s.Acquire(context.Background(), priority)
+		if err != nil {
+			t.Errorf("%s: Acquire: %v", name, err)
+			return
+		}
+		order <- name
+		<-done
+		release()
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		s.mu.Lock()
+		n := len(s.waiting)
+		s.mu.Unlock()
+		if n > queued {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s was not queued", name)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// drain releases the held slot and returns the order the waiting calls ran
+// in, each running alone.
+func drain(t *testing.T, release func(), order <-chan string, done chan<- struct{}, n int) []string {
+	t.Helper()
+	release()
+	var got []string
+	for i := 0; i < n; i++ {
+		select {
+		case name := <-order:
+			got = append(got, name)
+			done <- struct{}{}
+		case <-time.After(time.Second):
+			t.Fatalf("only %v ran", got)
+		}
+	}
+	return got
+}
+
+func TestSchedulerRunsHighestPriorityFirst(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	s := NewScheduler(1, 0, clk)
+	release, err := s.Acquire(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+
+	order := make(chan string)
+	done := make(chan struct{})
+	enqueue(t, s, "low", -1, order, done)
+	enqueue(t, s, "default-1", 0, order, done)
+	enqueue(t, s, "high", 5, order, done)
+	enqueue(t, s, "default-2", 0, order, done)
+
+	got := drain(t, release, order, done, 4)
+	want := []string{"high", "default-1", "default-2", "low"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("ran in order %v, want %v", got, want)
+		}
+	}
+}
+
+func TestSchedulerAgingPreventsStarvation(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	s := NewScheduler(1, 5*time.Second, clk)
+	release, err := s.Acquire(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+
+	order := make(chan string)
+	done := make(chan struct{})
+	enqueue(t, s, "background", 0, order, done)
+
+	// After waiting 20s the background call has aged 4 levels, past a fresh
+	// call of priority 3 but not one of 5
+	clk.Advance(20 * time.Second)
+	enqueue(t, s, "urgent", 5, order, done)
+	enqueue(t, s, "fresh", 3, order, done)
+
+	got := drain(t, release, order, done, 3)
+	want := []string{"urgent", "background", "fresh"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("ran in order %v, want %v", got, want)
+		}
+	}
+}
+
+func TestSchedulerAcquireCancelled(t *testing.T) {
+	s := NewScheduler(1, 0, clock.Real)
+	release, err := s.Acquire(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := s.Acquire(ctx, 10); err != context.DeadlineExceeded {
+		t.Fatalf("Acquire with a full scheduler: err = %v, want DeadlineExceeded", err)
+	}
+
+	// The cancelled call gave up its place, so the slot returns to the pool
+	release()
+	next, err := s.Acquire(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("Acquire after release: %v", err)
+	}
+	next()
+	if s.inUse != 0 || len(s.waiting) != 0 {
+		t.Errorf("inUse = %d, waiting = %d after every slot was released", s.inUse, len(s.waiting))
+	}
+}
@@ -2,9 +2,11 @@
 package agent
 
 import (
+	"context"
 	"sync"
 	"time"
 
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 )
@@ -16,6 +18,7 @@ const (
 	StateConnecting  ConnectionState = "connecting"
 	StateConnected   ConnectionState = "connected"
 	StateDisconnected ConnectionState = "disconnected"
+	StateSuspended    ConnectionState = "suspended" // WebSocket dropped; resumable within the grace period
 )
 
 // Transport represents the connection transport type.
@@ -47,6 +50,50 @@ type Connection struct {
 	mu     sync.Mutex
 	sendCh chan []byte
 	done   chan struct{}
+
+	// Resumption after the WebSocket drops
+	resumeToken string
+	wsDone      chan struct{} // Closed when the current WebSocket is detached
+	unsent      [][]byte      // Taken from sendCh but not delivered; sent first on resume
+	graceTimer  *time.Timer   // Disconnects a suspended connection when it fires
+
+	// Spending on tool calls, guarded by mu. Calls still running hold their
+	// estimated cost in reserve.
+	costCap      float64            // USD; 0 for no cap
+	costSpent    float64            // USD
+	costReserved float64            // USD
+	toolSpent    map[string]float64 // USD by "server.tool"
+	toolReserved map[string]float64 // USD by "server.tool"
+
+	// Context of tool calls made over the WebSocket, carrying the principal
+	// that upgraded it; guarded by mu
+	callCtx context.Context
+}
+
+// Outbox returns the messages queued for the connection. For connections
+// without a WebSocket it is drained by an SSE event stream instead.
+func (c *Connection) Outbox() <-chan []byte {
+	return c.sendCh
+}
+
+// HasWebSocket reports whether the connection has been upgraded to a
+// WebSocket, which then owns the outbox. A suspended connection keeps its
+// outbox for the WebSocket that resumes it.
+func (c *Connection) HasWebSocket() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ws != nil || c.State == StateSuspended
+}
+
+// ResumeToken returns the secret a client presents to resume the connection
+// after its WebSocket drops.
+func (c *Connection) ResumeToken() string {
+	return c.resumeToken
+}
+
+// Done is closed when the connection is disconnected.
+func (c *Connection) Done() <-chan struct{} {
+	return c.done
 }
 
 // ConnectRequest represents a request to establish an agent connection.
@@ -57,22 +104,40 @@ type ConnectRequest struct {
 	Transport    Transport      `json:"transport"`
 	CallbackURL  string         `json:"callback_url,omitempty"`
 	Metadata     map[string]any `json:"metadata,omitempty"`
+	CostCapUSD   float64        `json:"cost_cap_usd,omitempty"` // Lowers the gateway's per-connection cost cap
 }
 
 // ConnectResponse represents the response to a connection request.
 type ConnectResponse struct {
-	ConnectionID     uuid.UUID        `json:"connection_id"`
-	GatewayURL       string           `json:"gateway_url"`
-	AvailableServers []ServerInfo     `json:"available_servers"`
-	RateLimits       RateLimitInfo    `json:"rate_limits"`
+	ConnectionID     uuid.UUID     `json:"connection_id"`
+	ResumeToken      string        `json:"resume_token"` // Resumes the connection after its WebSocket drops
+	GatewayURL       string        `json:"gateway_url"`
+	AvailableServers []ServerInfo  `json:"available_servers"` // Only the servers the principal may use
+	RateLimits       RateLimitInfo `json:"rate_limits"`
+	Budgets          []BudgetInfo  `json:"budgets"` // Spend caps covering the principal's calls
 }
 
-// ServerInfo provides information about an available MCP server.
+// ResumedPayload is sent first on a resumed WebSocket, followed by the
+// messages buffered while it was down.
+type ResumedPayload struct {
+	ConnectionID uuid.UUID `json:"connection_id"`
+	Buffered     int       `json:"buffered"`
+}
+
+// ServerInfo provides information about an available MCP server and the
+// tools on it the principal may call.
 type ServerInfo struct {
-	Name        string `json:"name"`
-	Description string `json:"description,omitempty"`
-	ToolCount   int    `json:"tools"`
-	ResourceCount int  `json:"resources"`
+	Name             string     `json:"name"`
+	ToolCount        int        `json:"tools"`
+	Tools            []ToolInfo `json:"available_tools"`
+	ToolsUnavailable bool       `json:"tools_unavailable,omitempty"` // The server's tool list could not be fetched
+}
+
+// ToolInfo describes a tool the principal may call.
+type ToolInfo struct {
+	Name             string               `json:"name"`
+	Classification   domain.ToolRiskLevel `json:"classification"`
+	RequiresApproval bool                 `json:"requires_approval"` // Calls wait for approval; false once granted
 }
 
 // RateLimitInfo provides rate limit configuration.
@@ -81,12 +146,23 @@ type RateLimitInfo struct {
 	TokensPerMinute   int `json:"tokens_per_minute,omitempty"`
 }
 
+// BudgetInfo describes a budget covering the principal's calls.
+type BudgetInfo struct {
+	ID       uuid.UUID           `json:"id"`
+	Name     string              `json:"name"`
+	Period   domain.BudgetPeriod `json:"period"`
+	LimitUSD float64             `json:"limit_usd"`
+	SpentUSD float64             `json:"spent_usd"`
+}
+
 // ToolCall represents a single tool call in a batch.
 type ToolCall struct {
 	ID        string         `json:"id"`
 	Server    string         `json:"server"`
 	Tool      string         `json:"tool"`
 	Arguments map[string]any `json:"arguments"`
+	TimeoutMs int            `json:"timeout_ms,omitempty"` // Overrides the tool's default timeout
+	Priority  int            `json:"priority,omitempty"`   // Higher runs first when execution slots are scarce; overrides the batch's
 }
 
 // ExecuteRequest represents a batch tool execution request.
@@ -95,6 +171,8 @@ type ExecuteRequest struct {
 	Calls         []ToolCall `json:"calls"`
 	ExecutionMode string     `json:"execution_mode"` // "parallel" or "sequential"
 	TimeoutMs     int        `json:"timeout_ms,omitempty"`
+	Priority      int        `json:"priority,omitempty"` // Priority of calls that set none
+	DryRun        bool       `json:"dry_run,omitempty"`
 }
 
 // ToolResult represents the result of a single tool call.
@@ -115,11 +193,52 @@ type ContentBlock struct {
 	URI  string `json:"uri,omitempty"`
 }
 
-// ErrorInfo provides error details.
+// ErrorInfo provides error details. Errors from tool calls carry a category
+// and whether retrying the call unchanged may succeed; calls the MCP server
+// answered with an error also carry its status and message.
 type ErrorInfo struct {
-	Code    string `json:"code"`
-	Message string `json:"message"`
-	Details any    `json:"details,omitempty"`
+	Code            string        `json:"code"`
+	Message         string        `json:"message"`
+	Category        ErrorCategory `json:"category,omitempty"`
+	Retriable       bool          `json:"retriable"`
+	UpstreamStatus  int           `json:"upstream_status,omitempty"`
+	UpstreamMessage string        `json:"upstream_message,omitempty"`
+	Details         any           `json:"details,omitempty"`
+}
+
+// ErrorCategory classifies why a tool call failed.
+type ErrorCategory string
+
+const (
+	ErrorDeniedByPolicy   ErrorCategory = "denied_by_policy"  // Needs a permission or approval
+	ErrorInjectionBlocked ErrorCategory = "injection_blocked" // Blocked by prompt injection detection
+	ErrorUpstreamTimeout  ErrorCategory = "upstream_timeout"  // The MCP server did not answer in time
+	ErrorUpstreamError    ErrorCategory = "upstream_error"    // The MCP server failed or could not be reached
+	ErrorValidation       ErrorCategory = "validation_error"  // The call itself is malformed
+	ErrorRateLimited      ErrorCategory = "rate_limited"
+	ErrorBudgetExceeded   ErrorCategory = "budget_exceeded"
+	ErrorLoopDetected     ErrorCategory = "loop_detected" // The same call was repeated too often
+)
+
+// Retriable reports whether a call that failed with the category may succeed
+// if retried unchanged, possibly after a delay.
+func (c ErrorCategory) Retriable() bool {
+	switch c {
+	case ErrorUpstreamTimeout, ErrorUpstreamError, ErrorRateLimited:
+		return true
+	default:
+		return false
+	}
+}
+
+// NewToolError returns the error for a tool call that failed with category.
+func NewToolError(category ErrorCategory, message string) *ErrorInfo {
+	return &ErrorInfo{
+		Code:      string(category),
+		Message:   message,
+		Category:  category,
+		Retriable: category.Retriable(),
+	}
 }
 
 // ExecuteResponse represents the response to a batch execution request.
@@ -129,6 +248,13 @@ type ExecuteResponse struct {
 	TotalCost float64      `json:"total_cost"`
 }
 
+// DryRunResponse represents the response to a dry-run execution request.
+type DryRunResponse struct {
+	DryRun             bool                      `json:"dry_run"`
+	Decisions          []domain.ToolCallDecision `json:"decisions"`
+	EstimatedTotalCost float64                   `json:"estimated_total_cost"`
+}
+
 // WSMessage represents a WebSocket message.
 type WSMessage struct {
 	Type    string `json:"type"`
@@ -145,6 +271,15 @@ const (
 	WSTypeCancel     = "cancel"
 	WSTypePing       = "ping"
 	WSTypePong       = "pong"
+	WSTypeResumed    = "resumed"
+
+	WSTypeCostCapReached = "cost_cap_reached"
+
+	WSTypeResourceSubscribe    = "resource_subscribe"
+	WSTypeResourceSubscribed   = "resource_subscribed"
+	WSTypeResourceUnsubscribe  = "resource_unsubscribe"
+	WSTypeResourceUnsubscribed = "resource_unsubscribed"
+	WSTypeResourceUpdated      = "resource_updated"
 )
 
 // ProgressPayload represents progress update data.
@@ -0,0 +1,326 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/clock"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/google/uuid"
+)
+
+// hangingWebhook returns a webhook endpoint that holds every request until
+// the client gives up, and a channel that receives each request as it
+// arrives. The body is read first, since the server only notices the client
+// going away once it has.
+func hangingWebhook(t *testing.T) (string, <-chan struct{}) {
+	t.Helper()
+	arrived := make(chan struct{}, 16)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		arrived <- struct{}{}
+		<-r.Context().Done()
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL, arrived
+}
+
+func webhookChannel(s *Service, orgID uuid.UUID, url string, grouping domain.AlertGrouping) *domain.AlertChannel {
+	return s.CreateChannel(domain.AlertChannelInput{
+		Name:     "hook",
+		Type:     domain.AlertChannelWebhook,
+		Config:   map[string]interface{}{"url": url},
+		Grouping: grouping,
+		Enabled:  true,
+	}, orgID)
+}
+
+func TestDeliverSendsToChannelsConcurrently(t *testing.T) {
+	s := newTestService(t, clock.Real)
+	orgID := uuid.New()
+
+	// Each request is answered only once every channel's request has
+	// arrived, so sending one channel at a time would never finish
+	const channels = 3
+	var mu sync.Mutex
+	waiting := 0
+	all := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		mu.Lock()
+		waiting++
+		if waiting == channels {
+			close(all)
+		}
+		mu.Unlock()
+		select {
+		case <-all:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+
+	rule := domain.AlertRule{ID: uuid.New(), OrgID: orgID, Name: "errors"}
+	for i := 0; i < channels; i++ {
+		rule.Channels = append(rule.Channels, webhookChannel(s, orgID, srv.URL, domain.AlertGrouping{}).ID)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	deliveries := s.deliver(ctx, domain.Alert{ID: uuid.New(), OrgID: orgID}, rule)
+	for i, d := range deliveries {
+		if !d.Success {
+			t.Errorf("channel %d: %+v", i, d)
+		}
+	}
+}
+
+func TestDeliverGivesUpWhenContextEnds(t *testing.T) {
+	s := newTestService(t, clock.Real)
+	orgID := uuid.New()
+	url, _ := hangingWebhook(t)
+	channel := webhookChannel(s, orgID, url, domain.AlertGrouping{})
+	rule := domain.AlertRule{ID: uuid.New(), OrgID: orgID, Name: "errors", Channels: []uuid.UUID{channel.ID}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	deliveries := s.deliver(ctx, domain.Alert{ID: uuid.New(), OrgID: orgID}, rule)
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("delivery to a hanging channel took %s", elapsed)
+	}
+	if d := deliveries[0]; d.Success || d.Error == "" {
+		t.Errorf("delivery = %+v, want a timeout error", d)
+	}
+}
+
+func TestSendGroupStopsWithService(t *testing.T) {
+	s := newTestService(t, clock.Real)
+	orgID := uuid.New()
+	url, arrived := hangingWebhook(t)
+	channel := webhookChannel(s, orgID, url, domain.AlertGrouping{GroupWaitSeconds: 60})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.sendGroup(&alertGroup{
+			channelID: channel.ID,
+			alerts:    []groupedAlert{{alert: domain.Alert{ID: uuid.New(), OrgID: orgID}, ruleName: "errors"}},
+		})
+	}()
+
+	select {
+	case <-arrived:
+	case <-time.After(time.Second):
+		t.Fatal("grouped notification was not sent")
+	}
+	s.cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("grouped notification kept waiting after the service was stopped")
+	}
+}
+
+func TestTestFireRuleReportsEachChannel(t *testing.T) {
+	s := newTestService(t, clock.Real)
+	orgID := uuid.New()
+
+	var mu sync.Mutex
+	var messages []string
+	endpoint := func(status int) string {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var payload struct {
+				Message string `json:"message"`
+			}
+			json.NewDecoder(r.Body).Decode(&payload)
+			mu.Lock()
+			messages = append(messages, payload.Message)
+			mu.Unlock()
+			w.WriteHeader(status)
+		}))
+		t.Cleanup(srv.Close)
+		return srv.URL
+	}
+	ok := webhookChannel(s, orgID, endpoint(http.StatusOK), domain.AlertGrouping{})
+	failing := webhookChannel(s, orgID, endpoint(http.StatusInternalServerError), domain.AlertGrouping{})
+	disabled := webhookChannel(s, orgID, endpoint(http.StatusOK), domain.AlertGrouping{})
+	s.UpdateChannel(orgID, disabled.ID, domain.AlertChannelInput{Name: "hook", Type: domain.AlertChannelWebhook, Config: disabled.Config})
+	missing := uuid.New()
+
+	rule := s.CreateRule(domain.AlertRuleInput{
+		Name:          "errors",
+		Metric:        domain.AlertMetricErrorRate,
+		Condition:     domain.AlertConditionGreaterThan,
+		Threshold:     5,
+		WindowMinutes: 5,
+		Severity:      domain.AlertSeverityCritical,
+		Channels:      []uuid.UUID{ok.ID, failing.ID, disabled.ID, missing},
+		Enabled:       true,
+	}, orgID, uuid.New())
+
+	result, err := s.TestFireRule(context.Background(), orgID, rule.ID)
+	if err != nil {
+		t.Fatalf("TestFireRule: %v", err)
+	}
+	if len(result.Deliveries) != 4 {
+		t.Fatalf("%d deliveries, want one per channel", len(result.Deliveries))
+	}
+	for i, want := range []struct {
+		id      uuid.UUID
+		success bool
+		skipped bool
+	}{
+		{ok.ID, true, false},
+		{failing.ID, false, false},
+		{disabled.ID, false, true},
+		{missing, false, true},
+	} {
+		d := result.Deliveries[i]
+		if d.ChannelID != want.id || d.Success != want.success || d.Skipped != want.skipped || (d.Error == "") != want.success {
+			t.Errorf("delivery %d = %+v, want success %v skipped %v", i, d, want.success, want.skipped)
+		}
+	}
+	if result.Delivered != 1 || result.Failed != 1 {
+		t.Errorf("delivered %d, failed %d, want 1 and 1", result.Delivered, result.Failed)
+	}
+	if !strings.Contains(result.Deliveries[1].Error, "500") {
+		t.Errorf("failing channel error = %q, want the status", result.Deliveries[1].Error)
+	}
+
+	mu.Lock()
+	if len(messages) != 2 || !strings.HasPrefix(messages[0], "[TEST] ") {
+		t.Errorf("sent %q, want the test alert to the two enabled channels", messages)
+	}
+	mu.Unlock()
+	if result.Alert.Labels["test"] != "true" {
+		t.Errorf("alert labels = %v, want it marked as a test", result.Alert.Labels)
+	}
+	if page := s.GetAlerts(domain.AlertFilter{OrgID: orgID}); page.Total != 0 {
+		t.Errorf("test fire recorded %d alerts", page.Total)
+	}
+
+	if _, err := s.TestFireRule(context.Background(), uuid.New(), rule.ID); err == nil {
+		t.Error("another org test-fired the rule")
+	}
+}
+
+// redirectTransport sends every request to target, whatever its URL.
+type redirectTransport struct {
+	target *url.URL
+}
+
+func (t redirectTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.URL.Scheme, r.URL.Host = t.target.Scheme, t.target.Host
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+// pagerDutyEvent is the part of a PagerDuty Events API request the tests
+// check.
+type pagerDutyEvent struct {
+	RoutingKey  string `json:"routing_key"`
+	EventAction string `json:"event_action"`
+	DedupKey    string `json:"dedup_key"`
+}
+
+func TestResolveAlertResolvesPagerDutyIncident(t *testing.T) {
+	s := newTestService(t, clock.Real)
+	orgID := uuid.New()
+
+	// The Events API rejects triggers for the failing routing key
+	events := make(chan pagerDutyEvent, 16)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event pagerDutyEvent
+		json.NewDecoder(r.Body).Decode(&event)
+		events <- event
+		if event.RoutingKey == "failing-key" && event.EventAction == "trigger" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(srv.Close)
+	target, _ := url.Parse(srv.URL)
+	s.client.Transport = redirectTransport{target: target}
+
+	channel := func(routingKey string) uuid.UUID {
+		return s.CreateChannel(domain.AlertChannelInput{
+			Name:    routingKey,
+			Type:    domain.AlertChannelPagerDuty,
+			Config:  map[string]interface{}{"routing_key": routingKey},
+			Enabled: true,
+		}, orgID).ID
+	}
+	rule := s.CreateRule(domain.AlertRuleInput{
+		Name:          "errors",
+		Metric:        domain.AlertMetricErrorRate,
+		Condition:     domain.AlertConditionGreaterThan,
+		Threshold:     5,
+		WindowMinutes: 5,
+		Severity:      domain.AlertSeverityCritical,
+		Channels:      []uuid.UUID{channel("routing-key"), channel("failing-key")},
+		Enabled:       true,
+	}, orgID, uuid.New())
+
+	next := func(what string) pagerDutyEvent {
+		t.Helper()
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no %s event sent", what)
+			return pagerDutyEvent{}
+		}
+	}
+
+	alert := s.EvaluateRule(*rule, 50)
+	if alert == nil {
+		t.Fatal("rule did not fire")
+	}
+	triggers := map[string]pagerDutyEvent{}
+	for i := 0; i < 2; i++ {
+		event := next("trigger")
+		triggers[event.RoutingKey] = event
+	}
+	if event := triggers["routing-key"]; event.EventAction != "trigger" || event.DedupKey != alert.ID.String() {
+		t.Fatalf("trigger = %+v, want the alert ID as dedup key", event)
+	}
+
+	// The incident is tracked once the trigger has been accepted
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		s.mu.RLock()
+		tracked := len(s.incidents[alert.ID])
+		s.mu.RUnlock()
+		if tracked == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d incidents tracked, want the accepted trigger's", tracked)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	s.ResolveAlert(orgID, alert.ID, domain.SystemActor())
+	resolve := next("resolve")
+	want := pagerDutyEvent{RoutingKey: "routing-key", EventAction: "resolve", DedupKey: alert.ID.String()}
+	if resolve != want {
+		t.Errorf("resolve = %+v, want %+v", resolve, want)
+	}
+
+	// Nothing is resolved for the channel whose trigger failed
+	select {
+	case event := <-events:
+		t.Errorf("unexpected event %+v", event)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
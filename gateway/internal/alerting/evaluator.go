@@ -0,0 +1,127 @@
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+)
+
+const (
+	// evaluationInterval is how often enabled rules are evaluated.
+	evaluationInterval = 30 * time.Second
+	// pruneInterval is how often expired call samples are removed.
+	pruneInterval = time.Hour
+)
+
+// evaluationLoop periodically evaluates every enabled rule against the
+// recorded call samples until the service is shut down.
+func (s *Service) evaluationLoop() {
+	ticker := time.NewTicker(evaluationInterval)
+	defer ticker.Stop()
+
+	lastPrune := s.clock.Now()
+	for {
+		select {
+		case <-ticker.C:
+			s.EvaluateRules()
+			if s.clock.Now().Sub(lastPrune) >= pruneInterval {
+				s.pruneSamples()
+				lastPrune = s.clock.Now()
+			}
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// Shutdown stops the background rule evaluator, sends the alert groups still
+// waiting, and cancels deliveries still in flight.
+func (s *Service) Shutdown(ctx context.Context) error {
+	s.stopOnce.Do(func() { close(s.stop) })
+	s.flushGroups()
+	s.cancel()
+	return nil
+}
+
+// EvaluateRules computes each enabled rule's metric over its window and
+// evaluates it. Rules without data in their window are skipped.
+func (s *Service) EvaluateRules() {
+	for _, rule := range s.allRules() {
+		if !rule.Enabled {
+			continue
+		}
+
+		window := time.Duration(rule.WindowMinutes) * time.Minute
+		if window <= 0 {
+			window = 5 * time.Minute
+		}
+
+		value, ok := s.ComputeMetric(domain.MetricQuery{
+			OrgID:   rule.OrgID,
+			Metric:  rule.Metric,
+			Window:  window,
+			Filters: rule.Filters,
+		})
+		if !ok {
+			continue
+		}
+		s.EvaluateRule(rule, value)
+	}
+}
+
+// EvaluateRule fires an alert when the value breaches the rule's condition
+// and no alert is already open for the rule, and resolves the open alert once
+// the value recovers. It returns the alert that was fired, if any.
+func (s *Service) EvaluateRule(rule domain.AlertRule, value float64) *domain.Alert {
+	open := s.openAlertForRule(rule)
+
+	if !conditionMet(rule.Condition, value, rule.Threshold) {
+		if open != nil {
+			s.ResolveAlert(open.OrgID, open.ID, domain.SystemActor())
+		}
+		return nil
+	}
+	if open != nil {
+		return nil
+	}
+
+	message := fmt.Sprintf("%s: %s is %.4g (%s %.4g over %dm)",
+		rule.Name, rule.Metric, value, rule.Condition, rule.Threshold, rule.WindowMinutes)
+	return s.CreateAlert(rule.ID, value, message)
+}
+
+// openAlertForRule returns the firing or acknowledged alert for a rule.
+func (s *Service) openAlertForRule(rule domain.AlertRule) *domain.Alert {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for i := len(s.alerts) - 1; i >= 0; i-- {
+		alert := s.alerts[i]
+		if alert.RuleID == rule.ID && alert.Status != domain.AlertStatusResolved {
+			return &alert
+		}
+	}
+	return nil
+}
+
+// conditionMet reports whether value satisfies the condition against threshold.
+func conditionMet(condition domain.AlertCondition, value, threshold float64) bool {
+	switch condition {
+	case domain.AlertConditionGreaterThan:
+		return value > threshold
+	case domain.AlertConditionGreaterThanEqual:
+		return value >= threshold
+	case domain.AlertConditionLessThan:
+		return value < threshold
+	case domain.AlertConditionLessThanEqual:
+		return value <= threshold
+	case domain.AlertConditionEqual:
+		return value == threshold
+	case domain.AlertConditionNotEqual:
+		return value != threshold
+	default:
+		return false
+	}
+}
@@ -0,0 +1,211 @@
+package alerting
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/clock"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+func newTestService(t *testing.T, clk clock.Clock) *Service {
+	t.Helper()
+	s := NewService(zerolog.Nop(), nil, nil, 100, clk)
+	t.Cleanup(func() { s.Shutdown(context.Background()) })
+	return s
+}
+
+func TestEvaluateRulesUsesClockForWindow(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	s := newTestService(t, clk)
+	orgID := uuid.New()
+
+	rule := s.CreateRule(domain.AlertRuleInput{
+		Name:          "errors",
+		Metric:        domain.AlertMetricErrorRate,
+		Condition:     domain.AlertConditionGreaterThan,
+		Threshold:     50,
+		WindowMinutes: 5,
+		Severity:      domain.AlertSeverityWarning,
+		Enabled:       true,
+	}, orgID, uuid.New())
+
+	for i := 0; i < 3; i++ {
+		s.RecordCall(domain.CallSample{OrgID: orgID, MCPServer: "filesystem", IsError: true})
+	}
+	s.EvaluateRules()
+	if s.openAlertForRule(*rule) == nil {
+		t.Fatal("error rate of 100% did not fire the rule")
+	}
+
+	// Once the failures leave the window, a successful call brings the
+	// error rate down and resolves the alert
+	clk.Advance(6 * time.Minute)
+	s.RecordCall(domain.CallSample{OrgID: orgID, MCPServer: "filesystem"})
+	s.EvaluateRules()
+	if open := s.openAlertForRule(*rule); open != nil {
+		t.Errorf("alert still %s after the failures left the window", open.Status)
+	}
+}
+
+func TestPruneSamplesUsesClock(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	s := newTestService(t, clk)
+	orgID := uuid.New()
+
+	s.RecordCall(domain.CallSample{OrgID: orgID, MCPServer: "filesystem"})
+	clk.Advance(maxMetricWindow)
+	s.RecordCall(domain.CallSample{OrgID: orgID, MCPServer: "filesystem"})
+	clk.Advance(time.Second)
+	s.pruneSamples()
+
+	s.samplesMu.RLock()
+	defer s.samplesMu.RUnlock()
+	if len(s.samples) != 1 {
+		t.Errorf("%d samples kept, want only the one inside the window", len(s.samples))
+	}
+}
+
+func TestErrorRateSpikeFiresTheMatchingRule(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	s := newTestService(t, clk)
+	orgID, otherOrg, userID := uuid.New(), uuid.New(), uuid.New()
+
+	rule := func(org uuid.UUID, name string, metric domain.AlertMetric, threshold float64, enabled bool) *domain.AlertRule {
+		return s.CreateRule(domain.AlertRuleInput{
+			Name:          name,
+			Metric:        metric,
+			Condition:     domain.AlertConditionGreaterThan,
+			Threshold:     threshold,
+			WindowMinutes: 5,
+			Severity:      domain.AlertSeverityCritical,
+			Enabled:       enabled,
+		}, org, userID)
+	}
+	errorRate := rule(orgID, "error rate", domain.AlertMetricErrorRate, 10, true)
+	disabled := rule(orgID, "disabled error rate", domain.AlertMetricErrorRate, 10, false)
+	latency := rule(orgID, "latency", domain.AlertMetricLatencyP99, 1000, true)
+	otherOrgErrors := rule(otherOrg, "other org error rate", domain.AlertMetricErrorRate, 10, true)
+
+	// A healthy baseline, older than the window, then a spike: 4 of the
+	// last 10 calls fail
+	for i := 0; i < 20; i++ {
+		s.RecordCall(domain.CallSample{OrgID: orgID, MCPServer: "filesystem", DurationMs: 100})
+	}
+	s.RecordCall(domain.CallSample{OrgID: otherOrg, MCPServer: "filesystem", DurationMs: 100})
+	s.EvaluateRules()
+	if open := s.openAlertForRule(*errorRate); open != nil {
+		t.Fatalf("healthy baseline fired %s", open.Message)
+	}
+
+	clk.Advance(10 * time.Minute)
+	for i := 0; i < 10; i++ {
+		s.RecordCall(domain.CallSample{OrgID: orgID, MCPServer: "filesystem", IsError: i%5 < 2, DurationMs: 100})
+	}
+	s.RecordCall(domain.CallSample{OrgID: otherOrg, MCPServer: "filesystem", DurationMs: 100})
+
+	if got, ok := s.ComputeMetric(domain.MetricQuery{OrgID: orgID, Metric: domain.AlertMetricErrorRate, Window: 5 * time.Minute}); !ok || got != 40 {
+		t.Fatalf("error rate = %v (%v), want 40%% over the window", got, ok)
+	}
+	s.EvaluateRules()
+
+	open := s.openAlertForRule(*errorRate)
+	if open == nil {
+		t.Fatal("error rate spike did not fire the rule")
+	}
+	if open.Value != 40 || open.Severity != domain.AlertSeverityCritical || open.OrgID != orgID {
+		t.Errorf("fired alert = %+v, want the rule's org, severity and the 40%% error rate", open)
+	}
+	for _, r := range []*domain.AlertRule{disabled, latency, otherOrgErrors} {
+		if open := s.openAlertForRule(*r); open != nil {
+			t.Errorf("rule %q fired on the spike", r.Name)
+		}
+	}
+
+	// The spike keeps the one alert open rather than firing again
+	s.EvaluateRules()
+	if page := s.GetAlerts(domain.AlertFilter{OrgID: orgID}); page.Total != 1 {
+		t.Errorf("%d alerts after re-evaluating, want 1", page.Total)
+	}
+}
+
+func TestComputeMetric(t *testing.T) {
+	s := newTestService(t, clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)))
+	orgID := uuid.New()
+	for i := 1; i <= 100; i++ {
+		s.RecordCall(domain.CallSample{OrgID: orgID, MCPServer: "filesystem", IsError: i%10 == 0, DurationMs: int64(i), Cost: 0.01})
+	}
+
+	window := 10 * time.Minute
+	for _, tt := range []struct {
+		metric domain.AlertMetric
+		want   float64
+	}{
+		{domain.AlertMetricErrorRate, 10},
+		{domain.AlertMetricRequestRate, 10},
+		{domain.AlertMetricCostPerHour, 6},
+		{domain.AlertMetricCostPerDay, 144},
+		{domain.AlertMetricLatencyP50, 50},
+		{domain.AlertMetricLatencyP99, 99},
+	} {
+		got, ok := s.ComputeMetric(domain.MetricQuery{OrgID: orgID, Metric: tt.metric, Window: window})
+		if !ok || math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s = %v (%v), want %v", tt.metric, got, ok, tt.want)
+		}
+	}
+
+	if _, ok := s.ComputeMetric(domain.MetricQuery{OrgID: uuid.New(), Metric: domain.AlertMetricErrorRate, Window: window}); ok {
+		t.Error("an org without calls has an error rate")
+	}
+	if _, ok := s.ComputeMetric(domain.MetricQuery{OrgID: orgID, Metric: domain.AlertMetricBudgetUsage, Window: window}); ok {
+		t.Error("budget usage was computed from call samples")
+	}
+}
+
+func TestServerScopedRuleIgnoresOtherServers(t *testing.T) {
+	s := newTestService(t, clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)))
+	orgID, userID := uuid.New(), uuid.New()
+
+	rule := func(name string, filters domain.AlertFilters) *domain.AlertRule {
+		return s.CreateRule(domain.AlertRuleInput{
+			Name:          name,
+			Metric:        domain.AlertMetricErrorRate,
+			Condition:     domain.AlertConditionGreaterThan,
+			Threshold:     5,
+			WindowMinutes: 5,
+			Severity:      domain.AlertSeverityWarning,
+			Filters:       filters,
+			Enabled:       true,
+		}, orgID, userID)
+	}
+	global := rule("all servers", domain.AlertFilters{})
+	shell := rule("shell", domain.AlertFilters{MCPServers: []string{"shell"}})
+
+	// Every filesystem call fails; shell is healthy
+	for i := 0; i < 10; i++ {
+		s.RecordCall(domain.CallSample{OrgID: orgID, MCPServer: "filesystem", IsError: true})
+		s.RecordCall(domain.CallSample{OrgID: orgID, MCPServer: "shell"})
+	}
+	s.EvaluateRules()
+	if s.openAlertForRule(*global) == nil {
+		t.Error("the global rule did not fire on a 50% error rate")
+	}
+	if open := s.openAlertForRule(*shell); open != nil {
+		t.Fatalf("the shell rule fired on filesystem errors: %s", open.Message)
+	}
+
+	// One failed shell call in eleven crosses the shell rule's threshold
+	s.RecordCall(domain.CallSample{OrgID: orgID, MCPServer: "shell", IsError: true})
+	s.EvaluateRules()
+	open := s.openAlertForRule(*shell)
+	if open == nil {
+		t.Fatal("the shell rule did not fire on shell errors")
+	}
+	if want := 100.0 / 11; math.Abs(open.Value-want) > 1e-9 {
+		t.Errorf("shell error rate = %v, want %v from shell calls only", open.Value, want)
+	}
+}
@@ -0,0 +1,190 @@
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/google/uuid"
+)
+
+// groupSeverities orders severities from most to least severe, for
+// summaries of grouped alerts.
+var groupSeverities = []domain.AlertSeverity{
+	domain.AlertSeverityCritical,
+	domain.AlertSeverityWarning,
+	domain.AlertSeverityInfo,
+}
+
+// alertGroup is a batch of alerts waiting to be sent to a channel as one
+// notification.
+type alertGroup struct {
+	channelID uuid.UUID
+	labels    domain.Labels // The alerts' values for the channel's group_by labels
+	alerts    []groupedAlert
+}
+
+// groupedAlert is an alert in a group, its message already rendered for
+// the channel.
+type groupedAlert struct {
+	alert    domain.Alert
+	ruleName string
+}
+
+// groupKey identifies the group an alert falls in on a channel.
+func groupKey(channelID uuid.UUID, groupBy []string, labels domain.Labels) string {
+	var b strings.Builder
+	b.WriteString(channelID.String())
+	for _, name := range groupBy {
+		fmt.Fprintf(&b, "\x00%s=%s", name, labels[name])
+	}
+	return b.String()
+}
+
+// enqueueGrouped adds an alert to its group on the channel. The first alert
+// of a group starts the group's wait, after which the group is sent.
+func (s *Service) enqueueGrouped(channel domain.AlertChannel, alert domain.Alert, ruleName string) {
+	labels := make(domain.Labels, len(channel.Grouping.GroupBy))
+	for _, name := range channel.Grouping.GroupBy {
+		labels[name] = alert.Labels[name]
+	}
+	key := groupKey(channel.ID, channel.Grouping.GroupBy, labels)
+
+	s.groupsMu.Lock()
+	defer s.groupsMu.Unlock()
+
+	group, exists := s.groups[key]
+	if !exists {
+		group = &alertGroup{channelID: channel.ID, labels: labels}
+		s.groups[key] = group
+		time.AfterFunc(channel.Grouping.Wait(), func() { s.flushGroup(key) })
+	}
+	group.alerts = append(group.alerts, groupedAlert{alert: alert, ruleName: ruleName})
+}
+
+// flushGroup sends a group whose wait has passed.
+func (s *Service) flushGroup(key string) {
+	s.groupsMu.Lock()
+	group, exists := s.groups[key]
+	delete(s.groups, key)
+	s.groupsMu.Unlock()
+
+	if exists {
+		s.sendGroup(group)
+	}
+}
+
+// flushGroups sends every waiting group without waiting further.
+func (s *Service) flushGroups() {
+	s.groupsMu.Lock()
+	groups := s.groups
+	s.groups = make(map[string]*alertGroup)
+	s.groupsMu.Unlock()
+
+	for _, group := range groups {
+		s.sendGroup(group)
+	}
+}
+
+// sendGroup sends a group to its channel: a lone alert as it is, several as
+// one summary.
+func (s *Service) sendGroup(group *alertGroup) {
+	s.mu.RLock()
+	channel, exists := s.channels[group.channelID]
+	var snapshot domain.AlertChannel
+	if exists {
+		snapshot = *channel
+	}
+	s.mu.RUnlock()
+
+	if !exists || !snapshot.Enabled {
+		s.logger.Debug().
+			Str("channel_id", group.channelID.String()).
+			Int("alerts", len(group.alerts)).
+			Msg("Dropping alert group for a removed or disabled channel")
+		return
+	}
+
+	alert, title := group.alerts[0].alert, group.alerts[0].ruleName
+	if len(group.alerts) > 1 {
+		alert, title = summarizeGroup(group)
+	}
+
+	// Groups flushed at shutdown are sent before the service context is
+	// cancelled; any send still running after that is abandoned
+	ctx, cancel := context.WithTimeout(s.ctx, deliveryTimeout)
+	defer cancel()
+	if err := s.sendNotification(ctx, snapshot, alert, title); err != nil {
+		s.logger.Error().
+			Err(err).
+			Str("channel_id", snapshot.ID.String()).
+			Str("channel_type", string(snapshot.Type)).
+			Int("alerts", len(group.alerts)).
+			Msg("Failed to send grouped notification")
+		return
+	}
+
+	s.logger.Info().
+		Str("channel_id", snapshot.ID.String()).
+		Int("alerts", len(group.alerts)).
+		Msg("Grouped notification sent")
+}
+
+// summarizeGroup builds the alert and title sent for a group of alerts. The
+// summary takes the highest severity in the group and counts the alerts per
+// severity, followed by a line per alert.
+func summarizeGroup(group *alertGroup) (domain.Alert, string) {
+	counts := make(map[domain.AlertSeverity]int)
+	startedAt := group.alerts[0].alert.StartedAt
+	for _, grouped := range group.alerts {
+		counts[grouped.alert.Severity]++
+		if grouped.alert.StartedAt.Before(startedAt) {
+			startedAt = grouped.alert.StartedAt
+		}
+	}
+
+	severity := domain.AlertSeverityInfo
+	var parts []string
+	for _, sev := range groupSeverities {
+		if n := counts[sev]; n > 0 {
+			if len(parts) == 0 {
+				severity = sev
+			}
+			parts = append(parts, fmt.Sprintf("%d %s", n, sev))
+		}
+	}
+
+	var message strings.Builder
+	fmt.Fprintf(&message, "%d alerts firing: %s", len(group.alerts), strings.Join(parts, ", "))
+	for _, grouped := range group.alerts {
+		fmt.Fprintf(&message, "\n- [%s] %s: %s", grouped.alert.Severity, grouped.ruleName, grouped.alert.Message)
+	}
+
+	title := fmt.Sprintf("%d alerts", len(group.alerts))
+	if len(group.labels) > 0 {
+		pairs := make([]string, 0, len(group.labels))
+		for name, value := range group.labels {
+			pairs = append(pairs, name+"="+value)
+		}
+		sort.Strings(pairs)
+		title += " (" + strings.Join(pairs, ", ") + ")"
+	}
+
+	labels := domain.Labels{"grouped": fmt.Sprint(len(group.alerts))}
+	for name, value := range group.labels {
+		labels[name] = value
+	}
+
+	return domain.Alert{
+		ID:        uuid.New(),
+		OrgID:     group.alerts[0].alert.OrgID,
+		Status:    domain.AlertStatusFiring,
+		Severity:  severity,
+		Message:   message.String(),
+		Labels:    labels,
+		StartedAt: startedAt,
+	}, title
+}
@@ -0,0 +1,75 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/clock"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/google/uuid"
+)
+
+func TestAlertsWithinTheGroupWaitAreSentAsOne(t *testing.T) {
+	s := newTestService(t, clock.Real)
+	orgID := uuid.New()
+
+	received := make(chan map[string]any, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]any
+		json.NewDecoder(r.Body).Decode(&payload)
+		received <- payload
+	}))
+	t.Cleanup(srv.Close)
+	channel := webhookChannel(s, orgID, srv.URL, domain.AlertGrouping{GroupBy: []string{"mcp_server"}, GroupWaitSeconds: 1})
+	rule := domain.AlertRule{ID: uuid.New(), OrgID: orgID, Name: "errors", Channels: []uuid.UUID{channel.ID}}
+
+	fire := func(server string, severity domain.AlertSeverity, message string) {
+		alert := domain.Alert{ID: uuid.New(), OrgID: orgID, Severity: severity, Message: message, Labels: domain.Labels{"mcp_server": server}}
+		deliveries := s.deliver(context.Background(), alert, rule)
+		if len(deliveries) != 1 || !deliveries[0].Grouped {
+			t.Fatalf("deliveries = %+v, want the alert held for its group", deliveries)
+		}
+	}
+	fire("github", domain.AlertSeverityWarning, "latency high")
+	fire("github", domain.AlertSeverityCritical, "errors high")
+	fire("github", domain.AlertSeverityWarning, "cost high")
+	fire("filesystem", domain.AlertSeverityInfo, "disk busy")
+
+	select {
+	case payload := <-received:
+		t.Fatalf("sent %v before the group wait passed", payload)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	var got []map[string]any
+	for len(got) < 2 {
+		select {
+		case payload := <-received:
+			got = append(got, payload)
+		case <-time.After(3 * time.Second):
+			t.Fatalf("got %d notifications, want one per group", len(got))
+		}
+	}
+	select {
+	case payload := <-received:
+		t.Fatalf("extra notification %v", payload)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	sort.Slice(got, func(i, j int) bool { return got[i]["rule_name"].(string) < got[j]["rule_name"].(string) })
+	summary, single := got[0], got[1]
+	message, _ := summary["message"].(string)
+	if summary["rule_name"] != "3 alerts (mcp_server=github)" || summary["severity"] != "critical" ||
+		!strings.HasPrefix(message, "3 alerts firing: 1 critical, 2 warning\n") || strings.Count(message, "\n- ") != 3 {
+		t.Errorf("summary = %v, want the three github alerts counted by severity", summary)
+	}
+	if single["rule_name"] != "errors" || single["message"] != "disk busy" {
+		t.Errorf("lone alert = %v, want it sent as it is", single)
+	}
+}
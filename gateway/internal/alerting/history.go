@@ -0,0 +1,104 @@
+package alerting
+
+import (
+	"context"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/google/uuid"
+)
+
+// recordEvent appends a step to an alert's history, in memory and, for
+// alerts backed by a rule, in the database. Only the created event starts a
+// history, so a notification finishing after its alert left the buffer is
+// not kept in memory.
+func (s *Service) recordEvent(alert domain.Alert, eventType domain.AlertEventType, actor domain.AlertActor, details map[string]interface{}) {
+	event := domain.AlertEvent{
+		ID:        uuid.New(),
+		AlertID:   alert.ID,
+		OrgID:     alert.OrgID,
+		Type:      eventType,
+		Actor:     actor,
+		Details:   details,
+		CreatedAt: s.clock.Now(),
+	}
+
+	s.eventsMu.Lock()
+	if _, started := s.events[alert.ID]; started || eventType == domain.AlertEventCreated {
+		s.events[alert.ID] = append(s.events[alert.ID], event)
+	}
+	s.eventsMu.Unlock()
+
+	if s.repo != nil && alert.RuleID != uuid.Nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := s.repo.AppendAlertEvent(ctx, &event); err != nil {
+			s.logger.Error().
+				Err(err).
+				Str("alert_id", alert.ID.String()).
+				Str("event", string(eventType)).
+				Msg("Failed to persist alert event")
+		}
+	}
+}
+
+// forgetEvents drops the in-memory history of an alert evicted from the
+// buffer.
+func (s *Service) forgetEvents(alertID uuid.UUID) {
+	s.eventsMu.Lock()
+	delete(s.events, alertID)
+	s.eventsMu.Unlock()
+}
+
+// AlertHistory returns one of an organization's alerts' events, oldest
+// first, with the status they add up to. It returns nil when the alert has
+// no history.
+func (s *Service) AlertHistory(ctx context.Context, orgID, id uuid.UUID) (*domain.AlertHistory, error) {
+	var events []domain.AlertEvent
+	if s.repo != nil {
+		var err error
+		if events, err = s.repo.GetAlertEvents(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+	// System alerts are not stored, and neither is their history
+	if len(events) == 0 {
+		s.eventsMu.RLock()
+		events = append([]domain.AlertEvent(nil), s.events[id]...)
+		s.eventsMu.RUnlock()
+	}
+	if len(events) == 0 || events[0].OrgID != orgID {
+		return nil, nil
+	}
+
+	return &domain.AlertHistory{
+		AlertID: id,
+		Status:  Replay(events).Status,
+		Events:  events,
+	}, nil
+}
+
+// Replay rebuilds an alert's lifecycle state from its events, oldest first:
+// its status, when it started, and when and by whom it was acknowledged and
+// resolved. Notified and silenced events leave the status as it is.
+func Replay(events []domain.AlertEvent) domain.Alert {
+	var alert domain.Alert
+	for _, event := range events {
+		alert.ID = event.AlertID
+		alert.OrgID = event.OrgID
+		at := event.CreatedAt
+		switch event.Type {
+		case domain.AlertEventCreated:
+			alert.Status = domain.AlertStatusFiring
+			alert.StartedAt = at
+		case domain.AlertEventAcked:
+			alert.Status = domain.AlertStatusAcked
+			alert.AckedAt = &at
+			alert.AckedBy = event.Actor.UserID
+		case domain.AlertEventResolved:
+			alert.Status = domain.AlertStatusResolved
+			alert.ResolvedAt = &at
+		}
+	}
+	return alert
+}
@@ -0,0 +1,115 @@
+package alerting
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/clock"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// eventStore adds alerts and their append-only events to fakeAlertStore.
+type eventStore struct {
+	*fakeAlertStore
+	events []domain.AlertEvent
+}
+
+func (f *eventStore) CreateAlert(ctx context.Context, alert *domain.Alert) error { return nil }
+
+func (f *eventStore) UpdateAlert(ctx context.Context, alert *domain.Alert) error { return nil }
+
+func (f *eventStore) AppendAlertEvent(ctx context.Context, event *domain.AlertEvent) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.events = append(f.events, *event)
+	return nil
+}
+
+func (f *eventStore) GetAlertEvents(ctx context.Context, alertID uuid.UUID) ([]domain.AlertEvent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var events []domain.AlertEvent
+	for _, event := range f.events {
+		if event.AlertID == alertID {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func TestFireAckResolveIsRecordedInOrder(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		name  string
+		store *eventStore
+	}{
+		{"in memory", nil},
+		{"in the store", &eventStore{fakeAlertStore: newFakeAlertStore()}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			clk := clock.NewFake(start)
+			var s *Service
+			if tt.store != nil {
+				s = NewService(zerolog.Nop(), tt.store, nil, 100, clk)
+				t.Cleanup(func() { s.Shutdown(context.Background()) })
+			} else {
+				s = newTestService(t, clk)
+			}
+			orgID, userID := uuid.New(), uuid.New()
+			rule := s.CreateRule(domain.AlertRuleInput{
+				Name:      "Errors",
+				Metric:    domain.AlertMetricErrorRate,
+				Condition: domain.AlertConditionGreaterThan,
+				Threshold: 5,
+				Severity:  domain.AlertSeverityCritical,
+				Enabled:   true,
+			}, orgID, userID)
+
+			alert := s.CreateAlert(rule.ID, 12, "Error rate is 12%")
+			clk.Advance(time.Minute)
+			s.AcknowledgeAlert(orgID, alert.ID, domain.UserActor(userID))
+			clk.Advance(time.Minute)
+			s.ResolveAlert(orgID, alert.ID, domain.UserActor(userID))
+			// Resolving again is not another step
+			s.ResolveAlert(orgID, alert.ID, domain.UserActor(userID))
+
+			history, err := s.AlertHistory(context.Background(), orgID, alert.ID)
+			if err != nil || history == nil {
+				t.Fatalf("AlertHistory = %v, %v", history, err)
+			}
+			want := []struct {
+				eventType domain.AlertEventType
+				actor     domain.AlertActorType
+				at        time.Time
+			}{
+				{domain.AlertEventCreated, domain.AlertActorSystem, start},
+				{domain.AlertEventAcked, domain.AlertActorUser, start.Add(time.Minute)},
+				{domain.AlertEventResolved, domain.AlertActorUser, start.Add(2 * time.Minute)},
+			}
+			if len(history.Events) != len(want) {
+				t.Fatalf("%d events, want %d: %+v", len(history.Events), len(want), history.Events)
+			}
+			for i, w := range want {
+				e := history.Events[i]
+				if e.Type != w.eventType || e.Actor.Type != w.actor || !e.CreatedAt.Equal(w.at) || e.AlertID != alert.ID {
+					t.Errorf("event %d = %s by %s at %s, want %s by %s at %s", i, e.Type, e.Actor.Type, e.CreatedAt, w.eventType, w.actor, w.at)
+				}
+			}
+			if history.Status != domain.AlertStatusResolved {
+				t.Errorf("status = %s, want resolved", history.Status)
+			}
+
+			replayed := Replay(history.Events)
+			if replayed.AckedBy == nil || *replayed.AckedBy != userID || replayed.ResolvedAt == nil || !replayed.StartedAt.Equal(start) {
+				t.Errorf("replayed alert = %+v, want acknowledged by %s and resolved", replayed, userID)
+			}
+
+			if other, _ := s.AlertHistory(context.Background(), uuid.New(), alert.ID); other != nil {
+				t.Error("another organization read the alert's history")
+			}
+		})
+	}
+}
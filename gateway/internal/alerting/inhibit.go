@@ -0,0 +1,227 @@
+package alerting
+
+import (
+	"context"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/google/uuid"
+)
+
+// CreateInhibitRule creates a new inhibit rule. It applies to alerts fired
+// from then on.
+func (s *Service) CreateInhibitRule(input domain.InhibitRuleInput, orgID uuid.UUID) *domain.InhibitRule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rule := &domain.InhibitRule{
+		ID:          uuid.New(),
+		OrgID:       orgID,
+		Name:        input.Name,
+		SourceMatch: input.SourceMatch,
+		TargetMatch: input.TargetMatch,
+		Equal:       input.Equal,
+		Enabled:     input.Enabled,
+		CreatedAt:   s.clock.Now(),
+		UpdatedAt:   s.clock.Now(),
+	}
+
+	// Persist to database
+	if s.repo != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := s.repo.CreateInhibitRule(ctx, rule); err != nil {
+			s.logger.Error().Err(err).Msg("Failed to persist inhibit rule")
+		}
+	}
+
+	s.inhibitRules[rule.ID] = rule
+
+	s.logger.Info().
+		Str("inhibit_rule_id", rule.ID.String()).
+		Str("name", rule.Name).
+		Msg("Inhibit rule created")
+
+	return rule
+}
+
+// GetInhibitRule returns one of an organization's inhibit rules by ID.
+func (s *Service) GetInhibitRule(orgID, id uuid.UUID) *domain.InhibitRule {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rule, exists := s.inhibitRules[id]
+	if !exists || rule.OrgID != orgID {
+		return nil
+	}
+	copy := *rule
+	return &copy
+}
+
+// ListInhibitRules returns an organization's inhibit rules.
+func (s *Service) ListInhibitRules(orgID uuid.UUID) []domain.InhibitRule {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rules := make([]domain.InhibitRule, 0)
+	for _, rule := range s.inhibitRules {
+		if rule.OrgID == orgID {
+			rules = append(rules, *rule)
+		}
+	}
+	return rules
+}
+
+// UpdateInhibitRule updates an existing inhibit rule. Alerts already held back
+// stay so until their inhibiting alert resolves.
+func (s *Service) UpdateInhibitRule(orgID, id uuid.UUID, input domain.InhibitRuleInput) *domain.InhibitRule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rule, exists := s.inhibitRules[id]
+	if !exists || rule.OrgID != orgID {
+		return nil
+	}
+
+	rule.Name = input.Name
+	rule.SourceMatch = input.SourceMatch
+	rule.TargetMatch = input.TargetMatch
+	rule.Equal = input.Equal
+	rule.Enabled = input.Enabled
+	rule.UpdatedAt = s.clock.Now()
+
+	// Persist to database
+	if s.repo != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := s.repo.UpdateInhibitRule(ctx, rule); err != nil {
+			s.logger.Error().Err(err).Msg("Failed to update inhibit rule in database")
+		}
+	}
+
+	copy := *rule
+	return &copy
+}
+
+// DeleteInhibitRule deletes an inhibit rule.
+func (s *Service) DeleteInhibitRule(orgID, id uuid.UUID) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rule, exists := s.inhibitRules[id]
+	if !exists || rule.OrgID != orgID {
+		return false
+	}
+
+	// Delete from database
+	if s.repo != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := s.repo.DeleteInhibitRule(ctx, id); err != nil {
+			s.logger.Error().Err(err).Msg("Failed to delete inhibit rule from database")
+		}
+	}
+	delete(s.inhibitRules, id)
+	return true
+}
+
+// matchesLabels reports whether the alert has every label value in matchers.
+// The "severity" matcher compares the alert's severity.
+func matchesLabels(matchers domain.Labels, alert domain.Alert) bool {
+	for name, value := range matchers {
+		actual := alert.Labels[name]
+		if name == "severity" {
+			actual = string(alert.Severity)
+		}
+		if actual != value {
+			return false
+		}
+	}
+	return true
+}
+
+// inhibitor returns the active alert that inhibits the alert, if any. The
+// caller must hold s.mu.
+func (s *Service) inhibitor(alert domain.Alert) *uuid.UUID {
+	for _, rule := range s.inhibitRules {
+		if !rule.Enabled || rule.OrgID != alert.OrgID || !matchesLabels(rule.TargetMatch, alert) {
+			continue
+		}
+		for i := range s.alerts {
+			source := s.alerts[i]
+			if source.ID == alert.ID || source.OrgID != alert.OrgID || source.Status == domain.AlertStatusResolved {
+				continue
+			}
+			if !matchesLabels(rule.SourceMatch, source) || !equalLabels(rule.Equal, source, alert) {
+				continue
+			}
+			id := source.ID
+			return &id
+		}
+	}
+	return nil
+}
+
+// equalLabels reports whether two alerts have the same values for the
+// labels.
+func equalLabels(labels []string, a, b domain.Alert) bool {
+	for _, name := range labels {
+		if a.Labels[name] != b.Labels[name] {
+			return false
+		}
+	}
+	return true
+}
+
+// notifyOrInhibit sends a new alert to the rule's channels, unless an active
+// alert inhibits it. An inhibited alert is marked with its inhibitor and its
+// notification held back until the inhibitor resolves. The caller must hold
+// s.mu and store the alert afterwards.
+func (s *Service) notifyOrInhibit(alert *domain.Alert, rule domain.AlertRule) {
+	if inhibitor := s.inhibitor(*alert); inhibitor != nil {
+		alert.InhibitedBy = inhibitor
+		s.inhibited[alert.ID] = rule
+		s.recordEvent(*alert, domain.AlertEventSilenced, domain.SystemActor(), map[string]interface{}{"inhibited_by": inhibitor})
+
+		s.logger.Info().
+			Str("alert_id", alert.ID.String()).
+			Str("inhibited_by", inhibitor.String()).
+			Msg("Alert inhibited; notification held back")
+		return
+	}
+	go s.notifyChannels(*alert, rule)
+}
+
+// releaseInhibited notifies the alerts held back by a resolved alert that
+// are still firing, unless another active alert inhibits them. The caller
+// must hold s.mu.
+func (s *Service) releaseInhibited(inhibitorID uuid.UUID) {
+	for i := range s.alerts {
+		alert := &s.alerts[i]
+		if alert.InhibitedBy == nil || *alert.InhibitedBy != inhibitorID || alert.Status == domain.AlertStatusResolved {
+			continue
+		}
+
+		rule, held := s.inhibited[alert.ID]
+		alert.InhibitedBy = s.inhibitor(*alert)
+		if alert.InhibitedBy == nil {
+			delete(s.inhibited, alert.ID)
+			if held && alert.Status == domain.AlertStatusFiring {
+				go s.notifyChannels(*alert, rule)
+				s.logger.Info().
+					Str("alert_id", alert.ID.String()).
+					Str("inhibited_by", inhibitorID.String()).
+					Msg("Inhibiting alert resolved; sending held-back notification")
+			}
+		}
+
+		// Persist to database
+		if s.repo != nil && alert.RuleID != uuid.Nil {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if err := s.repo.UpdateAlert(ctx, alert); err != nil {
+				s.logger.Error().Err(err).Msg("Failed to update inhibited alert in database")
+			}
+			cancel()
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package alerting
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/clock"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/google/uuid"
+)
+
+func TestWarningIsInhibitedWhileItsCriticalAlertFires(t *testing.T) {
+	s := newTestService(t, clock.Real)
+	orgID, userID := uuid.New(), uuid.New()
+
+	notified := make(chan string, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			AlertID string `json:"alert_id"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		notified <- payload.AlertID
+	}))
+	t.Cleanup(srv.Close)
+	channel := webhookChannel(s, orgID, srv.URL, domain.AlertGrouping{})
+
+	rule := func(name string, severity domain.AlertSeverity, server string) *domain.AlertRule {
+		return s.CreateRule(domain.AlertRuleInput{
+			Name:      name,
+			Metric:    domain.AlertMetricErrorRate,
+			Condition: domain.AlertConditionGreaterThan,
+			Severity:  severity,
+			Channels:  []uuid.UUID{channel.ID},
+			Filters:   domain.AlertFilters{MCPServers: []string{server}},
+			Enabled:   true,
+		}, orgID, userID)
+	}
+	down := rule("github down", domain.AlertSeverityCritical, "github")
+	slow := rule("github slow", domain.AlertSeverityWarning, "github")
+	otherSlow := rule("filesystem slow", domain.AlertSeverityWarning, "filesystem")
+	s.CreateInhibitRule(domain.InhibitRuleInput{
+		Name:        "down inhibits slow",
+		SourceMatch: domain.Labels{"severity": "critical"},
+		TargetMatch: domain.Labels{"severity": "warning"},
+		Equal:       []string{"mcp_server"},
+		Enabled:     true,
+	}, orgID)
+
+	expectNotified := func(want string) {
+		t.Helper()
+		select {
+		case got := <-notified:
+			if got != want {
+				t.Fatalf("notified of %s, want %s", got, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("no notification for %s", want)
+		}
+	}
+	expectQuiet := func() {
+		t.Helper()
+		select {
+		case got := <-notified:
+			t.Fatalf("unexpected notification for %s", got)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	critical := s.CreateAlert(down.ID, 100, "github is down")
+	expectNotified(critical.ID.String())
+
+	warning := s.CreateAlert(slow.ID, 5000, "github is slow")
+	if warning.InhibitedBy == nil || *warning.InhibitedBy != critical.ID {
+		t.Errorf("warning inhibited by %v, want %s", warning.InhibitedBy, critical.ID)
+	}
+	expectQuiet()
+
+	// A warning on another server is not related to the critical alert
+	unrelated := s.CreateAlert(otherSlow.ID, 5000, "filesystem is slow")
+	if unrelated.InhibitedBy != nil {
+		t.Errorf("unrelated warning inhibited by %s", unrelated.InhibitedBy)
+	}
+	expectNotified(unrelated.ID.String())
+
+	// Resolving the inhibitor sends the held-back warning, still firing
+	s.ResolveAlert(orgID, critical.ID, domain.SystemActor())
+	expectNotified(warning.ID.String())
+	expectQuiet()
+	for _, alert := range s.GetAlerts(domain.AlertFilter{OrgID: orgID}).Alerts {
+		if alert.ID == warning.ID && alert.InhibitedBy != nil {
+			t.Errorf("warning still inhibited by %s after it resolved", alert.InhibitedBy)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package alerting
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// latencyReservoirSize bounds how many durations a latency computation keeps,
+// regardless of how many calls fall in the window.
+const latencyReservoirSize = 2048
+
+// latencyReservoir keeps a uniform random sample of call durations
+// (Vitter's Algorithm R) so percentiles can be estimated in bounded memory.
+// Percentiles are exact while fewer than size durations have been added.
+type latencyReservoir struct {
+	size   int
+	seen   int
+	values []int64
+	rng    *rand.Rand
+	sorted bool
+}
+
+func newLatencyReservoir(size int) *latencyReservoir {
+	return &latencyReservoir{
+		size:   size,
+		values: make([]int64, 0, size),
+		rng:    rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// add records a duration in milliseconds.
+func (r *latencyReservoir) add(durationMs int64) {
+	r.seen++
+	r.sorted = false
+	if len(r.values) < r.size {
+		r.values = append(r.values, durationMs)
+		return
+	}
+	if i := r.rng.Intn(r.seen); i < r.size {
+		r.values[i] = durationMs
+	}
+}
+
+// count returns the number of durations added, including those not retained.
+func (r *latencyReservoir) count() int {
+	return r.seen
+}
+
+// percentile returns the nearest-rank percentile (0 < p <= 1) of the retained
+// durations, or 0 when nothing has been added.
+func (r *latencyReservoir) percentile(p float64) float64 {
+	if len(r.values) == 0 {
+		return 0
+	}
+	if !r.sorted {
+		sort.Slice(r.values, func(i, j int) bool { return r.values[i] < r.values[j] })
+		r.sorted = true
+	}
+
+	rank := int(math.Ceil(p*float64(len(r.values)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(r.values) {
+		rank = len(r.values) - 1
+	}
+	return float64(r.values[rank])
+}
@@ -0,0 +1,80 @@
+package alerting
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/clock"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/google/uuid"
+)
+
+func TestLatencyPercentilesAreExactBelowTheReservoirSize(t *testing.T) {
+	r := newLatencyReservoir(latencyReservoirSize)
+	if got := r.percentile(0.5); got != 0 {
+		t.Errorf("empty p50 = %v, want 0", got)
+	}
+
+	// 1..1000ms in random order
+	for _, i := range rand.New(rand.NewSource(1)).Perm(1000) {
+		r.add(int64(i + 1))
+	}
+	for _, tt := range []struct {
+		p    float64
+		want float64
+	}{
+		{0.50, 500},
+		{0.90, 900},
+		{0.95, 950},
+		{0.99, 990},
+		{1, 1000},
+	} {
+		if got := r.percentile(tt.p); got != tt.want {
+			t.Errorf("p%v = %v, want %v", tt.p*100, got, tt.want)
+		}
+	}
+	if r.count() != 1000 {
+		t.Errorf("count = %d, want 1000", r.count())
+	}
+}
+
+func TestLatencyReservoirBoundsMemory(t *testing.T) {
+	r := newLatencyReservoir(latencyReservoirSize)
+	r.rng = rand.New(rand.NewSource(1))
+
+	// Uniform over 1..100000ms, so the p-th percentile is about p*100000
+	const n = 100000
+	for _, i := range rand.New(rand.NewSource(2)).Perm(n) {
+		r.add(int64(i + 1))
+	}
+	if len(r.values) != latencyReservoirSize || r.count() != n {
+		t.Fatalf("kept %d of %d durations, want %d of %d", len(r.values), r.count(), latencyReservoirSize, n)
+	}
+	for _, p := range []float64{0.50, 0.90, 0.95, 0.99} {
+		if got, want := r.percentile(p), p*n; math.Abs(got-want) > 0.03*n {
+			t.Errorf("p%v = %v, want within 3%% of %v", p*100, got, want)
+		}
+	}
+}
+
+func TestLatencySummaryScopesToServerAndTool(t *testing.T) {
+	s := newTestService(t, clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)))
+	orgID := uuid.New()
+	for i := 1; i <= 100; i++ {
+		s.RecordCall(domain.CallSample{OrgID: orgID, MCPServer: "filesystem", ToolName: "read_file", DurationMs: int64(i)})
+		s.RecordCall(domain.CallSample{OrgID: orgID, MCPServer: "filesystem", ToolName: "write_file", DurationMs: int64(1000 + i)})
+		s.RecordCall(domain.CallSample{OrgID: orgID, MCPServer: "github", ToolName: "read_file", DurationMs: 5000})
+	}
+
+	got := s.LatencySummary(domain.MetricQuery{OrgID: orgID, MCPServer: "filesystem", ToolName: "read_file", Window: 5 * time.Minute})
+	want := domain.LatencySummary{MCPServer: "filesystem", ToolName: "read_file", WindowMinutes: 5, Count: 100, P50: 50, P90: 90, P95: 95, P99: 99}
+	if got != want {
+		t.Errorf("summary = %+v, want %+v", got, want)
+	}
+
+	if got := s.LatencySummary(domain.MetricQuery{OrgID: orgID, MCPServer: "filesystem", Window: 5 * time.Minute}); got.Count != 200 || got.P50 != 100 || got.P99 != 1098 {
+		t.Errorf("server summary = %+v, want both filesystem tools", got)
+	}
+}
@@ -0,0 +1,174 @@
+package alerting
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/google/uuid"
+)
+
+const (
+	// maxMetricWindow bounds how much call history is kept for evaluation.
+	maxMetricWindow = 24 * time.Hour
+	// maxCallSamples bounds the in-memory sample buffer.
+	maxCallSamples = 100000
+)
+
+// RecordCall ingests an MCP call observation for alert evaluation. The sample
+// is kept in memory and persisted asynchronously.
+func (s *Service) RecordCall(sample domain.CallSample) {
+	if sample.ID == uuid.Nil {
+		sample.ID = uuid.New()
+	}
+	if sample.CreatedAt.IsZero() {
+		sample.CreatedAt = s.clock.Now()
+	}
+
+	s.samplesMu.Lock()
+	if len(s.samples) >= maxCallSamples {
+		s.samples = s.samples[1:]
+	}
+	s.samples = append(s.samples, sample)
+	s.samplesMu.Unlock()
+
+	if s.metricRepo != nil {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := s.metricRepo.RecordSample(ctx, &sample); err != nil {
+				s.logger.Error().Err(err).Msg("Failed to persist call sample")
+			}
+		}()
+	}
+}
+
+// ComputeMetric computes a metric over the query's trailing window. It
+// returns false when there is no data or the metric is not derived from call
+// samples.
+func (s *Service) ComputeMetric(q domain.MetricQuery) (float64, bool) {
+	var count, errors int
+	var cost float64
+	latencies := newLatencyReservoir(latencyReservoirSize)
+
+	s.scanSamples(q, func(sample domain.CallSample) {
+		count++
+		if sample.IsError {
+			errors++
+		}
+		cost += sample.Cost
+		latencies.add(sample.DurationMs)
+	})
+
+	if count == 0 {
+		return 0, false
+	}
+
+	minutes := q.Window.Minutes()
+	switch q.Metric {
+	case domain.AlertMetricErrorRate:
+		return float64(errors) / float64(count) * 100, true
+	case domain.AlertMetricRequestRate:
+		return float64(count) / minutes, true
+	case domain.AlertMetricCostPerHour:
+		return cost * 60 / minutes, true
+	case domain.AlertMetricCostPerDay:
+		return cost * 24 * 60 / minutes, true
+	case domain.AlertMetricLatencyP50:
+		return latencies.percentile(0.50), true
+	case domain.AlertMetricLatencyP90:
+		return latencies.percentile(0.90), true
+	case domain.AlertMetricLatencyP95:
+		return latencies.percentile(0.95), true
+	case domain.AlertMetricLatencyP99:
+		return latencies.percentile(0.99), true
+	default:
+		return 0, false
+	}
+}
+
+// LatencySummary returns call latency percentiles over the query's window.
+func (s *Service) LatencySummary(q domain.MetricQuery) domain.LatencySummary {
+	latencies := newLatencyReservoir(latencyReservoirSize)
+	s.scanSamples(q, func(sample domain.CallSample) {
+		latencies.add(sample.DurationMs)
+	})
+
+	return domain.LatencySummary{
+		MCPServer:     q.MCPServer,
+		ToolName:      q.ToolName,
+		WindowMinutes: int(q.Window.Minutes()),
+		Count:         latencies.count(),
+		P50:           latencies.percentile(0.50),
+		P90:           latencies.percentile(0.90),
+		P95:           latencies.percentile(0.95),
+		P99:           latencies.percentile(0.99),
+	}
+}
+
+// scanSamples calls fn for every sample matching the query, newest first.
+func (s *Service) scanSamples(q domain.MetricQuery, fn func(domain.CallSample)) {
+	since := s.clock.Now().Add(-q.Window)
+
+	s.samplesMu.RLock()
+	defer s.samplesMu.RUnlock()
+
+	for i := len(s.samples) - 1; i >= 0; i-- {
+		sample := s.samples[i]
+		if sample.CreatedAt.Before(since) {
+			break
+		}
+		if sample.OrgID != q.OrgID {
+			continue
+		}
+		if q.MCPServer != "" && sample.MCPServer != q.MCPServer {
+			continue
+		}
+		if q.ToolName != "" && sample.ToolName != q.ToolName {
+			continue
+		}
+		if !q.Filters.Matches(sample) {
+			continue
+		}
+		fn(sample)
+	}
+}
+
+// loadSamples restores recent call samples from the database.
+func (s *Service) loadSamples() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	samples, err := s.metricRepo.ListSamplesSince(ctx, s.clock.Now().Add(-maxMetricWindow), maxCallSamples)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to load call samples from database")
+		return
+	}
+
+	s.samplesMu.Lock()
+	s.samples = append(samples, s.samples...)
+	s.samplesMu.Unlock()
+
+	s.logger.Info().Int("samples", len(samples)).Msg("Loaded call samples from database")
+}
+
+// pruneSamples drops samples older than the maximum metric window.
+func (s *Service) pruneSamples() {
+	cutoff := s.clock.Now().Add(-maxMetricWindow)
+
+	s.samplesMu.Lock()
+	i := sort.Search(len(s.samples), func(i int) bool {
+		return !s.samples[i].CreatedAt.Before(cutoff)
+	})
+	s.samples = append([]domain.CallSample(nil), s.samples[i:]...)
+	s.samplesMu.Unlock()
+
+	if s.metricRepo != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if _, err := s.metricRepo.DeleteSamplesBefore(ctx, cutoff); err != nil {
+			s.logger.Error().Err(err).Msg("Failed to prune call samples")
+		}
+	}
+}
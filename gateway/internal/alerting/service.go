@@ -7,39 +7,113 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/akz4ol/gatewayops/gateway/internal/clock"
 	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/email"
 	"github.com/akz4ol/gatewayops/gateway/internal/repository"
+	"github.com/akz4ol/gatewayops/gateway/internal/ringbuf"
+	"github.com/akz4ol/gatewayops/gateway/internal/webhook"
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 )
 
+// slackDeliveryTimeout bounds a Slack delivery, including rate-limit waits
+// and retries.
+const slackDeliveryTimeout = time.Minute
+
+const (
+	// deliveryTimeout bounds the delivery of an alert to any one channel,
+	// so a slow channel cannot hold up the alert's history
+	deliveryTimeout = slackDeliveryTimeout
+	// maxConcurrentDeliveries bounds the channels an alert is sent to at once
+	maxConcurrentDeliveries = 8
+	// pagerDutyEventsURL receives PagerDuty trigger and resolve events
+	pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+)
+
 // Service manages alert rules, channels, and notifications.
 type Service struct {
-	logger   zerolog.Logger
-	repo     *repository.AlertRepository
-	rules    map[uuid.UUID]*domain.AlertRule
-	channels map[uuid.UUID]*domain.AlertChannel
-	alerts   []domain.Alert
-	mu       sync.RWMutex
-	client   *http.Client
-
-	// Simulated metrics for demo
-	metrics map[string]float64
+	logger     zerolog.Logger
+	repo       repository.AlertStore
+	metricRepo *repository.MetricRepository
+	clock      clock.Clock
+	rules      map[uuid.UUID]*domain.AlertRule
+	deleted    map[uuid.UUID]*domain.AlertRule // Soft-deleted rules, kept for restore
+	channels   map[uuid.UUID]*domain.AlertChannel
+	alerts     []domain.Alert
+	alertRing  *ringbuf.Ring
+	mu         sync.RWMutex
+	client     *http.Client
+	slack      *webhook.SlackClient
+	opsgenie   *webhook.OpsgenieClient
+	mailer     *email.Sender
+
+	// Incidents opened per alert ID, resolved when the alert resolves
+	incidents map[uuid.UUID][]openIncident
+
+	// History of the alerts in the buffer, by alert ID
+	events   map[uuid.UUID][]domain.AlertEvent
+	eventsMu sync.RWMutex
+
+	// Inhibit rules, and the rules whose notifications inhibited alerts are
+	// held back for, by alert ID
+	inhibitRules map[uuid.UUID]*domain.InhibitRule
+	inhibited    map[uuid.UUID]domain.AlertRule
+
+	// Alerts held back to be sent together, by group key
+	groups   map[string]*alertGroup
+	groupsMu sync.Mutex
+
+	// Recent MCP call samples for rule evaluation, oldest first
+	samples   []domain.CallSample
+	samplesMu sync.RWMutex
+
+	stop     chan struct{}
+	stopOnce sync.Once
+
+	// Cancelled on shutdown, ending deliveries still in flight
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// Live subscribers (SSE streams)
+	subscribers map[chan domain.Alert]struct{}
+	subMu       sync.Mutex
 }
 
-// NewService creates a new alerting service.
-func NewService(logger zerolog.Logger, repo *repository.AlertRepository) *Service {
+// NewService creates a new alerting service that keeps up to bufferSize recent
+// alerts in memory. Alert times and metric windows are read from clk.
+func NewService(logger zerolog.Logger, repo repository.AlertStore, metricRepo *repository.MetricRepository, bufferSize int, clk clock.Clock) *Service {
+	ctx, cancel := context.WithCancel(context.Background())
 	s := &Service{
-		logger:   logger,
-		repo:     repo,
-		rules:    make(map[uuid.UUID]*domain.AlertRule),
-		channels: make(map[uuid.UUID]*domain.AlertChannel),
-		alerts:   make([]domain.Alert, 0),
-		client:   &http.Client{Timeout: 10 * time.Second},
-		metrics:  make(map[string]float64),
+		ctx:        ctx,
+		cancel:     cancel,
+		logger:     logger,
+		repo:       repo,
+		metricRepo: metricRepo,
+		clock:      clk,
+		rules:      make(map[uuid.UUID]*domain.AlertRule),
+		deleted:    make(map[uuid.UUID]*domain.AlertRule),
+		channels:   make(map[uuid.UUID]*domain.AlertChannel),
+		alerts:     make([]domain.Alert, 0),
+		alertRing:  ringbuf.New(bufferSize),
+		client:     &http.Client{Timeout: 10 * time.Second},
+		slack:      webhook.NewSlackClient(),
+		opsgenie:   webhook.NewOpsgenieClient(),
+		mailer:     email.NewSender(),
+		stop:       make(chan struct{}),
+		incidents:  make(map[uuid.UUID][]openIncident),
+		events:     make(map[uuid.UUID][]domain.AlertEvent),
+		groups:     make(map[string]*alertGroup),
+
+		inhibitRules: make(map[uuid.UUID]*domain.InhibitRule),
+		inhibited:    make(map[uuid.UUID]domain.AlertRule),
+
+		subscribers: make(map[chan domain.Alert]struct{}),
 	}
 
 	// Load from database if available
@@ -51,8 +125,11 @@ func NewService(logger zerolog.Logger, repo *repository.AlertRepository) *Servic
 		s.createDemoRule()
 	}
 
-	// Initialize demo metrics
-	s.initDemoMetrics()
+	// Restore recent call samples and start evaluating rules
+	if metricRepo != nil {
+		s.loadSamples()
+	}
+	go s.evaluationLoop()
 
 	logger.Info().Msg("Alerting service initialized")
 	return s
@@ -63,28 +140,57 @@ func (s *Service) loadFromDatabase() {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	// Load all rules (for demo org)
-	demoOrgID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
-	rules, err := s.repo.ListRules(ctx, demoOrgID, false)
+	// Load every organization's rules and channels, falling back to the
+	// demo org when organizations cannot be listed
+	orgIDs, err := s.repo.ListOrgIDs(ctx)
 	if err != nil {
-		s.logger.Warn().Err(err).Msg("Failed to load alert rules from database")
-	} else {
+		s.logger.Warn().Err(err).Msg("Failed to list organizations")
+	}
+	if len(orgIDs) == 0 {
+		orgIDs = []uuid.UUID{uuid.MustParse("00000000-0000-0000-0000-000000000001")}
+	}
+
+	for _, orgID := range orgIDs {
+		rules, err := s.repo.ListRules(ctx, orgID, false)
+		if err != nil {
+			s.logger.Warn().Err(err).Str("org_id", orgID.String()).Msg("Failed to load alert rules from database")
+			continue
+		}
 		for i := range rules {
 			s.rules[rules[i].ID] = &rules[i]
 		}
-		s.logger.Info().Int("count", len(rules)).Msg("Loaded alert rules from database")
-	}
 
-	// Load all channels
-	channels, err := s.repo.ListChannels(ctx, demoOrgID)
-	if err != nil {
-		s.logger.Warn().Err(err).Msg("Failed to load alert channels from database")
-	} else {
+		deleted, err := s.repo.ListDeletedRules(ctx, orgID)
+		if err != nil {
+			s.logger.Warn().Err(err).Str("org_id", orgID.String()).Msg("Failed to load deleted alert rules from database")
+		}
+		for i := range deleted {
+			s.deleted[deleted[i].ID] = &deleted[i]
+		}
+
+		channels, err := s.repo.ListChannels(ctx, orgID)
+		if err != nil {
+			s.logger.Warn().Err(err).Str("org_id", orgID.String()).Msg("Failed to load alert channels from database")
+			continue
+		}
 		for i := range channels {
 			s.channels[channels[i].ID] = &channels[i]
 		}
-		s.logger.Info().Int("count", len(channels)).Msg("Loaded alert channels from database")
+
+		inhibitRules, err := s.repo.ListInhibitRules(ctx, orgID)
+		if err != nil {
+			s.logger.Warn().Err(err).Str("org_id", orgID.String()).Msg("Failed to load inhibit rules from database")
+		}
+		for i := range inhibitRules {
+			s.inhibitRules[inhibitRules[i].ID] = &inhibitRules[i]
+		}
 	}
+	s.logger.Info().
+		Int("orgs", len(orgIDs)).
+		Int("rules", len(s.rules)).
+		Int("channels", len(s.channels)).
+		Int("inhibit_rules", len(s.inhibitRules)).
+		Msg("Loaded alert rules and channels from database")
 
 	// If no data, create defaults
 	if len(s.rules) == 0 && len(s.channels) == 0 {
@@ -127,8 +233,8 @@ func (s *Service) createDemoChannel() {
 			"icon_emoji":  ":warning:",
 		},
 		Enabled:   true,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		CreatedAt: s.clock.Now(),
+		UpdatedAt: s.clock.Now(),
 	}
 	s.channels[channel.ID] = channel
 }
@@ -146,22 +252,12 @@ func (s *Service) createDemoRule() {
 		Severity:      domain.AlertSeverityWarning,
 		Channels:      []uuid.UUID{uuid.MustParse("00000000-0000-0000-0000-000000000001")},
 		Enabled:       true,
-		CreatedAt:     time.Now(),
-		UpdatedAt:     time.Now(),
+		CreatedAt:     s.clock.Now(),
+		UpdatedAt:     s.clock.Now(),
 	}
 	s.rules[rule.ID] = rule
 }
 
-func (s *Service) initDemoMetrics() {
-	s.metrics["error_rate"] = 0.12
-	s.metrics["latency_p50"] = 45.0
-	s.metrics["latency_p95"] = 127.0
-	s.metrics["latency_p99"] = 250.0
-	s.metrics["request_rate"] = 1500.0
-	s.metrics["cost_per_hour"] = 175.0
-	s.metrics["cost_per_day"] = 4200.0
-}
-
 // CreateRule creates a new alert rule.
 func (s *Service) CreateRule(input domain.AlertRuleInput, orgID, userID uuid.UUID) *domain.AlertRule {
 	s.mu.Lock()
@@ -179,9 +275,10 @@ func (s *Service) CreateRule(input domain.AlertRuleInput, orgID, userID uuid.UUI
 		Severity:      input.Severity,
 		Channels:      input.Channels,
 		Filters:       input.Filters,
+		Templates:     input.Templates,
 		Enabled:       input.Enabled,
-		CreatedAt:     time.Now(),
-		UpdatedAt:     time.Now(),
+		CreatedAt:     s.clock.Now(),
+		UpdatedAt:     s.clock.Now(),
 		CreatedBy:     userID,
 	}
 
@@ -205,15 +302,42 @@ func (s *Service) CreateRule(input domain.AlertRuleInput, orgID, userID uuid.UUI
 	return rule
 }
 
-// GetRule returns a rule by ID.
-func (s *Service) GetRule(id uuid.UUID) *domain.AlertRule {
+// GetRule returns one of an organization's rules by ID.
+func (s *Service) GetRule(orgID, id uuid.UUID) *domain.AlertRule {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rule, exists := s.rules[id]
+	if !exists || rule.OrgID != orgID {
+		return nil
+	}
+	return rule
+}
+
+// ListRules returns an organization's rules, and its soft-deleted ones when
+// includeDeleted is set.
+func (s *Service) ListRules(orgID uuid.UUID, includeDeleted bool) []domain.AlertRule {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	return s.rules[id]
+
+	rules := make([]domain.AlertRule, 0)
+	for _, r := range s.rules {
+		if r.OrgID == orgID {
+			rules = append(rules, *r)
+		}
+	}
+	if includeDeleted {
+		for _, r := range s.deleted {
+			if r.OrgID == orgID {
+				rules = append(rules, *r)
+			}
+		}
+	}
+	return rules
 }
 
-// ListRules returns all rules.
-func (s *Service) ListRules() []domain.AlertRule {
+// allRules returns the rules of every organization.
+func (s *Service) allRules() []domain.AlertRule {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -225,12 +349,12 @@ func (s *Service) ListRules() []domain.AlertRule {
 }
 
 // UpdateRule updates an existing rule.
-func (s *Service) UpdateRule(id uuid.UUID, input domain.AlertRuleInput) *domain.AlertRule {
+func (s *Service) UpdateRule(orgID, id uuid.UUID, input domain.AlertRuleInput) *domain.AlertRule {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	rule, exists := s.rules[id]
-	if !exists {
+	if !exists || rule.OrgID != orgID {
 		return nil
 	}
 
@@ -243,8 +367,9 @@ func (s *Service) UpdateRule(id uuid.UUID, input domain.AlertRuleInput) *domain.
 	rule.Severity = input.Severity
 	rule.Channels = input.Channels
 	rule.Filters = input.Filters
+	rule.Templates = input.Templates
 	rule.Enabled = input.Enabled
-	rule.UpdatedAt = time.Now()
+	rule.UpdatedAt = s.clock.Now()
 
 	// Persist to database
 	if s.repo != nil {
@@ -258,12 +383,13 @@ func (s *Service) UpdateRule(id uuid.UUID, input domain.AlertRuleInput) *domain.
 	return rule
 }
 
-// DeleteRule deletes a rule.
-func (s *Service) DeleteRule(id uuid.UUID) bool {
+// DeleteRule soft-deletes a rule. It stops being evaluated and can be
+// restored with RestoreRule.
+func (s *Service) DeleteRule(orgID, id uuid.UUID) bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if _, exists := s.rules[id]; exists {
+	if rule, exists := s.rules[id]; exists && rule.OrgID == orgID {
 		// Delete from database
 		if s.repo != nil {
 			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -272,12 +398,41 @@ func (s *Service) DeleteRule(id uuid.UUID) bool {
 				s.logger.Error().Err(err).Msg("Failed to delete alert rule from database")
 			}
 		}
+		now := s.clock.Now()
+		rule.DeletedAt = &now
+		s.deleted[id] = rule
 		delete(s.rules, id)
 		return true
 	}
 	return false
 }
 
+// RestoreRule restores one of an organization's soft-deleted rules with its
+// settings intact.
+func (s *Service) RestoreRule(orgID, id uuid.UUID) *domain.AlertRule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rule, exists := s.deleted[id]
+	if !exists || rule.OrgID != orgID {
+		return nil
+	}
+
+	if s.repo != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := s.repo.RestoreRule(ctx, id); err != nil {
+			s.logger.Error().Err(err).Msg("Failed to restore alert rule in database")
+		}
+	}
+	rule.DeletedAt = nil
+	rule.UpdatedAt = s.clock.Now()
+	s.rules[id] = rule
+	delete(s.deleted, id)
+
+	return rule
+}
+
 // CreateChannel creates a new alert channel.
 func (s *Service) CreateChannel(input domain.AlertChannelInput, orgID uuid.UUID) *domain.AlertChannel {
 	s.mu.Lock()
@@ -289,9 +444,10 @@ func (s *Service) CreateChannel(input domain.AlertChannelInput, orgID uuid.UUID)
 		Name:      input.Name,
 		Type:      input.Type,
 		Config:    input.Config,
+		Grouping:  input.Grouping,
 		Enabled:   input.Enabled,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		CreatedAt: s.clock.Now(),
+		UpdatedAt: s.clock.Now(),
 	}
 
 	// Persist to database
@@ -314,40 +470,48 @@ func (s *Service) CreateChannel(input domain.AlertChannelInput, orgID uuid.UUID)
 	return channel
 }
 
-// GetChannel returns a channel by ID.
-func (s *Service) GetChannel(id uuid.UUID) *domain.AlertChannel {
+// GetChannel returns one of an organization's channels by ID.
+func (s *Service) GetChannel(orgID, id uuid.UUID) *domain.AlertChannel {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	return s.channels[id]
+
+	channel, exists := s.channels[id]
+	if !exists || channel.OrgID != orgID {
+		return nil
+	}
+	return channel
 }
 
-// ListChannels returns all channels.
-func (s *Service) ListChannels() []domain.AlertChannel {
+// ListChannels returns an organization's channels.
+func (s *Service) ListChannels(orgID uuid.UUID) []domain.AlertChannel {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	channels := make([]domain.AlertChannel, 0, len(s.channels))
+	channels := make([]domain.AlertChannel, 0)
 	for _, c := range s.channels {
-		channels = append(channels, *c)
+		if c.OrgID == orgID {
+			channels = append(channels, *c)
+		}
 	}
 	return channels
 }
 
 // UpdateChannel updates an existing channel.
-func (s *Service) UpdateChannel(id uuid.UUID, input domain.AlertChannelInput) *domain.AlertChannel {
+func (s *Service) UpdateChannel(orgID, id uuid.UUID, input domain.AlertChannelInput) *domain.AlertChannel {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	channel, exists := s.channels[id]
-	if !exists {
+	if !exists || channel.OrgID != orgID {
 		return nil
 	}
 
 	channel.Name = input.Name
 	channel.Type = input.Type
 	channel.Config = input.Config
+	channel.Grouping = input.Grouping
 	channel.Enabled = input.Enabled
-	channel.UpdatedAt = time.Now()
+	channel.UpdatedAt = s.clock.Now()
 
 	// Persist to database
 	if s.repo != nil {
@@ -362,11 +526,11 @@ func (s *Service) UpdateChannel(id uuid.UUID, input domain.AlertChannelInput) *d
 }
 
 // DeleteChannel deletes a channel.
-func (s *Service) DeleteChannel(id uuid.UUID) bool {
+func (s *Service) DeleteChannel(orgID, id uuid.UUID) bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if _, exists := s.channels[id]; exists {
+	if channel, exists := s.channels[id]; exists && channel.OrgID == orgID {
 		// Delete from database
 		if s.repo != nil {
 			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -382,12 +546,12 @@ func (s *Service) DeleteChannel(id uuid.UUID) bool {
 }
 
 // TestChannel tests a channel by sending a test notification.
-func (s *Service) TestChannel(id uuid.UUID) error {
+func (s *Service) TestChannel(orgID, id uuid.UUID) error {
 	s.mu.RLock()
 	channel, exists := s.channels[id]
 	s.mu.RUnlock()
 
-	if !exists {
+	if !exists || channel.OrgID != orgID {
 		return fmt.Errorf("channel not found")
 	}
 
@@ -398,10 +562,12 @@ func (s *Service) TestChannel(id uuid.UUID) error {
 		Message:   "This is a test alert from GatewayOps",
 		Value:     0,
 		Threshold: 0,
-		StartedAt: time.Now(),
+		StartedAt: s.clock.Now(),
 	}
 
-	return s.sendNotification(*channel, testAlert, "Test Alert Rule")
+	ctx, cancel := context.WithTimeout(s.ctx, deliveryTimeout)
+	defer cancel()
+	return s.sendNotification(ctx, *channel, testAlert, "Test Alert Rule")
 }
 
 // CreateAlert creates a new alert and sends notifications.
@@ -423,12 +589,13 @@ func (s *Service) CreateAlert(ruleID uuid.UUID, value float64, message string) *
 		Message:   message,
 		Value:     value,
 		Threshold: rule.Threshold,
-		Labels: domain.Labels{
-			"metric":    string(rule.Metric),
-			"rule_name": rule.Name,
-		},
-		StartedAt: time.Now(),
+		Labels:    ruleLabels(*rule),
+		StartedAt: s.clock.Now(),
 	}
+	s.recordEvent(alert, domain.AlertEventCreated, domain.SystemActor(), createdDetails(alert))
+
+	// Send notifications
+	s.notifyOrInhibit(&alert, *rule)
 
 	// Persist to database
 	if s.repo != nil {
@@ -439,14 +606,8 @@ func (s *Service) CreateAlert(ruleID uuid.UUID, value float64, message string) *
 		}
 	}
 
-	// Keep only last 1000 alerts in memory
-	if len(s.alerts) >= 1000 {
-		s.alerts = s.alerts[1:]
-	}
-	s.alerts = append(s.alerts, alert)
-
-	// Send notifications
-	go s.notifyChannels(alert, *rule)
+	s.storeAlert(alert)
+	s.publish(alert)
 
 	s.logger.Warn().
 		Str("alert_id", alert.ID.String()).
@@ -459,16 +620,119 @@ func (s *Service) CreateAlert(ruleID uuid.UUID, value float64, message string) *
 	return &alert
 }
 
-// ResolveAlert resolves an existing alert.
-func (s *Service) ResolveAlert(id uuid.UUID) *domain.Alert {
+// storeAlert keeps an alert in the in-memory buffer, evicting the oldest
+// alert and forgetting its incidents and held-back notification once the
+// buffer is full. The caller must
+// hold s.mu.
+func (s *Service) storeAlert(alert domain.Alert) {
+	i, full := s.alertRing.Slot(len(s.alerts))
+	if !full {
+		s.alerts = append(s.alerts, alert)
+		return
+	}
+	delete(s.incidents, s.alerts[i].ID)
+	delete(s.inhibited, s.alerts[i].ID)
+	s.forgetEvents(s.alerts[i].ID)
+	s.alerts[i] = alert
+}
+
+// createdDetails describes a new alert in its created event.
+func createdDetails(alert domain.Alert) map[string]interface{} {
+	return map[string]interface{}{
+		"severity":  alert.Severity,
+		"message":   alert.Message,
+		"value":     alert.Value,
+		"threshold": alert.Threshold,
+	}
+}
+
+// ruleLabels returns the labels attached to alerts fired by a rule,
+// including the dimensions the rule is scoped to.
+func ruleLabels(rule domain.AlertRule) domain.Labels {
+	labels := domain.Labels{
+		"metric":    string(rule.Metric),
+		"rule_name": rule.Name,
+	}
+	if len(rule.Filters.MCPServers) > 0 {
+		labels["mcp_server"] = strings.Join(rule.Filters.MCPServers, ",")
+	}
+	if len(rule.Filters.Tools) > 0 {
+		labels["tool"] = strings.Join(rule.Filters.Tools, ",")
+	}
+	if len(rule.Filters.Environments) > 0 {
+		labels["environment"] = strings.Join(rule.Filters.Environments, ",")
+	}
+	return labels
+}
+
+// CreateSystemAlert raises an alert that is not backed by a rule, such as a
+// budget warning. It notifies the given channels, or every enabled channel in
+// the organization when none are given.
+func (s *Service) CreateSystemAlert(orgID uuid.UUID, name string, metric domain.AlertMetric, severity domain.AlertSeverity, value, threshold float64, message string, channels []uuid.UUID) *domain.Alert {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	alert := domain.Alert{
+		ID:        uuid.New(),
+		OrgID:     orgID,
+		Status:    domain.AlertStatusFiring,
+		Severity:  severity,
+		Message:   message,
+		Value:     value,
+		Threshold: threshold,
+		Labels: domain.Labels{
+			"metric":    string(metric),
+			"rule_name": name,
+		},
+		StartedAt: s.clock.Now(),
+	}
+	s.recordEvent(alert, domain.AlertEventCreated, domain.SystemActor(), createdDetails(alert))
+
+	if len(channels) == 0 {
+		for id, channel := range s.channels {
+			if channel.OrgID == orgID && channel.Enabled {
+				channels = append(channels, id)
+			}
+		}
+	}
+
+	rule := domain.AlertRule{
+		OrgID:    orgID,
+		Name:     name,
+		Metric:   metric,
+		Severity: severity,
+		Channels: channels,
+	}
+	s.notifyOrInhibit(&alert, rule)
+
+	s.storeAlert(alert)
+	s.publish(alert)
+
+	s.logger.Warn().
+		Str("alert_id", alert.ID.String()).
+		Str("metric", string(metric)).
+		Str("severity", string(severity)).
+		Float64("value", value).
+		Float64("threshold", threshold).
+		Msg("System alert created")
+
+	return &alert
+}
+
+// ResolveAlert resolves an existing alert on behalf of actor.
+func (s *Service) ResolveAlert(orgID, id uuid.UUID, actor domain.AlertActor) *domain.Alert {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	for i := range s.alerts {
-		if s.alerts[i].ID == id {
-			now := time.Now()
+		if s.alerts[i].ID == id && s.alerts[i].OrgID == orgID {
+			now := s.clock.Now()
+			previous := s.alerts[i].Status
 			s.alerts[i].Status = domain.AlertStatusResolved
 			s.alerts[i].ResolvedAt = &now
+			if previous != domain.AlertStatusResolved {
+				s.recordEvent(s.alerts[i], domain.AlertEventResolved, actor, nil)
+			}
 
 			// Persist to database
 			if s.repo != nil {
@@ -479,23 +743,41 @@ func (s *Service) ResolveAlert(id uuid.UUID) *domain.Alert {
 				}
 			}
 
-			return &s.alerts[i]
+			if incidents := s.incidents[id]; len(incidents) > 0 {
+				delete(s.incidents, id)
+				go s.resolveIncidents(s.alerts[i], incidents)
+			}
+
+			// The alert's own notification is no longer due, while those
+			// it held back are
+			delete(s.inhibited, id)
+			s.releaseInhibited(id)
+
+			// Return a copy: the slot may be updated or reused once the
+			// lock is released
+			alert := s.alerts[i]
+			s.publish(alert)
+			return &alert
 		}
 	}
 	return nil
 }
 
-// AcknowledgeAlert acknowledges an alert.
-func (s *Service) AcknowledgeAlert(id uuid.UUID, userID uuid.UUID) *domain.Alert {
+// AcknowledgeAlert acknowledges an alert on behalf of actor.
+func (s *Service) AcknowledgeAlert(orgID, id uuid.UUID, actor domain.AlertActor) *domain.Alert {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	for i := range s.alerts {
-		if s.alerts[i].ID == id {
-			now := time.Now()
+		if s.alerts[i].ID == id && s.alerts[i].OrgID == orgID {
+			now := s.clock.Now()
+			previous := s.alerts[i].Status
 			s.alerts[i].Status = domain.AlertStatusAcked
 			s.alerts[i].AckedAt = &now
-			s.alerts[i].AckedBy = &userID
+			s.alerts[i].AckedBy = actor.UserID
+			if previous != domain.AlertStatusAcked {
+				s.recordEvent(s.alerts[i], domain.AlertEventAcked, actor, nil)
+			}
 
 			// Persist to database
 			if s.repo != nil {
@@ -506,7 +788,9 @@ func (s *Service) AcknowledgeAlert(id uuid.UUID, userID uuid.UUID) *domain.Alert
 				}
 			}
 
-			return &s.alerts[i]
+			alert := s.alerts[i]
+			s.publish(alert)
+			return &alert
 		}
 	}
 	return nil
@@ -514,9 +798,9 @@ func (s *Service) AcknowledgeAlert(id uuid.UUID, userID uuid.UUID) *domain.Alert
 
 // GetAlerts returns alerts matching the filter.
 func (s *Service) GetAlerts(filter domain.AlertFilter) domain.AlertPage {
+	// Copy the matches out under the read lock so the sort below works on a
+	// slice of its own and never touches s.alerts.
 	s.mu.RLock()
-	defer s.mu.RUnlock()
-
 	filtered := make([]domain.Alert, 0)
 	for _, alert := range s.alerts {
 		if !s.matchesAlertFilter(alert, filter) {
@@ -524,11 +808,12 @@ func (s *Service) GetAlerts(filter domain.AlertFilter) domain.AlertPage {
 		}
 		filtered = append(filtered, alert)
 	}
+	s.mu.RUnlock()
 
-	// Sort by most recent first
-	for i, j := 0, len(filtered)-1; i < j; i, j = i+1, j-1 {
-		filtered[i], filtered[j] = filtered[j], filtered[i]
-	}
+	// Sort by most recent first, breaking ties by ID so pages are stable.
+	sort.Slice(filtered, func(i, j int) bool {
+		return domain.NewerFirst(filtered[i].StartedAt, filtered[i].ID, filtered[j].StartedAt, filtered[j].ID)
+	})
 
 	total := int64(len(filtered))
 	limit := filter.Limit
@@ -538,6 +823,13 @@ func (s *Service) GetAlerts(filter domain.AlertFilter) domain.AlertPage {
 	offset := filter.Offset
 
 	start := offset
+	if filter.Cursor != nil {
+		// Keyset pagination takes precedence over offset.
+		start = domain.CursorStart(len(filtered), filter.Cursor, func(i int) (time.Time, uuid.UUID) {
+			return filtered[i].StartedAt, filtered[i].ID
+		})
+		offset = 0
+	}
 	if start > len(filtered) {
 		start = len(filtered)
 	}
@@ -546,36 +838,95 @@ func (s *Service) GetAlerts(filter domain.AlertFilter) domain.AlertPage {
 		end = len(filtered)
 	}
 
-	return domain.AlertPage{
+	page := domain.AlertPage{
 		Alerts:  filtered[start:end],
 		Total:   total,
 		Limit:   limit,
 		Offset:  offset,
 		HasMore: end < len(filtered),
 	}
+	if page.HasMore && end > start {
+		page.NextCursor = domain.NewCursor(filtered[end-1].StartedAt, filtered[end-1].ID).Encode()
+	}
+	return page
+}
+
+// AlertOrg returns the organization an alert belongs to. It is for inbound
+// callbacks, which identify an alert but act for no organization.
+func (s *Service) AlertOrg(id uuid.UUID) (uuid.UUID, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, alert := range s.alerts {
+		if alert.ID == id {
+			return alert.OrgID, true
+		}
+	}
+	return uuid.Nil, false
 }
 
-// GetActiveAlerts returns all currently firing alerts.
-func (s *Service) GetActiveAlerts() []domain.Alert {
+// GetActiveAlerts returns an organization's currently firing alerts.
+func (s *Service) GetActiveAlerts(orgID uuid.UUID) []domain.Alert {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	active := make([]domain.Alert, 0)
 	for _, alert := range s.alerts {
-		if alert.Status == domain.AlertStatusFiring {
+		if alert.OrgID == orgID && alert.Status == domain.AlertStatusFiring {
 			active = append(active, alert)
 		}
 	}
 	return active
 }
 
+// ActiveAlertCounts returns the number of firing alerts across all
+// organizations, by severity.
+func (s *Service) ActiveAlertCounts() map[domain.AlertSeverity]int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := make(map[domain.AlertSeverity]int)
+	for _, alert := range s.alerts {
+		if alert.Status == domain.AlertStatusFiring {
+			counts[alert.Severity]++
+		}
+	}
+	return counts
+}
+
+// BufferStats describes the in-memory alert buffer.
+func (s *Service) BufferStats() ringbuf.Stats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.alertRing.Stats(len(s.alerts))
+}
+
+// CountActiveAlerts returns the number of firing alerts for an organization,
+// from the database when one is configured.
+func (s *Service) CountActiveAlerts(ctx context.Context, orgID uuid.UUID) (int64, error) {
+	if s.repo != nil {
+		return s.repo.CountActiveAlerts(ctx, orgID)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var count int64
+	for _, alert := range s.alerts {
+		if alert.OrgID == orgID && alert.Status == domain.AlertStatusFiring {
+			count++
+		}
+	}
+	return count, nil
+}
+
 // TriggerTestAlert creates a test alert for demo purposes.
-func (s *Service) TriggerTestAlert(metric string, value float64) *domain.Alert {
+func (s *Service) TriggerTestAlert(orgID uuid.UUID, metric string, value float64) *domain.Alert {
 	s.mu.RLock()
-	// Find a rule that matches this metric
+	// Find one of the org's rules that matches this metric
 	var matchingRule *domain.AlertRule
 	for _, rule := range s.rules {
-		if string(rule.Metric) == metric && rule.Enabled {
+		if rule.OrgID == orgID && string(rule.Metric) == metric && rule.Enabled {
 			matchingRule = rule
 			break
 		}
@@ -587,7 +938,7 @@ func (s *Service) TriggerTestAlert(metric string, value float64) *domain.Alert {
 		s.mu.Lock()
 		alert := domain.Alert{
 			ID:       uuid.New(),
-			OrgID:    uuid.MustParse("00000000-0000-0000-0000-000000000001"),
+			OrgID:    orgID,
 			Status:   domain.AlertStatusFiring,
 			Severity: domain.AlertSeverityWarning,
 			Message:  fmt.Sprintf("Test alert: %s = %.2f", metric, value),
@@ -596,9 +947,9 @@ func (s *Service) TriggerTestAlert(metric string, value float64) *domain.Alert {
 				"metric": metric,
 				"type":   "test",
 			},
-			StartedAt: time.Now(),
+			StartedAt: s.clock.Now(),
 		}
-		s.alerts = append(s.alerts, alert)
+		s.storeAlert(alert)
 		s.mu.Unlock()
 		return &alert
 	}
@@ -607,6 +958,9 @@ func (s *Service) TriggerTestAlert(metric string, value float64) *domain.Alert {
 }
 
 func (s *Service) matchesAlertFilter(alert domain.Alert, filter domain.AlertFilter) bool {
+	if alert.OrgID != filter.OrgID {
+		return false
+	}
 	if filter.RuleID != nil && alert.RuleID != *filter.RuleID {
 		return false
 	}
@@ -647,34 +1001,197 @@ func (s *Service) matchesAlertFilter(alert domain.Alert, filter domain.AlertFilt
 	return true
 }
 
+// Subscribe returns a channel that receives alerts as they are created or
+// change status, and a function to cancel the subscription.
+func (s *Service) Subscribe() (<-chan domain.Alert, func()) {
+	ch := make(chan domain.Alert, 64)
+
+	s.subMu.Lock()
+	s.subscribers[ch] = struct{}{}
+	s.subMu.Unlock()
+
+	return ch, func() {
+		s.subMu.Lock()
+		delete(s.subscribers, ch)
+		s.subMu.Unlock()
+	}
+}
+
+// publish delivers an alert to live subscribers without blocking.
+func (s *Service) publish(alert domain.Alert) {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+
+	for ch := range s.subscribers {
+		select {
+		case ch <- alert:
+		default:
+			s.logger.Warn().Str("alert_id", alert.ID.String()).Msg("Alert subscriber is slow, dropping event")
+		}
+	}
+}
+
+// notifyChannels delivers an alert to its rule's channels and records the
+// outcome in the alert's history.
 func (s *Service) notifyChannels(alert domain.Alert, rule domain.AlertRule) {
-	for _, channelID := range rule.Channels {
+	deliveries := s.deliver(s.ctx, alert, rule)
+	if len(deliveries) > 0 {
+		s.recordEvent(alert, domain.AlertEventNotified, domain.SystemActor(), map[string]interface{}{"deliveries": deliveries})
+	}
+}
+
+// deliver sends an alert to each of the rule's channels and reports the
+// outcome per channel, in the rule's order. Missing and disabled channels are
+// reported as skipped, and channels that group alerts as grouped. Up to
+// maxConcurrentDeliveries channels are sent to at once, each within
+// deliveryTimeout; sends not yet started when ctx is done fail with its error.
+func (s *Service) deliver(ctx context.Context, alert domain.Alert, rule domain.AlertRule) []domain.ChannelDelivery {
+	deliveries := make([]domain.ChannelDelivery, len(rule.Channels))
+	sem := make(chan struct{}, maxConcurrentDeliveries)
+	var wg sync.WaitGroup
+	for i, channelID := range rule.Channels {
 		s.mu.RLock()
 		channel, exists := s.channels[channelID]
+		var snapshot domain.AlertChannel
+		if exists {
+			snapshot = *channel
+		}
 		s.mu.RUnlock()
 
-		if !exists || !channel.Enabled {
+		result := &deliveries[i]
+		result.ChannelID = channelID
+		if !exists {
+			result.Skipped = true
+			result.Error = "channel not found"
+			continue
+		}
+		result.ChannelName = snapshot.Name
+		result.ChannelType = snapshot.Type
+		if !snapshot.Enabled {
+			result.Skipped = true
+			result.Error = "channel disabled"
 			continue
 		}
 
-		if err := s.sendNotification(*channel, alert, rule.Name); err != nil {
-			s.logger.Error().
-				Err(err).
-				Str("channel_id", channelID.String()).
-				Str("channel_type", string(channel.Type)).
-				Msg("Failed to send notification")
+		delivered := alert
+		delivered.Message = s.messageFor(rule, alert, snapshot.Type)
+
+		if snapshot.Grouping.Enabled() && !isIncidentChannel(snapshot.Type) && alert.Labels["test"] != "true" {
+			s.enqueueGrouped(snapshot, delivered, rule.Name)
+			result.Grouped = true
+			continue
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			result.Error = ctx.Err().Error()
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			s.deliverTo(ctx, snapshot, delivered, rule.Name, result)
+		}()
+	}
+	wg.Wait()
+	return deliveries
+}
+
+// deliverTo sends an alert to one channel within deliveryTimeout, recording
+// the outcome in result.
+func (s *Service) deliverTo(ctx context.Context, channel domain.AlertChannel, alert domain.Alert, ruleName string, result *domain.ChannelDelivery) {
+	ctx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := s.sendNotification(ctx, channel, alert, ruleName)
+	result.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		s.logger.Error().
+			Err(err).
+			Str("channel_id", channel.ID.String()).
+			Str("channel_type", string(channel.Type)).
+			Msg("Failed to send notification")
+		return
+	}
+	result.Success = true
+	if isIncidentChannel(channel.Type) && alert.Labels["test"] != "true" {
+		s.trackIncident(alert.ID, openIncident{channelID: channel.ID, key: incidentKey(channel.Type, alert)})
+	}
+}
+
+// TestFireRule builds a synthetic alert from a rule and delivers it through
+// the rule's channels, returning the per-channel outcome. The alert carries a
+// "test" label and is neither stored, streamed nor counted in alert history.
+func (s *Service) TestFireRule(ctx context.Context, orgID, id uuid.UUID) (*domain.TestFireResult, error) {
+	s.mu.RLock()
+	rule, exists := s.rules[id]
+	if exists && rule.OrgID != orgID {
+		exists = false
+	}
+	var snapshot domain.AlertRule
+	if exists {
+		snapshot = *rule
+	}
+	s.mu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("rule not found")
+	}
+
+	labels := ruleLabels(snapshot)
+	labels["test"] = "true"
+	alert := domain.Alert{
+		ID:        uuid.New(),
+		OrgID:     snapshot.OrgID,
+		RuleID:    snapshot.ID,
+		Status:    domain.AlertStatusFiring,
+		Severity:  snapshot.Severity,
+		Message:   fmt.Sprintf("[TEST] %s: %s %s %.4g", snapshot.Name, snapshot.Metric, snapshot.Condition, snapshot.Threshold),
+		Value:     snapshot.Threshold,
+		Threshold: snapshot.Threshold,
+		Labels:    labels,
+		StartedAt: s.clock.Now(),
+	}
+
+	result := &domain.TestFireResult{
+		RuleID:     snapshot.ID,
+		Alert:      alert,
+		Deliveries: s.deliver(ctx, alert, snapshot),
+	}
+	for _, d := range result.Deliveries {
+		switch {
+		case d.Success:
+			result.Delivered++
+		case !d.Skipped:
+			result.Failed++
 		}
 	}
+
+	s.logger.Info().
+		Str("rule_id", snapshot.ID.String()).
+		Int("delivered", result.Delivered).
+		Int("failed", result.Failed).
+		Msg("Alert rule test-fired")
+
+	return result, nil
 }
 
-func (s *Service) sendNotification(channel domain.AlertChannel, alert domain.Alert, ruleName string) error {
+func (s *Service) sendNotification(ctx context.Context, channel domain.AlertChannel, alert domain.Alert, ruleName string) error {
 	switch channel.Type {
 	case domain.AlertChannelSlack:
-		return s.sendSlackNotification(channel, alert, ruleName)
+		return s.sendSlackNotification(ctx, channel, alert, ruleName)
 	case domain.AlertChannelPagerDuty:
-		return s.sendPagerDutyNotification(channel, alert, ruleName)
+		return s.sendPagerDutyNotification(ctx, channel, alert, ruleName)
 	case domain.AlertChannelWebhook:
-		return s.sendWebhookNotification(channel, alert, ruleName)
+		return s.sendWebhookNotification(ctx, channel, alert, ruleName)
+	case domain.AlertChannelEmail:
+		return s.sendEmailNotification(ctx, channel, alert, ruleName)
+	case domain.AlertChannelOpsgenie:
+		return s.sendOpsgenieNotification(ctx, channel, alert, ruleName)
 	default:
 		s.logger.Debug().
 			Str("channel_type", string(channel.Type)).
@@ -683,7 +1200,7 @@ func (s *Service) sendNotification(channel domain.AlertChannel, alert domain.Ale
 	}
 }
 
-func (s *Service) sendSlackNotification(channel domain.AlertChannel, alert domain.Alert, ruleName string) error {
+func (s *Service) sendSlackNotification(ctx context.Context, channel domain.AlertChannel, alert domain.Alert, ruleName string) error {
 	webhookURL, ok := channel.Config["webhook_url"].(string)
 	if !ok || webhookURL == "" {
 		return fmt.Errorf("slack webhook_url not configured")
@@ -699,35 +1216,21 @@ func (s *Service) sendSlackNotification(channel domain.AlertChannel, alert domai
 		return nil
 	}
 
-	color := "#36a64f" // green
-	switch alert.Severity {
-	case domain.AlertSeverityWarning:
-		color = "#ffcc00"
-	case domain.AlertSeverityCritical:
-		color = "#ff0000"
-	}
-
-	payload := map[string]interface{}{
-		"attachments": []map[string]interface{}{
-			{
-				"color":  color,
-				"title":  fmt.Sprintf("[%s] %s", alert.Severity, ruleName),
-				"text":   alert.Message,
-				"fields": []map[string]interface{}{
-					{"title": "Value", "value": fmt.Sprintf("%.2f", alert.Value), "short": true},
-					{"title": "Threshold", "value": fmt.Sprintf("%.2f", alert.Threshold), "short": true},
-					{"title": "Status", "value": string(alert.Status), "short": true},
-				},
-				"footer": "GatewayOps",
-				"ts":     alert.StartedAt.Unix(),
-			},
-		},
-	}
+	ctx, cancel := context.WithTimeout(ctx, slackDeliveryTimeout)
+	defer cancel()
 
-	return s.postJSON(webhookURL, payload)
+	return s.slack.SendAlert(ctx, webhookURL, webhook.SlackAlert{
+		Title:     ruleName,
+		Message:   alert.Message,
+		Severity:  string(alert.Severity),
+		Value:     alert.Value,
+		Threshold: alert.Threshold,
+		Metric:    alert.Labels["metric"],
+		StartedAt: alert.StartedAt,
+	})
 }
 
-func (s *Service) sendPagerDutyNotification(channel domain.AlertChannel, alert domain.Alert, ruleName string) error {
+func (s *Service) sendPagerDutyNotification(ctx context.Context, channel domain.AlertChannel, alert domain.Alert, ruleName string) error {
 	routingKey, ok := channel.Config["routing_key"].(string)
 	if !ok || routingKey == "" {
 		return fmt.Errorf("pagerduty routing_key not configured")
@@ -744,7 +1247,7 @@ func (s *Service) sendPagerDutyNotification(channel domain.AlertChannel, alert d
 	payload := map[string]interface{}{
 		"routing_key":  routingKey,
 		"event_action": "trigger",
-		"dedup_key":    alert.ID.String(),
+		"dedup_key":    incidentKey(domain.AlertChannelPagerDuty, alert),
 		"payload": map[string]interface{}{
 			"summary":   fmt.Sprintf("[GatewayOps] %s: %s", ruleName, alert.Message),
 			"severity":  severity,
@@ -757,10 +1260,10 @@ func (s *Service) sendPagerDutyNotification(channel domain.AlertChannel, alert d
 		},
 	}
 
-	return s.postJSON("https://events.pagerduty.com/v2/enqueue", payload)
+	return s.postJSON(ctx, pagerDutyEventsURL, payload)
 }
 
-func (s *Service) sendWebhookNotification(channel domain.AlertChannel, alert domain.Alert, ruleName string) error {
+func (s *Service) sendWebhookNotification(ctx context.Context, channel domain.AlertChannel, alert domain.Alert, ruleName string) error {
 	webhookURL, ok := channel.Config["url"].(string)
 	if !ok || webhookURL == "" {
 		return fmt.Errorf("webhook url not configured")
@@ -777,16 +1280,178 @@ func (s *Service) sendWebhookNotification(channel domain.AlertChannel, alert dom
 		"started_at": alert.StartedAt.Format(time.RFC3339),
 	}
 
-	return s.postJSON(webhookURL, payload)
+	return s.postJSON(ctx, webhookURL, payload)
+}
+
+// opsgenieEndpoint returns the API key and base URL for an Opsgenie channel.
+// An explicit api_url overrides the region, e.g. for a proxy.
+func opsgenieEndpoint(channel domain.AlertChannel) (string, string, error) {
+	apiKey, ok := channel.Config["api_key"].(string)
+	if !ok || apiKey == "" {
+		return "", "", fmt.Errorf("opsgenie api_key not configured")
+	}
+	if apiURL, ok := channel.Config["api_url"].(string); ok && apiURL != "" {
+		return apiKey, strings.TrimRight(apiURL, "/"), nil
+	}
+	region, _ := channel.Config["region"].(string)
+	baseURL, err := webhook.OpsgenieBaseURL(region)
+	if err != nil {
+		return "", "", err
+	}
+	return apiKey, baseURL, nil
+}
+
+func (s *Service) sendOpsgenieNotification(ctx context.Context, channel domain.AlertChannel, alert domain.Alert, ruleName string) error {
+	apiKey, baseURL, err := opsgenieEndpoint(channel)
+	if err != nil {
+		return err
+	}
+
+	details := map[string]string{
+		"alert_id":  alert.ID.String(),
+		"value":     fmt.Sprintf("%.4g", alert.Value),
+		"threshold": fmt.Sprintf("%.4g", alert.Threshold),
+	}
+	for key, value := range alert.Labels {
+		details[key] = value
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return s.opsgenie.CreateAlert(ctx, baseURL, apiKey, webhook.OpsgenieAlert{
+		Message:     fmt.Sprintf("[GatewayOps] %s", ruleName),
+		Alias:       incidentKey(domain.AlertChannelOpsgenie, alert),
+		Description: alert.Message,
+		Priority:    webhook.OpsgeniePriority(string(alert.Severity)),
+		Source:      "gatewayops",
+		Tags:        []string{"gatewayops", string(alert.Severity)},
+		Details:     details,
+	})
+}
+
+// openIncident is an incident opened on an incident-tracking channel, kept
+// so it can be resolved with the same key when the alert resolves.
+type openIncident struct {
+	channelID uuid.UUID
+	key       string
+}
+
+// isIncidentChannel reports whether a channel type opens incidents that must
+// be closed explicitly.
+func isIncidentChannel(channelType domain.AlertChannelType) bool {
+	return channelType == domain.AlertChannelPagerDuty || channelType == domain.AlertChannelOpsgenie
+}
+
+// incidentKey returns the dedup key used for an alert on an incident channel:
+// the alert ID for PagerDuty, and the rule ID (so repeats of a rule collapse)
+// for Opsgenie.
+func incidentKey(channelType domain.AlertChannelType, alert domain.Alert) string {
+	if channelType == domain.AlertChannelOpsgenie && alert.RuleID != uuid.Nil {
+		return alert.RuleID.String()
+	}
+	return alert.ID.String()
+}
+
+// trackIncident records an incident opened for an alert.
+func (s *Service) trackIncident(alertID uuid.UUID, incident openIncident) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.incidents[alertID] = append(s.incidents[alertID], incident)
+}
+
+// resolveIncidents closes the incidents opened for a resolved alert. Channels
+// whose trigger failed were never tracked, so there is nothing to resolve.
+func (s *Service) resolveIncidents(alert domain.Alert, incidents []openIncident) {
+	for _, incident := range incidents {
+		s.mu.RLock()
+		channel, exists := s.channels[incident.channelID]
+		s.mu.RUnlock()
+
+		if !exists {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		var err error
+		switch channel.Type {
+		case domain.AlertChannelPagerDuty:
+			routingKey, _ := channel.Config["routing_key"].(string)
+			err = s.postJSON(ctx, pagerDutyEventsURL, map[string]interface{}{
+				"routing_key":  routingKey,
+				"event_action": "resolve",
+				"dedup_key":    incident.key,
+			})
+		case domain.AlertChannelOpsgenie:
+			var apiKey, baseURL string
+			if apiKey, baseURL, err = opsgenieEndpoint(*channel); err == nil {
+				err = s.opsgenie.CloseAlert(ctx, baseURL, apiKey, incident.key, "Resolved in GatewayOps")
+			}
+		}
+		cancel()
+
+		if err != nil {
+			s.logger.Error().
+				Err(err).
+				Str("channel_id", incident.channelID.String()).
+				Str("channel_type", string(channel.Type)).
+				Str("alert_id", alert.ID.String()).
+				Msg("Failed to resolve incident")
+			continue
+		}
+
+		s.logger.Info().
+			Str("channel_id", incident.channelID.String()).
+			Str("channel_type", string(channel.Type)).
+			Str("alert_id", alert.ID.String()).
+			Msg("Incident resolved")
+	}
+}
+
+func (s *Service) sendEmailNotification(ctx context.Context, channel domain.AlertChannel, alert domain.Alert, ruleName string) error {
+	cfg, err := email.ConfigFromChannel(channel.Config)
+	if err != nil {
+		return fmt.Errorf("email channel misconfigured: %w", err)
+	}
+
+	severity := string(alert.Severity)
+	status := "FIRING"
+	if alert.Status == domain.AlertStatusResolved {
+		severity = "resolved"
+		status = "RESOLVED"
+	}
+
+	msg := email.Message{
+		Subject:  fmt.Sprintf("[GatewayOps][%s] %s", status, ruleName),
+		Title:    ruleName,
+		Severity: severity,
+		Text:     alert.Message,
+		Fields: []email.Field{
+			{Name: "Status", Value: string(alert.Status)},
+			{Name: "Value", Value: fmt.Sprintf("%.2f", alert.Value)},
+			{Name: "Threshold", Value: fmt.Sprintf("%.2f", alert.Threshold)},
+			{Name: "Started", Value: alert.StartedAt.UTC().Format(time.RFC1123)},
+		},
+		Link: "https://app.gatewayops.com/alerts",
+	}
+	for _, key := range []string{"metric", "mcp_server", "tool", "environment"} {
+		if v := alert.Labels[key]; v != "" {
+			msg.Fields = append(msg.Fields, email.Field{Name: key, Value: v})
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	return s.mailer.Send(ctx, cfg, msg)
 }
 
-func (s *Service) postJSON(url string, payload interface{}) error {
+func (s *Service) postJSON(ctx context.Context, url string, payload interface{}) error {
 	body, err := json.Marshal(payload)
 	if err != nil {
 		return err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
@@ -0,0 +1,141 @@
+package alerting
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/clock"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/google/uuid"
+)
+
+// TestEvaluateAndListAlertsConcurrently fires and resolves alerts while
+// other goroutines list and acknowledge them, and writes to every alert it
+// is handed. Run under -race it shows that listing sorts a private copy and
+// that returned alerts never alias the service's own.
+func TestEvaluateAndListAlertsConcurrently(t *testing.T) {
+	s := newTestService(t, clock.Real)
+	orgID := uuid.New()
+
+	var rules []domain.AlertRule
+	for i := 0; i < 4; i++ {
+		rules = append(rules, *s.CreateRule(domain.AlertRuleInput{
+			Name:      fmt.Sprintf("rule-%d", i),
+			Metric:    domain.AlertMetricErrorRate,
+			Condition: domain.AlertConditionGreaterThan,
+			Threshold: 50,
+			Severity:  domain.AlertSeverityWarning,
+			Enabled:   true,
+		}, orgID, uuid.New()))
+	}
+
+	const rounds = 50
+	var wg sync.WaitGroup
+	for _, rule := range rules {
+		wg.Add(1)
+		go func(rule domain.AlertRule) {
+			defer wg.Done()
+			for i := 0; i < rounds; i++ {
+				if fired := s.EvaluateRule(rule, 100); fired != nil {
+					fired.Message = "changed by the caller"
+				}
+				s.EvaluateRule(rule, 0)
+			}
+		}(rule)
+	}
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < rounds; i++ {
+				page := s.GetAlerts(domain.AlertFilter{OrgID: orgID, Limit: 20})
+				for j := range page.Alerts {
+					if j > 0 && domain.NewerFirst(page.Alerts[j].StartedAt, page.Alerts[j].ID, page.Alerts[j-1].StartedAt, page.Alerts[j-1].ID) {
+						t.Errorf("alerts listed out of order at %d", j)
+					}
+					page.Alerts[j].Message = "changed by the caller"
+				}
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < rounds; i++ {
+			for _, active := range s.GetActiveAlerts(orgID) {
+				if acked := s.AcknowledgeAlert(orgID, active.ID, domain.SystemActor()); acked != nil {
+					acked.Message = "changed by the caller"
+				}
+			}
+		}
+	}()
+	wg.Wait()
+
+	page := s.GetAlerts(domain.AlertFilter{OrgID: orgID, Limit: 1000})
+	if page.Total == 0 {
+		t.Fatal("no alerts were fired")
+	}
+	for _, alert := range page.Alerts {
+		if alert.Message == "changed by the caller" {
+			t.Fatalf("alert %s was changed through a returned copy", alert.ID)
+		}
+	}
+}
+
+func TestSoftDeletedRuleCanBeRestored(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	s := newTestService(t, clk)
+	orgID := uuid.New()
+	rule := s.CreateRule(domain.AlertRuleInput{
+		Name:          "Latency",
+		Metric:        domain.AlertMetricLatencyP95,
+		Condition:     domain.AlertConditionGreaterThan,
+		Threshold:     500,
+		WindowMinutes: 10,
+		Severity:      domain.AlertSeverityWarning,
+		Filters:       domain.AlertFilters{MCPServers: []string{"filesystem"}},
+		Enabled:       true,
+	}, orgID, uuid.New())
+	settings := *rule
+
+	if s.DeleteRule(uuid.New(), rule.ID) {
+		t.Fatal("another org deleted the rule")
+	}
+	clk.Advance(time.Minute)
+	if !s.DeleteRule(orgID, rule.ID) {
+		t.Fatal("DeleteRule = false")
+	}
+	if got := s.ListRules(orgID, false); len(got) != 0 {
+		t.Errorf("deleted rule still listed: %+v", got)
+	}
+	if s.GetRule(orgID, rule.ID) != nil {
+		t.Error("deleted rule still returned by GetRule")
+	}
+	deleted := s.ListRules(orgID, true)
+	if len(deleted) != 1 || deleted[0].DeletedAt == nil || !deleted[0].DeletedAt.Equal(clk.Now()) {
+		t.Fatalf("include_deleted listed %+v, want the rule marked deleted", deleted)
+	}
+	if s.DeleteRule(orgID, rule.ID) {
+		t.Error("deleted a rule twice")
+	}
+
+	if s.RestoreRule(uuid.New(), rule.ID) != nil {
+		t.Fatal("another org restored the rule")
+	}
+	restored := s.RestoreRule(orgID, rule.ID)
+	if restored == nil {
+		t.Fatal("RestoreRule = nil")
+	}
+	if restored.DeletedAt != nil || restored.Name != settings.Name || restored.Threshold != settings.Threshold ||
+		restored.WindowMinutes != settings.WindowMinutes || len(restored.Filters.MCPServers) != 1 || !restored.Enabled {
+		t.Errorf("restored %+v, want the settings of %+v", restored, settings)
+	}
+	if got := s.ListRules(orgID, false); len(got) != 1 || got[0].ID != rule.ID {
+		t.Errorf("restored rule not listed: %+v", got)
+	}
+	if s.RestoreRule(orgID, rule.ID) != nil {
+		t.Error("restored a rule that is not deleted")
+	}
+}
@@ -0,0 +1,146 @@
+package alerting
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/clock"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/repository"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// fakeAlertStore keeps alert rules and channels in maps, standing in for
+// the Postgres repository. Methods the tests do not reach are left
+// unimplemented.
+type fakeAlertStore struct {
+	repository.AlertStore
+	mu       sync.Mutex
+	rules    map[uuid.UUID]domain.AlertRule
+	channels map[uuid.UUID]domain.AlertChannel
+}
+
+func newFakeAlertStore() *fakeAlertStore {
+	return &fakeAlertStore{
+		rules:    make(map[uuid.UUID]domain.AlertRule),
+		channels: make(map[uuid.UUID]domain.AlertChannel),
+	}
+}
+
+func (f *fakeAlertStore) ListOrgIDs(ctx context.Context) ([]uuid.UUID, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	seen := make(map[uuid.UUID]bool)
+	var orgIDs []uuid.UUID
+	for _, rule := range f.rules {
+		if !seen[rule.OrgID] {
+			seen[rule.OrgID] = true
+			orgIDs = append(orgIDs, rule.OrgID)
+		}
+	}
+	return orgIDs, nil
+}
+
+func (f *fakeAlertStore) CreateRule(ctx context.Context, rule *domain.AlertRule) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rules[rule.ID] = *rule
+	return nil
+}
+
+func (f *fakeAlertStore) UpdateRule(ctx context.Context, rule *domain.AlertRule) error {
+	return f.CreateRule(ctx, rule)
+}
+
+func (f *fakeAlertStore) DeleteRule(ctx context.Context, id uuid.UUID) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	rule := f.rules[id]
+	now := time.Now()
+	rule.DeletedAt = &now
+	f.rules[id] = rule
+	return nil
+}
+
+func (f *fakeAlertStore) listRules(orgID uuid.UUID, deleted bool) []domain.AlertRule {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var rules []domain.AlertRule
+	for _, rule := range f.rules {
+		if rule.OrgID == orgID && (rule.DeletedAt != nil) == deleted {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+func (f *fakeAlertStore) ListRules(ctx context.Context, orgID uuid.UUID, enabledOnly bool) ([]domain.AlertRule, error) {
+	return f.listRules(orgID, false), nil
+}
+
+func (f *fakeAlertStore) ListDeletedRules(ctx context.Context, orgID uuid.UUID) ([]domain.AlertRule, error) {
+	return f.listRules(orgID, true), nil
+}
+
+func (f *fakeAlertStore) CreateChannel(ctx context.Context, channel *domain.AlertChannel) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.channels[channel.ID] = *channel
+	return nil
+}
+
+func (f *fakeAlertStore) ListChannels(ctx context.Context, orgID uuid.UUID) ([]domain.AlertChannel, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var channels []domain.AlertChannel
+	for _, channel := range f.channels {
+		if channel.OrgID == orgID {
+			channels = append(channels, channel)
+		}
+	}
+	return channels, nil
+}
+
+func (f *fakeAlertStore) ListInhibitRules(ctx context.Context, orgID uuid.UUID) ([]domain.InhibitRule, error) {
+	return nil, nil
+}
+
+func TestServicePersistsRulesThroughItsStore(t *testing.T) {
+	store := newFakeAlertStore()
+	orgID, userID := uuid.New(), uuid.New()
+	existing := domain.AlertRule{ID: uuid.New(), OrgID: orgID, Name: "Errors", Metric: domain.AlertMetricErrorRate, Condition: domain.AlertConditionGreaterThan, Threshold: 5, Enabled: true}
+	store.CreateRule(context.Background(), &existing)
+
+	newService := func() *Service {
+		s := NewService(zerolog.Nop(), store, nil, 100, clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)))
+		t.Cleanup(func() { s.Shutdown(context.Background()) })
+		return s
+	}
+	s := newService()
+	if rule := s.GetRule(orgID, existing.ID); rule == nil || rule.Name != "Errors" {
+		t.Fatalf("GetRule = %+v, want the rule loaded from the store", rule)
+	}
+
+	input := domain.AlertRuleInput{Name: "Latency", Metric: domain.AlertMetricLatencyP95, Condition: domain.AlertConditionGreaterThan, Threshold: 500, Severity: domain.AlertSeverityWarning, Enabled: true}
+	created := s.CreateRule(input, orgID, userID)
+	input.Threshold = 900
+	s.UpdateRule(orgID, created.ID, input)
+	s.DeleteRule(orgID, existing.ID)
+
+	if stored := store.rules[created.ID]; stored.Name != "Latency" || stored.Threshold != 900 {
+		t.Errorf("stored rule = %+v, want the update persisted", stored)
+	}
+	if store.rules[existing.ID].DeletedAt == nil {
+		t.Error("deleted rule is still live in the store")
+	}
+
+	// A service started later over the same store picks up the changes
+	restarted := newService()
+	rules := restarted.ListRules(orgID, false)
+	if len(rules) != 1 || rules[0].ID != created.ID || rules[0].Threshold != 900 {
+		t.Errorf("rules after restart = %+v, want only the updated rule", rules)
+	}
+}
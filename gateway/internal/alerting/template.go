@@ -0,0 +1,133 @@
+package alerting
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+)
+
+// templateFuncs are the helpers available to alert message templates.
+var templateFuncs = template.FuncMap{
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"title": func(s string) string {
+		if s == "" {
+			return s
+		}
+		return strings.ToUpper(s[:1]) + s[1:]
+	},
+	"humanize": func(v float64) string {
+		return fmt.Sprintf("%.4g", v)
+	},
+	"percent": func(v float64) string {
+		return fmt.Sprintf("%.2f%%", v)
+	},
+	"duration": func(ms float64) string {
+		return time.Duration(ms * float64(time.Millisecond)).String()
+	},
+	"join": strings.Join,
+	"default": func(fallback, value string) string {
+		if value == "" {
+			return fallback
+		}
+		return value
+	},
+}
+
+// templateData is the value alert message templates are executed against.
+type templateData struct {
+	Rule      domain.AlertRule
+	Alert     domain.Alert
+	Value     float64
+	Threshold float64
+	Labels    domain.Labels
+	Message   string
+}
+
+// TemplateError identifies which of a rule's templates failed to parse.
+type TemplateError struct {
+	Field string
+	Err   error
+}
+
+func (e *TemplateError) Error() string {
+	return fmt.Sprintf("templates.%s: %v", e.Field, e.Err)
+}
+
+func (e *TemplateError) Unwrap() error {
+	return e.Err
+}
+
+// ValidateTemplates parses each non-empty template and reports the first one
+// that is invalid.
+func ValidateTemplates(t domain.AlertTemplates) error {
+	for _, tmpl := range []struct {
+		field  string
+		source string
+	}{
+		{"default", t.Default},
+		{"slack", t.Slack},
+		{"pagerduty", t.PagerDuty},
+		{"webhook", t.Webhook},
+	} {
+		if tmpl.source == "" {
+			continue
+		}
+		if _, err := parseTemplate(tmpl.field, tmpl.source); err != nil {
+			return &TemplateError{Field: tmpl.field, Err: err}
+		}
+	}
+	return nil
+}
+
+// RenderMessage renders a template against an alert. Label lookups of missing
+// keys render as empty strings.
+func RenderMessage(source string, rule domain.AlertRule, alert domain.Alert) (string, error) {
+	tmpl, err := parseTemplate("message", source)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, templateData{
+		Rule:      rule,
+		Alert:     alert,
+		Value:     alert.Value,
+		Threshold: alert.Threshold,
+		Labels:    alert.Labels,
+		Message:   alert.Message,
+	})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+func parseTemplate(name, source string) (*template.Template, error) {
+	return template.New(name).Funcs(templateFuncs).Option("missingkey=zero").Parse(source)
+}
+
+// messageFor returns the alert message to send on a channel, rendering the
+// rule's template for the channel type and falling back to the default
+// message when there is no template or it fails to render.
+func (s *Service) messageFor(rule domain.AlertRule, alert domain.Alert, channelType domain.AlertChannelType) string {
+	source := rule.Templates.For(channelType)
+	if source == "" {
+		return alert.Message
+	}
+
+	message, err := RenderMessage(source, rule, alert)
+	if err != nil || message == "" {
+		s.logger.Warn().
+			Err(err).
+			Str("rule_id", rule.ID.String()).
+			Str("channel_type", string(channelType)).
+			Msg("Failed to render alert template, using default message")
+		return alert.Message
+	}
+	return message
+}
@@ -0,0 +1,95 @@
+package alerting
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/clock"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+)
+
+func TestRenderMessageWithLabel(t *testing.T) {
+	rule := domain.AlertRule{Name: "Shell errors", Metric: domain.AlertMetricErrorRate, Severity: domain.AlertSeverityCritical}
+	alert := domain.Alert{
+		Value:     12.5,
+		Threshold: 5,
+		Labels:    domain.Labels{"mcp_server": "shell", "runbook": "https://runbooks.example.com/shell"},
+		Message:   "Shell errors: error_rate is 12.5",
+	}
+
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{
+			name:   "label and helpers",
+			source: `{{upper (print .Rule.Severity)}} {{.Rule.Name}} on {{.Labels.mcp_server}}: {{percent .Value}} > {{humanize .Threshold}} ({{.Labels.runbook}})`,
+			want:   "CRITICAL Shell errors on shell: 12.50% > 5 (https://runbooks.example.com/shell)",
+		},
+		{
+			name:   "missing label",
+			source: `team={{default "none" .Labels.team}} {{.Labels.missing}}|`,
+			want:   "team=none |",
+		},
+		{
+			name:   "default message",
+			source: "  {{.Message}} - see {{index .Labels \"runbook\"}}\n",
+			want:   "Shell errors: error_rate is 12.5 - see https://runbooks.example.com/shell",
+		},
+	}
+	for _, tt := range tests {
+		got, err := RenderMessage(tt.source, rule, alert)
+		if err != nil {
+			t.Errorf("%s: RenderMessage: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: message = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTemplates(t *testing.T) {
+	if err := ValidateTemplates(domain.AlertTemplates{Default: "{{.Message}}", Slack: "{{.Labels.mcp_server}}"}); err != nil {
+		t.Errorf("valid templates rejected: %v", err)
+	}
+
+	err := ValidateTemplates(domain.AlertTemplates{Default: "{{.Message}}", PagerDuty: "{{.Value"})
+	var templateErr *TemplateError
+	if !errors.As(err, &templateErr) || templateErr.Field != "pagerduty" {
+		t.Errorf("err = %v, want the pagerduty template reported", err)
+	}
+	if err := ValidateTemplates(domain.AlertTemplates{Webhook: "{{nosuchfunc .Value}}"}); err == nil {
+		t.Error("a template calling an unknown function was accepted")
+	}
+}
+
+func TestMessageForPicksTheChannelTemplate(t *testing.T) {
+	s := newTestService(t, clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)))
+	alert := domain.Alert{Value: 12.5, Labels: domain.Labels{"mcp_server": "shell"}, Message: "default message"}
+	rule := domain.AlertRule{Name: "Shell errors", Templates: domain.AlertTemplates{
+		Default: "default: {{.Labels.mcp_server}}",
+		Slack:   "slack: {{.Labels.mcp_server}}",
+		Webhook: "{{.Value.Missing}}",
+	}}
+
+	for _, tt := range []struct {
+		channel domain.AlertChannelType
+		want    string
+	}{
+		{domain.AlertChannelSlack, "slack: shell"},
+		{domain.AlertChannelPagerDuty, "default: shell"},
+		// The webhook template fails to execute, so the default message is sent
+		{domain.AlertChannelWebhook, "default message"},
+	} {
+		if got := s.messageFor(rule, alert, tt.channel); got != tt.want {
+			t.Errorf("%s message = %q, want %q", tt.channel, got, tt.want)
+		}
+	}
+
+	if got := s.messageFor(domain.AlertRule{}, alert, domain.AlertChannelSlack); got != "default message" {
+		t.Errorf("message without templates = %q, want the default", got)
+	}
+}
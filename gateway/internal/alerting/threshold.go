@@ -0,0 +1,91 @@
+package alerting
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+)
+
+// metricRange is the values a metric can take, so that a rule's threshold
+// can be checked against them.
+type metricRange struct {
+	min, max float64 // max is +Inf for metrics without an upper bound
+	unit     string
+	measured bool // Values are measured rather than counted, so eq and neq almost never hold
+}
+
+// metricRanges are the ranges of the metrics rules can be created for.
+var metricRanges = map[domain.AlertMetric]metricRange{
+	domain.AlertMetricErrorRate:         {0, 100, "percent", true},
+	domain.AlertMetricLatencyP50:        {0, math.Inf(1), "milliseconds", true},
+	domain.AlertMetricLatencyP90:        {0, math.Inf(1), "milliseconds", true},
+	domain.AlertMetricLatencyP95:        {0, math.Inf(1), "milliseconds", true},
+	domain.AlertMetricLatencyP99:        {0, math.Inf(1), "milliseconds", true},
+	domain.AlertMetricRequestRate:       {0, math.Inf(1), "requests per minute", true},
+	domain.AlertMetricCostPerHour:       {0, math.Inf(1), "USD", true},
+	domain.AlertMetricCostPerDay:        {0, math.Inf(1), "USD", true},
+	domain.AlertMetricRateLimitHit:      {0, math.Inf(1), "requests", false},
+	domain.AlertMetricInjectionDetected: {0, math.Inf(1), "detections", false},
+	domain.AlertMetricBudgetUsage:       {0, math.Inf(1), "USD", true},
+}
+
+// ThresholdError identifies the field of a rule whose threshold or condition
+// makes no sense for its metric.
+type ThresholdError struct {
+	Field   string
+	Message string
+}
+
+func (e *ThresholdError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// ValidateThreshold checks that a rule's threshold lies within the values its
+// metric can take, and that its condition can both hold and fail to hold for
+// them, so the rule can fire. Metrics without a known range are not checked.
+func ValidateThreshold(metric domain.AlertMetric, condition domain.AlertCondition, threshold float64) error {
+	r, ok := metricRanges[metric]
+	if !ok {
+		return nil
+	}
+
+	if math.IsNaN(threshold) || threshold < r.min || threshold > r.max {
+		return &ThresholdError{Field: "threshold", Message: r.describe(metric)}
+	}
+
+	switch condition {
+	case domain.AlertConditionEqual, domain.AlertConditionNotEqual:
+		if r.measured {
+			return &ThresholdError{
+				Field:   "condition",
+				Message: fmt.Sprintf("%s is a measured value that almost never equals a threshold exactly; use gt, gte, lt or lte", metric),
+			}
+		}
+	case domain.AlertConditionGreaterThan:
+		if threshold == r.max {
+			return &ThresholdError{Field: "threshold", Message: fmt.Sprintf("%s can never be greater than %g", metric, r.max)}
+		}
+	case domain.AlertConditionLessThan:
+		if threshold == r.min {
+			return &ThresholdError{Field: "threshold", Message: fmt.Sprintf("%s can never be less than %g", metric, r.min)}
+		}
+	case domain.AlertConditionGreaterThanEqual:
+		if threshold == r.min {
+			return &ThresholdError{Field: "threshold", Message: fmt.Sprintf("%s is always at least %g, so the rule would always fire", metric, r.min)}
+		}
+	case domain.AlertConditionLessThanEqual:
+		if threshold == r.max {
+			return &ThresholdError{Field: "threshold", Message: fmt.Sprintf("%s is always at most %g, so the rule would always fire", metric, r.max)}
+		}
+	}
+	return nil
+}
+
+// describe explains the thresholds allowed for a metric.
+func (r metricRange) describe(metric domain.AlertMetric) string {
+	if math.IsInf(r.max, 1) {
+		return fmt.Sprintf("Threshold for %s is in %s and must be at least %g", metric, r.unit, r.min)
+	}
+	return fmt.Sprintf("Threshold for %s is in %s and must be between %g and %g", metric, r.unit, r.min, r.max)
+}
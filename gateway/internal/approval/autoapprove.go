@@ -0,0 +1,141 @@
+package approval
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/config"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/google/uuid"
+)
+
+// AutoApprover approves the requests its rules match without review. A nil
+// AutoApprover approves nothing.
+type AutoApprover struct {
+	rules []autoRule
+}
+
+type autoRule struct {
+	name      string
+	server    string // Empty matches every server
+	tool      string // Empty matches every tool
+	teams     map[uuid.UUID]bool
+	arguments map[string]argumentConstraint
+	ttl       time.Duration
+}
+
+type argumentConstraint struct {
+	equals     string
+	pathPrefix string
+	pattern    *regexp.Regexp
+}
+
+// NewAutoApprover compiles auto-approval rules, which are tried in order.
+func NewAutoApprover(rules []config.AutoApprovalRule) (*AutoApprover, error) {
+	a := &AutoApprover{}
+	for _, spec := range rules {
+		rule := autoRule{
+			name:      spec.Name,
+			server:    anyIfStar(spec.Server),
+			tool:      anyIfStar(spec.Tool),
+			arguments: make(map[string]argumentConstraint, len(spec.Arguments)),
+			ttl:       time.Duration(spec.ExpiresIn) * time.Second,
+		}
+		if len(spec.Teams) > 0 {
+			rule.teams = make(map[uuid.UUID]bool, len(spec.Teams))
+			for _, team := range spec.Teams {
+				id, err := uuid.Parse(team)
+				if err != nil {
+					return nil, fmt.Errorf("rule %q: team %q: %w", spec.Name, team, err)
+				}
+				rule.teams[id] = true
+			}
+		}
+		for name, c := range spec.Arguments {
+			constraint := argumentConstraint{equals: c.Equals}
+			if c.PathPrefix != "" {
+				constraint.pathPrefix = path.Clean(c.PathPrefix)
+			}
+			if c.Pattern != "" {
+				re, err := regexp.Compile("^(?:" + c.Pattern + ")$")
+				if err != nil {
+					return nil, fmt.Errorf("rule %q: argument %q: %w", spec.Name, name, err)
+				}
+				constraint.pattern = re
+			}
+			rule.arguments[name] = constraint
+		}
+		a.rules = append(a.rules, rule)
+	}
+	return a, nil
+}
+
+// Match returns the name of the first rule that matches a request, and how
+// long the request is approved for under it.
+func (a *AutoApprover) Match(input domain.ToolApprovalRequest) (string, time.Duration, bool) {
+	if a == nil {
+		return "", 0, false
+	}
+	for _, rule := range a.rules {
+		if rule.matches(input) {
+			return rule.name, rule.ttl, true
+		}
+	}
+	return "", 0, false
+}
+
+// anyIfStar maps the * wildcard to the empty string that matches everything.
+func anyIfStar(s string) string {
+	if s == "*" {
+		return ""
+	}
+	return s
+}
+
+func (r autoRule) matches(input domain.ToolApprovalRequest) bool {
+	if r.server != "" && r.server != input.MCPServer {
+		return false
+	}
+	if r.tool != "" && r.tool != input.ToolName {
+		return false
+	}
+	if r.teams != nil && (input.TeamID == nil || !r.teams[*input.TeamID]) {
+		return false
+	}
+	for name, constraint := range r.arguments {
+		value, ok := input.Arguments[name]
+		if !ok || !constraint.allows(value) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c argumentConstraint) allows(value interface{}) bool {
+	if c.equals != "" && fmt.Sprint(value) != c.equals {
+		return false
+	}
+	if c.pathPrefix == "" && c.pattern == nil {
+		return true
+	}
+	s, ok := value.(string)
+	if !ok {
+		return false
+	}
+	if c.pathPrefix != "" && !withinDir(path.Clean(s), c.pathPrefix) {
+		return false
+	}
+	return c.pattern == nil || c.pattern.MatchString(s)
+}
+
+// withinDir reports whether a cleaned path is dir or inside it. Cleaning
+// resolves .. first, so /workspace/../etc is not inside /workspace.
+func withinDir(p, dir string) bool {
+	if p == dir || dir == "/" && strings.HasPrefix(p, "/") {
+		return true
+	}
+	return strings.HasPrefix(p, dir+"/")
+}
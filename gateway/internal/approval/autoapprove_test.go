@@ -0,0 +1,171 @@
+package approval
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/clock"
+	"github.com/akz4ol/gatewayops/gateway/internal/config"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+func TestAutoApproverMatch(t *testing.T) {
+	team := uuid.New()
+	a, err := NewAutoApprover([]config.AutoApprovalRule{
+		{
+			Name:      "workspace writes",
+			Server:    "filesystem",
+			Tool:      "write_file",
+			Arguments: map[string]config.ArgumentConstraint{"path": {PathPrefix: "/workspace/"}},
+			ExpiresIn: 300,
+		},
+		{
+			Name:      "read-only queries",
+			Server:    "database",
+			Tool:      "*",
+			Teams:     []string{team.String()},
+			Arguments: map[string]config.ArgumentConstraint{"query": {Pattern: `(?i)select .*`}, "readonly": {Equals: "true"}},
+			ExpiresIn: 60,
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewAutoApprover: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		input    domain.ToolApprovalRequest
+		wantRule string
+		wantTTL  time.Duration
+	}{
+		{
+			name:     "path inside the prefix",
+			input:    domain.ToolApprovalRequest{MCPServer: "filesystem", ToolName: "write_file", Arguments: map[string]interface{}{"path": "/workspace/src/main.go"}},
+			wantRule: "workspace writes",
+			wantTTL:  5 * time.Minute,
+		},
+		{
+			name:     "the prefix itself",
+			input:    domain.ToolApprovalRequest{MCPServer: "filesystem", ToolName: "write_file", Arguments: map[string]interface{}{"path": "/workspace"}},
+			wantRule: "workspace writes",
+			wantTTL:  5 * time.Minute,
+		},
+		{
+			name:  "path escaping the prefix",
+			input: domain.ToolApprovalRequest{MCPServer: "filesystem", ToolName: "write_file", Arguments: map[string]interface{}{"path": "/workspace/../etc/passwd"}},
+		},
+		{
+			name:  "sibling directory sharing the prefix",
+			input: domain.ToolApprovalRequest{MCPServer: "filesystem", ToolName: "write_file", Arguments: map[string]interface{}{"path": "/workspace-other/x"}},
+		},
+		{
+			name:  "missing argument",
+			input: domain.ToolApprovalRequest{MCPServer: "filesystem", ToolName: "write_file"},
+		},
+		{
+			name:  "non-string path",
+			input: domain.ToolApprovalRequest{MCPServer: "filesystem", ToolName: "write_file", Arguments: map[string]interface{}{"path": 42}},
+		},
+		{
+			name:  "other tool",
+			input: domain.ToolApprovalRequest{MCPServer: "filesystem", ToolName: "delete_file", Arguments: map[string]interface{}{"path": "/workspace/a"}},
+		},
+		{
+			name:     "any tool for the team",
+			input:    domain.ToolApprovalRequest{MCPServer: "database", ToolName: "execute_query", TeamID: &team, Arguments: map[string]interface{}{"query": "SELECT * FROM t", "readonly": true}},
+			wantRule: "read-only queries",
+			wantTTL:  time.Minute,
+		},
+		{
+			name:  "pattern must match the whole argument",
+			input: domain.ToolApprovalRequest{MCPServer: "database", ToolName: "execute_query", TeamID: &team, Arguments: map[string]interface{}{"query": "DELETE FROM t; SELECT 1", "readonly": true}},
+		},
+		{
+			name:  "equals compares the string form",
+			input: domain.ToolApprovalRequest{MCPServer: "database", ToolName: "execute_query", TeamID: &team, Arguments: map[string]interface{}{"query": "SELECT 1", "readonly": false}},
+		},
+		{
+			name:  "no team",
+			input: domain.ToolApprovalRequest{MCPServer: "database", ToolName: "execute_query", Arguments: map[string]interface{}{"query": "SELECT 1", "readonly": true}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, ttl, ok := a.Match(tt.input)
+			if ok != (tt.wantRule != "") || rule != tt.wantRule || ttl != tt.wantTTL {
+				t.Errorf("Match = %q, %s, %v, want %q, %s", rule, ttl, ok, tt.wantRule, tt.wantTTL)
+			}
+		})
+	}
+
+	var none *AutoApprover
+	if _, _, ok := none.Match(tests[0].input); ok {
+		t.Error("a nil AutoApprover matched")
+	}
+}
+
+func TestNewAutoApproverRejectsBadRules(t *testing.T) {
+	for name, rule := range map[string]config.AutoApprovalRule{
+		"bad team":    {Name: "r", Teams: []string{"engineering"}},
+		"bad pattern": {Name: "r", Arguments: map[string]config.ArgumentConstraint{"q": {Pattern: "("}}},
+	} {
+		if _, err := NewAutoApprover([]config.AutoApprovalRule{rule}); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
+
+// recordingNotifier records the approvals reviewers were told about.
+type recordingNotifier struct {
+	mu        sync.Mutex
+	approvals []domain.ToolApproval
+}
+
+func (n *recordingNotifier) NotifyApprovalRequested(approval domain.ToolApproval) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.approvals = append(n.approvals, approval)
+}
+
+func TestRequestApprovalAutoApproves(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	a, err := NewAutoApprover([]config.AutoApprovalRule{{Name: "scratch", Server: "filesystem", Tool: "write_file", ExpiresIn: 600}})
+	if err != nil {
+		t.Fatalf("NewAutoApprover: %v", err)
+	}
+	notifier := &recordingNotifier{}
+	s := NewService(zerolog.Nop(), nil, notifier, nil, nil, a, 100, clk)
+	orgID, userID := uuid.New(), uuid.New()
+	s.SetClassification(context.Background(), domain.ToolClassificationInput{MCPServer: "filesystem", ToolName: "write_file", Classification: domain.ToolRiskSensitive, RequiresApproval: true}, orgID, userID)
+
+	auto := s.RequestApproval(context.Background(), domain.ToolApprovalRequest{MCPServer: "filesystem", ToolName: "write_file"}, orgID, userID)
+	if auto.Status != domain.ApprovalStatusApproved || auto.AutoApproval != "scratch" {
+		t.Fatalf("approval = %+v, want approved by the rule", auto)
+	}
+	if auto.ExpiresAt == nil || !auto.ExpiresAt.Equal(clk.Now().Add(10*time.Minute)) {
+		t.Errorf("ExpiresAt = %v, want the rule's TTL from now", auto.ExpiresAt)
+	}
+	if ok, reason := s.CheckAccess(orgID, userID, nil, "filesystem", "write_file"); !ok {
+		t.Errorf("CheckAccess after auto-approval: %s", reason)
+	}
+
+	clk.Advance(11 * time.Minute)
+	if ok, _ := s.CheckAccess(orgID, userID, nil, "filesystem", "write_file"); ok {
+		t.Error("auto-approval still grants access after its TTL")
+	}
+
+	manual := s.RequestApproval(context.Background(), domain.ToolApprovalRequest{MCPServer: "filesystem", ToolName: "read_file"}, orgID, userID)
+	if manual.Status != domain.ApprovalStatusPending {
+		t.Errorf("unmatched request status = %s, want pending", manual.Status)
+	}
+	notifier.mu.Lock()
+	defer notifier.mu.Unlock()
+	if len(notifier.approvals) != 1 || notifier.approvals[0].ID != manual.ID {
+		t.Errorf("reviewers notified of %d approvals, want only the pending one", len(notifier.approvals))
+	}
+}
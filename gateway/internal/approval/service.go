@@ -3,32 +3,77 @@ package approval
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sort"
 	"sync"
 	"time"
 
+	"github.com/akz4ol/gatewayops/gateway/internal/clock"
 	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/middleware"
+	"github.com/akz4ol/gatewayops/gateway/internal/redact"
 	"github.com/akz4ol/gatewayops/gateway/internal/repository"
+	"github.com/akz4ol/gatewayops/gateway/internal/ringbuf"
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 )
 
+// Errors returned by ReplayableApproval.
+var (
+	ErrApprovalNotGranted = errors.New("approval has not been granted")
+	ErrApprovalExpired    = errors.New("approval has expired")
+	ErrApprovalRedacted   = errors.New("approval arguments were redacted")
+)
+
+// ReviewerNotifier is told about approval requests awaiting review.
+type ReviewerNotifier interface {
+	NotifyApprovalRequested(approval domain.ToolApproval)
+}
+
+// ServerDefaults gives the risk level of an MCP server's unclassified tools,
+// for servers that set one. Registered servers are looked up among those of
+// orgID.
+type ServerDefaults interface {
+	DefaultClassification(orgID uuid.UUID, server string) (domain.ToolRiskLevel, bool)
+}
+
 // Service manages tool classifications and approval workflows.
 type Service struct {
 	logger          zerolog.Logger
-	repo            *repository.ToolRepository
-	classifications map[string]*domain.ToolClassification // key: "server:tool"
+	repo            repository.ToolStore
+	notifier        ReviewerNotifier
+	servers         ServerDefaults
+	redactor        *redact.Redactor
+	autoApprover    *AutoApprover
+	clock           clock.Clock
+	classifications map[string]*domain.ToolClassification  // key: "server:tool"
+	unknownPolicies map[uuid.UUID]domain.UnknownToolPolicy // Unset means UnknownToolDefault
 	approvals       []domain.ToolApproval
+	approvalRing    *ringbuf.Ring
 	permissions     map[string]*domain.ToolPermission // key: "user_or_team:server:tool"
 	mu              sync.RWMutex
 }
 
-// NewService creates a new approval service.
-func NewService(logger zerolog.Logger, repo *repository.ToolRepository) *Service {
+// NewService creates a new approval service that keeps up to bufferSize
+// recent approvals in memory. Under the default policy for unclassified
+// tools, servers that set a default classification apply it to their tools.
+// Approval and permission expiry are judged by clk. Requests' arguments are
+// scrubbed by redactor before they are stored, and requests autoApprover
+// matches are approved without review.
+func NewService(logger zerolog.Logger, repo repository.ToolStore, notifier ReviewerNotifier, servers ServerDefaults, redactor *redact.Redactor, autoApprover *AutoApprover, bufferSize int, clk clock.Clock) *Service {
 	s := &Service{
 		logger:          logger,
 		repo:            repo,
+		notifier:        notifier,
+		servers:         servers,
+		redactor:        redactor,
+		autoApprover:    autoApprover,
+		clock:           clk,
 		classifications: make(map[string]*domain.ToolClassification),
+		unknownPolicies: make(map[uuid.UUID]domain.UnknownToolPolicy),
 		approvals:       make([]domain.ToolApproval, 0),
+		approvalRing:    ringbuf.New(bufferSize),
 		permissions:     make(map[string]*domain.ToolPermission),
 	}
 
@@ -49,33 +94,56 @@ func (s *Service) loadFromDatabase() {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	demoOrgID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
-
-	// Load classifications
-	classifications, err := s.repo.ListClassifications(ctx, demoOrgID, "")
+	// Load every organization's data, falling back to the demo org when
+	// organizations cannot be listed
+	orgIDs, err := s.repo.ListOrgIDs(ctx)
 	if err != nil {
-		s.logger.Warn().Err(err).Msg("Failed to load tool classifications from database")
-	} else {
-		for i := range classifications {
-			key := classificationKey(classifications[i].MCPServer, classifications[i].ToolName)
-			s.classifications[key] = &classifications[i]
-		}
-		s.logger.Info().Int("count", len(classifications)).Msg("Loaded tool classifications from database")
+		s.logger.Warn().Err(err).Msg("Failed to list organizations")
 	}
-
-	// Load pending approvals
-	filter := domain.ToolApprovalFilter{
-		OrgID:    demoOrgID,
-		Statuses: []domain.ApprovalStatus{domain.ApprovalStatusPending},
-		Limit:    100,
+	if len(orgIDs) == 0 {
+		orgIDs = []uuid.UUID{uuid.MustParse("00000000-0000-0000-0000-000000000001")}
 	}
-	approvalPage, err := s.repo.ListApprovals(ctx, filter)
-	if err != nil {
-		s.logger.Warn().Err(err).Msg("Failed to load tool approvals from database")
-	} else if approvalPage != nil {
-		s.approvals = approvalPage.Approvals
-		s.logger.Info().Int("count", len(s.approvals)).Msg("Loaded tool approvals from database")
+
+	for _, orgID := range orgIDs {
+		// Load classifications
+		classifications, err := s.repo.ListClassifications(ctx, orgID, "")
+		if err != nil {
+			s.logger.Warn().Err(err).Str("org_id", orgID.String()).Msg("Failed to load tool classifications from database")
+		} else {
+			for i := range classifications {
+				key := classificationKey(orgID, classifications[i].MCPServer, classifications[i].ToolName)
+				s.classifications[key] = &classifications[i]
+			}
+		}
+
+		// Load the policy for unclassified tools
+		policy, err := s.repo.GetUnknownToolPolicy(ctx, orgID)
+		if err != nil {
+			s.logger.Warn().Err(err).Str("org_id", orgID.String()).Msg("Failed to load unknown tool policy from database")
+		} else if domain.ValidUnknownToolPolicy(policy) {
+			s.unknownPolicies[orgID] = policy
+		}
+
+		// Load pending approvals
+		filter := domain.ToolApprovalFilter{
+			OrgID:    orgID,
+			Statuses: []domain.ApprovalStatus{domain.ApprovalStatusPending},
+			Limit:    100,
+		}
+		approvalPage, err := s.repo.ListApprovals(ctx, filter)
+		if err != nil {
+			s.logger.Warn().Err(err).Str("org_id", orgID.String()).Msg("Failed to load tool approvals from database")
+		} else if approvalPage != nil {
+			for _, approval := range approvalPage.Approvals {
+				s.storeApproval(approval)
+			}
+		}
 	}
+	s.logger.Info().
+		Int("orgs", len(orgIDs)).
+		Int("classifications", len(s.classifications)).
+		Int("approvals", len(s.approvals)).
+		Msg("Loaded tool classifications and approvals from database")
 
 	// If no classifications, create defaults
 	if len(s.classifications) == 0 {
@@ -103,8 +171,8 @@ func (s *Service) initDemoClassifications() {
 			Classification:   domain.ToolRiskSafe,
 			RequiresApproval: false,
 			Description:      "Read file contents - safe operation",
-			CreatedAt:        time.Now(),
-			UpdatedAt:        time.Now(),
+			CreatedAt:        s.clock.Now(),
+			UpdatedAt:        s.clock.Now(),
 			CreatedBy:        demoUser,
 		},
 		{
@@ -115,8 +183,8 @@ func (s *Service) initDemoClassifications() {
 			Classification:   domain.ToolRiskSensitive,
 			RequiresApproval: true,
 			Description:      "Write file contents - requires approval",
-			CreatedAt:        time.Now(),
-			UpdatedAt:        time.Now(),
+			CreatedAt:        s.clock.Now(),
+			UpdatedAt:        s.clock.Now(),
 			CreatedBy:        demoUser,
 		},
 		{
@@ -127,8 +195,8 @@ func (s *Service) initDemoClassifications() {
 			Classification:   domain.ToolRiskSensitive,
 			RequiresApproval: true,
 			Description:      "Execute database queries - requires approval",
-			CreatedAt:        time.Now(),
-			UpdatedAt:        time.Now(),
+			CreatedAt:        s.clock.Now(),
+			UpdatedAt:        s.clock.Now(),
 			CreatedBy:        demoUser,
 		},
 		{
@@ -139,45 +207,113 @@ func (s *Service) initDemoClassifications() {
 			Classification:   domain.ToolRiskDangerous,
 			RequiresApproval: true,
 			Description:      "Execute shell commands - dangerous, blocked by default",
-			CreatedAt:        time.Now(),
-			UpdatedAt:        time.Now(),
+			CreatedAt:        s.clock.Now(),
+			UpdatedAt:        s.clock.Now(),
 			CreatedBy:        demoUser,
 		},
 	}
 
 	for i := range classifications {
-		key := classificationKey(classifications[i].MCPServer, classifications[i].ToolName)
+		key := classificationKey(demoOrg, classifications[i].MCPServer, classifications[i].ToolName)
 		s.classifications[key] = &classifications[i]
 	}
 }
 
-func classificationKey(server, tool string) string {
-	return server + ":" + tool
+func classificationKey(orgID uuid.UUID, server, tool string) string {
+	return orgID.String() + ":" + server + ":" + tool
 }
 
 func permissionKey(id uuid.UUID, server, tool string) string {
 	return id.String() + ":" + server + ":" + tool
 }
 
-// GetClassification returns the classification for a tool.
-func (s *Service) GetClassification(server, tool string) *domain.ToolClassification {
+// GetClassification returns an organization's classification for a tool.
+func (s *Service) GetClassification(orgID uuid.UUID, server, tool string) *domain.ToolClassification {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	key := classificationKey(server, tool)
+	key := classificationKey(orgID, server, tool)
 	if c, exists := s.classifications[key]; exists {
 		return c
 	}
 	return nil
 }
 
-// ListClassifications returns all classifications.
-func (s *Service) ListClassifications(server string) []domain.ToolClassification {
+// DefaultClassification returns the risk level an organization's policy for
+// unclassified tools gives a tool on server it has not classified.
+func (s *Service) DefaultClassification(orgID uuid.UUID, server, tool string) domain.ToolRiskLevel {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	policy := s.unknownPolicy(orgID)
+	if policy == domain.UnknownToolDefault {
+		return s.serverDefault(orgID, server, tool)
+	}
+	return policy.Classify(tool)
+}
+
+// serverDefault returns the risk level of an unclassified tool under the
+// default policy: the server's default classification if it sets one, and
+// the built-in default otherwise.
+func (s *Service) serverDefault(orgID uuid.UUID, server, tool string) domain.ToolRiskLevel {
+	if s.servers != nil {
+		if level, ok := s.servers.DefaultClassification(orgID, server); ok {
+			return level
+		}
+	}
+	return domain.GetDefaultClassification(tool)
+}
+
+// UnknownToolPolicy returns an organization's policy for unclassified tools.
+func (s *Service) UnknownToolPolicy(orgID uuid.UUID) domain.UnknownToolPolicy {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.unknownPolicy(orgID)
+}
+
+// unknownPolicy returns an organization's policy for unclassified tools. The
+// caller must hold s.mu.
+func (s *Service) unknownPolicy(orgID uuid.UUID) domain.UnknownToolPolicy {
+	if policy, ok := s.unknownPolicies[orgID]; ok {
+		return policy
+	}
+	return domain.UnknownToolDefault
+}
+
+// SetUnknownToolPolicy sets how an organization treats tools it has not
+// classified.
+func (s *Service) SetUnknownToolPolicy(ctx context.Context, orgID uuid.UUID, policy domain.UnknownToolPolicy) {
+	logger := middleware.RequestLogger(ctx, s.logger)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.unknownPolicies[orgID] = policy
+
+	// Persist to database
+	if s.repo != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := s.repo.SetUnknownToolPolicy(ctx, orgID, policy); err != nil {
+			logger.Error().Err(err).Msg("Failed to persist unknown tool policy to database")
+		}
+	}
+
+	logger.Info().
+		Str("org_id", orgID.String()).
+		Str("policy", string(policy)).
+		Msg("Unknown tool policy set")
+}
+
+// ListClassifications returns an organization's classifications.
+func (s *Service) ListClassifications(orgID uuid.UUID, server string) []domain.ToolClassification {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	result := make([]domain.ToolClassification, 0)
 	for _, c := range s.classifications {
+		if c.OrgID != orgID {
+			continue
+		}
 		if server == "" || c.MCPServer == server {
 			result = append(result, *c)
 		}
@@ -186,11 +322,13 @@ func (s *Service) ListClassifications(server string) []domain.ToolClassification
 }
 
 // SetClassification sets the classification for a tool.
-func (s *Service) SetClassification(input domain.ToolClassificationInput, orgID, userID uuid.UUID) *domain.ToolClassification {
+func (s *Service) SetClassification(ctx context.Context, input domain.ToolClassificationInput, orgID, userID uuid.UUID) *domain.ToolClassification {
+	logger := middleware.RequestLogger(ctx, s.logger)
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	key := classificationKey(input.MCPServer, input.ToolName)
+	key := classificationKey(orgID, input.MCPServer, input.ToolName)
 
 	classification := &domain.ToolClassification{
 		ID:               uuid.New(),
@@ -200,8 +338,8 @@ func (s *Service) SetClassification(input domain.ToolClassificationInput, orgID,
 		Classification:   input.Classification,
 		RequiresApproval: input.RequiresApproval,
 		Description:      input.Description,
-		CreatedAt:        time.Now(),
-		UpdatedAt:        time.Now(),
+		CreatedAt:        s.clock.Now(),
+		UpdatedAt:        s.clock.Now(),
 		CreatedBy:        userID,
 	}
 
@@ -216,13 +354,13 @@ func (s *Service) SetClassification(input domain.ToolClassificationInput, orgID,
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 		if err := s.repo.CreateClassification(ctx, classification); err != nil {
-			s.logger.Error().Err(err).Msg("Failed to persist tool classification")
+			logger.Error().Err(err).Msg("Failed to persist tool classification")
 		}
 	}
 
 	s.classifications[key] = classification
 
-	s.logger.Info().
+	logger.Info().
 		Str("server", input.MCPServer).
 		Str("tool", input.ToolName).
 		Str("classification", string(input.Classification)).
@@ -232,19 +370,30 @@ func (s *Service) SetClassification(input domain.ToolClassificationInput, orgID,
 	return classification
 }
 
+// SetClassifications sets the classification for several tools.
+func (s *Service) SetClassifications(ctx context.Context, inputs []domain.ToolClassificationInput, orgID, userID uuid.UUID) []domain.ToolClassification {
+	result := make([]domain.ToolClassification, 0, len(inputs))
+	for _, input := range inputs {
+		result = append(result, *s.SetClassification(ctx, input, orgID, userID))
+	}
+	return result
+}
+
 // DeleteClassification removes a classification.
-func (s *Service) DeleteClassification(server, tool string, orgID uuid.UUID) bool {
+func (s *Service) DeleteClassification(ctx context.Context, server, tool string, orgID uuid.UUID) bool {
+	logger := middleware.RequestLogger(ctx, s.logger)
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	key := classificationKey(server, tool)
+	key := classificationKey(orgID, server, tool)
 	if _, exists := s.classifications[key]; exists {
 		// Delete from database
 		if s.repo != nil {
 			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 			defer cancel()
 			if err := s.repo.DeleteClassification(ctx, orgID, server, tool); err != nil {
-				s.logger.Error().Err(err).Msg("Failed to delete tool classification from database")
+				logger.Error().Err(err).Msg("Failed to delete tool classification from database")
 			}
 		}
 		delete(s.classifications, key)
@@ -253,18 +402,33 @@ func (s *Service) DeleteClassification(server, tool string, orgID uuid.UUID) boo
 	return false
 }
 
-// CheckAccess checks if a user/team has access to a tool.
-func (s *Service) CheckAccess(userID uuid.UUID, teamID *uuid.UUID, server, tool string) (bool, string) {
+// CheckAccess checks if a user/team has access to a tool under their
+// organization's classifications.
+func (s *Service) CheckAccess(orgID, userID uuid.UUID, teamID *uuid.UUID, server, tool string) (bool, string) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	// Get classification
-	key := classificationKey(server, tool)
+	key := classificationKey(orgID, server, tool)
 	classification := s.classifications[key]
 
-	// If no classification, use default
+	// If no classification, apply the organization's policy for unknown tools
 	if classification == nil {
-		defaultLevel := domain.GetDefaultClassification(tool)
+		switch s.unknownPolicy(orgID) {
+		case domain.UnknownToolAllow:
+			return true, ""
+		case domain.UnknownToolDeny:
+			if s.hasPermission(userID, teamID, server, tool) {
+				return true, ""
+			}
+			return false, "Tool is not classified and the organization denies unclassified tools"
+		case domain.UnknownToolApproval:
+			if s.hasPermission(userID, teamID, server, tool) || s.hasApproval(orgID, userID, server, tool) {
+				return true, ""
+			}
+			return false, "Tool is not classified and requires approval"
+		}
+		defaultLevel := s.serverDefault(orgID, server, tool)
 		if defaultLevel == domain.ToolRiskSafe {
 			return true, ""
 		}
@@ -296,7 +460,7 @@ func (s *Service) CheckAccess(userID uuid.UUID, teamID *uuid.UUID, server, tool
 	}
 
 	// Check for pending/approved request
-	hasApproval := s.hasApproval(userID, server, tool)
+	hasApproval := s.hasApproval(orgID, userID, server, tool)
 	if hasApproval {
 		return true, ""
 	}
@@ -308,7 +472,7 @@ func (s *Service) hasPermission(userID uuid.UUID, teamID *uuid.UUID, server, too
 	// Check user-level permission
 	userKey := permissionKey(userID, server, tool)
 	if perm, exists := s.permissions[userKey]; exists {
-		if perm.ExpiresAt == nil || perm.ExpiresAt.After(time.Now()) {
+		if perm.ExpiresAt == nil || perm.ExpiresAt.After(s.clock.Now()) {
 			return true
 		}
 	}
@@ -316,7 +480,7 @@ func (s *Service) hasPermission(userID uuid.UUID, teamID *uuid.UUID, server, too
 	// Check user wildcard permission
 	userWildcardKey := permissionKey(userID, server, "*")
 	if perm, exists := s.permissions[userWildcardKey]; exists {
-		if perm.ExpiresAt == nil || perm.ExpiresAt.After(time.Now()) {
+		if perm.ExpiresAt == nil || perm.ExpiresAt.After(s.clock.Now()) {
 			return true
 		}
 	}
@@ -325,7 +489,7 @@ func (s *Service) hasPermission(userID uuid.UUID, teamID *uuid.UUID, server, too
 	if teamID != nil {
 		teamKey := permissionKey(*teamID, server, tool)
 		if perm, exists := s.permissions[teamKey]; exists {
-			if perm.ExpiresAt == nil || perm.ExpiresAt.After(time.Now()) {
+			if perm.ExpiresAt == nil || perm.ExpiresAt.After(s.clock.Now()) {
 				return true
 			}
 		}
@@ -333,7 +497,7 @@ func (s *Service) hasPermission(userID uuid.UUID, teamID *uuid.UUID, server, too
 		// Check team wildcard permission
 		teamWildcardKey := permissionKey(*teamID, server, "*")
 		if perm, exists := s.permissions[teamWildcardKey]; exists {
-			if perm.ExpiresAt == nil || perm.ExpiresAt.After(time.Now()) {
+			if perm.ExpiresAt == nil || perm.ExpiresAt.After(s.clock.Now()) {
 				return true
 			}
 		}
@@ -342,14 +506,15 @@ func (s *Service) hasPermission(userID uuid.UUID, teamID *uuid.UUID, server, too
 	return false
 }
 
-func (s *Service) hasApproval(userID uuid.UUID, server, tool string) bool {
+func (s *Service) hasApproval(orgID, userID uuid.UUID, server, tool string) bool {
 	for _, approval := range s.approvals {
-		if approval.RequestedBy == userID &&
+		if approval.OrgID == orgID &&
+			approval.RequestedBy == userID &&
 			approval.MCPServer == server &&
 			approval.ToolName == tool &&
 			approval.Status == domain.ApprovalStatusApproved {
 			// Check if expired
-			if approval.ExpiresAt == nil || approval.ExpiresAt.After(time.Now()) {
+			if approval.ExpiresAt == nil || approval.ExpiresAt.After(s.clock.Now()) {
 				return true
 			}
 		}
@@ -357,8 +522,14 @@ func (s *Service) hasApproval(userID uuid.UUID, server, tool string) bool {
 	return false
 }
 
-// RequestApproval creates a new approval request.
-func (s *Service) RequestApproval(input domain.ToolApprovalRequest, orgID, userID uuid.UUID) *domain.ToolApproval {
+// RequestApproval creates a new approval request. A request matching an
+// auto-approval rule is approved at once for the rule's TTL; the others stay
+// pending for review.
+func (s *Service) RequestApproval(ctx context.Context, input domain.ToolApprovalRequest, orgID, userID uuid.UUID) *domain.ToolApproval {
+	logger := middleware.RequestLogger(ctx, s.logger)
+
+	arguments, redacted := s.redactor.Arguments(input.MCPServer, input.ToolName, input.Arguments)
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -369,51 +540,114 @@ func (s *Service) RequestApproval(input domain.ToolApprovalRequest, orgID, userI
 		MCPServer:   input.MCPServer,
 		ToolName:    input.ToolName,
 		RequestedBy: userID,
-		RequestedAt: time.Now(),
+		RequestedAt: s.clock.Now(),
 		Reason:      input.Reason,
-		Arguments:   input.Arguments,
+		Arguments:   arguments,
+		Redacted:    redacted,
 		Status:      domain.ApprovalStatusPending,
 		TraceID:     input.TraceID,
 	}
 
+	rule, ttl, auto := s.autoApprover.Match(input)
+	if auto {
+		expiresAt := approval.RequestedAt.Add(ttl)
+		approval.Status = domain.ApprovalStatusApproved
+		approval.ReviewedAt = &approval.RequestedAt
+		approval.ReviewNote = fmt.Sprintf("Auto-approved by rule %q", rule)
+		approval.ExpiresAt = &expiresAt
+		approval.AutoApproval = rule
+	}
+
 	// Persist to database
 	if s.repo != nil {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 		if err := s.repo.CreateApproval(ctx, &approval); err != nil {
-			s.logger.Error().Err(err).Msg("Failed to persist tool approval request")
+			logger.Error().Err(err).Msg("Failed to persist tool approval request")
 		}
 	}
 
-	// Keep only last 1000 approvals
-	if len(s.approvals) >= 1000 {
-		s.approvals = s.approvals[1:]
-	}
-	s.approvals = append(s.approvals, approval)
+	s.storeApproval(approval)
 
-	s.logger.Info().
+	logger.Info().
 		Str("approval_id", approval.ID.String()).
 		Str("server", input.MCPServer).
 		Str("tool", input.ToolName).
 		Str("requested_by", userID.String()).
+		Str("auto_approval_rule", rule).
 		Msg("Tool approval requested")
 
+	if s.notifier != nil && !auto {
+		s.notifier.NotifyApprovalRequested(approval)
+	}
+
 	return &approval
 }
 
-// GetApproval returns an approval by ID.
-func (s *Service) GetApproval(id uuid.UUID) *domain.ToolApproval {
+// storeApproval keeps an approval in the in-memory buffer, overwriting the
+// oldest once the buffer is full. The caller must hold s.mu.
+func (s *Service) storeApproval(approval domain.ToolApproval) {
+	if i, full := s.approvalRing.Slot(len(s.approvals)); full {
+		s.approvals[i] = approval
+	} else {
+		s.approvals = append(s.approvals, approval)
+	}
+}
+
+// BufferStats describes the in-memory approval buffer.
+func (s *Service) BufferStats() ringbuf.Stats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.approvalRing.Stats(len(s.approvals))
+}
+
+// GetApproval returns one of an organization's approvals by ID.
+func (s *Service) GetApproval(orgID, id uuid.UUID) *domain.ToolApproval {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	for i := range s.approvals {
-		if s.approvals[i].ID == id {
-			return &s.approvals[i]
+		if s.approvals[i].ID == id && s.approvals[i].OrgID == orgID {
+			// Return a copy: the slot is reused once the buffer wraps
+			approval := s.approvals[i]
+			return &approval
 		}
 	}
 	return nil
 }
 
+// ReplayableApproval returns one of an organization's approvals if its
+// original call may be replayed now: the approval must have been granted,
+// still be within its validity window, and hold the call's arguments as they
+// were sent rather than redacted. It returns nil and no error when the
+// approval does not exist.
+func (s *Service) ReplayableApproval(orgID, id uuid.UUID) (*domain.ToolApproval, error) {
+	s.mu.RLock()
+	var approval *domain.ToolApproval
+	for i := range s.approvals {
+		if s.approvals[i].ID == id && s.approvals[i].OrgID == orgID {
+			a := s.approvals[i]
+			approval = &a
+			break
+		}
+	}
+	s.mu.RUnlock()
+
+	if approval == nil {
+		return nil, nil
+	}
+	if approval.Status != domain.ApprovalStatusApproved {
+		return approval, ErrApprovalNotGranted
+	}
+	if approval.ExpiresAt != nil && !approval.ExpiresAt.After(s.clock.Now()) {
+		return approval, ErrApprovalExpired
+	}
+	if approval.Redacted {
+		return approval, ErrApprovalRedacted
+	}
+	return approval, nil
+}
+
 // ListApprovals returns approvals matching the filter.
 func (s *Service) ListApprovals(filter domain.ToolApprovalFilter) domain.ToolApprovalPage {
 	s.mu.RLock()
@@ -427,10 +661,14 @@ func (s *Service) ListApprovals(filter domain.ToolApprovalFilter) domain.ToolApp
 		filtered = append(filtered, approval)
 	}
 
-	// Sort by most recent first
+	// Sort by most recent first; the insertion order is reversed first so
+	// rows sharing a timestamp keep their historical ordering.
 	for i, j := 0, len(filtered)-1; i < j; i, j = i+1, j-1 {
 		filtered[i], filtered[j] = filtered[j], filtered[i]
 	}
+	sort.SliceStable(filtered, func(i, j int) bool {
+		return domain.NewerFirst(filtered[i].RequestedAt, filtered[i].ID, filtered[j].RequestedAt, filtered[j].ID)
+	})
 
 	total := int64(len(filtered))
 	limit := filter.Limit
@@ -440,6 +678,13 @@ func (s *Service) ListApprovals(filter domain.ToolApprovalFilter) domain.ToolApp
 	offset := filter.Offset
 
 	start := offset
+	if filter.Cursor != nil {
+		// Keyset pagination takes precedence over offset.
+		start = domain.CursorStart(len(filtered), filter.Cursor, func(i int) (time.Time, uuid.UUID) {
+			return filtered[i].RequestedAt, filtered[i].ID
+		})
+		offset = 0
+	}
 	if start > len(filtered) {
 		start = len(filtered)
 	}
@@ -448,16 +693,26 @@ func (s *Service) ListApprovals(filter domain.ToolApprovalFilter) domain.ToolApp
 		end = len(filtered)
 	}
 
-	return domain.ToolApprovalPage{
+	page := domain.ToolApprovalPage{
 		Approvals: filtered[start:end],
 		Total:     total,
 		Limit:     limit,
 		Offset:    offset,
 		HasMore:   end < len(filtered),
 	}
+	if page.HasMore && end > start {
+		page.NextCursor = domain.NewCursor(filtered[end-1].RequestedAt, filtered[end-1].ID).Encode()
+	}
+	return page
 }
 
 func (s *Service) matchesFilter(approval domain.ToolApproval, filter domain.ToolApprovalFilter) bool {
+	if approval.OrgID != filter.OrgID {
+		return false
+	}
+	if filter.TeamID != nil && (approval.TeamID == nil || *approval.TeamID != *filter.TeamID) {
+		return false
+	}
 	if filter.MCPServer != "" && approval.MCPServer != filter.MCPServer {
 		return false
 	}
@@ -467,6 +722,12 @@ func (s *Service) matchesFilter(approval domain.ToolApproval, filter domain.Tool
 	if filter.RequestedBy != nil && approval.RequestedBy != *filter.RequestedBy {
 		return false
 	}
+	if filter.TraceID != "" && approval.TraceID != filter.TraceID {
+		return false
+	}
+	if !domain.MatchesQuery(filter.Query, approval.Reason, approval.ToolName, approval.MCPServer) {
+		return false
+	}
 	if len(filter.Statuses) > 0 {
 		found := false
 		for _, status := range filter.Statuses {
@@ -483,13 +744,15 @@ func (s *Service) matchesFilter(approval domain.ToolApproval, filter domain.Tool
 }
 
 // ReviewApproval approves or denies an approval request.
-func (s *Service) ReviewApproval(id uuid.UUID, review domain.ToolApprovalReview, reviewerID uuid.UUID) *domain.ToolApproval {
+func (s *Service) ReviewApproval(ctx context.Context, orgID, id uuid.UUID, review domain.ToolApprovalReview, reviewerID uuid.UUID) *domain.ToolApproval {
+	logger := middleware.RequestLogger(ctx, s.logger)
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	for i := range s.approvals {
-		if s.approvals[i].ID == id {
-			now := time.Now()
+		if s.approvals[i].ID == id && s.approvals[i].OrgID == orgID {
+			now := s.clock.Now()
 			s.approvals[i].Status = review.Status
 			s.approvals[i].ReviewedBy = &reviewerID
 			s.approvals[i].ReviewedAt = &now
@@ -505,24 +768,108 @@ func (s *Service) ReviewApproval(id uuid.UUID, review domain.ToolApprovalReview,
 				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 				defer cancel()
 				if err := s.repo.UpdateApproval(ctx, &s.approvals[i]); err != nil {
-					s.logger.Error().Err(err).Msg("Failed to update tool approval in database")
+					logger.Error().Err(err).Msg("Failed to update tool approval in database")
 				}
 			}
 
-			s.logger.Info().
+			logger.Info().
 				Str("approval_id", id.String()).
 				Str("status", string(review.Status)).
 				Str("reviewed_by", reviewerID.String()).
 				Msg("Tool approval reviewed")
 
-			return &s.approvals[i]
+			approval := s.approvals[i]
+			return &approval
 		}
 	}
 	return nil
 }
 
+// ReviewApprovals approves or denies several of an organization's approval
+// requests at once. Only pending requests are reviewed; the others are left
+// as they are. The reviews are stored in one transaction, so either all of
+// them take effect or, with an error, none do. It returns the outcome for
+// each ID in order.
+func (s *Service) ReviewApprovals(ctx context.Context, orgID uuid.UUID, ids []uuid.UUID, review domain.ToolApprovalReview, reviewerID uuid.UUID) ([]domain.ApprovalReviewResult, error) {
+	logger := middleware.RequestLogger(ctx, s.logger)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index := make(map[uuid.UUID]int)
+	for i := range s.approvals {
+		if s.approvals[i].OrgID == orgID {
+			index[s.approvals[i].ID] = i
+		}
+	}
+
+	now := s.clock.Now()
+	var expiresAt *time.Time
+	if review.ExpiresIn != nil && *review.ExpiresIn > 0 {
+		t := now.Add(time.Duration(*review.ExpiresIn) * time.Second)
+		expiresAt = &t
+	}
+
+	results := make([]domain.ApprovalReviewResult, len(ids))
+	var updated []domain.ToolApproval
+	var positions []int
+	seen := make(map[uuid.UUID]bool, len(ids))
+	for n, id := range ids {
+		results[n].ID = id
+		i, ok := index[id]
+		switch {
+		case !ok:
+			results[n].Outcome = domain.ApprovalReviewNotFound
+		case seen[id] || s.approvals[i].Status != domain.ApprovalStatusPending:
+			results[n].Outcome = domain.ApprovalReviewAlreadyReviewed
+		default:
+			approval := s.approvals[i]
+			approval.Status = review.Status
+			approval.ReviewedBy = &reviewerID
+			approval.ReviewedAt = &now
+			approval.ReviewNote = review.ReviewNote
+			if expiresAt != nil {
+				approval.ExpiresAt = expiresAt
+			}
+			updated = append(updated, approval)
+			positions = append(positions, i)
+			results[n].Outcome = domain.ApprovalReviewApplied
+		}
+		seen[id] = true
+	}
+
+	// Persist to database
+	if s.repo != nil && len(updated) > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := s.repo.UpdateApprovals(ctx, updated); err != nil {
+			return nil, fmt.Errorf("store approval reviews: %w", err)
+		}
+	}
+	for k, i := range positions {
+		s.approvals[i] = updated[k]
+	}
+
+	for n := range results {
+		if i, ok := index[results[n].ID]; ok {
+			approval := s.approvals[i]
+			results[n].Approval = &approval
+		}
+	}
+
+	logger.Info().
+		Int("requested", len(ids)).
+		Int("reviewed", len(updated)).
+		Str("status", string(review.Status)).
+		Str("reviewed_by", reviewerID.String()).
+		Msg("Tool approvals reviewed in bulk")
+
+	return results, nil
+}
+
 // GrantPermission grants a permanent permission to use a tool.
 func (s *Service) GrantPermission(
+	ctx context.Context,
 	orgID uuid.UUID,
 	userID *uuid.UUID,
 	teamID *uuid.UUID,
@@ -531,6 +878,8 @@ func (s *Service) GrantPermission(
 	expiresIn *int,
 	maxUsesDay *int,
 ) *domain.ToolPermission {
+	logger := middleware.RequestLogger(ctx, s.logger)
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -542,12 +891,12 @@ func (s *Service) GrantPermission(
 		MCPServer:  server,
 		ToolName:   tool,
 		GrantedBy:  grantedBy,
-		GrantedAt:  time.Now(),
+		GrantedAt:  s.clock.Now(),
 		MaxUsesDay: maxUsesDay,
 	}
 
 	if expiresIn != nil && *expiresIn > 0 {
-		expiresAt := time.Now().Add(time.Duration(*expiresIn) * time.Second)
+		expiresAt := s.clock.Now().Add(time.Duration(*expiresIn) * time.Second)
 		permission.ExpiresAt = &expiresAt
 	}
 
@@ -563,7 +912,7 @@ func (s *Service) GrantPermission(
 
 	s.permissions[key] = permission
 
-	s.logger.Info().
+	logger.Info().
 		Str("permission_id", permission.ID.String()).
 		Str("server", server).
 		Str("tool", tool).
@@ -572,13 +921,13 @@ func (s *Service) GrantPermission(
 	return permission
 }
 
-// RevokePermission removes a permission.
-func (s *Service) RevokePermission(id uuid.UUID) bool {
+// RevokePermission removes one of an organization's permissions.
+func (s *Service) RevokePermission(orgID, id uuid.UUID) bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	for key, perm := range s.permissions {
-		if perm.ID == id {
+		if perm.ID == id && perm.OrgID == orgID {
 			delete(s.permissions, key)
 			return true
 		}
@@ -586,13 +935,60 @@ func (s *Service) RevokePermission(id uuid.UUID) bool {
 	return false
 }
 
-// ListPermissions returns all permissions.
-func (s *Service) ListPermissions(server string) []domain.ToolPermission {
+// ExpireApprovals marks granted approvals past their expiry as expired,
+// returning how many were expired.
+func (s *Service) ExpireApprovals(ctx context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	var count int64
+	for i := range s.approvals {
+		approval := &s.approvals[i]
+		if approval.Status == domain.ApprovalStatusApproved && approval.ExpiresAt != nil && approval.ExpiresAt.Before(now) {
+			approval.Status = domain.ApprovalStatusExpired
+			count++
+		}
+	}
+
+	if s.repo != nil {
+		// Approvals evicted from memory may also have expired
+		expired, err := s.repo.ExpireApprovals(ctx)
+		if err != nil {
+			return count, err
+		}
+		count = max(count, expired)
+	}
+	return count, nil
+}
+
+// PurgeExpiredPermissions removes permissions past their expiry, returning
+// how many were removed.
+func (s *Service) PurgeExpiredPermissions() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	var count int64
+	for key, perm := range s.permissions {
+		if perm.ExpiresAt != nil && perm.ExpiresAt.Before(now) {
+			delete(s.permissions, key)
+			count++
+		}
+	}
+	return count
+}
+
+// ListPermissions returns an organization's permissions.
+func (s *Service) ListPermissions(orgID uuid.UUID, server string) []domain.ToolPermission {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	result := make([]domain.ToolPermission, 0)
 	for _, p := range s.permissions {
+		if p.OrgID != orgID {
+			continue
+		}
 		if server == "" || p.MCPServer == server {
 			result = append(result, *p)
 		}
@@ -600,14 +996,38 @@ func (s *Service) ListPermissions(server string) []domain.ToolPermission {
 	return result
 }
 
-// GetPendingCount returns the count of pending approvals.
-func (s *Service) GetPendingCount() int {
+// ActivePermissions returns the unexpired permissions granted to a user,
+// directly or through their team.
+func (s *Service) ActivePermissions(orgID, userID uuid.UUID, teamID *uuid.UUID) []domain.ToolPermission {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := s.clock.Now()
+	result := make([]domain.ToolPermission, 0)
+	for _, p := range s.permissions {
+		if p.OrgID != orgID || (p.ExpiresAt != nil && !p.ExpiresAt.After(now)) {
+			continue
+		}
+		held := p.UserID != nil && *p.UserID == userID
+		if p.UserID == nil && teamID != nil && p.TeamID != nil && *p.TeamID == *teamID {
+			held = true
+		}
+		if held {
+			result = append(result, *p)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].GrantedAt.After(result[j].GrantedAt) })
+	return result
+}
+
+// GetPendingCount returns the count of an organization's pending approvals.
+func (s *Service) GetPendingCount(orgID uuid.UUID) int {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	count := 0
 	for _, approval := range s.approvals {
-		if approval.Status == domain.ApprovalStatusPending {
+		if approval.OrgID == orgID && approval.Status == domain.ApprovalStatusPending {
 			count++
 		}
 	}
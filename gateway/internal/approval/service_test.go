@@ -0,0 +1,196 @@
+package approval
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/clock"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+func newTestService(t *testing.T, clk clock.Clock) *Service {
+	t.Helper()
+	return NewService(zerolog.Nop(), nil, nil, nil, nil, nil, 100, clk)
+}
+
+func TestReviewApprovals(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	s := newTestService(t, clk)
+	orgID, otherOrg, userID, reviewer := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	request := func(org uuid.UUID) uuid.UUID {
+		return s.RequestApproval(context.Background(), domain.ToolApprovalRequest{MCPServer: "shell", ToolName: "execute_command"}, org, userID).ID
+	}
+
+	first, second := request(orgID), request(orgID)
+	reviewed := request(orgID)
+	s.ReviewApproval(context.Background(), orgID, reviewed, domain.ToolApprovalReview{Status: domain.ApprovalStatusDenied}, reviewer)
+	foreign := request(otherOrg)
+	missing := uuid.New()
+
+	expiresIn := 3600
+	results, err := s.ReviewApprovals(context.Background(), orgID, []uuid.UUID{first, second, first, reviewed, foreign, missing},
+		domain.ToolApprovalReview{Status: domain.ApprovalStatusApproved, ReviewNote: "ok", ExpiresIn: &expiresIn}, reviewer)
+	if err != nil {
+		t.Fatalf("ReviewApprovals: %v", err)
+	}
+
+	want := []domain.ApprovalReviewOutcome{
+		domain.ApprovalReviewApplied,
+		domain.ApprovalReviewApplied,
+		domain.ApprovalReviewAlreadyReviewed, // Listed twice
+		domain.ApprovalReviewAlreadyReviewed,
+		domain.ApprovalReviewNotFound, // Another organization's
+		domain.ApprovalReviewNotFound,
+	}
+	if len(results) != len(want) {
+		t.Fatalf("%d results, want %d", len(results), len(want))
+	}
+	for i, r := range results {
+		if r.Outcome != want[i] {
+			t.Errorf("result %d: outcome %s, want %s", i, r.Outcome, want[i])
+		}
+	}
+
+	approved := s.GetApproval(orgID, first)
+	if approved.Status != domain.ApprovalStatusApproved || approved.ReviewedBy == nil || *approved.ReviewedBy != reviewer || approved.ReviewNote != "ok" {
+		t.Errorf("approval after review = %+v", approved)
+	}
+	if approved.ExpiresAt == nil || !approved.ExpiresAt.Equal(clk.Now().Add(time.Hour)) {
+		t.Errorf("ExpiresAt = %v, want an hour from the review", approved.ExpiresAt)
+	}
+	if got := s.GetApproval(orgID, reviewed).Status; got != domain.ApprovalStatusDenied {
+		t.Errorf("already reviewed approval changed to %s", got)
+	}
+	if got := s.GetApproval(otherOrg, foreign).Status; got != domain.ApprovalStatusPending {
+		t.Errorf("another organization's approval changed to %s", got)
+	}
+}
+
+func TestExpireApprovals(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	s := newTestService(t, clk)
+	orgID, userID, reviewer := uuid.New(), uuid.New(), uuid.New()
+
+	short, long := 60, 3600
+	for _, expiresIn := range []*int{&short, &long, nil} {
+		id := s.RequestApproval(context.Background(), domain.ToolApprovalRequest{MCPServer: "shell", ToolName: "execute_command"}, orgID, userID).ID
+		s.ReviewApproval(context.Background(), orgID, id, domain.ToolApprovalReview{Status: domain.ApprovalStatusApproved, ExpiresIn: expiresIn}, reviewer)
+	}
+	s.RequestApproval(context.Background(), domain.ToolApprovalRequest{MCPServer: "shell", ToolName: "execute_command"}, orgID, userID)
+
+	clk.Advance(2 * time.Minute)
+	n, err := s.ExpireApprovals(context.Background())
+	if err != nil || n != 1 {
+		t.Fatalf("ExpireApprovals = %d, %v, want 1", n, err)
+	}
+	if n, _ := s.ExpireApprovals(context.Background()); n != 0 {
+		t.Errorf("second run expired %d, want 0", n)
+	}
+
+	counts := make(map[domain.ApprovalStatus]int)
+	for _, a := range s.ListApprovals(domain.ToolApprovalFilter{OrgID: orgID, Limit: 10}).Approvals {
+		counts[a.Status]++
+	}
+	if counts[domain.ApprovalStatusExpired] != 1 || counts[domain.ApprovalStatusApproved] != 2 || counts[domain.ApprovalStatusPending] != 1 {
+		t.Errorf("statuses = %v", counts)
+	}
+}
+
+func TestListApprovalsSearch(t *testing.T) {
+	s := newTestService(t, clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)))
+	orgID, otherOrg, userID, reviewer := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	request := func(org uuid.UUID, server, tool, reason string) uuid.UUID {
+		return s.RequestApproval(context.Background(), domain.ToolApprovalRequest{MCPServer: server, ToolName: tool, Reason: reason}, org, userID).ID
+	}
+
+	deploy := request(orgID, "shell", "execute_command", "Run the DEPLOY script")
+	deployed := request(orgID, "shell", "execute_command", "deploy hotfix")
+	s.ReviewApproval(context.Background(), orgID, deployed, domain.ToolApprovalReview{Status: domain.ApprovalStatusDenied}, reviewer)
+	deployTool := request(orgID, "github", "deploy_release", "")
+	request(orgID, "shell", "execute_command", "Clean the cache")
+	request(otherOrg, "shell", "execute_command", "deploy")
+
+	ids := func(page domain.ToolApprovalPage) map[uuid.UUID]bool {
+		got := make(map[uuid.UUID]bool)
+		for _, a := range page.Approvals {
+			got[a.ID] = true
+		}
+		return got
+	}
+	tests := []struct {
+		name   string
+		filter domain.ToolApprovalFilter
+		want   []uuid.UUID
+	}{
+		{"reason or tool, any case", domain.ToolApprovalFilter{OrgID: orgID, Query: "Deploy"}, []uuid.UUID{deploy, deployed, deployTool}},
+		{"with status", domain.ToolApprovalFilter{OrgID: orgID, Query: "deploy", Statuses: []domain.ApprovalStatus{domain.ApprovalStatusPending}}, []uuid.UUID{deploy, deployTool}},
+		{"with server", domain.ToolApprovalFilter{OrgID: orgID, Query: "deploy", MCPServer: "shell"}, []uuid.UUID{deploy, deployed}},
+		{"no match", domain.ToolApprovalFilter{OrgID: orgID, Query: "rollback"}, nil},
+	}
+	for _, tt := range tests {
+		page := s.ListApprovals(tt.filter)
+		got := ids(page)
+		if len(got) != len(tt.want) || page.Total != int64(len(tt.want)) {
+			t.Errorf("%s: %d approvals (total %d), want %d", tt.name, len(got), page.Total, len(tt.want))
+			continue
+		}
+		for _, id := range tt.want {
+			if !got[id] {
+				t.Errorf("%s: approval %s missing", tt.name, id)
+			}
+		}
+	}
+}
+
+func TestUnknownToolPolicy(t *testing.T) {
+	tests := []struct {
+		policy        domain.UnknownToolPolicy
+		level         domain.ToolRiskLevel
+		allowed       bool
+		afterApproval bool
+		afterGrant    bool
+	}{
+		// The built-in heuristic treats an unknown name as sensitive
+		{policy: domain.UnknownToolDefault, level: domain.ToolRiskSensitive},
+		{policy: domain.UnknownToolDeny, level: domain.ToolRiskDangerous, afterGrant: true},
+		{policy: domain.UnknownToolApproval, level: domain.ToolRiskSensitive, afterApproval: true, afterGrant: true},
+		{policy: domain.UnknownToolAllow, level: domain.ToolRiskSafe, allowed: true, afterApproval: true, afterGrant: true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			ctx := context.Background()
+			s := newTestService(t, clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)))
+			orgID, otherOrg, userID, reviewer := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+			s.SetUnknownToolPolicy(ctx, orgID, tt.policy)
+
+			if got := s.UnknownToolPolicy(otherOrg); got != domain.UnknownToolDefault {
+				t.Errorf("another org's policy = %s, want default", got)
+			}
+			if got := s.DefaultClassification(orgID, "acme", "frobnicate"); got != tt.level {
+				t.Errorf("DefaultClassification = %s, want %s", got, tt.level)
+			}
+			if allowed, reason := s.CheckAccess(orgID, userID, nil, "acme", "frobnicate"); allowed != tt.allowed {
+				t.Fatalf("CheckAccess = %v (%s), want %v", allowed, reason, tt.allowed)
+			}
+
+			request := s.RequestApproval(ctx, domain.ToolApprovalRequest{MCPServer: "acme", ToolName: "frobnicate"}, orgID, userID)
+			s.ReviewApproval(ctx, orgID, request.ID, domain.ToolApprovalReview{Status: domain.ApprovalStatusApproved}, reviewer)
+			if allowed, reason := s.CheckAccess(orgID, userID, nil, "acme", "frobnicate"); allowed != tt.afterApproval {
+				t.Errorf("after approval: CheckAccess = %v (%s), want %v", allowed, reason, tt.afterApproval)
+			}
+
+			grantee := uuid.New()
+			s.GrantPermission(ctx, orgID, &grantee, nil, "acme", "frobnicate", reviewer, nil, nil)
+			if tt.policy == domain.UnknownToolDefault {
+				// The default policy does not consult permissions for unclassified tools
+				return
+			}
+			if allowed, reason := s.CheckAccess(orgID, grantee, nil, "acme", "frobnicate"); allowed != tt.afterGrant {
+				t.Errorf("with a permission: CheckAccess = %v (%s), want %v", allowed, reason, tt.afterGrant)
+			}
+		})
+	}
+}
@@ -5,29 +5,43 @@ import (
 	"context"
 	"encoding/csv"
 	"encoding/json"
+	"fmt"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/repository"
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 )
 
+// persistTimeout bounds how long an audit event may wait on the store.
+const persistTimeout = 5 * time.Second
+
+// verifyPageSize is how many stored records Verify reads at a time.
+const verifyPageSize = 1000
+
 // Logger implements audit logging functionality.
 type Logger struct {
 	logger   zerolog.Logger
+	store    repository.AuditStore // nil keeps the chain in memory only
 	logs     []domain.AuditLog
+	lastHash map[uuid.UUID]string // Chain head per org, without a store
 	mu       sync.RWMutex
 	maxLogs  int
 }
 
-// NewLogger creates a new audit logger.
-func NewLogger(logger zerolog.Logger) *Logger {
+// NewLogger creates a new audit logger. With a store, every event is
+// persisted and chained to the org's latest stored record, so the chain
+// survives restarts and is shared across replicas.
+func NewLogger(logger zerolog.Logger, store repository.AuditStore) *Logger {
 	l := &Logger{
-		logger:  logger,
-		logs:    make([]domain.AuditLog, 0),
-		maxLogs: 10000, // Keep last 10k logs in memory for demo
+		logger:   logger,
+		store:    store,
+		logs:     make([]domain.AuditLog, 0),
+		lastHash: make(map[uuid.UUID]string),
+		maxLogs:  10000, // Keep last 10k logs in memory for demo
 	}
 
 	logger.Info().Msg("Audit logging initialized")
@@ -36,9 +50,6 @@ func NewLogger(logger zerolog.Logger) *Logger {
 
 // LogEvent logs an audit event.
 func (l *Logger) LogEvent(ctx context.Context, event Event) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-
 	log := domain.AuditLog{
 		ID:         uuid.New(),
 		OrgID:      event.OrgID,
@@ -55,7 +66,29 @@ func (l *Logger) LogEvent(ctx context.Context, event Event) {
 		UserAgent:  event.UserAgent,
 		RequestID:  event.RequestID,
 		DurationMS: event.DurationMS,
-		CreatedAt:  time.Now(),
+		CreatedAt:  time.Now().UTC().Truncate(time.Microsecond), // Postgres precision, so hashes survive a round trip
+	}
+
+	// Chain the record to the org's previous record for tamper evidence. The
+	// event is recorded even if the request that caused it has ended.
+	if l.store != nil {
+		storeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), persistTimeout)
+		err := l.store.AppendChained(storeCtx, &log, seal)
+		cancel()
+		if err != nil {
+			l.logger.Error().Err(err).Str("action", string(log.Action)).Msg("Failed to persist audit event")
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.store == nil {
+		log.PrevHash = l.lastHash[log.OrgID]
+		if err := seal(&log); err != nil {
+			l.logger.Error().Err(err).Str("action", string(log.Action)).Msg("Failed to hash audit event")
+		}
+		l.lastHash[log.OrgID] = log.Hash
 	}
 
 	// Add to in-memory store
@@ -196,6 +229,52 @@ func (l *Logger) Search(query string, filter domain.AuditLogFilter) domain.Audit
 	}
 }
 
+// Verify walks the org's hash chain and reports the first broken link. With
+// a store the whole stored chain is checked; otherwise only the logs still
+// held in memory.
+func (l *Logger) Verify(ctx context.Context, orgID uuid.UUID) (ChainVerification, error) {
+	if l.store == nil {
+		l.mu.RLock()
+		defer l.mu.RUnlock()
+
+		chain := make([]domain.AuditLog, 0)
+		for _, log := range l.logs {
+			if log.OrgID == orgID {
+				chain = append(chain, log)
+			}
+		}
+		return VerifyChain(orgID, chain), nil
+	}
+
+	v := newChainVerifier(orgID)
+	var after int64
+	for {
+		logs, next, err := l.store.ListChain(ctx, orgID, after, verifyPageSize)
+		if err != nil {
+			return ChainVerification{}, fmt.Errorf("list audit chain: %w", err)
+		}
+		for _, log := range logs {
+			if !v.add(log) {
+				return v.result, nil
+			}
+		}
+		if len(logs) < verifyPageSize {
+			return v.result, nil
+		}
+		after = next
+	}
+}
+
+// seal sets the record's hash over its contents and PrevHash.
+func seal(log *domain.AuditLog) error {
+	hash, err := ComputeHash(*log)
+	if err != nil {
+		return err
+	}
+	log.Hash = hash
+	return nil
+}
+
 // Export exports audit logs in the specified format.
 func (l *Logger) Export(filter domain.AuditLogFilter, format domain.AuditExportFormat) ([]byte, error) {
 	page := l.GetLogs(filter)
@@ -333,7 +412,7 @@ func (l *Logger) exportCSV(logs []domain.AuditLog) ([]byte, error) {
 	writer := csv.NewWriter(&buf)
 
 	// Write header
-	header := []string{"ID", "Timestamp", "Action", "Resource", "ResourceID", "Outcome", "UserID", "APIKeyID", "IPAddress", "DurationMS"}
+	header := []string{"ID", "Timestamp", "Action", "Resource", "ResourceID", "Outcome", "UserID", "APIKeyID", "IPAddress", "DurationMS", "PrevHash", "Hash"}
 	if err := writer.Write(header); err != nil {
 		return nil, err
 	}
@@ -342,7 +421,7 @@ func (l *Logger) exportCSV(logs []domain.AuditLog) ([]byte, error) {
 	for _, log := range logs {
 		row := []string{
 			log.ID.String(),
-			log.CreatedAt.Format(time.RFC3339),
+			log.CreatedAt.Format(time.RFC3339Nano),
 			string(log.Action),
 			log.Resource,
 			log.ResourceID,
@@ -351,6 +430,8 @@ func (l *Logger) exportCSV(logs []domain.AuditLog) ([]byte, error) {
 			uuidToString(log.APIKeyID),
 			log.IPAddress,
 			intToString(log.DurationMS),
+			log.PrevHash,
+			log.Hash,
 		}
 		if err := writer.Write(row); err != nil {
 			return nil, err
@@ -0,0 +1,221 @@
+package audit
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// fakeStore holds audit logs in insertion order, appending under one lock the
+// way the repository locks an org's chain.
+type fakeStore struct {
+	mu   sync.Mutex
+	logs []domain.AuditLog
+}
+
+func (s *fakeStore) AppendChained(ctx context.Context, log *domain.AuditLog, seal func(*domain.AuditLog) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	log.PrevHash = ""
+	for i := len(s.logs) - 1; i >= 0; i-- {
+		if s.logs[i].OrgID == log.OrgID {
+			log.PrevHash = s.logs[i].Hash
+			break
+		}
+	}
+	if err := seal(log); err != nil {
+		return err
+	}
+	s.logs = append(s.logs, *log)
+	return nil
+}
+
+func (s *fakeStore) ListChain(ctx context.Context, orgID uuid.UUID, after int64, limit int) ([]domain.AuditLog, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var logs []domain.AuditLog
+	for seq := after; seq < int64(len(s.logs)) && len(logs) < limit; seq++ {
+		if s.logs[seq].OrgID == orgID {
+			logs = append(logs, s.logs[seq])
+		}
+		after = seq + 1
+	}
+	return logs, after, nil
+}
+
+// chain returns the org's stored logs, oldest first.
+func (s *fakeStore) chain(orgID uuid.UUID) []domain.AuditLog {
+	logs, _, _ := s.ListChain(context.Background(), orgID, 0, len(s.logs))
+	return logs
+}
+
+func logEvents(l *Logger, orgID uuid.UUID, n int) {
+	for i := 0; i < n; i++ {
+		l.LogEvent(context.Background(), Event{
+			OrgID:    orgID,
+			Action:   domain.AuditActionMCPToolCall,
+			Resource: "mcp",
+			Outcome:  domain.AuditOutcomeSuccess,
+			Details:  map[string]interface{}{"n": i},
+		})
+	}
+}
+
+func verify(t *testing.T, l *Logger, orgID uuid.UUID) ChainVerification {
+	t.Helper()
+	result, err := l.Verify(context.Background(), orgID)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	return result
+}
+
+func TestLogEventContinuesStoredChainAfterRestart(t *testing.T) {
+	store := &fakeStore{}
+	orgID := uuid.New()
+
+	logEvents(NewLogger(zerolog.Nop(), store), orgID, 3)
+	head := store.chain(orgID)[2].Hash
+
+	// A restarted process starts with an empty buffer but continues the
+	// stored chain instead of starting a new one
+	restarted := NewLogger(zerolog.Nop(), store)
+	logEvents(restarted, orgID, 1)
+
+	chain := store.chain(orgID)
+	if chain[3].PrevHash != head {
+		t.Fatalf("first record after restart has prev_hash %q, want the stored head %q", chain[3].PrevHash, head)
+	}
+	if result := verify(t, restarted, orgID); !result.Valid || result.Checked != 4 {
+		t.Errorf("Verify = %+v, want a valid chain of 4", result)
+	}
+}
+
+func TestLogEventReplicasShareOneChain(t *testing.T) {
+	store := &fakeStore{}
+	orgID := uuid.New()
+	replicas := []*Logger{NewLogger(zerolog.Nop(), store), NewLogger(zerolog.Nop(), store)}
+
+	var wg sync.WaitGroup
+	for _, l := range replicas {
+		wg.Add(1)
+		go func(l *Logger) {
+			defer wg.Done()
+			logEvents(l, orgID, 50)
+		}(l)
+	}
+	wg.Wait()
+
+	for i, l := range replicas {
+		if result := verify(t, l, orgID); !result.Valid || result.Checked != 100 {
+			t.Errorf("replica %d: Verify = %+v, want a valid chain of 100", i, result)
+		}
+	}
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(logs []domain.AuditLog) []domain.AuditLog
+		broken int // Index of the first record reported, after tampering
+	}{
+		{
+			name: "modified contents",
+			tamper: func(logs []domain.AuditLog) []domain.AuditLog {
+				logs[2].Outcome = domain.AuditOutcomeFailure
+				return logs
+			},
+			broken: 2,
+		},
+		{
+			name: "modified contents rehashed",
+			tamper: func(logs []domain.AuditLog) []domain.AuditLog {
+				logs[2].Outcome = domain.AuditOutcomeFailure
+				logs[2].Hash, _ = ComputeHash(logs[2])
+				return logs
+			},
+			broken: 3,
+		},
+		{
+			name: "removed record",
+			tamper: func(logs []domain.AuditLog) []domain.AuditLog {
+				return append(logs[:2], logs[3:]...)
+			},
+			broken: 2,
+		},
+		{
+			name: "reordered records",
+			tamper: func(logs []domain.AuditLog) []domain.AuditLog {
+				logs[1], logs[2] = logs[2], logs[1]
+				return logs
+			},
+			broken: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeStore{}
+			orgID := uuid.New()
+			l := NewLogger(zerolog.Nop(), store)
+			logEvents(l, orgID, 5)
+			logEvents(l, uuid.New(), 2) // Another org's records are not part of the chain
+
+			if result := verify(t, l, orgID); !result.Valid {
+				t.Fatalf("untampered chain: %+v", result)
+			}
+
+			store.logs = tt.tamper(store.logs)
+			want := store.logs[tt.broken].ID
+			result := verify(t, l, orgID)
+			if result.Valid {
+				t.Fatal("tampered chain verified as valid")
+			}
+			if result.BrokenAt == nil || *result.BrokenAt != want {
+				t.Errorf("broken at %v, want %s (%s)", result.BrokenAt, want, result.Reason)
+			}
+		})
+	}
+}
+
+func TestVerifyChecksStoredChainBeyondMemory(t *testing.T) {
+	store := &fakeStore{}
+	orgID := uuid.New()
+	l := NewLogger(zerolog.Nop(), store)
+	l.maxLogs = 10
+
+	n := verifyPageSize + 50
+	logEvents(l, orgID, n)
+	if result := verify(t, l, orgID); !result.Valid || result.Checked != n {
+		t.Fatalf("Verify = %+v, want a valid chain of %d", result, n)
+	}
+
+	// The tampered record left memory long ago
+	store.logs[5].Details = map[string]interface{}{"n": "forged"}
+	result := verify(t, l, orgID)
+	if result.Valid || *result.BrokenAt != store.logs[5].ID {
+		t.Errorf("Verify = %+v, want broken at the record evicted from memory", result)
+	}
+}
+
+func TestVerifyInMemory(t *testing.T) {
+	orgID := uuid.New()
+	l := NewLogger(zerolog.Nop(), nil)
+	logEvents(l, orgID, 4)
+
+	if result := verify(t, l, orgID); !result.Valid || result.Checked != 4 {
+		t.Fatalf("Verify = %+v, want a valid chain of 4", result)
+	}
+
+	l.logs[1].ResourceID = "forged"
+	result := verify(t, l, orgID)
+	if result.Valid || *result.BrokenAt != l.logs[1].ID {
+		t.Errorf("Verify = %+v, want broken at the modified record", result)
+	}
+}
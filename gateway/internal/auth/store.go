@@ -1,33 +1,62 @@
-// Package auth provides API key authentication.
+// Package auth provides API key and session authentication.
 package auth
 
 import (
 	"context"
 	cryptoRand "crypto/rand"
 	"crypto/sha256"
-	"database/sql"
 	"encoding/hex"
 	"errors"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
 	"github.com/akz4ol/gatewayops/gateway/internal/middleware"
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 )
 
 var (
-	ErrInvalidKey = errors.New("invalid API key")
-	ErrExpiredKey = errors.New("API key has expired")
-	ErrRevokedKey = errors.New("API key has been revoked")
+	ErrInvalidKey     = errors.New("invalid API key")
+	ErrExpiredKey     = errors.New("API key has expired")
+	ErrRevokedKey     = errors.New("API key has been revoked")
+	ErrInvalidSession = errors.New("invalid or expired session")
 )
 
-// Store implements middleware.AuthStore for API key validation.
+// KeyStore looks up stored API keys by their raw value.
+type KeyStore interface {
+	GetByHash(ctx context.Context, rawKey string) (*domain.APIKey, error)
+}
+
+// OrgStore looks up the IP allowlists organizations set for their keys and
+// sessions.
+type OrgStore interface {
+	GetOrgAllowedCIDRs(ctx context.Context, orgID uuid.UUID) ([]string, error)
+}
+
+// SessionValidator validates SSO session tokens.
+type SessionValidator interface {
+	ValidateSession(token string) (*domain.UserSession, *domain.User)
+}
+
+// PermissionResolver returns the permissions a user holds through their
+// roles.
+type PermissionResolver interface {
+	GetUserPermissions(userID uuid.UUID) []domain.Permission
+}
+
+// Store implements middleware.AuthStore, resolving API keys and SSO sessions
+// to the org, user and permissions a request acts with.
 type Store struct {
-	db     *sql.DB
-	logger zerolog.Logger
-	cache  *keyCache
+	keys     KeyStore
+	orgs     OrgStore
+	sessions SessionValidator
+	roles    PermissionResolver
+	demoMode bool
+	logger   zerolog.Logger
+	cache    *keyCache
+	orgCache *orgCache
 }
 
 // keyCache provides in-memory caching of validated keys.
@@ -42,28 +71,93 @@ type cacheItem struct {
 	expiresAt time.Time
 }
 
-// NewStore creates a new auth store.
-func NewStore(db *sql.DB, logger zerolog.Logger) *Store {
+// orgCache provides in-memory caching of organizations' IP allowlists, so
+// session requests do not look them up every time.
+type orgCache struct {
+	mu    sync.RWMutex
+	items map[uuid.UUID]orgCacheItem
+	ttl   time.Duration
+}
+
+type orgCacheItem struct {
+	cidrs     []string
+	expiresAt time.Time
+}
+
+// NewStore creates a new auth store. API keys are looked up in keys, their
+// orgs' IP allowlists in orgs, and session users' permissions come from their
+// roles in roles. In demo mode, a key that is not stored acts for the demo
+// org.
+func NewStore(keys KeyStore, orgs OrgStore, sessions SessionValidator, roles PermissionResolver, demoMode bool, logger zerolog.Logger) *Store {
 	return &Store{
-		db:     db,
-		logger: logger,
+		keys:     keys,
+		orgs:     orgs,
+		sessions: sessions,
+		roles:    roles,
+		demoMode: demoMode,
+		logger:   logger,
 		cache: &keyCache{
 			items: make(map[string]*cacheItem),
-			ttl:   5 * time.Minute,
+			ttl:   time.Minute,
+		},
+		orgCache: &orgCache{
+			items: make(map[uuid.UUID]orgCacheItem),
+			ttl:   time.Minute,
 		},
 	}
 }
 
-// ValidateAPIKey validates an API key and returns auth info.
-// In demo mode, returns mock auth info for any key starting with "gwo_".
+// ValidateAPIKey validates an API key and returns the org, user, team and
+// permissions it was issued with. Validated keys are cached briefly, so a
+// revoked key may be accepted for up to a minute.
 func (s *Store) ValidateAPIKey(ctx context.Context, apiKey string) (*middleware.AuthInfo, error) {
-	// Demo mode: accept any key starting with "gwo_"
-	if strings.HasPrefix(apiKey, "gwo_") {
+	keyHash := hashKey(apiKey)
+	if info := s.cache.get(keyHash); info != nil {
+		return info, nil
+	}
+
+	if s.keys != nil {
+		key, err := s.keys.GetByHash(ctx, apiKey)
+		if err != nil {
+			return nil, err
+		}
+		if key != nil {
+			if key.Revoked {
+				return nil, ErrRevokedKey
+			}
+			if key.ExpiresAt != nil && !key.ExpiresAt.After(time.Now()) {
+				return nil, ErrExpiredKey
+			}
+			orgCIDRs, err := s.orgAllowedCIDRs(ctx, key.OrgID)
+			if err != nil {
+				return nil, err
+			}
+			info := &middleware.AuthInfo{
+				KeyID:           key.KeyPrefix,
+				APIKeyID:        key.ID,
+				UserID:          key.CreatedBy,
+				OrgID:           key.OrgID,
+				Environment:     key.Environment,
+				Permissions:     key.Permissions,
+				RateLimit:       key.RateLimit,
+				AllowedCIDRs:    key.AllowedCIDRs,
+				OrgAllowedCIDRs: orgCIDRs,
+			}
+			if key.TeamID != nil {
+				info.TeamID = *key.TeamID
+			}
+			s.cache.set(keyHash, info, key.ExpiresAt)
+			return info, nil
+		}
+	}
+
+	// Demo mode: accept any other key starting with "gwo_"
+	if s.demoMode && strings.HasPrefix(apiKey, "gwo_") {
 		s.logger.Debug().Str("key_prefix", apiKey[:12]).Msg("Demo mode: API key accepted")
 		return &middleware.AuthInfo{
 			KeyID:       "demo-key",
 			APIKeyID:    uuid.New(),
-			OrgID:       uuid.MustParse("00000000-0000-0000-0000-000000000001"),
+			OrgID:       middleware.DemoOrgID,
 			UserID:      uuid.MustParse("00000000-0000-0000-0000-000000000002"),
 			Environment: "demo",
 			Permissions: []string{"*"},
@@ -74,6 +168,56 @@ func (s *Store) ValidateAPIKey(ctx context.Context, apiKey string) (*middleware.
 	return nil, ErrInvalidKey
 }
 
+// ValidateSession validates an SSO session token and returns the signed-in
+// user's org, the org's IP allowlist and the permissions their roles grant.
+func (s *Store) ValidateSession(ctx context.Context, token string) (*middleware.AuthInfo, error) {
+	if s.sessions == nil {
+		return nil, ErrInvalidSession
+	}
+	session, _ := s.sessions.ValidateSession(token)
+	if session == nil {
+		return nil, ErrInvalidSession
+	}
+
+	orgCIDRs, err := s.orgAllowedCIDRs(ctx, session.OrgID)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &middleware.AuthInfo{
+		KeyID:           "session:" + session.ID.String(),
+		UserID:          session.UserID,
+		OrgID:           session.OrgID,
+		Environment:     "session",
+		Permissions:     []string{},
+		OrgAllowedCIDRs: orgCIDRs,
+	}
+	if s.roles != nil {
+		for _, permission := range s.roles.GetUserPermissions(session.UserID) {
+			info.Permissions = append(info.Permissions, string(permission))
+		}
+	}
+	return info, nil
+}
+
+// orgAllowedCIDRs returns the org's IP allowlist, cached briefly like keys.
+// An org that cannot be looked up fails authentication rather than going
+// unrestricted.
+func (s *Store) orgAllowedCIDRs(ctx context.Context, orgID uuid.UUID) ([]string, error) {
+	if s.orgs == nil {
+		return nil, nil
+	}
+	if cidrs, ok := s.orgCache.get(orgID); ok {
+		return cidrs, nil
+	}
+	cidrs, err := s.orgs.GetOrgAllowedCIDRs(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	s.orgCache.set(orgID, cidrs)
+	return cidrs, nil
+}
+
 // hashKey creates a SHA-256 hash of the API key for cache lookup.
 func hashKey(key string) string {
 	h := sha256.Sum256([]byte(key))
@@ -108,16 +252,40 @@ func (c *keyCache) get(keyHash string) *middleware.AuthInfo {
 	return item.info
 }
 
-func (c *keyCache) set(keyHash string, info *middleware.AuthInfo) {
+// set caches a key's auth info for the cache TTL, or until the key itself
+// expires if that is sooner.
+func (c *keyCache) set(keyHash string, info *middleware.AuthInfo, keyExpiresAt *time.Time) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	expiresAt := time.Now().Add(c.ttl)
+	if keyExpiresAt != nil && keyExpiresAt.Before(expiresAt) {
+		expiresAt = *keyExpiresAt
+	}
 	c.items[keyHash] = &cacheItem{
 		info:      info,
-		expiresAt: time.Now().Add(c.ttl),
+		expiresAt: expiresAt,
 	}
 }
 
+func (c *orgCache) get(orgID uuid.UUID) ([]string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	item, ok := c.items[orgID]
+	if !ok || time.Now().After(item.expiresAt) {
+		return nil, false
+	}
+	return item.cidrs, true
+}
+
+func (c *orgCache) set(orgID uuid.UUID, cidrs []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.items[orgID] = orgCacheItem{cidrs: cidrs, expiresAt: time.Now().Add(c.ttl)}
+}
+
 // GenerateAPIKey generates a new API key.
 // Format: gwo_{env}_{32_random_chars}
 func GenerateAPIKey(env string) (string, error) {
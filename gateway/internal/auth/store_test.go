@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+const testKey = "gwo_prd_0123456789abcdef0123456789abcdef"
+
+type fakeKeyStore struct {
+	key *domain.APIKey
+}
+
+func (s *fakeKeyStore) GetByHash(ctx context.Context, rawKey string) (*domain.APIKey, error) {
+	if rawKey != testKey {
+		return nil, nil
+	}
+	return s.key, nil
+}
+
+// fakeOrgStore serves fixed allowlists and counts lookups.
+type fakeOrgStore struct {
+	cidrs   map[uuid.UUID][]string
+	err     error
+	lookups int
+}
+
+func (s *fakeOrgStore) GetOrgAllowedCIDRs(ctx context.Context, orgID uuid.UUID) ([]string, error) {
+	s.lookups++
+	return s.cidrs[orgID], s.err
+}
+
+type fakeSessions struct {
+	session *domain.UserSession
+}
+
+func (s *fakeSessions) ValidateSession(token string) (*domain.UserSession, *domain.User) {
+	if token != "session-token" {
+		return nil, nil
+	}
+	return s.session, nil
+}
+
+func TestValidateAPIKeyLoadsOrgAllowlist(t *testing.T) {
+	orgID := uuid.New()
+	keys := &fakeKeyStore{key: &domain.APIKey{
+		ID:           uuid.New(),
+		OrgID:        orgID,
+		KeyPrefix:    "gwo_prd_01234567",
+		AllowedCIDRs: []string{"192.0.2.0/24"},
+	}}
+	orgs := &fakeOrgStore{cidrs: map[uuid.UUID][]string{orgID: {"203.0.113.0/24"}}}
+	store := NewStore(keys, orgs, nil, nil, false, zerolog.Nop())
+
+	info, err := store.ValidateAPIKey(context.Background(), testKey)
+	if err != nil {
+		t.Fatalf("ValidateAPIKey: %v", err)
+	}
+	if len(info.AllowedCIDRs) != 1 || info.AllowedCIDRs[0] != "192.0.2.0/24" {
+		t.Errorf("AllowedCIDRs = %v", info.AllowedCIDRs)
+	}
+	if len(info.OrgAllowedCIDRs) != 1 || info.OrgAllowedCIDRs[0] != "203.0.113.0/24" {
+		t.Errorf("OrgAllowedCIDRs = %v", info.OrgAllowedCIDRs)
+	}
+}
+
+func TestValidateSessionLoadsOrgAllowlist(t *testing.T) {
+	orgID := uuid.New()
+	orgs := &fakeOrgStore{cidrs: map[uuid.UUID][]string{orgID: {"203.0.113.0/24"}}}
+	sessions := &fakeSessions{session: &domain.UserSession{ID: uuid.New(), UserID: uuid.New(), OrgID: orgID}}
+	store := NewStore(nil, orgs, sessions, nil, false, zerolog.Nop())
+
+	for i := 0; i < 3; i++ {
+		info, err := store.ValidateSession(context.Background(), "session-token")
+		if err != nil {
+			t.Fatalf("ValidateSession: %v", err)
+		}
+		if len(info.OrgAllowedCIDRs) != 1 || info.OrgAllowedCIDRs[0] != "203.0.113.0/24" {
+			t.Fatalf("OrgAllowedCIDRs = %v", info.OrgAllowedCIDRs)
+		}
+	}
+	if orgs.lookups != 1 {
+		t.Errorf("looked up the org %d times, want 1 while cached", orgs.lookups)
+	}
+}
+
+func TestOrgLookupFailureRejects(t *testing.T) {
+	orgID := uuid.New()
+	orgs := &fakeOrgStore{err: errors.New("database unavailable")}
+	keys := &fakeKeyStore{key: &domain.APIKey{ID: uuid.New(), OrgID: orgID}}
+	sessions := &fakeSessions{session: &domain.UserSession{ID: uuid.New(), OrgID: orgID}}
+	store := NewStore(keys, orgs, sessions, nil, false, zerolog.Nop())
+
+	if _, err := store.ValidateAPIKey(context.Background(), testKey); err == nil {
+		t.Error("ValidateAPIKey succeeded without the org's allowlist")
+	}
+	if _, err := store.ValidateSession(context.Background(), "session-token"); err == nil {
+		t.Error("ValidateSession succeeded without the org's allowlist")
+	}
+}
@@ -0,0 +1,322 @@
+// Package budget provides spend caps for organizations and teams.
+package budget
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/alerting"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/orgsettings"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// Service tracks spend against budgets and enforces soft and hard caps.
+type Service struct {
+	logger   zerolog.Logger
+	alerts   *alerting.Service
+	settings *orgsettings.Service
+	budgets  map[uuid.UUID]*domain.Budget
+	mu       sync.RWMutex
+}
+
+// NewService creates a new budget service. Organizations that turn off the
+// budget_enforcement setting are never blocked, though their spend is still
+// tracked and alerted on.
+func NewService(logger zerolog.Logger, alerts *alerting.Service, settings *orgsettings.Service) *Service {
+	s := &Service{
+		logger:   logger,
+		alerts:   alerts,
+		settings: settings,
+		budgets:  make(map[uuid.UUID]*domain.Budget),
+	}
+
+	logger.Info().Msg("Budget service initialized")
+	return s
+}
+
+// CreateBudget creates a new budget.
+func (s *Service) CreateBudget(input domain.BudgetInput, orgID uuid.UUID) *domain.Budget {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	b := &domain.Budget{
+		ID:        uuid.New(),
+		OrgID:     orgID,
+		CreatedAt: now,
+	}
+	applyInput(b, input)
+	b.PeriodStart = domain.BudgetPeriodStart(b.Period, now)
+	b.UpdatedAt = now
+
+	s.budgets[b.ID] = b
+
+	s.logger.Info().
+		Str("budget_id", b.ID.String()).
+		Str("name", b.Name).
+		Float64("limit_usd", b.LimitUSD).
+		Msg("Budget created")
+
+	return b
+}
+
+// GetBudget returns a budget by ID.
+func (s *Service) GetBudget(id uuid.UUID) *domain.Budget {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, exists := s.budgets[id]
+	if !exists {
+		return nil
+	}
+	s.resetIfExpired(b, time.Now())
+	copy := *b
+	return &copy
+}
+
+// ListBudgets returns all budgets for an organization.
+func (s *Service) ListBudgets(orgID uuid.UUID) []domain.Budget {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	budgets := make([]domain.Budget, 0)
+	for _, b := range s.budgets {
+		if b.OrgID != orgID {
+			continue
+		}
+		s.resetIfExpired(b, now)
+		budgets = append(budgets, *b)
+	}
+	return budgets
+}
+
+// UpdateBudget updates an existing budget. Spend in the current period is kept.
+func (s *Service) UpdateBudget(id uuid.UUID, input domain.BudgetInput) *domain.Budget {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, exists := s.budgets[id]
+	if !exists {
+		return nil
+	}
+
+	period := b.Period
+	applyInput(b, input)
+	if b.Period != period {
+		b.PeriodStart = domain.BudgetPeriodStart(b.Period, time.Now())
+	}
+	b.SoftAlerted = b.SpentUSD >= b.LimitUSD*b.SoftThreshold
+	b.UpdatedAt = time.Now()
+
+	copy := *b
+	return &copy
+}
+
+// DeleteBudget deletes a budget.
+func (s *Service) DeleteBudget(id uuid.UUID) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.budgets[id]; exists {
+		delete(s.budgets, id)
+		return true
+	}
+	return false
+}
+
+// Reservation holds an estimated cost against every budget covering a call
+// until the call's actual cost is known.
+type Reservation struct {
+	orgID   uuid.UUID
+	teamID  *uuid.UUID
+	amount  float64
+	budgets map[uuid.UUID]time.Time // Budget ID to the period the amount was held in
+}
+
+// Reserve checks that a call with the given estimated cost fits within every
+// applicable budget and, when it does, holds the estimate against them in the
+// same step so concurrent calls cannot overshoot a cap. When it does not, the
+// exhausted budget is returned and nothing is held. Every reservation must be
+// settled with Settle.
+func (s *Service) Reserve(orgID uuid.UUID, teamID *uuid.UUID, estimatedCost float64) (*Reservation, *domain.Budget) {
+	res := &Reservation{orgID: orgID, teamID: teamID, budgets: make(map[uuid.UUID]time.Time)}
+	if estimatedCost <= 0 || !s.settings.Bool(orgID, domain.SettingBudgetEnforcement) {
+		return res, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var applicable []*domain.Budget
+	for _, b := range s.budgets {
+		if !s.applies(b, orgID, teamID) {
+			continue
+		}
+		s.resetIfExpired(b, now)
+		if b.SpentUSD+estimatedCost > b.LimitUSD {
+			copy := *b
+			return nil, &copy
+		}
+		applicable = append(applicable, b)
+	}
+
+	res.amount = estimatedCost
+	for _, b := range applicable {
+		b.SpentUSD += estimatedCost
+		res.budgets[b.ID] = b.PeriodStart
+	}
+	return res, nil
+}
+
+// Settle replaces a reservation's estimate with the call's actual cost. A
+// call that failed before it was billed settles with a cost of zero, which
+// releases the reservation.
+func (s *Service) Settle(res *Reservation, cost float64) {
+	if res == nil {
+		return
+	}
+
+	s.mu.Lock()
+	for id, periodStart := range res.budgets {
+		// A budget that was deleted, or has since started a new period, no
+		// longer holds the estimate
+		if b, exists := s.budgets[id]; exists && b.PeriodStart.Equal(periodStart) {
+			b.SpentUSD -= res.amount
+			if b.SpentUSD < 0 {
+				b.SpentUSD = 0
+			}
+		}
+	}
+	res.budgets = nil
+	crossed := s.record(res.orgID, res.teamID, cost)
+	s.mu.Unlock()
+
+	s.alertCrossed(crossed)
+}
+
+// Applicable returns the enabled budgets covering calls from the org and
+// team, with their spend in the current period.
+func (s *Service) Applicable(orgID uuid.UUID, teamID *uuid.UUID) []domain.Budget {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var budgets []domain.Budget
+	for _, b := range s.budgets {
+		if !s.applies(b, orgID, teamID) {
+			continue
+		}
+		s.resetIfExpired(b, now)
+		budgets = append(budgets, *b)
+	}
+	sort.Slice(budgets, func(i, j int) bool { return budgets[i].CreatedAt.Before(budgets[j].CreatedAt) })
+	return budgets
+}
+
+// Record adds the cost of a completed call to every applicable budget and
+// fires a warning alert the first time a budget crosses its soft threshold.
+func (s *Service) Record(orgID uuid.UUID, teamID *uuid.UUID, cost float64) {
+	s.mu.Lock()
+	crossed := s.record(orgID, teamID, cost)
+	s.mu.Unlock()
+
+	s.alertCrossed(crossed)
+}
+
+// record adds cost to every applicable budget and returns the budgets that
+// crossed their soft threshold. The caller must hold s.mu.
+func (s *Service) record(orgID uuid.UUID, teamID *uuid.UUID, cost float64) []domain.Budget {
+	if cost <= 0 {
+		return nil
+	}
+
+	var crossed []domain.Budget
+	now := time.Now()
+	for _, b := range s.budgets {
+		if !s.applies(b, orgID, teamID) {
+			continue
+		}
+		s.resetIfExpired(b, now)
+		b.SpentUSD += cost
+
+		if !b.SoftAlerted && b.SoftThreshold > 0 && b.SpentUSD >= b.LimitUSD*b.SoftThreshold {
+			b.SoftAlerted = true
+			crossed = append(crossed, *b)
+		}
+	}
+	return crossed
+}
+
+// alertCrossed fires the soft threshold alerts for budgets returned by record.
+func (s *Service) alertCrossed(crossed []domain.Budget) {
+	for _, b := range crossed {
+		s.logger.Warn().
+			Str("budget_id", b.ID.String()).
+			Float64("spent_usd", b.SpentUSD).
+			Float64("limit_usd", b.LimitUSD).
+			Msg("Budget soft threshold reached")
+
+		if s.alerts != nil {
+			s.alerts.CreateSystemAlert(
+				b.OrgID,
+				fmt.Sprintf("Budget: %s", b.Name),
+				domain.AlertMetricBudgetUsage,
+				domain.AlertSeverityWarning,
+				b.SpentUSD,
+				b.LimitUSD*b.SoftThreshold,
+				fmt.Sprintf("Budget %q has used $%.2f of $%.2f (%.0f%%) this %s period",
+					b.Name, b.SpentUSD, b.LimitUSD, b.SpentUSD/b.LimitUSD*100, b.Period),
+				b.Channels,
+			)
+		}
+	}
+}
+
+// applies reports whether the budget covers calls from the org and team.
+func (s *Service) applies(b *domain.Budget, orgID uuid.UUID, teamID *uuid.UUID) bool {
+	if !b.Enabled || b.OrgID != orgID {
+		return false
+	}
+	if b.TeamID == nil {
+		return true
+	}
+	return teamID != nil && *b.TeamID == *teamID
+}
+
+// resetIfExpired starts a new period when the current one has ended.
+func (s *Service) resetIfExpired(b *domain.Budget, now time.Time) {
+	if now.Before(b.PeriodEnd()) {
+		return
+	}
+	b.PeriodStart = domain.BudgetPeriodStart(b.Period, now)
+	b.SpentUSD = 0
+	b.SoftAlerted = false
+
+	s.logger.Info().
+		Str("budget_id", b.ID.String()).
+		Time("period_start", b.PeriodStart).
+		Msg("Budget period reset")
+}
+
+// applyInput copies input fields onto a budget, applying defaults.
+func applyInput(b *domain.Budget, input domain.BudgetInput) {
+	b.TeamID = input.TeamID
+	b.Name = input.Name
+	b.Period = input.Period
+	if b.Period != domain.BudgetPeriodDaily {
+		b.Period = domain.BudgetPeriodMonthly
+	}
+	b.LimitUSD = input.LimitUSD
+	b.SoftThreshold = input.SoftThreshold
+	if b.SoftThreshold <= 0 || b.SoftThreshold > 1 {
+		b.SoftThreshold = 0.8
+	}
+	b.Channels = input.Channels
+	b.Enabled = input.Enabled
+}
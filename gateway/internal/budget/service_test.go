@@ -0,0 +1,172 @@
+package budget
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/alerting"
+	"github.com/akz4ol/gatewayops/gateway/internal/clock"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/orgsettings"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// fits reports whether a call with the estimated cost would be admitted,
+// releasing the reservation it takes.
+func fits(s *Service, orgID uuid.UUID, teamID *uuid.UUID, estimatedCost float64) (bool, *domain.Budget) {
+	res, exhausted := s.Reserve(orgID, teamID, estimatedCost)
+	s.Settle(res, 0)
+	return res != nil, exhausted
+}
+
+func newTestService(t *testing.T) (*Service, *alerting.Service, *orgsettings.Service) {
+	t.Helper()
+	alerts := alerting.NewService(zerolog.Nop(), nil, nil, 100, clock.Real)
+	t.Cleanup(func() { alerts.Shutdown(context.Background()) })
+	settings := orgsettings.NewService(nil, zerolog.Nop(), clock.Real)
+	return NewService(zerolog.Nop(), alerts, settings), alerts, settings
+}
+
+func TestReserveAndRecord(t *testing.T) {
+	s, alerts, _ := newTestService(t)
+	orgID, teamID, otherTeam := uuid.New(), uuid.New(), uuid.New()
+
+	org := s.CreateBudget(domain.BudgetInput{Name: "org", LimitUSD: 10, SoftThreshold: 0.5, Enabled: true}, orgID)
+	team := s.CreateBudget(domain.BudgetInput{Name: "team", TeamID: &teamID, LimitUSD: 2, Enabled: true}, orgID)
+	s.CreateBudget(domain.BudgetInput{Name: "disabled", LimitUSD: 0.01}, orgID)
+
+	s.Record(orgID, &teamID, 1.5)
+	if ok, exhausted := fits(s, orgID, &teamID, 1); ok || exhausted == nil || exhausted.ID != team.ID {
+		t.Errorf("Reserve past the team cap = %v, %v; want the team budget exhausted", ok, exhausted)
+	}
+	if ok, _ := fits(s, orgID, &otherTeam, 1); !ok {
+		t.Error("another team's call was held to the team budget")
+	}
+	if ok, _ := fits(s, orgID, nil, 8.5); !ok {
+		t.Error("a call that exactly fills the org budget was refused")
+	}
+	if ok, exhausted := fits(s, orgID, nil, 8.6); ok || exhausted.ID != org.ID {
+		t.Errorf("Reserve past the org cap = %v, %v; want the org budget exhausted", ok, exhausted)
+	}
+	if ok, _ := fits(s, uuid.New(), nil, 100); !ok {
+		t.Error("another organization's call was held to this organization's budgets")
+	}
+
+	// The soft threshold alerts once, when first crossed
+	s.Record(orgID, nil, 4)
+	s.Record(orgID, nil, 1)
+	if active := alerts.GetActiveAlerts(orgID); len(active) != 1 || active[0].Threshold != 5 {
+		t.Errorf("active alerts = %+v, want one alert at the soft threshold of $5", active)
+	}
+	if got := s.GetBudget(org.ID).SpentUSD; got != 6.5 {
+		t.Errorf("org budget spent = %v, want 6.5", got)
+	}
+	if got := s.GetBudget(team.ID).SpentUSD; got != 1.5 {
+		t.Errorf("team budget spent = %v, want 1.5: calls without the team do not count", got)
+	}
+}
+
+func TestReserveHonoursEnforcementSetting(t *testing.T) {
+	s, _, settings := newTestService(t)
+	orgID := uuid.New()
+	b := s.CreateBudget(domain.BudgetInput{Name: "org", LimitUSD: 1, Enabled: true}, orgID)
+
+	if _, err := settings.Set(context.Background(), orgID, domain.SettingBudgetEnforcement, false, nil); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if ok, _ := fits(s, orgID, nil, 5); !ok {
+		t.Error("a call was blocked with budget enforcement off")
+	}
+	s.Record(orgID, nil, 5)
+	if got := s.GetBudget(b.ID).SpentUSD; got != 5 {
+		t.Errorf("spent = %v, want spend tracked with enforcement off", got)
+	}
+}
+
+func TestBudgetPeriodResets(t *testing.T) {
+	s, _, _ := newTestService(t)
+	orgID := uuid.New()
+	b := s.CreateBudget(domain.BudgetInput{Name: "daily", Period: domain.BudgetPeriodDaily, LimitUSD: 1, Enabled: true}, orgID)
+	s.Record(orgID, nil, 1)
+	if ok, _ := fits(s, orgID, nil, 0.5); ok {
+		t.Fatal("a call past a spent budget was allowed")
+	}
+
+	// Move the period back a day
+	s.mu.Lock()
+	s.budgets[b.ID].PeriodStart = s.budgets[b.ID].PeriodStart.Add(-24 * time.Hour)
+	s.mu.Unlock()
+
+	if ok, _ := fits(s, orgID, nil, 0.5); !ok {
+		t.Error("a call was refused in a new period")
+	}
+	got := s.GetBudget(b.ID)
+	if got.SpentUSD != 0 || got.SoftAlerted || !got.PeriodStart.Equal(domain.BudgetPeriodStart(domain.BudgetPeriodDaily, time.Now())) {
+		t.Errorf("budget after its period ended = %+v, want the spend reset for today", got)
+	}
+}
+
+func TestReserveHoldsTheEstimateUntilSettled(t *testing.T) {
+	s, _, _ := newTestService(t)
+	orgID := uuid.New()
+	b := s.CreateBudget(domain.BudgetInput{Name: "org", LimitUSD: 10, Enabled: true}, orgID)
+
+	res, _ := s.Reserve(orgID, nil, 4)
+	if res == nil {
+		t.Fatal("a call within the budget was refused")
+	}
+	if got := s.GetBudget(b.ID).SpentUSD; got != 4 {
+		t.Errorf("spent while reserved = %v, want the estimate of 4 held", got)
+	}
+	if ok, _ := fits(s, orgID, nil, 7); ok {
+		t.Error("a call was admitted past the cap while another call's estimate was held")
+	}
+
+	s.Settle(res, 2.5)
+	if got := s.GetBudget(b.ID).SpentUSD; got != 2.5 {
+		t.Errorf("spent after settling = %v, want the actual cost of 2.5", got)
+	}
+
+	res, _ = s.Reserve(orgID, nil, 5)
+	s.Settle(res, 0)
+	if got := s.GetBudget(b.ID).SpentUSD; got != 2.5 {
+		t.Errorf("spent after releasing a reservation = %v, want 2.5", got)
+	}
+}
+
+func TestConcurrentReservationsDoNotOvershoot(t *testing.T) {
+	s, _, _ := newTestService(t)
+	orgID := uuid.New()
+	b := s.CreateBudget(domain.BudgetInput{Name: "org", LimitUSD: 10, Enabled: true}, orgID)
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		admitted int
+	)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, _ := s.Reserve(orgID, nil, 1)
+			if res == nil {
+				return
+			}
+			mu.Lock()
+			admitted++
+			mu.Unlock()
+			s.Settle(res, 1)
+		}()
+	}
+	wg.Wait()
+
+	if admitted != 10 {
+		t.Errorf("admitted %d calls of $1 against a $10 budget, want 10", admitted)
+	}
+	if got := s.GetBudget(b.ID).SpentUSD; got != 10 {
+		t.Errorf("spent = %v, want 10", got)
+	}
+}
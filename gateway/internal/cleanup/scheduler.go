@@ -0,0 +1,126 @@
+// Package cleanup runs periodic housekeeping: expiring approvals and
+// permissions, and deleting expired sessions and SSO login states.
+package cleanup
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Task is a cleanup run every Interval. Run returns how many records it
+// expired or removed.
+type Task struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) (int64, error)
+}
+
+// ticker delivers ticks on C until stopped.
+type ticker struct {
+	C    <-chan time.Time
+	Stop func()
+}
+
+// Scheduler runs each task on its own interval until shut down.
+type Scheduler struct {
+	logger zerolog.Logger
+	tasks  []Task
+
+	// newTicker starts a ticker for an interval; replaceable with a fake
+	// clock.
+	newTicker func(time.Duration) ticker
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewScheduler creates a scheduler for tasks. Tasks without a positive
+// interval are never run.
+func NewScheduler(logger zerolog.Logger, tasks ...Task) *Scheduler {
+	return &Scheduler{
+		logger:    logger,
+		tasks:     tasks,
+		newTicker: realTicker,
+		stop:      make(chan struct{}),
+	}
+}
+
+// realTicker is a ticker backed by time.Ticker.
+func realTicker(interval time.Duration) ticker {
+	t := time.NewTicker(interval)
+	return ticker{C: t.C, Stop: t.Stop}
+}
+
+// Start runs the tasks in the background. A task first runs one interval
+// after Start.
+func (s *Scheduler) Start() {
+	for _, task := range s.tasks {
+		if task.Interval <= 0 {
+			continue
+		}
+		s.wg.Add(1)
+		go s.loop(task)
+	}
+	s.logger.Info().Int("tasks", len(s.tasks)).Msg("Cleanup scheduler started")
+}
+
+// loop runs task on every tick until the scheduler is shut down.
+func (s *Scheduler) loop(task Task) {
+	defer s.wg.Done()
+
+	tick := s.newTicker(task.Interval)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-tick.C:
+			s.run(task)
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// run runs task once, bounded by its interval so a slow run cannot overlap
+// the next.
+func (s *Scheduler) run(task Task) {
+	ctx, cancel := context.WithTimeout(context.Background(), task.Interval)
+	defer cancel()
+
+	start := time.Now()
+	count, err := task.Run(ctx)
+	if err != nil {
+		s.logger.Error().Err(err).Str("task", task.Name).Msg("Cleanup failed")
+		return
+	}
+	event := s.logger.Debug()
+	if count > 0 {
+		event = s.logger.Info()
+	}
+	event.
+		Str("task", task.Name).
+		Int64("count", count).
+		Dur("duration", time.Since(start)).
+		Msg("Cleanup completed")
+}
+
+// Shutdown stops the scheduler and waits for running tasks to finish.
+func (s *Scheduler) Shutdown(ctx context.Context) error {
+	s.stopOnce.Do(func() { close(s.stop) })
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
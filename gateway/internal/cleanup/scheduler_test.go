@@ -0,0 +1,144 @@
+package cleanup
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// fakeTickers replaces a scheduler's tickers with channels the test ticks by
+// hand, one per interval.
+type fakeTickers struct {
+	mu      sync.Mutex
+	ticks   map[time.Duration]chan time.Time
+	stopped map[time.Duration]bool
+}
+
+func useFakeTickers(s *Scheduler) *fakeTickers {
+	f := &fakeTickers{ticks: make(map[time.Duration]chan time.Time), stopped: make(map[time.Duration]bool)}
+	s.newTicker = func(interval time.Duration) ticker {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		c := make(chan time.Time)
+		f.ticks[interval] = c
+		return ticker{C: c, Stop: func() {
+			f.mu.Lock()
+			f.stopped[interval] = true
+			f.mu.Unlock()
+		}}
+	}
+	return f
+}
+
+// tick delivers one tick to the task running every interval.
+func (f *fakeTickers) tick(t *testing.T, interval time.Duration) {
+	t.Helper()
+	deadline := time.After(time.Second)
+	for {
+		f.mu.Lock()
+		c, ok := f.ticks[interval]
+		f.mu.Unlock()
+		if ok {
+			select {
+			case c <- time.Now():
+				return
+			case <-deadline:
+				t.Fatalf("no task took the %s tick", interval)
+			}
+		}
+		select {
+		case <-deadline:
+			t.Fatalf("no ticker was started for %s", interval)
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
+func TestSchedulerRunsEachTaskOnItsTicks(t *testing.T) {
+	runs := make(chan string, 10)
+	deadlines := make(chan time.Duration, 10)
+	task := func(name string) func(ctx context.Context) (int64, error) {
+		return func(ctx context.Context) (int64, error) {
+			if deadline, ok := ctx.Deadline(); ok {
+				deadlines <- time.Until(deadline)
+			}
+			runs <- name
+			if name == "failing" {
+				return 0, errors.New("database unavailable")
+			}
+			return 1, nil
+		}
+	}
+
+	s := NewScheduler(zerolog.Nop(),
+		Task{Name: "approvals", Interval: time.Minute, Run: task("approvals")},
+		Task{Name: "failing", Interval: time.Hour, Run: task("failing")},
+		Task{Name: "disabled", Interval: 0, Run: task("disabled")},
+	)
+	tickers := useFakeTickers(s)
+	s.Start()
+
+	tickers.tick(t, time.Minute)
+	tickers.tick(t, time.Hour)
+	tickers.tick(t, time.Minute) // A failed run does not stop the other tasks
+	tickers.tick(t, time.Hour)   // nor later runs of the failing task
+
+	counts := make(map[string]int)
+	for i := 0; i < 4; i++ {
+		select {
+		case name := <-runs:
+			counts[name]++
+		case <-time.After(time.Second):
+			t.Fatalf("only %v ran", counts)
+		}
+	}
+	if counts["approvals"] != 2 || counts["failing"] != 2 {
+		t.Errorf("runs = %v, want 2 of each ticked task", counts)
+	}
+	for i := 0; i < 4; i++ {
+		if d := <-deadlines; d <= 0 || d > time.Hour {
+			t.Errorf("run deadline %s away, want within the task's interval", d)
+		}
+	}
+
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	tickers.mu.Lock()
+	defer tickers.mu.Unlock()
+	if len(tickers.ticks) != 2 {
+		t.Errorf("%d tickers started, want none for the task without an interval", len(tickers.ticks))
+	}
+	if !tickers.stopped[time.Minute] || !tickers.stopped[time.Hour] {
+		t.Errorf("stopped tickers = %v, want both stopped at shutdown", tickers.stopped)
+	}
+}
+
+func TestSchedulerShutdownWaitsForRunningTask(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	s := NewScheduler(zerolog.Nop(), Task{Name: "slow", Interval: time.Minute, Run: func(ctx context.Context) (int64, error) {
+		close(started)
+		<-release
+		return 0, nil
+	}})
+	tickers := useFakeTickers(s)
+	s.Start()
+	tickers.tick(t, time.Minute)
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := s.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown during a run: err = %v, want DeadlineExceeded", err)
+	}
+
+	close(release)
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown after the run finished: %v", err)
+	}
+}
@@ -0,0 +1,216 @@
+package compliance
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/google/uuid"
+)
+
+// ContentTypes are the content types of each report format.
+var ContentTypes = map[domain.ComplianceReportFormat]string{
+	domain.ComplianceReportJSON: "application/json",
+	domain.ComplianceReportCSV:  "text/csv",
+	domain.ComplianceReportHTML: "text/html; charset=utf-8",
+}
+
+// Render encodes a report in format.
+func Render(report *domain.ComplianceReport, format domain.ComplianceReportFormat) ([]byte, error) {
+	switch format {
+	case domain.ComplianceReportCSV:
+		return renderCSV(report)
+	case domain.ComplianceReportHTML:
+		return renderHTML(report)
+	default:
+		return json.MarshalIndent(report, "", "  ")
+	}
+}
+
+// Digest returns the SHA-256 of a rendered report, as sha256=<hex>.
+func Digest(body []byte) string {
+	sum := sha256.Sum256(body)
+	return "sha256=" + hex.EncodeToString(sum[:])
+}
+
+// Sign returns the HMAC-SHA256 of a rendered report under key, as
+// sha256=<hex>. Anyone holding the key can check that the report is
+// unaltered by computing the same over the downloaded file.
+func Sign(key, body []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// section is one table of a report, as rendered to CSV and HTML.
+type section struct {
+	Name   string
+	Header []string
+	Rows   [][]string
+}
+
+// sections flattens a report into tables, starting with its summary.
+func sections(r *domain.ComplianceReport) []section {
+	summary := section{Name: "summary", Header: []string{"field", "value"}}
+	addSummary := func(field, value string) { summary.Rows = append(summary.Rows, []string{field, value}) }
+	addSummary("org_id", r.OrgID.String())
+	addSummary("start_date", formatTime(r.StartDate))
+	addSummary("end_date", formatTime(r.EndDate))
+	addSummary("generated_at", formatTime(r.GeneratedAt))
+	addSummary("dangerous_tools", strconv.Itoa(r.Summary.DangerousTools))
+	addSummary("active_permissions", strconv.Itoa(r.Summary.ActivePermissions))
+	addSummary("role_assignments", strconv.Itoa(r.Summary.RoleAssignments))
+	for _, status := range []domain.ApprovalStatus{domain.ApprovalStatusPending, domain.ApprovalStatusApproved, domain.ApprovalStatusDenied, domain.ApprovalStatusExpired} {
+		addSummary("approvals_"+string(status), strconv.Itoa(r.Summary.ApprovalsByStatus[status]))
+	}
+	for _, severity := range []domain.DetectionSeverity{domain.DetectionSeverityLow, domain.DetectionSeverityMedium, domain.DetectionSeverityHigh, domain.DetectionSeverityCritical} {
+		addSummary("detections_"+string(severity), strconv.Itoa(r.Summary.DetectionsBySeverity[severity]))
+	}
+
+	users := section{Name: "users", Header: []string{"id", "email", "name"}}
+	for _, u := range r.Users {
+		users.Rows = append(users.Rows, []string{u.ID.String(), u.Email, u.Name})
+	}
+
+	classifications := section{Name: "tool_classifications", Header: []string{"mcp_server", "tool_name", "classification", "requires_approval", "updated_at", "created_by"}}
+	for _, c := range r.ToolClassifications {
+		classifications.Rows = append(classifications.Rows, []string{
+			c.MCPServer, c.ToolName, string(c.Classification), strconv.FormatBool(c.RequiresApproval), formatTime(c.UpdatedAt), c.CreatedBy.String(),
+		})
+	}
+
+	permissions := section{Name: "tool_permissions", Header: []string{"id", "mcp_server", "tool_name", "user_id", "team_id", "granted_by", "granted_at", "expires_at"}}
+	for _, p := range r.ToolPermissions {
+		permissions.Rows = append(permissions.Rows, []string{
+			p.ID.String(), p.MCPServer, p.ToolName, optionalID(p.UserID), optionalID(p.TeamID), p.GrantedBy.String(), formatTime(p.GrantedAt), optionalTime(p.ExpiresAt),
+		})
+	}
+
+	approvals := section{Name: "approval_decisions", Header: []string{"id", "mcp_server", "tool_name", "requested_by", "requested_at", "reason", "status", "reviewed_by", "reviewed_at", "review_note", "expires_at"}}
+	for _, a := range r.ApprovalDecisions {
+		approvals.Rows = append(approvals.Rows, []string{
+			a.ID.String(), a.MCPServer, a.ToolName, a.RequestedBy.String(), formatTime(a.RequestedAt), a.Reason,
+			string(a.Status), optionalID(a.ReviewedBy), optionalTime(a.ReviewedAt), a.ReviewNote, optionalTime(a.ExpiresAt),
+		})
+	}
+
+	roles := section{Name: "role_assignments", Header: []string{"id", "user_id", "role_name", "permissions", "scope_type", "scope_id", "created_by", "created_at"}}
+	for _, a := range r.RoleAssignments {
+		perms := make([]string, len(a.Permissions))
+		for i, p := range a.Permissions {
+			perms[i] = string(p)
+		}
+		scope := string(a.ScopeType)
+		if scope == "" {
+			scope = "org"
+		}
+		roles.Rows = append(roles.Rows, []string{
+			a.ID.String(), a.UserID.String(), a.RoleName, strings.Join(perms, " "), scope, optionalID(a.ScopeID), a.CreatedBy.String(), formatTime(a.CreatedAt),
+		})
+	}
+
+	detections := section{Name: "safety_detections", Header: []string{"id", "created_at", "type", "severity", "action_taken", "pattern_matched", "mcp_server", "tool_name", "api_key_id", "ip_address", "trace_id"}}
+	for _, d := range r.SafetyDetections {
+		detections.Rows = append(detections.Rows, []string{
+			d.ID.String(), formatTime(d.CreatedAt), string(d.Type), string(d.Severity), string(d.ActionTaken), d.PatternMatched,
+			d.MCPServer, d.ToolName, optionalID(d.APIKeyID), d.IPAddress, d.TraceID,
+		})
+	}
+
+	return []section{summary, users, classifications, permissions, approvals, roles, detections}
+}
+
+// renderCSV writes each section as a line naming it, a header row and its
+// rows, with a blank line between sections.
+func renderCSV(report *domain.ComplianceReport) ([]byte, error) {
+	var buf bytes.Buffer
+	cw := csv.NewWriter(&buf)
+	for i, s := range sections(report) {
+		if i > 0 {
+			cw.Flush()
+			buf.WriteString("\n")
+		}
+		cw.Write([]string{"# " + s.Name})
+		cw.Write(s.Header)
+		cw.WriteAll(s.Rows)
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return nil, fmt.Errorf("write compliance report CSV: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"title": func(name string) string { return strings.ReplaceAll(name, "_", " ") },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Compliance report {{.Report.OrgID}}</title>
+<style>
+body { font-family: sans-serif; font-size: 11px; margin: 2em; }
+h1 { font-size: 18px; }
+h2 { font-size: 14px; text-transform: capitalize; margin-top: 2em; page-break-after: avoid; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border: 1px solid #999; padding: 3px 5px; text-align: left; vertical-align: top; word-break: break-word; }
+th { background: #eee; }
+tr { page-break-inside: avoid; }
+</style>
+</head>
+<body>
+<h1>Compliance report</h1>
+<p>Org {{.Report.OrgID}}, {{.Start}} to {{.End}}. Generated {{.Generated}}.</p>
+{{range .Sections}}
+<h2>{{title .Name}}</h2>
+{{if .Rows}}<table>
+<tr>{{range .Header}}<th>{{.}}</th>{{end}}</tr>
+{{range .Rows}}<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</table>{{else}}<p>None.</p>{{end}}
+{{end}}
+</body>
+</html>
+`))
+
+// renderHTML writes a printable document, for saving as PDF from a browser.
+func renderHTML(report *domain.ComplianceReport) ([]byte, error) {
+	var buf bytes.Buffer
+	err := reportTemplate.Execute(&buf, map[string]interface{}{
+		"Report":    report,
+		"Start":     formatTime(report.StartDate),
+		"End":       formatTime(report.EndDate),
+		"Generated": formatTime(report.GeneratedAt),
+		"Sections":  sections(report),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("render compliance report: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func optionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return formatTime(*t)
+}
+
+func optionalID(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
@@ -0,0 +1,236 @@
+// Package compliance compiles the access-control evidence auditors ask for:
+// which tools are dangerous, who may use them, who approved their use, who
+// holds which roles, and what the safety checks caught.
+package compliance
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/approval"
+	"github.com/akz4ol/gatewayops/gateway/internal/clock"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/rbac"
+	"github.com/akz4ol/gatewayops/gateway/internal/repository"
+	"github.com/akz4ol/gatewayops/gateway/internal/safety"
+	"github.com/google/uuid"
+)
+
+// pageSize is how many approvals, detections or users are read at a time.
+const pageSize = 500
+
+// Generator builds compliance reports from the approval, RBAC and safety
+// services. Any of them may be nil, leaving its sections empty.
+type Generator struct {
+	approvals *approval.Service
+	rbac      *rbac.Service
+	detector  *safety.Detector
+	users     repository.UserStore
+	clock     clock.Clock
+}
+
+// NewGenerator creates a report generator.
+func NewGenerator(approvals *approval.Service, rbacService *rbac.Service, detector *safety.Detector, users repository.UserStore, clk clock.Clock) *Generator {
+	return &Generator{
+		approvals: approvals,
+		rbac:      rbacService,
+		detector:  detector,
+		users:     users,
+		clock:     clk,
+	}
+}
+
+// Generate builds an org's report for the period from start to end.
+func (g *Generator) Generate(ctx context.Context, orgID uuid.UUID, start, end time.Time) (*domain.ComplianceReport, error) {
+	now := g.clock.Now().UTC()
+	report := &domain.ComplianceReport{
+		OrgID:               orgID,
+		StartDate:           start,
+		EndDate:             end,
+		GeneratedAt:         now,
+		Users:               []domain.ComplianceUser{},
+		ToolClassifications: []domain.ToolClassification{},
+		ToolPermissions:     []domain.ToolPermission{},
+		ApprovalDecisions:   []domain.ToolApproval{},
+		RoleAssignments:     []domain.ComplianceRoleAssignment{},
+		SafetyDetections:    []domain.InjectionDetection{},
+	}
+
+	if g.approvals != nil {
+		report.ToolClassifications = g.approvals.ListClassifications(orgID, "")
+		sort.Slice(report.ToolClassifications, func(i, j int) bool {
+			a, b := report.ToolClassifications[i], report.ToolClassifications[j]
+			if a.MCPServer != b.MCPServer {
+				return a.MCPServer < b.MCPServer
+			}
+			return a.ToolName < b.ToolName
+		})
+
+		for _, permission := range g.approvals.ListPermissions(orgID, "") {
+			if permission.ExpiresAt == nil || permission.ExpiresAt.After(now) {
+				report.ToolPermissions = append(report.ToolPermissions, permission)
+			}
+		}
+		sort.Slice(report.ToolPermissions, func(i, j int) bool {
+			return report.ToolPermissions[i].GrantedAt.Before(report.ToolPermissions[j].GrantedAt)
+		})
+
+		report.ApprovalDecisions = g.approvalsIn(orgID, start, end)
+	}
+
+	if g.detector != nil {
+		report.SafetyDetections = g.detectionsIn(orgID, start, end)
+	}
+
+	users, err := g.userDirectory(ctx, orgID, report)
+	if err != nil {
+		return nil, err
+	}
+	report.Users = users
+
+	if g.rbac != nil {
+		for _, user := range users {
+			for _, assignment := range g.rbac.GetUserRoles(user.ID) {
+				role := g.rbac.GetRole(orgID, assignment.RoleID)
+				if role == nil {
+					continue
+				}
+				report.RoleAssignments = append(report.RoleAssignments, domain.ComplianceRoleAssignment{
+					RoleAssignment: assignment,
+					RoleName:       role.Name,
+					Permissions:    role.Permissions,
+				})
+			}
+		}
+	}
+
+	report.Summary = summarize(report)
+	return report, nil
+}
+
+// approvalsIn returns the approvals requested or reviewed in the period,
+// oldest first.
+func (g *Generator) approvalsIn(orgID uuid.UUID, start, end time.Time) []domain.ToolApproval {
+	result := []domain.ToolApproval{}
+	filter := domain.ToolApprovalFilter{OrgID: orgID, Limit: pageSize}
+	for {
+		page := g.approvals.ListApprovals(filter)
+		for _, approval := range page.Approvals {
+			if within(approval.RequestedAt, start, end) || (approval.ReviewedAt != nil && within(*approval.ReviewedAt, start, end)) {
+				result = append(result, approval)
+			}
+		}
+		if !page.HasMore || len(page.Approvals) == 0 {
+			break
+		}
+		last := page.Approvals[len(page.Approvals)-1]
+		filter.Cursor = domain.NewCursor(last.RequestedAt, last.ID)
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].RequestedAt.Before(result[j].RequestedAt) })
+	return result
+}
+
+// detectionsIn returns the detections recorded in the period, oldest first.
+func (g *Generator) detectionsIn(orgID uuid.UUID, start, end time.Time) []domain.InjectionDetection {
+	result := []domain.InjectionDetection{}
+	filter := domain.DetectionFilter{OrgID: orgID, StartTime: &start, EndTime: &end, Limit: pageSize}
+	for {
+		page := g.detector.GetDetections(filter)
+		result = append(result, page.Detections...)
+		if !page.HasMore || len(page.Detections) == 0 {
+			break
+		}
+		last := page.Detections[len(page.Detections)-1]
+		filter.Cursor = domain.NewCursor(last.CreatedAt, last.ID)
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].CreatedAt.Before(result[j].CreatedAt) })
+	return result
+}
+
+// userDirectory returns the org's users, and any other user the report
+// refers to, sorted by email. Users not in the user store are listed by ID
+// alone.
+func (g *Generator) userDirectory(ctx context.Context, orgID uuid.UUID, report *domain.ComplianceReport) ([]domain.ComplianceUser, error) {
+	known := make(map[uuid.UUID]domain.ComplianceUser)
+	if g.users != nil {
+		for offset := 0; ; offset += pageSize {
+			users, total, err := g.users.ListUsersByOrg(ctx, orgID, pageSize, offset)
+			if err != nil {
+				return nil, fmt.Errorf("list users: %w", err)
+			}
+			for _, user := range users {
+				known[user.ID] = domain.ComplianceUser{ID: user.ID, Email: user.Email, Name: user.Name}
+			}
+			if len(users) == 0 || int64(offset+len(users)) >= total {
+				break
+			}
+		}
+	}
+
+	refer := func(id *uuid.UUID) {
+		if id == nil || *id == uuid.Nil {
+			return
+		}
+		if _, ok := known[*id]; ok {
+			return
+		}
+		entry := domain.ComplianceUser{ID: *id}
+		if g.users != nil {
+			if user, err := g.users.GetUser(ctx, *id); err == nil && user != nil && user.OrgID == orgID {
+				entry.Email, entry.Name = user.Email, user.Name
+			}
+		}
+		known[*id] = entry
+	}
+	for i := range report.ToolPermissions {
+		refer(report.ToolPermissions[i].UserID)
+		refer(&report.ToolPermissions[i].GrantedBy)
+	}
+	for i := range report.ApprovalDecisions {
+		refer(&report.ApprovalDecisions[i].RequestedBy)
+		refer(report.ApprovalDecisions[i].ReviewedBy)
+	}
+	for i := range report.ToolClassifications {
+		refer(&report.ToolClassifications[i].CreatedBy)
+	}
+
+	users := make([]domain.ComplianceUser, 0, len(known))
+	for _, user := range known {
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool {
+		if users[i].Email != users[j].Email {
+			return users[i].Email < users[j].Email
+		}
+		return users[i].ID.String() < users[j].ID.String()
+	})
+	return users, nil
+}
+
+// summarize counts the contents of a report.
+func summarize(report *domain.ComplianceReport) domain.ComplianceSummary {
+	summary := domain.ComplianceSummary{
+		ActivePermissions:    len(report.ToolPermissions),
+		ApprovalsByStatus:    make(map[domain.ApprovalStatus]int),
+		RoleAssignments:      len(report.RoleAssignments),
+		DetectionsBySeverity: make(map[domain.DetectionSeverity]int),
+	}
+	for _, classification := range report.ToolClassifications {
+		if classification.Classification == domain.ToolRiskDangerous {
+			summary.DangerousTools++
+		}
+	}
+	for _, approval := range report.ApprovalDecisions {
+		summary.ApprovalsByStatus[approval.Status]++
+	}
+	for _, detection := range report.SafetyDetections {
+		summary.DetectionsBySeverity[detection.Severity]++
+	}
+	return summary
+}
+
+func within(t, start, end time.Time) bool {
+	return !t.Before(start) && !t.After(end)
+}
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/rs/zerolog"
+)
+
+// ReloadResult describes the outcome of a configuration reload.
+type ReloadResult struct {
+	Applied  []string `json:"applied"`
+	Rejected []string `json:"rejected"`
+}
+
+// Reloader re-reads configuration and applies the settings that are safe to
+// change while the gateway is running: log level, MCP servers and rate limits.
+// Changes to other settings are rejected with a warning and need a restart.
+type Reloader struct {
+	config *Config
+	load   func() (*Config, error)
+	logger zerolog.Logger
+	hooks  []func(*Config)
+	mu     sync.Mutex
+}
+
+// NewReloader creates a reloader for the running config using the given loader.
+func NewReloader(cfg *Config, load func() (*Config, error), logger zerolog.Logger) *Reloader {
+	return &Reloader{
+		config: cfg,
+		load:   load,
+		logger: logger,
+	}
+}
+
+// OnReload registers a function that runs after changes have been applied.
+func (r *Reloader) OnReload(fn func(*Config)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hooks = append(r.hooks, fn)
+}
+
+// Reload loads and validates the configuration and applies safe changes. If
+// the new configuration fails to load or validate, the running configuration
+// is kept and the error is returned.
+func (r *Reloader) Reload() (*ReloadResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next, err := r.load()
+	if err != nil {
+		r.logger.Error().Err(err).Msg("Config reload failed, keeping current config")
+		return nil, err
+	}
+	if err := next.Validate(); err != nil {
+		r.logger.Error().Err(err).Msg("Config reload failed validation, keeping current config")
+		return nil, err
+	}
+
+	result := r.config.apply(next)
+	for _, msg := range result.Rejected {
+		r.logger.Warn().Str("change", msg).Msg("Config change requires restart, ignoring")
+	}
+	for _, msg := range result.Applied {
+		r.logger.Info().Str("change", msg).Msg("Config change applied")
+	}
+
+	if len(result.Applied) > 0 {
+		for _, hook := range r.hooks {
+			hook(r.config)
+		}
+	}
+
+	return result, nil
+}
+
+// apply copies the reloadable settings from next into c and reports which
+// changes were applied and which were rejected.
+func (c *Config) apply(next *Config) *ReloadResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	result := &ReloadResult{Applied: []string{}, Rejected: []string{}}
+
+	// Settings that need a restart
+	restart := []struct {
+		name        string
+		old, update interface{}
+	}{
+		{"server", c.Server, next.Server},
+		{"database", c.Database, next.Database},
+		{"redis", c.Redis, next.Redis},
+		{"clickhouse", c.ClickHouse, next.ClickHouse},
+		{"auth", c.Auth, next.Auth},
+		{"log format", c.Logging.Format, next.Logging.Format},
+		{"rate limit allowlist", c.RateLimit.Allowlist, next.RateLimit.Allowlist},
+		{"mcp egress", c.Egress, next.Egress},
+		{"compression", c.Compression, next.Compression},
+	}
+	for _, s := range restart {
+		if !reflect.DeepEqual(s.old, s.update) {
+			result.Rejected = append(result.Rejected, fmt.Sprintf("%s settings changed", s.name))
+		}
+	}
+
+	// Log level
+	if c.Logging.Level != next.Logging.Level {
+		result.Applied = append(result.Applied, fmt.Sprintf("log level: %s -> %s", c.Logging.Level, next.Logging.Level))
+		c.Logging.Level = next.Logging.Level
+	}
+
+	// Rate limits
+	if c.RateLimit.DefaultRPM != next.RateLimit.DefaultRPM || c.RateLimit.Burst != next.RateLimit.Burst {
+		result.Applied = append(result.Applied, fmt.Sprintf("rate limit: %d rpm (burst %d) -> %d rpm (burst %d)",
+			c.RateLimit.DefaultRPM, c.RateLimit.Burst, next.RateLimit.DefaultRPM, next.RateLimit.Burst))
+		c.RateLimit.DefaultRPM = next.RateLimit.DefaultRPM
+		c.RateLimit.Burst = next.RateLimit.Burst
+	}
+
+	// MCP servers
+	var changes []string
+	for name, server := range next.MCPServers {
+		old, ok := c.MCPServers[name]
+		switch {
+		case !ok:
+			changes = append(changes, "mcp server added: "+name)
+		case !reflect.DeepEqual(old, server):
+			changes = append(changes, "mcp server updated: "+name)
+		}
+	}
+	for name := range c.MCPServers {
+		if _, ok := next.MCPServers[name]; !ok {
+			changes = append(changes, "mcp server removed: "+name)
+		}
+	}
+	if len(changes) > 0 {
+		sort.Strings(changes)
+		result.Applied = append(result.Applied, changes...)
+		c.MCPServers = next.MCPServers
+	}
+
+	return result
+}
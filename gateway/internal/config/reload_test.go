@@ -0,0 +1,151 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+// testReloader returns a reloader for cfg whose loads read *vars.
+func testReloader(t *testing.T, cfg *Config, vars *map[string]string) *Reloader {
+	t.Helper()
+	return NewReloader(cfg, func() (*Config, error) { return LoadFrom(env(*vars)) }, zerolog.Nop())
+}
+
+func TestReloadAppliesSafeChanges(t *testing.T) {
+	vars := map[string]string{
+		"LOG_LEVEL":            "info",
+		"MCP_SERVERS":          "files,git",
+		"MCP_SERVER_FILES_URL": "http://files:3000",
+		"MCP_SERVER_GIT_URL":   "http://git:3000",
+	}
+	cfg, err := LoadFrom(env(vars))
+	if err != nil {
+		t.Fatalf("LoadFrom: %v", err)
+	}
+	r := testReloader(t, cfg, &vars)
+	hooks := 0
+	r.OnReload(func(*Config) { hooks++ })
+
+	vars = map[string]string{
+		"LOG_LEVEL":              "debug",
+		"RATE_LIMIT_DEFAULT_RPM": "120",
+		"MCP_SERVERS":            "files,search",
+		"MCP_SERVER_FILES_URL":   "http://files:4000",
+		"MCP_SERVER_SEARCH_URL":  "http://search:3000",
+		"PORT":                   "9090",
+	}
+	result, err := r.Reload()
+	if err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+
+	applied := strings.Join(result.Applied, "\n")
+	for _, want := range []string{"log level: info -> debug", "rate limit:", "mcp server added: search", "mcp server removed: git", "mcp server updated: files"} {
+		if !strings.Contains(applied, want) {
+			t.Errorf("applied changes lack %q:\n%s", want, applied)
+		}
+	}
+	if len(result.Rejected) != 1 || result.Rejected[0] != "server settings changed" {
+		t.Errorf("rejected = %q, want the port change", result.Rejected)
+	}
+	if hooks != 1 {
+		t.Errorf("reload hooks ran %d times, want once", hooks)
+	}
+
+	if cfg.LogLevel() != "debug" || cfg.DefaultRateLimit() != 120 {
+		t.Errorf("log level %s, rate limit %d after reload", cfg.LogLevel(), cfg.DefaultRateLimit())
+	}
+	if _, ok := cfg.MCPServer("search"); !ok {
+		t.Error("added server is not configured")
+	}
+	if _, ok := cfg.MCPServer("git"); ok {
+		t.Error("removed server is still configured")
+	}
+	if cfg.Server.Port != "8080" {
+		t.Errorf("port changed to %s without a restart", cfg.Server.Port)
+	}
+
+	// Nothing to apply: hooks do not run again
+	if _, err := r.Reload(); err != nil {
+		t.Fatalf("second Reload: %v", err)
+	}
+	if hooks != 1 {
+		t.Errorf("reload hooks ran %d times after a reload without changes", hooks)
+	}
+}
+
+func TestReloadKeepsConfigOnInvalidChanges(t *testing.T) {
+	vars := map[string]string{"LOG_LEVEL": "info"}
+	cfg, err := LoadFrom(env(vars))
+	if err != nil {
+		t.Fatalf("LoadFrom: %v", err)
+	}
+	r := testReloader(t, cfg, &vars)
+
+	vars = map[string]string{"LOG_LEVEL": "debug", "RATE_LIMIT_DEFAULT_RPM": "-1"}
+	var verr *ValidationError
+	if _, err := r.Reload(); !errors.As(err, &verr) {
+		t.Fatalf("Reload of an invalid config: err = %v, want a *ValidationError", err)
+	}
+	if cfg.LogLevel() != "info" {
+		t.Errorf("log level changed to %s by an invalid config", cfg.LogLevel())
+	}
+
+	failing := NewReloader(cfg, func() (*Config, error) { return nil, errors.New("unreadable") }, zerolog.Nop())
+	if _, err := failing.Reload(); err == nil {
+		t.Error("Reload ignored a load failure")
+	}
+}
+
+func TestReadEnvFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gateway.env")
+	content := strings.Join([]string{
+		"# gateway settings",
+		"",
+		"PORT=9090",
+		"export LOG_LEVEL=debug",
+		`DATABASE_URL="postgres://localhost/gatewayops?sslmode=disable"`,
+		"CORS_ALLOWED_ORIGINS='https://a.example.com,https://b.example.com'",
+		`QUOTED="unterminated`,
+		"EMPTY=",
+	}, "\n")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	vars, err := ReadEnvFile(path)
+	if err != nil {
+		t.Fatalf("ReadEnvFile: %v", err)
+	}
+	want := map[string]string{
+		"PORT":                 "9090",
+		"LOG_LEVEL":            "debug",
+		"DATABASE_URL":         "postgres://localhost/gatewayops?sslmode=disable",
+		"CORS_ALLOWED_ORIGINS": "https://a.example.com,https://b.example.com",
+		"QUOTED":               `"unterminated`,
+		"EMPTY":                "",
+	}
+	if len(vars) != len(want) {
+		t.Errorf("vars = %q, want %q", vars, want)
+	}
+	for key, value := range want {
+		if vars[key] != value {
+			t.Errorf("%s = %q, want %q", key, vars[key], value)
+		}
+	}
+
+	if err := os.WriteFile(path, []byte("PORT=9090\nnot a setting\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadEnvFile(path); err == nil || !strings.Contains(err.Error(), ":2:") {
+		t.Errorf("malformed line: err = %v, want its line number", err)
+	}
+	if _, err := ReadEnvFile(filepath.Join(t.TempDir(), "missing.env")); err == nil {
+		t.Error("missing file: no error")
+	}
+}
@@ -0,0 +1,542 @@
+package config
+
+import (
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"net"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/egress"
+	"github.com/akz4ol/gatewayops/gateway/internal/transform"
+	"github.com/google/uuid"
+)
+
+// ValidationError aggregates every problem found in a configuration.
+type ValidationError struct {
+	Problems []string
+}
+
+// Error implements the error interface.
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid configuration (%d problems):\n  - %s", len(e.Problems), strings.Join(e.Problems, "\n  - "))
+}
+
+// Validate checks the configuration for semantically invalid values. It
+// returns a *ValidationError listing all problems, or nil if the
+// configuration is valid.
+func (c *Config) Validate() error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	v := &validator{problems: append([]string(nil), c.loadProblems...)}
+
+	// Server
+	if port, err := strconv.Atoi(c.Server.Port); err != nil || port < 1 || port > 65535 {
+		v.add("PORT: %q is not a valid port (1-65535)", c.Server.Port)
+	}
+	switch c.Server.Env {
+	case "development", "staging", "production", "test":
+	default:
+		v.add("ENV: %q must be one of development, staging, production, test", c.Server.Env)
+	}
+	v.positive("SERVER_READ_TIMEOUT", c.Server.ReadTimeout.Seconds())
+	v.positive("SERVER_WRITE_TIMEOUT", c.Server.WriteTimeout.Seconds())
+	v.positive("SERVER_IDLE_TIMEOUT", c.Server.IdleTimeout.Seconds())
+	v.positive("SERVER_SHUTDOWN_TIMEOUT", c.Server.ShutdownTimeout.Seconds())
+	v.positive("SERVER_SHUTDOWN_HOOK_TIMEOUT", c.Server.HookTimeout.Seconds())
+	v.cidrs("TRUSTED_PROXIES", c.Server.TrustedProxies)
+	v.positive("IDEMPOTENCY_KEY_TTL", c.Server.IdempotencyTTL.Seconds())
+	v.positive("SERVER_MAX_REQUEST_BODY", float64(c.Server.MaxRequestBody))
+	if c.Server.MaxConcurrency < 0 {
+		v.add("SERVER_MAX_CONCURRENCY: must not be negative")
+	}
+	for prefix, limit := range c.Server.RouteConcurrency {
+		if !strings.HasPrefix(prefix, "/") {
+			v.add("SERVER_ROUTE_CONCURRENCY: %q is not a path prefix such as /v1/mcp", prefix)
+		}
+		if limit <= 0 {
+			v.add("SERVER_ROUTE_CONCURRENCY: limit for %q must be greater than zero", prefix)
+		}
+	}
+	v.positive("SERVER_SHED_RETRY_AFTER", c.Server.ShedRetryAfter.Seconds())
+
+	// CORS
+	for _, origin := range c.CORS.AllowedOrigins {
+		if origin == "*" {
+			if c.CORS.AllowCredentials {
+				v.add("CORS_ALLOWED_ORIGINS: * cannot be used with CORS_ALLOW_CREDENTIALS; list the allowed origins")
+			}
+			continue
+		}
+		if strings.Count(origin, "*") > 1 {
+			v.add("CORS_ALLOWED_ORIGINS: %q has more than one * wildcard", origin)
+			continue
+		}
+		u, err := url.Parse(strings.Replace(origin, "*", "wildcard", 1))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			v.add("CORS_ALLOWED_ORIGINS: %q is not an origin such as https://dashboard.example.com", origin)
+		}
+	}
+	if c.CORS.MaxAge < 0 {
+		v.add("CORS_MAX_AGE: must not be negative")
+	}
+
+	// Compression
+	if c.Compression.Level < 1 || c.Compression.Level > 9 {
+		v.add("COMPRESSION_LEVEL: must be between 1 and 9")
+	}
+	if c.Compression.MinSize < 0 {
+		v.add("COMPRESSION_MIN_SIZE: must not be negative")
+	}
+	for _, contentType := range c.Compression.ContentTypes {
+		if mediaType, _, err := mime.ParseMediaType(contentType); err != nil || mediaType != strings.ToLower(strings.TrimSpace(contentType)) {
+			v.add("COMPRESSION_CONTENT_TYPES: %q is not a media type such as application/json", contentType)
+		} else if mediaType == "text/event-stream" {
+			v.add("COMPRESSION_CONTENT_TYPES: event streams cannot be compressed")
+		}
+	}
+
+	// Storage
+	if c.Server.Storage != StoragePostgres && c.Server.Storage != StorageMemory {
+		v.add("STORAGE: %q must be one of postgres, memory", c.Server.Storage)
+	}
+
+	// Database
+	v.url("DATABASE_URL", c.Database.URL, "postgres", "postgresql")
+	if c.Database.MaxOpenConns <= 0 {
+		v.add("DATABASE_MAX_OPEN_CONNS: must be greater than zero")
+	}
+	if c.Database.MaxIdleConns < 0 || c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		v.add("DATABASE_MAX_IDLE_CONNS: must be between 0 and DATABASE_MAX_OPEN_CONNS")
+	}
+	v.positive("DATABASE_CONN_MAX_LIFETIME", c.Database.ConnMaxLifetime.Seconds())
+	if c.Database.ConnMaxIdleTime < 0 {
+		v.add("DATABASE_CONN_MAX_IDLE_TIME: must not be negative")
+	}
+
+	// Redis
+	v.url("REDIS_URL", c.Redis.URL, "redis", "rediss")
+	if c.Redis.MaxRetries < 0 {
+		v.add("REDIS_MAX_RETRIES: must not be negative")
+	}
+	if c.Redis.PoolSize <= 0 {
+		v.add("REDIS_POOL_SIZE: must be greater than zero")
+	}
+	if c.Redis.MinIdleConns < 0 || c.Redis.MinIdleConns > c.Redis.PoolSize {
+		v.add("REDIS_MIN_IDLE_CONNS: must be between 0 and REDIS_POOL_SIZE")
+	}
+
+	// Auth
+	if c.Auth.BcryptCost < 4 || c.Auth.BcryptCost > 31 {
+		v.add("API_KEY_BCRYPT_COST: %d must be between 4 and 31", c.Auth.BcryptCost)
+	}
+	if key := c.Auth.SessionSigningKey; key != "" && len(key) < 32 {
+		v.add("SESSION_SIGNING_KEY: must be at least 32 bytes")
+	}
+	if key := c.Auth.ReportSigningKey; key != "" && len(key) < 32 {
+		v.add("COMPLIANCE_REPORT_SIGNING_KEY: must be at least 32 bytes")
+	}
+	if key := c.Auth.SecretsKey; key != "" {
+		if raw, err := hex.DecodeString(key); err != nil || len(raw) != 32 {
+			v.add("SECRETS_ENCRYPTION_KEY: must be 64 hex characters (a 32-byte AES-256 key)")
+		}
+	}
+	if c.Auth.SecretsKeyVersion < 1 {
+		v.add("SECRETS_ENCRYPTION_KEY_VERSION: must be at least 1")
+	}
+	if len(c.Auth.RetiredSecretsKeys) > 0 && c.Auth.SecretsKey == "" {
+		v.add("SECRETS_RETIRED_KEYS: requires SECRETS_ENCRYPTION_KEY")
+	}
+	retired := make([]string, 0, len(c.Auth.RetiredSecretsKeys))
+	for version := range c.Auth.RetiredSecretsKeys {
+		retired = append(retired, version)
+	}
+	sort.Strings(retired)
+	for _, version := range retired {
+		if n, err := strconv.Atoi(version); err != nil || n < 1 {
+			v.add("SECRETS_RETIRED_KEYS: version %q must be a positive integer", version)
+		} else if n == c.Auth.SecretsKeyVersion {
+			v.add("SECRETS_RETIRED_KEYS: version %d is the current SECRETS_ENCRYPTION_KEY_VERSION", n)
+		}
+		if raw, err := hex.DecodeString(c.Auth.RetiredSecretsKeys[version]); err != nil || len(raw) != 32 {
+			v.add("SECRETS_RETIRED_KEYS: key %q must be 64 hex characters", version)
+		}
+	}
+	if c.Auth.LoginRateLimit <= 0 {
+		v.add("AUTH_RATE_LIMIT_RPM: must be greater than zero")
+	}
+	if c.Auth.LockoutThreshold <= 0 {
+		v.add("AUTH_LOCKOUT_THRESHOLD: must be greater than zero")
+	}
+	v.positive("AUTH_LOCKOUT_WINDOW", c.Auth.LockoutWindow.Seconds())
+	v.positive("AUTH_LOCKOUT_DURATION", c.Auth.LockoutDuration.Seconds())
+	if c.Auth.AnomalyTravelSpeed < 0 {
+		v.add("AUTH_ANOMALY_TRAVEL_SPEED: must not be negative")
+	}
+	if c.Auth.AnomalyMaxSessions < 0 {
+		v.add("AUTH_ANOMALY_MAX_SESSIONS: must not be negative")
+	}
+	v.positive("AUTH_ANOMALY_HISTORY", c.Auth.AnomalyHistory.Seconds())
+	for cidr, point := range c.Auth.GeoLocations {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			v.add("AUTH_GEO_LOCATIONS: %q is not a CIDR block", cidr)
+		}
+		if point.Latitude < -90 || point.Latitude > 90 || point.Longitude < -180 || point.Longitude > 180 {
+			v.add("AUTH_GEO_LOCATIONS: location of %q is outside latitude -90..90 and longitude -180..180", cidr)
+		}
+	}
+
+	// Rate limiting
+	if c.RateLimit.DefaultRPM <= 0 {
+		v.add("RATE_LIMIT_DEFAULT_RPM: must be greater than zero")
+	}
+	if c.RateLimit.Burst < 0 {
+		v.add("RATE_LIMIT_BURST: must not be negative")
+	}
+	for i, entry := range c.RateLimit.Allowlist {
+		name := entry.Name
+		if name == "" {
+			name = fmt.Sprintf("entry %d", i)
+		}
+		for _, id := range entry.APIKeys {
+			if _, err := uuid.Parse(id); err != nil {
+				v.add("RATE_LIMIT_ALLOWLIST: %s: api key %q is not a UUID", name, id)
+			}
+		}
+		v.cidrs("RATE_LIMIT_ALLOWLIST: "+name, entry.CIDRs)
+		if entry.Limit < 0 {
+			v.add("RATE_LIMIT_ALLOWLIST: %s: limit must not be negative", name)
+		}
+		switch {
+		case len(entry.APIKeys) == 0 && !entry.ServiceAccount && len(entry.CIDRs) == 0:
+			v.add("RATE_LIMIT_ALLOWLIST: %s: must match on api_keys, service_account or cidrs", name)
+		case len(entry.APIKeys) == 0 && !entry.ServiceAccount && entry.Limit == 0:
+			// Any key used from the network would go unlimited
+			v.add("RATE_LIMIT_ALLOWLIST: %s: an entry matching on cidrs alone must set a limit", name)
+		}
+	}
+
+	// Logging
+	switch strings.ToLower(c.Logging.Level) {
+	case "trace", "debug", "info", "warn", "error", "fatal", "panic", "disabled":
+	default:
+		v.add("LOG_LEVEL: %q is not a valid level", c.Logging.Level)
+	}
+	if c.Logging.Format != "json" && c.Logging.Format != "console" {
+		v.add("LOG_FORMAT: %q must be json or console", c.Logging.Format)
+	}
+	if c.Logging.SampleBurst < 0 {
+		v.add("LOG_SAMPLE_BURST: must not be negative")
+	}
+	v.positive("LOG_SAMPLE_PERIOD", c.Logging.SamplePeriod.Seconds())
+
+	// SMTP
+	if c.SMTP.Host != "" {
+		if c.SMTP.Port < 1 || c.SMTP.Port > 65535 {
+			v.add("SMTP_PORT: %d is not a valid port (1-65535)", c.SMTP.Port)
+		}
+		if c.SMTP.From == "" {
+			v.add("SMTP_FROM: required when SMTP_HOST is set")
+		}
+		switch c.SMTP.TLS {
+		case "starttls", "tls", "none":
+		default:
+			v.add("SMTP_TLS: %q must be one of starttls, tls, none", c.SMTP.TLS)
+		}
+	}
+	if len(c.Approvals.ReviewerEmails) > 0 && c.SMTP.Host == "" {
+		v.add("APPROVAL_REVIEWER_EMAILS: requires SMTP_HOST")
+	}
+	switch c.Approvals.StepUpRiskLevel {
+	case "none", "safe", "sensitive", "dangerous":
+	default:
+		v.add("APPROVAL_STEP_UP_RISK_LEVEL: %q must be one of none, safe, sensitive, dangerous", c.Approvals.StepUpRiskLevel)
+	}
+	v.positive("APPROVAL_STEP_UP_MAX_AGE", c.Approvals.StepUpMaxAge.Seconds())
+	ruleNames := make(map[string]bool, len(c.Approvals.AutoRules))
+	for i, rule := range c.Approvals.AutoRules {
+		key := fmt.Sprintf("APPROVAL_AUTO_RULES[%d]", i)
+		if rule.Name == "" {
+			v.add("%s: name is required", key)
+		} else if ruleNames[rule.Name] {
+			v.add("%s: name %q is used by another rule", key, rule.Name)
+		}
+		ruleNames[rule.Name] = true
+		if rule.ExpiresIn <= 0 {
+			v.add("%s: expires_in must be greater than zero", key)
+		}
+		for _, team := range rule.Teams {
+			if _, err := uuid.Parse(team); err != nil {
+				v.add("%s: team %q is not a UUID", key, team)
+			}
+		}
+		arguments := make([]string, 0, len(rule.Arguments))
+		for name := range rule.Arguments {
+			arguments = append(arguments, name)
+		}
+		sort.Strings(arguments)
+		for _, name := range arguments {
+			constraint := rule.Arguments[name]
+			if constraint.Equals == "" && constraint.PathPrefix == "" && constraint.Pattern == "" {
+				v.add("%s: argument %q needs equals, path_prefix or pattern", key, name)
+			}
+			if _, err := regexp.Compile(constraint.Pattern); err != nil {
+				v.add("%s: argument %q pattern is not a valid regular expression: %v", key, name, err)
+			}
+		}
+	}
+
+	// Safety
+	if c.Safety.EscalationThreshold < 0 {
+		v.add("SAFETY_ESCALATION_THRESHOLD: must not be negative")
+	}
+	v.positive("SAFETY_ESCALATION_WINDOW", c.Safety.EscalationWindow.Seconds())
+	if c.Safety.LoopThreshold < 0 {
+		v.add("SAFETY_LOOP_THRESHOLD: must not be negative")
+	}
+	v.positive("SAFETY_LOOP_WINDOW", c.Safety.LoopWindow.Seconds())
+	if c.Safety.LoopAction != "block" && c.Safety.LoopAction != "warn" {
+		v.add("SAFETY_LOOP_ACTION: %q must be one of block, warn", c.Safety.LoopAction)
+	}
+
+	// SIEM forwarding
+	if c.SIEM.Address != "" {
+		if _, _, err := net.SplitHostPort(c.SIEM.Address); err != nil {
+			v.add("SIEM_SYSLOG_ADDRESS: %q must be host:port", c.SIEM.Address)
+		}
+		if c.SIEM.Network != "tcp" && c.SIEM.Network != "udp" {
+			v.add("SIEM_SYSLOG_NETWORK: %q must be one of tcp, udp", c.SIEM.Network)
+		}
+		if c.SIEM.Format != "cef" && c.SIEM.Format != "leef" {
+			v.add("SIEM_FORMAT: %q must be one of cef, leef", c.SIEM.Format)
+		}
+		v.positive("SIEM_BUFFER_SIZE", float64(c.SIEM.BufferSize))
+		v.positive("SIEM_RETRY_INTERVAL", c.SIEM.RetryInterval.Seconds())
+	}
+
+	// Argument redaction
+	v.patterns("TOOL_REDACT_PATTERNS", c.Redaction.Patterns)
+	for i, rule := range c.Redaction.Rules {
+		key := fmt.Sprintf("TOOL_REDACTION_RULES[%d]", i)
+		if len(rule.Keys) == 0 && len(rule.Patterns) == 0 {
+			v.add("%s: needs keys or patterns", key)
+		}
+		v.patterns(key, rule.Patterns)
+	}
+
+	// Inbound webhooks
+	integrations := make([]string, 0, len(c.Webhooks.Secrets))
+	for integration := range c.Webhooks.Secrets {
+		integrations = append(integrations, integration)
+	}
+	sort.Strings(integrations)
+	for _, integration := range integrations {
+		if c.Webhooks.Secrets[integration] == "" {
+			v.add("WEBHOOK_SECRETS: secret for %q is empty", integration)
+		}
+	}
+	v.positive("WEBHOOK_SIGNATURE_TOLERANCE", c.Webhooks.SignatureTolerance.Seconds())
+
+	// Agents
+	v.positive("AGENT_RESOURCE_POLL_INTERVAL", c.Agents.ResourcePollInterval.Seconds())
+	v.positive("AGENT_RESUME_GRACE_PERIOD", c.Agents.ResumeGracePeriod.Seconds())
+	v.positive("AGENT_MAX_BATCH_CALLS", float64(c.Agents.MaxBatchCalls))
+	v.positive("AGENT_MAX_BATCH_COST", c.Agents.MaxBatchCost)
+	v.positive("AGENT_MAX_INFLIGHT_BATCHES", float64(c.Agents.MaxInFlightBatches))
+	if c.Agents.MaxConcurrentCalls < 0 {
+		v.add("AGENT_MAX_CONCURRENT_CALLS: must not be negative")
+	}
+	if c.Agents.PriorityAging < 0 {
+		v.add("AGENT_PRIORITY_AGING: must not be negative")
+	}
+	if c.Agents.MaxPriority < 0 {
+		v.add("AGENT_MAX_PRIORITY: must not be negative")
+	}
+	classifications := make([]string, 0, len(c.Agents.ToolTimeouts))
+	for classification := range c.Agents.ToolTimeouts {
+		classifications = append(classifications, classification)
+	}
+	sort.Strings(classifications)
+	for _, classification := range classifications {
+		switch classification {
+		case "safe", "sensitive", "dangerous":
+		default:
+			v.add("AGENT_TOOL_TIMEOUTS: %q must be one of safe, sensitive, dangerous", classification)
+		}
+		if c.Agents.ToolTimeouts[classification] <= 0 {
+			v.add("AGENT_TOOL_TIMEOUTS: timeout for %q must be positive", classification)
+		}
+	}
+	if c.Agents.ConnectionCostCap < 0 {
+		v.add("AGENT_CONNECTION_COST_CAP: must not be negative")
+	}
+	tools := make([]string, 0, len(c.Agents.ToolCostCaps))
+	for tool := range c.Agents.ToolCostCaps {
+		tools = append(tools, tool)
+	}
+	sort.Strings(tools)
+	for _, tool := range tools {
+		if server, name, ok := strings.Cut(tool, "."); !ok || server == "" || name == "" {
+			v.add("AGENT_TOOL_COST_CAPS: %q is not server.tool", tool)
+		}
+		if c.Agents.ToolCostCaps[tool] <= 0 {
+			v.add("AGENT_TOOL_COST_CAPS: cap for %q must be positive", tool)
+		}
+	}
+
+	// In-memory buffers
+	v.positive("DETECTION_BUFFER_SIZE", float64(c.Buffers.Detections))
+	v.positive("ALERT_BUFFER_SIZE", float64(c.Buffers.Alerts))
+	v.positive("APPROVAL_BUFFER_SIZE", float64(c.Buffers.Approvals))
+
+	// Cleanup
+	v.positive("CLEANUP_APPROVAL_INTERVAL", c.Cleanup.ApprovalInterval.Seconds())
+	v.positive("CLEANUP_PERMISSION_INTERVAL", c.Cleanup.PermissionInterval.Seconds())
+	v.positive("CLEANUP_SESSION_INTERVAL", c.Cleanup.SessionInterval.Seconds())
+	v.positive("CLEANUP_AUTH_STATE_INTERVAL", c.Cleanup.AuthStateInterval.Seconds())
+
+	// Outbound network policy
+	if _, err := egress.New(c.Egress.Allow, nil); err != nil {
+		v.add("MCP_EGRESS_ALLOW: %v", strings.TrimPrefix(err.Error(), "allow: "))
+	}
+	if _, err := egress.New(nil, c.Egress.Deny); err != nil {
+		v.add("MCP_EGRESS_DENY: %v", strings.TrimPrefix(err.Error(), "deny: "))
+	}
+
+	// MCP servers
+	keys := make([]string, 0, len(c.MCPServers))
+	for key := range c.MCPServers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		server := c.MCPServers[key]
+		prefix := "MCP_SERVER_" + strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+		if server.Name != key {
+			v.add("%s: server name %q does not match key %q", prefix, server.Name, key)
+		}
+		switch server.Transport {
+		case MCPTransportHTTP:
+			v.url(prefix+"_URL", server.URL, "http", "https")
+		case MCPTransportWebSocket:
+			v.url(prefix+"_URL", server.URL, "ws", "wss")
+		case MCPTransportSandbox:
+			if key != MCPSandboxServer {
+				v.add("%s_TRANSPORT: %q is reserved for the built-in sandbox server", prefix, server.Transport)
+			}
+		default:
+			v.add("%s_TRANSPORT: %q must be one of http, websocket", prefix, server.Transport)
+		}
+		v.positive(prefix+"_TIMEOUT", server.Timeout.Seconds())
+		v.positive(prefix+"_ATTEMPT_TIMEOUT", server.AttemptTimeout.Seconds())
+		if server.MaxRetries < 0 {
+			v.add("%s_RETRIES: must not be negative", prefix)
+		}
+		if server.RetryBaseDelay < 0 || server.RetryMaxDelay < 0 {
+			v.add("%s_RETRY_BASE_DELAY: retry delays must not be negative", prefix)
+		} else if server.RetryMaxDelay > 0 && server.RetryBaseDelay > server.RetryMaxDelay {
+			v.add("%s_RETRY_BASE_DELAY: must not exceed %s_RETRY_MAX_DELAY", prefix, prefix)
+		}
+		for _, code := range server.RetryStatusCodes {
+			if code < 100 || code > 599 {
+				v.add("%s_RETRY_STATUS_CODES: %d is not an HTTP status code", prefix, code)
+			}
+		}
+		if server.Pricing.negative() {
+			v.add("%s_PRICE_PER_*: prices must not be negative", prefix)
+		}
+		for tool, pricing := range server.Pricing.Tools {
+			if pricing.negative() {
+				v.add("%s_TOOL_PRICES: prices for tool %q must not be negative", prefix, tool)
+			}
+		}
+		switch server.ArgValidation {
+		case ArgValidationOff, ArgValidationLenient, ArgValidationStrict:
+		default:
+			v.add("%s_ARG_VALIDATION: %q must be one of off, lenient, strict", prefix, server.ArgValidation)
+		}
+		v.positive(prefix+"_SCHEMA_CACHE_TTL", server.SchemaCacheTTL.Seconds())
+		for tool, timeout := range server.ToolTimeouts {
+			if timeout <= 0 {
+				v.add("%s_TOOL_TIMEOUTS: timeout for tool %q must be positive", prefix, tool)
+			}
+		}
+		if _, err := transform.New(server.Transforms); err != nil {
+			v.add("%s_TRANSFORMS: %v", prefix, err)
+		}
+		if problem := server.Auth.Problem(); problem != "" {
+			v.add("%s_AUTH_TYPE: %s", prefix, problem)
+		}
+	}
+
+	if len(v.problems) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: v.problems}
+}
+
+// validator collects validation problems.
+type validator struct {
+	problems []string
+}
+
+func (v *validator) add(format string, args ...interface{}) {
+	v.problems = append(v.problems, fmt.Sprintf(format, args...))
+}
+
+func (v *validator) positive(key string, value float64) {
+	if value <= 0 {
+		v.add("%s: must be greater than zero", key)
+	}
+}
+
+func (v *validator) patterns(key string, patterns map[string]string) {
+	names := make([]string, 0, len(patterns))
+	for name := range patterns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, err := regexp.Compile(patterns[name]); err != nil {
+			v.add("%s: pattern %q is not a valid regular expression: %v", key, name, err)
+		}
+	}
+}
+
+func (v *validator) cidrs(key string, values []string) {
+	for _, value := range values {
+		if strings.Contains(value, "/") {
+			if _, _, err := net.ParseCIDR(value); err != nil {
+				v.add("%s: %q is not a CIDR block", key, value)
+			}
+		} else if net.ParseIP(value) == nil {
+			v.add("%s: %q is not an IP address", key, value)
+		}
+	}
+}
+
+func (v *validator) url(key, value string, schemes ...string) {
+	if value == "" {
+		v.add("%s: is required", key)
+		return
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		v.add("%s: not a valid URL: %v", key, err)
+		return
+	}
+	for _, scheme := range schemes {
+		if u.Scheme == scheme {
+			if u.Host == "" {
+				v.add("%s: missing host", key)
+			}
+			return
+		}
+	}
+	v.add("%s: scheme %q must be one of %s", key, u.Scheme, strings.Join(schemes, ", "))
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// env returns a variable lookup over vars.
+func env(vars map[string]string) func(string) string {
+	return func(key string) string { return vars[key] }
+}
+
+func TestDefaultsAreValid(t *testing.T) {
+	cfg, err := LoadFrom(env(nil))
+	if err != nil {
+		t.Fatalf("LoadFrom: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("default configuration: %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		vars map[string]string
+		want string // Substring of the one expected problem
+	}{
+		{"port out of range", map[string]string{"PORT": "70000"}, "PORT"},
+		{"unknown env", map[string]string{"ENV": "prod"}, "ENV"},
+		{"unparsable integer", map[string]string{"DATABASE_MAX_OPEN_CONNS": "many"}, `DATABASE_MAX_OPEN_CONNS: "many" is not an integer`},
+		{"unparsable duration", map[string]string{"SERVER_READ_TIMEOUT": "30"}, "SERVER_READ_TIMEOUT"},
+		{"idle above open connections", map[string]string{"DATABASE_MAX_OPEN_CONNS": "5", "DATABASE_MAX_IDLE_CONNS": "10"}, "DATABASE_MAX_IDLE_CONNS"},
+		{"wrong database scheme", map[string]string{"DATABASE_URL": "mysql://localhost/db"}, "DATABASE_URL"},
+		{"wildcard origin with credentials", map[string]string{"CORS_ALLOWED_ORIGINS": "*", "CORS_ALLOW_CREDENTIALS": "true"}, "CORS_ALLOWED_ORIGINS"},
+		{"origin with a path", map[string]string{"CORS_ALLOWED_ORIGINS": "https://example.com/app"}, "CORS_ALLOWED_ORIGINS"},
+		{"short session key", map[string]string{"SESSION_SIGNING_KEY": "short"}, "SESSION_SIGNING_KEY"},
+		{"bad log level", map[string]string{"LOG_LEVEL": "verbose"}, "LOG_LEVEL"},
+		{"reviewer emails without smtp", map[string]string{"APPROVAL_REVIEWER_EMAILS": "a@example.com"}, "APPROVAL_REVIEWER_EMAILS"},
+		{"smtp without sender", map[string]string{"SMTP_HOST": "smtp.example.com"}, "SMTP_FROM"},
+		{"bad auto rule", map[string]string{"APPROVAL_AUTO_RULES": `[{"name":"r","expires_in":0}]`}, "expires_in"},
+		{"siem address without port", map[string]string{"SIEM_SYSLOG_ADDRESS": "siem.example.com"}, "SIEM_SYSLOG_ADDRESS"},
+		{"compressed event streams", map[string]string{"COMPRESSION_CONTENT_TYPES": "text/event-stream"}, "event streams"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := LoadFrom(env(tt.vars))
+			if err != nil {
+				t.Fatalf("LoadFrom: %v", err)
+			}
+			err = cfg.Validate()
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("Validate = %v, want a *ValidationError", err)
+			}
+			if len(verr.Problems) != 1 || !strings.Contains(verr.Problems[0], tt.want) {
+				t.Errorf("problems = %q, want one mentioning %q", verr.Problems, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	cfg, err := LoadFrom(env(map[string]string{"PORT": "0", "LOG_LEVEL": "loud", "RATE_LIMIT_DEFAULT_RPM": "0"}))
+	if err != nil {
+		t.Fatalf("LoadFrom: %v", err)
+	}
+	var verr *ValidationError
+	if !errors.As(cfg.Validate(), &verr) || len(verr.Problems) != 3 {
+		t.Fatalf("Validate = %v, want three problems", verr)
+	}
+	if msg := verr.Error(); !strings.HasPrefix(msg, "invalid configuration (3 problems)") {
+		t.Errorf("Error() = %q", msg)
+	}
+}
@@ -24,6 +24,8 @@ func NewPostgres(cfg config.DatabaseConfig, logger zerolog.Logger) (*Postgres, e
 		Str("url", maskDSN(cfg.URL)).
 		Int("max_open_conns", cfg.MaxOpenConns).
 		Int("max_idle_conns", cfg.MaxIdleConns).
+		Dur("conn_max_lifetime", cfg.ConnMaxLifetime).
+		Dur("conn_max_idle_time", cfg.ConnMaxIdleTime).
 		Msg("Connecting to PostgreSQL")
 
 	db, err := sql.Open("postgres", cfg.URL)
@@ -35,6 +37,7 @@ func NewPostgres(cfg config.DatabaseConfig, logger zerolog.Logger) (*Postgres, e
 	db.SetMaxOpenConns(cfg.MaxOpenConns)
 	db.SetMaxIdleConns(cfg.MaxIdleConns)
 	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
 
 	// Test connection with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
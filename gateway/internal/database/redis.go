@@ -95,6 +95,17 @@ func (r *Redis) Set(ctx context.Context, key string, value interface{}, expirati
 	return r.Client.Set(ctx, key, value, expiration).Err()
 }
 
+// GetDel retrieves a value by key and deletes it in one step.
+func (r *Redis) GetDel(ctx context.Context, key string) (string, error) {
+	return r.Client.GetDel(ctx, key).Result()
+}
+
+// SetNX sets a value with optional expiration if the key does not exist,
+// reporting whether it was set.
+func (r *Redis) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	return r.Client.SetNX(ctx, key, value, expiration).Result()
+}
+
 // Del deletes one or more keys.
 func (r *Redis) Del(ctx context.Context, keys ...string) error {
 	return r.Client.Del(ctx, keys...).Err()
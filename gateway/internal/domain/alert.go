@@ -1,6 +1,8 @@
 package domain
 
 import (
+	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -30,6 +32,7 @@ type AlertMetric string
 const (
 	AlertMetricErrorRate    AlertMetric = "error_rate"
 	AlertMetricLatencyP50   AlertMetric = "latency_p50"
+	AlertMetricLatencyP90   AlertMetric = "latency_p90"
 	AlertMetricLatencyP95   AlertMetric = "latency_p95"
 	AlertMetricLatencyP99   AlertMetric = "latency_p99"
 	AlertMetricRequestRate  AlertMetric = "request_rate"
@@ -37,6 +40,9 @@ const (
 	AlertMetricCostPerDay   AlertMetric = "cost_per_day"
 	AlertMetricRateLimitHit AlertMetric = "rate_limit_hit"
 	AlertMetricInjectionDetected AlertMetric = "injection_detected"
+	AlertMetricBudgetUsage       AlertMetric = "budget_usage"
+	AlertMetricToolLoop          AlertMetric = "tool_loop"
+	AlertMetricLoginAnomaly      AlertMetric = "login_anomaly"
 )
 
 // AlertCondition represents the comparison condition.
@@ -64,30 +70,137 @@ type AlertRule struct {
 	Severity      AlertSeverity  `json:"severity"`
 	Channels      []uuid.UUID    `json:"channels"` // Alert channel IDs
 	Filters       AlertFilters   `json:"filters,omitempty"`
+	Templates     AlertTemplates `json:"templates,omitempty"`
 	Enabled       bool           `json:"enabled"`
 	CreatedAt     time.Time      `json:"created_at"`
 	UpdatedAt     time.Time      `json:"updated_at"`
 	CreatedBy     uuid.UUID      `json:"created_by"`
+	DeletedAt     *time.Time     `json:"deleted_at,omitempty"`
 }
 
-// AlertFilters defines optional filters for alert rules.
+// AlertFilters defines optional filters for alert rules. A rule with filters
+// evaluates its metric only over calls matching every non-empty dimension.
 type AlertFilters struct {
-	MCPServers  []string   `json:"mcp_servers,omitempty"`
-	Teams       []uuid.UUID `json:"teams,omitempty"`
-	Environments []string   `json:"environments,omitempty"`
+	MCPServers   []string    `json:"mcp_servers,omitempty"`
+	Tools        []string    `json:"tools,omitempty"`
+	Teams        []uuid.UUID `json:"teams,omitempty"`
+	Environments []string    `json:"environments,omitempty"`
+}
+
+// AlertTemplates holds Go text/template sources used to render an alert's
+// message at fire time. A channel-specific template takes precedence over
+// Default; when both are empty the evaluator's message is sent unchanged.
+type AlertTemplates struct {
+	Default   string `json:"default,omitempty"`
+	Slack     string `json:"slack,omitempty"`
+	PagerDuty string `json:"pagerduty,omitempty"`
+	Webhook   string `json:"webhook,omitempty"`
+}
+
+// For returns the template to use for a channel type.
+func (t AlertTemplates) For(channelType AlertChannelType) string {
+	var tmpl string
+	switch channelType {
+	case AlertChannelSlack:
+		tmpl = t.Slack
+	case AlertChannelPagerDuty:
+		tmpl = t.PagerDuty
+	case AlertChannelWebhook:
+		tmpl = t.Webhook
+	}
+	if tmpl == "" {
+		tmpl = t.Default
+	}
+	return tmpl
+}
+
+// AlertFilterKeys lists the dimensions an alert rule can be scoped to.
+var AlertFilterKeys = []string{"mcp_servers", "tools", "teams", "environments"}
+
+// UnknownFilterKeyError is returned when alert filters name a dimension that
+// call samples do not carry.
+type UnknownFilterKeyError struct {
+	Key string
+}
+
+func (e *UnknownFilterKeyError) Error() string {
+	return fmt.Sprintf("unknown alert filter %q", e.Key)
+}
+
+// UnmarshalJSON rejects filter keys outside AlertFilterKeys so a misspelled
+// dimension does not silently widen a rule to every call.
+func (f *AlertFilters) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	for key := range raw {
+		known := false
+		for _, k := range AlertFilterKeys {
+			if key == k {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return &UnknownFilterKeyError{Key: key}
+		}
+	}
+
+	type plain AlertFilters
+	return json.Unmarshal(data, (*plain)(f))
+}
+
+// Matches reports whether a call sample falls within the filters.
+func (f AlertFilters) Matches(sample CallSample) bool {
+	if len(f.MCPServers) > 0 && !containsString(f.MCPServers, sample.MCPServer) {
+		return false
+	}
+	if len(f.Tools) > 0 && !containsString(f.Tools, sample.ToolName) {
+		return false
+	}
+	if len(f.Environments) > 0 && !containsString(f.Environments, sample.Environment) {
+		return false
+	}
+	if len(f.Teams) > 0 {
+		if sample.TeamID == nil {
+			return false
+		}
+		found := false
+		for _, team := range f.Teams {
+			if team == *sample.TeamID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
 }
 
 // AlertRuleInput represents input for creating/updating an alert rule.
 type AlertRuleInput struct {
-	Name          string         `json:"name"`
+	Name          string         `json:"name" validate:"required,max=200"`
 	Description   string         `json:"description,omitempty"`
-	Metric        AlertMetric    `json:"metric"`
-	Condition     AlertCondition `json:"condition"`
+	Metric        AlertMetric    `json:"metric" validate:"required,oneof=error_rate latency_p50 latency_p90 latency_p95 latency_p99 request_rate cost_per_hour cost_per_day rate_limit_hit injection_detected budget_usage"`
+	Condition     AlertCondition `json:"condition" validate:"required,oneof=gt lt gte lte eq neq"`
 	Threshold     float64        `json:"threshold"`
-	WindowMinutes int            `json:"window_minutes"`
-	Severity      AlertSeverity  `json:"severity"`
+	WindowMinutes int            `json:"window_minutes" validate:"min=0"`
+	Severity      AlertSeverity  `json:"severity" validate:"oneof=info warning critical"`
 	Channels      []uuid.UUID    `json:"channels"`
 	Filters       AlertFilters   `json:"filters,omitempty"`
+	Templates     AlertTemplates `json:"templates,omitempty"`
 	Enabled       bool           `json:"enabled"`
 }
 
@@ -110,6 +223,7 @@ type AlertChannel struct {
 	Name      string                 `json:"name"`
 	Type      AlertChannelType       `json:"type"`
 	Config    map[string]interface{} `json:"config"`
+	Grouping  AlertGrouping          `json:"grouping"`
 	Enabled   bool                   `json:"enabled"`
 	CreatedAt time.Time              `json:"created_at"`
 	UpdatedAt time.Time              `json:"updated_at"`
@@ -117,10 +231,35 @@ type AlertChannel struct {
 
 // AlertChannelInput represents input for creating/updating an alert channel.
 type AlertChannelInput struct {
-	Name    string                 `json:"name"`
-	Type    AlertChannelType       `json:"type"`
-	Config  map[string]interface{} `json:"config"`
-	Enabled bool                   `json:"enabled"`
+	Name     string                 `json:"name" validate:"required,max=200"`
+	Type     AlertChannelType       `json:"type" validate:"required,oneof=slack pagerduty opsgenie webhook email"`
+	Config   map[string]interface{} `json:"config" validate:"required"`
+	Grouping AlertGrouping          `json:"grouping"`
+	Enabled  bool                   `json:"enabled"`
+}
+
+// MaxAlertGroupWaitSeconds bounds how long a channel holds alerts back to
+// group them.
+const MaxAlertGroupWaitSeconds = 3600
+
+// AlertGrouping batches the alerts a channel receives into one notification.
+// Alerts firing within GroupWaitSeconds of the first alert of a group, and
+// sharing its values for the GroupBy labels, are sent together. Without
+// GroupBy every alert on the channel falls in one group. Grouping is off
+// while GroupWaitSeconds is zero.
+type AlertGrouping struct {
+	GroupBy          []string `json:"group_by,omitempty"`
+	GroupWaitSeconds int      `json:"group_wait_seconds,omitempty"`
+}
+
+// Enabled reports whether alerts are grouped.
+func (g AlertGrouping) Enabled() bool {
+	return g.GroupWaitSeconds > 0
+}
+
+// Wait returns how long a group collects alerts before it is sent.
+func (g AlertGrouping) Wait() time.Duration {
+	return time.Duration(g.GroupWaitSeconds) * time.Second
 }
 
 // SlackChannelConfig represents Slack-specific channel configuration.
@@ -159,6 +298,97 @@ type Alert struct {
 	ResolvedAt *time.Time    `json:"resolved_at,omitempty"`
 	AckedAt    *time.Time    `json:"acked_at,omitempty"`
 	AckedBy    *uuid.UUID    `json:"acked_by,omitempty"`
+	// The active alert holding back this alert's notifications under an
+	// inhibit rule
+	InhibitedBy *uuid.UUID `json:"inhibited_by,omitempty"`
+}
+
+// AlertEventType is a step in an alert's lifecycle.
+type AlertEventType string
+
+const (
+	AlertEventCreated  AlertEventType = "created"
+	AlertEventNotified AlertEventType = "notified" // Sent to the rule's channels
+	AlertEventSilenced AlertEventType = "silenced" // Notification held back by an inhibiting alert
+	AlertEventAcked    AlertEventType = "acknowledged"
+	AlertEventResolved AlertEventType = "resolved"
+)
+
+// AlertActorType is the kind of actor that moved an alert along.
+type AlertActorType string
+
+const (
+	AlertActorSystem      AlertActorType = "system" // Rule evaluation and notification
+	AlertActorUser        AlertActorType = "user"
+	AlertActorIntegration AlertActorType = "integration" // An inbound callback, such as from PagerDuty
+)
+
+// AlertActor is who moved an alert along: the gateway itself, a user, or an
+// integration.
+type AlertActor struct {
+	Type        AlertActorType `json:"type"`
+	UserID      *uuid.UUID     `json:"user_id,omitempty"`
+	Integration string         `json:"integration,omitempty"`
+}
+
+// SystemActor is the gateway acting on its own, such as a rule firing.
+func SystemActor() AlertActor {
+	return AlertActor{Type: AlertActorSystem}
+}
+
+// UserActor is a user acting on an alert.
+func UserActor(userID uuid.UUID) AlertActor {
+	return AlertActor{Type: AlertActorUser, UserID: &userID}
+}
+
+// IntegrationActor is an integration acting on an alert through a callback.
+func IntegrationActor(name string) AlertActor {
+	return AlertActor{Type: AlertActorIntegration, Integration: name}
+}
+
+// AlertEvent records one step in an alert's lifecycle. Events are only ever
+// appended, so an alert's events are its full history, oldest first.
+type AlertEvent struct {
+	ID        uuid.UUID              `json:"id"`
+	AlertID   uuid.UUID              `json:"alert_id"`
+	OrgID     uuid.UUID              `json:"org_id"`
+	Type      AlertEventType         `json:"type"`
+	Actor     AlertActor             `json:"actor"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+// AlertHistory is an alert's events, with the status they add up to.
+type AlertHistory struct {
+	AlertID uuid.UUID    `json:"alert_id"`
+	Status  AlertStatus  `json:"status"`
+	Events  []AlertEvent `json:"events"`
+}
+
+// InhibitRule holds back the notifications of alerts matching TargetMatch
+// while an alert matching SourceMatch is active in the same organization,
+// such as latency warnings while a service-down alert fires. With Equal, the
+// two alerts must also have the same values for the listed labels. Matchers
+// compare label values exactly; "severity" matches the alert's severity.
+type InhibitRule struct {
+	ID          uuid.UUID `json:"id"`
+	OrgID       uuid.UUID `json:"org_id"`
+	Name        string    `json:"name"`
+	SourceMatch Labels    `json:"source_match"`
+	TargetMatch Labels    `json:"target_match"`
+	Equal       []string  `json:"equal,omitempty"`
+	Enabled     bool      `json:"enabled"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// InhibitRuleInput represents input for creating/updating an inhibit rule.
+type InhibitRuleInput struct {
+	Name        string   `json:"name" validate:"required,max=200"`
+	SourceMatch Labels   `json:"source_match" validate:"required"`
+	TargetMatch Labels   `json:"target_match" validate:"required"`
+	Equal       []string `json:"equal,omitempty"`
+	Enabled     bool     `json:"enabled"`
 }
 
 // Labels represents key-value labels for an alert.
@@ -174,15 +404,17 @@ type AlertFilter struct {
 	EndTime    *time.Time      `json:"end_time,omitempty"`
 	Limit      int             `json:"limit,omitempty"`
 	Offset     int             `json:"offset,omitempty"`
+	Cursor     *Cursor         `json:"-"` // Keyset position; takes precedence over Offset
 }
 
 // AlertPage represents a paginated list of alerts.
 type AlertPage struct {
-	Alerts  []Alert `json:"alerts"`
-	Total   int64   `json:"total"`
-	Limit   int     `json:"limit"`
-	Offset  int     `json:"offset"`
-	HasMore bool    `json:"has_more"`
+	Alerts     []Alert `json:"alerts"`
+	Total      int64   `json:"total"`
+	Limit      int     `json:"limit"`
+	Offset     int     `json:"offset"`
+	HasMore    bool    `json:"has_more"`
+	NextCursor string  `json:"next_cursor,omitempty"`
 }
 
 // AlertNotification represents a notification to be sent.
@@ -191,3 +423,24 @@ type AlertNotification struct {
 	Rule    AlertRule    `json:"rule"`
 	Channel AlertChannel `json:"channel"`
 }
+
+// ChannelDelivery reports the outcome of delivering an alert to one channel.
+type ChannelDelivery struct {
+	ChannelID   uuid.UUID        `json:"channel_id"`
+	ChannelName string           `json:"channel_name,omitempty"`
+	ChannelType AlertChannelType `json:"channel_type,omitempty"`
+	Success     bool             `json:"success"`
+	Skipped     bool             `json:"skipped,omitempty"`
+	Grouped     bool             `json:"grouped,omitempty"` // Held back to be sent with its group
+	Error       string           `json:"error,omitempty"`
+	DurationMs  int64            `json:"duration_ms"`
+}
+
+// TestFireResult is the outcome of test-firing an alert rule.
+type TestFireResult struct {
+	RuleID     uuid.UUID         `json:"rule_id"`
+	Alert      Alert             `json:"alert"`
+	Deliveries []ChannelDelivery `json:"deliveries"`
+	Delivered  int               `json:"delivered"`
+	Failed     int               `json:"failed"`
+}
@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestAlertFiltersRejectUnknownKeys(t *testing.T) {
+	var filters AlertFilters
+	if err := json.Unmarshal([]byte(`{"mcp_servers":["shell"],"tools":["execute_command"]}`), &filters); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if len(filters.MCPServers) != 1 || filters.MCPServers[0] != "shell" || len(filters.Tools) != 1 {
+		t.Errorf("filters = %+v", filters)
+	}
+
+	err := json.Unmarshal([]byte(`{"mcp_server":["shell"]}`), &filters)
+	var unknown *UnknownFilterKeyError
+	if !errors.As(err, &unknown) || unknown.Key != "mcp_server" {
+		t.Errorf("err = %v, want the misspelled key reported", err)
+	}
+}
+
+func TestAlertFiltersMatch(t *testing.T) {
+	team, otherTeam := uuid.New(), uuid.New()
+	sample := CallSample{MCPServer: "shell", ToolName: "execute_command", TeamID: &team, Environment: "production"}
+
+	tests := []struct {
+		name    string
+		filters AlertFilters
+		want    bool
+	}{
+		{"no filters", AlertFilters{}, true},
+		{"server", AlertFilters{MCPServers: []string{"filesystem", "shell"}}, true},
+		{"other server", AlertFilters{MCPServers: []string{"filesystem"}}, false},
+		{"server and other tool", AlertFilters{MCPServers: []string{"shell"}, Tools: []string{"read_file"}}, false},
+		{"team", AlertFilters{Teams: []uuid.UUID{team}}, true},
+		{"other team", AlertFilters{Teams: []uuid.UUID{otherTeam}}, false},
+		{"environment", AlertFilters{Environments: []string{"staging"}}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.filters.Matches(sample); got != tt.want {
+			t.Errorf("%s: Matches = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if (AlertFilters{Teams: []uuid.UUID{team}}).Matches(CallSample{MCPServer: "shell"}) {
+		t.Error("a team filter matched a call without a team")
+	}
+}
@@ -17,8 +17,11 @@ const (
 	AuditActionAPIKeyCreate   AuditAction = "api_key.create"
 	AuditActionAPIKeyRevoke   AuditAction = "api_key.revoke"
 	AuditActionAPIKeyRotate   AuditAction = "api_key.rotate"
+	AuditActionAPIKeyIPDenied AuditAction = "api_key.ip_denied"
 	AuditActionUserLogin      AuditAction = "user.login"
 	AuditActionUserLogout     AuditAction = "user.logout"
+	AuditActionUserLockout    AuditAction = "user.lockout"
+	AuditActionSessionRevoke  AuditAction = "session.revoke"
 	AuditActionRoleCreate     AuditAction = "role.create"
 	AuditActionRoleUpdate     AuditAction = "role.update"
 	AuditActionRoleDelete     AuditAction = "role.delete"
@@ -27,10 +30,43 @@ const (
 	AuditActionPolicyCreate   AuditAction = "policy.create"
 	AuditActionPolicyUpdate   AuditAction = "policy.update"
 	AuditActionPolicyDelete   AuditAction = "policy.delete"
+	AuditActionPolicyRestore  AuditAction = "policy.restore"
+	AuditActionLibraryCreate  AuditAction = "pattern_library.create"
+	AuditActionLibraryUpdate  AuditAction = "pattern_library.update"
+	AuditActionLibraryDelete  AuditAction = "pattern_library.delete"
 	AuditActionApprovalCreate AuditAction = "approval.create"
 	AuditActionApprovalGrant  AuditAction = "approval.grant"
+	AuditActionApprovalAuto   AuditAction = "approval.auto_grant"
 	AuditActionApprovalDeny   AuditAction = "approval.deny"
+	AuditActionApprovalReplay AuditAction = "approval.replay"
 	AuditActionConfigChange   AuditAction = "config.change"
+
+	AuditActionAlertRuleCreate          AuditAction = "alert_rule.create"
+	AuditActionAlertRuleUpdate          AuditAction = "alert_rule.update"
+	AuditActionAlertRuleDelete          AuditAction = "alert_rule.delete"
+	AuditActionAlertRuleRestore         AuditAction = "alert_rule.restore"
+	AuditActionAlertChannelCreate       AuditAction = "alert_channel.create"
+	AuditActionAlertChannelUpdate       AuditAction = "alert_channel.update"
+	AuditActionAlertChannelDelete       AuditAction = "alert_channel.delete"
+	AuditActionInhibitRuleCreate        AuditAction = "inhibit_rule.create"
+	AuditActionInhibitRuleUpdate        AuditAction = "inhibit_rule.update"
+	AuditActionInhibitRuleDelete        AuditAction = "inhibit_rule.delete"
+	AuditActionSSOProviderCreate        AuditAction = "sso_provider.create"
+	AuditActionSSOProviderUpdate        AuditAction = "sso_provider.update"
+	AuditActionSSOProviderDelete        AuditAction = "sso_provider.delete"
+	AuditActionSSOSecretsRotate         AuditAction = "sso_provider.rotate_key"
+	AuditActionSecretsRotate            AuditAction = "secrets.rotate_key"
+	AuditActionToolClassificationSet    AuditAction = "tool_classification.set"
+	AuditActionToolClassificationDelete AuditAction = "tool_classification.delete"
+	AuditActionToolPermissionGrant      AuditAction = "tool_permission.grant"
+	AuditActionMCPServerRegister        AuditAction = "mcp_server.register"
+	AuditActionMCPServerUpdate          AuditAction = "mcp_server.update"
+	AuditActionMCPServerRemove          AuditAction = "mcp_server.remove"
+	AuditActionSettingSet               AuditAction = "setting.set"
+	AuditActionSettingReset             AuditAction = "setting.reset"
+	AuditActionRateLimitBypass          AuditAction = "rate_limit.bypass"
+	AuditActionMCPEgressDenied          AuditAction = "mcp.egress_denied"
+	AuditActionSessionIPDenied          AuditAction = "session.ip_denied"
 )
 
 // AuditOutcome represents the result of an audited action.
@@ -60,6 +96,8 @@ type AuditLog struct {
 	RequestID   string                 `json:"request_id,omitempty"`
 	DurationMS  int64                  `json:"duration_ms,omitempty"`
 	CreatedAt   time.Time              `json:"created_at"`
+	PrevHash    string                 `json:"prev_hash,omitempty"` // Hash of the org's previous record
+	Hash        string                 `json:"hash,omitempty"`      // SHA-256 over this record and PrevHash
 }
 
 // AuditLogFilter defines filters for querying audit logs.
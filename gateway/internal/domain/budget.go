@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BudgetPeriod represents how often a budget resets.
+type BudgetPeriod string
+
+const (
+	BudgetPeriodDaily   BudgetPeriod = "daily"
+	BudgetPeriodMonthly BudgetPeriod = "monthly"
+)
+
+// Budget caps spend for an organization or a team within a period.
+type Budget struct {
+	ID            uuid.UUID    `json:"id"`
+	OrgID         uuid.UUID    `json:"org_id"`
+	TeamID        *uuid.UUID   `json:"team_id,omitempty"` // nil applies to the whole org
+	Name          string       `json:"name"`
+	Period        BudgetPeriod `json:"period"`
+	LimitUSD      float64      `json:"limit_usd"`      // Hard cap; calls are blocked once reached
+	SoftThreshold float64      `json:"soft_threshold"` // Fraction of the limit (0-1) that fires a warning
+	Channels      []uuid.UUID  `json:"channels,omitempty"`
+	Enabled       bool         `json:"enabled"`
+	SpentUSD      float64      `json:"spent_usd"`
+	PeriodStart   time.Time    `json:"period_start"`
+	SoftAlerted   bool         `json:"soft_alerted"`
+	CreatedAt     time.Time    `json:"created_at"`
+	UpdatedAt     time.Time    `json:"updated_at"`
+}
+
+// BudgetInput represents input for creating or updating a budget.
+type BudgetInput struct {
+	TeamID        *uuid.UUID   `json:"team_id,omitempty"`
+	Name          string       `json:"name"`
+	Period        BudgetPeriod `json:"period"`
+	LimitUSD      float64      `json:"limit_usd"`
+	SoftThreshold float64      `json:"soft_threshold"`
+	Channels      []uuid.UUID  `json:"channels,omitempty"`
+	Enabled       bool         `json:"enabled"`
+}
+
+// Remaining returns the unspent amount of the budget.
+func (b *Budget) Remaining() float64 {
+	if b.SpentUSD >= b.LimitUSD {
+		return 0
+	}
+	return b.LimitUSD - b.SpentUSD
+}
+
+// PeriodEnd returns when the current budget period ends.
+func (b *Budget) PeriodEnd() time.Time {
+	if b.Period == BudgetPeriodDaily {
+		return b.PeriodStart.AddDate(0, 0, 1)
+	}
+	return b.PeriodStart.AddDate(0, 1, 0)
+}
+
+// BudgetPeriodStart returns the start of the period containing t.
+func BudgetPeriodStart(period BudgetPeriod, t time.Time) time.Time {
+	t = t.UTC()
+	if period == BudgetPeriodDaily {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
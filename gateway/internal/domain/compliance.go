@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ComplianceReportFormat is the format a compliance report is downloaded in.
+type ComplianceReportFormat string
+
+const (
+	ComplianceReportJSON ComplianceReportFormat = "json"
+	ComplianceReportCSV  ComplianceReportFormat = "csv"
+	ComplianceReportHTML ComplianceReportFormat = "html" // Printable, for saving as PDF from a browser
+)
+
+// ComplianceReport answers who could use which tools, and who approved it,
+// for an org over a period. Classifications, permissions and role
+// assignments are as of GeneratedAt; approval decisions and detections are
+// those within the period.
+type ComplianceReport struct {
+	OrgID       uuid.UUID `json:"org_id"`
+	StartDate   time.Time `json:"start_date"`
+	EndDate     time.Time `json:"end_date"`
+	GeneratedAt time.Time `json:"generated_at"`
+
+	Summary             ComplianceSummary          `json:"summary"`
+	Users               []ComplianceUser           `json:"users"`
+	ToolClassifications []ToolClassification       `json:"tool_classifications"`
+	ToolPermissions     []ToolPermission           `json:"tool_permissions"`
+	ApprovalDecisions   []ToolApproval             `json:"approval_decisions"`
+	RoleAssignments     []ComplianceRoleAssignment `json:"role_assignments"`
+	SafetyDetections    []InjectionDetection       `json:"safety_detections"`
+}
+
+// ComplianceSummary counts the contents of a compliance report.
+type ComplianceSummary struct {
+	DangerousTools       int                       `json:"dangerous_tools"`
+	ActivePermissions    int                       `json:"active_permissions"`
+	ApprovalsByStatus    map[ApprovalStatus]int    `json:"approvals_by_status"`
+	RoleAssignments      int                       `json:"role_assignments"`
+	DetectionsBySeverity map[DetectionSeverity]int `json:"detections_by_severity"`
+}
+
+// ComplianceUser identifies a user that requested, reviewed or granted
+// access, or holds a role, so IDs elsewhere in the report can be resolved.
+type ComplianceUser struct {
+	ID    uuid.UUID `json:"id"`
+	Email string    `json:"email,omitempty"`
+	Name  string    `json:"name,omitempty"`
+}
+
+// ComplianceRoleAssignment is a role assignment with the role it grants.
+type ComplianceRoleAssignment struct {
+	RoleAssignment
+	RoleName    string       `json:"role_name"`
+	Permissions []Permission `json:"permissions"`
+}
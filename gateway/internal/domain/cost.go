@@ -50,3 +50,66 @@ type CostFilter struct {
 	StartDate time.Time  `json:"start_date"`
 	EndDate   time.Time  `json:"end_date"`
 }
+
+// CostEvent represents the cost of a single MCP call, used for attribution reports.
+type CostEvent struct {
+	ID        uuid.UUID  `json:"id"`
+	OrgID     uuid.UUID  `json:"org_id"`
+	TeamID    *uuid.UUID `json:"team_id,omitempty"`
+	UserID    *uuid.UUID `json:"user_id,omitempty"`
+	APIKeyID  *uuid.UUID `json:"api_key_id,omitempty"`
+	TraceID   string     `json:"trace_id"`
+	MCPServer string     `json:"mcp_server"`
+	ToolName  string     `json:"tool_name,omitempty"`
+	Operation string     `json:"operation"`
+	Cost      float64    `json:"cost"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// CostGroupBy represents a dimension a cost report can be grouped by.
+type CostGroupBy string
+
+const (
+	CostGroupByTeam      CostGroupBy = "team"
+	CostGroupByUser      CostGroupBy = "user"
+	CostGroupByMCPServer CostGroupBy = "mcp_server"
+	CostGroupByTool      CostGroupBy = "tool"
+)
+
+// ValidCostGroupBy returns true if the dimension is supported.
+func ValidCostGroupBy(g CostGroupBy) bool {
+	switch g {
+	case CostGroupByTeam, CostGroupByUser, CostGroupByMCPServer, CostGroupByTool:
+		return true
+	}
+	return false
+}
+
+// CostReportFilter represents filters for a cost attribution report.
+type CostReportFilter struct {
+	OrgID     uuid.UUID     `json:"org_id"`
+	TeamID    *uuid.UUID    `json:"team_id,omitempty"`
+	StartDate time.Time     `json:"start_date"`
+	EndDate   time.Time     `json:"end_date"`
+	GroupBy   []CostGroupBy `json:"group_by"`
+}
+
+// CostReportRow represents summed cost for one combination of group-by values.
+type CostReportRow struct {
+	Team      string  `json:"team,omitempty"`
+	User      string  `json:"user,omitempty"`
+	MCPServer string  `json:"mcp_server,omitempty"`
+	Tool      string  `json:"tool,omitempty"`
+	TotalCost float64 `json:"total_cost"`
+	CallCount int64   `json:"call_count"`
+}
+
+// CostReport represents a cost attribution report.
+type CostReport struct {
+	GroupBy   []CostGroupBy   `json:"group_by"`
+	StartDate time.Time       `json:"start_date"`
+	EndDate   time.Time       `json:"end_date"`
+	TotalCost float64         `json:"total_cost"`
+	CallCount int64           `json:"call_count"`
+	Rows      []CostReportRow `json:"rows"`
+}
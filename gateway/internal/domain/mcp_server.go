@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MCPServerSource says where an MCP server's configuration comes from.
+type MCPServerSource string
+
+const (
+	MCPServerSourceConfig   MCPServerSource = "config"   // Environment configuration; read-only through the API
+	MCPServerSourceRegistry MCPServerSource = "registry" // Registered at runtime through the admin API
+)
+
+// MCPServer is an MCP server the gateway proxies to. DefaultClassification,
+// when set, is the risk level of the server's tools that an organization has
+// not classified, in place of the built-in defaults. A registered server
+// belongs to the organization in OrgID; configured servers are shared.
+type MCPServer struct {
+	OrgID                 *uuid.UUID      `json:"org_id,omitempty"` // Unset for configured servers
+	Name                  string          `json:"name"`
+	URL                   string          `json:"url"`
+	TimeoutSeconds        int             `json:"timeout_seconds"`
+	DefaultClassification ToolRiskLevel   `json:"default_classification,omitempty"`
+	AuthType              MCPAuthType     `json:"auth_type,omitempty"` // The credential itself is never returned
+	Source                MCPServerSource `json:"source"`
+	Auth                  *MCPServerAuth  `json:"-"`
+	AuthEncrypted         []byte          `json:"-"` // Auth as stored
+	CreatedBy             *uuid.UUID      `json:"created_by,omitempty"`
+	CreatedAt             *time.Time      `json:"created_at,omitempty"` // Unset for configured servers
+	UpdatedAt             *time.Time      `json:"updated_at,omitempty"`
+}
+
+// MCPServerInput represents input for registering an MCP server.
+type MCPServerInput struct {
+	Name                  string         `json:"name" validate:"required,max=100"`
+	URL                   string         `json:"url" validate:"required,url"`
+	TimeoutSeconds        int            `json:"timeout_seconds,omitempty" validate:"min=1,max=300"`
+	DefaultClassification ToolRiskLevel  `json:"default_classification,omitempty" validate:"oneof=safe sensitive dangerous"`
+	Auth                  *MCPServerAuth `json:"auth,omitempty"`
+}
+
+// MCPServerUpdate represents input for updating a registered MCP server. It
+// replaces the server's settings; omitted optional fields are reset.
+type MCPServerUpdate struct {
+	URL                   string         `json:"url" validate:"required,url"`
+	TimeoutSeconds        int            `json:"timeout_seconds,omitempty" validate:"min=1,max=300"`
+	DefaultClassification ToolRiskLevel  `json:"default_classification,omitempty" validate:"oneof=safe sensitive dangerous"`
+	Auth                  *MCPServerAuth `json:"auth,omitempty"`
+}
+
+// MCPAuthType is how the gateway authenticates to an MCP server.
+type MCPAuthType string
+
+const (
+	MCPAuthBearer MCPAuthType = "bearer" // Authorization: Bearer with a token
+	MCPAuthBasic  MCPAuthType = "basic"  // Authorization: Basic with a username and password
+	MCPAuthHeader MCPAuthType = "header" // A custom header set to a value
+)
+
+// MCPServerAuth is the credential the gateway sends on every request to an
+// MCP server. Only the fields of its type are used.
+type MCPServerAuth struct {
+	Type     MCPAuthType `json:"type" validate:"required,oneof=bearer basic header"`
+	Token    string      `json:"token,omitempty"`
+	Username string      `json:"username,omitempty"`
+	Password string      `json:"password,omitempty"`
+	Header   string      `json:"header,omitempty"`
+	Value    string      `json:"value,omitempty"`
+}
+
+// String describes the credential without revealing it.
+func (a MCPServerAuth) String() string {
+	return string(a.Type) + " (redacted)"
+}
@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CallSample is a single MCP call observation used to compute alert metrics.
+type CallSample struct {
+	ID          uuid.UUID  `json:"id"`
+	OrgID       uuid.UUID  `json:"org_id"`
+	TeamID      *uuid.UUID `json:"team_id,omitempty"`
+	Environment string     `json:"environment,omitempty"`
+	MCPServer   string     `json:"mcp_server"`
+	ToolName    string     `json:"tool_name,omitempty"`
+	IsError     bool       `json:"is_error"`
+	DurationMs  int64      `json:"duration_ms"`
+	Cost        float64    `json:"cost"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// MetricQuery scopes a metric computation to an organization, a trailing
+// window and optionally a single MCP server or tool and a rule's filters.
+type MetricQuery struct {
+	OrgID     uuid.UUID
+	Metric    AlertMetric
+	Window    time.Duration
+	MCPServer string
+	ToolName  string
+	Filters   AlertFilters
+}
+
+// LatencySummary holds call latency percentiles in milliseconds.
+type LatencySummary struct {
+	MCPServer     string  `json:"mcp_server,omitempty"`
+	ToolName      string  `json:"tool_name,omitempty"`
+	WindowMinutes int     `json:"window_minutes"`
+	Count         int     `json:"count"`
+	P50           float64 `json:"p50"`
+	P90           float64 `json:"p90"`
+	P95           float64 `json:"p95"`
+	P99           float64 `json:"p99"`
+}
+
+// GatewayStats aggregates the dashboard overview counters from several
+// services. A component that failed or timed out is left empty and its error
+// is reported in Errors, so callers always receive whatever was available.
+type GatewayStats struct {
+	SSO         map[string]interface{} `json:"sso,omitempty"`
+	Safety      *SafetySummary         `json:"safety,omitempty"`
+	Approvals   *ApprovalStats         `json:"approvals,omitempty"`
+	Alerts      *AlertStats            `json:"alerts,omitempty"`
+	Telemetry   *TelemetryStats        `json:"telemetry,omitempty"`
+	Concurrency *ConcurrencyStats      `json:"concurrency,omitempty"`
+	Errors      map[string]string      `json:"errors,omitempty"`
+	Partial     bool                   `json:"partial"`
+	GeneratedAt time.Time              `json:"generated_at"`
+}
+
+// ConcurrencyStats reports the requests being served against the
+// concurrency limits, and how many were shed for exceeding them.
+type ConcurrencyStats struct {
+	InFlight int64                   `json:"in_flight"`
+	Limit    int                     `json:"limit"` // 0 means unlimited
+	Shed     int64                   `json:"shed"`
+	Routes   []RouteConcurrencyStats `json:"routes,omitempty"`
+}
+
+// RouteConcurrencyStats reports the requests being served under a path
+// prefix with its own concurrency limit.
+type RouteConcurrencyStats struct {
+	Prefix   string `json:"prefix"`
+	InFlight int64  `json:"in_flight"`
+	Limit    int    `json:"limit"`
+	Shed     int64  `json:"shed"`
+}
+
+// ApprovalStats summarizes the tool approval queue.
+type ApprovalStats struct {
+	PendingCount int `json:"pending_count"`
+}
+
+// AlertStats summarizes alert state.
+type AlertStats struct {
+	ActiveCount int64 `json:"active_count"`
+}
@@ -0,0 +1,104 @@
+package domain
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxSearchQueryLength bounds the length of free-text list search queries.
+const MaxSearchQueryLength = 200
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor marks a position in a list ordered by (created_at DESC, id DESC).
+// The next page contains the rows strictly after the cursor.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// NewCursor creates a cursor positioned at the given row.
+func NewCursor(createdAt time.Time, id uuid.UUID) *Cursor {
+	return &Cursor{CreatedAt: createdAt, ID: id}
+}
+
+// Encode returns the opaque string form of the cursor.
+func (c Cursor) Encode() string {
+	raw := strconv.FormatInt(c.CreatedAt.UnixNano(), 10) + ":" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor parses a cursor produced by Encode.
+func DecodeCursor(s string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	nanos, idStr, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &Cursor{CreatedAt: time.Unix(0, n).UTC(), ID: id}, nil
+}
+
+// Precedes reports whether the cursor comes before the given row in
+// (created_at DESC, id DESC) order, i.e. the row belongs on a later page.
+func (c Cursor) Precedes(createdAt time.Time, id uuid.UUID) bool {
+	if !createdAt.Equal(c.CreatedAt) {
+		return createdAt.Before(c.CreatedAt)
+	}
+	return id.String() < c.ID.String()
+}
+
+// NewerFirst reports whether row a sorts before row b in
+// (created_at DESC, id DESC) order.
+func NewerFirst(aCreatedAt time.Time, aID uuid.UUID, bCreatedAt time.Time, bID uuid.UUID) bool {
+	if !aCreatedAt.Equal(bCreatedAt) {
+		return aCreatedAt.After(bCreatedAt)
+	}
+	return aID.String() > bID.String()
+}
+
+// CursorStart returns the index of the first of n items, already sorted in
+// (created_at DESC, id DESC) order, that falls after the cursor. key returns
+// the ordering columns of the item at index i.
+func CursorStart(n int, cursor *Cursor, key func(i int) (time.Time, uuid.UUID)) int {
+	if cursor == nil {
+		return 0
+	}
+	for i := 0; i < n; i++ {
+		if cursor.Precedes(key(i)) {
+			return i
+		}
+	}
+	return n
+}
+
+// MatchesQuery reports whether any of the fields contains the search query,
+// ignoring case. An empty query matches everything.
+func MatchesQuery(query string, fields ...string) bool {
+	if query == "" {
+		return true
+	}
+	query = strings.ToLower(query)
+	for _, field := range fields {
+		if strings.Contains(strings.ToLower(field), query) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"encoding/base64"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	c := NewCursor(time.Date(2026, 1, 1, 12, 0, 0, 123456789, time.UTC), uuid.New())
+	got, err := DecodeCursor(c.Encode())
+	if err != nil {
+		t.Fatalf("DecodeCursor: %v", err)
+	}
+	if !got.CreatedAt.Equal(c.CreatedAt) || got.ID != c.ID {
+		t.Errorf("decoded %+v, want %+v", got, c)
+	}
+
+	for _, s := range []string{
+		"",
+		"not base64!",
+		base64.RawURLEncoding.EncodeToString([]byte("no separator")),
+		base64.RawURLEncoding.EncodeToString([]byte("soon:" + uuid.NewString())),
+		base64.RawURLEncoding.EncodeToString([]byte("1:not-a-uuid")),
+	} {
+		if _, err := DecodeCursor(s); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("DecodeCursor(%q): err = %v, want ErrInvalidCursor", s, err)
+		}
+	}
+}
+
+func TestCursorPages(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	type row struct {
+		createdAt time.Time
+		id        uuid.UUID
+	}
+	// Rows share timestamps, so the ID breaks ties
+	var rows []row
+	for i := 0; i < 7; i++ {
+		rows = append(rows, row{start.Add(time.Duration(i/3) * time.Minute), uuid.New()})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return NewerFirst(rows[i].createdAt, rows[i].id, rows[j].createdAt, rows[j].id)
+	})
+
+	var seen []uuid.UUID
+	var cursor *Cursor
+	for page := 0; page < 10; page++ {
+		i := CursorStart(len(rows), cursor, func(i int) (time.Time, uuid.UUID) { return rows[i].createdAt, rows[i].id })
+		end := i + 3
+		if end > len(rows) {
+			end = len(rows)
+		}
+		for _, r := range rows[i:end] {
+			seen = append(seen, r.id)
+		}
+		if end == len(rows) {
+			break
+		}
+		last := rows[end-1]
+		cursor, _ = DecodeCursor(NewCursor(last.createdAt, last.id).Encode())
+	}
+
+	if len(seen) != len(rows) {
+		t.Fatalf("paged through %d rows, want %d", len(seen), len(rows))
+	}
+	for i, id := range seen {
+		if id != rows[i].id {
+			t.Errorf("row %d out of order or repeated", i)
+		}
+	}
+}
+
+func TestMatchesQuery(t *testing.T) {
+	tests := []struct {
+		query  string
+		fields []string
+		want   bool
+	}{
+		{"", nil, true},
+		{"shell", []string{"files", "Shell Access"}, true},
+		{"SHELL", []string{"shell"}, true},
+		{"git", []string{"files", "search"}, false},
+	}
+	for _, tt := range tests {
+		if got := MatchesQuery(tt.query, tt.fields...); got != tt.want {
+			t.Errorf("MatchesQuery(%q, %q) = %v, want %v", tt.query, tt.fields, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package domain
+
+import "github.com/google/uuid"
+
+// Quota is what the calling principal can still do right now: the requests
+// left in its rate limit window, the spend left in its budgets, the tool
+// permissions it holds and the approvals it is waiting on.
+type Quota struct {
+	RateLimit            RateLimitQuota   `json:"rate_limit"`
+	Budgets              []BudgetQuota    `json:"budgets"`
+	Permissions          []ToolPermission `json:"permissions"`
+	PendingApprovals     []ToolApproval   `json:"pending_approvals"`      // Most recent first
+	PendingApprovalCount int64            `json:"pending_approval_count"` // Including any not listed
+}
+
+// RateLimitQuota is the principal's use of its rate limit in the current
+// window.
+type RateLimitQuota struct {
+	RequestsPerMinute int  `json:"requests_per_minute"`
+	Used              int  `json:"used"`
+	Remaining         int  `json:"remaining"`
+	Unavailable       bool `json:"unavailable,omitempty"` // Usage could not be read; Used and Remaining are unknown
+}
+
+// BudgetQuota is the spend left in a budget covering the principal's calls.
+type BudgetQuota struct {
+	ID           uuid.UUID    `json:"id"`
+	Name         string       `json:"name"`
+	Period       BudgetPeriod `json:"period"`
+	LimitUSD     float64      `json:"limit_usd"`
+	SpentUSD     float64      `json:"spent_usd"`
+	RemainingUSD float64      `json:"remaining_usd"`
+}
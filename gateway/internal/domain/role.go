@@ -66,6 +66,11 @@ const (
 	// Settings permissions
 	PermissionSettingsRead  Permission = "settings:read"
 	PermissionSettingsAdmin Permission = "settings:admin"
+
+	// PermissionServiceAccount marks an API key as an internal service
+	// account, which rate limit allowlist entries can match. Only an
+	// explicit grant counts; wildcards do not confer it.
+	PermissionServiceAccount Permission = "service_account"
 )
 
 // Role represents a role with a set of permissions.
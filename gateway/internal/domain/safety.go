@@ -13,6 +13,9 @@ const (
 	SafetyModeBlock SafetyMode = "block" // Block the request
 	SafetyModeWarn  SafetyMode = "warn"  // Log and allow
 	SafetyModeLog   SafetyMode = "log"   // Log only
+	// SafetyModeShadow evaluates a policy alongside the enforcing one and
+	// records what it would block, without affecting the request
+	SafetyModeShadow SafetyMode = "shadow"
 )
 
 // SafetySensitivity represents the detection sensitivity level.
@@ -38,20 +41,79 @@ type SafetyPolicy struct {
 	CreatedAt        time.Time              `json:"created_at"`
 	UpdatedAt        time.Time              `json:"updated_at"`
 	CreatedBy        uuid.UUID              `json:"created_by"`
+	DeletedAt        *time.Time             `json:"deleted_at,omitempty"`
 }
 
-// SafetyPatterns defines block and allow patterns for detection.
+// SafetyPatterns defines block and allow patterns for detection. Patterns
+// from the referenced libraries are applied after the inline ones.
 type SafetyPatterns struct {
-	Block []string `json:"block,omitempty"` // Patterns to block
-	Allow []string `json:"allow,omitempty"` // Patterns to allow (override blocks)
+	Block     []string    `json:"block,omitempty"`     // Patterns to block
+	Allow     []string    `json:"allow,omitempty"`     // Patterns to allow (override blocks)
+	Libraries []uuid.UUID `json:"libraries,omitempty"` // Pattern libraries to apply by reference
+}
+
+// PatternCategory classifies the patterns in a pattern library.
+type PatternCategory string
+
+const (
+	PatternCategoryInjection PatternCategory = "injection"
+	PatternCategoryJailbreak PatternCategory = "jailbreak"
+	PatternCategoryPII       PatternCategory = "pii"
+	PatternCategorySecrets   PatternCategory = "secrets"
+)
+
+// ValidPatternCategory reports whether c is a known pattern category.
+func ValidPatternCategory(c PatternCategory) bool {
+	switch c {
+	case PatternCategoryInjection, PatternCategoryJailbreak, PatternCategoryPII, PatternCategorySecrets:
+		return true
+	}
+	return false
+}
+
+// DetectionType returns the type of detection reported when one of the
+// category's patterns matches.
+func (c PatternCategory) DetectionType() DetectionType {
+	switch c {
+	case PatternCategoryPII:
+		return DetectionTypePII
+	case PatternCategorySecrets:
+		return DetectionTypeSecret
+	default:
+		return DetectionTypePromptInjection
+	}
+}
+
+// PatternLibrary is a named set of patterns shared by an organization's
+// safety policies. Policies reference a library by ID, so an update takes
+// effect for every policy that uses it; Version counts the updates.
+type PatternLibrary struct {
+	ID          uuid.UUID       `json:"id"`
+	OrgID       uuid.UUID       `json:"org_id"`
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Category    PatternCategory `json:"category"`
+	Patterns    SafetyPatterns  `json:"patterns"` // Libraries is ignored; libraries do not nest
+	Version     int             `json:"version"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	CreatedBy   uuid.UUID       `json:"created_by"`
+}
+
+// PatternLibraryInput represents input for creating/updating a pattern library.
+type PatternLibraryInput struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Category    PatternCategory `json:"category"`
+	Patterns    SafetyPatterns  `json:"patterns"`
 }
 
 // SafetyPolicyInput represents input for creating/updating a safety policy.
 type SafetyPolicyInput struct {
-	Name        string            `json:"name"`
+	Name        string            `json:"name" validate:"required,max=200"`
 	Description string            `json:"description,omitempty"`
-	Sensitivity SafetySensitivity `json:"sensitivity"`
-	Mode        SafetyMode        `json:"mode"`
+	Sensitivity SafetySensitivity `json:"sensitivity" validate:"oneof=strict moderate permissive"`
+	Mode        SafetyMode        `json:"mode" validate:"oneof=block warn log shadow"`
 	Patterns    SafetyPatterns    `json:"patterns"`
 	MCPServers  []string          `json:"mcp_servers,omitempty"`
 	Enabled     bool              `json:"enabled"`
@@ -105,6 +167,18 @@ type DetectionResult struct {
 	Confidence     float64           `json:"confidence,omitempty"` // 0-1 for ML-based detection
 	Action         SafetyMode        `json:"action"`
 	Message        string            `json:"message,omitempty"`
+	LibraryID      *uuid.UUID        `json:"library_id,omitempty"`      // Pattern library the matched pattern came from
+	LibraryVersion int               `json:"library_version,omitempty"` // Version of that library
+	Escalated      bool              `json:"escalated,omitempty"`       // Blocked because the caller is a repeat offender
+}
+
+// OffenderCount reports how many detections a principal has triggered
+// within the escalation window.
+type OffenderCount struct {
+	Principal  string    `json:"principal"` // "api_key:<id>", or "ip:<address>" for requests without a key
+	Detections int       `json:"detections"`
+	Escalated  bool      `json:"escalated"` // Further detections are blocked
+	LastSeen   time.Time `json:"last_seen"`
 }
 
 // DetectionFilter defines filters for querying detections.
@@ -114,10 +188,13 @@ type DetectionFilter struct {
 	Severities []DetectionSeverity `json:"severities,omitempty"`
 	Actions    []SafetyMode        `json:"actions,omitempty"`
 	MCPServer  string              `json:"mcp_server,omitempty"`
+	TraceID    string              `json:"trace_id,omitempty"`
 	StartTime  *time.Time          `json:"start_time,omitempty"`
 	EndTime    *time.Time          `json:"end_time,omitempty"`
 	Limit      int                 `json:"limit,omitempty"`
 	Offset     int                 `json:"offset,omitempty"`
+	Query      string              `json:"q,omitempty"` // Case-insensitive match on input, pattern and tool
+	Cursor     *Cursor             `json:"-"` // Keyset position; takes precedence over Offset
 }
 
 // DetectionPage represents a paginated list of detections.
@@ -127,6 +204,7 @@ type DetectionPage struct {
 	Limit      int                  `json:"limit"`
 	Offset     int                  `json:"offset"`
 	HasMore    bool                 `json:"has_more"`
+	NextCursor string               `json:"next_cursor,omitempty"`
 }
 
 // SafetyTestRequest represents a request to test safety detection.
@@ -141,6 +219,44 @@ type SafetyTestResponse struct {
 	PolicyID *uuid.UUID      `json:"policy_id,omitempty"`
 }
 
+// SafetyMatchKind is the kind of check that matched input.
+type SafetyMatchKind string
+
+const (
+	SafetyMatchAllow     SafetyMatchKind = "allow"     // An allow pattern; overrides every other match
+	SafetyMatchBlock     SafetyMatchKind = "block"     // A block pattern
+	SafetyMatchHeuristic SafetyMatchKind = "heuristic" // A built-in regular expression
+)
+
+// SafetyMatch is one place where a check matched input. Start and End are
+// byte offsets into the input as lowercased for matching.
+type SafetyMatch struct {
+	Kind           SafetyMatchKind   `json:"kind"`
+	Pattern        string            `json:"pattern"`
+	Start          int               `json:"start"`
+	End            int               `json:"end"`
+	Text           string            `json:"text"`
+	Type           DetectionType     `json:"type,omitempty"`
+	Severity       DetectionSeverity `json:"severity,omitempty"`
+	Message        string            `json:"message,omitempty"`
+	LibraryID      *uuid.UUID        `json:"library_id,omitempty"`
+	LibraryVersion int               `json:"library_version,omitempty"`
+}
+
+// SafetyExplanation shows how a policy treats input: every check that
+// matched it, and the decision the policy reaches with the reasons for it.
+type SafetyExplanation struct {
+	PolicyID          *uuid.UUID        `json:"policy_id,omitempty"`
+	PolicyName        string            `json:"policy_name,omitempty"`
+	PolicyEnabled     bool              `json:"policy_enabled"`
+	Mode              SafetyMode        `json:"mode,omitempty"`
+	Sensitivity       SafetySensitivity `json:"sensitivity,omitempty"`
+	HeuristicsApplied bool              `json:"heuristics_applied"` // Heuristics are skipped at permissive sensitivity
+	Matches           []SafetyMatch     `json:"matches"`
+	Result            DetectionResult   `json:"result"`
+	Reasoning         []string          `json:"reasoning"`
+}
+
 // DefaultBlockPatterns provides default patterns to block.
 var DefaultBlockPatterns = []string{
 	// Common prompt injection patterns
@@ -192,3 +308,64 @@ type PatternCount struct {
 	Pattern string `json:"pattern"`
 	Count   int64  `json:"count"`
 }
+
+// SafetyOffenders ranks the API keys, client IPs, MCP servers and tools
+// safety detections came from over a period, most detections first, with the
+// detections over time for charting. A request detected more than once, as
+// when it is retried, counts once.
+type SafetyOffenders struct {
+	Period  string           `json:"period"` // day, week or month
+	APIKeys []SafetyOffender `json:"api_keys"`
+	IPs     []SafetyOffender `json:"ips"`
+	Servers []SafetyOffender `json:"mcp_servers"`
+	Tools   []SafetyOffender `json:"tools"`
+	Bucket  string           `json:"bucket"` // Width of each series bucket: hour or day
+	Series  []DetectionCount `json:"series"` // Oldest first, including empty buckets
+}
+
+// SafetyOffender is one entry of a top offenders ranking.
+type SafetyOffender struct {
+	Value      string    `json:"value"`                // API key ID, IP address, MCP server or tool name
+	MCPServer  string    `json:"mcp_server,omitempty"` // Server of a tool
+	Detections int64     `json:"detections"`
+	Blocked    int64     `json:"blocked"`
+	LastSeen   time.Time `json:"last_seen"`
+}
+
+// DetectionCount is the number of detections in the bucket starting at Time.
+type DetectionCount struct {
+	Time       time.Time `json:"time"`
+	Detections int64     `json:"detections"`
+}
+
+// SafetyPeriod is a period of safety analytics: how far back it reaches and
+// how wide its series buckets are.
+type SafetyPeriod struct {
+	Name       string // day, week or month
+	Window     time.Duration
+	Bucket     time.Duration
+	BucketName string // hour or day
+}
+
+// ParseSafetyPeriod returns the period named period: a day in hours, or a
+// week or month in days. Unknown periods are treated as a day.
+func ParseSafetyPeriod(period string) SafetyPeriod {
+	switch period {
+	case "week":
+		return SafetyPeriod{Name: period, Window: 7 * 24 * time.Hour, Bucket: 24 * time.Hour, BucketName: "day"}
+	case "month":
+		return SafetyPeriod{Name: period, Window: 30 * 24 * time.Hour, Bucket: 24 * time.Hour, BucketName: "day"}
+	default:
+		return SafetyPeriod{Name: "day", Window: 24 * time.Hour, Bucket: time.Hour, BucketName: "hour"}
+	}
+}
+
+// DetectionSeries lays counts out as a series of bucket-wide buckets from the
+// one containing start to the one containing end, oldest first.
+func DetectionSeries(start, end time.Time, bucket time.Duration, counts map[time.Time]int64) []DetectionCount {
+	series := make([]DetectionCount, 0)
+	for t := start.UTC().Truncate(bucket); !t.After(end); t = t.Add(bucket) {
+		series = append(series, DetectionCount{Time: t, Detections: counts[t]})
+	}
+	return series
+}
@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SettingKey names an organization setting.
+type SettingKey string
+
+const (
+	SettingBudgetEnforcement SettingKey = "budget_enforcement"
+	SettingOutputScanning    SettingKey = "output_scanning"
+)
+
+// SettingType is the type of a setting's value.
+type SettingType string
+
+const (
+	SettingTypeBool SettingType = "bool"
+)
+
+// SettingDefinition describes a setting and the value organizations that
+// have not set it get.
+type SettingDefinition struct {
+	Key         SettingKey  `json:"key"`
+	Type        SettingType `json:"type"`
+	Default     any         `json:"default"`
+	Description string      `json:"description"`
+}
+
+// SettingDefinitions lists every organization setting.
+var SettingDefinitions = []SettingDefinition{
+	{SettingBudgetEnforcement, SettingTypeBool, true, "Block calls that would exceed a hard budget cap; when off, spend is still tracked and alerted on"},
+	{SettingOutputScanning, SettingTypeBool, true, "Scan streamed tool output for prompt injection"},
+}
+
+// LookupSetting returns the definition of a setting.
+func LookupSetting(key SettingKey) (SettingDefinition, bool) {
+	for _, def := range SettingDefinitions {
+		if def.Key == key {
+			return def, true
+		}
+	}
+	return SettingDefinition{}, false
+}
+
+// Valid reports whether value has the setting's type.
+func (d SettingDefinition) Valid(value any) bool {
+	switch d.Type {
+	case SettingTypeBool:
+		_, ok := value.(bool)
+		return ok
+	default:
+		return false
+	}
+}
+
+// OrgSetting is a value an organization has set.
+type OrgSetting struct {
+	OrgID     uuid.UUID  `json:"org_id"`
+	Key       SettingKey `json:"key"`
+	Value     any        `json:"value"`
+	UpdatedBy *uuid.UUID `json:"updated_by,omitempty"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// SettingValue is an organization's effective value for a setting.
+type SettingValue struct {
+	SettingDefinition
+	Value     any        `json:"value"`
+	IsDefault bool       `json:"is_default"` // The organization has not set it
+	UpdatedBy *uuid.UUID `json:"updated_by,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// SettingInput sets a setting's value.
+type SettingInput struct {
+	Value any `json:"value"`
+}
@@ -15,6 +15,27 @@ const (
 	ToolRiskDangerous ToolRiskLevel = "dangerous" // Blocked by default (e.g., execute_command, delete_*)
 )
 
+// ValidToolRiskLevel returns true if the risk level is supported.
+func ValidToolRiskLevel(level ToolRiskLevel) bool {
+	switch level {
+	case ToolRiskSafe, ToolRiskSensitive, ToolRiskDangerous:
+		return true
+	}
+	return false
+}
+
+// AtLeast reports whether the risk level is as high as min or higher.
+// Unknown levels rank below safe.
+func (level ToolRiskLevel) AtLeast(min ToolRiskLevel) bool {
+	return toolRiskRank[level] >= toolRiskRank[min]
+}
+
+var toolRiskRank = map[ToolRiskLevel]int{
+	ToolRiskSafe:      1,
+	ToolRiskSensitive: 2,
+	ToolRiskDangerous: 3,
+}
+
 // ToolClassification represents the risk classification of a specific tool.
 type ToolClassification struct {
 	ID               uuid.UUID     `json:"id"`
@@ -31,13 +52,18 @@ type ToolClassification struct {
 
 // ToolClassificationInput represents input for classifying a tool.
 type ToolClassificationInput struct {
-	MCPServer        string        `json:"mcp_server"`
-	ToolName         string        `json:"tool_name"`
-	Classification   ToolRiskLevel `json:"classification"`
+	MCPServer        string        `json:"mcp_server" validate:"required"`
+	ToolName         string        `json:"tool_name" validate:"required"`
+	Classification   ToolRiskLevel `json:"classification" validate:"oneof=safe sensitive dangerous"`
 	RequiresApproval bool          `json:"requires_approval"`
 	Description      string        `json:"description,omitempty"`
 }
 
+// BulkToolClassificationInput represents input for classifying several tools at once.
+type BulkToolClassificationInput struct {
+	Classifications []ToolClassificationInput `json:"classifications" validate:"required"`
+}
+
 // ApprovalStatus represents the status of a tool approval request.
 type ApprovalStatus string
 
@@ -50,21 +76,24 @@ const (
 
 // ToolApproval represents a request to use a classified tool.
 type ToolApproval struct {
-	ID           uuid.UUID              `json:"id"`
-	OrgID        uuid.UUID              `json:"org_id"`
-	TeamID       *uuid.UUID             `json:"team_id,omitempty"`
-	MCPServer    string                 `json:"mcp_server"`
-	ToolName     string                 `json:"tool_name"`
-	RequestedBy  uuid.UUID              `json:"requested_by"`
-	RequestedAt  time.Time              `json:"requested_at"`
-	Reason       string                 `json:"reason,omitempty"`
-	Arguments    map[string]interface{} `json:"arguments,omitempty"` // Tool arguments for context
-	Status       ApprovalStatus         `json:"status"`
-	ReviewedBy   *uuid.UUID             `json:"reviewed_by,omitempty"`
-	ReviewedAt   *time.Time             `json:"reviewed_at,omitempty"`
-	ReviewNote   string                 `json:"review_note,omitempty"`
-	ExpiresAt    *time.Time             `json:"expires_at,omitempty"` // For time-limited approvals
-	TraceID      string                 `json:"trace_id,omitempty"`
+	ID            uuid.UUID              `json:"id"`
+	OrgID         uuid.UUID              `json:"org_id"`
+	TeamID        *uuid.UUID             `json:"team_id,omitempty"`
+	MCPServer     string                 `json:"mcp_server"`
+	ToolName      string                 `json:"tool_name"`
+	RequestedBy   uuid.UUID              `json:"requested_by"`
+	RequestedAt   time.Time              `json:"requested_at"`
+	Reason        string                 `json:"reason,omitempty"`
+	Arguments     map[string]interface{} `json:"arguments,omitempty"`          // Tool arguments for context
+	Redacted      bool                   `json:"arguments_redacted,omitempty"` // Sensitive arguments were replaced before storing, so the call cannot be replayed
+	Status        ApprovalStatus         `json:"status"`
+	ReviewedBy    *uuid.UUID             `json:"reviewed_by,omitempty"`
+	ReviewedAt    *time.Time             `json:"reviewed_at,omitempty"`
+	ReviewNote    string                 `json:"review_note,omitempty"`
+	ExpiresAt     *time.Time             `json:"expires_at,omitempty"`         // For time-limited approvals
+	AutoApproval  string                 `json:"auto_approval_rule,omitempty"` // Name of the rule that approved the request without review
+	TraceID       string                 `json:"trace_id,omitempty"`
+	EstimatedCost float64                `json:"estimated_cost,omitempty"` // Priced from current rates when served; not stored
 }
 
 // ToolApprovalRequest represents a request to approve a tool use.
@@ -84,6 +113,41 @@ type ToolApprovalReview struct {
 	ExpiresIn  *int           `json:"expires_in,omitempty"` // Duration in seconds
 }
 
+// BulkApprovalReviewInput reviews several approval requests at once: those
+// listed in IDs, or every pending request matching Filter.
+type BulkApprovalReviewInput struct {
+	IDs        []uuid.UUID           `json:"ids,omitempty"`
+	Filter     *ApprovalReviewFilter `json:"filter,omitempty"`
+	Status     ApprovalStatus        `json:"status" validate:"required,oneof=approved denied"`
+	ReviewNote string                `json:"review_note,omitempty"`
+	ExpiresIn  *int                  `json:"expires_in,omitempty"` // Duration in seconds
+}
+
+// ApprovalReviewFilter selects the pending approval requests of a bulk review.
+type ApprovalReviewFilter struct {
+	MCPServer   string     `json:"mcp_server,omitempty"`
+	ToolName    string     `json:"tool_name,omitempty"`
+	TeamID      *uuid.UUID `json:"team_id,omitempty"`
+	RequestedBy *uuid.UUID `json:"requested_by,omitempty"`
+}
+
+// ApprovalReviewOutcome is what a bulk review did with one approval request.
+type ApprovalReviewOutcome string
+
+const (
+	ApprovalReviewApplied         ApprovalReviewOutcome = "reviewed"
+	ApprovalReviewNotFound        ApprovalReviewOutcome = "not_found"
+	ApprovalReviewAlreadyReviewed ApprovalReviewOutcome = "already_reviewed" // No longer pending
+	ApprovalReviewStepUpRequired  ApprovalReviewOutcome = "step_up_required" // Approving the tool needs a recent MFA sign-in
+)
+
+// ApprovalReviewResult is the outcome of a bulk review for one request.
+type ApprovalReviewResult struct {
+	ID       uuid.UUID             `json:"id"`
+	Outcome  ApprovalReviewOutcome `json:"outcome"`
+	Approval *ToolApproval         `json:"approval,omitempty"` // As it stands after the review
+}
+
 // ToolApprovalFilter defines filters for querying tool approvals.
 type ToolApprovalFilter struct {
 	OrgID       uuid.UUID        `json:"org_id"`
@@ -91,18 +155,22 @@ type ToolApprovalFilter struct {
 	MCPServer   string           `json:"mcp_server,omitempty"`
 	ToolName    string           `json:"tool_name,omitempty"`
 	RequestedBy *uuid.UUID       `json:"requested_by,omitempty"`
+	TraceID     string           `json:"trace_id,omitempty"`
 	Statuses    []ApprovalStatus `json:"statuses,omitempty"`
 	Limit       int              `json:"limit,omitempty"`
 	Offset      int              `json:"offset,omitempty"`
+	Query       string           `json:"q,omitempty"` // Case-insensitive match on reason, server and tool
+	Cursor      *Cursor          `json:"-"` // Keyset position; takes precedence over Offset
 }
 
 // ToolApprovalPage represents a paginated list of tool approvals.
 type ToolApprovalPage struct {
-	Approvals []ToolApproval `json:"approvals"`
-	Total     int64          `json:"total"`
-	Limit     int            `json:"limit"`
-	Offset    int            `json:"offset"`
-	HasMore   bool           `json:"has_more"`
+	Approvals  []ToolApproval `json:"approvals"`
+	Total      int64          `json:"total"`
+	Limit      int            `json:"limit"`
+	Offset     int            `json:"offset"`
+	HasMore    bool           `json:"has_more"`
+	NextCursor string         `json:"next_cursor,omitempty"`
 }
 
 // ToolPermission represents a pre-approved permission for a user/team to use a tool.
@@ -149,6 +217,52 @@ var DefaultToolClassifications = map[string]ToolRiskLevel{
 	"admin_action":    ToolRiskDangerous,
 }
 
+// ToolCallDecision describes how the gateway would handle a tool call without executing it.
+type ToolCallDecision struct {
+	CallID           string           `json:"call_id,omitempty"`
+	MCPServer        string           `json:"mcp_server"`
+	ToolName         string           `json:"tool_name,omitempty"`
+	Allowed          bool             `json:"allowed"`
+	Reason           string           `json:"reason,omitempty"`
+	Classification   ToolRiskLevel    `json:"classification,omitempty"`
+	RequiresApproval bool             `json:"requires_approval"`
+	Safety           *DetectionResult `json:"safety,omitempty"`
+	EstimatedCost    float64          `json:"estimated_cost"`
+}
+
+// UnknownToolPolicy decides how an organization treats tools it has not
+// classified.
+type UnknownToolPolicy string
+
+const (
+	UnknownToolDefault  UnknownToolPolicy = "default"  // Classify by the server's default classification, else GetDefaultClassification
+	UnknownToolDeny     UnknownToolPolicy = "deny"     // Block unless explicitly permitted
+	UnknownToolApproval UnknownToolPolicy = "approval" // Treat as sensitive, requiring approval
+	UnknownToolAllow    UnknownToolPolicy = "allow"    // Allow without approval
+)
+
+// ValidUnknownToolPolicy returns true if the policy is supported.
+func ValidUnknownToolPolicy(policy UnknownToolPolicy) bool {
+	switch policy {
+	case UnknownToolDefault, UnknownToolDeny, UnknownToolApproval, UnknownToolAllow:
+		return true
+	}
+	return false
+}
+
+// Classify returns the risk level the policy gives an unclassified tool.
+func (policy UnknownToolPolicy) Classify(toolName string) ToolRiskLevel {
+	switch policy {
+	case UnknownToolDeny:
+		return ToolRiskDangerous
+	case UnknownToolApproval:
+		return ToolRiskSensitive
+	case UnknownToolAllow:
+		return ToolRiskSafe
+	}
+	return GetDefaultClassification(toolName)
+}
+
 // GetDefaultClassification returns the default classification for a tool.
 func GetDefaultClassification(toolName string) ToolRiskLevel {
 	if level, ok := DefaultToolClassifications[toolName]; ok {
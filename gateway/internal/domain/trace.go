@@ -44,10 +44,32 @@ type TraceSpan struct {
 	Attributes map[string]string `json:"attributes,omitempty"`
 }
 
-// TraceDetail includes a trace with all its spans.
+// TraceDetail includes a trace with all its spans, and a timeline of
+// everything recorded under its trace ID.
 type TraceDetail struct {
-	Trace Trace       `json:"trace"`
-	Spans []TraceSpan `json:"spans"`
+	Trace    Trace        `json:"trace"`
+	Spans    []TraceSpan  `json:"spans"`
+	Timeline []TraceEvent `json:"timeline"`
+}
+
+// TraceEventType identifies what a trace timeline event records.
+type TraceEventType string
+
+const (
+	TraceEventCall      TraceEventType = "call"      // An MCP call proxied to a server
+	TraceEventDetection TraceEventType = "detection" // A safety detection on call input
+	TraceEventApproval  TraceEventType = "approval"  // A tool approval requested for the trace
+)
+
+// TraceEvent is one entry in a trace's timeline. The field matching Type
+// holds the record; the others are nil.
+type TraceEvent struct {
+	Type      TraceEventType      `json:"type"`
+	Time      time.Time           `json:"time"`
+	SpanID    string              `json:"span_id,omitempty"`
+	Call      *Trace              `json:"call,omitempty"`
+	Detection *InjectionDetection `json:"detection,omitempty"`
+	Approval  *ToolApproval       `json:"approval,omitempty"`
 }
 
 // TraceFilter represents filters for querying traces.
@@ -57,6 +79,7 @@ type TraceFilter struct {
 	MCPServer string     `json:"mcp_server,omitempty"`
 	Operation string     `json:"operation,omitempty"`
 	Status    string     `json:"status,omitempty"`
+	TraceID   string     `json:"trace_id,omitempty"`
 	StartTime *time.Time `json:"start_time,omitempty"`
 	EndTime   *time.Time `json:"end_time,omitempty"`
 	Limit     int        `json:"limit,omitempty"`
@@ -37,6 +37,10 @@ type UserSession struct {
 	OrgID          uuid.UUID `json:"org_id"`
 	AccessToken    string    `json:"-"` // Never serialize
 	RefreshToken   string    `json:"-"` // Never serialize
+	TokenID        string    `json:"-"` // ID of the current access token, for revocation
+	ACR            string    `json:"acr,omitempty"`
+	AMR            []string  `json:"amr,omitempty"`
+	AuthTime       time.Time `json:"auth_time"` // When the user last actively authenticated
 	ExpiresAt      time.Time `json:"expires_at"`
 	LastActivityAt time.Time `json:"last_activity_at"`
 	IPAddress      string    `json:"ip_address,omitempty"`
@@ -44,6 +48,45 @@ type UserSession struct {
 	CreatedAt      time.Time `json:"created_at"`
 }
 
+// mfaMethods are the RFC 8176 authentication method references that show a
+// login went beyond a password.
+var mfaMethods = map[string]bool{
+	"mfa": true, "otp": true, "hwk": true, "swk": true, "sc": true,
+	"fpt": true, "face": true, "iris": true, "retina": true, "vbm": true,
+}
+
+// MFAVerified reports whether the session was established with multi-factor
+// authentication: its amr claim names an MFA method, or its acr claim is one
+// of acrValues.
+func (s *UserSession) MFAVerified(acrValues []string) bool {
+	for _, method := range s.AMR {
+		if mfaMethods[method] {
+			return true
+		}
+	}
+	for _, acr := range acrValues {
+		if s.ACR != "" && s.ACR == acr {
+			return true
+		}
+	}
+	return false
+}
+
+// LoginAnomalyType identifies why a login looks suspicious.
+type LoginAnomalyType string
+
+const (
+	LoginAnomalyImpossibleTravel   LoginAnomalyType = "impossible_travel"
+	LoginAnomalyNewDevice          LoginAnomalyType = "new_device"
+	LoginAnomalyConcurrentSessions LoginAnomalyType = "concurrent_sessions"
+)
+
+// LoginAnomaly is a suspicious pattern found in an SSO login.
+type LoginAnomaly struct {
+	Type    LoginAnomalyType `json:"type"`
+	Message string           `json:"message"`
+}
+
 // SSOProviderType represents the type of SSO provider.
 type SSOProviderType string
 
@@ -71,6 +114,7 @@ type SSOProvider struct {
 	Scopes                []string               `json:"scopes"`
 	ClaimMappings         map[string]string      `json:"claim_mappings,omitempty"`
 	GroupMappings         map[string]string      `json:"group_mappings,omitempty"` // SSO group -> Role name
+	GroupNames            map[string]string      `json:"group_names,omitempty"`    // Group object ID -> name, for IdPs such as Azure AD that send IDs
 	Enabled               bool                   `json:"enabled"`
 	CreatedAt             time.Time              `json:"created_at"`
 	UpdatedAt             time.Time              `json:"updated_at"`
@@ -78,14 +122,15 @@ type SSOProvider struct {
 
 // SSOProviderInput represents input for creating/updating an SSO provider.
 type SSOProviderInput struct {
-	Type          SSOProviderType   `json:"type"`
-	Name          string            `json:"name"`
-	IssuerURL     string            `json:"issuer_url"`
-	ClientID      string            `json:"client_id"`
-	ClientSecret  string            `json:"client_secret"`
+	Type          SSOProviderType   `json:"type" validate:"required,oneof=okta azure_ad google onelogin auth0 oidc"`
+	Name          string            `json:"name" validate:"required,max=200"`
+	IssuerURL     string            `json:"issuer_url" validate:"required,url"`
+	ClientID      string            `json:"client_id" validate:"required"`
+	ClientSecret  string            `json:"client_secret" validate:"required"`
 	Scopes        []string          `json:"scopes,omitempty"`
 	ClaimMappings map[string]string `json:"claim_mappings,omitempty"`
 	GroupMappings map[string]string `json:"group_mappings,omitempty"`
+	GroupNames    map[string]string `json:"group_names,omitempty"`
 	Enabled       bool              `json:"enabled"`
 }
 
@@ -97,6 +142,10 @@ type OIDCClaims struct {
 	Name          string   `json:"name"`
 	Picture       string   `json:"picture,omitempty"`
 	Groups        []string `json:"groups,omitempty"`
+	ACR           string   `json:"acr,omitempty"`       // Authentication context class achieved
+	AMR           []string `json:"amr,omitempty"`       // Authentication methods used
+	AuthTime      int64    `json:"auth_time,omitempty"` // Unix time the user actively authenticated
+	Nonce         string   `json:"nonce,omitempty"`     // Echoes the nonce of the authorization request
 }
 
 // AuthState represents OAuth state for CSRF protection.
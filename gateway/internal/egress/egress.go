@@ -0,0 +1,238 @@
+// Package egress decides which network destinations the gateway may reach on
+// behalf of callers, so an MCP server URL cannot be pointed at internal
+// services such as a cloud metadata endpoint.
+package egress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ErrDenied is wrapped by every error for a destination the policy refuses.
+var ErrDenied = errors.New("destination is not allowed by the egress policy")
+
+// DefaultDeny are the ranges refused unless the deny list is configured:
+// link-local addresses, where cloud metadata services such as
+// 169.254.169.254 live, and the metadata addresses outside them.
+var DefaultDeny = []string{
+	"169.254.0.0/16",
+	"fe80::/10",
+	"100.100.100.200/32",
+	"fd00:ec2::254/128",
+}
+
+// Policy decides which hosts may be reached. A destination is refused when
+// its host name or any address it resolves to is denied; when anything is
+// allowed, it is also refused unless its host name or the address connected
+// to is allowed. Deny entries win over allow entries. The zero Policy
+// allows everything.
+type Policy struct {
+	allowHosts []string
+	allowNets  []*net.IPNet
+	denyHosts  []string
+	denyNets   []*net.IPNet
+}
+
+// New creates a policy from allow and deny lists. Each entry is an IP
+// address, a CIDR block, a host name, or a host name pattern such as
+// *.example.com that matches its subdomains.
+func New(allow, deny []string) (*Policy, error) {
+	p := &Policy{}
+	var err error
+	if p.allowHosts, p.allowNets, err = parseEntries(allow); err != nil {
+		return nil, fmt.Errorf("allow: %w", err)
+	}
+	if p.denyHosts, p.denyNets, err = parseEntries(deny); err != nil {
+		return nil, fmt.Errorf("deny: %w", err)
+	}
+	return p, nil
+}
+
+// CheckURL reports whether the destination of rawURL may be reached, as far
+// as can be told without resolving its host: it returns an error wrapping
+// ErrDenied for a denied host name or IP address. Where a host name resolves
+// to is checked on every connection made through DialContext, so the answer
+// cannot change between the check and the connection.
+func (p *Policy) CheckURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDenied, err)
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "" {
+		return fmt.Errorf("%w: %q has no host", ErrDenied, rawURL)
+	}
+	allowedByName, err := p.checkName(host)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return p.checkIPs(host, []net.IP{ip}, allowedByName)
+	}
+	return nil
+}
+
+// DialContext connects to addr like net.Dialer.DialContext, after checking
+// that the policy allows the host and every address it resolves to. The
+// addresses checked are the ones dialed, so DNS cannot send the connection
+// anywhere else.
+func (p *Policy) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	allowedByName, err := p.checkName(host)
+	if err != nil {
+		return nil, err
+	}
+	ips, err := lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.checkIPs(host, ips, allowedByName); err != nil {
+		return nil, err
+	}
+
+	var dialer net.Dialer
+	var lastErr error
+	for _, ip := range ips {
+		conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// Transport returns an HTTP transport like http.DefaultTransport whose
+// connections are checked against the policy.
+func (p *Policy) Transport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = p.DialContext
+	return transport
+}
+
+// checkName checks a host name against the policy, reporting whether it is
+// allowed by name.
+func (p *Policy) checkName(host string) (bool, error) {
+	if p == nil {
+		return true, nil
+	}
+	if pattern, ok := matchHost(p.denyHosts, host); ok {
+		return false, fmt.Errorf("%w: %s matches denied host %s", ErrDenied, host, pattern)
+	}
+	_, ok := matchHost(p.allowHosts, host)
+	return ok, nil
+}
+
+// checkIPs checks the addresses host resolves to against the policy. Denied
+// ranges apply even to hosts allowed by name.
+func (p *Policy) checkIPs(host string, ips []net.IP, allowedByName bool) error {
+	if p == nil {
+		return nil
+	}
+	for _, ip := range ips {
+		n, ok := matchIP(p.denyNets, ip)
+		switch {
+		case ok && ip.String() == host:
+			return fmt.Errorf("%w: %s is in denied range %s", ErrDenied, host, n)
+		case ok:
+			return fmt.Errorf("%w: %s resolves to %s, in denied range %s", ErrDenied, host, ip, n)
+		}
+	}
+	if allowedByName || !p.restricted() {
+		return nil
+	}
+	for _, ip := range ips {
+		if _, ok := matchIP(p.allowNets, ip); !ok {
+			return fmt.Errorf("%w: %s is not on the allowlist", ErrDenied, host)
+		}
+	}
+	return nil
+}
+
+// restricted reports whether only allowed destinations may be reached.
+func (p *Policy) restricted() bool {
+	return len(p.allowHosts) > 0 || len(p.allowNets) > 0
+}
+
+// lookup returns the addresses of host, which may be an IP address.
+func lookup(ctx context.Context, host string) ([]net.IP, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IP{ip}, nil
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	ips := make([]net.IP, len(addrs))
+	for i, addr := range addrs {
+		ips[i] = addr.IP
+	}
+	return ips, nil
+}
+
+// parseEntries splits policy entries into host names and networks.
+func parseEntries(entries []string) ([]string, []*net.IPNet, error) {
+	var hosts []string
+	var nets []*net.IPNet
+	for _, entry := range entries {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		switch {
+		case entry == "":
+			continue
+		case strings.Contains(entry, "/"):
+			_, n, err := net.ParseCIDR(entry)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%q is not a CIDR block", entry)
+			}
+			nets = append(nets, n)
+		case net.ParseIP(entry) != nil:
+			ip := net.ParseIP(entry)
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+		default:
+			name := strings.TrimPrefix(entry, "*.")
+			if name == "" || strings.ContainsAny(name, "*:") {
+				return nil, nil, fmt.Errorf("%q is not a host name, IP address or CIDR block", entry)
+			}
+			hosts = append(hosts, entry)
+		}
+	}
+	return hosts, nets, nil
+}
+
+// matchHost returns the first pattern host matches: the name itself, or a
+// *.name pattern for a subdomain of name.
+func matchHost(patterns []string, host string) (string, bool) {
+	for _, pattern := range patterns {
+		if suffix, ok := strings.CutPrefix(pattern, "*"); ok {
+			if strings.HasSuffix(host, suffix) {
+				return pattern, true
+			}
+		} else if host == pattern {
+			return pattern, true
+		}
+	}
+	return "", false
+}
+
+// matchIP returns the first network containing ip.
+func matchIP(nets []*net.IPNet, ip net.IP) (*net.IPNet, bool) {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return n, true
+		}
+	}
+	return nil, false
+}
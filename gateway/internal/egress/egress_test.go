@@ -0,0 +1,115 @@
+package egress
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestCheckURL(t *testing.T) {
+	tests := []struct {
+		name       string
+		allow      []string
+		deny       []string
+		url        string
+		wantDenied bool
+	}{
+		{name: "no policy", url: "http://169.254.169.254/latest/meta-data"},
+		{name: "metadata address", deny: DefaultDeny, url: "http://169.254.169.254/latest/meta-data", wantDenied: true},
+		{name: "metadata over ipv6", deny: DefaultDeny, url: "http://[fd00:ec2::254]/", wantDenied: true},
+		{name: "public address", deny: DefaultDeny, url: "https://203.0.113.10/mcp"},
+		{name: "denied host", deny: []string{"internal.example.com"}, url: "https://internal.example.com/mcp", wantDenied: true},
+		{name: "denied host any case", deny: []string{"internal.example.com"}, url: "https://Internal.Example.com./mcp", wantDenied: true},
+		{name: "denied subdomain", deny: []string{"*.corp"}, url: "https://db.eu.corp/mcp", wantDenied: true},
+		{name: "allowed subdomain", allow: []string{"*.example.com"}, url: "https://mcp.example.com/"},
+		{name: "deny wins over allow", allow: []string{"*.example.com"}, deny: []string{"admin.example.com"}, url: "https://admin.example.com/", wantDenied: true},
+		{name: "allowed range", allow: []string{"10.1.0.0/16"}, url: "http://10.1.2.3:8080/"},
+		{name: "address outside allowlist", allow: []string{"10.1.0.0/16"}, url: "http://10.2.0.1/", wantDenied: true},
+		{name: "denied range inside allowed range", allow: []string{"10.0.0.0/8"}, deny: []string{"10.9.0.0/16"}, url: "http://10.9.0.1/", wantDenied: true},
+		// Unresolved names are left to DialContext
+		{name: "name under an address allowlist", allow: []string{"10.1.0.0/16"}, url: "https://mcp.example.com/"},
+		{name: "no host", url: "file:///etc/passwd", wantDenied: true},
+		{name: "unparsable", url: "http://[::1", wantDenied: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(tt.allow, tt.deny)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			err = p.CheckURL(tt.url)
+			if denied := errors.Is(err, ErrDenied); denied != tt.wantDenied {
+				t.Errorf("CheckURL(%q) = %v, want denied %v", tt.url, err, tt.wantDenied)
+			}
+		})
+	}
+}
+
+func TestNilPolicyAllowsEverything(t *testing.T) {
+	var p *Policy
+	if err := p.CheckURL("http://169.254.169.254/"); err != nil {
+		t.Errorf("CheckURL on a nil policy: %v", err)
+	}
+}
+
+func TestNewRejectsBadEntries(t *testing.T) {
+	for _, entry := range []string{"10.0.0.0/33", "*", "*.", "host:80", "a.*.com"} {
+		if _, err := New([]string{entry}, nil); err == nil {
+			t.Errorf("New accepted allow entry %q", entry)
+		}
+		if _, err := New(nil, []string{entry}); err == nil {
+			t.Errorf("New accepted deny entry %q", entry)
+		}
+	}
+	if _, err := New([]string{" example.com ", "", "::1"}, nil); err != nil {
+		t.Errorf("New with valid entries: %v", err)
+	}
+}
+
+// TestTransportChecksResolvedAddresses connects to a local server by name,
+// so the policy can only refuse it by what the name resolves to.
+func TestTransportChecksResolvedAddresses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatalf("parse %s: %v", srv.URL, err)
+	}
+	byName := "http://localhost:" + u.Port() + "/"
+
+	tests := []struct {
+		name       string
+		allow      []string
+		deny       []string
+		wantDenied bool
+	}{
+		{name: "no restrictions"},
+		{name: "resolves to a denied range", deny: []string{"127.0.0.0/8", "::1"}, wantDenied: true},
+		{name: "allowed by name", allow: []string{"localhost"}},
+		{name: "allowed by name but resolves to a denied range", allow: []string{"localhost"}, deny: []string{"127.0.0.0/8", "::1"}, wantDenied: true},
+		{name: "resolves outside the allowlist", allow: []string{"10.0.0.0/8"}, wantDenied: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(tt.allow, tt.deny)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			if err := p.CheckURL(byName); err != nil {
+				t.Fatalf("CheckURL refused a name it cannot resolve: %v", err)
+			}
+			client := &http.Client{Transport: p.Transport()}
+			resp, err := client.Get(byName)
+			if err == nil {
+				resp.Body.Close()
+			}
+			if denied := errors.Is(err, ErrDenied); denied != tt.wantDenied {
+				t.Errorf("GET %s = %v, want denied %v", byName, err, tt.wantDenied)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package email
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/rs/zerolog"
+)
+
+// ApprovalNotifier emails reviewers when a tool approval is requested.
+type ApprovalNotifier struct {
+	logger zerolog.Logger
+	sender *Sender
+	config Config
+}
+
+// NewApprovalNotifier creates a notifier that mails config.To.
+func NewApprovalNotifier(logger zerolog.Logger, sender *Sender, config Config) *ApprovalNotifier {
+	return &ApprovalNotifier{
+		logger: logger,
+		sender: sender,
+		config: config,
+	}
+}
+
+// NotifyApprovalRequested sends the review request asynchronously.
+func (n *ApprovalNotifier) NotifyApprovalRequested(approval domain.ToolApproval) {
+	msg := Message{
+		Subject:  fmt.Sprintf("[GatewayOps] Approval needed: %s/%s", approval.MCPServer, approval.ToolName),
+		Title:    fmt.Sprintf("Approval requested for %s on %s", approval.ToolName, approval.MCPServer),
+		Severity: "warning",
+		Text:     approval.Reason,
+		Fields: []Field{
+			{Name: "Server", Value: approval.MCPServer},
+			{Name: "Tool", Value: approval.ToolName},
+			{Name: "Requested by", Value: approval.RequestedBy.String()},
+			{Name: "Requested at", Value: approval.RequestedAt.UTC().Format(time.RFC1123)},
+		},
+		Link:     "https://app.gatewayops.com/approvals",
+		LinkText: "Review in GatewayOps",
+	}
+	if len(approval.Arguments) > 0 {
+		if args, err := json.Marshal(approval.Arguments); err == nil {
+			msg.Fields = append(msg.Fields, Field{Name: "Arguments", Value: string(args)})
+		}
+	}
+	if approval.TraceID != "" {
+		msg.Fields = append(msg.Fields, Field{Name: "Trace ID", Value: approval.TraceID})
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := n.sender.Send(ctx, n.config, msg); err != nil {
+			n.logger.Error().
+				Err(err).
+				Str("approval_id", approval.ID.String()).
+				Msg("Failed to send approval notification email")
+		}
+	}()
+}
@@ -0,0 +1,372 @@
+// Package email sends notification emails over SMTP.
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TLSMode selects how the SMTP connection is secured.
+type TLSMode string
+
+const (
+	// TLSModeStartTLS upgrades a plain connection with STARTTLS and fails if
+	// the server does not offer it.
+	TLSModeStartTLS TLSMode = "starttls"
+	// TLSModeImplicit connects over TLS from the start (usually port 465).
+	TLSModeImplicit TLSMode = "tls"
+	// TLSModeNone sends in clear text; only for local relays.
+	TLSModeNone TLSMode = "none"
+)
+
+// Config holds SMTP delivery settings.
+type Config struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	To       []string
+	ReplyTo  string
+	TLS      TLSMode
+}
+
+// Validate reports the first missing or invalid setting.
+func (c Config) Validate() error {
+	if c.Host == "" {
+		return fmt.Errorf("host is required")
+	}
+	if c.Port < 1 || c.Port > 65535 {
+		return fmt.Errorf("port %d is not valid", c.Port)
+	}
+	if c.From == "" {
+		return fmt.Errorf("from is required")
+	}
+	if len(c.To) == 0 {
+		return fmt.Errorf("at least one recipient is required")
+	}
+	switch c.TLS {
+	case TLSModeStartTLS, TLSModeImplicit, TLSModeNone:
+	default:
+		return fmt.Errorf("tls must be starttls, tls or none")
+	}
+	return nil
+}
+
+// ConfigFromChannel builds a Config from an alert channel's config map. It
+// accepts "to" as a list or a comma-separated string and "port" as a number
+// or string.
+func ConfigFromChannel(config map[string]interface{}) (Config, error) {
+	cfg := Config{
+		Host:     stringValue(config["host"]),
+		Username: stringValue(config["username"]),
+		Password: stringValue(config["password"]),
+		From:     stringValue(config["from"]),
+		ReplyTo:  stringValue(config["reply_to"]),
+		TLS:      TLSMode(stringValue(config["tls"])),
+		Port:     587,
+	}
+	if cfg.TLS == "" {
+		cfg.TLS = TLSModeStartTLS
+	}
+
+	switch port := config["port"].(type) {
+	case float64:
+		cfg.Port = int(port)
+	case int:
+		cfg.Port = port
+	case string:
+		n, err := strconv.Atoi(port)
+		if err != nil {
+			return cfg, fmt.Errorf("port %q is not a number", port)
+		}
+		cfg.Port = n
+	}
+
+	switch to := config["to"].(type) {
+	case []interface{}:
+		for _, addr := range to {
+			if s := stringValue(addr); s != "" {
+				cfg.To = append(cfg.To, s)
+			}
+		}
+	case []string:
+		cfg.To = append(cfg.To, to...)
+	case string:
+		cfg.To = splitAddresses(to)
+	}
+
+	return cfg, cfg.Validate()
+}
+
+// splitAddresses parses a comma-separated recipient list.
+func splitAddresses(list string) []string {
+	var addrs []string
+	for _, addr := range strings.Split(list, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs
+}
+
+func stringValue(v interface{}) string {
+	s, _ := v.(string)
+	return strings.TrimSpace(s)
+}
+
+// Field is a labelled value shown in the message body.
+type Field struct {
+	Name  string
+	Value string
+}
+
+// Message is a notification to render as a multipart email.
+type Message struct {
+	Subject  string
+	Title    string
+	Severity string // info, warning, critical; controls the accent color
+	Text     string
+	Fields   []Field
+	Link     string
+	LinkText string
+}
+
+// Sender delivers messages over SMTP.
+type Sender struct {
+	timeout time.Duration
+}
+
+// NewSender creates a new SMTP sender.
+func NewSender() *Sender {
+	return &Sender{timeout: 30 * time.Second}
+}
+
+// Send composes msg and delivers it to every recipient in cfg.
+func (s *Sender) Send(ctx context.Context, cfg Config, msg Message) error {
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid smtp config: %w", err)
+	}
+
+	body, err := Compose(cfg, msg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("dial smtp: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	tlsConfig := &tls.Config{ServerName: cfg.Host}
+	if cfg.TLS == TLSModeImplicit {
+		conn = tls.Client(conn, tlsConfig)
+	}
+
+	client, err := smtp.NewClient(conn, cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp handshake: %w", err)
+	}
+	defer client.Close()
+
+	if cfg.TLS == TLSModeStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("smtp server does not support STARTTLS")
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("starttls: %w", err)
+		}
+	}
+
+	if cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)); err != nil {
+			return fmt.Errorf("smtp auth: %w", err)
+		}
+	}
+
+	if err := client.Mail(cfg.From); err != nil {
+		return fmt.Errorf("smtp mail from: %w", err)
+	}
+	for _, to := range cfg.To {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("smtp rcpt %s: %w", to, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp data: %w", err)
+	}
+	if _, err := w.Write(body); err != nil {
+		return fmt.Errorf("write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp data: %w", err)
+	}
+
+	return client.Quit()
+}
+
+// Compose renders msg as a multipart/alternative MIME message with a plain
+// text and an HTML part.
+func Compose(cfg Config, msg Message, now time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+
+	header := func(key, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", key, value)
+	}
+	header("From", cfg.From)
+	header("To", strings.Join(cfg.To, ", "))
+	if cfg.ReplyTo != "" {
+		header("Reply-To", cfg.ReplyTo)
+	}
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", now.Format(time.RFC1123Z))
+	header("Message-ID", messageID(cfg.From))
+	header("MIME-Version", "1.0")
+	header("Content-Type", "multipart/alternative; boundary="+mw.Boundary())
+	buf.WriteString("\r\n")
+
+	htmlBody, err := renderHTML(msg)
+	if err != nil {
+		return nil, fmt.Errorf("render html: %w", err)
+	}
+
+	for _, part := range []struct {
+		contentType string
+		body        string
+	}{
+		{"text/plain; charset=utf-8", renderText(msg)},
+		{"text/html; charset=utf-8", htmlBody},
+	} {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("create part: %w", err)
+		}
+		qp := quotedprintable.NewWriter(pw)
+		if _, err := qp.Write([]byte(part.body)); err != nil {
+			return nil, fmt.Errorf("write part: %w", err)
+		}
+		if err := qp.Close(); err != nil {
+			return nil, fmt.Errorf("write part: %w", err)
+		}
+	}
+
+	if err := mw.Close(); err != nil {
+		return nil, fmt.Errorf("close multipart: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func messageID(from string) string {
+	domain := "gatewayops.local"
+	if i := strings.LastIndex(from, "@"); i >= 0 {
+		domain = strings.Trim(from[i+1:], "> ")
+	}
+	b := make([]byte, 12)
+	rand.Read(b)
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">"
+}
+
+func renderText(msg Message) string {
+	var b strings.Builder
+	if msg.Severity != "" {
+		fmt.Fprintf(&b, "[%s] ", strings.ToUpper(msg.Severity))
+	}
+	b.WriteString(msg.Title)
+	b.WriteString("\r\n\r\n")
+	if msg.Text != "" {
+		b.WriteString(msg.Text)
+		b.WriteString("\r\n\r\n")
+	}
+	for _, f := range msg.Fields {
+		fmt.Fprintf(&b, "%s: %s\r\n", f.Name, f.Value)
+	}
+	if msg.Link != "" {
+		fmt.Fprintf(&b, "\r\n%s: %s\r\n", linkText(msg), msg.Link)
+	}
+	b.WriteString("\r\n-- \r\nGatewayOps\r\n")
+	return b.String()
+}
+
+// severityColor returns the accent color for a severity level.
+func severityColor(severity string) string {
+	switch severity {
+	case "critical":
+		return "#dc3545"
+	case "warning":
+		return "#ffc107"
+	case "info":
+		return "#17a2b8"
+	case "resolved":
+		return "#36a64f"
+	default:
+		return "#6c757d"
+	}
+}
+
+func linkText(msg Message) string {
+	if msg.LinkText != "" {
+		return msg.LinkText
+	}
+	return "View in GatewayOps"
+}
+
+var htmlTemplate = template.Must(template.New("email").Parse(`<!DOCTYPE html>
+<html>
+<body style="margin:0;padding:24px;background:#f5f5f5;font-family:-apple-system,Segoe UI,Helvetica,Arial,sans-serif;color:#212529;">
+<table role="presentation" width="100%" style="max-width:600px;margin:0 auto;background:#ffffff;border-top:4px solid {{.Color}};">
+<tr><td style="padding:20px 24px;">
+{{if .Severity}}<span style="display:inline-block;padding:2px 8px;border-radius:3px;background:{{.Color}};color:#ffffff;font-size:12px;font-weight:bold;text-transform:uppercase;">{{.Severity}}</span>{{end}}
+<h2 style="margin:12px 0 8px;font-size:18px;">{{.Title}}</h2>
+{{if .Text}}<p style="margin:0 0 16px;line-height:1.5;">{{.Text}}</p>{{end}}
+{{if .Fields}}<table role="presentation" style="border-collapse:collapse;font-size:14px;">
+{{range .Fields}}<tr><td style="padding:4px 16px 4px 0;color:#6c757d;">{{.Name}}</td><td style="padding:4px 0;">{{.Value}}</td></tr>
+{{end}}</table>{{end}}
+{{if .Link}}<p style="margin:20px 0 0;"><a href="{{.Link}}" style="color:{{.Color}};">{{.LinkText}}</a></p>{{end}}
+</td></tr>
+<tr><td style="padding:12px 24px;font-size:12px;color:#6c757d;border-top:1px solid #eeeeee;">GatewayOps</td></tr>
+</table>
+</body>
+</html>
+`))
+
+func renderHTML(msg Message) (string, error) {
+	var buf bytes.Buffer
+	err := htmlTemplate.Execute(&buf, struct {
+		Message
+		Color    string
+		LinkText string
+	}{msg, severityColor(msg.Severity), linkText(msg)})
+	return buf.String(), err
+}
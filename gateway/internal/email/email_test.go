@@ -0,0 +1,255 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfigFromChannel(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  map[string]interface{}
+		want    Config
+		wantErr bool
+	}{
+		{
+			name:   "defaults",
+			config: map[string]interface{}{"host": "smtp.example.com", "from": "ops@example.com", "to": "a@example.com, b@example.com"},
+			want:   Config{Host: "smtp.example.com", Port: 587, From: "ops@example.com", To: []string{"a@example.com", "b@example.com"}, TLS: TLSModeStartTLS},
+		},
+		{
+			name:   "json list and numeric port",
+			config: map[string]interface{}{"host": "smtp.example.com", "port": float64(465), "tls": "tls", "from": "ops@example.com", "to": []interface{}{"a@example.com", ""}},
+			want:   Config{Host: "smtp.example.com", Port: 465, From: "ops@example.com", To: []string{"a@example.com"}, TLS: TLSModeImplicit},
+		},
+		{
+			name:   "string port",
+			config: map[string]interface{}{"host": "relay", "port": "25", "tls": "none", "from": "ops@example.com", "to": "a@example.com"},
+			want:   Config{Host: "relay", Port: 25, From: "ops@example.com", To: []string{"a@example.com"}, TLS: TLSModeNone},
+		},
+		{name: "bad port", config: map[string]interface{}{"host": "relay", "port": "smtp", "from": "ops@example.com", "to": "a@example.com"}, wantErr: true},
+		{name: "port out of range", config: map[string]interface{}{"host": "relay", "port": float64(70000), "from": "ops@example.com", "to": "a@example.com"}, wantErr: true},
+		{name: "no host", config: map[string]interface{}{"from": "ops@example.com", "to": "a@example.com"}, wantErr: true},
+		{name: "no recipients", config: map[string]interface{}{"host": "relay", "from": "ops@example.com", "to": " , "}, wantErr: true},
+		{name: "unknown tls mode", config: map[string]interface{}{"host": "relay", "tls": "ssl", "from": "ops@example.com", "to": "a@example.com"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ConfigFromChannel(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Host != tt.want.Host || got.Port != tt.want.Port || got.From != tt.want.From ||
+				got.TLS != tt.want.TLS || strings.Join(got.To, ",") != strings.Join(tt.want.To, ",") {
+				t.Errorf("config = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// parts parses a composed message and returns its headers and decoded
+// parts by content type.
+func parts(t *testing.T, raw []byte) (mail.Header, map[string]string) {
+	t.Helper()
+	m, err := mail.ReadMessage(strings.NewReader(string(raw)))
+	if err != nil {
+		t.Fatalf("read message: %v", err)
+	}
+	mediaType, params, err := mime.ParseMediaType(m.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q, %v", m.Header.Get("Content-Type"), err)
+	}
+	bodies := make(map[string]string)
+	mr := multipart.NewReader(m.Body, params["boundary"])
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("next part: %v", err)
+		}
+		// multipart.Reader decodes quoted-printable parts itself
+		body, err := io.ReadAll(p)
+		if err != nil {
+			t.Fatalf("read part: %v", err)
+		}
+		contentType, _, _ := mime.ParseMediaType(p.Header.Get("Content-Type"))
+		bodies[contentType] = string(body)
+	}
+	return m.Header, bodies
+}
+
+func TestCompose(t *testing.T) {
+	cfg := Config{From: "GatewayOps <ops@example.com>", To: []string{"a@example.com", "b@example.com"}, ReplyTo: "oncall@example.com"}
+	msg := Message{
+		Subject:  "Alert: error rate über 5%",
+		Title:    "Error rate <script>alert(1)</script>",
+		Severity: "critical",
+		Text:     "Error rate is above the threshold",
+		Fields:   []Field{{Name: "Value", Value: "12.00"}},
+		Link:     "https://app.gatewayops.com/alerts",
+	}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	raw, err := Compose(cfg, msg, now)
+	if err != nil {
+		t.Fatalf("Compose: %v", err)
+	}
+	header, bodies := parts(t, raw)
+
+	if got := header.Get("To"); got != "a@example.com, b@example.com" {
+		t.Errorf("To = %q", got)
+	}
+	if got := header.Get("Reply-To"); got != cfg.ReplyTo {
+		t.Errorf("Reply-To = %q", got)
+	}
+	if subject, err := new(mime.WordDecoder).DecodeHeader(header.Get("Subject")); err != nil || subject != msg.Subject {
+		t.Errorf("Subject = %q, %v", subject, err)
+	}
+	if date, err := header.Date(); err != nil || !date.Equal(now) {
+		t.Errorf("Date = %v, %v", date, err)
+	}
+	if id := header.Get("Message-ID"); !strings.HasSuffix(id, "@example.com>") {
+		t.Errorf("Message-ID = %q, want the sender's domain", id)
+	}
+
+	text := bodies["text/plain"]
+	for _, want := range []string{"[CRITICAL] Error rate", "Value: 12.00", "View in GatewayOps: https://app.gatewayops.com/alerts"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("text part lacks %q:\n%s", want, text)
+		}
+	}
+	html := bodies["text/html"]
+	if strings.Contains(html, "<script>") || !strings.Contains(html, "&lt;script&gt;") {
+		t.Error("html part does not escape the title")
+	}
+	if !strings.Contains(html, severityColor("critical")) || !strings.Contains(html, `href="https://app.gatewayops.com/alerts"`) {
+		t.Error("html part lacks the severity color or link")
+	}
+
+	// Encoded lines stay within the SMTP line limit
+	for _, line := range strings.Split(string(raw), "\r\n") {
+		if len(line) > 998 {
+			t.Fatalf("line of %d bytes", len(line))
+		}
+	}
+}
+
+// smtpServer is a minimal SMTP server without STARTTLS or AUTH. It serves
+// one connection and sends the envelope and data it received on the
+// returned channel.
+func smtpServer(t *testing.T) (host string, port int, received <-chan []string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	out := make(chan []string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(s string) { io.WriteString(conn, s+"\r\n") }
+
+		var got []string
+		reply("220 test ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				out <- got
+				return
+			}
+			cmd := strings.TrimRight(line, "\r\n")
+			switch strings.ToUpper(strings.SplitN(cmd, " ", 2)[0]) {
+			case "EHLO":
+				reply("250 test")
+			case "MAIL", "RCPT":
+				got = append(got, cmd)
+				reply("250 ok")
+			case "DATA":
+				reply("354 go ahead")
+				var data strings.Builder
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil || l == ".\r\n" {
+						break
+					}
+					data.WriteString(l)
+				}
+				got = append(got, data.String())
+				reply("250 queued")
+			case "QUIT":
+				reply("221 bye")
+				out <- got
+				return
+			default:
+				reply("502 not implemented")
+			}
+		}
+	}()
+
+	addr := ln.Addr().(*net.TCPAddr)
+	return "127.0.0.1", addr.Port, out
+}
+
+func TestSendDeliversToEveryRecipient(t *testing.T) {
+	host, port, received := smtpServer(t)
+	cfg := Config{Host: host, Port: port, From: "ops@example.com", To: []string{"a@example.com", "b@example.com"}, TLS: TLSModeNone}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := NewSender().Send(ctx, cfg, Message{Subject: "Test", Title: "Test"}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	got := <-received
+	if len(got) != 4 {
+		t.Fatalf("server received %q", got)
+	}
+	if got[0] != "MAIL FROM:<ops@example.com>" || got[1] != "RCPT TO:<a@example.com>" || got[2] != "RCPT TO:<b@example.com>" {
+		t.Errorf("envelope = %q", got[:3])
+	}
+	if !strings.Contains(got[3], "Subject: Test") {
+		t.Errorf("data lacks the subject:\n%s", got[3])
+	}
+}
+
+func TestSendRequiresStartTLS(t *testing.T) {
+	host, port, received := smtpServer(t)
+	cfg := Config{Host: host, Port: port, From: "ops@example.com", To: []string{"a@example.com"}, TLS: TLSModeStartTLS}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := NewSender().Send(ctx, cfg, Message{Subject: "Test", Title: "Test"})
+	if err == nil || !strings.Contains(err.Error(), "STARTTLS") {
+		t.Fatalf("Send to a server without STARTTLS: err = %v", err)
+	}
+	if got := <-received; len(got) != 0 {
+		t.Errorf("message sent in clear text: %q", got)
+	}
+}
+
+func TestSendRejectsInvalidConfig(t *testing.T) {
+	err := NewSender().Send(context.Background(), Config{Host: "relay", Port: 25, TLS: TLSModeNone}, Message{})
+	if err == nil || !strings.Contains(err.Error(), "invalid smtp config") {
+		t.Errorf("err = %v", err)
+	}
+}
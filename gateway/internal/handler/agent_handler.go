@@ -3,45 +3,98 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/akz4ol/gatewayops/gateway/internal/agent"
+	"github.com/akz4ol/gatewayops/gateway/internal/budget"
+	"github.com/akz4ol/gatewayops/gateway/internal/clock"
+	"github.com/akz4ol/gatewayops/gateway/internal/config"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
 	"github.com/akz4ol/gatewayops/gateway/internal/middleware"
+	"github.com/akz4ol/gatewayops/gateway/internal/pricing"
+	"github.com/akz4ol/gatewayops/gateway/internal/response"
+	"github.com/akz4ol/gatewayops/gateway/internal/safety"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 )
 
+// ToolStreamer executes a tool call on an MCP server, passing its output to
+// onChunk as it arrives, and returns the cost of the call.
+type ToolStreamer interface {
+	StreamTool(ctx context.Context, server, tool string, args map[string]interface{}, onChunk func([]agent.ContentBlock) error) (float64, error)
+}
+
+// ToolLister lists the names of the tools an MCP server offers.
+type ToolLister interface {
+	ServerTools(ctx context.Context, server string) ([]string, error)
+}
+
+// MCPServerCatalog looks up and lists the MCP servers agents of an
+// organization can call.
+type MCPServerCatalog interface {
+	MCPServerLookup
+	List(orgID uuid.UUID) []domain.MCPServer
+}
+
 // AgentHandler handles agent platform API requests.
 type AgentHandler struct {
-	logger  zerolog.Logger
-	manager *agent.Manager
-	baseURL string
+	logger       zerolog.Logger
+	manager      *agent.Manager
+	simulator    *ToolCallSimulator
+	streamer     ToolStreamer
+	tools        ToolLister
+	servers      MCPServerCatalog
+	budgets      *budget.Service
+	defaultLimit func() int
+	baseURL      string
+	limits       config.AgentsConfig
+	scheduler    *agent.Scheduler
+
+	// Batches executing per connection or API key
+	inFlight   map[string]int
+	inFlightMu sync.Mutex
 }
 
-// NewAgentHandler creates a new agent handler.
-func NewAgentHandler(logger zerolog.Logger, manager *agent.Manager, baseURL string) *AgentHandler {
-	return &AgentHandler{
-		logger:  logger,
-		manager: manager,
-		baseURL: baseURL,
+// NewAgentHandler creates a new agent handler. Tool call batches are held to
+// the batch limits in limits, and their calls to its execution slots. Tool calls are executed through streamer when
+// it is set; servers supplies their per-tool timeouts. Connecting agents are
+// offered the servers and tools listed by servers and tools, and told the
+// budgets and rate limit, defaulting to defaultLimit, that apply to them.
+func NewAgentHandler(logger zerolog.Logger, manager *agent.Manager, simulator *ToolCallSimulator, streamer ToolStreamer, tools ToolLister, servers MCPServerCatalog, budgets *budget.Service, defaultLimit func() int, baseURL string, limits config.AgentsConfig) *AgentHandler {
+	h := &AgentHandler{
+		logger:       logger,
+		manager:      manager,
+		simulator:    simulator,
+		streamer:     streamer,
+		tools:        tools,
+		servers:      servers,
+		budgets:      budgets,
+		defaultLimit: defaultLimit,
+		baseURL:      baseURL,
+		limits:       limits,
+		scheduler:    agent.NewScheduler(limits.MaxConcurrentCalls, limits.PriorityAging, clock.Real),
+		inFlight:     make(map[string]int),
 	}
+	manager.SetToolExecutor(h.executeConnectionCall)
+	return h
 }
 
 // Connect establishes a new agent connection.
 func (h *AgentHandler) Connect(w http.ResponseWriter, r *http.Request) {
 	var req agent.ConnectRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+	if !decodeInput(w, r, &req) {
 		return
 	}
 
 	// Validate request
 	if req.Platform == "" {
-		WriteError(w, http.StatusBadRequest, "invalid_request", "Platform is required")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidRequest, "Platform is required")
 		return
 	}
 	if req.Transport == "" {
@@ -49,70 +102,161 @@ func (h *AgentHandler) Connect(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get auth info
-	authInfo := middleware.GetAuthInfo(r.Context())
-	orgID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
-	userID := uuid.MustParse("00000000-0000-0000-0000-000000000002")
-	if authInfo != nil {
-		orgID = authInfo.OrgID
-		userID = authInfo.UserID
-	}
+	orgID := middleware.GetOrgID(r.Context())
+	userID := middleware.GetUserID(r.Context())
 
 	// Create connection
 	conn, err := h.manager.Connect(r.Context(), req, orgID, userID)
 	if err != nil {
 		h.logger.Error().Err(err).Msg("Failed to create agent connection")
-		WriteError(w, http.StatusInternalServerError, "connection_error", "Failed to create connection")
+		WriteError(w, http.StatusInternalServerError, response.CodeConnectionError, "Failed to create connection")
 		return
 	}
 
 	// Build response
 	resp := agent.ConnectResponse{
-		ConnectionID: conn.ID,
-		GatewayURL:   fmt.Sprintf("wss://%s/v1/agents/%s", h.baseURL, conn.ID),
-		AvailableServers: []agent.ServerInfo{
-			{Name: "filesystem", Description: "File system operations", ToolCount: 12, ResourceCount: 5},
-			{Name: "github", Description: "GitHub integration", ToolCount: 8, ResourceCount: 3},
-			{Name: "database", Description: "Database operations", ToolCount: 6, ResourceCount: 2},
-			{Name: "slack", Description: "Slack messaging", ToolCount: 4, ResourceCount: 1},
-		},
-		RateLimits: agent.RateLimitInfo{
-			RequestsPerMinute: 1000,
-			TokensPerMinute:   100000,
-		},
+		ConnectionID:     conn.ID,
+		ResumeToken:      conn.ResumeToken(),
+		GatewayURL:       fmt.Sprintf("wss://%s/v1/agents/%s", h.baseURL, conn.ID),
+		AvailableServers: h.availableServers(r.Context()),
+		RateLimits:       h.rateLimits(r.Context()),
+		Budgets:          h.applicableBudgets(r.Context()),
 	}
 
 	WriteJSON(w, http.StatusOK, resp)
 }
 
-// WebSocket handles WebSocket upgrade for agent connections.
+// availableServers returns the servers the principal may call, each with the
+// tools on it the principal is not blocked from. A principal without
+// mcp:call permission is offered none.
+func (h *AgentHandler) availableServers(ctx context.Context) []agent.ServerInfo {
+	servers := make([]agent.ServerInfo, 0)
+	if h.servers == nil || !middleware.HasPermission(ctx, domain.PermissionMCPCall) {
+		return servers
+	}
+	authInfo := middleware.GetAuthInfo(ctx)
+	for _, server := range h.servers.List(middleware.GetOrgID(ctx)) {
+		if authInfo != nil && !authInfo.ServerAllowed(server.Name) {
+			continue
+		}
+		info := agent.ServerInfo{Name: server.Name, Tools: make([]agent.ToolInfo, 0)}
+		var names []string
+		if h.tools != nil {
+			var err error
+			if names, err = h.tools.ServerTools(ctx, server.Name); err != nil {
+				logger := middleware.RequestLogger(ctx, h.logger)
+				logger.Warn().
+					Err(err).
+					Str("server", server.Name).
+					Msg("Failed to list MCP server tools for agent connect")
+				info.ToolsUnavailable = true
+			}
+		}
+		for _, name := range names {
+			tool := agent.ToolInfo{Name: name}
+			if h.simulator != nil {
+				var allowed bool
+				tool.Classification, tool.RequiresApproval, allowed = h.simulator.Discover(authInfo, server.Name, name)
+				if !allowed {
+					continue
+				}
+			}
+			info.Tools = append(info.Tools, tool)
+		}
+		info.ToolCount = len(info.Tools)
+		servers = append(servers, info)
+	}
+	return servers
+}
+
+// rateLimits returns the request rate the principal's API key is held to.
+func (h *AgentHandler) rateLimits(ctx context.Context) agent.RateLimitInfo {
+	authInfo := middleware.GetAuthInfo(ctx)
+	if authInfo == nil {
+		authInfo = &middleware.AuthInfo{}
+	}
+	return agent.RateLimitInfo{RequestsPerMinute: middleware.KeyRateLimit(authInfo, h.defaultLimit)}
+}
+
+// applicableBudgets returns the budgets the principal's calls are charged to.
+func (h *AgentHandler) applicableBudgets(ctx context.Context) []agent.BudgetInfo {
+	budgets := make([]agent.BudgetInfo, 0)
+	if h.budgets == nil {
+		return budgets
+	}
+	var teamID *uuid.UUID
+	if authInfo := middleware.GetAuthInfo(ctx); authInfo != nil && authInfo.TeamID != uuid.Nil {
+		teamID = &authInfo.TeamID
+	}
+	for _, b := range h.budgets.Applicable(middleware.GetOrgID(ctx), teamID) {
+		budgets = append(budgets, agent.BudgetInfo{
+			ID:       b.ID,
+			Name:     b.Name,
+			Period:   b.Period,
+			LimitUSD: b.LimitUSD,
+			SpentUSD: b.SpentUSD,
+		})
+	}
+	return budgets
+}
+
+// WebSocket handles WebSocket upgrade for agent connections. A connection
+// whose WebSocket dropped is resumed by passing its resume token in the
+// resume_token query parameter within the grace period.
 func (h *AgentHandler) WebSocket(w http.ResponseWriter, r *http.Request) {
 	connIDStr := chi.URLParam(r, "connectionID")
 	connID, err := uuid.Parse(connIDStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_connection_id", "Invalid connection ID")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidConnectionID, "Invalid connection ID")
 		return
 	}
 
-	if err := h.manager.UpgradeToWebSocket(w, r, connID); err != nil {
+	err = h.manager.UpgradeToWebSocket(w, r, connID, r.URL.Query().Get("resume_token"))
+	switch {
+	case err == nil:
+	case errors.Is(err, agent.ErrConnectionNotFound):
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Connection not found")
+	case errors.Is(err, agent.ErrInvalidResumeToken):
+		WriteError(w, http.StatusUnauthorized, response.CodeInvalidResumeToken, "Invalid resume token")
+	case errors.Is(err, agent.ErrAlreadyConnected):
+		WriteError(w, http.StatusConflict, response.CodeTransportConflict, "Connection already has an open WebSocket")
+	default:
 		h.logger.Error().Err(err).Str("connection_id", connIDStr).Msg("WebSocket upgrade failed")
 		// Note: Can't write error response after upgrade attempt
-		return
 	}
 }
 
 // Execute handles batch tool execution.
 func (h *AgentHandler) Execute(w http.ResponseWriter, r *http.Request) {
 	var req agent.ExecuteRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+	if !decodeInput(w, r, &req) {
 		return
 	}
 
 	if len(req.Calls) == 0 {
-		WriteError(w, http.StatusBadRequest, "invalid_request", "At least one call is required")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidRequest, "At least one call is required")
+		return
+	}
+	if !h.checkBatchSize(w, req.Calls) {
+		return
+	}
+	h.inheritPriority(&req)
+
+	if req.DryRun || middleware.IsDryRun(r) {
+		h.dryRun(w, r, req.Calls)
+		return
+	}
+	if req.ConnectionID == uuid.Nil && h.manager.CostCapped() {
+		WriteFieldError(w, "connection_id", "is required while agent cost caps are configured")
 		return
 	}
 
+	release, ok := h.admitBatch(w, r, req)
+	if !ok {
+		return
+	}
+	defer release()
+
 	if req.ExecutionMode == "" {
 		req.ExecutionMode = "parallel"
 	}
@@ -128,9 +272,9 @@ func (h *AgentHandler) Execute(w http.ResponseWriter, r *http.Request) {
 	traceID := fmt.Sprintf("tr_%s", uuid.New().String()[:8])
 
 	if req.ExecutionMode == "parallel" {
-		results, totalCost = h.executeParallel(ctx, req.Calls)
+		results, totalCost = h.executeParallel(ctx, req.ConnectionID, req.Calls, traceID)
 	} else {
-		results, totalCost = h.executeSequential(ctx, req.Calls)
+		results, totalCost = h.executeSequential(ctx, req.ConnectionID, req.Calls, traceID)
 	}
 
 	resp := agent.ExecuteResponse{
@@ -142,8 +286,124 @@ func (h *AgentHandler) Execute(w http.ResponseWriter, r *http.Request) {
 	WriteJSON(w, http.StatusOK, resp)
 }
 
+// checkBatchSize rejects a batch with more calls than allowed, writing a 400
+// response and returning false.
+func (h *AgentHandler) checkBatchSize(w http.ResponseWriter, calls []agent.ToolCall) bool {
+	if max := h.limits.MaxBatchCalls; max > 0 && len(calls) > max {
+		WriteError(w, http.StatusBadRequest, response.CodeBatchTooLarge,
+			fmt.Sprintf("Batch has %d calls; at most %d are allowed", len(calls), max))
+		return false
+	}
+	return true
+}
+
+// inheritPriority gives the batch's priority to its calls that set none, and
+// holds every call's priority within the configured range.
+func (h *AgentHandler) inheritPriority(req *agent.ExecuteRequest) {
+	for i := range req.Calls {
+		if req.Calls[i].Priority == 0 {
+			req.Calls[i].Priority = req.Priority
+		}
+		req.Calls[i].Priority = clampPriority(req.Calls[i].Priority, h.limits.MaxPriority)
+	}
+}
+
+// clampPriority holds priority within -max to max.
+func clampPriority(priority, max int) int {
+	if priority > max {
+		return max
+	}
+	if priority < -max {
+		return -max
+	}
+	return priority
+}
+
+// admitBatch checks a batch's estimated cost against the per-batch limit and
+// takes one of its caller's in-flight batch slots. If either limit is hit it
+// writes an error response and returns false; otherwise the returned func
+// must be called when the batch finishes.
+func (h *AgentHandler) admitBatch(w http.ResponseWriter, r *http.Request, req agent.ExecuteRequest) (func(), bool) {
+	if max := h.limits.MaxBatchCost; max > 0 {
+		var estimated float64
+		for _, call := range req.Calls {
+			estimated += h.estimateCost(call)
+		}
+		if estimated > max {
+			WriteError(w, http.StatusBadRequest, response.CodeBatchCostLimit,
+				fmt.Sprintf("Batch has an estimated cost of $%g; at most $%g is allowed", estimated, max))
+			return nil, false
+		}
+	}
+
+	owner := batchOwner(r, req)
+	h.inFlightMu.Lock()
+	defer h.inFlightMu.Unlock()
+	if max := h.limits.MaxInFlightBatches; max > 0 && h.inFlight[owner] >= max {
+		WriteError(w, http.StatusTooManyRequests, response.CodeTooManyBatches,
+			fmt.Sprintf("Too many batches in flight (limit %d); wait for one to finish", max))
+		return nil, false
+	}
+	h.inFlight[owner]++
+
+	return func() {
+		h.inFlightMu.Lock()
+		defer h.inFlightMu.Unlock()
+		if h.inFlight[owner]--; h.inFlight[owner] <= 0 {
+			delete(h.inFlight, owner)
+		}
+	}, true
+}
+
+// batchOwner identifies whose in-flight batches a batch counts against: its
+// connection, or for batches sent without one, its API key or client IP.
+func batchOwner(r *http.Request, req agent.ExecuteRequest) string {
+	if req.ConnectionID != uuid.Nil {
+		return "connection:" + req.ConnectionID.String()
+	}
+	if authInfo := middleware.GetAuthInfo(r.Context()); authInfo != nil && authInfo.KeyID != "" {
+		return "key:" + authInfo.KeyID
+	}
+	return "ip:" + middleware.GetClientIP(r.Context())
+}
+
+// estimateCost returns the estimated cost of a tool call before it is made.
+func (h *AgentHandler) estimateCost(call agent.ToolCall) float64 {
+	if h.simulator == nil || h.simulator.estimator == nil {
+		return pricing.DefaultCallCost
+	}
+	return h.simulator.estimator.Estimate(call.Server, call.Tool, call.Arguments)
+}
+
+// dryRun evaluates tool calls against policy and returns the decisions without executing them.
+func (h *AgentHandler) dryRun(w http.ResponseWriter, r *http.Request, calls []agent.ToolCall) {
+	authInfo := middleware.GetAuthInfo(r.Context())
+
+	resp := agent.DryRunResponse{
+		DryRun:    true,
+		Decisions: make([]domain.ToolCallDecision, 0, len(calls)),
+	}
+
+	for _, call := range calls {
+		decision := domain.ToolCallDecision{
+			MCPServer:     call.Server,
+			ToolName:      call.Tool,
+			Allowed:       true,
+			EstimatedCost: pricing.DefaultCallCost,
+		}
+		if h.simulator != nil {
+			decision = h.simulator.Simulate(r.Context(), authInfo, call.Server, call.Tool, call.Arguments)
+		}
+		decision.CallID = call.ID
+		resp.Decisions = append(resp.Decisions, decision)
+		resp.EstimatedTotalCost += decision.EstimatedCost
+	}
+
+	WriteJSON(w, http.StatusOK, resp)
+}
+
 // executeParallel executes tool calls in parallel.
-func (h *AgentHandler) executeParallel(ctx context.Context, calls []agent.ToolCall) ([]agent.ToolResult, float64) {
+func (h *AgentHandler) executeParallel(ctx context.Context, connID uuid.UUID, calls []agent.ToolCall, traceID string) ([]agent.ToolResult, float64) {
 	results := make([]agent.ToolResult, len(calls))
 	var wg sync.WaitGroup
 	var mu sync.Mutex
@@ -153,7 +413,7 @@ func (h *AgentHandler) executeParallel(ctx context.Context, calls []agent.ToolCa
 		wg.Add(1)
 		go func(idx int, c agent.ToolCall) {
 			defer wg.Done()
-			result := h.executeToolCall(ctx, c)
+			result := h.executeToolCall(ctx, connID, c, traceID, nil)
 			
 			mu.Lock()
 			results[idx] = result
@@ -167,7 +427,7 @@ func (h *AgentHandler) executeParallel(ctx context.Context, calls []agent.ToolCa
 }
 
 // executeSequential executes tool calls sequentially.
-func (h *AgentHandler) executeSequential(ctx context.Context, calls []agent.ToolCall) ([]agent.ToolResult, float64) {
+func (h *AgentHandler) executeSequential(ctx context.Context, connID uuid.UUID, calls []agent.ToolCall, traceID string) ([]agent.ToolResult, float64) {
 	results := make([]agent.ToolResult, 0, len(calls))
 	var totalCost float64
 
@@ -176,19 +436,12 @@ func (h *AgentHandler) executeSequential(ctx context.Context, calls []agent.Tool
 		case <-ctx.Done():
 			// Add timeout results for remaining calls
 			for i := len(results); i < len(calls); i++ {
-				results = append(results, agent.ToolResult{
-					ID:     calls[i].ID,
-					Status: "timeout",
-					Error: &agent.ErrorInfo{
-						Code:    "timeout",
-						Message: "Execution timed out",
-					},
-				})
+				results = append(results, failedToolResult(calls[i], agent.ErrorUpstreamTimeout, "Execution timed out"))
 			}
 			return results, totalCost
 
 		default:
-			result := h.executeToolCall(ctx, call)
+			result := h.executeToolCall(ctx, connID, call, traceID, nil)
 			results = append(results, result)
 			totalCost += result.Cost
 		}
@@ -197,8 +450,105 @@ func (h *AgentHandler) executeSequential(ctx context.Context, calls []agent.Tool
 	return results, totalCost
 }
 
-// executeToolCall executes a single tool call.
-func (h *AgentHandler) executeToolCall(ctx context.Context, call agent.ToolCall) agent.ToolResult {
+// errCallTimeout is the cause of a tool call cancelled because it ran past
+// its own timeout, rather than the batch's.
+var errCallTimeout = errors.New("tool call timed out")
+
+// executeToolCall executes a single tool call. Calls that are malformed, or
+// that approval or safety policy would reject, fail without being executed,
+// as do calls whose estimated cost their connection's caps do not allow. The
+// estimate is held against the caps while the call runs and replaced by its
+// actual cost when it finishes. A call waits for an
+// execution slot by its priority, then runs under its own timeout, within
+// the batch's; a call that runs past it fails alone. Progress events are
+// passed to progress when it is set.
+func (h *AgentHandler) executeToolCall(ctx context.Context, connID uuid.UUID, call agent.ToolCall, traceID string, progress func(map[string]any)) (result agent.ToolResult) {
+	if result, rejected := h.rejectToolCall(ctx, call); rejected {
+		return result
+	}
+	reservation, err := h.manager.ReserveCost(connID, call.Server, call.Tool, h.estimateCost(call))
+	switch {
+	case errors.Is(err, agent.ErrConnectionNotFound):
+		return failedToolResult(call, agent.ErrorValidation, fmt.Sprintf("Connection '%s' not found", connID))
+	case errors.Is(err, agent.ErrConnectionRequired):
+		return failedToolResult(call, agent.ErrorValidation, "A connection is required while cost caps are configured")
+	case err != nil:
+		return failedToolResult(call, agent.ErrorBudgetExceeded, err.Error())
+	}
+	defer func() {
+		reservation.Settle(result.Cost)
+	}()
+
+	release, err := h.scheduler.Acquire(ctx, call.Priority)
+	if err != nil {
+		return failedToolResult(call, agent.ErrorUpstreamTimeout, "Timed out waiting for an execution slot")
+	}
+	defer release()
+
+	timeout := h.callTimeout(ctx, call)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, timeout, errCallTimeout)
+		defer cancel()
+	}
+
+	if h.streamer != nil {
+		result = h.streamToolCall(ctx, call, traceID, progress)
+	} else {
+		result = h.mockToolCall(ctx, call, progress)
+	}
+	if result.Status == "timeout" && errors.Is(context.Cause(ctx), errCallTimeout) {
+		result.Error.Message = fmt.Sprintf("Tool call exceeded its timeout of %s", timeout)
+	}
+	return result
+}
+
+// connectionCallTimeout bounds a tool call made over a WebSocket, as the
+// default timeout_ms bounds a batch.
+const connectionCallTimeout = 30 * time.Second
+
+// executeConnectionCall executes a tool call made over a connection's
+// WebSocket as a batch of one.
+func (h *AgentHandler) executeConnectionCall(ctx context.Context, conn *agent.Connection, call agent.ToolCall, progress func(map[string]any)) agent.ToolResult {
+	call.Priority = clampPriority(call.Priority, h.limits.MaxPriority)
+	ctx, cancel := context.WithTimeout(ctx, connectionCallTimeout)
+	defer cancel()
+
+	traceID := fmt.Sprintf("tr_%s", uuid.New().String()[:8])
+	return h.executeToolCall(ctx, conn.ID, call, traceID, progress)
+}
+
+// callTimeout returns the timeout of a tool call: the one the call sets,
+// else the one configured for its tool on its MCP server, else the default
+// for its classification. It returns 0 when none applies.
+func (h *AgentHandler) callTimeout(ctx context.Context, call agent.ToolCall) time.Duration {
+	if call.TimeoutMs > 0 {
+		return time.Duration(call.TimeoutMs) * time.Millisecond
+	}
+	if h.servers != nil {
+		if server, ok := h.servers.MCPServer(middleware.GetOrgID(ctx), call.Server); ok {
+			if timeout, ok := server.ToolTimeouts[call.Tool]; ok {
+				return timeout
+			}
+		}
+	}
+	if h.simulator != nil && len(h.limits.ToolTimeouts) > 0 {
+		classification, _ := h.simulator.Classification(middleware.GetOrgID(ctx), call.Server, call.Tool)
+		return h.limits.ToolTimeouts[string(classification)]
+	}
+	return 0
+}
+
+// mockToolCall stands in for executing a tool call when no streamer is set.
+func (h *AgentHandler) mockToolCall(ctx context.Context, call agent.ToolCall, progress func(map[string]any)) agent.ToolResult {
+	if progress != nil {
+		progress(map[string]any{
+			"call_id":  call.ID,
+			"progress": 0.5,
+			"message":  fmt.Sprintf("Executing %s.%s...", call.Server, call.Tool),
+		})
+	}
+
 	start := time.Now()
 
 	// TODO: Integrate with actual MCP handler
@@ -206,7 +556,11 @@ func (h *AgentHandler) executeToolCall(ctx context.Context, call agent.ToolCall)
 	duration := time.Since(start)
 
 	// Simulate some processing time
-	time.Sleep(20 * time.Millisecond)
+	select {
+	case <-time.After(20 * time.Millisecond):
+	case <-ctx.Done():
+		return failedToolResult(call, agent.ErrorUpstreamTimeout, "Execution timed out")
+	}
 
 	return agent.ToolResult{
 		ID:     call.ID,
@@ -218,22 +572,185 @@ func (h *AgentHandler) executeToolCall(ctx context.Context, call agent.ToolCall)
 			},
 		},
 		DurationMs: int(duration.Milliseconds()) + 20,
-		Cost:       0.0001,
+		Cost:       pricing.DefaultCallCost,
 	}
 }
 
-// ExecuteStream handles SSE streaming tool execution.
+// rejectToolCall returns the failed result of a tool call that is malformed,
+// or that approval or safety policy would reject.
+func (h *AgentHandler) rejectToolCall(ctx context.Context, call agent.ToolCall) (agent.ToolResult, bool) {
+	if call.Server == "" || call.Tool == "" {
+		return failedToolResult(call, agent.ErrorValidation, "server and tool are required"), true
+	}
+	if call.TimeoutMs < 0 {
+		return failedToolResult(call, agent.ErrorValidation, "timeout_ms must not be negative"), true
+	}
+
+	if h.simulator != nil {
+		decision := h.simulator.Simulate(ctx, middleware.GetAuthInfo(ctx), call.Server, call.Tool, call.Arguments)
+		if !decision.Allowed {
+			category := agent.ErrorDeniedByPolicy
+			if decision.Safety != nil && decision.Safety.Action == domain.SafetyModeBlock {
+				category = agent.ErrorInjectionBlocked
+			}
+			result := failedToolResult(call, category, decision.Reason)
+			result.Error.Details = decision
+			return result, true
+		}
+	}
+
+	return agent.ToolResult{}, false
+}
+
+// streamToolCall executes a tool call through the streamer, passing a
+// progress event with each chunk of output to progress as it arrives. Every
+// chunk is scanned for prompt injection first; a blocked chunk ends the
+// call. The result carries all of the call's output.
+func (h *AgentHandler) streamToolCall(ctx context.Context, call agent.ToolCall, traceID string, progress func(map[string]any)) agent.ToolResult {
+	detect := safety.DetectOptions{
+		TraceID:   traceID,
+		MCPServer: call.Server,
+		ToolName:  call.Tool,
+		IPAddress: middleware.GetClientIP(ctx),
+	}
+	if authInfo := middleware.GetAuthInfo(ctx); authInfo != nil {
+		detect.OrgID = authInfo.OrgID
+		if authInfo.APIKeyID != uuid.Nil {
+			detect.APIKeyID = &authInfo.APIKeyID
+		}
+	}
+
+	var content []agent.ContentBlock
+	var chunks int
+	var blocked *domain.DetectionResult
+	start := time.Now()
+	cost, err := h.streamer.StreamTool(ctx, call.Server, call.Tool, call.Arguments, func(chunk []agent.ContentBlock) error {
+		event := map[string]any{
+			"call_id": call.ID,
+			"chunk":   chunks,
+			"content": chunk,
+		}
+		if h.simulator != nil {
+			if result := h.simulator.ScanOutput(ctx, contentText(chunk), detect); result != nil {
+				if result.Action == domain.SafetyModeBlock {
+					blocked = result
+					return errOutputBlocked
+				}
+				if result.Action == domain.SafetyModeWarn {
+					event["safety"] = result
+				}
+			}
+		}
+		content = append(content, chunk...)
+		chunks++
+		if progress != nil {
+			progress(event)
+		}
+		return nil
+	})
+	duration := time.Since(start)
+
+	var upstreamErr *upstreamStatusError
+	switch {
+	case err == nil:
+		return agent.ToolResult{
+			ID:         call.ID,
+			Status:     "success",
+			Content:    content,
+			DurationMs: int(duration.Milliseconds()),
+			Cost:       cost,
+		}
+	case blocked != nil:
+		h.logger.Warn().
+			Str("severity", string(blocked.Severity)).
+			Str("pattern", blocked.PatternMatched).
+			Str("mcp_server", call.Server).
+			Str("tool", call.Tool).
+			Msg("Blocked streamed tool output due to prompt injection detection")
+		result := failedToolResult(call, agent.ErrorInjectionBlocked, "Tool output blocked: potential prompt injection detected")
+		result.Error.Details = blocked
+		return result
+	case errors.Is(err, errMCPServerNotFound):
+		return failedToolResult(call, agent.ErrorValidation, fmt.Sprintf("MCP server '%s' not found", call.Server))
+	case errors.Is(err, errToolLoop):
+		return failedToolResult(call, agent.ErrorLoopDetected, fmt.Sprintf("Tool '%s' was called with the same arguments too many times in a short window", call.Tool))
+	case errors.As(err, &upstreamErr):
+		info := toolCallFailure(upstreamErr.StatusCode, upstreamErr.Body)
+		result := failedToolResult(call, info.Category, info.Message)
+		result.Error = info
+		return result
+	case errors.Is(err, errStreamIdle), ctx.Err() != nil:
+		return failedToolResult(call, agent.ErrorUpstreamTimeout, "Execution timed out")
+	default:
+		h.logger.Warn().Err(err).Str("mcp_server", call.Server).Str("tool", call.Tool).Msg("Streamed tool call failed")
+		return failedToolResult(call, agent.ErrorUpstreamError, "Failed to stream tool output from MCP server")
+	}
+}
+
+// errOutputBlocked ends a streamed tool call whose output was blocked.
+var errOutputBlocked = errors.New("tool output blocked")
+
+// contentText returns the text of content blocks, for safety scanning.
+func contentText(content []agent.ContentBlock) string {
+	var text strings.Builder
+	for _, block := range content {
+		if block.Text == "" {
+			continue
+		}
+		if text.Len() > 0 {
+			text.WriteString("\n")
+		}
+		text.WriteString(block.Text)
+	}
+	return text.String()
+}
+
+// failedToolResult returns the result of a tool call that failed with
+// category.
+func failedToolResult(call agent.ToolCall, category agent.ErrorCategory, message string) agent.ToolResult {
+	status := "error"
+	if category == agent.ErrorUpstreamTimeout {
+		status = "timeout"
+	}
+	return agent.ToolResult{
+		ID:     call.ID,
+		Status: status,
+		Error:  agent.NewToolError(category, message),
+	}
+}
+
+// ExecuteStream handles SSE streaming tool execution. Output that MCP servers
+// stream is forwarded as progress events while the call runs, followed by a
+// complete event with all of it.
 func (h *AgentHandler) ExecuteStream(w http.ResponseWriter, r *http.Request) {
 	var req agent.ExecuteRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+	if !decodeInput(w, r, &req) {
 		return
 	}
 
 	if len(req.Calls) == 0 {
-		WriteError(w, http.StatusBadRequest, "invalid_request", "At least one call is required")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidRequest, "At least one call is required")
+		return
+	}
+	if !h.checkBatchSize(w, req.Calls) {
 		return
 	}
+	h.inheritPriority(&req)
+
+	if req.DryRun || middleware.IsDryRun(r) {
+		h.dryRun(w, r, req.Calls)
+		return
+	}
+	if req.ConnectionID == uuid.Nil && h.manager.CostCapped() {
+		WriteFieldError(w, "connection_id", "is required while agent cost caps are configured")
+		return
+	}
+
+	release, ok := h.admitBatch(w, r, req)
+	if !ok {
+		return
+	}
+	defer release()
 
 	// Set headers for SSE
 	w.Header().Set("Content-Type", "text/event-stream")
@@ -243,7 +760,7 @@ func (h *AgentHandler) ExecuteStream(w http.ResponseWriter, r *http.Request) {
 
 	flusher, ok := w.(http.Flusher)
 	if !ok {
-		WriteError(w, http.StatusInternalServerError, "streaming_not_supported", "Streaming not supported")
+		WriteError(w, http.StatusInternalServerError, response.CodeStreamingNotSupported, "Streaming not supported")
 		return
 	}
 
@@ -258,27 +775,28 @@ func (h *AgentHandler) ExecuteStream(w http.ResponseWriter, r *http.Request) {
 			"tool":    call.Tool,
 		})
 
-		// Send progress
-		h.sendSSE(w, flusher, agent.SSEEventProgress, map[string]any{
-			"call_id":  call.ID,
-			"progress": 0.5,
-			"message":  fmt.Sprintf("Executing %s.%s...", call.Server, call.Tool),
+		result := h.executeToolCall(r.Context(), req.ConnectionID, call, traceID, func(event map[string]any) {
+			h.sendSSE(w, flusher, agent.SSEEventProgress, event)
 		})
+		totalCost += result.Cost
 
-		// Simulate execution
-		time.Sleep(50 * time.Millisecond)
+		// Send error, with the same categories as batch results
+		if result.Error != nil {
+			h.sendSSE(w, flusher, agent.SSEEventError, map[string]any{
+				"call_id": call.ID,
+				"status":  result.Status,
+				"error":   result.Error,
+			})
+			continue
+		}
 
 		// Send complete
-		cost := 0.0001
-		totalCost += cost
 		h.sendSSE(w, flusher, agent.SSEEventComplete, map[string]any{
 			"call_id":     call.ID,
-			"status":      "success",
-			"duration_ms": 50,
-			"cost":        cost,
-			"content": []map[string]any{
-				{"type": "text", "text": fmt.Sprintf("Tool %s.%s executed", call.Server, call.Tool)},
-			},
+			"status":      result.Status,
+			"duration_ms": result.DurationMs,
+			"cost":        result.Cost,
+			"content":     result.Content,
 		})
 	}
 
@@ -301,17 +819,17 @@ func (h *AgentHandler) GetConnection(w http.ResponseWriter, r *http.Request) {
 	connIDStr := chi.URLParam(r, "connectionID")
 	connID, err := uuid.Parse(connIDStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_connection_id", "Invalid connection ID")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidConnectionID, "Invalid connection ID")
 		return
 	}
 
 	conn, exists := h.manager.GetConnection(connID)
 	if !exists {
-		WriteError(w, http.StatusNotFound, "not_found", "Connection not found")
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Connection not found")
 		return
 	}
 
-	WriteJSON(w, http.StatusOK, conn)
+	WriteJSON(w, http.StatusOK, agent.ConnectionDetail{Connection: conn, Cost: h.manager.Cost(conn)})
 }
 
 // Disconnect closes an agent connection.
@@ -319,7 +837,7 @@ func (h *AgentHandler) Disconnect(w http.ResponseWriter, r *http.Request) {
 	connIDStr := chi.URLParam(r, "connectionID")
 	connID, err := uuid.Parse(connIDStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_connection_id", "Invalid connection ID")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidConnectionID, "Invalid connection ID")
 		return
 	}
 
@@ -463,3 +981,138 @@ func (h *AgentHandler) ListTools(w http.ResponseWriter, r *http.Request) {
 
 	WriteJSON(w, http.StatusOK, map[string]any{"tools": tools})
 }
+
+// parseConnectionID reads the connectionID path parameter, writing an error
+// response if it is invalid.
+func parseConnectionID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	connID, err := uuid.Parse(chi.URLParam(r, "connectionID"))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidConnectionID, "Invalid connection ID")
+		return uuid.Nil, false
+	}
+	return connID, true
+}
+
+// Subscribe starts watching an MCP resource for changes on behalf of a
+// connection. Changes are delivered as resource_updated messages over the
+// connection's WebSocket or event stream.
+func (h *AgentHandler) Subscribe(w http.ResponseWriter, r *http.Request) {
+	connID, ok := parseConnectionID(w, r)
+	if !ok {
+		return
+	}
+
+	var req agent.SubscribeRequest
+	if !decodeInput(w, r, &req) {
+		return
+	}
+	if req.Server == "" {
+		WriteFieldError(w, "server", "server is required")
+		return
+	}
+	if req.URI == "" {
+		WriteFieldError(w, "uri", "uri is required")
+		return
+	}
+
+	sub, err := h.manager.Subscribe(r.Context(), connID, req)
+	switch {
+	case err == nil:
+		WriteJSON(w, http.StatusCreated, sub)
+	case errors.Is(err, agent.ErrConnectionNotFound):
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Connection not found")
+	case errors.Is(err, errMCPServerNotFound):
+		WriteError(w, http.StatusNotFound, response.CodeServerNotFound, fmt.Sprintf("MCP server '%s' not found", req.Server))
+	case errors.Is(err, agent.ErrTooManySubscriptions):
+		WriteError(w, http.StatusConflict, response.CodeSubscriptionLimit, err.Error())
+	case errors.Is(err, agent.ErrResourcesUnavailable):
+		WriteError(w, http.StatusServiceUnavailable, response.CodeInternalError, err.Error())
+	default:
+		h.logger.Warn().Err(err).Str("server", req.Server).Str("uri", req.URI).Msg("Resource subscription failed")
+		WriteError(w, http.StatusBadGateway, response.CodeUpstreamError, "Failed to read resource from MCP server")
+	}
+}
+
+// ListSubscriptions returns a connection's resource subscriptions.
+func (h *AgentHandler) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	connID, ok := parseConnectionID(w, r)
+	if !ok {
+		return
+	}
+	if _, exists := h.manager.GetConnection(connID); !exists {
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Connection not found")
+		return
+	}
+
+	subs := h.manager.ListSubscriptions(connID)
+	WriteJSON(w, http.StatusOK, map[string]any{
+		"subscriptions": subs,
+		"total":         len(subs),
+	})
+}
+
+// Unsubscribe stops watching a resource.
+func (h *AgentHandler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	connID, ok := parseConnectionID(w, r)
+	if !ok {
+		return
+	}
+	subID, err := uuid.Parse(chi.URLParam(r, "subscriptionID"))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid subscription ID")
+		return
+	}
+
+	if err := h.manager.Unsubscribe(connID, subID); err != nil {
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Subscription not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Events streams a connection's outbound messages, including resource
+// updates, as Server-Sent Events for agents that do not use WebSocket.
+func (h *AgentHandler) Events(w http.ResponseWriter, r *http.Request) {
+	connID, ok := parseConnectionID(w, r)
+	if !ok {
+		return
+	}
+	conn, exists := h.manager.GetConnection(connID)
+	if !exists {
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Connection not found")
+		return
+	}
+	if conn.HasWebSocket() {
+		WriteError(w, http.StatusConflict, response.CodeTransportConflict, "Connection delivers events over WebSocket")
+		return
+	}
+
+	flusher, ok := startSSE(w)
+	if !ok {
+		return
+	}
+
+	keepAlive := time.NewTicker(sseKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-conn.Done():
+			return
+		case <-keepAlive.C:
+			if err := writeSSEKeepAlive(w, flusher); err != nil {
+				return
+			}
+		case data := <-conn.Outbox():
+			var msg agent.WSMessage
+			if err := json.Unmarshal(data, &msg); err != nil {
+				continue
+			}
+			if err := writeSSE(w, flusher, msg.Type, msg); err != nil {
+				return
+			}
+		}
+	}
+}
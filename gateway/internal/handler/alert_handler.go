@@ -2,13 +2,20 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/akz4ol/gatewayops/gateway/internal/alerting"
+	"github.com/akz4ol/gatewayops/gateway/internal/audit"
 	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/email"
+	"github.com/akz4ol/gatewayops/gateway/internal/middleware"
+	"github.com/akz4ol/gatewayops/gateway/internal/response"
+	"github.com/akz4ol/gatewayops/gateway/internal/webhook"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
@@ -16,21 +23,30 @@ import (
 
 // AlertHandler handles alert-related HTTP requests.
 type AlertHandler struct {
-	logger  zerolog.Logger
-	service *alerting.Service
+	logger      zerolog.Logger
+	service     *alerting.Service
+	auditLogger *audit.Logger
 }
 
 // NewAlertHandler creates a new alert handler.
-func NewAlertHandler(logger zerolog.Logger, service *alerting.Service) *AlertHandler {
+func NewAlertHandler(logger zerolog.Logger, service *alerting.Service, auditLogger *audit.Logger) *AlertHandler {
 	return &AlertHandler{
-		logger:  logger,
-		service: service,
+		logger:      logger,
+		service:     service,
+		auditLogger: auditLogger,
 	}
 }
 
-// ListRules returns all alert rules.
+// ListRules returns the organization's alert rules. Admins may pass
+// include_deleted=true to also list soft-deleted rules.
 func (h *AlertHandler) ListRules(w http.ResponseWriter, r *http.Request) {
-	rules := h.service.ListRules()
+	includeDeleted := r.URL.Query().Get("include_deleted") == "true"
+	if includeDeleted && !middleware.HasPermission(r.Context(), domain.PermissionAlertsAdmin) {
+		WriteError(w, http.StatusForbidden, response.CodeForbidden, "Listing deleted rules requires the alerts:admin permission")
+		return
+	}
+
+	rules := h.service.ListRules(middleware.GetOrgID(r.Context()), includeDeleted)
 	WriteJSON(w, http.StatusOK, map[string]interface{}{
 		"rules": rules,
 		"total": len(rules),
@@ -42,13 +58,13 @@ func (h *AlertHandler) GetRule(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "ruleID")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid rule ID")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid rule ID")
 		return
 	}
 
-	rule := h.service.GetRule(id)
+	rule := h.service.GetRule(middleware.GetOrgID(r.Context()), id)
 	if rule == nil {
-		WriteError(w, http.StatusNotFound, "not_found", "Rule not found")
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Rule not found")
 		return
 	}
 
@@ -58,23 +74,10 @@ func (h *AlertHandler) GetRule(w http.ResponseWriter, r *http.Request) {
 // CreateRule creates a new alert rule.
 func (h *AlertHandler) CreateRule(w http.ResponseWriter, r *http.Request) {
 	var input domain.AlertRuleInput
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_json", "Invalid request body")
+	if !decodeRuleInput(w, r, &input) {
 		return
 	}
 
-	if input.Name == "" {
-		WriteError(w, http.StatusBadRequest, "validation_error", "Name is required")
-		return
-	}
-	if input.Metric == "" {
-		WriteError(w, http.StatusBadRequest, "validation_error", "Metric is required")
-		return
-	}
-	if input.Condition == "" {
-		WriteError(w, http.StatusBadRequest, "validation_error", "Condition is required")
-		return
-	}
 	if input.WindowMinutes <= 0 {
 		input.WindowMinutes = 5 // default
 	}
@@ -82,35 +85,84 @@ func (h *AlertHandler) CreateRule(w http.ResponseWriter, r *http.Request) {
 		input.Severity = domain.AlertSeverityWarning
 	}
 
-	// Demo org and user
-	orgID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
-	userID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	orgID := middleware.GetOrgID(r.Context())
+	userID := middleware.GetUserID(r.Context())
 
 	rule := h.service.CreateRule(input, orgID, userID)
+	recordAudit(r, h.auditLogger, domain.AuditActionAlertRuleCreate, "alert_rule", rule.ID.String(), nil, rule)
 	WriteJSON(w, http.StatusCreated, rule)
 }
 
+// decodeRuleInput decodes and validates an alert rule body, reporting unknown
+// filter dimensions, thresholds out of their metric's range and unparseable
+// message templates as field errors.
+func decodeRuleInput(w http.ResponseWriter, r *http.Request, input *domain.AlertRuleInput) bool {
+	if err := decodeStrict(r, input); err != nil {
+		var filterErr *domain.UnknownFilterKeyError
+		if errors.As(err, &filterErr) {
+			WriteFieldError(w, "filters."+filterErr.Key,
+				"Unknown filter; supported filters are "+strings.Join(domain.AlertFilterKeys, ", "))
+			return false
+		}
+		writeDecodeError(w, err)
+		return false
+	}
+	if !checkInput(w, input, false) {
+		return false
+	}
+
+	if err := alerting.ValidateThreshold(input.Metric, input.Condition, input.Threshold); err != nil {
+		var thresholdErr *alerting.ThresholdError
+		if errors.As(err, &thresholdErr) {
+			WriteFieldError(w, thresholdErr.Field, thresholdErr.Message)
+			return false
+		}
+		WriteFieldError(w, "threshold", err.Error())
+		return false
+	}
+
+	if err := alerting.ValidateTemplates(input.Templates); err != nil {
+		var tmplErr *alerting.TemplateError
+		if errors.As(err, &tmplErr) {
+			WriteFieldError(w, "templates."+tmplErr.Field, "Invalid template: "+tmplErr.Err.Error())
+			return false
+		}
+		WriteFieldError(w, "templates", err.Error())
+		return false
+	}
+	return true
+}
+
 // UpdateRule updates an existing rule.
 func (h *AlertHandler) UpdateRule(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "ruleID")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid rule ID")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid rule ID")
 		return
 	}
 
 	var input domain.AlertRuleInput
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_json", "Invalid request body")
+	if !decodeRuleInput(w, r, &input) {
 		return
 	}
 
-	rule := h.service.UpdateRule(id, input)
+	orgID := middleware.GetOrgID(r.Context())
+
+	var before *domain.AlertRule
+	if existing := h.service.GetRule(orgID, id); existing != nil {
+		snapshot := *existing
+		before = &snapshot
+	}
+
+	rule := h.service.UpdateRule(orgID, id, input)
 	if rule == nil {
-		WriteError(w, http.StatusNotFound, "not_found", "Rule not found")
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Rule not found")
 		return
 	}
 
+	recordAudit(r, h.auditLogger, domain.AuditActionAlertRuleUpdate, "alert_rule", id.String(), before, rule)
+
 	WriteJSON(w, http.StatusOK, rule)
 }
 
@@ -119,21 +171,50 @@ func (h *AlertHandler) DeleteRule(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "ruleID")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid rule ID")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid rule ID")
 		return
 	}
 
-	if !h.service.DeleteRule(id) {
-		WriteError(w, http.StatusNotFound, "not_found", "Rule not found")
+	orgID := middleware.GetOrgID(r.Context())
+	before := h.service.GetRule(orgID, id)
+	if !h.service.DeleteRule(orgID, id) {
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Rule not found")
 		return
 	}
 
+	recordAudit(r, h.auditLogger, domain.AuditActionAlertRuleDelete, "alert_rule", id.String(), before, nil)
+
 	WriteJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
 }
 
-// ListChannels returns all alert channels.
+// RestoreRule restores a soft-deleted rule.
+func (h *AlertHandler) RestoreRule(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "ruleID")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid rule ID")
+		return
+	}
+
+	if !middleware.HasPermission(r.Context(), domain.PermissionAlertsAdmin) {
+		WriteError(w, http.StatusForbidden, response.CodeForbidden, "Restoring rules requires the alerts:admin permission")
+		return
+	}
+
+	rule := h.service.RestoreRule(middleware.GetOrgID(r.Context()), id)
+	if rule == nil {
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Deleted rule not found")
+		return
+	}
+
+	recordAudit(r, h.auditLogger, domain.AuditActionAlertRuleRestore, "alert_rule", id.String(), nil, rule)
+
+	WriteJSON(w, http.StatusOK, rule)
+}
+
+// ListChannels returns the organization's alert channels.
 func (h *AlertHandler) ListChannels(w http.ResponseWriter, r *http.Request) {
-	channels := h.service.ListChannels()
+	channels := h.service.ListChannels(middleware.GetOrgID(r.Context()))
 	WriteJSON(w, http.StatusOK, map[string]interface{}{
 		"channels": channels,
 		"total":    len(channels),
@@ -145,13 +226,13 @@ func (h *AlertHandler) GetChannel(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "channelID")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid channel ID")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid channel ID")
 		return
 	}
 
-	channel := h.service.GetChannel(id)
+	channel := h.service.GetChannel(middleware.GetOrgID(r.Context()), id)
 	if channel == nil {
-		WriteError(w, http.StatusNotFound, "not_found", "Channel not found")
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Channel not found")
 		return
 	}
 
@@ -161,29 +242,64 @@ func (h *AlertHandler) GetChannel(w http.ResponseWriter, r *http.Request) {
 // CreateChannel creates a new alert channel.
 func (h *AlertHandler) CreateChannel(w http.ResponseWriter, r *http.Request) {
 	var input domain.AlertChannelInput
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_json", "Invalid request body")
-		return
-	}
-
-	if input.Name == "" {
-		WriteError(w, http.StatusBadRequest, "validation_error", "Name is required")
+	if !decodeInput(w, r, &input) {
 		return
 	}
-	if input.Type == "" {
-		WriteError(w, http.StatusBadRequest, "validation_error", "Type is required")
+	if !validateChannelConfig(w, input) {
 		return
 	}
-	if input.Config == nil {
-		WriteError(w, http.StatusBadRequest, "validation_error", "Config is required")
-		return
+
+	channel := h.service.CreateChannel(input, middleware.GetOrgID(r.Context()))
+	recordAudit(r, h.auditLogger, domain.AuditActionAlertChannelCreate, "alert_channel", channel.ID.String(), nil, auditChannel(channel))
+	WriteJSON(w, http.StatusCreated, channel)
+}
+
+// validateChannelConfig checks type-specific channel settings that would
+// otherwise only fail at delivery time.
+func validateChannelConfig(w http.ResponseWriter, input domain.AlertChannelInput) bool {
+	if !validateChannelGrouping(w, input) {
+		return false
 	}
 
-	// Demo org
-	orgID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	switch input.Type {
+	case domain.AlertChannelEmail:
+		if _, err := email.ConfigFromChannel(input.Config); err != nil {
+			WriteFieldError(w, "config", "Invalid email config: "+err.Error())
+			return false
+		}
+	case domain.AlertChannelOpsgenie:
+		if key, _ := input.Config["api_key"].(string); key == "" {
+			WriteFieldError(w, "config.api_key", "Opsgenie api_key is required")
+			return false
+		}
+		region, _ := input.Config["region"].(string)
+		if _, err := webhook.OpsgenieBaseURL(region); err != nil {
+			WriteFieldError(w, "config.region", "Opsgenie region must be us or eu")
+			return false
+		}
+	}
+	return true
+}
 
-	channel := h.service.CreateChannel(input, orgID)
-	WriteJSON(w, http.StatusCreated, channel)
+// validateChannelGrouping checks a channel's grouping. Incident channels open
+// and resolve an incident per alert, so their alerts cannot be grouped.
+func validateChannelGrouping(w http.ResponseWriter, input domain.AlertChannelInput) bool {
+	grouping := input.Grouping
+	if grouping.GroupWaitSeconds < 0 || grouping.GroupWaitSeconds > domain.MaxAlertGroupWaitSeconds {
+		WriteFieldError(w, "grouping.group_wait_seconds", fmt.Sprintf("group_wait_seconds must be between 0 and %d", domain.MaxAlertGroupWaitSeconds))
+		return false
+	}
+	for _, label := range grouping.GroupBy {
+		if strings.TrimSpace(label) == "" {
+			WriteFieldError(w, "grouping.group_by", "group_by labels must not be empty")
+			return false
+		}
+	}
+	if grouping.Enabled() && (input.Type == domain.AlertChannelPagerDuty || input.Type == domain.AlertChannelOpsgenie) {
+		WriteFieldError(w, "grouping", fmt.Sprintf("%s channels open an incident per alert and cannot group alerts", input.Type))
+		return false
+	}
+	return true
 }
 
 // UpdateChannel updates an existing channel.
@@ -191,22 +307,29 @@ func (h *AlertHandler) UpdateChannel(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "channelID")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid channel ID")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid channel ID")
 		return
 	}
 
 	var input domain.AlertChannelInput
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_json", "Invalid request body")
+	if !decodeInput(w, r, &input) {
+		return
+	}
+	if !validateChannelConfig(w, input) {
 		return
 	}
 
-	channel := h.service.UpdateChannel(id, input)
+	orgID := middleware.GetOrgID(r.Context())
+	before := auditChannel(h.service.GetChannel(orgID, id))
+
+	channel := h.service.UpdateChannel(orgID, id, input)
 	if channel == nil {
-		WriteError(w, http.StatusNotFound, "not_found", "Channel not found")
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Channel not found")
 		return
 	}
 
+	recordAudit(r, h.auditLogger, domain.AuditActionAlertChannelUpdate, "alert_channel", id.String(), before, auditChannel(channel))
+
 	WriteJSON(w, http.StatusOK, channel)
 }
 
@@ -215,15 +338,107 @@ func (h *AlertHandler) DeleteChannel(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "channelID")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid channel ID")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid channel ID")
 		return
 	}
 
-	if !h.service.DeleteChannel(id) {
-		WriteError(w, http.StatusNotFound, "not_found", "Channel not found")
+	orgID := middleware.GetOrgID(r.Context())
+	before := auditChannel(h.service.GetChannel(orgID, id))
+	if !h.service.DeleteChannel(orgID, id) {
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Channel not found")
 		return
 	}
 
+	recordAudit(r, h.auditLogger, domain.AuditActionAlertChannelDelete, "alert_channel", id.String(), before, nil)
+
+	WriteJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// ListInhibitRules returns the organization's inhibit rules.
+func (h *AlertHandler) ListInhibitRules(w http.ResponseWriter, r *http.Request) {
+	rules := h.service.ListInhibitRules(middleware.GetOrgID(r.Context()))
+	WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"inhibit_rules": rules,
+		"total":         len(rules),
+	})
+}
+
+// GetInhibitRule returns a single inhibit rule by ID.
+func (h *AlertHandler) GetInhibitRule(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "inhibitRuleID")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid inhibit rule ID")
+		return
+	}
+
+	rule := h.service.GetInhibitRule(middleware.GetOrgID(r.Context()), id)
+	if rule == nil {
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Inhibit rule not found")
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, rule)
+}
+
+// CreateInhibitRule creates a new inhibit rule.
+func (h *AlertHandler) CreateInhibitRule(w http.ResponseWriter, r *http.Request) {
+	var input domain.InhibitRuleInput
+	if !decodeInput(w, r, &input) {
+		return
+	}
+
+	rule := h.service.CreateInhibitRule(input, middleware.GetOrgID(r.Context()))
+	recordAudit(r, h.auditLogger, domain.AuditActionInhibitRuleCreate, "inhibit_rule", rule.ID.String(), nil, rule)
+	WriteJSON(w, http.StatusCreated, rule)
+}
+
+// UpdateInhibitRule updates an existing inhibit rule.
+func (h *AlertHandler) UpdateInhibitRule(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "inhibitRuleID")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid inhibit rule ID")
+		return
+	}
+
+	var input domain.InhibitRuleInput
+	if !decodeInput(w, r, &input) {
+		return
+	}
+
+	orgID := middleware.GetOrgID(r.Context())
+	before := h.service.GetInhibitRule(orgID, id)
+
+	rule := h.service.UpdateInhibitRule(orgID, id, input)
+	if rule == nil {
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Inhibit rule not found")
+		return
+	}
+
+	recordAudit(r, h.auditLogger, domain.AuditActionInhibitRuleUpdate, "inhibit_rule", id.String(), before, rule)
+
+	WriteJSON(w, http.StatusOK, rule)
+}
+
+// DeleteInhibitRule deletes an inhibit rule.
+func (h *AlertHandler) DeleteInhibitRule(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "inhibitRuleID")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid inhibit rule ID")
+		return
+	}
+
+	orgID := middleware.GetOrgID(r.Context())
+	before := h.service.GetInhibitRule(orgID, id)
+	if !h.service.DeleteInhibitRule(orgID, id) {
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Inhibit rule not found")
+		return
+	}
+
+	recordAudit(r, h.auditLogger, domain.AuditActionInhibitRuleDelete, "inhibit_rule", id.String(), before, nil)
+
 	WriteJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
 }
 
@@ -232,12 +447,12 @@ func (h *AlertHandler) TestChannel(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "channelID")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid channel ID")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid channel ID")
 		return
 	}
 
-	if err := h.service.TestChannel(id); err != nil {
-		WriteError(w, http.StatusBadRequest, "test_failed", err.Error())
+	if err := h.service.TestChannel(middleware.GetOrgID(r.Context()), id); err != nil {
+		WriteError(w, http.StatusBadRequest, response.CodeTestFailed, err.Error())
 		return
 	}
 
@@ -247,16 +462,41 @@ func (h *AlertHandler) TestChannel(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// TestFireRule sends a synthetic alert for a rule through its channels and
+// reports delivery per channel.
+func (h *AlertHandler) TestFireRule(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "ruleID")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid rule ID")
+		return
+	}
+
+	result, err := h.service.TestFireRule(r.Context(), middleware.GetOrgID(r.Context()), id)
+	if err != nil {
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Rule not found")
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, result)
+}
+
 // ListAlerts returns alerts matching the filter.
 func (h *AlertHandler) ListAlerts(w http.ResponseWriter, r *http.Request) {
 	filter := h.parseAlertFilter(r)
+	cursor, ok := parseCursorParam(w, r)
+	if !ok {
+		return
+	}
+	filter.Cursor = cursor
+
 	page := h.service.GetAlerts(filter)
 	WriteJSON(w, http.StatusOK, page)
 }
 
-// GetActiveAlerts returns all currently firing alerts.
+// GetActiveAlerts returns the organization's currently firing alerts.
 func (h *AlertHandler) GetActiveAlerts(w http.ResponseWriter, r *http.Request) {
-	alerts := h.service.GetActiveAlerts()
+	alerts := h.service.GetActiveAlerts(middleware.GetOrgID(r.Context()))
 	WriteJSON(w, http.StatusOK, map[string]interface{}{
 		"alerts": alerts,
 		"total":  len(alerts),
@@ -268,16 +508,14 @@ func (h *AlertHandler) AcknowledgeAlert(w http.ResponseWriter, r *http.Request)
 	idStr := chi.URLParam(r, "alertID")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid alert ID")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid alert ID")
 		return
 	}
 
-	// Demo user
-	userID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
-
-	alert := h.service.AcknowledgeAlert(id, userID)
+	ctx := r.Context()
+	alert := h.service.AcknowledgeAlert(middleware.GetOrgID(ctx), id, domain.UserActor(middleware.GetUserID(ctx)))
 	if alert == nil {
-		WriteError(w, http.StatusNotFound, "not_found", "Alert not found")
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Alert not found")
 		return
 	}
 
@@ -289,27 +527,151 @@ func (h *AlertHandler) ResolveAlert(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "alertID")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid alert ID")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid alert ID")
 		return
 	}
 
-	alert := h.service.ResolveAlert(id)
+	ctx := r.Context()
+	alert := h.service.ResolveAlert(middleware.GetOrgID(ctx), id, domain.UserActor(middleware.GetUserID(ctx)))
 	if alert == nil {
-		WriteError(w, http.StatusNotFound, "not_found", "Alert not found")
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Alert not found")
 		return
 	}
 
 	WriteJSON(w, http.StatusOK, alert)
 }
 
+// GetAlertHistory returns every step of an alert's lifecycle, oldest first,
+// with the status they add up to.
+func (h *AlertHandler) GetAlertHistory(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "alertID")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid alert ID")
+		return
+	}
+
+	ctx := r.Context()
+	history, err := h.service.AlertHistory(ctx, middleware.GetOrgID(ctx), id)
+	if err != nil {
+		h.logger.Error().Err(err).Str("alert_id", id.String()).Msg("Failed to load alert history")
+		WriteError(w, http.StatusInternalServerError, response.CodeDBError, "Failed to load alert history")
+		return
+	}
+	if history == nil {
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Alert not found")
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, history)
+}
+
+// alertCallback is an inbound callback from an integration, such as an
+// incident being acknowledged or resolved in the integration's own UI.
+type alertCallback struct {
+	AlertID string `json:"alert_id"`
+	Action  string `json:"action"` // acknowledge or resolve
+}
+
+// InboundCallback applies an integration's callback to the alert it names.
+// Callbacks are verified by signature before they reach the handler, so the
+// alert's own organization is acted for.
+func (h *AlertHandler) InboundCallback(w http.ResponseWriter, r *http.Request) {
+	integration := chi.URLParam(r, "integration")
+
+	var callback alertCallback
+	// Integrations may send fields of their own, so unknown fields are
+	// ignored
+	if err := json.NewDecoder(r.Body).Decode(&callback); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+	id, err := uuid.Parse(callback.AlertID)
+	if err != nil {
+		WriteFieldError(w, "alert_id", "must be a valid alert ID")
+		return
+	}
+	if callback.Action != "acknowledge" && callback.Action != "resolve" {
+		WriteFieldError(w, "action", "must be one of acknowledge, resolve")
+		return
+	}
+
+	orgID, ok := h.service.AlertOrg(id)
+	if !ok {
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Alert not found")
+		return
+	}
+
+	var alert *domain.Alert
+	if callback.Action == "acknowledge" {
+		alert = h.service.AcknowledgeAlert(orgID, id, domain.IntegrationActor(integration))
+	} else {
+		alert = h.service.ResolveAlert(orgID, id, domain.IntegrationActor(integration))
+	}
+	if alert == nil {
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Alert not found")
+		return
+	}
+
+	h.logger.Info().
+		Str("integration", integration).
+		Str("alert_id", id.String()).
+		Str("action", callback.Action).
+		Msg("Applied inbound alert callback")
+
+	WriteJSON(w, http.StatusOK, alert)
+}
+
+// StreamAlerts streams alert events as Server-Sent Events.
+func (h *AlertHandler) StreamAlerts(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	severities := parseListParam(query.Get("severity"))
+	servers := parseListParam(query.Get("mcp_server"))
+	orgID := middleware.GetOrgID(r.Context())
+
+	alerts, cancel := h.service.Subscribe()
+	defer cancel()
+
+	flusher, ok := startSSE(w)
+	if !ok {
+		return
+	}
+
+	keepAlive := time.NewTicker(sseKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if err := writeSSEKeepAlive(w, flusher); err != nil {
+				return
+			}
+		case alert := <-alerts:
+			if alert.OrgID != orgID {
+				continue
+			}
+			if severities != nil && !severities[string(alert.Severity)] {
+				continue
+			}
+			if servers != nil && !servers[alert.Labels["mcp_server"]] {
+				continue
+			}
+			if err := writeSSE(w, flusher, "alert", alert); err != nil {
+				return
+			}
+		}
+	}
+}
+
 // TriggerTestAlert triggers a test alert for demo purposes.
 func (h *AlertHandler) TriggerTestAlert(w http.ResponseWriter, r *http.Request) {
 	var input struct {
 		Metric string  `json:"metric"`
 		Value  float64 `json:"value"`
 	}
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_json", "Invalid request body")
+	if !decodeInput(w, r, &input) {
 		return
 	}
 
@@ -320,9 +682,9 @@ func (h *AlertHandler) TriggerTestAlert(w http.ResponseWriter, r *http.Request)
 		input.Value = 10.0 // Default value above threshold
 	}
 
-	alert := h.service.TriggerTestAlert(input.Metric, input.Value)
+	alert := h.service.TriggerTestAlert(middleware.GetOrgID(r.Context()), input.Metric, input.Value)
 	if alert == nil {
-		WriteError(w, http.StatusInternalServerError, "trigger_failed", "Failed to trigger test alert")
+		WriteError(w, http.StatusInternalServerError, response.CodeTriggerFailed, "Failed to trigger test alert")
 		return
 	}
 
@@ -334,7 +696,7 @@ func (h *AlertHandler) parseAlertFilter(r *http.Request) domain.AlertFilter {
 	query := r.URL.Query()
 
 	filter := domain.AlertFilter{
-		OrgID: uuid.MustParse("00000000-0000-0000-0000-000000000001"),
+		OrgID: middleware.GetOrgID(r.Context()),
 	}
 
 	// Parse rule ID
@@ -390,3 +752,17 @@ func (h *AlertHandler) parseAlertFilter(r *http.Request) domain.AlertFilter {
 
 	return filter
 }
+
+// auditChannel returns a copy of the channel suitable for the audit log. Config
+// values may hold webhook URLs and integration keys, so only the keys are kept.
+func auditChannel(channel *domain.AlertChannel) *domain.AlertChannel {
+	if channel == nil {
+		return nil
+	}
+	snapshot := *channel
+	snapshot.Config = make(map[string]interface{}, len(channel.Config))
+	for key := range channel.Config {
+		snapshot.Config[key] = "[redacted]"
+	}
+	return &snapshot
+}
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/alerting"
+	"github.com/akz4ol/gatewayops/gateway/internal/clock"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/response"
+	"github.com/rs/zerolog"
+)
+
+func TestCreateRuleValidatesFilterKeys(t *testing.T) {
+	alerts := alerting.NewService(zerolog.Nop(), nil, nil, 100, clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)))
+	h := NewAlertHandler(zerolog.Nop(), alerts, nil)
+	create := func(filters string) *httptest.ResponseRecorder {
+		body := `{"name":"Shell errors","metric":"error_rate","condition":"gt","threshold":5,"severity":"warning","enabled":true,"filters":` + filters + `}`
+		rec := httptest.NewRecorder()
+		h.CreateRule(rec, httptest.NewRequest(http.MethodPost, "/v1/alerts/rules", strings.NewReader(body)))
+		return rec
+	}
+
+	rec := create(`{"server":["shell"]}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("unknown filter: status %d, want 400: %s", rec.Code, rec.Body)
+	}
+	var resp struct {
+		Error struct {
+			Details []response.FieldError `json:"details"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Error.Details) != 1 || resp.Error.Details[0].Field != "filters.server" {
+		t.Errorf("details = %+v, want the unknown filter named", resp.Error.Details)
+	}
+
+	rec = create(`{"mcp_servers":["shell"]}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("known filter: status %d, want 201: %s", rec.Code, rec.Body)
+	}
+	var rule domain.AlertRule
+	if err := json.Unmarshal(rec.Body.Bytes(), &rule); err != nil {
+		t.Fatalf("decode rule: %v", err)
+	}
+	if len(rule.Filters.MCPServers) != 1 || rule.Filters.MCPServers[0] != "shell" {
+		t.Errorf("rule filters = %+v, want the shell scope kept", rule.Filters)
+	}
+}
+
+func TestCreateRuleChecksThresholdAgainstItsMetric(t *testing.T) {
+	alerts := alerting.NewService(zerolog.Nop(), nil, nil, 100, clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)))
+	h := NewAlertHandler(zerolog.Nop(), alerts, nil)
+
+	tests := []struct {
+		name    string
+		rule    string
+		field   string
+		message string
+	}{
+		{
+			name:    "error rate over 100",
+			rule:    `"metric":"error_rate","condition":"gt","threshold":500`,
+			field:   "threshold",
+			message: "Threshold for error_rate is in percent and must be between 0 and 100",
+		},
+		{
+			name:    "negative latency",
+			rule:    `"metric":"latency_p95","condition":"gt","threshold":-1`,
+			field:   "threshold",
+			message: "Threshold for latency_p95 is in milliseconds and must be at least 0",
+		},
+		{
+			name:    "error rate that can never exceed 100",
+			rule:    `"metric":"error_rate","condition":"gt","threshold":100`,
+			field:   "threshold",
+			message: "error_rate can never be greater than 100",
+		},
+		{
+			name:    "equality on a measured value",
+			rule:    `"metric":"cost_per_hour","condition":"eq","threshold":10`,
+			field:   "condition",
+			message: "cost_per_hour is a measured value that almost never equals a threshold exactly; use gt, gte, lt or lte",
+		},
+		{name: "error rate in range", rule: `"metric":"error_rate","condition":"gt","threshold":5`},
+		{name: "count equal to a threshold", rule: `"metric":"injection_detected","condition":"eq","threshold":3`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := `{"name":"Rule","severity":"warning",` + tt.rule + `}`
+			rec := httptest.NewRecorder()
+			h.CreateRule(rec, asKey(httptest.NewRequest(http.MethodPost, "/v1/alerts/rules", strings.NewReader(body)), domain.PermissionAlertsAdmin))
+			if tt.field == "" {
+				if rec.Code != http.StatusCreated {
+					t.Errorf("status %d, want 201: %s", rec.Code, rec.Body)
+				}
+				return
+			}
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("status %d, want 400: %s", rec.Code, rec.Body)
+			}
+			var resp struct {
+				Error struct {
+					Details []response.FieldError `json:"details"`
+				} `json:"error"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if want := []response.FieldError{{Field: tt.field, Message: tt.message}}; !reflect.DeepEqual(resp.Error.Details, want) {
+				t.Errorf("details = %+v, want %+v", resp.Error.Details, want)
+			}
+		})
+	}
+}
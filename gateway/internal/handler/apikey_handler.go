@@ -8,6 +8,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/akz4ol/gatewayops/gateway/internal/response"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/akz4ol/gatewayops/gateway/internal/domain"
@@ -30,11 +31,7 @@ func NewAPIKeyHandler(logger zerolog.Logger, repo *repository.APIKeyRepository,
 
 // List returns all API keys for the authenticated organization.
 func (h *APIKeyHandler) List(w http.ResponseWriter, r *http.Request) {
-	authInfo := middleware.GetAuthInfo(r.Context())
-	orgID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
-	if authInfo != nil {
-		orgID = authInfo.OrgID
-	}
+	orgID := middleware.GetOrgID(r.Context())
 
 	// Parse query parameters
 	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
@@ -58,7 +55,7 @@ func (h *APIKeyHandler) List(w http.ResponseWriter, r *http.Request) {
 		keys, total, err := h.repo.List(r.Context(), filter)
 		if err != nil {
 			h.logger.Error().Err(err).Msg("Failed to list API keys")
-			WriteError(w, http.StatusInternalServerError, "internal_error", "Failed to list API keys")
+			WriteError(w, http.StatusInternalServerError, response.CodeInternalError, "Failed to list API keys")
 			return
 		}
 
@@ -142,22 +139,21 @@ func (h *APIKeyHandler) List(w http.ResponseWriter, r *http.Request) {
 
 // Create creates a new API key.
 func (h *APIKeyHandler) Create(w http.ResponseWriter, r *http.Request) {
-	authInfo := middleware.GetAuthInfo(r.Context())
-	orgID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
-	userID := uuid.MustParse("00000000-0000-0000-0000-000000000002")
-	if authInfo != nil {
-		orgID = authInfo.OrgID
-		userID = authInfo.UserID
-	}
+	orgID := middleware.GetOrgID(r.Context())
+	userID := middleware.GetUserID(r.Context())
 
 	var req domain.APIKeyCreate
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		writeDecodeError(w, err)
 		return
 	}
 
 	if req.Name == "" {
-		WriteError(w, http.StatusBadRequest, "invalid_request", "Name is required")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidRequest, "Name is required")
+		return
+	}
+	if _, err := middleware.ParseCIDRs(req.AllowedCIDRs); err != nil {
+		WriteFieldError(w, "allowed_cidrs", err.Error())
 		return
 	}
 
@@ -175,7 +171,7 @@ func (h *APIKeyHandler) Create(w http.ResponseWriter, r *http.Request) {
 	// Generate a random API key
 	keyBytes := make([]byte, 32)
 	if _, err := rand.Read(keyBytes); err != nil {
-		WriteError(w, http.StatusInternalServerError, "internal_error", "Failed to generate key")
+		WriteError(w, http.StatusInternalServerError, response.CodeInternalError, "Failed to generate key")
 		return
 	}
 	envPrefix := req.Environment
@@ -187,18 +183,19 @@ func (h *APIKeyHandler) Create(w http.ResponseWriter, r *http.Request) {
 	now := time.Now()
 	key := domain.APIKeyCreated{
 		APIKey: domain.APIKey{
-			ID:          uuid.New(),
-			OrgID:       orgID,
-			TeamID:      req.TeamID,
-			Name:        req.Name,
-			KeyPrefix:   rawKey[:16],
-			Environment: req.Environment,
-			Permissions: req.Permissions,
-			RateLimit:   req.RateLimit,
-			ExpiresAt:   req.ExpiresAt,
-			CreatedAt:   now,
-			CreatedBy:   userID,
-			Revoked:     false,
+			ID:           uuid.New(),
+			OrgID:        orgID,
+			TeamID:       req.TeamID,
+			Name:         req.Name,
+			KeyPrefix:    rawKey[:16],
+			Environment:  req.Environment,
+			Permissions:  req.Permissions,
+			RateLimit:    req.RateLimit,
+			AllowedCIDRs: req.AllowedCIDRs,
+			ExpiresAt:    req.ExpiresAt,
+			CreatedAt:    now,
+			CreatedBy:    userID,
+			Revoked:      false,
 		},
 		RawKey: rawKey,
 	}
@@ -207,7 +204,7 @@ func (h *APIKeyHandler) Create(w http.ResponseWriter, r *http.Request) {
 	if h.repo != nil {
 		if err := h.repo.Create(r.Context(), &key.APIKey, rawKey); err != nil {
 			h.logger.Error().Err(err).Msg("Failed to create API key")
-			WriteError(w, http.StatusInternalServerError, "internal_error", "Failed to create API key")
+			WriteError(w, http.StatusInternalServerError, response.CodeInternalError, "Failed to create API key")
 			return
 		}
 	}
@@ -223,21 +220,17 @@ func (h *APIKeyHandler) Create(w http.ResponseWriter, r *http.Request) {
 
 // Get returns a single API key by ID.
 func (h *APIKeyHandler) Get(w http.ResponseWriter, r *http.Request) {
-	authInfo := middleware.GetAuthInfo(r.Context())
-	orgID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
-	if authInfo != nil {
-		orgID = authInfo.OrgID
-	}
+	orgID := middleware.GetOrgID(r.Context())
 
 	keyID := chi.URLParam(r, "keyID")
 	if keyID == "" {
-		WriteError(w, http.StatusBadRequest, "invalid_request", "Key ID is required")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidRequest, "Key ID is required")
 		return
 	}
 
 	keyUUID, err := uuid.Parse(keyID)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid key ID format")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidRequest, "Invalid key ID format")
 		return
 	}
 
@@ -246,11 +239,11 @@ func (h *APIKeyHandler) Get(w http.ResponseWriter, r *http.Request) {
 		key, err := h.repo.Get(r.Context(), orgID, keyUUID)
 		if err != nil {
 			h.logger.Error().Err(err).Msg("Failed to get API key")
-			WriteError(w, http.StatusInternalServerError, "internal_error", "Failed to get API key")
+			WriteError(w, http.StatusInternalServerError, response.CodeInternalError, "Failed to get API key")
 			return
 		}
 		if key == nil {
-			WriteError(w, http.StatusNotFound, "not_found", "API key not found")
+			WriteError(w, http.StatusNotFound, response.CodeNotFound, "API key not found")
 			return
 		}
 
@@ -301,21 +294,17 @@ func (h *APIKeyHandler) Get(w http.ResponseWriter, r *http.Request) {
 
 // Delete revokes an API key.
 func (h *APIKeyHandler) Delete(w http.ResponseWriter, r *http.Request) {
-	authInfo := middleware.GetAuthInfo(r.Context())
-	orgID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
-	if authInfo != nil {
-		orgID = authInfo.OrgID
-	}
+	orgID := middleware.GetOrgID(r.Context())
 
 	keyID := chi.URLParam(r, "keyID")
 	if keyID == "" {
-		WriteError(w, http.StatusBadRequest, "invalid_request", "Key ID is required")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidRequest, "Key ID is required")
 		return
 	}
 
 	keyUUID, err := uuid.Parse(keyID)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid key ID format")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidRequest, "Invalid key ID format")
 		return
 	}
 
@@ -323,7 +312,7 @@ func (h *APIKeyHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	if h.repo != nil {
 		if err := h.repo.Revoke(r.Context(), orgID, keyUUID); err != nil {
 			h.logger.Error().Err(err).Msg("Failed to revoke API key")
-			WriteError(w, http.StatusInternalServerError, "internal_error", "Failed to revoke API key")
+			WriteError(w, http.StatusInternalServerError, response.CodeInternalError, "Failed to revoke API key")
 			return
 		}
 	}
@@ -339,23 +328,18 @@ func (h *APIKeyHandler) Delete(w http.ResponseWriter, r *http.Request) {
 
 // Rotate generates a new key while revoking the old one.
 func (h *APIKeyHandler) Rotate(w http.ResponseWriter, r *http.Request) {
-	authInfo := middleware.GetAuthInfo(r.Context())
-	orgID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
-	userID := uuid.MustParse("00000000-0000-0000-0000-000000000002")
-	if authInfo != nil {
-		orgID = authInfo.OrgID
-		userID = authInfo.UserID
-	}
+	orgID := middleware.GetOrgID(r.Context())
+	userID := middleware.GetUserID(r.Context())
 
 	keyID := chi.URLParam(r, "keyID")
 	if keyID == "" {
-		WriteError(w, http.StatusBadRequest, "invalid_request", "Key ID is required")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidRequest, "Key ID is required")
 		return
 	}
 
 	keyUUID, err := uuid.Parse(keyID)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid key ID format")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidRequest, "Invalid key ID format")
 		return
 	}
 
@@ -370,7 +354,7 @@ func (h *APIKeyHandler) Rotate(w http.ResponseWriter, r *http.Request) {
 		oldKey, err = h.repo.Get(r.Context(), orgID, keyUUID)
 		if err != nil {
 			h.logger.Error().Err(err).Msg("Failed to get old API key")
-			WriteError(w, http.StatusInternalServerError, "internal_error", "Failed to get API key")
+			WriteError(w, http.StatusInternalServerError, response.CodeInternalError, "Failed to get API key")
 			return
 		}
 		if oldKey != nil {
@@ -384,7 +368,7 @@ func (h *APIKeyHandler) Rotate(w http.ResponseWriter, r *http.Request) {
 	// Generate a new key
 	keyBytes := make([]byte, 32)
 	if _, err := rand.Read(keyBytes); err != nil {
-		WriteError(w, http.StatusInternalServerError, "internal_error", "Failed to generate key")
+		WriteError(w, http.StatusInternalServerError, response.CodeInternalError, "Failed to generate key")
 		return
 	}
 	envPrefix := environment
@@ -420,7 +404,7 @@ func (h *APIKeyHandler) Rotate(w http.ResponseWriter, r *http.Request) {
 		// Create new key
 		if err := h.repo.Create(r.Context(), &key.APIKey, rawKey); err != nil {
 			h.logger.Error().Err(err).Msg("Failed to create rotated API key")
-			WriteError(w, http.StatusInternalServerError, "internal_error", "Failed to create API key")
+			WriteError(w, http.StatusInternalServerError, response.CodeInternalError, "Failed to create API key")
 			return
 		}
 	}
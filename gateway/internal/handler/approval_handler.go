@@ -1,36 +1,75 @@
 package handler
 
 import (
-	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/akz4ol/gatewayops/gateway/internal/approval"
+	"github.com/akz4ol/gatewayops/gateway/internal/audit"
 	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/middleware"
+	"github.com/akz4ol/gatewayops/gateway/internal/pricing"
+	"github.com/akz4ol/gatewayops/gateway/internal/response"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 )
 
+// ToolCaller executes a tool call through the MCP proxy path, writing the
+// result to w.
+type ToolCaller interface {
+	CallTool(w http.ResponseWriter, r *http.Request, server, tool string, arguments map[string]interface{})
+}
+
+// SessionValidator validates SSO session tokens.
+type SessionValidator interface {
+	ValidateSession(token string) (*domain.UserSession, *domain.User)
+}
+
+// StepUpPolicy decides which grants need the reviewer to have signed in with
+// multi-factor authentication recently.
+type StepUpPolicy struct {
+	RiskLevel domain.ToolRiskLevel // Tools at or above this level need step-up; empty disables it
+	MaxAge    time.Duration        // How long ago the MFA sign-in may have been
+	ACRValues []string             // acr values that count as MFA besides the amr claim
+}
+
 // ApprovalHandler handles tool approval HTTP requests.
 type ApprovalHandler struct {
-	logger  zerolog.Logger
-	service *approval.Service
+	logger      zerolog.Logger
+	service     *approval.Service
+	auditLogger *audit.Logger
+	toolCaller  ToolCaller
+	sessions    SessionValidator
+	stepUp      StepUpPolicy
+	estimator   *pricing.CostEstimator
 }
 
-// NewApprovalHandler creates a new approval handler.
-func NewApprovalHandler(logger zerolog.Logger, service *approval.Service) *ApprovalHandler {
+// NewApprovalHandler creates a new approval handler. Approved calls are
+// replayed through toolCaller. Reviewers' MFA sign-ins are checked against
+// the SSO sessions validated by sessions. Approvals are served with the
+// estimated cost of the call they would allow.
+func NewApprovalHandler(logger zerolog.Logger, service *approval.Service, auditLogger *audit.Logger, toolCaller ToolCaller, sessions SessionValidator, stepUp StepUpPolicy, estimator *pricing.CostEstimator) *ApprovalHandler {
 	return &ApprovalHandler{
-		logger:  logger,
-		service: service,
+		logger:      logger,
+		service:     service,
+		auditLogger: auditLogger,
+		toolCaller:  toolCaller,
+		sessions:    sessions,
+		stepUp:      stepUp,
+		estimator:   estimator,
 	}
 }
 
-// ListClassifications returns all tool classifications.
+// ListClassifications returns the organization's tool classifications.
 func (h *ApprovalHandler) ListClassifications(w http.ResponseWriter, r *http.Request) {
 	server := r.URL.Query().Get("server")
-	classifications := h.service.ListClassifications(server)
+	classifications := h.service.ListClassifications(middleware.GetOrgID(r.Context()), server)
 	WriteJSON(w, http.StatusOK, map[string]interface{}{
 		"classifications": classifications,
 		"total":           len(classifications),
@@ -42,10 +81,11 @@ func (h *ApprovalHandler) GetClassification(w http.ResponseWriter, r *http.Reque
 	server := chi.URLParam(r, "server")
 	tool := chi.URLParam(r, "tool")
 
-	classification := h.service.GetClassification(server, tool)
+	orgID := middleware.GetOrgID(r.Context())
+	classification := h.service.GetClassification(orgID, server, tool)
 	if classification == nil {
-		// Return default classification
-		defaultLevel := domain.GetDefaultClassification(tool)
+		// Return the classification the org's unknown tool policy gives it
+		defaultLevel := h.service.DefaultClassification(orgID, server, tool)
 		WriteJSON(w, http.StatusOK, map[string]interface{}{
 			"server":           server,
 			"tool":             tool,
@@ -62,29 +102,56 @@ func (h *ApprovalHandler) GetClassification(w http.ResponseWriter, r *http.Reque
 // SetClassification sets or updates a tool classification.
 func (h *ApprovalHandler) SetClassification(w http.ResponseWriter, r *http.Request) {
 	var input domain.ToolClassificationInput
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_json", "Invalid request body")
+	if !decodeInput(w, r, &input) {
 		return
 	}
+	defaultClassification(&input)
 
-	if input.MCPServer == "" {
-		WriteError(w, http.StatusBadRequest, "validation_error", "MCP server is required")
+	orgID := middleware.GetOrgID(r.Context())
+	userID := middleware.GetUserID(r.Context())
+
+	before := h.snapshotClassification(orgID, input.MCPServer, input.ToolName)
+	classification := h.service.SetClassification(r.Context(), input, orgID, userID)
+	recordAudit(r, h.auditLogger, domain.AuditActionToolClassificationSet, "tool_classification", classificationResourceID(input.MCPServer, input.ToolName), before, classification)
+	WriteJSON(w, http.StatusOK, classification)
+}
+
+// BulkSetClassifications sets or updates several tool classifications. The
+// whole batch is validated before any classification is applied.
+func (h *ApprovalHandler) BulkSetClassifications(w http.ResponseWriter, r *http.Request) {
+	var input domain.BulkToolClassificationInput
+	if !decodeInput(w, r, &input) {
 		return
 	}
-	if input.ToolName == "" {
-		WriteError(w, http.StatusBadRequest, "validation_error", "Tool name is required")
-		return
+	for i := range input.Classifications {
+		defaultClassification(&input.Classifications[i])
 	}
-	if input.Classification == "" {
-		input.Classification = domain.ToolRiskSensitive
+
+	orgID := middleware.GetOrgID(r.Context())
+	userID := middleware.GetUserID(r.Context())
+
+	befores := make([]*domain.ToolClassification, len(input.Classifications))
+	for i, c := range input.Classifications {
+		befores[i] = h.snapshotClassification(orgID, c.MCPServer, c.ToolName)
 	}
 
-	// Demo org and user
-	orgID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
-	userID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	classifications := h.service.SetClassifications(r.Context(), input.Classifications, orgID, userID)
+	for i := range classifications {
+		c := &classifications[i]
+		recordAudit(r, h.auditLogger, domain.AuditActionToolClassificationSet, "tool_classification", classificationResourceID(c.MCPServer, c.ToolName), befores[i], c)
+	}
+	WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"classifications": classifications,
+		"total":           len(classifications),
+	})
+}
 
-	classification := h.service.SetClassification(input, orgID, userID)
-	WriteJSON(w, http.StatusOK, classification)
+// defaultClassification applies the default risk level to a validated
+// classification input.
+func defaultClassification(input *domain.ToolClassificationInput) {
+	if input.Classification == "" {
+		input.Classification = domain.ToolRiskSensitive
+	}
 }
 
 // DeleteClassification removes a tool classification.
@@ -92,31 +159,54 @@ func (h *ApprovalHandler) DeleteClassification(w http.ResponseWriter, r *http.Re
 	server := chi.URLParam(r, "server")
 	tool := chi.URLParam(r, "tool")
 
-	// Demo org
-	orgID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	orgID := middleware.GetOrgID(r.Context())
 
-	if !h.service.DeleteClassification(server, tool, orgID) {
-		WriteError(w, http.StatusNotFound, "not_found", "Classification not found")
+	before := h.snapshotClassification(orgID, server, tool)
+	if !h.service.DeleteClassification(r.Context(), server, tool, orgID) {
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Classification not found")
 		return
 	}
 
+	recordAudit(r, h.auditLogger, domain.AuditActionToolClassificationDelete, "tool_classification", classificationResourceID(server, tool), before, nil)
+
 	WriteJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
 }
 
+// snapshotClassification returns a copy of the stored classification for the
+// audit log, or nil if the tool is unclassified.
+func (h *ApprovalHandler) snapshotClassification(orgID uuid.UUID, server, tool string) *domain.ToolClassification {
+	existing := h.service.GetClassification(orgID, server, tool)
+	if existing == nil {
+		return nil
+	}
+	snapshot := *existing
+	return &snapshot
+}
+
+// classificationResourceID identifies a tool classification in the audit log.
+func classificationResourceID(server, tool string) string {
+	return server + ":" + tool
+}
+
 // CheckAccess checks if access is allowed to a tool.
 func (h *ApprovalHandler) CheckAccess(w http.ResponseWriter, r *http.Request) {
 	server := r.URL.Query().Get("server")
 	tool := r.URL.Query().Get("tool")
 
-	if server == "" || tool == "" {
-		WriteError(w, http.StatusBadRequest, "validation_error", "Server and tool are required")
+	var fields []FieldError
+	if server == "" {
+		fields = append(fields, FieldError{Field: "server", Message: "Server is required"})
+	}
+	if tool == "" {
+		fields = append(fields, FieldError{Field: "tool", Message: "Tool is required"})
+	}
+	if len(fields) > 0 {
+		WriteValidationError(w, "Server and tool are required", fields)
 		return
 	}
 
-	// Demo user
-	userID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
-
-	allowed, reason := h.service.CheckAccess(userID, nil, server, tool)
+	ctx := r.Context()
+	allowed, reason := h.service.CheckAccess(middleware.GetOrgID(ctx), middleware.GetUserID(ctx), nil, server, tool)
 
 	WriteJSON(w, http.StatusOK, map[string]interface{}{
 		"allowed": allowed,
@@ -129,7 +219,19 @@ func (h *ApprovalHandler) CheckAccess(w http.ResponseWriter, r *http.Request) {
 // ListApprovals returns tool approval requests.
 func (h *ApprovalHandler) ListApprovals(w http.ResponseWriter, r *http.Request) {
 	filter := h.parseApprovalFilter(r)
+	cursor, ok := parseCursorParam(w, r)
+	if !ok {
+		return
+	}
+	filter.Cursor = cursor
+	if filter.Query, ok = parseSearchParam(w, r); !ok {
+		return
+	}
+
 	page := h.service.ListApprovals(filter)
+	for i := range page.Approvals {
+		h.estimateCost(&page.Approvals[i])
+	}
 	WriteJSON(w, http.StatusOK, page)
 }
 
@@ -138,69 +240,118 @@ func (h *ApprovalHandler) GetApproval(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "approvalID")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid approval ID")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid approval ID")
 		return
 	}
 
-	approval := h.service.GetApproval(id)
+	approval := h.service.GetApproval(middleware.GetOrgID(r.Context()), id)
 	if approval == nil {
-		WriteError(w, http.StatusNotFound, "not_found", "Approval not found")
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Approval not found")
 		return
 	}
 
+	h.estimateCost(approval)
 	WriteJSON(w, http.StatusOK, approval)
 }
 
 // RequestApproval creates a new approval request.
 func (h *ApprovalHandler) RequestApproval(w http.ResponseWriter, r *http.Request) {
 	var input domain.ToolApprovalRequest
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_json", "Invalid request body")
+	if !decodeInput(w, r, &input) {
 		return
 	}
 
 	if input.MCPServer == "" {
-		WriteError(w, http.StatusBadRequest, "validation_error", "MCP server is required")
+		WriteFieldError(w, "mcp_server", "MCP server is required")
 		return
 	}
 	if input.ToolName == "" {
-		WriteError(w, http.StatusBadRequest, "validation_error", "Tool name is required")
+		WriteFieldError(w, "tool_name", "Tool name is required")
 		return
 	}
 
-	// Demo org and user
-	orgID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
-	userID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	orgID := middleware.GetOrgID(r.Context())
+	userID := middleware.GetUserID(r.Context())
 
-	approval := h.service.RequestApproval(input, orgID, userID)
+	// A team API key requests for its own team, which auto-approval rules
+	// match on
+	if authInfo := middleware.GetAuthInfo(r.Context()); authInfo != nil && authInfo.TeamID != uuid.Nil {
+		input.TeamID = &authInfo.TeamID
+	}
+
+	approval := h.service.RequestApproval(r.Context(), input, orgID, userID)
+	if approval.AutoApproval != "" {
+		logAuditEvent(r, h.auditLogger, domain.AuditActionApprovalAuto, "approval", approval.ID.String(), domain.AuditOutcomeSuccess, map[string]interface{}{
+			"rule":  approval.AutoApproval,
+			"after": approval,
+		})
+	}
+	h.estimateCost(approval)
 	WriteJSON(w, http.StatusCreated, approval)
 }
 
+// estimateCost prices the call an approval would allow so reviewers can
+// weigh its cost.
+func (h *ApprovalHandler) estimateCost(approval *domain.ToolApproval) {
+	if h.estimator != nil {
+		approval.EstimatedCost = h.estimator.Estimate(approval.MCPServer, approval.ToolName, approval.Arguments)
+	}
+}
+
+// requireReviewer writes a 403 and returns false unless the caller may
+// review approval requests.
+func requireReviewer(w http.ResponseWriter, r *http.Request) bool {
+	if !middleware.HasPermission(r.Context(), domain.PermissionApprovalsReview) {
+		WriteError(w, http.StatusForbidden, response.CodeForbidden, "API key lacks the approvals:review permission")
+		return false
+	}
+	return true
+}
+
 // ApproveRequest approves an approval request.
 func (h *ApprovalHandler) ApproveRequest(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "approvalID")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid approval ID")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid approval ID")
+		return
+	}
+	if !requireReviewer(w, r) {
 		return
 	}
 
 	var review domain.ToolApprovalReview
-	if err := json.NewDecoder(r.Body).Decode(&review); err != nil {
-		// Allow empty body for simple approval
-		review = domain.ToolApprovalReview{}
+	// An empty body approves with no note
+	if err := decodeStrict(r, &review); err != nil && !errors.Is(err, io.EOF) {
+		writeDecodeError(w, err)
+		return
 	}
 	review.Status = domain.ApprovalStatusApproved
 
-	// Demo reviewer
-	reviewerID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	ctx := r.Context()
+	orgID := middleware.GetOrgID(ctx)
+	before := h.service.GetApproval(orgID, id)
+	if before == nil {
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Approval not found")
+		return
+	}
+	mfa := h.reviewerMFA(r, h.requiresStepUp(orgID, before.MCPServer, before.ToolName))
+	if mfa.Required && !mfa.Verified {
+		h.rejectStepUp(w, r, domain.AuditActionApprovalGrant, "approval", id.String(), mfa)
+		return
+	}
 
-	approval := h.service.ReviewApproval(id, review, reviewerID)
+	approval := h.service.ReviewApproval(ctx, orgID, id, review, middleware.GetUserID(ctx))
 	if approval == nil {
-		WriteError(w, http.StatusNotFound, "not_found", "Approval not found")
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Approval not found")
 		return
 	}
 
+	logAuditEvent(r, h.auditLogger, domain.AuditActionApprovalGrant, "approval", id.String(), domain.AuditOutcomeSuccess, map[string]interface{}{
+		"before": before,
+		"after":  approval,
+		"mfa":    mfa,
+	})
 	WriteJSON(w, http.StatusOK, approval)
 }
 
@@ -209,32 +360,206 @@ func (h *ApprovalHandler) DenyRequest(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "approvalID")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid approval ID")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid approval ID")
+		return
+	}
+	if !requireReviewer(w, r) {
 		return
 	}
 
 	var review domain.ToolApprovalReview
-	if err := json.NewDecoder(r.Body).Decode(&review); err != nil {
-		review = domain.ToolApprovalReview{}
+	if err := decodeStrict(r, &review); err != nil && !errors.Is(err, io.EOF) {
+		writeDecodeError(w, err)
+		return
 	}
 	review.Status = domain.ApprovalStatusDenied
 
-	// Demo reviewer
-	reviewerID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
-
-	approval := h.service.ReviewApproval(id, review, reviewerID)
+	ctx := r.Context()
+	orgID := middleware.GetOrgID(ctx)
+	before := h.service.GetApproval(orgID, id)
+	approval := h.service.ReviewApproval(ctx, orgID, id, review, middleware.GetUserID(ctx))
 	if approval == nil {
-		WriteError(w, http.StatusNotFound, "not_found", "Approval not found")
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Approval not found")
 		return
 	}
 
+	// Denying never needs step-up, but the reviewer's MFA status is still
+	// recorded
+	logAuditEvent(r, h.auditLogger, domain.AuditActionApprovalDeny, "approval", id.String(), domain.AuditOutcomeSuccess, map[string]interface{}{
+		"before": before,
+		"after":  approval,
+		"mfa":    h.reviewerMFA(r, false),
+	})
 	WriteJSON(w, http.StatusOK, approval)
 }
 
-// ListPermissions returns all tool permissions.
+// maxBulkReview is the most approval requests one bulk review acts on.
+const maxBulkReview = 500
+
+// BulkReviewApprovals handles POST /v1/approvals/bulk-review. It
+// approves or denies the listed requests, or up to maxBulkReview pending
+// requests matching a filter, in one transaction, and returns the outcome for
+// each. Every request is checked on its own: approving one whose tool needs
+// step-up is skipped unless the reviewer shows a recent MFA sign-in.
+func (h *ApprovalHandler) BulkReviewApprovals(w http.ResponseWriter, r *http.Request) {
+	var input domain.BulkApprovalReviewInput
+	if !decodeInput(w, r, &input) {
+		return
+	}
+	if (len(input.IDs) == 0) == (input.Filter == nil) {
+		WriteFieldError(w, "ids", "Give either ids or filter")
+		return
+	}
+	if len(input.IDs) > maxBulkReview {
+		WriteFieldError(w, "ids", fmt.Sprintf("ids may list at most %d approvals", maxBulkReview))
+		return
+	}
+
+	if !requireReviewer(w, r) {
+		return
+	}
+	ctx := r.Context()
+	orgID := middleware.GetOrgID(ctx)
+
+	ids := input.IDs
+	hasMore := false
+	if f := input.Filter; f != nil {
+		page := h.service.ListApprovals(domain.ToolApprovalFilter{
+			OrgID:       orgID,
+			TeamID:      f.TeamID,
+			MCPServer:   f.MCPServer,
+			ToolName:    f.ToolName,
+			RequestedBy: f.RequestedBy,
+			Statuses:    []domain.ApprovalStatus{domain.ApprovalStatusPending},
+			Limit:       maxBulkReview,
+		})
+		for _, approval := range page.Approvals {
+			ids = append(ids, approval.ID)
+		}
+		hasMore = page.HasMore
+	}
+
+	review := domain.ToolApprovalReview{Status: input.Status, ReviewNote: input.ReviewNote, ExpiresIn: input.ExpiresIn}
+	approving := review.Status == domain.ApprovalStatusApproved
+	session := h.reviewerMFA(r, false)
+
+	// Step-up is checked for each request before any is reviewed
+	results := make([]domain.ApprovalReviewResult, len(ids))
+	befores := make(map[uuid.UUID]*domain.ToolApproval, len(ids))
+	stepUp := make(map[uuid.UUID]bool, len(ids))
+	var allowed []uuid.UUID
+	var positions []int
+	for n, id := range ids {
+		before := h.service.GetApproval(orgID, id)
+		befores[id] = before
+		if approving && before != nil && before.Status == domain.ApprovalStatusPending {
+			stepUp[id] = h.requiresStepUp(orgID, before.MCPServer, before.ToolName)
+			if stepUp[id] && !session.Verified {
+				results[n] = domain.ApprovalReviewResult{ID: id, Outcome: domain.ApprovalReviewStepUpRequired, Approval: before}
+				mfa := session
+				mfa.Required = true
+				logAuditEvent(r, h.auditLogger, domain.AuditActionApprovalGrant, "approval", id.String(), domain.AuditOutcomeBlocked, map[string]interface{}{
+					"reason": "step_up_required",
+					"mfa":    mfa,
+				})
+				continue
+			}
+		}
+		allowed = append(allowed, id)
+		positions = append(positions, n)
+	}
+
+	reviewed, err := h.service.ReviewApprovals(r.Context(), orgID, allowed, review, middleware.GetUserID(ctx))
+	if err != nil {
+		h.logger.Error().Err(err).Str("org_id", orgID.String()).Msg("Failed to review approvals")
+		WriteError(w, http.StatusInternalServerError, response.CodeInternalError, "Failed to review approvals")
+		return
+	}
+
+	action := domain.AuditActionApprovalDeny
+	if approving {
+		action = domain.AuditActionApprovalGrant
+	}
+	count := 0
+	for k, result := range reviewed {
+		results[positions[k]] = result
+		if result.Outcome != domain.ApprovalReviewApplied {
+			continue
+		}
+		count++
+		mfa := session
+		mfa.Required = stepUp[result.ID]
+		logAuditEvent(r, h.auditLogger, action, "approval", result.ID.String(), domain.AuditOutcomeSuccess, map[string]interface{}{
+			"before": befores[result.ID],
+			"after":  result.Approval,
+			"mfa":    mfa,
+			"bulk":   true,
+		})
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"results":  results,
+		"reviewed": count,
+		"total":    len(results),
+		"has_more": hasMore,
+	})
+}
+
+// ReplayApproval handles POST /v1/tools/approvals/{approvalID}/replay. It
+// executes the approved call with its original arguments and returns the MCP
+// result. Only the requester or an approvals reviewer may replay, and only
+// while the approval is valid.
+func (h *ApprovalHandler) ReplayApproval(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "approvalID"))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid approval ID")
+		return
+	}
+
+	ctx := r.Context()
+	replay, err := h.service.ReplayableApproval(middleware.GetOrgID(ctx), id)
+	if replay == nil {
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Approval not found")
+		return
+	}
+	if replay.RequestedBy != middleware.GetUserID(ctx) && !middleware.HasPermission(ctx, domain.PermissionApprovalsReview) {
+		WriteError(w, http.StatusForbidden, response.CodeForbidden, "Only the requester or an approvals reviewer can replay this call")
+		return
+	}
+	switch err {
+	case nil:
+	case approval.ErrApprovalNotGranted:
+		WriteError(w, http.StatusConflict, response.CodeApprovalNotGranted, fmt.Sprintf("Approval is %s; only approved calls can be replayed", replay.Status))
+		return
+	case approval.ErrApprovalExpired:
+		WriteError(w, http.StatusConflict, response.CodeApprovalExpired, "Approval has expired")
+		return
+	case approval.ErrApprovalRedacted:
+		WriteError(w, http.StatusConflict, response.CodeApprovalRedacted, "Approval's arguments were redacted; send the tool call again")
+		return
+	}
+	if h.toolCaller == nil {
+		WriteError(w, http.StatusInternalServerError, response.CodeInternalError, "Tool calls cannot be replayed")
+		return
+	}
+
+	recordAudit(r, h.auditLogger, domain.AuditActionApprovalReplay, "approval", replay.ID.String(), nil, map[string]interface{}{
+		"mcp_server": replay.MCPServer,
+		"tool_name":  replay.ToolName,
+	})
+	h.logger.Info().
+		Str("approval_id", replay.ID.String()).
+		Str("server", replay.MCPServer).
+		Str("tool", replay.ToolName).
+		Msg("Replaying approved tool call")
+
+	h.toolCaller.CallTool(w, r, replay.MCPServer, replay.ToolName, replay.Arguments)
+}
+
+// ListPermissions returns the organization's tool permissions.
 func (h *ApprovalHandler) ListPermissions(w http.ResponseWriter, r *http.Request) {
 	server := r.URL.Query().Get("server")
-	permissions := h.service.ListPermissions(server)
+	permissions := h.service.ListPermissions(middleware.GetOrgID(r.Context()), server)
 	WriteJSON(w, http.StatusOK, map[string]interface{}{
 		"permissions": permissions,
 		"total":       len(permissions),
@@ -251,29 +576,35 @@ func (h *ApprovalHandler) GrantPermission(w http.ResponseWriter, r *http.Request
 		ExpiresIn  *int       `json:"expires_in,omitempty"` // seconds
 		MaxUsesDay *int       `json:"max_uses_day,omitempty"`
 	}
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_json", "Invalid request body")
+	if !decodeInput(w, r, &input) {
 		return
 	}
 
 	if input.MCPServer == "" {
-		WriteError(w, http.StatusBadRequest, "validation_error", "MCP server is required")
+		WriteFieldError(w, "mcp_server", "MCP server is required")
 		return
 	}
 	if input.ToolName == "" {
-		WriteError(w, http.StatusBadRequest, "validation_error", "Tool name is required")
+		WriteFieldError(w, "tool_name", "Tool name is required")
 		return
 	}
 	if input.UserID == nil && input.TeamID == nil {
-		WriteError(w, http.StatusBadRequest, "validation_error", "Either user_id or team_id is required")
+		WriteFieldError(w, "user_id", "Either user_id or team_id is required")
 		return
 	}
 
-	// Demo org and granter
-	orgID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
-	granterID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	orgID := middleware.GetOrgID(r.Context())
+	granterID := middleware.GetUserID(r.Context())
+
+	// A permission is the break-glass route to dangerous tools
+	mfa := h.reviewerMFA(r, h.requiresStepUp(orgID, input.MCPServer, input.ToolName))
+	if mfa.Required && !mfa.Verified {
+		h.rejectStepUp(w, r, domain.AuditActionToolPermissionGrant, "tool_permission", classificationResourceID(input.MCPServer, input.ToolName), mfa)
+		return
+	}
 
 	permission := h.service.GrantPermission(
+		r.Context(),
 		orgID,
 		input.UserID,
 		input.TeamID,
@@ -285,10 +616,14 @@ func (h *ApprovalHandler) GrantPermission(w http.ResponseWriter, r *http.Request
 	)
 
 	if permission == nil {
-		WriteError(w, http.StatusBadRequest, "grant_failed", "Failed to grant permission")
+		WriteError(w, http.StatusBadRequest, response.CodeGrantFailed, "Failed to grant permission")
 		return
 	}
 
+	logAuditEvent(r, h.auditLogger, domain.AuditActionToolPermissionGrant, "tool_permission", permission.ID.String(), domain.AuditOutcomeSuccess, map[string]interface{}{
+		"after": permission,
+		"mfa":   mfa,
+	})
 	WriteJSON(w, http.StatusCreated, permission)
 }
 
@@ -297,21 +632,99 @@ func (h *ApprovalHandler) RevokePermission(w http.ResponseWriter, r *http.Reques
 	idStr := chi.URLParam(r, "permissionID")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid permission ID")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid permission ID")
 		return
 	}
 
-	if !h.service.RevokePermission(id) {
-		WriteError(w, http.StatusNotFound, "not_found", "Permission not found")
+	if !h.service.RevokePermission(middleware.GetOrgID(r.Context()), id) {
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Permission not found")
 		return
 	}
 
 	WriteJSON(w, http.StatusOK, map[string]string{"status": "revoked"})
 }
 
-// GetPendingCount returns the count of pending approvals.
+// mfaStatus describes a reviewer's multi-factor sign-in at the time of a
+// decision. It is recorded in the decision's audit event.
+type mfaStatus struct {
+	Required bool       `json:"required"`            // Whether the decision needed step-up
+	Verified bool       `json:"verified"`            // Whether a recent enough MFA sign-in was shown
+	Methods  []string   `json:"methods,omitempty"`   // amr of the reviewer's session
+	ACR      string     `json:"acr,omitempty"`       // acr of the reviewer's session
+	AuthTime *time.Time `json:"auth_time,omitempty"` // When the reviewer last signed in
+}
+
+// requiresStepUp reports whether granting access to a tool needs step-up
+// under the organization's classification of it.
+func (h *ApprovalHandler) requiresStepUp(orgID uuid.UUID, server, tool string) bool {
+	if h.stepUp.RiskLevel == "" {
+		return false
+	}
+	level := h.service.DefaultClassification(orgID, server, tool)
+	if classification := h.service.GetClassification(orgID, server, tool); classification != nil {
+		level = classification.Classification
+	}
+	return level.AtLeast(h.stepUp.RiskLevel)
+}
+
+// reviewerMFA reads the reviewer's SSO session, sent as the session cookie or
+// the X-Session-Token header alongside the API key, and reports whether it
+// shows an MFA sign-in within the policy's max age. A session belonging to
+// anyone but the caller is ignored.
+func (h *ApprovalHandler) reviewerMFA(r *http.Request, required bool) mfaStatus {
+	status := mfaStatus{Required: required}
+
+	token := r.Header.Get(middleware.SessionHeader)
+	if cookie, err := r.Cookie("session"); token == "" && err == nil {
+		token = cookie.Value
+	}
+	if token == "" || h.sessions == nil {
+		return status
+	}
+	ctx := r.Context()
+	session, _ := h.sessions.ValidateSession(token)
+	if session == nil || session.UserID != middleware.GetUserID(ctx) || session.OrgID != middleware.GetOrgID(ctx) {
+		return status
+	}
+
+	authTime := session.AuthTime
+	status.Methods = session.AMR
+	status.ACR = session.ACR
+	status.AuthTime = &authTime
+	status.Verified = session.MFAVerified(h.stepUp.ACRValues) && time.Since(authTime) <= h.stepUp.MaxAge
+	return status
+}
+
+// rejectStepUp refuses a grant that needs a fresh MFA sign-in. The response
+// carries an RFC 9470 challenge, and its details give the max_age and
+// acr_values to pass to /v1/sso/authorize when re-authenticating.
+func (h *ApprovalHandler) rejectStepUp(w http.ResponseWriter, r *http.Request, action domain.AuditAction, resource, resourceID string, mfa mfaStatus) {
+	logAuditEvent(r, h.auditLogger, action, resource, resourceID, domain.AuditOutcomeBlocked, map[string]interface{}{
+		"reason": "step_up_required",
+		"mfa":    mfa,
+	})
+	h.logger.Warn().
+		Str("resource", resource).
+		Str("resource_id", resourceID).
+		Str("user_id", middleware.GetUserID(r.Context()).String()).
+		Msg("Grant refused without a recent MFA sign-in")
+
+	maxAge := int(h.stepUp.MaxAge.Seconds())
+	challenge := fmt.Sprintf(`Bearer error="insufficient_user_authentication", error_description="A recent multi-factor sign-in is required", max_age=%d`, maxAge)
+	details := map[string]interface{}{"max_age": maxAge}
+	if len(h.stepUp.ACRValues) > 0 {
+		acrValues := strings.Join(h.stepUp.ACRValues, " ")
+		challenge += fmt.Sprintf(`, acr_values="%s"`, acrValues)
+		details["acr_values"] = acrValues
+	}
+	w.Header().Set("WWW-Authenticate", challenge)
+	response.WriteErrorDetails(w, http.StatusUnauthorized, response.CodeStepUpRequired,
+		"This grant requires a recent multi-factor sign-in; re-authenticate through SSO and retry with the new session token", details)
+}
+
+// GetPendingCount returns the count of the organization's pending approvals.
 func (h *ApprovalHandler) GetPendingCount(w http.ResponseWriter, r *http.Request) {
-	count := h.service.GetPendingCount()
+	count := h.service.GetPendingCount(middleware.GetOrgID(r.Context()))
 	WriteJSON(w, http.StatusOK, map[string]int{"pending_count": count})
 }
 
@@ -319,7 +732,7 @@ func (h *ApprovalHandler) parseApprovalFilter(r *http.Request) domain.ToolApprov
 	query := r.URL.Query()
 
 	filter := domain.ToolApprovalFilter{
-		OrgID: uuid.MustParse("00000000-0000-0000-0000-000000000001"),
+		OrgID: middleware.GetOrgID(r.Context()),
 	}
 
 	if server := query.Get("server"); server != "" {
@@ -328,6 +741,9 @@ func (h *ApprovalHandler) parseApprovalFilter(r *http.Request) domain.ToolApprov
 	if tool := query.Get("tool"); tool != "" {
 		filter.ToolName = tool
 	}
+	if traceID := query.Get("trace_id"); traceID != "" {
+		filter.TraceID = traceID
+	}
 	if statusesStr := query.Get("statuses"); statusesStr != "" {
 		statuses := strings.Split(statusesStr, ",")
 		for _, s := range statuses {
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/approval"
+	"github.com/akz4ol/gatewayops/gateway/internal/clock"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/middleware"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// newTestApprovalHandler returns an approval handler over an in-memory
+// service holding one pending request.
+func newTestApprovalHandler(t *testing.T) (*ApprovalHandler, *approval.Service, *domain.ToolApproval) {
+	t.Helper()
+	service := approval.NewService(zerolog.Nop(), nil, nil, nil, nil, nil, 100, clock.Real)
+	pending := service.RequestApproval(context.Background(), domain.ToolApprovalRequest{MCPServer: "filesystem", ToolName: "write_file"}, middleware.DemoOrgID, middleware.DemoUserID)
+	if pending.Status != domain.ApprovalStatusPending {
+		t.Fatalf("approval status = %s, want pending", pending.Status)
+	}
+	h := NewApprovalHandler(zerolog.Nop(), service, nil, nil, nil, StepUpPolicy{}, nil)
+	return h, service, pending
+}
+
+// asKey returns the request authenticated as a demo-org API key holding the
+// permissions.
+func asKey(r *http.Request, permissions ...domain.Permission) *http.Request {
+	info := &middleware.AuthInfo{OrgID: middleware.DemoOrgID, UserID: middleware.DemoUserID}
+	for _, p := range permissions {
+		info.Permissions = append(info.Permissions, string(p))
+	}
+	return r.WithContext(context.WithValue(r.Context(), middleware.AuthInfoKey, info))
+}
+
+// withApprovalID sets the approvalID route parameter.
+func withApprovalID(r *http.Request, id uuid.UUID) *http.Request {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("approvalID", id.String())
+	return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestReviewRequiresApprovalsReview(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler func(h *ApprovalHandler) http.HandlerFunc
+		body    func(id uuid.UUID) string
+	}{
+		{
+			name:    "approve",
+			handler: func(h *ApprovalHandler) http.HandlerFunc { return h.ApproveRequest },
+			body:    func(uuid.UUID) string { return "" },
+		},
+		{
+			name:    "deny",
+			handler: func(h *ApprovalHandler) http.HandlerFunc { return h.DenyRequest },
+			body:    func(uuid.UUID) string { return "" },
+		},
+		{
+			name:    "bulk review",
+			handler: func(h *ApprovalHandler) http.HandlerFunc { return h.BulkReviewApprovals },
+			body:    func(id uuid.UUID) string { return fmt.Sprintf(`{"ids":[%q],"status":"approved"}`, id) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, service, pending := newTestApprovalHandler(t)
+			newRequest := func(permissions ...domain.Permission) *http.Request {
+				r := httptest.NewRequest(http.MethodPost, "/v1/approvals/"+pending.ID.String(), strings.NewReader(tt.body(pending.ID)))
+				return asKey(withApprovalID(r, pending.ID), permissions...)
+			}
+
+			// A key that may read and request approvals still cannot review them
+			rec := httptest.NewRecorder()
+			tt.handler(h)(rec, newRequest(domain.PermissionApprovalsRead, domain.PermissionApprovalsRequest))
+			if rec.Code != http.StatusForbidden {
+				t.Fatalf("without approvals:review: status = %d, want %d: %s", rec.Code, http.StatusForbidden, rec.Body)
+			}
+			if got := service.GetApproval(middleware.DemoOrgID, pending.ID); got.Status != domain.ApprovalStatusPending {
+				t.Fatalf("rejected review changed the approval to %s", got.Status)
+			}
+
+			rec = httptest.NewRecorder()
+			tt.handler(h)(rec, newRequest(domain.PermissionApprovalsReview))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("with approvals:review: status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+			}
+			if got := service.GetApproval(middleware.DemoOrgID, pending.ID); got.Status == domain.ApprovalStatusPending {
+				t.Error("review by a reviewer left the approval pending")
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/approval"
+	"github.com/akz4ol/gatewayops/gateway/internal/clock"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/middleware"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// recordingToolCaller answers every replayed call with 200 and records it.
+type recordingToolCaller struct {
+	calls []domain.ToolApprovalRequest
+}
+
+func (c *recordingToolCaller) CallTool(w http.ResponseWriter, r *http.Request, server, tool string, arguments map[string]interface{}) {
+	c.calls = append(c.calls, domain.ToolApprovalRequest{MCPServer: server, ToolName: tool, Arguments: arguments})
+	WriteJSON(w, http.StatusOK, map[string]interface{}{"result": "done"})
+}
+
+func TestReplayApproval(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	service := approval.NewService(zerolog.Nop(), nil, nil, nil, nil, nil, 100, clk)
+	caller := &recordingToolCaller{}
+	h := NewApprovalHandler(zerolog.Nop(), service, nil, caller, nil, StepUpPolicy{}, nil)
+
+	args := map[string]interface{}{"path": "/tmp/report.txt", "content": "quarterly numbers"}
+	pending := service.RequestApproval(context.Background(), domain.ToolApprovalRequest{
+		MCPServer: "filesystem", ToolName: "write_file", Arguments: args,
+	}, middleware.DemoOrgID, middleware.DemoUserID)
+
+	replay := func(r *http.Request) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ReplayApproval(rec, withApprovalID(r, pending.ID))
+		return rec
+	}
+	newRequest := func() *http.Request {
+		return httptest.NewRequest(http.MethodPost, "/v1/tools/approvals/"+pending.ID.String()+"/replay", nil)
+	}
+
+	if rec := replay(asKey(newRequest(), domain.PermissionApprovalsRequest)); rec.Code != http.StatusConflict {
+		t.Fatalf("pending: status %d, want %d: %s", rec.Code, http.StatusConflict, rec.Body)
+	}
+	if len(caller.calls) != 0 {
+		t.Fatalf("pending approval replayed %+v", caller.calls)
+	}
+
+	expiresIn := 3600
+	service.ReviewApproval(context.Background(), middleware.DemoOrgID, pending.ID,
+		domain.ToolApprovalReview{Status: domain.ApprovalStatusApproved, ExpiresIn: &expiresIn}, uuid.New())
+
+	// Another user needs approvals:review to replay the requester's call
+	otherUser := func(permissions ...domain.Permission) *http.Request {
+		r := asKey(newRequest(), permissions...)
+		info := *middleware.GetAuthInfo(r.Context())
+		info.UserID = uuid.New()
+		return r.WithContext(context.WithValue(r.Context(), middleware.AuthInfoKey, &info))
+	}
+	if rec := replay(otherUser(domain.PermissionApprovalsRequest)); rec.Code != http.StatusForbidden {
+		t.Errorf("another user: status %d, want %d", rec.Code, http.StatusForbidden)
+	}
+	if rec := replay(otherUser(domain.PermissionApprovalsReview)); rec.Code != http.StatusOK {
+		t.Errorf("reviewer: status %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+
+	if rec := replay(asKey(newRequest(), domain.PermissionApprovalsRequest)); rec.Code != http.StatusOK {
+		t.Fatalf("approved: status %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+	want := domain.ToolApprovalRequest{MCPServer: "filesystem", ToolName: "write_file", Arguments: args}
+	if len(caller.calls) != 2 || !reflect.DeepEqual(caller.calls[1], want) {
+		t.Errorf("replayed %+v, want the original call %+v", caller.calls, want)
+	}
+
+	clk.Advance(time.Hour)
+	if rec := replay(asKey(newRequest(), domain.PermissionApprovalsRequest)); rec.Code != http.StatusConflict {
+		t.Errorf("expired: status %d, want %d", rec.Code, http.StatusConflict)
+	}
+	if rec := replay(asOrg(newRequest(), uuid.New())); rec.Code != http.StatusNotFound {
+		t.Errorf("another org: status %d, want %d", rec.Code, http.StatusNotFound)
+	}
+	if len(caller.calls) != 2 {
+		t.Errorf("%d calls replayed, want 2", len(caller.calls))
+	}
+}
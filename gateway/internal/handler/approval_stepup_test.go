@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/approval"
+	"github.com/akz4ol/gatewayops/gateway/internal/audit"
+	"github.com/akz4ol/gatewayops/gateway/internal/clock"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/middleware"
+	"github.com/akz4ol/gatewayops/gateway/internal/response"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// staticSessions validates the SSO sessions it holds, by token.
+type staticSessions map[string]*domain.UserSession
+
+func (s staticSessions) ValidateSession(token string) (*domain.UserSession, *domain.User) {
+	session, ok := s[token]
+	if !ok {
+		return nil, nil
+	}
+	return session, &domain.User{ID: session.UserID, OrgID: session.OrgID}
+}
+
+func TestApprovingADangerousToolRequiresStepUp(t *testing.T) {
+	session := func(userID uuid.UUID, age time.Duration, amr ...string) *domain.UserSession {
+		return &domain.UserSession{UserID: userID, OrgID: middleware.DemoOrgID, AMR: amr, AuthTime: time.Now().Add(-age)}
+	}
+	sessions := staticSessions{
+		"fresh-mfa":     session(middleware.DemoUserID, time.Minute, "pwd", "otp"),
+		"password-only": session(middleware.DemoUserID, time.Minute, "pwd"),
+		"stale-mfa":     session(middleware.DemoUserID, time.Hour, "pwd", "otp"),
+		"someone-else":  session(uuid.New(), time.Minute, "pwd", "otp"),
+	}
+	policy := StepUpPolicy{RiskLevel: domain.ToolRiskDangerous, MaxAge: 15 * time.Minute}
+
+	tests := []struct {
+		name  string
+		tool  string
+		token string
+		want  int
+	}{
+		{name: "no session", tool: "execute_command", want: http.StatusUnauthorized},
+		{name: "password sign-in", tool: "execute_command", token: "password-only", want: http.StatusUnauthorized},
+		{name: "MFA sign-in too long ago", tool: "execute_command", token: "stale-mfa", want: http.StatusUnauthorized},
+		{name: "another user's session", tool: "execute_command", token: "someone-else", want: http.StatusUnauthorized},
+		{name: "recent MFA sign-in", tool: "execute_command", token: "fresh-mfa", want: http.StatusOK},
+		{name: "tool below the risk level", tool: "write_file", want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := approval.NewService(zerolog.Nop(), nil, nil, nil, nil, nil, 100, clock.Real)
+			auditLogger := audit.NewLogger(zerolog.Nop(), nil)
+			h := NewApprovalHandler(zerolog.Nop(), service, auditLogger, nil, sessions, policy, nil)
+			pending := service.RequestApproval(context.Background(), domain.ToolApprovalRequest{MCPServer: "shell", ToolName: tt.tool},
+				middleware.DemoOrgID, uuid.New())
+
+			r := httptest.NewRequest(http.MethodPost, "/v1/tools/approvals/"+pending.ID.String()+"/approve", nil)
+			if tt.token != "" {
+				r.Header.Set(middleware.SessionHeader, tt.token)
+			}
+			rec := httptest.NewRecorder()
+			h.ApproveRequest(rec, asKey(withApprovalID(r, pending.ID), domain.PermissionApprovalsReview))
+			if rec.Code != tt.want {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+
+			status := service.GetApproval(middleware.DemoOrgID, pending.ID).Status
+			logs := auditLogger.GetLogs(domain.AuditLogFilter{OrgID: middleware.DemoOrgID, Actions: []domain.AuditAction{domain.AuditActionApprovalGrant}}).Logs
+			if len(logs) != 1 {
+				t.Fatalf("%d grant audit records, want 1", len(logs))
+			}
+			mfa, _ := logs[0].Details["mfa"].(mfaStatus)
+
+			if tt.want == http.StatusUnauthorized {
+				if status != domain.ApprovalStatusPending {
+					t.Errorf("refused grant left the approval %s", status)
+				}
+				if !strings.Contains(rec.Body.String(), string(response.CodeStepUpRequired)) ||
+					!strings.Contains(rec.Header().Get("WWW-Authenticate"), `insufficient_user_authentication", error_description="A recent multi-factor sign-in is required", max_age=900`) {
+					t.Errorf("response %s with challenge %q, want a step-up challenge", rec.Body, rec.Header().Get("WWW-Authenticate"))
+				}
+				if logs[0].Outcome != domain.AuditOutcomeBlocked || !mfa.Required || mfa.Verified {
+					t.Errorf("audited %s with mfa %+v, want a blocked grant", logs[0].Outcome, mfa)
+				}
+				return
+			}
+			if status != domain.ApprovalStatusApproved {
+				t.Errorf("approval is %s, want approved", status)
+			}
+			if logs[0].Outcome != domain.AuditOutcomeSuccess || mfa.Required != (tt.tool == "execute_command") || mfa.Verified != (tt.token == "fresh-mfa") {
+				t.Errorf("audited %s with mfa %+v", logs[0].Outcome, mfa)
+			}
+		})
+	}
+}
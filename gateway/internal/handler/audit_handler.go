@@ -8,6 +8,8 @@ import (
 
 	"github.com/akz4ol/gatewayops/gateway/internal/audit"
 	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/middleware"
+	"github.com/akz4ol/gatewayops/gateway/internal/response"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
@@ -39,13 +41,13 @@ func (h *AuditHandler) Get(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "logID")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid audit log ID")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid audit log ID")
 		return
 	}
 
 	log := h.auditLogger.GetLog(id)
 	if log == nil {
-		WriteError(w, http.StatusNotFound, "not_found", "Audit log not found")
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Audit log not found")
 		return
 	}
 
@@ -56,7 +58,7 @@ func (h *AuditHandler) Get(w http.ResponseWriter, r *http.Request) {
 func (h *AuditHandler) Search(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query().Get("q")
 	if query == "" {
-		WriteError(w, http.StatusBadRequest, "validation_error", "Search query 'q' is required")
+		WriteFieldError(w, "q", "Search query 'q' is required")
 		return
 	}
 
@@ -81,7 +83,7 @@ func (h *AuditHandler) Export(w http.ResponseWriter, r *http.Request) {
 
 	data, err := h.auditLogger.Export(filter, format)
 	if err != nil {
-		WriteError(w, http.StatusInternalServerError, "export_error", "Failed to export audit logs")
+		WriteError(w, http.StatusInternalServerError, response.CodeExportError, "Failed to export audit logs")
 		return
 	}
 
@@ -99,6 +101,27 @@ func (h *AuditHandler) Export(w http.ResponseWriter, r *http.Request) {
 	w.Write(data)
 }
 
+// Verify walks the org's audit hash chain and reports the first broken link.
+func (h *AuditHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	orgID := middleware.GetOrgID(r.Context())
+
+	result, err := h.auditLogger.Verify(r.Context(), orgID)
+	if err != nil {
+		h.logger.Error().Err(err).Str("org_id", orgID.String()).Msg("Failed to verify audit log chain")
+		WriteError(w, http.StatusInternalServerError, response.CodeInternalError, "Failed to verify audit log chain")
+		return
+	}
+	if !result.Valid {
+		h.logger.Warn().
+			Str("org_id", orgID.String()).
+			Str("broken_at", result.BrokenAt.String()).
+			Str("reason", result.Reason).
+			Msg("Audit log hash chain is broken")
+	}
+
+	WriteJSON(w, http.StatusOK, result)
+}
+
 // Stats returns audit log statistics.
 func (h *AuditHandler) Stats(w http.ResponseWriter, r *http.Request) {
 	stats := h.auditLogger.GetStats()
@@ -110,7 +133,7 @@ func (h *AuditHandler) parseFilter(r *http.Request) domain.AuditLogFilter {
 	query := r.URL.Query()
 
 	filter := domain.AuditLogFilter{
-		OrgID: uuid.MustParse("00000000-0000-0000-0000-000000000001"), // Demo org
+		OrgID: middleware.GetOrgID(r.Context()),
 	}
 
 	// Parse actions
@@ -178,3 +201,47 @@ func (h *AuditHandler) parseFilter(r *http.Request) domain.AuditLogFilter {
 
 	return filter
 }
+
+// recordAudit records a successful administrative mutation. before and after
+// hold the resource state around the change; either may be nil.
+func recordAudit(r *http.Request, auditLogger *audit.Logger, action domain.AuditAction, resource, resourceID string, before, after interface{}) {
+	if auditLogger == nil {
+		return
+	}
+
+	details := make(map[string]interface{})
+	if before != nil {
+		details["before"] = before
+	}
+	if after != nil {
+		details["after"] = after
+	}
+	logAuditEvent(r, auditLogger, action, resource, resourceID, domain.AuditOutcomeSuccess, details)
+}
+
+// logAuditEvent records an action taken by the request's caller.
+func logAuditEvent(r *http.Request, auditLogger *audit.Logger, action domain.AuditAction, resource, resourceID string, outcome domain.AuditOutcome, details map[string]interface{}) {
+	if auditLogger == nil {
+		return
+	}
+
+	ctx := r.Context()
+	event := audit.Event{
+		OrgID:      middleware.GetOrgID(ctx),
+		APIKeyID:   middleware.GetAPIKeyID(ctx),
+		TraceID:    middleware.GetTraceID(ctx),
+		Action:     action,
+		Resource:   resource,
+		ResourceID: resourceID,
+		Outcome:    outcome,
+		Details:    details,
+		IPAddress:  middleware.RequestClientIP(r),
+		UserAgent:  r.UserAgent(),
+		RequestID:  middleware.GetRequestID(ctx),
+	}
+	if authInfo := middleware.GetAuthInfo(ctx); authInfo != nil {
+		event.UserID = &authInfo.UserID
+	}
+
+	auditLogger.LogEvent(ctx, event)
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/alerting"
+	"github.com/akz4ol/gatewayops/gateway/internal/audit"
+	"github.com/akz4ol/gatewayops/gateway/internal/clock"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/middleware"
+	"github.com/akz4ol/gatewayops/gateway/internal/safety"
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+)
+
+func TestAdministrativeMutationsAreAudited(t *testing.T) {
+	auditLogger := audit.NewLogger(zerolog.Nop(), nil)
+	// lastAudit returns the one audit record of the action.
+	lastAudit := func(action domain.AuditAction) domain.AuditLog {
+		t.Helper()
+		page := auditLogger.GetLogs(domain.AuditLogFilter{OrgID: middleware.DemoOrgID, Actions: []domain.AuditAction{action}})
+		if len(page.Logs) != 1 {
+			t.Fatalf("%d %s records, want 1", len(page.Logs), action)
+		}
+		return page.Logs[0]
+	}
+
+	alerts := alerting.NewService(zerolog.Nop(), nil, nil, 100, clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)))
+	alertHandler := NewAlertHandler(zerolog.Nop(), alerts, auditLogger)
+	rec := httptest.NewRecorder()
+	alertHandler.CreateRule(rec, httptest.NewRequest(http.MethodPost, "/v1/alerts/rules",
+		strings.NewReader(`{"name":"Errors","metric":"error_rate","condition":"gt","threshold":0.05,"severity":"critical","enabled":true}`)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create rule: status %d: %s", rec.Code, rec.Body)
+	}
+	created := lastAudit(domain.AuditActionAlertRuleCreate)
+	rule, ok := created.Details["after"].(*domain.AlertRule)
+	if !ok || created.Resource != "alert_rule" || created.ResourceID != rule.ID.String() || rule.Name != "Errors" {
+		t.Errorf("rule creation audited as %+v", created)
+	}
+	if _, ok := created.Details["before"]; ok || created.Outcome != domain.AuditOutcomeSuccess || created.OrgID != middleware.DemoOrgID {
+		t.Errorf("rule creation audited as %+v, want a successful creation in the caller's org", created)
+	}
+
+	detector := safety.NewDetector(zerolog.Nop(), nil, 100, safety.EscalationPolicy{}, nil, nil)
+	safetyHandler := NewSafetyHandler(zerolog.Nop(), detector, auditLogger)
+	policy := detector.CreatePolicy(context.Background(), domain.SafetyPolicyInput{
+		Name: "Strict", Sensitivity: domain.SafetySensitivityStrict, Mode: domain.SafetyModeBlock, Enabled: true,
+	}, middleware.DemoOrgID, middleware.DemoUserID)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("policyID", policy.ID.String())
+	req := httptest.NewRequest(http.MethodDelete, "/v1/safety/policies/"+policy.ID.String(), nil)
+	rec = httptest.NewRecorder()
+	safetyHandler.DeletePolicy(rec, req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx)))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("delete policy: status %d: %s", rec.Code, rec.Body)
+	}
+	deleted := lastAudit(domain.AuditActionPolicyDelete)
+	before, ok := deleted.Details["before"].(*domain.SafetyPolicy)
+	if !ok || deleted.Resource != "safety_policy" || deleted.ResourceID != policy.ID.String() || before.Name != "Strict" {
+		t.Errorf("policy deletion audited as %+v, want the policy before deletion", deleted)
+	}
+	if _, ok := deleted.Details["after"]; ok {
+		t.Errorf("policy deletion recorded an after state: %+v", deleted.Details)
+	}
+}
@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/budget"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/middleware"
+	"github.com/akz4ol/gatewayops/gateway/internal/response"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// BudgetHandler handles budget-related HTTP requests.
+type BudgetHandler struct {
+	logger  zerolog.Logger
+	service *budget.Service
+}
+
+// NewBudgetHandler creates a new budget handler.
+func NewBudgetHandler(logger zerolog.Logger, service *budget.Service) *BudgetHandler {
+	return &BudgetHandler{
+		logger:  logger,
+		service: service,
+	}
+}
+
+// ListBudgets returns all budgets for the organization.
+func (h *BudgetHandler) ListBudgets(w http.ResponseWriter, r *http.Request) {
+	budgets := h.service.ListBudgets(middleware.GetOrgID(r.Context()))
+	WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"budgets": budgets,
+		"total":   len(budgets),
+	})
+}
+
+// GetBudget returns a single budget by ID.
+func (h *BudgetHandler) GetBudget(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "budgetID"))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid budget ID")
+		return
+	}
+
+	b := h.service.GetBudget(id)
+	if b == nil || b.OrgID != middleware.GetOrgID(r.Context()) {
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Budget not found")
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, b)
+}
+
+// CreateBudget creates a new budget.
+func (h *BudgetHandler) CreateBudget(w http.ResponseWriter, r *http.Request) {
+	var input domain.BudgetInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	if !validateBudgetInput(w, input) {
+		return
+	}
+
+	b := h.service.CreateBudget(input, middleware.GetOrgID(r.Context()))
+	WriteJSON(w, http.StatusCreated, b)
+}
+
+// UpdateBudget updates an existing budget.
+func (h *BudgetHandler) UpdateBudget(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "budgetID"))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid budget ID")
+		return
+	}
+
+	var input domain.BudgetInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	if !validateBudgetInput(w, input) {
+		return
+	}
+
+	if existing := h.service.GetBudget(id); existing == nil || existing.OrgID != middleware.GetOrgID(r.Context()) {
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Budget not found")
+		return
+	}
+
+	b := h.service.UpdateBudget(id, input)
+	if b == nil {
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Budget not found")
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, b)
+}
+
+// DeleteBudget deletes a budget.
+func (h *BudgetHandler) DeleteBudget(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "budgetID"))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid budget ID")
+		return
+	}
+
+	if existing := h.service.GetBudget(id); existing == nil || existing.OrgID != middleware.GetOrgID(r.Context()) {
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Budget not found")
+		return
+	}
+
+	if !h.service.DeleteBudget(id) {
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Budget not found")
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// validateBudgetInput writes a validation error and returns false if the input is invalid.
+func validateBudgetInput(w http.ResponseWriter, input domain.BudgetInput) bool {
+	if input.Name == "" {
+		WriteFieldError(w, "name", "Name is required")
+		return false
+	}
+	if input.LimitUSD <= 0 {
+		WriteFieldError(w, "limit_usd", "limit_usd must be greater than zero")
+		return false
+	}
+	if input.Period != "" && input.Period != domain.BudgetPeriodDaily && input.Period != domain.BudgetPeriodMonthly {
+		WriteFieldError(w, "period", "period must be daily or monthly")
+		return false
+	}
+	if input.SoftThreshold < 0 || input.SoftThreshold > 1 {
+		WriteFieldError(w, "soft_threshold", "soft_threshold must be between 0 and 1")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/compliance"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/middleware"
+	"github.com/akz4ol/gatewayops/gateway/internal/response"
+	"github.com/rs/zerolog"
+)
+
+// ComplianceHandler serves compliance reports for auditors.
+type ComplianceHandler struct {
+	logger     zerolog.Logger
+	generator  *compliance.Generator
+	signingKey []byte
+}
+
+// NewComplianceHandler creates a new compliance handler. Reports are signed
+// when signingKey is set.
+func NewComplianceHandler(logger zerolog.Logger, generator *compliance.Generator, signingKey string) *ComplianceHandler {
+	h := &ComplianceHandler{
+		logger:    logger,
+		generator: generator,
+	}
+	if signingKey != "" {
+		h.signingKey = []byte(signingKey)
+	}
+	return h
+}
+
+// Report handles GET /v1/compliance/report, downloading the caller's org
+// report for start_date to end_date (the last 30 days by default) as JSON,
+// CSV or printable HTML. X-Report-Digest carries the SHA-256 of the file, and
+// X-Report-Signature its HMAC when a signing key is configured.
+func (h *ComplianceHandler) Report(w http.ResponseWriter, r *http.Request) {
+	orgID := middleware.GetOrgID(r.Context())
+	query := r.URL.Query()
+
+	format := domain.ComplianceReportFormat(query.Get("format"))
+	if format == "" {
+		format = domain.ComplianceReportJSON
+	}
+	contentType, ok := compliance.ContentTypes[format]
+	if !ok {
+		WriteFieldError(w, "format", "format must be json, csv or html")
+		return
+	}
+
+	endDate := time.Now().UTC()
+	startDate := endDate.AddDate(0, 0, -30)
+	if v := query.Get("start_date"); v != "" {
+		t, err := parseReportTime(v)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, response.CodeInvalidStartDate, "start_date must be RFC3339 or YYYY-MM-DD")
+			return
+		}
+		startDate = t
+	}
+	if v := query.Get("end_date"); v != "" {
+		t, err := parseReportTime(v)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, response.CodeInvalidEndDate, "end_date must be RFC3339 or YYYY-MM-DD")
+			return
+		}
+		endDate = t
+	}
+	if endDate.Before(startDate) {
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidRange, "end_date must be after start_date")
+		return
+	}
+
+	report, err := h.generator.Generate(r.Context(), orgID, startDate, endDate)
+	if err != nil {
+		h.logger.Error().Err(err).Str("org_id", orgID.String()).Msg("Failed to generate compliance report")
+		WriteError(w, http.StatusInternalServerError, response.CodeExportError, "Failed to generate compliance report")
+		return
+	}
+	body, err := compliance.Render(report, format)
+	if err != nil {
+		h.logger.Error().Err(err).Str("org_id", orgID.String()).Msg("Failed to render compliance report")
+		WriteError(w, http.StatusInternalServerError, response.CodeExportError, "Failed to generate compliance report")
+		return
+	}
+
+	h.logger.Info().
+		Str("org_id", orgID.String()).
+		Str("format", string(format)).
+		Time("start_date", startDate).
+		Time("end_date", endDate).
+		Bool("signed", h.signingKey != nil).
+		Msg("Compliance report generated")
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=compliance-report-%s.%s", report.GeneratedAt.Format("2006-01-02"), format))
+	w.Header().Set("X-Report-Digest", compliance.Digest(body))
+	if h.signingKey != nil {
+		w.Header().Set("X-Report-Signature", compliance.Sign(h.signingKey, body))
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/config"
+	"github.com/akz4ol/gatewayops/gateway/internal/response"
+	"github.com/rs/zerolog"
+)
+
+// ConfigHandler handles gateway configuration HTTP requests.
+type ConfigHandler struct {
+	logger   zerolog.Logger
+	reloader *config.Reloader
+}
+
+// NewConfigHandler creates a new config handler.
+func NewConfigHandler(logger zerolog.Logger, reloader *config.Reloader) *ConfigHandler {
+	return &ConfigHandler{
+		logger:   logger,
+		reloader: reloader,
+	}
+}
+
+// ValidateConfigRequest is the body of a config validation request.
+type ValidateConfigRequest struct {
+	Env map[string]string `json:"env"`
+}
+
+// ValidateConfigResponse reports the result of validating a configuration.
+type ValidateConfigResponse struct {
+	Valid    bool     `json:"valid"`
+	Problems []string `json:"problems"`
+}
+
+// Validate loads a configuration from the given environment variables and
+// reports every problem found, without applying it.
+func (h *ConfigHandler) Validate(w http.ResponseWriter, r *http.Request) {
+	var req ValidateConfigRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	cfg, err := config.LoadFrom(func(key string) string {
+		return req.Env[key]
+	})
+	if err != nil {
+		WriteJSON(w, http.StatusOK, ValidateConfigResponse{Problems: []string{err.Error()}})
+		return
+	}
+
+	resp := ValidateConfigResponse{Valid: true, Problems: []string{}}
+	if err := cfg.Validate(); err != nil {
+		resp.Valid = false
+		if verr, ok := err.(*config.ValidationError); ok {
+			resp.Problems = verr.Problems
+		} else {
+			resp.Problems = []string{err.Error()}
+		}
+	}
+
+	WriteJSON(w, http.StatusOK, resp)
+}
+
+// Reload re-reads the gateway configuration and applies safe changes.
+func (h *ConfigHandler) Reload(w http.ResponseWriter, r *http.Request) {
+	if h.reloader == nil {
+		WriteError(w, http.StatusServiceUnavailable, response.CodeReloadUnavailable, "Config reload is not enabled")
+		return
+	}
+
+	result, err := h.reloader.Reload()
+	if err != nil {
+		if verr, ok := err.(*config.ValidationError); ok {
+			response.WriteErrorDetails(w, http.StatusUnprocessableEntity, response.CodeValidationError,
+				"New configuration is invalid, keeping current config", problemFields(verr.Problems))
+			return
+		}
+		WriteError(w, http.StatusInternalServerError, response.CodeReloadFailed, err.Error())
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, result)
+}
+
+// problemFields converts "KEY: message" validation problems into field errors.
+func problemFields(problems []string) []FieldError {
+	fields := make([]FieldError, 0, len(problems))
+	for _, p := range problems {
+		field, msg, ok := strings.Cut(p, ": ")
+		if !ok {
+			field, msg = "", p
+		}
+		fields = append(fields, FieldError{Field: field, Message: msg})
+	}
+	return fields
+}
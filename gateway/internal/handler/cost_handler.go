@@ -1,12 +1,17 @@
 package handler
 
 import (
+	"encoding/csv"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/akz4ol/gatewayops/gateway/internal/domain"
 	"github.com/akz4ol/gatewayops/gateway/internal/middleware"
 	"github.com/akz4ol/gatewayops/gateway/internal/repository"
+	"github.com/akz4ol/gatewayops/gateway/internal/response"
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 )
@@ -25,11 +30,7 @@ func NewCostHandler(logger zerolog.Logger, repo *repository.CostRepository, demo
 
 // Summary returns cost summary for the authenticated organization.
 func (h *CostHandler) Summary(w http.ResponseWriter, r *http.Request) {
-	authInfo := middleware.GetAuthInfo(r.Context())
-	orgID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
-	if authInfo != nil {
-		orgID = authInfo.OrgID
-	}
+	orgID := middleware.GetOrgID(r.Context())
 
 	// Parse period from query params (default: month)
 	period := r.URL.Query().Get("period")
@@ -61,7 +62,7 @@ func (h *CostHandler) Summary(w http.ResponseWriter, r *http.Request) {
 		summary, err := h.repo.GetSummary(r.Context(), filter)
 		if err != nil {
 			h.logger.Error().Err(err).Msg("Failed to get cost summary")
-			WriteError(w, http.StatusInternalServerError, "internal_error", "Failed to get cost summary")
+			WriteError(w, http.StatusInternalServerError, response.CodeInternalError, "Failed to get cost summary")
 			return
 		}
 
@@ -99,11 +100,7 @@ func (h *CostHandler) Summary(w http.ResponseWriter, r *http.Request) {
 
 // ByServer returns cost breakdown by MCP server.
 func (h *CostHandler) ByServer(w http.ResponseWriter, r *http.Request) {
-	authInfo := middleware.GetAuthInfo(r.Context())
-	orgID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
-	if authInfo != nil {
-		orgID = authInfo.OrgID
-	}
+	orgID := middleware.GetOrgID(r.Context())
 
 	now := time.Now()
 	filter := domain.CostFilter{
@@ -117,7 +114,7 @@ func (h *CostHandler) ByServer(w http.ResponseWriter, r *http.Request) {
 		data, err := h.repo.GetByServer(r.Context(), filter)
 		if err != nil {
 			h.logger.Error().Err(err).Msg("Failed to get cost by server")
-			WriteError(w, http.StatusInternalServerError, "internal_error", "Failed to get cost by server")
+			WriteError(w, http.StatusInternalServerError, response.CodeInternalError, "Failed to get cost by server")
 			return
 		}
 
@@ -151,11 +148,7 @@ func (h *CostHandler) ByServer(w http.ResponseWriter, r *http.Request) {
 
 // ByTeam returns cost breakdown by team.
 func (h *CostHandler) ByTeam(w http.ResponseWriter, r *http.Request) {
-	authInfo := middleware.GetAuthInfo(r.Context())
-	orgID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
-	if authInfo != nil {
-		orgID = authInfo.OrgID
-	}
+	orgID := middleware.GetOrgID(r.Context())
 
 	now := time.Now()
 	filter := domain.CostFilter{
@@ -169,7 +162,7 @@ func (h *CostHandler) ByTeam(w http.ResponseWriter, r *http.Request) {
 		data, err := h.repo.GetByTeam(r.Context(), filter)
 		if err != nil {
 			h.logger.Error().Err(err).Msg("Failed to get cost by team")
-			WriteError(w, http.StatusInternalServerError, "internal_error", "Failed to get cost by team")
+			WriteError(w, http.StatusInternalServerError, response.CodeInternalError, "Failed to get cost by team")
 			return
 		}
 
@@ -200,11 +193,7 @@ func (h *CostHandler) ByTeam(w http.ResponseWriter, r *http.Request) {
 
 // Daily returns daily cost data for charts.
 func (h *CostHandler) Daily(w http.ResponseWriter, r *http.Request) {
-	authInfo := middleware.GetAuthInfo(r.Context())
-	orgID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
-	if authInfo != nil {
-		orgID = authInfo.OrgID
-	}
+	orgID := middleware.GetOrgID(r.Context())
 
 	now := time.Now()
 	filter := domain.CostFilter{
@@ -218,7 +207,7 @@ func (h *CostHandler) Daily(w http.ResponseWriter, r *http.Request) {
 		data, err := h.repo.GetByDay(r.Context(), filter)
 		if err != nil {
 			h.logger.Error().Err(err).Msg("Failed to get daily costs")
-			WriteError(w, http.StatusInternalServerError, "internal_error", "Failed to get daily costs")
+			WriteError(w, http.StatusInternalServerError, response.CodeInternalError, "Failed to get daily costs")
 			return
 		}
 
@@ -248,3 +237,166 @@ func (h *CostHandler) Daily(w http.ResponseWriter, r *http.Request) {
 		"daily": data,
 	})
 }
+
+// Report handles GET /v1/costs/report and returns cost attribution grouped by
+// team, user, mcp_server and/or tool.
+func (h *CostHandler) Report(w http.ResponseWriter, r *http.Request) {
+	authInfo := middleware.GetAuthInfo(r.Context())
+	orgID := middleware.GetOrgID(r.Context())
+	var teamID *uuid.UUID
+	if authInfo != nil {
+		switch {
+		case authInfo.HasPermission(domain.PermissionCostsRead):
+			// Org-wide access
+		case authInfo.HasPermission(domain.PermissionCostsReadTeam):
+			if authInfo.TeamID == uuid.Nil {
+				WriteError(w, http.StatusForbidden, response.CodeForbidden, "API key is not associated with a team")
+				return
+			}
+			teamID = &authInfo.TeamID
+		default:
+			WriteError(w, http.StatusForbidden, response.CodeForbidden, "Missing permission: costs:read")
+			return
+		}
+	}
+
+	query := r.URL.Query()
+
+	// Team-scoped callers may not widen the filter; org-wide callers may narrow it
+	if teamID == nil {
+		if v := query.Get("team_id"); v != "" {
+			id, err := uuid.Parse(v)
+			if err != nil {
+				WriteError(w, http.StatusBadRequest, response.CodeInvalidTeamID, "Invalid team ID")
+				return
+			}
+			teamID = &id
+		}
+	}
+
+	now := time.Now()
+	startDate := now.AddDate(0, -1, 0)
+	endDate := now
+	if v := query.Get("start_date"); v != "" {
+		t, err := parseReportTime(v)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, response.CodeInvalidStartDate, "start_date must be RFC3339 or YYYY-MM-DD")
+			return
+		}
+		startDate = t
+	}
+	if v := query.Get("end_date"); v != "" {
+		t, err := parseReportTime(v)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, response.CodeInvalidEndDate, "end_date must be RFC3339 or YYYY-MM-DD")
+			return
+		}
+		endDate = t
+	}
+	if endDate.Before(startDate) {
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidRange, "end_date must be after start_date")
+		return
+	}
+
+	groupBy := []domain.CostGroupBy{domain.CostGroupByMCPServer}
+	if v := query.Get("group_by"); v != "" {
+		groupBy = groupBy[:0]
+		seen := make(map[domain.CostGroupBy]bool)
+		for _, part := range strings.Split(v, ",") {
+			g := domain.CostGroupBy(strings.TrimSpace(part))
+			if !domain.ValidCostGroupBy(g) {
+				WriteError(w, http.StatusBadRequest, response.CodeInvalidGroupBy, fmt.Sprintf("Unsupported group_by '%s' (use team, user, mcp_server, tool)", g))
+				return
+			}
+			if !seen[g] {
+				seen[g] = true
+				groupBy = append(groupBy, g)
+			}
+		}
+	}
+
+	filter := domain.CostReportFilter{
+		OrgID:     orgID,
+		TeamID:    teamID,
+		StartDate: startDate,
+		EndDate:   endDate,
+		GroupBy:   groupBy,
+	}
+
+	report := domain.CostReport{
+		GroupBy:   groupBy,
+		StartDate: startDate,
+		EndDate:   endDate,
+		Rows:      []domain.CostReportRow{},
+	}
+
+	if h.repo != nil {
+		rows, err := h.repo.GetReport(r.Context(), filter)
+		if err != nil {
+			h.logger.Error().Err(err).Msg("Failed to get cost report")
+			WriteError(w, http.StatusInternalServerError, response.CodeInternalError, "Failed to get cost report")
+			return
+		}
+		if rows != nil {
+			report.Rows = rows
+		}
+	}
+
+	for _, row := range report.Rows {
+		report.TotalCost += row.TotalCost
+		report.CallCount += row.CallCount
+	}
+
+	if query.Get("format") == "csv" {
+		h.writeReportCSV(w, report)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, report)
+}
+
+// writeReportCSV writes a cost report as CSV.
+func (h *CostHandler) writeReportCSV(w http.ResponseWriter, report domain.CostReport) {
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", "attachment; filename=cost-report.csv")
+	w.WriteHeader(http.StatusOK)
+
+	cw := csv.NewWriter(w)
+	header := make([]string, 0, len(report.GroupBy)+2)
+	for _, g := range report.GroupBy {
+		header = append(header, string(g))
+	}
+	header = append(header, "total_cost", "call_count")
+	cw.Write(header)
+
+	for _, row := range report.Rows {
+		record := make([]string, 0, len(header))
+		for _, g := range report.GroupBy {
+			switch g {
+			case domain.CostGroupByTeam:
+				record = append(record, row.Team)
+			case domain.CostGroupByUser:
+				record = append(record, row.User)
+			case domain.CostGroupByMCPServer:
+				record = append(record, row.MCPServer)
+			case domain.CostGroupByTool:
+				record = append(record, row.Tool)
+			}
+		}
+		record = append(record,
+			strconv.FormatFloat(row.TotalCost, 'f', 6, 64),
+			strconv.FormatInt(row.CallCount, 10),
+		)
+		cw.Write(record)
+	}
+
+	cw.Flush()
+}
+
+// parseReportTime parses an RFC3339 timestamp or a YYYY-MM-DD date.
+func parseReportTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+)
+
+func TestWriteReportCSV(t *testing.T) {
+	report := domain.CostReport{
+		GroupBy: []domain.CostGroupBy{domain.CostGroupByMCPServer, domain.CostGroupByTool},
+		Rows: []domain.CostReportRow{
+			{MCPServer: "filesystem", Tool: "read_file", TotalCost: 0.03, CallCount: 3},
+			{MCPServer: "github", Tool: "create_issue", TotalCost: 0.05, CallCount: 1},
+		},
+	}
+
+	rec := httptest.NewRecorder()
+	(&CostHandler{}).writeReportCSV(rec, report)
+
+	want := "mcp_server,tool,total_cost,call_count\n" +
+		"filesystem,read_file,0.030000,3\n" +
+		"github,create_issue,0.050000,1\n"
+	if got := rec.Body.String(); got != want {
+		t.Errorf("CSV =\n%s\nwant\n%s", got, want)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "text/csv" {
+		t.Errorf("Content-Type = %q, want text/csv", ct)
+	}
+}
@@ -3,6 +3,7 @@ package handler
 import (
 	"net/http"
 
+	"github.com/akz4ol/gatewayops/gateway/internal/response"
 	"github.com/rs/zerolog"
 )
 
@@ -35,6 +36,14 @@ func (h *DocsHandler) OpenAPISpec(w http.ResponseWriter, r *http.Request) {
 	w.Write(h.openAPI)
 }
 
+// ErrorCodes lists the error codes the API can return.
+func (h *DocsHandler) ErrorCodes(w http.ResponseWriter, r *http.Request) {
+	WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"errors": response.ErrorCatalog,
+		"total":  len(response.ErrorCatalog),
+	})
+}
+
 const swaggerUIHTML = `<!DOCTYPE html>
 <html lang="en">
 <head>
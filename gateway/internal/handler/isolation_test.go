@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/alerting"
+	"github.com/akz4ol/gatewayops/gateway/internal/approval"
+	"github.com/akz4ol/gatewayops/gateway/internal/clock"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/middleware"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// asOrg returns the request authenticated as a key of orgID with read
+// access to alerts and approvals.
+func asOrg(r *http.Request, orgID uuid.UUID) *http.Request {
+	info := &middleware.AuthInfo{
+		OrgID:       orgID,
+		UserID:      uuid.New(),
+		Permissions: []string{string(domain.PermissionAlertsRead), string(domain.PermissionApprovalsRead)},
+	}
+	return r.WithContext(context.WithValue(r.Context(), middleware.AuthInfoKey, info))
+}
+
+func TestListsDoNotLeakAcrossOrgs(t *testing.T) {
+	orgA, orgB := uuid.New(), uuid.New()
+
+	alerts := alerting.NewService(zerolog.Nop(), nil, nil, 100, clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)))
+	alertHandler := NewAlertHandler(zerolog.Nop(), alerts, nil)
+	approvals := approval.NewService(zerolog.Nop(), nil, nil, nil, nil, nil, 100, clock.Real)
+	approvalHandler := NewApprovalHandler(zerolog.Nop(), approvals, nil, nil, nil, StepUpPolicy{}, nil)
+
+	owned := map[uuid.UUID]map[uuid.UUID]bool{orgA: {}, orgB: {}}
+	for _, orgID := range []uuid.UUID{orgA, orgB} {
+		alert := alerts.TriggerTestAlert(orgID, "error_rate", 0.5)
+		owned[orgID][alert.ID] = true
+		pending := approvals.RequestApproval(context.Background(), domain.ToolApprovalRequest{MCPServer: "filesystem", ToolName: "write_file"}, orgID, uuid.New())
+		owned[orgID][pending.ID] = true
+	}
+
+	for _, orgID := range []uuid.UUID{orgA, orgB} {
+		rec := httptest.NewRecorder()
+		alertHandler.ListAlerts(rec, asOrg(httptest.NewRequest(http.MethodGet, "/v1/alerts", nil), orgID))
+		var alertPage domain.AlertPage
+		if err := json.Unmarshal(rec.Body.Bytes(), &alertPage); err != nil {
+			t.Fatalf("alerts: %v", err)
+		}
+		if len(alertPage.Alerts) != 1 {
+			t.Errorf("org listed %d alerts, want its own 1", len(alertPage.Alerts))
+		}
+		for _, alert := range alertPage.Alerts {
+			if alert.OrgID != orgID || !owned[orgID][alert.ID] {
+				t.Errorf("org %s listed alert %s of org %s", orgID, alert.ID, alert.OrgID)
+			}
+		}
+
+		rec = httptest.NewRecorder()
+		approvalHandler.ListApprovals(rec, asOrg(httptest.NewRequest(http.MethodGet, "/v1/approvals", nil), orgID))
+		var approvalPage domain.ToolApprovalPage
+		if err := json.Unmarshal(rec.Body.Bytes(), &approvalPage); err != nil {
+			t.Fatalf("approvals: %v", err)
+		}
+		if len(approvalPage.Approvals) != 1 {
+			t.Errorf("org listed %d approvals, want its own 1", len(approvalPage.Approvals))
+		}
+		for _, a := range approvalPage.Approvals {
+			if a.OrgID != orgID || !owned[orgID][a.ID] {
+				t.Errorf("org %s listed approval %s of org %s", orgID, a.ID, a.OrgID)
+			}
+		}
+	}
+}
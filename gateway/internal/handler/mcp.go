@@ -4,15 +4,26 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/akz4ol/gatewayops/gateway/internal/alerting"
+	"github.com/akz4ol/gatewayops/gateway/internal/audit"
+	"github.com/akz4ol/gatewayops/gateway/internal/budget"
 	"github.com/akz4ol/gatewayops/gateway/internal/config"
 	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/egress"
+	"github.com/akz4ol/gatewayops/gateway/internal/loopguard"
+	"github.com/akz4ol/gatewayops/gateway/internal/metrics"
 	"github.com/akz4ol/gatewayops/gateway/internal/middleware"
+	"github.com/akz4ol/gatewayops/gateway/internal/pricing"
 	"github.com/akz4ol/gatewayops/gateway/internal/repository"
+	"github.com/akz4ol/gatewayops/gateway/internal/response"
+	"github.com/akz4ol/gatewayops/gateway/internal/transform"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
@@ -23,26 +34,84 @@ type MCPClient interface {
 	Forward(ctx context.Context, server, endpoint string, body []byte) ([]byte, int, error)
 }
 
+// MCPServerLookup finds the configuration of the MCP server requests are
+// proxied to, among those orgID can call.
+type MCPServerLookup interface {
+	MCPServer(orgID uuid.UUID, name string) (config.MCPServerConfig, bool)
+}
+
 // MCPHandler handles MCP proxy requests.
 type MCPHandler struct {
-	config     *config.Config
+	servers    MCPServerLookup
 	logger     zerolog.Logger
 	httpClient *http.Client
 	traceRepo  *repository.TraceRepository
+	costRepo   *repository.CostRepository
+	simulator  *ToolCallSimulator
+	budgets    *budget.Service
+	estimator  *pricing.CostEstimator
+	alerts     *alerting.Service
+	metrics    *metrics.Registry
+	loops      *loopguard.Guard
+	schemas    *toolSchemaCache
+	upstreams  *wsUpstreams
+	egress     *egress.Policy
+	audit      *audit.Logger
 }
 
-// NewMCPHandler creates a new MCP handler.
-func NewMCPHandler(cfg *config.Config, logger zerolog.Logger, traceRepo *repository.TraceRepository) *MCPHandler {
+// NewMCPHandler creates a new MCP handler that proxies to the servers found
+// by servers. Only destinations the egress policy allows are reached.
+func NewMCPHandler(servers MCPServerLookup, logger zerolog.Logger, traceRepo *repository.TraceRepository, costRepo *repository.CostRepository, simulator *ToolCallSimulator, budgets *budget.Service, estimator *pricing.CostEstimator, alerts *alerting.Service, metricsRegistry *metrics.Registry, loops *loopguard.Guard, egressPolicy *egress.Policy, auditLogger *audit.Logger) *MCPHandler {
 	return &MCPHandler{
-		config: cfg,
-		logger: logger,
+		servers: servers,
+		logger:  logger,
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: egressPolicy.Transport(),
 		},
 		traceRepo: traceRepo,
+		costRepo:  costRepo,
+		simulator: simulator,
+		budgets:   budgets,
+		estimator: estimator,
+		alerts:    alerts,
+		metrics:   metricsRegistry,
+		loops:     loops,
+		schemas:   newToolSchemaCache(),
+		upstreams: newWSUpstreams(egressPolicy),
+		egress:    egressPolicy,
+		audit:     auditLogger,
 	}
 }
 
+// Shutdown closes the connections to WebSocket MCP servers.
+func (h *MCPHandler) Shutdown(ctx context.Context) error {
+	h.upstreams.close()
+	return nil
+}
+
+// recordCall feeds a completed MCP call into Prometheus metrics and alert rule
+// evaluation.
+func (h *MCPHandler) recordCall(authInfo *middleware.AuthInfo, server, tool string, isError bool, duration time.Duration, cost float64) {
+	h.metrics.ObserveMCPCall(server, tool, isError, duration, cost)
+	if h.alerts == nil {
+		return
+	}
+	sample := domain.CallSample{
+		OrgID:       authInfo.OrgID,
+		Environment: authInfo.Environment,
+		MCPServer:   server,
+		ToolName:    tool,
+		IsError:     isError,
+		DurationMs:  duration.Milliseconds(),
+		Cost:        cost,
+	}
+	if authInfo.TeamID != uuid.Nil {
+		sample.TeamID = &authInfo.TeamID
+	}
+	h.alerts.RecordCall(sample)
+}
+
 // MCPRequest represents a generic MCP request.
 type MCPRequest struct {
 	Tool      string                 `json:"tool,omitempty"`
@@ -76,34 +145,110 @@ func (h *MCPHandler) PromptsGet(w http.ResponseWriter, r *http.Request) {
 	h.proxyRequest(w, r, "/prompts/get")
 }
 
-// PromptsList handles POST /v1/mcp/{server}/prompts/list
+// PromptsList handles POST /v1/mcp/{server}/prompts/list. Each listed prompt
+// gains server and qualified_name fields.
 func (h *MCPHandler) PromptsList(w http.ResponseWriter, r *http.Request) {
 	h.proxyRequest(w, r, "/prompts/list")
 }
 
-// proxyRequest forwards the request to the target MCP server.
+// CallTool executes a tool call on server through the full proxy path and
+// writes the result to w, as if the call had been posted to tools/call. It
+// implements ToolCaller for approval replays.
+func (h *MCPHandler) CallTool(w http.ResponseWriter, r *http.Request, server, tool string, arguments map[string]interface{}) {
+	body, err := json.Marshal(MCPRequest{Tool: tool, Arguments: arguments})
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, response.CodeInternalError, "Failed to encode tool call")
+		return
+	}
+	r = r.Clone(r.Context())
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+	h.proxy(w, r, server, "/tools/call")
+}
+
+// errMCPServerNotFound is returned when a named MCP server is not configured.
+var errMCPServerNotFound = errors.New("MCP server not found")
+
+// errToolLoop is returned when a tool call is blocked as part of a loop.
+var errToolLoop = errors.New("tool call loop detected")
+
+// checkLoop counts a tool call towards loop detection for the caller: its
+// API key, or its client IP when it has none.
+func (h *MCPHandler) checkLoop(ctx context.Context, server, tool string, args map[string]interface{}) loopguard.Verdict {
+	var principal string
+	if ip := middleware.GetClientIP(ctx); ip != "" {
+		principal = "ip:" + ip
+	}
+	var orgID uuid.UUID
+	if authInfo := middleware.GetAuthInfo(ctx); authInfo != nil {
+		orgID = authInfo.OrgID
+		if authInfo.APIKeyID != uuid.Nil {
+			principal = "api_key:" + authInfo.APIKeyID.String()
+		}
+	}
+	return h.loops.Check(orgID, principal, server, tool, args)
+}
+
+// ReadResource reads a resource directly from an MCP server orgID can call,
+// retrying like any other read. It implements agent.ResourceReader for
+// subscriptions.
+func (h *MCPHandler) ReadResource(ctx context.Context, orgID uuid.UUID, server, uri string) ([]byte, error) {
+	serverConfig, ok := h.servers.MCPServer(orgID, server)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", errMCPServerNotFound, server)
+	}
+
+	body, err := json.Marshal(MCPRequest{URI: uri})
+	if err != nil {
+		return nil, err
+	}
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+
+	resp, _, err := h.forward(ctx, serverConfig, serverConfig.URL+"/resources/read", body, header, true)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		return nil, errors.New(parseUpstreamError(resp.StatusCode, resp.Body).summary())
+	}
+	return resp.Body, nil
+}
+
+// proxyRequest forwards the request to the MCP server named in the path.
 func (h *MCPHandler) proxyRequest(w http.ResponseWriter, r *http.Request, endpoint string) {
-	serverName := chi.URLParam(r, "server")
+	h.proxy(w, r, chi.URLParam(r, "server"), endpoint)
+}
+
+// proxy forwards the request to the named MCP server.
+func (h *MCPHandler) proxy(w http.ResponseWriter, r *http.Request, serverName, endpoint string) {
 	if serverName == "" {
-		WriteError(w, http.StatusBadRequest, "missing_server", "Server name is required")
+		WriteError(w, http.StatusBadRequest, response.CodeMissingServer, "Server name is required")
 		return
 	}
 
 	// Look up server configuration
-	serverConfig, ok := h.config.MCPServers[serverName]
+	serverConfig, ok := h.servers.MCPServer(middleware.GetOrgID(r.Context()), serverName)
 	if !ok {
-		WriteError(w, http.StatusNotFound, "server_not_found", fmt.Sprintf("MCP server '%s' not found", serverName))
+		WriteError(w, http.StatusNotFound, response.CodeServerNotFound, fmt.Sprintf("MCP server '%s' not found", serverName))
+		return
+	}
+	if authInfo := middleware.GetAuthInfo(r.Context()); authInfo != nil && !authInfo.ServerAllowed(serverName) {
+		WriteError(w, http.StatusForbidden, response.CodeForbidden, serverScopeReason(serverName))
 		return
 	}
 
 	// Read request body
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_body", "Failed to read request body")
+	body, ok := readBody(w, r)
+	if !ok {
 		return
 	}
 	defer r.Body.Close()
 
+	if endpoint == "/prompts/get" {
+		body = unqualifyPromptRequest(serverName, body)
+	}
+
 	// Get trace info for logging
 	traceID := middleware.GetTraceID(r.Context())
 	spanID := middleware.GetSpanID(r.Context())
@@ -111,8 +256,16 @@ func (h *MCPHandler) proxyRequest(w http.ResponseWriter, r *http.Request, endpoi
 	// Get auth info
 	authInfo := middleware.GetAuthInfo(r.Context())
 
-	h.logger.Info().
-		Str("trace_id", traceID).
+	// Request-scoped logger carries request_id and trace_id
+	logger := middleware.RequestLogger(r.Context(), h.logger)
+
+	// Dry run: evaluate policy and return the decision without forwarding
+	if middleware.IsDryRun(r) {
+		h.dryRun(w, r, logger, authInfo, serverName, endpoint, body)
+		return
+	}
+
+	logger.Info().
 		Str("span_id", spanID).
 		Str("server", serverName).
 		Str("endpoint", endpoint).
@@ -122,47 +275,115 @@ func (h *MCPHandler) proxyRequest(w http.ResponseWriter, r *http.Request, endpoi
 
 	start := time.Now()
 
-	// Build target URL
-	targetURL := serverConfig.URL + endpoint
+	// Extract tool name from request body for tracing
+	var mcpReq MCPRequest
+	toolName := ""
+	if err := json.Unmarshal(body, &mcpReq); err == nil {
+		toolName = mcpReq.Tool
+		if toolName == "" {
+			toolName = mcpReq.Name
+		}
+	}
 
-	// Create proxy request
-	proxyReq, err := http.NewRequestWithContext(r.Context(), http.MethodPost, targetURL, bytes.NewReader(body))
-	if err != nil {
-		h.logger.Error().Err(err).Msg("Failed to create proxy request")
-		WriteError(w, http.StatusInternalServerError, "proxy_error", "Failed to create proxy request")
-		return
+	// Enforce budget hard caps against the estimated cost before executing
+	// billable calls. The estimate is held against the budgets until the
+	// call's actual cost is known, and released if the call never completes.
+	estimatedCost := h.estimator.Estimate(serverName, toolName, mcpReq.Arguments)
+	var teamID *uuid.UUID
+	if authInfo.TeamID != uuid.Nil {
+		teamID = &authInfo.TeamID
+	}
+	var cost float64
+	if h.budgets != nil {
+		reservation, exceeded := h.budgets.Reserve(authInfo.OrgID, teamID, estimatedCost)
+		if reservation == nil {
+			logger.Warn().
+				Str("server", serverName).
+				Str("budget_id", exceeded.ID.String()).
+				Float64("spent_usd", exceeded.SpentUSD).
+				Float64("limit_usd", exceeded.LimitUSD).
+				Msg("MCP request blocked by budget")
+			WriteError(w, http.StatusPaymentRequired, response.CodeBudgetExceeded,
+				fmt.Sprintf("Budget '%s' exhausted for this %s period", exceeded.Name, exceeded.Period))
+			return
+		}
+		defer func() { h.budgets.Settle(reservation, cost) }()
+	}
+
+	// Stop agents stuck repeating the same tool call
+	if endpoint == "/tools/call" {
+		verdict := h.checkLoop(r.Context(), serverName, toolName, mcpReq.Arguments)
+		if verdict.Blocked {
+			WriteError(w, http.StatusTooManyRequests, response.CodeToolLoopDetected,
+				fmt.Sprintf("Tool '%s' was called with the same arguments %d times in a short window", toolName, verdict.Repeats))
+			return
+		}
+		if verdict.Looping {
+			w.Header().Set("X-Loop-Warning", strconv.Itoa(verdict.Repeats))
+		}
 	}
 
-	// Copy relevant headers
-	proxyReq.Header.Set("Content-Type", "application/json")
-	proxyReq.Header.Set("X-Trace-ID", traceID)
-	proxyReq.Header.Set("X-Span-ID", spanID)
-	proxyReq.Header.Set("X-Forwarded-For", r.RemoteAddr)
+	// Build target URL
+	targetURL := serverConfig.URL + endpoint
+
+	// Build proxy headers
+	proxyHeader := http.Header{}
+	proxyHeader.Set("Content-Type", "application/json")
+	proxyHeader.Set("X-Trace-ID", traceID)
+	proxyHeader.Set("X-Span-ID", spanID)
+	proxyHeader.Set(middleware.RequestIDHeader, middleware.GetRequestID(r.Context()))
+	proxyHeader.Set("X-Forwarded-For", middleware.RequestClientIP(r))
 
 	// Set timeout from config
 	ctx, cancel := context.WithTimeout(r.Context(), serverConfig.Timeout)
 	defer cancel()
-	proxyReq = proxyReq.WithContext(ctx)
 
-	// Extract tool name from request body for tracing
-	var mcpReq MCPRequest
-	toolName := ""
-	if err := json.Unmarshal(body, &mcpReq); err == nil {
-		toolName = mcpReq.Tool
-		if toolName == "" {
-			toolName = mcpReq.Name
+	// Apply the server's request transforms before dispatch
+	pipeline, err := transform.New(serverConfig.Transforms)
+	if err != nil {
+		logger.Error().Err(err).Str("server", serverName).Msg("Invalid MCP transform configuration")
+		WriteError(w, http.StatusInternalServerError, response.CodeInternalError, "MCP server transforms are misconfigured")
+		return
+	}
+	if body, err = pipeline.Apply(transform.PhaseRequest, toolName, proxyHeader, body); err != nil {
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	// Reject tool calls whose arguments do not match the tool's input schema
+	if endpoint == "/tools/call" {
+		if verr := h.validateToolArguments(ctx, serverConfig, toolName, body); verr != nil {
+			field := "arguments"
+			if verr.Path != "" {
+				field += "." + verr.Path
+			}
+			logger.Info().
+				Str("server", serverName).
+				Str("tool", toolName).
+				Str("field", field).
+				Str("violation", verr.Message).
+				Msg("MCP tool call rejected by input schema")
+			WriteFieldError(w, field, fmt.Sprintf("Arguments for tool '%s' do not match its input schema: %s", toolName, verr.Message))
+			return
 		}
 	}
 
-	// Send request to MCP server
-	resp, err := h.httpClient.Do(proxyReq)
+	// Send request to MCP server, retrying idempotent calls
+	idempotent := h.isIdempotent(middleware.GetOrgID(r.Context()), serverName, endpoint, toolName)
+	forwardStart := time.Now()
+	resp, retries, err := h.forward(ctx, serverConfig, targetURL, body, proxyHeader, idempotent)
+	forwardEnd := time.Now()
+	if errors.Is(err, egress.ErrDenied) {
+		h.egressDenied(w, r, serverName, err)
+		return
+	}
 	if err != nil {
 		duration := time.Since(start)
-		h.logger.Error().
+		logger.Error().
 			Err(err).
-			Str("trace_id", traceID).
 			Dur("duration", duration).
 			Str("target_url", targetURL).
+			Int("retries", retries.count).
 			Msg("MCP server request failed")
 
 		// Persist error trace
@@ -181,54 +402,58 @@ func (h *MCPHandler) proxyRequest(w http.ResponseWriter, r *http.Request, endpoi
 				DurationMs:  duration.Milliseconds(),
 				RequestSize: len(body),
 				ErrorMsg:    err.Error(),
-				CreatedAt:   time.Now(),
+				Metadata: map[string]string{
+					"retries":    strconv.Itoa(retries.count),
+					"request_id": middleware.GetRequestID(r.Context()),
+				},
+				CreatedAt: time.Now(),
 			}
 			if authInfo.TeamID != uuid.Nil {
 				trace.TeamID = &authInfo.TeamID
 			}
+			span := upstreamSpan(traceID, spanID, forwardStart, forwardEnd, serverName, 0, retries)
 			go func() {
 				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 				defer cancel()
 				h.traceRepo.Create(ctx, trace)
+				h.traceRepo.CreateSpan(ctx, &span)
 			}()
 		}
 
-		WriteError(w, http.StatusBadGateway, "upstream_error", "Failed to reach MCP server")
-		return
-	}
-	defer resp.Body.Close()
+		h.recordCall(authInfo, serverName, toolName, true, duration, 0)
 
-	// Read response body
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		h.logger.Error().Err(err).Msg("Failed to read MCP server response")
-		WriteError(w, http.StatusBadGateway, "upstream_error", "Failed to read MCP server response")
+		WriteError(w, http.StatusBadGateway, response.CodeUpstreamError, "Failed to reach MCP server")
 		return
 	}
+	respBody := resp.Body
 
 	duration := time.Since(start)
 
-	// Calculate cost (simple per-call pricing for now)
-	cost := serverConfig.Pricing.PerCall
+	// Price the call from what was actually sent and received; the estimate
+	// is kept on the trace for reconciliation
+	cost = h.estimator.Actual(serverName, toolName, len(body), len(respBody))
 
 	// Determine status
 	status := "success"
 	var errorMsg string
+	var upstream upstreamError
 	if resp.StatusCode >= 400 {
 		status = "error"
-		errorMsg = fmt.Sprintf("HTTP %d", resp.StatusCode)
+		upstream = parseUpstreamError(resp.StatusCode, respBody)
+		errorMsg = upstream.summary()
 	}
 
-	h.logger.Info().
-		Str("trace_id", traceID).
+	logger.Info().
 		Str("span_id", spanID).
 		Str("server", serverName).
 		Str("endpoint", endpoint).
 		Str("tool", toolName).
 		Int("status", resp.StatusCode).
+		Int("retries", retries.count).
 		Int("response_size", len(respBody)).
 		Dur("duration", duration).
 		Float64("cost", cost).
+		Float64("estimated_cost", estimatedCost).
 		Msg("MCP request completed")
 
 	// Persist trace to database
@@ -249,7 +474,12 @@ func (h *MCPHandler) proxyRequest(w http.ResponseWriter, r *http.Request, endpoi
 			ResponseSize: len(respBody),
 			Cost:         cost,
 			ErrorMsg:     errorMsg,
-			CreatedAt:    time.Now(),
+			Metadata: map[string]string{
+				"retries":        strconv.Itoa(retries.count),
+				"request_id":     middleware.GetRequestID(r.Context()),
+				"estimated_cost": strconv.FormatFloat(estimatedCost, 'f', 6, 64),
+			},
+			CreatedAt: time.Now(),
 		}
 
 		if authInfo.TeamID != uuid.Nil {
@@ -257,24 +487,144 @@ func (h *MCPHandler) proxyRequest(w http.ResponseWriter, r *http.Request, endpoi
 		}
 
 		// Create trace asynchronously to not block response
+		span := upstreamSpan(traceID, spanID, forwardStart, forwardEnd, serverName, resp.StatusCode, retries)
 		go func() {
 			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 			defer cancel()
 			if err := h.traceRepo.Create(ctx, trace); err != nil {
 				h.logger.Error().Err(err).Str("trace_id", traceID).Msg("Failed to persist trace")
 			}
+			if err := h.traceRepo.CreateSpan(ctx, &span); err != nil {
+				h.logger.Error().Err(err).Str("trace_id", traceID).Msg("Failed to persist trace span")
+			}
 		}()
 	}
 
+	h.recordCall(authInfo, serverName, toolName, resp.StatusCode >= 400, duration, cost)
+
+	// Record cost event for attribution reports
+	if h.costRepo != nil {
+		event := &domain.CostEvent{
+			ID:        uuid.New(),
+			OrgID:     authInfo.OrgID,
+			TraceID:   traceID,
+			MCPServer: serverName,
+			ToolName:  toolName,
+			Operation: endpoint,
+			Cost:      cost,
+			CreatedAt: time.Now(),
+		}
+		if authInfo.TeamID != uuid.Nil {
+			event.TeamID = &authInfo.TeamID
+		}
+		if authInfo.UserID != uuid.Nil {
+			event.UserID = &authInfo.UserID
+		}
+		if authInfo.APIKeyID != uuid.Nil {
+			event.APIKeyID = &authInfo.APIKeyID
+		}
+
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := h.costRepo.RecordEvent(ctx, event); err != nil {
+				h.logger.Error().Err(err).Str("trace_id", traceID).Msg("Failed to persist cost event")
+			}
+		}()
+	}
+
+	// Namespace listed prompts, optionally scan fetched prompt text and
+	// refresh cached tool schemas from listed tools
+	if resp.StatusCode < 400 {
+		switch endpoint {
+		case "/tools/list":
+			h.schemas.store(serverConfig.CacheKey(), respBody)
+		case "/prompts/list":
+			respBody = qualifyPromptList(serverName, respBody)
+		case "/prompts/get":
+			if serverConfig.ScanPrompts && !h.scanPrompt(w, r, logger, authInfo, serverName, toolName, respBody) {
+				return
+			}
+		}
+	}
+
 	// Forward response to client
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("X-MCP-Server", serverName)
 	w.Header().Set("X-MCP-Duration-Ms", fmt.Sprintf("%d", duration.Milliseconds()))
 	w.Header().Set("X-MCP-Cost", fmt.Sprintf("%.6f", cost))
+	w.Header().Set("X-MCP-Retries", strconv.Itoa(retries.count))
+	if resp.StatusCode >= 400 {
+		writeUpstreamError(w, upstream)
+		return
+	}
+	if respBody, err = pipeline.Apply(transform.PhaseResponse, toolName, w.Header(), respBody); err != nil {
+		logger.Error().Err(err).Str("server", serverName).Msg("MCP response transform failed")
+		WriteError(w, http.StatusInternalServerError, response.CodeInternalError, "Failed to transform MCP response")
+		return
+	}
 	w.WriteHeader(resp.StatusCode)
 	w.Write(respBody)
 }
 
+// egressDenied refuses a request to a server at a destination the egress
+// policy does not allow, and audits the attempt.
+func (h *MCPHandler) egressDenied(w http.ResponseWriter, r *http.Request, serverName string, err error) {
+	logger := middleware.RequestLogger(r.Context(), h.logger)
+	logger.Warn().Err(err).Str("server", serverName).Msg("MCP request refused by egress policy")
+	logAuditEvent(r, h.audit, domain.AuditActionMCPEgressDenied, "mcp_server", serverName, domain.AuditOutcomeBlocked, map[string]interface{}{
+		"reason": err.Error(),
+	})
+	WriteError(w, http.StatusForbidden, response.CodeEgressDenied,
+		fmt.Sprintf("MCP server '%s' is at a destination the gateway may not reach", serverName))
+}
+
+// dryRun writes the policy decision for a request without contacting the MCP server.
+func (h *MCPHandler) dryRun(w http.ResponseWriter, r *http.Request, logger zerolog.Logger, authInfo *middleware.AuthInfo, serverName, endpoint string, body []byte) {
+	var mcpReq MCPRequest
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &mcpReq); err != nil {
+			WriteError(w, http.StatusBadRequest, response.CodeInvalidBody, "Request body must be valid JSON")
+			return
+		}
+	}
+
+	toolName := ""
+	if endpoint == "/tools/call" {
+		toolName = mcpReq.Tool
+		if toolName == "" {
+			toolName = mcpReq.Name
+		}
+	}
+
+	var decision domain.ToolCallDecision
+	if h.simulator != nil {
+		decision = h.simulator.Simulate(r.Context(), authInfo, serverName, toolName, mcpReq.Arguments)
+	} else {
+		decision = domain.ToolCallDecision{
+			MCPServer:     serverName,
+			ToolName:      toolName,
+			Allowed:       true,
+			EstimatedCost: h.estimator.Estimate(serverName, toolName, mcpReq.Arguments),
+		}
+	}
+
+	logger.Info().
+		Str("server", serverName).
+		Str("endpoint", endpoint).
+		Str("tool", toolName).
+		Bool("allowed", decision.Allowed).
+		Msg("MCP dry run evaluated")
+
+	w.Header().Set("X-MCP-Server", serverName)
+	w.Header().Set("X-MCP-Dry-Run", "true")
+	w.Header().Set("X-MCP-Cost", fmt.Sprintf("%.6f", decision.EstimatedCost))
+	WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"dry_run":  true,
+		"decision": decision,
+	})
+}
+
 // validateMCPRequest validates the MCP request body.
 func validateMCPRequest(body []byte, endpoint string) error {
 	var req MCPRequest
@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/middleware"
+	"github.com/akz4ol/gatewayops/gateway/internal/response"
+	"github.com/akz4ol/gatewayops/gateway/internal/safety"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// promptNamespaceSep separates the server from the prompt name in qualified
+// prompt names, matching the server__tool form used for tools.
+const promptNamespaceSep = "__"
+
+// Prompts handles GET /v1/mcp/{server}/prompts as an alias of prompts/list.
+func (h *MCPHandler) Prompts(w http.ResponseWriter, r *http.Request) {
+	r.Body = io.NopCloser(strings.NewReader("{}"))
+	h.proxyRequest(w, r, "/prompts/list")
+}
+
+// qualifiedPromptName returns the gateway-wide name of a server's prompt.
+func qualifiedPromptName(server, name string) string {
+	return server + promptNamespaceSep + name
+}
+
+// unqualifyPromptRequest strips this server's namespace from the prompt name
+// in a prompts/get body so clients may use either form. Bodies without a
+// qualified name are returned unchanged.
+func unqualifyPromptRequest(server string, body []byte) []byte {
+	var req map[string]interface{}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return body
+	}
+	name, _ := req["name"].(string)
+	prefix := server + promptNamespaceSep
+	if !strings.HasPrefix(name, prefix) || len(name) == len(prefix) {
+		return body
+	}
+	req["name"] = strings.TrimPrefix(name, prefix)
+	rewritten, err := json.Marshal(req)
+	if err != nil {
+		return body
+	}
+	return rewritten
+}
+
+// qualifyPromptList adds server and qualified_name to each prompt in a
+// prompts/list response.
+func qualifyPromptList(server string, body []byte) []byte {
+	var list map[string]interface{}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return body
+	}
+	prompts, ok := list["prompts"].([]interface{})
+	if !ok {
+		return body
+	}
+	for _, p := range prompts {
+		prompt, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if name, ok := prompt["name"].(string); ok {
+			prompt["server"] = server
+			prompt["qualified_name"] = qualifiedPromptName(server, name)
+		}
+	}
+	rewritten, err := json.Marshal(list)
+	if err != nil {
+		return body
+	}
+	return rewritten
+}
+
+// promptText collects the text of every message in a prompts/get response.
+func promptText(body []byte) string {
+	var prompt struct {
+		Messages []struct {
+			Content json.RawMessage `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(body, &prompt); err != nil {
+		return ""
+	}
+
+	var texts []string
+	for _, msg := range prompt.Messages {
+		// Content is a single content block or a list of them
+		var blocks []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		}
+		if err := json.Unmarshal(msg.Content, &blocks); err != nil {
+			var block struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+			}
+			if err := json.Unmarshal(msg.Content, &block); err != nil {
+				continue
+			}
+			blocks = append(blocks, block)
+		}
+		for _, b := range blocks {
+			if b.Text != "" {
+				texts = append(texts, b.Text)
+			}
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// scanPrompt runs injection detection over the text of a fetched prompt. It
+// reports false after writing an error response when policy blocks the
+// prompt; warnings are added as headers as for tool calls.
+func (h *MCPHandler) scanPrompt(w http.ResponseWriter, r *http.Request, logger zerolog.Logger, authInfo *middleware.AuthInfo, server, name string, body []byte) bool {
+	if h.simulator == nil || h.simulator.detector == nil {
+		return true
+	}
+	text := promptText(body)
+	if text == "" {
+		return true
+	}
+
+	opts := safety.DetectOptions{
+		Input:     text,
+		OrgID:     authInfo.OrgID,
+		TraceID:   middleware.GetTraceID(r.Context()),
+		MCPServer: server,
+		ToolName:  name,
+		IPAddress: middleware.RequestClientIP(r),
+	}
+	if authInfo.APIKeyID != uuid.Nil {
+		opts.APIKeyID = &authInfo.APIKeyID
+	}
+	result := h.simulator.detector.Detect(r.Context(), text, opts)
+	if !result.Detected {
+		return true
+	}
+
+	switch result.Action {
+	case domain.SafetyModeBlock:
+		logger.Warn().
+			Str("severity", string(result.Severity)).
+			Str("pattern", result.PatternMatched).
+			Str("mcp_server", server).
+			Str("prompt", name).
+			Msg("Blocked prompt due to prompt injection detection")
+		response.WriteErrorDetails(w, http.StatusBadGateway, response.CodeInjectionDetected,
+			"Prompt blocked: potential prompt injection detected in server response",
+			map[string]interface{}{
+				"severity": result.Severity,
+				"type":     result.Type,
+			})
+		return false
+	case domain.SafetyModeWarn:
+		w.Header().Set("X-Safety-Warning", "potential_injection_detected")
+		w.Header().Set("X-Safety-Severity", string(result.Severity))
+	}
+	return true
+}
@@ -0,0 +1,208 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/config"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/egress"
+	"github.com/akz4ol/gatewayops/gateway/internal/middleware"
+	"github.com/google/uuid"
+)
+
+// upstreamResponse holds the result of a forwarded MCP request.
+type upstreamResponse struct {
+	StatusCode int
+	Body       []byte
+}
+
+// retryInfo describes the retries forward performed before its final
+// response.
+type retryInfo struct {
+	count      int // Retries performed
+	lastStatus int // Upstream status that prompted the last retry; 0 after a transport error
+}
+
+// forward sends the request to the MCP server, retrying transient failures
+// with exponential backoff when the call is idempotent. It returns the
+// retries performed alongside the final response. A destination the egress
+// policy refuses fails with an error wrapping egress.ErrDenied, and is not
+// retried.
+func (h *MCPHandler) forward(ctx context.Context, serverConfig config.MCPServerConfig, targetURL string, body []byte, header http.Header, idempotent bool) (*upstreamResponse, retryInfo, error) {
+	var retries retryInfo
+	if serverConfig.Transport != config.MCPTransportSandbox {
+		if err := h.egress.CheckURL(targetURL); err != nil {
+			return nil, retries, err
+		}
+	}
+
+	maxRetries := 0
+	if idempotent && serverConfig.MaxRetries > 0 {
+		maxRetries = serverConfig.MaxRetries
+	}
+
+	var lastErr error
+	for attempt := 0; ; attempt++ {
+		retries.count = attempt
+		resp, err := h.attempt(ctx, serverConfig, targetURL, body, header)
+		if err == nil && !isRetriableStatus(serverConfig, resp.StatusCode) {
+			return resp, retries, nil
+		}
+
+		if attempt >= maxRetries || ctx.Err() != nil || errors.Is(err, egress.ErrDenied) {
+			if err != nil {
+				return nil, retries, err
+			}
+			return resp, retries, nil
+		}
+
+		if err != nil {
+			lastErr = err
+			retries.lastStatus = 0
+		} else {
+			lastErr = fmt.Errorf("upstream returned status %d", resp.StatusCode)
+			retries.lastStatus = resp.StatusCode
+		}
+
+		delay := backoffDelay(serverConfig, attempt)
+		logger := middleware.RequestLogger(ctx, h.logger)
+		logger.Warn().
+			Err(lastErr).
+			Str("server", serverConfig.Name).
+			Int("attempt", attempt+1).
+			Dur("backoff", delay).
+			Msg("Retrying MCP request")
+
+		select {
+		case <-ctx.Done():
+			return nil, retries, lastErr
+		case <-time.After(delay):
+		}
+	}
+}
+
+// upstreamSpan returns the span for a request forwarded to an MCP server,
+// with the retries it took, as a child of the request's span. A statusCode of
+// zero means the server was never reached.
+func upstreamSpan(traceID, parentID string, start, end time.Time, serverName string, statusCode int, retries retryInfo) domain.TraceSpan {
+	span := domain.TraceSpan{
+		ID:         uuid.New(),
+		TraceID:    traceID,
+		SpanID:     "sp_" + uuid.New().String()[:8],
+		ParentID:   parentID,
+		Name:       "proxy_to_mcp",
+		Kind:       "client",
+		Status:     "success",
+		StartTime:  start,
+		EndTime:    end,
+		DurationMs: end.Sub(start).Milliseconds(),
+		Attributes: map[string]string{
+			"mcp.server":  serverName,
+			"mcp.retries": strconv.Itoa(retries.count),
+		},
+	}
+	if statusCode == 0 || statusCode >= 400 {
+		span.Status = "error"
+	}
+	if statusCode != 0 {
+		span.Attributes["http.status_code"] = strconv.Itoa(statusCode)
+	}
+	if retries.count > 0 && retries.lastStatus != 0 {
+		span.Attributes["mcp.retry.last_status"] = strconv.Itoa(retries.lastStatus)
+	}
+	return span
+}
+
+// attempt performs a single request to the MCP server, over its WebSocket
+// connection when the server uses that transport, or in process for the
+// sandbox server.
+func (h *MCPHandler) attempt(ctx context.Context, serverConfig config.MCPServerConfig, targetURL string, body []byte, header http.Header) (*upstreamResponse, error) {
+	if serverConfig.AttemptTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, serverConfig.AttemptTimeout)
+		defer cancel()
+	}
+
+	switch serverConfig.Transport {
+	case config.MCPTransportWebSocket:
+		return h.upstreams.roundTrip(ctx, serverConfig, strings.TrimPrefix(targetURL, serverConfig.URL), body, header)
+	case config.MCPTransportSandbox:
+		return sandboxRoundTrip(ctx, serverConfig, strings.TrimPrefix(targetURL, serverConfig.URL), body, header)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, targetURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header = header.Clone()
+	serverConfig.Auth.Apply(req.Header)
+
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+
+	return &upstreamResponse{
+		StatusCode: resp.StatusCode,
+		Body:       respBody,
+	}, nil
+}
+
+// isIdempotent reports whether an MCP request can be safely retried under the
+// organization's tool classifications.
+func (h *MCPHandler) isIdempotent(orgID uuid.UUID, serverName, endpoint, toolName string) bool {
+	if endpoint != "/tools/call" {
+		return true
+	}
+	if toolName == "" || h.simulator == nil {
+		return false
+	}
+	level, _ := h.simulator.Classification(orgID, serverName, toolName)
+	return level == domain.ToolRiskSafe
+}
+
+// isRetriableStatus reports whether the upstream status code should be retried.
+func isRetriableStatus(serverConfig config.MCPServerConfig, statusCode int) bool {
+	for _, code := range serverConfig.RetryStatusCodes {
+		if code == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// backoffDelay returns the exponential backoff with jitter for the given attempt.
+func backoffDelay(serverConfig config.MCPServerConfig, attempt int) time.Duration {
+	base := serverConfig.RetryBaseDelay
+	if base <= 0 {
+		base = 100 * time.Millisecond
+	}
+	maxDelay := serverConfig.RetryMaxDelay
+	if maxDelay <= 0 {
+		maxDelay = 2 * time.Second
+	}
+
+	delay := base << uint(attempt)
+	if delay <= 0 || delay > maxDelay {
+		delay = maxDelay
+	}
+
+	// Equal jitter: half fixed, half random
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/config"
+	"github.com/akz4ol/gatewayops/gateway/internal/egress"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// flakyServer answers with status for the first failures requests and 200
+// after that, counting the requests it receives.
+func flakyServer(t *testing.T, status, failures int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if int(hits.Add(1)) <= failures {
+			w.WriteHeader(status)
+			return
+		}
+		w.Write([]byte(`{"content":[]}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+func newRetryTestHandler() *MCPHandler {
+	return NewMCPHandler(nil, zerolog.Nop(), nil, nil, nil, nil, nil, nil, nil, nil, &egress.Policy{}, nil)
+}
+
+func retryingServer(url string) config.MCPServerConfig {
+	return config.MCPServerConfig{
+		Name:             "flaky",
+		URL:              url,
+		Transport:        config.MCPTransportHTTP,
+		Timeout:          5 * time.Second,
+		MaxRetries:       3,
+		RetryBaseDelay:   20 * time.Millisecond,
+		RetryMaxDelay:    time.Second,
+		RetryStatusCodes: []int{http.StatusServiceUnavailable},
+	}
+}
+
+func TestForwardRetriesRetriableStatusWithBackoff(t *testing.T) {
+	srv, hits := flakyServer(t, http.StatusServiceUnavailable, 2)
+	h := newRetryTestHandler()
+	serverConfig := retryingServer(srv.URL)
+
+	start := time.Now()
+	resp, retries, err := h.forward(context.Background(), serverConfig, srv.URL+"/tools/list", []byte("{}"), http.Header{}, true)
+	elapsed := time.Since(start)
+	if err != nil {
+		t.Fatalf("forward: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || hits.Load() != 3 {
+		t.Fatalf("status %d after %d requests, want 200 after 3", resp.StatusCode, hits.Load())
+	}
+	if retries.count != 2 || retries.lastStatus != http.StatusServiceUnavailable {
+		t.Errorf("retries = %+v, want 2 retries, the last after a 503", retries)
+	}
+	// Equal jitter waits at least half of 20ms, then half of 40ms
+	if elapsed < 30*time.Millisecond {
+		t.Errorf("retried within %s, want backoff of at least 30ms", elapsed)
+	}
+
+	span := upstreamSpan("tr_1", "sp_parent", start, start.Add(elapsed), serverConfig.Name, resp.StatusCode, retries)
+	if span.ParentID != "sp_parent" || span.Status != "success" {
+		t.Errorf("span = %+v, want a successful child of the request span", span)
+	}
+	if span.Attributes["mcp.retries"] != "2" || span.Attributes["mcp.retry.last_status"] != "503" || span.Attributes["http.status_code"] != "200" {
+		t.Errorf("span attributes = %v, want the retries and the last retry status", span.Attributes)
+	}
+}
+
+func TestForwardDoesNotRetryNonIdempotentToolCall(t *testing.T) {
+	srv, hits := flakyServer(t, http.StatusServiceUnavailable, 1)
+	h := newRetryTestHandler()
+
+	// Without a classification marking the tool safe, a tool call is not
+	// idempotent
+	idempotent := h.isIdempotent(uuid.New(), "flaky", "/tools/call", "write_file")
+	if idempotent {
+		t.Fatal("an unclassified tool call was treated as idempotent")
+	}
+	resp, retries, err := h.forward(context.Background(), retryingServer(srv.URL), srv.URL+"/tools/call", []byte("{}"), http.Header{}, idempotent)
+	if err != nil {
+		t.Fatalf("forward: %v", err)
+	}
+	if resp.StatusCode != http.StatusServiceUnavailable || hits.Load() != 1 || retries.count != 0 {
+		t.Errorf("status %d after %d requests and %d retries, want the 503 returned without a retry", resp.StatusCode, hits.Load(), retries.count)
+	}
+
+	span := upstreamSpan("tr_1", "sp_parent", time.Now(), time.Now(), "flaky", resp.StatusCode, retries)
+	if span.Attributes["mcp.retries"] != "0" || span.Status != "error" {
+		t.Errorf("span = %+v, want a failed span with no retries", span)
+	}
+	if _, ok := span.Attributes["mcp.retry.last_status"]; ok {
+		t.Error("a span without retries carries a last retry status")
+	}
+}
@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/config"
+)
+
+// sandboxTool is a tool of the built-in sandbox server.
+type sandboxTool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	InputSchema json.RawMessage `json:"inputSchema"`
+}
+
+// sandboxTools are the tools of the sandbox server. Their names carry the
+// default safe, sensitive and dangerous classifications, so calls to them
+// go through the same allow, approval and block paths as on a real server.
+var sandboxTools = []sandboxTool{
+	{
+		Name:        "echo",
+		Description: "Return the arguments as given",
+		InputSchema: json.RawMessage(`{"type":"object"}`),
+	},
+	{
+		Name:        "read_file",
+		Description: "Pretend to read a file",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{"path":{"type":"string"}},"required":["path"]}`),
+	},
+	{
+		Name:        "write_file",
+		Description: "Pretend to write a file",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{"path":{"type":"string"},"content":{"type":"string"}},"required":["path","content"]}`),
+	},
+	{
+		Name:        "delete_file",
+		Description: "Pretend to delete a file",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{"path":{"type":"string"}},"required":["path"]}`),
+	},
+}
+
+// sandboxResource is the one resource of the sandbox server.
+const sandboxResource = "sandbox://readme"
+
+// sandboxRoundTrip answers a request to the sandbox server in process. Tool
+// calls echo their arguments back as text, so anything placed in them, such
+// as an injection attempt, is also seen by output scanning. Every response
+// is a function of the request alone.
+func sandboxRoundTrip(ctx context.Context, serverConfig config.MCPServerConfig, endpoint string, body []byte, header http.Header) (*upstreamResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var req MCPRequest
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &req); err != nil {
+			return sandboxResponse(http.StatusBadRequest, map[string]interface{}{"error": "Invalid request body"})
+		}
+	}
+
+	switch endpoint {
+	case "/tools/list":
+		return sandboxResponse(http.StatusOK, map[string]interface{}{"tools": sandboxTools})
+	case "/tools/call":
+		for _, tool := range sandboxTools {
+			if tool.Name == req.Tool {
+				return sandboxResponse(http.StatusOK, sandboxToolResult(req))
+			}
+		}
+		return sandboxResponse(http.StatusBadRequest, map[string]interface{}{"error": fmt.Sprintf("Unknown tool: %s", req.Tool)})
+	case "/resources/list":
+		return sandboxResponse(http.StatusOK, map[string]interface{}{
+			"resources": []map[string]string{
+				{"uri": sandboxResource, "name": "README", "description": "About the sandbox server", "mimeType": "text/plain"},
+			},
+		})
+	case "/resources/read":
+		if req.URI != sandboxResource {
+			return sandboxResponse(http.StatusNotFound, map[string]interface{}{"error": fmt.Sprintf("Unknown resource: %s", req.URI)})
+		}
+		return sandboxResponse(http.StatusOK, map[string]interface{}{
+			"contents": []map[string]string{
+				{"uri": sandboxResource, "mimeType": "text/plain", "text": "The sandbox server echoes tool calls back without side effects."},
+			},
+		})
+	case "/prompts/list":
+		return sandboxResponse(http.StatusOK, map[string]interface{}{
+			"prompts": []map[string]interface{}{
+				{"name": "echo", "description": "A prompt whose text is its arguments"},
+			},
+		})
+	case "/prompts/get":
+		if req.Name != "echo" {
+			return sandboxResponse(http.StatusNotFound, map[string]interface{}{"error": fmt.Sprintf("Unknown prompt: %s", req.Name)})
+		}
+		return sandboxResponse(http.StatusOK, map[string]interface{}{
+			"messages": []map[string]interface{}{
+				{"role": "user", "content": map[string]string{"type": "text", "text": sandboxArgumentText(req.Arguments)}},
+			},
+		})
+	default:
+		return sandboxResponse(http.StatusNotFound, map[string]interface{}{"error": "Not found"})
+	}
+}
+
+// sandboxToolResult returns the result of a sandbox tool call: the call's
+// arguments, as text and as given.
+func sandboxToolResult(req MCPRequest) map[string]interface{} {
+	arguments := req.Arguments
+	if arguments == nil {
+		arguments = map[string]interface{}{}
+	}
+	return map[string]interface{}{
+		"content": []map[string]string{
+			{"type": "text", "text": sandboxArgumentText(arguments)},
+		},
+		"tool":      req.Tool,
+		"arguments": arguments,
+		"sandbox":   true,
+	}
+}
+
+// sandboxArgumentText renders arguments as key=value lines in key order, with
+// string values as is so their text can be scanned.
+func sandboxArgumentText(arguments map[string]interface{}) string {
+	keys := make([]string, 0, len(arguments))
+	for key := range arguments {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	lines := make([]string, 0, len(keys))
+	for _, key := range keys {
+		value, ok := arguments[key].(string)
+		if !ok {
+			encoded, _ := json.Marshal(arguments[key])
+			value = string(encoded)
+		}
+		lines = append(lines, key+"="+value)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// sandboxResponse encodes a sandbox response body.
+func sandboxResponse(status int, body interface{}) (*upstreamResponse, error) {
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return &upstreamResponse{StatusCode: status, Body: encoded}, nil
+}
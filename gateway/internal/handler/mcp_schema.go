@@ -0,0 +1,207 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/config"
+	"github.com/akz4ol/gatewayops/gateway/internal/jsonschema"
+	"github.com/akz4ol/gatewayops/gateway/internal/middleware"
+)
+
+// schemaRetryInterval is how long a failed tools/list fetch is remembered
+// before the server is asked again. Calls go unvalidated in the meantime.
+const schemaRetryInterval = 30 * time.Second
+
+// toolSchemaCache holds the names and compiled input schemas of each
+// server's tools.
+type toolSchemaCache struct {
+	mu      sync.Mutex
+	servers map[string]*serverSchemas
+}
+
+// serverSchemas is one server's cached tool list. Its mutex is held while
+// the list is fetched so concurrent calls wait for a single fetch.
+type serverSchemas struct {
+	mu        sync.Mutex
+	names     []string // Every tool listed, in the server's order
+	tools     map[string]*jsonschema.Schema
+	fetchedAt time.Time
+	failed    bool
+}
+
+func newToolSchemaCache() *toolSchemaCache {
+	return &toolSchemaCache{servers: make(map[string]*serverSchemas)}
+}
+
+func (c *toolSchemaCache) server(name string) *serverSchemas {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.servers[name]
+	if !ok {
+		s = &serverSchemas{}
+		c.servers[name] = s
+	}
+	return s
+}
+
+// store replaces a server's cached schemas from a tools/list response body.
+// Bodies that are not a tool list are ignored.
+func (c *toolSchemaCache) store(server string, body []byte) {
+	names, tools, ok := parseToolSchemas(body)
+	if !ok {
+		return
+	}
+	s := c.server(server)
+	s.mu.Lock()
+	s.names, s.tools, s.fetchedAt, s.failed = names, tools, time.Now(), false
+	s.mu.Unlock()
+}
+
+// parseToolSchemas returns the names of the tools in a tools/list response
+// and compiles their inputSchema. Tools without a usable schema are left out
+// of the schemas and so go unvalidated.
+func parseToolSchemas(body []byte) ([]string, map[string]*jsonschema.Schema, bool) {
+	var list struct {
+		Tools []struct {
+			Name        string          `json:"name"`
+			InputSchema json.RawMessage `json:"inputSchema"`
+		} `json:"tools"`
+	}
+	if err := json.Unmarshal(body, &list); err != nil || list.Tools == nil {
+		return nil, nil, false
+	}
+	names := make([]string, 0, len(list.Tools))
+	tools := make(map[string]*jsonschema.Schema, len(list.Tools))
+	for _, tool := range list.Tools {
+		if tool.Name == "" {
+			continue
+		}
+		names = append(names, tool.Name)
+		if len(tool.InputSchema) == 0 {
+			continue
+		}
+		if schema, err := jsonschema.Compile(tool.InputSchema); err == nil {
+			tools[tool.Name] = schema
+		}
+	}
+	return names, tools, true
+}
+
+// toolSchema returns the input schema of a server's tool, fetching the
+// server's tool list when the cache is stale. It returns nil when the schema
+// is unknown, in which case the call is forwarded unvalidated.
+func (h *MCPHandler) toolSchema(ctx context.Context, serverConfig config.MCPServerConfig, tool string) *jsonschema.Schema {
+	s := h.schemas.server(serverConfig.CacheKey())
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := h.refreshToolSchemas(ctx, serverConfig, s); err != nil {
+		logger := middleware.RequestLogger(ctx, h.logger)
+		logger.Warn().
+			Err(err).
+			Str("server", serverConfig.Name).
+			Msg("Failed to fetch MCP tool schemas; arguments will not be validated")
+	}
+	return s.tools[tool]
+}
+
+// ServerTools returns the names of a server's tools, from the cached tool
+// list when it is fresh. When the server cannot be asked, the last list it
+// returned is used; it is an error only if there is none.
+func (h *MCPHandler) ServerTools(ctx context.Context, server string) ([]string, error) {
+	serverConfig, ok := h.servers.MCPServer(middleware.GetOrgID(ctx), server)
+	if !ok {
+		return nil, fmt.Errorf("MCP server '%s' not found", server)
+	}
+	s := h.schemas.server(serverConfig.CacheKey())
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := h.refreshToolSchemas(ctx, serverConfig, s)
+	if s.names == nil {
+		if err == nil {
+			err = fmt.Errorf("tools/list has not been fetched")
+		}
+		return nil, err
+	}
+	return append([]string(nil), s.names...), nil
+}
+
+// refreshToolSchemas fetches a server's tool list into s when the cached one
+// is stale, keeping the old list when the fetch fails. s.mu must be held.
+func (h *MCPHandler) refreshToolSchemas(ctx context.Context, serverConfig config.MCPServerConfig, s *serverSchemas) error {
+	ttl := serverConfig.SchemaCacheTTL
+	if s.failed {
+		ttl = schemaRetryInterval
+	}
+	if !s.fetchedAt.IsZero() && time.Since(s.fetchedAt) <= ttl {
+		return nil
+	}
+	names, tools, err := h.fetchToolSchemas(ctx, serverConfig)
+	s.fetchedAt = time.Now()
+	if err != nil {
+		s.failed = true
+		return err
+	}
+	s.names, s.tools, s.failed = names, tools, false
+	return nil
+}
+
+// fetchToolSchemas asks the server for its tool list.
+func (h *MCPHandler) fetchToolSchemas(ctx context.Context, serverConfig config.MCPServerConfig) ([]string, map[string]*jsonschema.Schema, error) {
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+
+	resp, _, err := h.forward(ctx, serverConfig, serverConfig.URL+"/tools/list", []byte("{}"), header, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode >= 400 {
+		return nil, nil, fmt.Errorf("tools/list returned status %d", resp.StatusCode)
+	}
+	names, tools, ok := parseToolSchemas(resp.Body)
+	if !ok {
+		return nil, nil, fmt.Errorf("tools/list response has no tools array")
+	}
+	return names, tools, nil
+}
+
+// validateToolArguments checks a tools/call body against the tool's input
+// schema. It returns the path of the offending argument and the violation,
+// or nil when the arguments are valid or the schema is unknown.
+func (h *MCPHandler) validateToolArguments(ctx context.Context, serverConfig config.MCPServerConfig, tool string, body []byte) *jsonschema.ValidationError {
+	if serverConfig.ArgValidation == config.ArgValidationOff || tool == "" {
+		return nil
+	}
+	schema := h.toolSchema(ctx, serverConfig, tool)
+	if schema == nil {
+		return nil
+	}
+
+	var req struct {
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil
+	}
+	var args interface{} = map[string]interface{}{}
+	if len(req.Arguments) > 0 && string(req.Arguments) != "null" {
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil
+		}
+	}
+
+	opts := jsonschema.Options{RejectUnknown: serverConfig.ArgValidation == config.ArgValidationStrict}
+	if err := schema.Validate(args, opts); err != nil {
+		if verr, ok := err.(*jsonschema.ValidationError); ok {
+			return verr
+		}
+		return &jsonschema.ValidationError{Message: err.Error()}
+	}
+	return nil
+}
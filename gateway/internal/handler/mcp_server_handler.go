@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/audit"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/egress"
+	"github.com/akz4ol/gatewayops/gateway/internal/mcpserver"
+	"github.com/akz4ol/gatewayops/gateway/internal/middleware"
+	"github.com/akz4ol/gatewayops/gateway/internal/response"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// MCPServerHandler handles registration of MCP servers at runtime.
+type MCPServerHandler struct {
+	logger      zerolog.Logger
+	registry    *mcpserver.Registry
+	auditLogger *audit.Logger
+}
+
+// NewMCPServerHandler creates a new MCP server handler.
+func NewMCPServerHandler(logger zerolog.Logger, registry *mcpserver.Registry, auditLogger *audit.Logger) *MCPServerHandler {
+	return &MCPServerHandler{
+		logger:      logger,
+		registry:    registry,
+		auditLogger: auditLogger,
+	}
+}
+
+// ListServers returns the configured MCP servers and those the caller's
+// organization registered.
+func (h *MCPServerHandler) ListServers(w http.ResponseWriter, r *http.Request) {
+	servers := h.registry.List(middleware.GetOrgID(r.Context()))
+	WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"servers": servers,
+		"total":   len(servers),
+	})
+}
+
+// GetServer returns an MCP server.
+func (h *MCPServerHandler) GetServer(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	server, ok := h.registry.Get(middleware.GetOrgID(r.Context()), name)
+	if !ok {
+		WriteError(w, http.StatusNotFound, response.CodeServerNotFound, fmt.Sprintf("MCP server '%s' not found", name))
+		return
+	}
+	WriteJSON(w, http.StatusOK, server)
+}
+
+// RegisterServer registers an MCP server. The server must answer at its URL;
+// it can be called as soon as it is registered.
+func (h *MCPServerHandler) RegisterServer(w http.ResponseWriter, r *http.Request) {
+	var input domain.MCPServerInput
+	if !decodeInput(w, r, &input) {
+		return
+	}
+
+	var createdBy *uuid.UUID
+	if userID := middleware.GetUserID(r.Context()); userID != uuid.Nil {
+		createdBy = &userID
+	}
+	server, err := h.registry.Register(r.Context(), middleware.GetOrgID(r.Context()), input, createdBy)
+	if errors.Is(err, egress.ErrDenied) {
+		h.egressDenied(w, r, input.Name, input.URL, err)
+		return
+	}
+	if err != nil {
+		h.writeRegistryError(w, err, input.Name)
+		return
+	}
+
+	recordAudit(r, h.auditLogger, domain.AuditActionMCPServerRegister, "mcp_server", server.Name, nil, server)
+
+	WriteJSON(w, http.StatusCreated, server)
+}
+
+// UpdateServer replaces the settings of a registered MCP server.
+func (h *MCPServerHandler) UpdateServer(w http.ResponseWriter, r *http.Request) {
+	var input domain.MCPServerUpdate
+	if !decodeInput(w, r, &input) {
+		return
+	}
+
+	orgID := middleware.GetOrgID(r.Context())
+	name := chi.URLParam(r, "name")
+	before, _ := h.registry.Get(orgID, name)
+	server, err := h.registry.Update(r.Context(), orgID, name, input)
+	if errors.Is(err, egress.ErrDenied) {
+		h.egressDenied(w, r, name, input.URL, err)
+		return
+	}
+	if err != nil {
+		h.writeRegistryError(w, err, name)
+		return
+	}
+
+	recordAudit(r, h.auditLogger, domain.AuditActionMCPServerUpdate, "mcp_server", name, before, server)
+
+	WriteJSON(w, http.StatusOK, server)
+}
+
+// RemoveServer removes a registered MCP server. Calls to it fail from then on.
+func (h *MCPServerHandler) RemoveServer(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	server, err := h.registry.Remove(r.Context(), middleware.GetOrgID(r.Context()), name)
+	if err != nil {
+		h.writeRegistryError(w, err, name)
+		return
+	}
+
+	recordAudit(r, h.auditLogger, domain.AuditActionMCPServerRemove, "mcp_server", name, server, nil)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// egressDenied refuses to register a server at a destination the egress
+// policy does not allow, and audits the attempt.
+func (h *MCPServerHandler) egressDenied(w http.ResponseWriter, r *http.Request, name, url string, err error) {
+	h.logger.Warn().Err(err).Str("server", name).Str("url", url).Msg("MCP server refused by egress policy")
+	logAuditEvent(r, h.auditLogger, domain.AuditActionMCPEgressDenied, "mcp_server", name, domain.AuditOutcomeBlocked, map[string]interface{}{
+		"url":    url,
+		"reason": err.Error(),
+	})
+	WriteError(w, http.StatusForbidden, response.CodeEgressDenied, err.Error())
+}
+
+// writeRegistryError writes the response for an error from the registry.
+func (h *MCPServerHandler) writeRegistryError(w http.ResponseWriter, err error, name string) {
+	switch {
+	case errors.Is(err, mcpserver.ErrInvalidName):
+		WriteFieldError(w, "name", "name must be lowercase letters and digits, separated by single hyphens or underscores")
+	case errors.Is(err, mcpserver.ErrServerExists):
+		WriteError(w, http.StatusConflict, response.CodeDuplicateName, fmt.Sprintf("MCP server '%s' already exists", name))
+	case errors.Is(err, mcpserver.ErrServerNotFound):
+		WriteError(w, http.StatusNotFound, response.CodeServerNotFound, fmt.Sprintf("MCP server '%s' not found", name))
+	case errors.Is(err, mcpserver.ErrServerReadOnly):
+		WriteError(w, http.StatusForbidden, response.CodeConfiguredServer, fmt.Sprintf("MCP server '%s' is configured through the environment and cannot be changed", name))
+	case errors.Is(err, mcpserver.ErrInvalidAuth):
+		WriteFieldError(w, "auth", strings.TrimPrefix(err.Error(), mcpserver.ErrInvalidAuth.Error()+": "))
+	case errors.Is(err, mcpserver.ErrNoSecretsKey):
+		WriteError(w, http.StatusServiceUnavailable, response.CodeSecretsUnavailable, "Credentials cannot be stored because SECRETS_ENCRYPTION_KEY is not set")
+	case errors.Is(err, mcpserver.ErrServerUnreachable):
+		WriteError(w, http.StatusUnprocessableEntity, response.CodeServerUnreachable, err.Error())
+	default:
+		h.logger.Error().Err(err).Str("server", name).Msg("Failed to save MCP server")
+		WriteError(w, http.StatusInternalServerError, response.CodeInternalError, "Failed to save MCP server")
+	}
+}
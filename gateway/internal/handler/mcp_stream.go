@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/agent"
+	"github.com/akz4ol/gatewayops/gateway/internal/config"
+	"github.com/akz4ol/gatewayops/gateway/internal/middleware"
+)
+
+// streamContentType marks a tool response streamed as newline-delimited
+// JSON, one chunk of output per line.
+const streamContentType = "application/x-ndjson"
+
+// errStreamIdle is the cause of a streamed call cancelled because the server
+// sent nothing for longer than its timeout.
+var errStreamIdle = errors.New("MCP server stopped sending output")
+
+// upstreamStatusError is returned when the MCP server answers a streamed call
+// with an error status.
+type upstreamStatusError struct {
+	StatusCode int
+	Body       []byte
+}
+
+func (e *upstreamStatusError) Error() string {
+	return fmt.Sprintf("MCP server returned status %d", e.StatusCode)
+}
+
+// StreamTool calls tool on server and passes its output to onChunk as it
+// arrives. Servers stream output by answering with Content-Type
+// application/x-ndjson, each line holding a tool result, a content block or
+// text; any other response is passed on as a single chunk. The
+// server's timeout bounds the wait for each chunk rather than the whole
+// call, so long-running tools are not cut off while they make progress.
+// Streamed calls are not retried. An error from onChunk ends the call and is
+// returned. StreamTool returns the cost of the call.
+func (h *MCPHandler) StreamTool(ctx context.Context, server, tool string, args map[string]interface{}, onChunk func([]agent.ContentBlock) error) (float64, error) {
+	serverConfig, ok := h.servers.MCPServer(middleware.GetOrgID(ctx), server)
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", errMCPServerNotFound, server)
+	}
+
+	if verdict := h.checkLoop(ctx, server, tool, args); verdict.Blocked {
+		return 0, fmt.Errorf("%w: %s called %d times", errToolLoop, tool, verdict.Repeats)
+	}
+
+	body, err := json.Marshal(MCPRequest{Tool: tool, Arguments: args})
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	idle := time.AfterFunc(serverConfig.Timeout, func() { cancel(errStreamIdle) })
+	defer idle.Stop()
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set("Accept", streamContentType+", application/json")
+	header.Set(middleware.RequestIDHeader, middleware.GetRequestID(ctx))
+
+	start := time.Now()
+	var size int
+	if serverConfig.Transport == config.MCPTransportWebSocket || serverConfig.Transport == config.MCPTransportSandbox {
+		// WebSocket and sandbox servers answer each request with one frame
+		size, err = h.readFrame(ctx, serverConfig, body, header, func(chunk []byte) error {
+			return onChunk(streamChunkContent(chunk))
+		})
+	} else {
+		req, reqErr := http.NewRequestWithContext(ctx, http.MethodPost, serverConfig.URL+"/tools/call", bytes.NewReader(body))
+		if reqErr != nil {
+			return 0, fmt.Errorf("create request: %w", reqErr)
+		}
+		req.Header = header
+		serverConfig.Auth.Apply(req.Header)
+		size, err = h.readStream(req, func(chunk []byte) error {
+			idle.Reset(serverConfig.Timeout)
+			return onChunk(streamChunkContent(chunk))
+		})
+	}
+	cost := h.estimator.Actual(server, tool, len(body), size)
+	if authInfo := middleware.GetAuthInfo(ctx); authInfo != nil {
+		h.recordCall(authInfo, server, tool, err != nil, time.Since(start), cost)
+	}
+	if err != nil {
+		if cause := context.Cause(ctx); errors.Is(cause, errStreamIdle) {
+			return 0, cause
+		}
+		return 0, err
+	}
+	return cost, nil
+}
+
+// readStream sends req and passes each chunk of the response to onChunk,
+// returning the number of bytes received.
+func (h *MCPHandler) readStream(req *http.Request, onChunk func([]byte) error) (int, error) {
+	// The shared client's overall timeout would cut long streams short
+	client := &http.Client{Transport: h.httpClient.Transport}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		return len(body), &upstreamStatusError{StatusCode: resp.StatusCode, Body: body}
+	}
+
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), streamContentType) {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return len(body), err
+		}
+		return len(body), onChunk(body)
+	}
+
+	size := 0
+	reader := bufio.NewReader(resp.Body)
+	for {
+		line, err := reader.ReadBytes('\n')
+		size += len(line)
+		if chunk := bytes.TrimSpace(line); len(chunk) > 0 {
+			if err := onChunk(chunk); err != nil {
+				return size, err
+			}
+		}
+		if err == io.EOF {
+			return size, nil
+		}
+		if err != nil {
+			return size, err
+		}
+	}
+}
+
+// readFrame sends a tool call to a WebSocket or sandbox MCP server and
+// passes its response to onChunk as a single chunk, returning the number of
+// bytes received.
+func (h *MCPHandler) readFrame(ctx context.Context, serverConfig config.MCPServerConfig, body []byte, header http.Header, onChunk func([]byte) error) (int, error) {
+	roundTrip := h.upstreams.roundTrip
+	if serverConfig.Transport == config.MCPTransportSandbox {
+		roundTrip = sandboxRoundTrip
+	}
+	resp, err := roundTrip(ctx, serverConfig, "/tools/call", body, header)
+	if err != nil {
+		return 0, err
+	}
+	if resp.StatusCode >= 400 {
+		return len(resp.Body), &upstreamStatusError{StatusCode: resp.StatusCode, Body: resp.Body}
+	}
+	return len(resp.Body), onChunk(resp.Body)
+}
+
+// streamChunkContent returns the content of one chunk of streamed output: the
+// content of a tool result, a single content block, or text given as a JSON
+// string or as is.
+func streamChunkContent(chunk []byte) []agent.ContentBlock {
+	var result struct {
+		Content []agent.ContentBlock `json:"content"`
+	}
+	if json.Unmarshal(chunk, &result) == nil && len(result.Content) > 0 {
+		return result.Content
+	}
+	var block agent.ContentBlock
+	if json.Unmarshal(chunk, &block) == nil && block.Type != "" {
+		return []agent.ContentBlock{block}
+	}
+	var text string
+	if json.Unmarshal(chunk, &text) == nil {
+		return []agent.ContentBlock{{Type: "text", Text: text}}
+	}
+	return []agent.ContentBlock{{Type: "text", Text: string(chunk)}}
+}
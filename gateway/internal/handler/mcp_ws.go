@@ -0,0 +1,263 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/config"
+	"github.com/akz4ol/gatewayops/gateway/internal/egress"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+// wsWriteTimeout bounds sending a single frame to a WebSocket MCP server.
+const wsWriteTimeout = 10 * time.Second
+
+// errUpstreamClosed is returned for requests in flight when the connection to
+// a WebSocket MCP server drops. The next request opens a new connection.
+var errUpstreamClosed = errors.New("connection to MCP server closed")
+
+// wsFrame is a message exchanged with a WebSocket MCP server. Each request
+// carries the endpoint it would be POSTed to over HTTP and an ID that the
+// server echoes on its response, so responses may arrive in any order.
+type wsFrame struct {
+	ID       string            `json:"id"`
+	Endpoint string            `json:"endpoint,omitempty"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	Status   int               `json:"status,omitempty"` // HTTP status of a response; 0 means 200
+	Body     json.RawMessage   `json:"body,omitempty"`
+}
+
+// wsUpstreams keeps one persistent connection per WebSocket MCP server,
+// opened on first use.
+type wsUpstreams struct {
+	mu      sync.Mutex
+	conns   map[string]*wsUpstream
+	dialing map[string]*sync.Mutex // Held while connecting to a server, so concurrent requests share one dial
+	egress  *egress.Policy         // Checks every connection made
+}
+
+func newWSUpstreams(egressPolicy *egress.Policy) *wsUpstreams {
+	return &wsUpstreams{
+		conns:   make(map[string]*wsUpstream),
+		dialing: make(map[string]*sync.Mutex),
+		egress:  egressPolicy,
+	}
+}
+
+// wsUpstream is a connection to a WebSocket MCP server with the requests
+// awaiting a response on it.
+type wsUpstream struct {
+	conn   *websocket.Conn
+	url    string
+	auth   config.MCPAuth
+	closed chan struct{}
+
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	pending map[string]chan wsFrame
+	err     error
+}
+
+// roundTrip sends a request to the server over its connection and waits for
+// the response with the same ID.
+func (p *wsUpstreams) roundTrip(ctx context.Context, serverConfig config.MCPServerConfig, endpoint string, body []byte, header http.Header) (*upstreamResponse, error) {
+	if !json.Valid(body) {
+		return nil, errors.New("request body is not valid JSON")
+	}
+	upstream, err := p.get(ctx, serverConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	frame := wsFrame{
+		ID:       uuid.NewString(),
+		Endpoint: endpoint,
+		Headers:  make(map[string]string, len(header)),
+		Body:     body,
+	}
+	for name := range header {
+		frame.Headers[name] = header.Get(name)
+	}
+
+	respCh, err := upstream.register(frame.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer upstream.unregister(frame.ID)
+
+	if err := upstream.send(frame); err != nil {
+		upstream.fail(err)
+		return nil, err
+	}
+
+	select {
+	case resp := <-respCh:
+		status := resp.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		return &upstreamResponse{StatusCode: status, Body: resp.Body}, nil
+	case <-upstream.closed:
+		return nil, upstream.closeErr()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// get returns the open connection to the server, dialing a new one when
+// there is none or the server's URL or credential has changed.
+func (p *wsUpstreams) get(ctx context.Context, serverConfig config.MCPServerConfig) (*wsUpstream, error) {
+	existing, dialMu := p.current(serverConfig)
+	if existing != nil && existing.usable(serverConfig) {
+		return existing, nil
+	}
+
+	dialMu.Lock()
+	defer dialMu.Unlock()
+	if existing, _ = p.current(serverConfig); existing != nil && existing.usable(serverConfig) {
+		return existing, nil
+	}
+
+	dialer := websocket.Dialer{HandshakeTimeout: serverConfig.Timeout, NetDialContext: p.egress.DialContext}
+	header := http.Header{}
+	serverConfig.Auth.Apply(header)
+	conn, resp, err := dialer.DialContext(ctx, serverConfig.URL, header)
+	if err != nil {
+		if resp != nil {
+			return nil, fmt.Errorf("connect to MCP server: %w (HTTP %d)", err, resp.StatusCode)
+		}
+		return nil, fmt.Errorf("connect to MCP server: %w", err)
+	}
+
+	upstream := &wsUpstream{
+		conn:    conn,
+		url:     serverConfig.URL,
+		auth:    serverConfig.Auth,
+		closed:  make(chan struct{}),
+		pending: make(map[string]chan wsFrame),
+	}
+
+	p.mu.Lock()
+	p.conns[serverConfig.CacheKey()] = upstream
+	p.mu.Unlock()
+
+	if existing != nil {
+		existing.fail(errUpstreamClosed)
+	}
+	go upstream.readLoop()
+	return upstream, nil
+}
+
+// current returns the server's connection, if any, and the lock held while
+// connecting to it.
+func (p *wsUpstreams) current(serverConfig config.MCPServerConfig) (*wsUpstream, *sync.Mutex) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	dialMu, ok := p.dialing[serverConfig.CacheKey()]
+	if !ok {
+		dialMu = &sync.Mutex{}
+		p.dialing[serverConfig.CacheKey()] = dialMu
+	}
+	return p.conns[serverConfig.CacheKey()], dialMu
+}
+
+// close closes every connection, failing the requests still waiting.
+func (p *wsUpstreams) close() {
+	p.mu.Lock()
+	conns := p.conns
+	p.conns = make(map[string]*wsUpstream)
+	p.mu.Unlock()
+
+	for _, upstream := range conns {
+		upstream.writeMu.Lock()
+		upstream.conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseGoingAway, "gateway shutting down"),
+			time.Now().Add(time.Second))
+		upstream.writeMu.Unlock()
+		upstream.fail(errUpstreamClosed)
+	}
+}
+
+// usable reports whether the connection is open and still points at the
+// configured server.
+func (u *wsUpstream) usable(serverConfig config.MCPServerConfig) bool {
+	select {
+	case <-u.closed:
+		return false
+	default:
+	}
+	return u.url == serverConfig.URL && u.auth == serverConfig.Auth
+}
+
+// register adds a request awaiting its response.
+func (u *wsUpstream) register(id string) (chan wsFrame, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.err != nil {
+		return nil, u.err
+	}
+	ch := make(chan wsFrame, 1)
+	u.pending[id] = ch
+	return ch, nil
+}
+
+// unregister forgets a request once it has its response or gave up waiting.
+func (u *wsUpstream) unregister(id string) {
+	u.mu.Lock()
+	delete(u.pending, id)
+	u.mu.Unlock()
+}
+
+// send writes a frame to the server.
+func (u *wsUpstream) send(frame wsFrame) error {
+	u.writeMu.Lock()
+	defer u.writeMu.Unlock()
+	u.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	return u.conn.WriteJSON(frame)
+}
+
+// readLoop hands each response to the request with its ID until the
+// connection drops.
+func (u *wsUpstream) readLoop() {
+	for {
+		var frame wsFrame
+		if err := u.conn.ReadJSON(&frame); err != nil {
+			u.fail(fmt.Errorf("%w: %v", errUpstreamClosed, err))
+			return
+		}
+
+		u.mu.Lock()
+		ch, ok := u.pending[frame.ID]
+		delete(u.pending, frame.ID)
+		u.mu.Unlock()
+		if ok {
+			ch <- frame
+		}
+	}
+}
+
+// fail closes the connection, ending every request waiting on it with err.
+func (u *wsUpstream) fail(err error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.err != nil {
+		return
+	}
+	u.err = err
+	close(u.closed)
+	u.conn.Close()
+}
+
+// closeErr returns why the connection closed.
+func (u *wsUpstream) closeErr() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.err
+}
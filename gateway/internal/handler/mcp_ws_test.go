@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/config"
+	"github.com/akz4ol/gatewayops/gateway/internal/egress"
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog"
+)
+
+// mockWSServer is a WebSocket MCP server that collects batch requests before
+// answering them in reverse order, so responses only reach the right caller
+// if they are matched by ID. Each response echoes the request's endpoint and
+// tool.
+func mockWSServer(t *testing.T, batch int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var connections atomic.Int32
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		connections.Add(1)
+
+		for {
+			var frames []wsFrame
+			for len(frames) < batch {
+				var frame wsFrame
+				if err := conn.ReadJSON(&frame); err != nil {
+					return
+				}
+				frames = append(frames, frame)
+			}
+			for i := len(frames) - 1; i >= 0; i-- {
+				var req MCPRequest
+				json.Unmarshal(frames[i].Body, &req)
+				status := http.StatusOK
+				body := fmt.Sprintf(`{"content":[{"type":"text","text":"%s %s"}]}`, frames[i].Endpoint, req.Tool)
+				if req.Tool == "missing" {
+					status, body = http.StatusBadRequest, `"Unknown tool: missing"`
+				}
+				conn.WriteJSON(wsFrame{ID: frames[i].ID, Status: status, Body: json.RawMessage(body)})
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &connections
+}
+
+func TestWebSocketRequestsAreMatchedToTheirResponses(t *testing.T) {
+	const calls = 5
+	srv, connections := mockWSServer(t, calls)
+	upstreams := newWSUpstreams(&egress.Policy{})
+	t.Cleanup(upstreams.close)
+	server := config.MCPServerConfig{
+		Name:      "ws",
+		URL:       "ws" + strings.TrimPrefix(srv.URL, "http"),
+		Transport: config.MCPTransportWebSocket,
+		Timeout:   5 * time.Second,
+	}
+
+	var wg sync.WaitGroup
+	for i := range calls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			tool := fmt.Sprintf("tool_%d", i)
+			resp, err := upstreams.roundTrip(ctx, server, "/tools/call", []byte(`{"tool":"`+tool+`"}`), http.Header{})
+			if err != nil {
+				t.Errorf("%s: %v", tool, err)
+				return
+			}
+			if got := toolResultText(resp.Body); resp.StatusCode != http.StatusOK || got != "/tools/call "+tool {
+				t.Errorf("%s: got %d %q, another request's response", tool, resp.StatusCode, got)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := connections.Load(); n != 1 {
+		t.Errorf("opened %d connections, want the requests multiplexed over one", n)
+	}
+}
+
+func TestToolsCallProxiesToWebSocketServers(t *testing.T) {
+	srv, _ := mockWSServer(t, 1)
+	servers := staticServers{"code": {
+		Name:      "code",
+		URL:       "ws" + strings.TrimPrefix(srv.URL, "http"),
+		Transport: config.MCPTransportWebSocket,
+		Timeout:   5 * time.Second,
+	}}
+	h := NewMCPHandler(servers, zerolog.Nop(), nil, nil, nil, nil, nil, nil, nil, nil, &egress.Policy{}, nil)
+	t.Cleanup(func() { h.Shutdown(context.Background()) })
+
+	rec := serveMCP(h.ToolsCall, http.MethodPost, "/v1/mcp/code/tools/call", `{"tool":"read_file","arguments":{}}`)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "/tools/call read_file") {
+		t.Errorf("status %d: %s", rec.Code, rec.Body)
+	}
+
+	rec = serveMCP(h.ToolsCall, http.MethodPost, "/v1/mcp/code/tools/call", `{"tool":"missing","arguments":{}}`)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "Unknown tool: missing") {
+		t.Errorf("unknown tool: status %d: %s", rec.Code, rec.Body)
+	}
+}
@@ -2,19 +2,29 @@ package handler
 
 import (
 	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/akz4ol/gatewayops/gateway/internal/alerting"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/middleware"
 	"github.com/rs/zerolog"
 )
 
+const (
+	defaultLatencyWindowMinutes = 60
+	maxLatencyWindowMinutes     = 24 * 60
+)
+
 // MetricsHandler handles metrics and dashboard data requests.
 type MetricsHandler struct {
 	logger zerolog.Logger
+	alerts *alerting.Service
 }
 
 // NewMetricsHandler creates a new metrics handler.
-func NewMetricsHandler(logger zerolog.Logger) *MetricsHandler {
-	return &MetricsHandler{logger: logger}
+func NewMetricsHandler(logger zerolog.Logger, alerts *alerting.Service) *MetricsHandler {
+	return &MetricsHandler{logger: logger, alerts: alerts}
 }
 
 // Overview returns dashboard overview metrics.
@@ -156,3 +166,28 @@ func (h *MetricsHandler) RecentTraces(w http.ResponseWriter, r *http.Request) {
 		"traces": traces,
 	})
 }
+
+// Latency returns p50/p90/p95/p99 call latency computed from recorded MCP
+// calls, optionally scoped to one server or tool.
+func (h *MetricsHandler) Latency(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	windowMinutes := defaultLatencyWindowMinutes
+	if v := query.Get("window_minutes"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxLatencyWindowMinutes {
+			WriteFieldError(w, "window_minutes", "window_minutes must be between 1 and 1440")
+			return
+		}
+		windowMinutes = n
+	}
+
+	summary := h.alerts.LatencySummary(domain.MetricQuery{
+		OrgID:     middleware.GetOrgID(r.Context()),
+		Window:    time.Duration(windowMinutes) * time.Minute,
+		MCPServer: query.Get("mcp_server"),
+		ToolName:  query.Get("tool"),
+	})
+
+	WriteJSON(w, http.StatusOK, summary)
+}
@@ -0,0 +1,298 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/agent"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/middleware"
+	"github.com/akz4ol/gatewayops/gateway/internal/response"
+	"github.com/akz4ol/gatewayops/gateway/internal/safety"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// openAIToolSeparator joins the server and tool in the function names listed
+// by AgentHandler.ListTools, e.g. filesystem__read_file.
+const openAIToolSeparator = "__"
+
+// ChatCompletionRequest is the part of an OpenAI chat completions request
+// the gateway reads. Other fields, such as tools or temperature, are ignored.
+type ChatCompletionRequest struct {
+	Model    string        `json:"model"`
+	Messages []ChatMessage `json:"messages"`
+}
+
+// ChatMessage is a message in an OpenAI chat conversation.
+type ChatMessage struct {
+	Role       string         `json:"role"`
+	Content    any            `json:"content"`
+	ToolCalls  []ChatToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string         `json:"tool_call_id,omitempty"`
+}
+
+// ChatToolCall is a function call requested by the assistant.
+type ChatToolCall struct {
+	ID       string           `json:"id"`
+	Type     string           `json:"type"`
+	Function ChatFunctionCall `json:"function"`
+}
+
+// ChatFunctionCall names the function to call and its JSON-encoded
+// arguments.
+type ChatFunctionCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// ChatCompletionResponse carries one tool message per tool call, in the
+// order the calls were made.
+type ChatCompletionResponse struct {
+	ID      string       `json:"id"`
+	Object  string       `json:"object"`
+	Created int64        `json:"created"`
+	Model   string       `json:"model"`
+	Choices []ChatChoice `json:"choices"`
+}
+
+// ChatChoice is one message of a chat completion response.
+type ChatChoice struct {
+	Index        int         `json:"index"`
+	Message      ChatMessage `json:"message"`
+	FinishReason string      `json:"finish_reason"`
+}
+
+// OpenAIHandler executes the tool calls of OpenAI-style agents through the
+// gateway, so agents built on the OpenAI SDK can use it without changes.
+type OpenAIHandler struct {
+	logger       zerolog.Logger
+	simulator    *ToolCallSimulator
+	toolCaller   ToolCaller
+	limiter      middleware.RateLimiter
+	defaultLimit func() int
+}
+
+// NewOpenAIHandler creates a new OpenAI-compatible handler. Each tool call
+// counts against the caller's rate limit, is checked against approval and
+// safety policy by simulator, and is then made through toolCaller.
+func NewOpenAIHandler(logger zerolog.Logger, simulator *ToolCallSimulator, toolCaller ToolCaller, limiter middleware.RateLimiter, defaultLimit func() int) *OpenAIHandler {
+	return &OpenAIHandler{
+		logger:       logger,
+		simulator:    simulator,
+		toolCaller:   toolCaller,
+		limiter:      limiter,
+		defaultLimit: defaultLimit,
+	}
+}
+
+// ChatCompletions executes the tool calls in the last message, which must be
+// an assistant message with tool_calls, and returns a tool message for each,
+// linked to its call by tool_call_id. A call that fails yields a tool message
+// whose content is a JSON error with a category and whether it is retriable.
+func (h *OpenAIHandler) ChatCompletions(w http.ResponseWriter, r *http.Request) {
+	var req ChatCompletionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+	if len(req.Messages) == 0 {
+		WriteFieldError(w, "messages", "messages is required")
+		return
+	}
+	last := req.Messages[len(req.Messages)-1]
+	if last.Role != "assistant" || len(last.ToolCalls) == 0 {
+		WriteFieldError(w, "messages", "The last message must be an assistant message with tool_calls")
+		return
+	}
+	for i, call := range last.ToolCalls {
+		if call.ID == "" {
+			field := fmt.Sprintf("messages[%d].tool_calls[%d].id", len(req.Messages)-1, i)
+			WriteFieldError(w, field, field+" is required")
+			return
+		}
+	}
+
+	choices := make([]ChatChoice, len(last.ToolCalls))
+	var wg sync.WaitGroup
+	for i, call := range last.ToolCalls {
+		wg.Add(1)
+		go func(idx int, c ChatToolCall) {
+			defer wg.Done()
+			choices[idx] = ChatChoice{
+				Index: idx,
+				Message: ChatMessage{
+					Role:       "tool",
+					Content:    h.callTool(r, c),
+					ToolCallID: c.ID,
+				},
+				FinishReason: "stop",
+			}
+		}(i, call)
+	}
+	wg.Wait()
+
+	WriteJSON(w, http.StatusOK, ChatCompletionResponse{
+		ID:      "chatcmpl-" + uuid.New().String(),
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Model:   req.Model,
+		Choices: choices,
+	})
+}
+
+// callTool makes one tool call and returns the content of its tool message.
+func (h *OpenAIHandler) callTool(r *http.Request, call ChatToolCall) string {
+	server, tool, ok := strings.Cut(call.Function.Name, openAIToolSeparator)
+	if !ok || server == "" || tool == "" {
+		return toolErrorContent(agent.ErrorValidation,
+			fmt.Sprintf("Function '%s' is not a gateway tool; names have the form server%stool", call.Function.Name, openAIToolSeparator))
+	}
+
+	args := map[string]interface{}{}
+	if strings.TrimSpace(call.Function.Arguments) != "" {
+		if err := json.Unmarshal([]byte(call.Function.Arguments), &args); err != nil {
+			return toolErrorContent(agent.ErrorValidation, "Function arguments must be a JSON object")
+		}
+	}
+
+	authInfo := middleware.GetAuthInfo(r.Context())
+	if h.limiter != nil && authInfo != nil {
+		allowed, _, resetSeconds, err := h.limiter.Allow(r.Context(), middleware.RateLimitKey(authInfo), middleware.KeyRateLimit(authInfo, h.defaultLimit))
+		if err != nil {
+			// Allow the call but log it, as the rate limit middleware does
+			h.logger.Error().Err(err).Msg("Rate limiter error")
+		} else if !allowed {
+			return toolErrorContent(agent.ErrorRateLimited, fmt.Sprintf("Rate limit exceeded. Try again in %d seconds", resetSeconds))
+		}
+	}
+
+	if h.simulator != nil {
+		decision := h.simulator.Evaluate(r.Context(), authInfo, server, tool, args, safety.DetectOptions{
+			TraceID:   middleware.GetTraceID(r.Context()),
+			IPAddress: middleware.RequestClientIP(r),
+		})
+		if !decision.Allowed {
+			category := agent.ErrorDeniedByPolicy
+			if decision.Safety != nil && decision.Safety.Action == domain.SafetyModeBlock {
+				category = agent.ErrorInjectionBlocked
+			}
+			return toolErrorContent(category, decision.Reason)
+		}
+	}
+
+	rec := &toolCallRecorder{header: http.Header{}}
+	h.toolCaller.CallTool(rec, r, server, tool, args)
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	if rec.status >= 400 {
+		return toolErrorInfoContent(toolCallFailure(rec.status, rec.body.Bytes()))
+	}
+	return toolResultText(rec.body.Bytes())
+}
+
+// toolCallRecorder captures the response to a tool call made through the
+// MCP proxy path.
+type toolCallRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *toolCallRecorder) Header() http.Header { return rec.header }
+
+func (rec *toolCallRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	return rec.body.Write(b)
+}
+
+func (rec *toolCallRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+}
+
+// toolCallFailure returns the error for a failed tool call from the proxy's
+// error response, or the MCP server's own. Errors from the MCP server carry
+// its status and message.
+func toolCallFailure(status int, body []byte) *agent.ErrorInfo {
+	var errResp response.ErrorResponse
+	json.Unmarshal(body, &errResp)
+	code, message := errResp.Error.Code, errResp.Error.Message
+	upstream, fromServer := upstreamFailure(status, body)
+	if code == "" {
+		// The MCP server's own response, answered as the proxy would
+		status, code = upstreamErrorStatus(status)
+		message = upstream.summary()
+	}
+
+	var category agent.ErrorCategory
+	switch {
+	case code == response.CodeInjectionDetected:
+		category = agent.ErrorInjectionBlocked
+	case code == response.CodeToolLoopDetected:
+		category = agent.ErrorLoopDetected
+	case status == http.StatusPaymentRequired:
+		category = agent.ErrorBudgetExceeded
+	case status == http.StatusTooManyRequests:
+		category = agent.ErrorRateLimited
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		category = agent.ErrorDeniedByPolicy
+	case status == http.StatusGatewayTimeout || status == http.StatusRequestTimeout:
+		category = agent.ErrorUpstreamTimeout
+	case status < 500:
+		category = agent.ErrorValidation
+	default:
+		category = agent.ErrorUpstreamError
+	}
+	if message == "" {
+		message = fmt.Sprintf("MCP server returned HTTP %d", status)
+	}
+
+	info := agent.NewToolError(category, message)
+	if fromServer {
+		info.UpstreamStatus, info.UpstreamMessage = upstream.Status, upstream.Message
+	}
+	return info
+}
+
+// toolResultText returns the text of an MCP tool result, or the raw result
+// when it has no text content.
+func toolResultText(body []byte) string {
+	var result struct {
+		Content []agent.ContentBlock `json:"content"`
+	}
+	if json.Unmarshal(body, &result) == nil {
+		var texts []string
+		for _, block := range result.Content {
+			if block.Type == "text" && block.Text != "" {
+				texts = append(texts, block.Text)
+			}
+		}
+		if len(texts) > 0 {
+			return strings.Join(texts, "\n")
+		}
+	}
+	return string(body)
+}
+
+// toolErrorContent returns the content of the tool message for a failed
+// call: a JSON object with the error.
+func toolErrorContent(category agent.ErrorCategory, message string) string {
+	return toolErrorInfoContent(agent.NewToolError(category, message))
+}
+
+// toolErrorInfoContent returns the content of the tool message for a call
+// that failed with info.
+func toolErrorInfoContent(info *agent.ErrorInfo) string {
+	data, _ := json.Marshal(map[string]any{"error": info})
+	return string(data)
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/agent"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/rs/zerolog"
+)
+
+// echoToolCaller answers each tool call with text naming the tool and its
+// path argument, as an MCP server's tools/call would.
+type echoToolCaller struct{}
+
+func (echoToolCaller) CallTool(w http.ResponseWriter, r *http.Request, server, tool string, arguments map[string]interface{}) {
+	WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"content": []agent.ContentBlock{{Type: "text", Text: fmt.Sprintf("%s.%s %v", server, tool, arguments["path"])}},
+	})
+}
+
+func TestChatCompletionsAnswersEachToolCall(t *testing.T) {
+	h := NewOpenAIHandler(zerolog.Nop(), nil, echoToolCaller{}, nil, func() int { return 0 })
+	body := `{"model":"gpt-4o","messages":[
+		{"role":"user","content":"Read both files"},
+		{"role":"assistant","content":null,"tool_calls":[
+			{"id":"call_a","type":"function","function":{"name":"filesystem__read_file","arguments":"{\"path\":\"/a.txt\"}"}},
+			{"id":"call_b","type":"function","function":{"name":"filesystem__read_file","arguments":"{\"path\":\"/b.txt\"}"}},
+			{"id":"call_c","type":"function","function":{"name":"read_file","arguments":"{}"}}]}]}`
+	req := asKey(httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body)), domain.PermissionMCPCall)
+	rec := httptest.NewRecorder()
+	h.ChatCompletions(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+
+	var resp ChatCompletionResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Object != "chat.completion" || resp.Model != "gpt-4o" || len(resp.Choices) != 3 {
+		t.Fatalf("response = %+v, want a chat.completion with three choices", resp)
+	}
+	for i, want := range []struct {
+		id      string
+		content string
+	}{
+		{"call_a", "filesystem.read_file /a.txt"},
+		{"call_b", "filesystem.read_file /b.txt"},
+	} {
+		msg := resp.Choices[i].Message
+		if resp.Choices[i].Index != i || msg.Role != "tool" || msg.ToolCallID != want.id || msg.Content != want.content {
+			t.Errorf("choice %d = %+v, want the tool result %q for %s", i, resp.Choices[i], want.content, want.id)
+		}
+	}
+
+	// A function that is not a gateway tool fails alone
+	failed := resp.Choices[2].Message
+	var content struct {
+		Error agent.ErrorInfo `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(fmt.Sprint(failed.Content)), &content); err != nil {
+		t.Fatalf("decode error content %v: %v", failed.Content, err)
+	}
+	if failed.Role != "tool" || failed.ToolCallID != "call_c" || content.Error.Category != agent.ErrorValidation {
+		t.Errorf("unqualified function: message = %+v, want a validation error for call_c", failed)
+	}
+}
+
+func TestChatCompletionsRequiresToolCalls(t *testing.T) {
+	h := NewOpenAIHandler(zerolog.Nop(), nil, echoToolCaller{}, nil, func() int { return 0 })
+	for _, body := range []string{
+		`{"messages":[]}`,
+		`{"messages":[{"role":"user","content":"hello"}]}`,
+		`{"messages":[{"role":"assistant","tool_calls":[{"type":"function","function":{"name":"filesystem__read_file"}}]}]}`,
+	} {
+		rec := httptest.NewRecorder()
+		h.ChatCompletions(rec, httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want %d", body, rec.Code, http.StatusBadRequest)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/response"
+)
+
+// parseCursorParam decodes the optional cursor query parameter. It writes a
+// 400 response and returns false if the cursor is malformed.
+func parseCursorParam(w http.ResponseWriter, r *http.Request) (*domain.Cursor, bool) {
+	value := r.URL.Query().Get("cursor")
+	if value == "" {
+		return nil, true
+	}
+	cursor, err := domain.DecodeCursor(value)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidCursor, "Invalid pagination cursor")
+		return nil, false
+	}
+	return cursor, true
+}
+
+// parseSearchParam returns the trimmed q query parameter. It writes a 400
+// response and returns false if the query exceeds the maximum length.
+func parseSearchParam(w http.ResponseWriter, r *http.Request) (string, bool) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if utf8.RuneCountInString(query) > domain.MaxSearchQueryLength {
+		WriteFieldError(w, "q", fmt.Sprintf("Search query must be at most %d characters", domain.MaxSearchQueryLength))
+		return "", false
+	}
+	return query, true
+}
@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/approval"
+	"github.com/akz4ol/gatewayops/gateway/internal/budget"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/middleware"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// quotaPendingApprovals is the most pending approvals listed in a quota.
+const quotaPendingApprovals = 50
+
+// RateLimitUsage reads how many requests a rate limit key has made in the
+// current window, without counting one.
+type RateLimitUsage interface {
+	GetUsage(ctx context.Context, key string) (int, error)
+}
+
+// QuotaHandler reports what the calling principal can still do.
+type QuotaHandler struct {
+	logger       zerolog.Logger
+	usage        RateLimitUsage
+	defaultLimit func() int
+	budgets      *budget.Service
+	approvals    *approval.Service
+}
+
+// NewQuotaHandler creates a new quota handler. Keys without their own rate
+// limit are reported against defaultLimit. Nil services leave their sections
+// empty.
+func NewQuotaHandler(logger zerolog.Logger, usage RateLimitUsage, defaultLimit func() int, budgets *budget.Service, approvals *approval.Service) *QuotaHandler {
+	return &QuotaHandler{
+		logger:       logger,
+		usage:        usage,
+		defaultLimit: defaultLimit,
+		budgets:      budgets,
+		approvals:    approvals,
+	}
+}
+
+// Get returns the calling API key's remaining rate limit, the spend left in
+// the budgets covering it, and its user's tool permissions and pending
+// approvals. It does not count against the rate limit itself.
+func (h *QuotaHandler) Get(w http.ResponseWriter, r *http.Request) {
+	authInfo := middleware.GetAuthInfo(r.Context())
+	var teamID *uuid.UUID
+	if authInfo.TeamID != uuid.Nil {
+		teamID = &authInfo.TeamID
+	}
+
+	quota := domain.Quota{
+		RateLimit:        h.rateLimit(r.Context(), authInfo),
+		Budgets:          make([]domain.BudgetQuota, 0),
+		Permissions:      make([]domain.ToolPermission, 0),
+		PendingApprovals: make([]domain.ToolApproval, 0),
+	}
+
+	if h.budgets != nil {
+		for _, b := range h.budgets.Applicable(authInfo.OrgID, teamID) {
+			remaining := b.LimitUSD - b.SpentUSD
+			if remaining < 0 {
+				remaining = 0
+			}
+			quota.Budgets = append(quota.Budgets, domain.BudgetQuota{
+				ID:           b.ID,
+				Name:         b.Name,
+				Period:       b.Period,
+				LimitUSD:     b.LimitUSD,
+				SpentUSD:     b.SpentUSD,
+				RemainingUSD: remaining,
+			})
+		}
+	}
+
+	if h.approvals != nil {
+		quota.Permissions = h.approvals.ActivePermissions(authInfo.OrgID, authInfo.UserID, teamID)
+		page := h.approvals.ListApprovals(domain.ToolApprovalFilter{
+			OrgID:       authInfo.OrgID,
+			RequestedBy: &authInfo.UserID,
+			Statuses:    []domain.ApprovalStatus{domain.ApprovalStatusPending},
+			Limit:       quotaPendingApprovals,
+		})
+		quota.PendingApprovals = page.Approvals
+		quota.PendingApprovalCount = page.Total
+	}
+
+	WriteJSON(w, http.StatusOK, quota)
+}
+
+// rateLimit returns the API key's use of its rate limit in the current
+// window. A usage that cannot be read is reported as unavailable rather
+// than failing the request.
+func (h *QuotaHandler) rateLimit(ctx context.Context, authInfo *middleware.AuthInfo) domain.RateLimitQuota {
+	limit := middleware.KeyRateLimit(authInfo, h.defaultLimit)
+	quota := domain.RateLimitQuota{RequestsPerMinute: limit, Remaining: limit}
+	if h.usage == nil {
+		return quota
+	}
+
+	used, err := h.usage.GetUsage(ctx, middleware.RateLimitKey(authInfo))
+	if err != nil {
+		logger := middleware.RequestLogger(ctx, h.logger)
+		logger.Warn().Err(err).Msg("Failed to read rate limit usage")
+		quota.Remaining = 0
+		quota.Unavailable = true
+		return quota
+	}
+	quota.Used = used
+	if quota.Remaining = limit - used; quota.Remaining < 0 {
+		quota.Remaining = 0
+	}
+	return quota
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/clock"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/middleware"
+	"github.com/akz4ol/gatewayops/gateway/internal/ratelimit"
+	"github.com/rs/zerolog"
+)
+
+func TestQuotaReflectsRateLimitedCalls(t *testing.T) {
+	limiter := ratelimit.NewLimiter(nil, zerolog.Nop(), clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)))
+	defaultLimit := func() int { return 10 }
+	h := NewQuotaHandler(zerolog.Nop(), limiter, defaultLimit, nil, nil)
+	limited := middleware.RateLimit(limiter, zerolog.Nop(), defaultLimit, nil, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	key := &middleware.AuthInfo{OrgID: middleware.DemoOrgID, UserID: middleware.DemoUserID, KeyID: "gwo_prd_first"}
+	other := &middleware.AuthInfo{OrgID: middleware.DemoOrgID, UserID: middleware.DemoUserID, KeyID: "gwo_prd_second", RateLimit: 50}
+	as := func(info *middleware.AuthInfo, path string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		return r.WithContext(context.WithValue(r.Context(), middleware.AuthInfoKey, info))
+	}
+	quota := func(info *middleware.AuthInfo) domain.RateLimitQuota {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		h.Get(rec, as(info, "/v1/me/quota"))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status %d: %s", rec.Code, rec.Body)
+		}
+		var body domain.Quota
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return body.RateLimit
+	}
+
+	if got := quota(key); got != (domain.RateLimitQuota{RequestsPerMinute: 10, Remaining: 10}) {
+		t.Errorf("quota before any calls = %+v", got)
+	}
+	for i := 0; i < 3; i++ {
+		limited.ServeHTTP(httptest.NewRecorder(), as(key, "/v1/traces"))
+	}
+	want := domain.RateLimitQuota{RequestsPerMinute: 10, Used: 3, Remaining: 7}
+	if got := quota(key); got != want {
+		t.Errorf("quota after 3 calls = %+v, want %+v", got, want)
+	}
+	// Reading the quota is not a call against it
+	if got := quota(key); got != want {
+		t.Errorf("quota read twice = %+v, want %+v", got, want)
+	}
+	if got := quota(other); got != (domain.RateLimitQuota{RequestsPerMinute: 50, Remaining: 50}) {
+		t.Errorf("another key's quota = %+v, want its own limit untouched", got)
+	}
+
+	for i := 0; i < 10; i++ {
+		limited.ServeHTTP(httptest.NewRecorder(), as(key, "/v1/traces"))
+	}
+	if got := quota(key); got.Remaining != 0 || got.Used != 13 {
+		t.Errorf("quota past the limit = %+v, want none remaining", got)
+	}
+}
@@ -5,8 +5,11 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/akz4ol/gatewayops/gateway/internal/audit"
 	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/middleware"
 	"github.com/akz4ol/gatewayops/gateway/internal/rbac"
+	"github.com/akz4ol/gatewayops/gateway/internal/response"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
@@ -14,22 +17,24 @@ import (
 
 // RBACHandler handles RBAC-related HTTP requests.
 type RBACHandler struct {
-	logger  zerolog.Logger
-	service *rbac.Service
+	logger      zerolog.Logger
+	service     *rbac.Service
+	auditLogger *audit.Logger
 }
 
 // NewRBACHandler creates a new RBAC handler.
-func NewRBACHandler(logger zerolog.Logger, service *rbac.Service) *RBACHandler {
+func NewRBACHandler(logger zerolog.Logger, service *rbac.Service, auditLogger *audit.Logger) *RBACHandler {
 	return &RBACHandler{
-		logger:  logger,
-		service: service,
+		logger:      logger,
+		service:     service,
+		auditLogger: auditLogger,
 	}
 }
 
 // ListRoles returns all roles.
 func (h *RBACHandler) ListRoles(w http.ResponseWriter, r *http.Request) {
 	includeBuiltin := r.URL.Query().Get("include_builtin") != "false"
-	roles := h.service.ListRoles(includeBuiltin)
+	roles := h.service.ListRoles(middleware.GetOrgID(r.Context()), includeBuiltin)
 	WriteJSON(w, http.StatusOK, map[string]interface{}{
 		"roles": roles,
 		"total": len(roles),
@@ -41,13 +46,13 @@ func (h *RBACHandler) GetRole(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "roleID")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid role ID")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid role ID")
 		return
 	}
 
-	role := h.service.GetRole(id)
+	role := h.service.GetRole(middleware.GetOrgID(r.Context()), id)
 	if role == nil {
-		WriteError(w, http.StatusNotFound, "not_found", "Role not found")
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Role not found")
 		return
 	}
 
@@ -63,29 +68,28 @@ func (h *RBACHandler) GetRole(w http.ResponseWriter, r *http.Request) {
 func (h *RBACHandler) CreateRole(w http.ResponseWriter, r *http.Request) {
 	var input domain.RoleInput
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_json", "Invalid request body")
+		writeDecodeError(w, err)
 		return
 	}
 
 	if input.Name == "" {
-		WriteError(w, http.StatusBadRequest, "validation_error", "Name is required")
+		WriteFieldError(w, "name", "Name is required")
 		return
 	}
 	if len(input.Permissions) == 0 {
-		WriteError(w, http.StatusBadRequest, "validation_error", "At least one permission is required")
+		WriteFieldError(w, "permissions", "At least one permission is required")
 		return
 	}
 
 	// Check for duplicate name
-	if existing := h.service.GetRoleByName(input.Name); existing != nil {
-		WriteError(w, http.StatusConflict, "duplicate_name", "A role with this name already exists")
+	orgID := middleware.GetOrgID(r.Context())
+	if existing := h.service.GetRoleByName(orgID, input.Name); existing != nil {
+		WriteError(w, http.StatusConflict, response.CodeDuplicateName, "A role with this name already exists")
 		return
 	}
 
-	// Demo org
-	orgID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
-
 	role := h.service.CreateRole(input, orgID)
+	recordAudit(r, h.auditLogger, domain.AuditActionRoleCreate, "role", role.ID.String(), nil, role)
 	WriteJSON(w, http.StatusCreated, role)
 }
 
@@ -94,29 +98,37 @@ func (h *RBACHandler) UpdateRole(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "roleID")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid role ID")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid role ID")
 		return
 	}
 
 	var input domain.RoleInput
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_json", "Invalid request body")
+		writeDecodeError(w, err)
 		return
 	}
 
+	orgID := middleware.GetOrgID(r.Context())
+
 	// Check if built-in
-	existing := h.service.GetRole(id)
+	existing := h.service.GetRole(orgID, id)
 	if existing != nil && existing.IsBuiltin {
-		WriteError(w, http.StatusForbidden, "builtin_role", "Built-in roles cannot be modified")
+		WriteError(w, http.StatusForbidden, response.CodeBuiltinRole, "Built-in roles cannot be modified")
 		return
 	}
+	var before *domain.Role
+	if existing != nil {
+		snapshot := *existing
+		before = &snapshot
+	}
 
-	role := h.service.UpdateRole(id, input)
+	role := h.service.UpdateRole(orgID, id, input)
 	if role == nil {
-		WriteError(w, http.StatusNotFound, "not_found", "Role not found")
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Role not found")
 		return
 	}
 
+	recordAudit(r, h.auditLogger, domain.AuditActionRoleUpdate, "role", id.String(), before, role)
 	WriteJSON(w, http.StatusOK, role)
 }
 
@@ -125,22 +137,26 @@ func (h *RBACHandler) DeleteRole(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "roleID")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid role ID")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid role ID")
 		return
 	}
 
+	orgID := middleware.GetOrgID(r.Context())
+
 	// Check if built-in
-	existing := h.service.GetRole(id)
+	existing := h.service.GetRole(orgID, id)
 	if existing != nil && existing.IsBuiltin {
-		WriteError(w, http.StatusForbidden, "builtin_role", "Built-in roles cannot be deleted")
+		WriteError(w, http.StatusForbidden, response.CodeBuiltinRole, "Built-in roles cannot be deleted")
 		return
 	}
 
-	if !h.service.DeleteRole(id) {
-		WriteError(w, http.StatusNotFound, "not_found", "Role not found")
+	if !h.service.DeleteRole(orgID, id) {
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Role not found")
 		return
 	}
 
+	recordAudit(r, h.auditLogger, domain.AuditActionRoleDelete, "role", id.String(), existing, nil)
+
 	WriteJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
 }
 
@@ -149,7 +165,7 @@ func (h *RBACHandler) GetUserRoles(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "userID")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid user ID")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid user ID")
 		return
 	}
 
@@ -157,9 +173,10 @@ func (h *RBACHandler) GetUserRoles(w http.ResponseWriter, r *http.Request) {
 	permissions := h.service.GetUserPermissions(id)
 
 	// Enrich assignments with role details
+	orgID := middleware.GetOrgID(r.Context())
 	enriched := make([]map[string]interface{}, 0, len(assignments))
 	for _, a := range assignments {
-		role := h.service.GetRole(a.RoleID)
+		role := h.service.GetRole(orgID, a.RoleID)
 		enriched = append(enriched, map[string]interface{}{
 			"assignment": a,
 			"role":       role,
@@ -178,36 +195,36 @@ func (h *RBACHandler) AssignRole(w http.ResponseWriter, r *http.Request) {
 	userIDStr := chi.URLParam(r, "userID")
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid user ID")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid user ID")
 		return
 	}
 
 	var input domain.RoleAssignmentInput
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_json", "Invalid request body")
+		writeDecodeError(w, err)
 		return
 	}
 
 	if input.RoleID == uuid.Nil {
-		WriteError(w, http.StatusBadRequest, "validation_error", "Role ID is required")
+		WriteFieldError(w, "role_id", "Role ID is required")
 		return
 	}
 
 	// Verify role exists
-	if h.service.GetRole(input.RoleID) == nil {
-		WriteError(w, http.StatusNotFound, "role_not_found", "Role not found")
+	if h.service.GetRole(middleware.GetOrgID(r.Context()), input.RoleID) == nil {
+		WriteError(w, http.StatusNotFound, response.CodeRoleNotFound, "Role not found")
 		return
 	}
 
-	// Demo assigner
-	assignedBy := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	assignedBy := middleware.GetUserID(r.Context())
 
 	assignment := h.service.AssignRole(userID, input, assignedBy)
 	if assignment == nil {
-		WriteError(w, http.StatusBadRequest, "assignment_failed", "Failed to assign role")
+		WriteError(w, http.StatusBadRequest, response.CodeAssignmentFailed, "Failed to assign role")
 		return
 	}
 
+	recordAudit(r, h.auditLogger, domain.AuditActionRoleAssign, "role_assignment", assignment.ID.String(), nil, assignment)
 	WriteJSON(w, http.StatusCreated, assignment)
 }
 
@@ -216,22 +233,33 @@ func (h *RBACHandler) RevokeRole(w http.ResponseWriter, r *http.Request) {
 	userIDStr := chi.URLParam(r, "userID")
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid user ID")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid user ID")
 		return
 	}
 
 	assignmentIDStr := chi.URLParam(r, "assignmentID")
 	assignmentID, err := uuid.Parse(assignmentIDStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid assignment ID")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid assignment ID")
 		return
 	}
 
+	var before *domain.RoleAssignment
+	for _, assignment := range h.service.GetUserRoles(userID) {
+		if assignment.ID == assignmentID {
+			snapshot := assignment
+			before = &snapshot
+			break
+		}
+	}
+
 	if !h.service.RevokeRole(userID, assignmentID) {
-		WriteError(w, http.StatusNotFound, "not_found", "Assignment not found")
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Assignment not found")
 		return
 	}
 
+	recordAudit(r, h.auditLogger, domain.AuditActionRoleRevoke, "role_assignment", assignmentID.String(), before, nil)
+
 	WriteJSON(w, http.StatusOK, map[string]string{"status": "revoked"})
 }
 
@@ -241,18 +269,17 @@ func (h *RBACHandler) CheckPermission(w http.ResponseWriter, r *http.Request) {
 	permission := r.URL.Query().Get("permission")
 
 	if userIDStr == "" {
-		// Use demo user
-		userIDStr = "00000000-0000-0000-0000-000000000001"
+		userIDStr = middleware.GetUserID(r.Context()).String()
 	}
 
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid user ID")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid user ID")
 		return
 	}
 
 	if permission == "" {
-		WriteError(w, http.StatusBadRequest, "validation_error", "Permission is required")
+		WriteFieldError(w, "permission", "Permission is required")
 		return
 	}
 
@@ -294,8 +321,8 @@ func (h *RBACHandler) ListPermissions(w http.ResponseWriter, r *http.Request) {
 
 // GetMyPermissions returns permissions for the current user.
 func (h *RBACHandler) GetMyPermissions(w http.ResponseWriter, r *http.Request) {
-	// Demo user
-	userID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	userID := middleware.GetUserID(r.Context())
+	orgID := middleware.GetOrgID(r.Context())
 
 	assignments := h.service.GetUserRoles(userID)
 	permissions := h.service.GetUserPermissions(userID)
@@ -303,7 +330,7 @@ func (h *RBACHandler) GetMyPermissions(w http.ResponseWriter, r *http.Request) {
 	// Enrich assignments with role details
 	roles := make([]domain.Role, 0)
 	for _, a := range assignments {
-		if role := h.service.GetRole(a.RoleID); role != nil {
+		if role := h.service.GetRole(orgID, a.RoleID); role != nil {
 			roles = append(roles, *role)
 		}
 	}
@@ -320,7 +347,12 @@ func (h *RBACHandler) GetRoleUsers(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "roleID")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid role ID")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid role ID")
+		return
+	}
+
+	if h.service.GetRole(middleware.GetOrgID(r.Context()), id) == nil {
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Role not found")
 		return
 	}
 
@@ -2,41 +2,41 @@
 package handler
 
 import (
-	"encoding/json"
 	"net/http"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/response"
 )
 
 // ErrorResponse represents an error response.
-type ErrorResponse struct {
-	Error ErrorDetail `json:"error"`
-}
+type ErrorResponse = response.ErrorResponse
 
 // ErrorDetail contains error details.
-type ErrorDetail struct {
-	Code    string `json:"code"`
-	Message string `json:"message"`
-}
+type ErrorDetail = response.ErrorDetail
+
+// FieldError describes a problem with a single request field.
+type FieldError = response.FieldError
 
 // SuccessResponse represents a successful response.
-type SuccessResponse struct {
-	Data interface{} `json:"data"`
-}
+type SuccessResponse = response.SuccessResponse
 
 // WriteJSON writes a JSON response.
 func WriteJSON(w http.ResponseWriter, status int, data interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(data)
+	response.WriteJSON(w, status, data)
 }
 
-// WriteError writes an error response.
-func WriteError(w http.ResponseWriter, status int, code string, message string) {
-	WriteJSON(w, status, ErrorResponse{
-		Error: ErrorDetail{
-			Code:    code,
-			Message: message,
-		},
-	})
+// WriteError writes an error response including the request and trace IDs.
+func WriteError(w http.ResponseWriter, status int, code response.ErrorCode, message string) {
+	response.WriteError(w, status, code, message)
+}
+
+// WriteValidationError writes a 400 validation error listing the offending fields.
+func WriteValidationError(w http.ResponseWriter, message string, fields []FieldError) {
+	response.WriteValidationError(w, message, fields)
+}
+
+// WriteFieldError writes a 400 validation error for a single field.
+func WriteFieldError(w http.ResponseWriter, field, message string) {
+	response.WriteFieldError(w, field, message)
 }
 
 // WriteSuccess writes a success response with status code.
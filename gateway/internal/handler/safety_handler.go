@@ -1,10 +1,14 @@
 package handler
 
 import (
-	"encoding/json"
+	"fmt"
 	"net/http"
+	"time"
 
+	"github.com/akz4ol/gatewayops/gateway/internal/audit"
 	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/middleware"
+	"github.com/akz4ol/gatewayops/gateway/internal/response"
 	"github.com/akz4ol/gatewayops/gateway/internal/safety"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
@@ -13,21 +17,30 @@ import (
 
 // SafetyHandler handles safety-related HTTP requests.
 type SafetyHandler struct {
-	logger   zerolog.Logger
-	detector *safety.Detector
+	logger      zerolog.Logger
+	detector    *safety.Detector
+	auditLogger *audit.Logger
 }
 
 // NewSafetyHandler creates a new safety handler.
-func NewSafetyHandler(logger zerolog.Logger, detector *safety.Detector) *SafetyHandler {
+func NewSafetyHandler(logger zerolog.Logger, detector *safety.Detector, auditLogger *audit.Logger) *SafetyHandler {
 	return &SafetyHandler{
-		logger:   logger,
-		detector: detector,
+		logger:      logger,
+		detector:    detector,
+		auditLogger: auditLogger,
 	}
 }
 
-// ListPolicies returns all safety policies.
+// ListPolicies returns the organization's safety policies. Admins may pass
+// include_deleted=true to also list soft-deleted policies.
 func (h *SafetyHandler) ListPolicies(w http.ResponseWriter, r *http.Request) {
-	policies := h.detector.GetPolicies()
+	includeDeleted := r.URL.Query().Get("include_deleted") == "true"
+	if includeDeleted && !middleware.HasPermission(r.Context(), domain.PermissionPoliciesAdmin) {
+		WriteError(w, http.StatusForbidden, response.CodeForbidden, "Listing deleted policies requires the policies:admin permission")
+		return
+	}
+
+	policies := h.detector.GetPolicies(middleware.GetOrgID(r.Context()), includeDeleted)
 	WriteJSON(w, http.StatusOK, map[string]interface{}{
 		"policies": policies,
 	})
@@ -38,13 +51,13 @@ func (h *SafetyHandler) GetPolicy(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "policyID")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid policy ID")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid policy ID")
 		return
 	}
 
-	policy := h.detector.GetPolicy(id)
+	policy := h.detector.GetPolicy(middleware.GetOrgID(r.Context()), id)
 	if policy == nil {
-		WriteError(w, http.StatusNotFound, "not_found", "Policy not found")
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Policy not found")
 		return
 	}
 
@@ -54,14 +67,7 @@ func (h *SafetyHandler) GetPolicy(w http.ResponseWriter, r *http.Request) {
 // CreatePolicy creates a new safety policy.
 func (h *SafetyHandler) CreatePolicy(w http.ResponseWriter, r *http.Request) {
 	var input domain.SafetyPolicyInput
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_body", "Invalid request body")
-		return
-	}
-
-	// Validate input
-	if input.Name == "" {
-		WriteError(w, http.StatusBadRequest, "validation_error", "Policy name is required")
+	if !decodeInput(w, r, &input) {
 		return
 	}
 
@@ -73,17 +79,21 @@ func (h *SafetyHandler) CreatePolicy(w http.ResponseWriter, r *http.Request) {
 		input.Mode = domain.SafetyModeBlock
 	}
 
-	// Demo mode: use fixed org and user IDs
-	orgID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
-	userID := uuid.MustParse("00000000-0000-0000-0000-000000000002")
+	orgID := middleware.GetOrgID(r.Context())
+	userID := middleware.GetUserID(r.Context())
+	if !h.checkLibraryRefs(w, orgID, input.Patterns) {
+		return
+	}
 
-	policy := h.detector.CreatePolicy(input, orgID, userID)
+	policy := h.detector.CreatePolicy(r.Context(), input, orgID, userID)
 
 	h.logger.Info().
 		Str("policy_id", policy.ID.String()).
 		Str("name", policy.Name).
 		Msg("Safety policy created")
 
+	recordAudit(r, h.auditLogger, domain.AuditActionPolicyCreate, "safety_policy", policy.ID.String(), nil, policy)
+
 	WriteJSON(w, http.StatusCreated, policy)
 }
 
@@ -92,19 +102,29 @@ func (h *SafetyHandler) UpdatePolicy(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "policyID")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid policy ID")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid policy ID")
 		return
 	}
 
 	var input domain.SafetyPolicyInput
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_body", "Invalid request body")
+	if !decodeInput(w, r, &input) {
 		return
 	}
 
-	policy := h.detector.UpdatePolicy(id, input)
+	orgID := middleware.GetOrgID(r.Context())
+	if !h.checkLibraryRefs(w, orgID, input.Patterns) {
+		return
+	}
+
+	var before *domain.SafetyPolicy
+	if existing := h.detector.GetPolicy(orgID, id); existing != nil {
+		snapshot := *existing
+		before = &snapshot
+	}
+
+	policy := h.detector.UpdatePolicy(r.Context(), orgID, id, input)
 	if policy == nil {
-		WriteError(w, http.StatusNotFound, "not_found", "Policy not found")
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Policy not found")
 		return
 	}
 
@@ -113,6 +133,8 @@ func (h *SafetyHandler) UpdatePolicy(w http.ResponseWriter, r *http.Request) {
 		Str("name", policy.Name).
 		Msg("Safety policy updated")
 
+	recordAudit(r, h.auditLogger, domain.AuditActionPolicyUpdate, "safety_policy", id.String(), before, policy)
+
 	WriteJSON(w, http.StatusOK, policy)
 }
 
@@ -121,18 +143,20 @@ func (h *SafetyHandler) DeletePolicy(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "policyID")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid policy ID")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid policy ID")
 		return
 	}
 
 	// Check if it's the default policy
-	if id == uuid.MustParse("00000000-0000-0000-0000-000000000001") {
-		WriteError(w, http.StatusForbidden, "forbidden", "Cannot delete default policy")
+	if id == safety.DefaultPolicyID {
+		WriteError(w, http.StatusForbidden, response.CodeForbidden, "Cannot delete default policy")
 		return
 	}
 
-	if !h.detector.DeletePolicy(id) {
-		WriteError(w, http.StatusNotFound, "not_found", "Policy not found")
+	orgID := middleware.GetOrgID(r.Context())
+	before := h.detector.GetPolicy(orgID, id)
+	if !h.detector.DeletePolicy(r.Context(), orgID, id) {
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Policy not found")
 		return
 	}
 
@@ -140,29 +164,201 @@ func (h *SafetyHandler) DeletePolicy(w http.ResponseWriter, r *http.Request) {
 		Str("policy_id", id.String()).
 		Msg("Safety policy deleted")
 
+	recordAudit(r, h.auditLogger, domain.AuditActionPolicyDelete, "safety_policy", id.String(), before, nil)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RestorePolicy restores a soft-deleted safety policy.
+func (h *SafetyHandler) RestorePolicy(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "policyID")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid policy ID")
+		return
+	}
+
+	if !middleware.HasPermission(r.Context(), domain.PermissionPoliciesAdmin) {
+		WriteError(w, http.StatusForbidden, response.CodeForbidden, "Restoring policies requires the policies:admin permission")
+		return
+	}
+
+	policy := h.detector.RestorePolicy(r.Context(), middleware.GetOrgID(r.Context()), id)
+	if policy == nil {
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Deleted policy not found")
+		return
+	}
+
+	h.logger.Info().
+		Str("policy_id", id.String()).
+		Msg("Safety policy restored")
+
+	recordAudit(r, h.auditLogger, domain.AuditActionPolicyRestore, "safety_policy", id.String(), nil, policy)
+
+	WriteJSON(w, http.StatusOK, policy)
+}
+
+// checkLibraryRefs writes a validation error and returns false when patterns
+// reference a pattern library the organization does not have.
+func (h *SafetyHandler) checkLibraryRefs(w http.ResponseWriter, orgID uuid.UUID, patterns domain.SafetyPatterns) bool {
+	for _, id := range patterns.Libraries {
+		if h.detector.GetLibrary(orgID, id) == nil {
+			WriteFieldError(w, "patterns.libraries", fmt.Sprintf("Pattern library %s not found", id))
+			return false
+		}
+	}
+	return true
+}
+
+// ListLibraries returns the organization's pattern libraries.
+func (h *SafetyHandler) ListLibraries(w http.ResponseWriter, r *http.Request) {
+	libraries := h.detector.GetLibraries(middleware.GetOrgID(r.Context()))
+	WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"libraries": libraries,
+	})
+}
+
+// GetLibrary returns a specific pattern library by ID.
+func (h *SafetyHandler) GetLibrary(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "libraryID"))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid pattern library ID")
+		return
+	}
+
+	library := h.detector.GetLibrary(middleware.GetOrgID(r.Context()), id)
+	if library == nil {
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Pattern library not found")
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, library)
+}
+
+// CreateLibrary creates a new pattern library.
+func (h *SafetyHandler) CreateLibrary(w http.ResponseWriter, r *http.Request) {
+	input, ok := decodeLibraryInput(w, r)
+	if !ok {
+		return
+	}
+
+	library := h.detector.CreateLibrary(r.Context(), input, middleware.GetOrgID(r.Context()), middleware.GetUserID(r.Context()))
+
+	h.logger.Info().
+		Str("library_id", library.ID.String()).
+		Str("name", library.Name).
+		Msg("Pattern library created")
+
+	recordAudit(r, h.auditLogger, domain.AuditActionLibraryCreate, "pattern_library", library.ID.String(), nil, library)
+
+	WriteJSON(w, http.StatusCreated, library)
+}
+
+// UpdateLibrary replaces a pattern library's patterns. Every policy that
+// references the library applies the new version immediately.
+func (h *SafetyHandler) UpdateLibrary(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "libraryID"))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid pattern library ID")
+		return
+	}
+
+	input, ok := decodeLibraryInput(w, r)
+	if !ok {
+		return
+	}
+
+	orgID := middleware.GetOrgID(r.Context())
+	before := h.detector.GetLibrary(orgID, id)
+	library := h.detector.UpdateLibrary(r.Context(), orgID, id, input)
+	if library == nil {
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Pattern library not found")
+		return
+	}
+
+	recordAudit(r, h.auditLogger, domain.AuditActionLibraryUpdate, "pattern_library", id.String(), before, library)
+
+	WriteJSON(w, http.StatusOK, library)
+}
+
+// DeleteLibrary deletes a pattern library. Libraries still referenced by a
+// policy cannot be deleted.
+func (h *SafetyHandler) DeleteLibrary(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "libraryID"))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid pattern library ID")
+		return
+	}
+
+	orgID := middleware.GetOrgID(r.Context())
+	before := h.detector.GetLibrary(orgID, id)
+	deleted, referencedBy := h.detector.DeleteLibrary(r.Context(), orgID, id)
+	if len(referencedBy) > 0 {
+		response.WriteErrorDetails(w, http.StatusConflict, response.CodeLibraryInUse,
+			"Pattern library is referenced by safety policies",
+			map[string]interface{}{"policy_ids": referencedBy})
+		return
+	}
+	if !deleted {
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Pattern library not found")
+		return
+	}
+
+	h.logger.Info().
+		Str("library_id", id.String()).
+		Msg("Pattern library deleted")
+
+	recordAudit(r, h.auditLogger, domain.AuditActionLibraryDelete, "pattern_library", id.String(), before, nil)
+
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// decodeLibraryInput decodes and validates a pattern library request body,
+// writing an error response when it is invalid.
+func decodeLibraryInput(w http.ResponseWriter, r *http.Request) (domain.PatternLibraryInput, bool) {
+	var input domain.PatternLibraryInput
+	if !decodeInput(w, r, &input) {
+		return input, false
+	}
+
+	if input.Name == "" {
+		WriteFieldError(w, "name", "Pattern library name is required")
+		return input, false
+	}
+	if !domain.ValidPatternCategory(input.Category) {
+		WriteFieldError(w, "category", "Category must be one of injection, jailbreak, pii or secrets")
+		return input, false
+	}
+	if len(input.Patterns.Block) == 0 && len(input.Patterns.Allow) == 0 {
+		WriteFieldError(w, "patterns", "At least one block or allow pattern is required")
+		return input, false
+	}
+	if len(input.Patterns.Libraries) > 0 {
+		WriteFieldError(w, "patterns.libraries", "Pattern libraries cannot reference other libraries")
+		return input, false
+	}
+	return input, true
+}
+
 // TestInput tests input against safety detection.
 func (h *SafetyHandler) TestInput(w http.ResponseWriter, r *http.Request) {
 	var req domain.SafetyTestRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_body", "Invalid request body")
+	if !decodeInput(w, r, &req) {
 		return
 	}
 
 	if req.Input == "" {
-		WriteError(w, http.StatusBadRequest, "validation_error", "Input is required")
+		WriteFieldError(w, "input", "Input is required")
 		return
 	}
 
 	opts := safety.DetectOptions{
 		Input:    req.Input,
 		PolicyID: req.PolicyID,
-		OrgID:    uuid.MustParse("00000000-0000-0000-0000-000000000001"), // Demo org
+		OrgID:    middleware.GetOrgID(r.Context()),
 	}
 
-	result := h.detector.Detect(req.Input, opts)
+	result := h.detector.Detect(r.Context(), req.Input, opts)
 
 	WriteJSON(w, http.StatusOK, domain.SafetyTestResponse{
 		Result:   result,
@@ -170,10 +366,27 @@ func (h *SafetyHandler) TestInput(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// ExplainInput shows every check of a policy that matches input and why
+// the policy reaches its decision, without recording a detection.
+func (h *SafetyHandler) ExplainInput(w http.ResponseWriter, r *http.Request) {
+	var req domain.SafetyTestRequest
+	if !decodeInput(w, r, &req) {
+		return
+	}
+
+	if req.Input == "" {
+		WriteFieldError(w, "input", "Input is required")
+		return
+	}
+
+	explanation := h.detector.Explain(middleware.GetOrgID(r.Context()), req.PolicyID, req.Input)
+	WriteJSON(w, http.StatusOK, explanation)
+}
+
 // ListDetections returns recent injection detections.
 func (h *SafetyHandler) ListDetections(w http.ResponseWriter, r *http.Request) {
 	filter := domain.DetectionFilter{
-		OrgID: uuid.MustParse("00000000-0000-0000-0000-000000000001"), // Demo org
+		OrgID: middleware.GetOrgID(r.Context()),
 	}
 
 	// Parse query params
@@ -181,6 +394,13 @@ func (h *SafetyHandler) ListDetections(w http.ResponseWriter, r *http.Request) {
 	if mcpServer := query.Get("mcp_server"); mcpServer != "" {
 		filter.MCPServer = mcpServer
 	}
+	if traceID := query.Get("trace_id"); traceID != "" {
+		filter.TraceID = traceID
+	}
+	// action=shadow lists what shadow policies would have blocked
+	for action := range parseListParam(query.Get("action")) {
+		filter.Actions = append(filter.Actions, domain.SafetyMode(action))
+	}
 	if limit := query.Get("limit"); limit != "" {
 		var l int
 		if _, err := parseIntParam(limit, &l); err == nil {
@@ -193,14 +413,109 @@ func (h *SafetyHandler) ListDetections(w http.ResponseWriter, r *http.Request) {
 			filter.Offset = o
 		}
 	}
+	cursor, ok := parseCursorParam(w, r)
+	if !ok {
+		return
+	}
+	filter.Cursor = cursor
+	if filter.Query, ok = parseSearchParam(w, r); !ok {
+		return
+	}
 
 	page := h.detector.GetDetections(filter)
 	WriteJSON(w, http.StatusOK, page)
 }
 
-// GetSummary returns a summary of safety detections.
+// StreamDetections streams injection detections as Server-Sent Events.
+func (h *SafetyHandler) StreamDetections(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	severities := parseListParam(query.Get("severity"))
+	servers := parseListParam(query.Get("mcp_server"))
+	orgID := middleware.GetOrgID(r.Context())
+
+	detections, cancel := h.detector.Subscribe()
+	defer cancel()
+
+	flusher, ok := startSSE(w)
+	if !ok {
+		return
+	}
+
+	keepAlive := time.NewTicker(sseKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if err := writeSSEKeepAlive(w, flusher); err != nil {
+				return
+			}
+		case detection := <-detections:
+			if detection.OrgID != orgID {
+				continue
+			}
+			if severities != nil && !severities[string(detection.Severity)] {
+				continue
+			}
+			if servers != nil && !servers[detection.MCPServer] {
+				continue
+			}
+			if err := writeSSE(w, flusher, "detection", detection); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// ListOffenders returns the API keys and client IPs with detections inside
+// the escalation window, with whether they are being blocked as repeat
+// offenders.
+func (h *SafetyHandler) ListOffenders(w http.ResponseWriter, r *http.Request) {
+	offenders := h.detector.OffenderCounts(middleware.GetOrgID(r.Context()))
+	WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"offenders": offenders,
+	})
+}
+
+// maxTopOffenders bounds the entries of each top offenders ranking.
+const maxTopOffenders = 100
+
+// TopOffenders ranks the API keys, client IPs, MCP servers and tools with the
+// most detections over the period query parameter (day, week or month), up to
+// limit of each, with detections over time for charting.
+func (h *SafetyHandler) TopOffenders(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	period := query.Get("period")
+	switch period {
+	case "":
+		period = "day"
+	case "day", "week", "month":
+	default:
+		WriteFieldError(w, "period", "period must be day, week or month")
+		return
+	}
+	limit := 10
+	if value := query.Get("limit"); value != "" {
+		if ok, _ := parseIntParam(value, &limit); !ok || limit < 1 || limit > maxTopOffenders {
+			WriteFieldError(w, "limit", fmt.Sprintf("limit must be between 1 and %d", maxTopOffenders))
+			return
+		}
+	}
+
+	offenders, err := h.detector.TopOffenders(r.Context(), middleware.GetOrgID(r.Context()), period, limit)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to rank safety offenders")
+		WriteError(w, http.StatusInternalServerError, response.CodeInternalError, "Failed to rank safety offenders")
+		return
+	}
+	WriteJSON(w, http.StatusOK, offenders)
+}
+
+// GetSummary returns a summary of the organization's safety detections.
 func (h *SafetyHandler) GetSummary(w http.ResponseWriter, r *http.Request) {
-	summary := h.detector.GetSummary()
+	summary := h.detector.GetSummary(middleware.GetOrgID(r.Context()))
 	WriteJSON(w, http.StatusOK, summary)
 }
 
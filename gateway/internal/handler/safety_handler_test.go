@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/safety"
+	"github.com/rs/zerolog"
+)
+
+func TestTopOffendersQuery(t *testing.T) {
+	detector := safety.NewDetector(zerolog.Nop(), nil, 100, safety.EscalationPolicy{}, nil, nil)
+	h := NewSafetyHandler(zerolog.Nop(), detector, nil)
+
+	tests := []struct {
+		query      string
+		wantStatus int
+		wantPeriod string
+	}{
+		{"", http.StatusOK, "day"},
+		{"?period=month&limit=100", http.StatusOK, "month"},
+		{"?period=year", http.StatusBadRequest, ""},
+		{"?limit=0", http.StatusBadRequest, ""},
+		{"?limit=101", http.StatusBadRequest, ""},
+		{"?limit=ten", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h.TopOffenders(rec, httptest.NewRequest(http.MethodGet, "/v1/safety/offenders/top"+tt.query, nil))
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%q: status = %d, want %d: %s", tt.query, rec.Code, tt.wantStatus, rec.Body)
+			continue
+		}
+		if tt.wantStatus != http.StatusOK {
+			continue
+		}
+		var offenders domain.SafetyOffenders
+		if err := json.Unmarshal(rec.Body.Bytes(), &offenders); err != nil {
+			t.Fatalf("%q: decode: %v", tt.query, err)
+		}
+		if offenders.Period != tt.wantPeriod {
+			t.Errorf("%q: period = %q, want %q", tt.query, offenders.Period, tt.wantPeriod)
+		}
+	}
+}
+
+func TestListDetectionsSearchLength(t *testing.T) {
+	detector := safety.NewDetector(zerolog.Nop(), nil, 100, safety.EscalationPolicy{}, nil, nil)
+	h := NewSafetyHandler(zerolog.Nop(), detector, nil)
+
+	for _, tt := range []struct {
+		length     int
+		wantStatus int
+	}{
+		{domain.MaxSearchQueryLength, http.StatusOK},
+		{domain.MaxSearchQueryLength + 1, http.StatusBadRequest},
+	} {
+		rec := httptest.NewRecorder()
+		q := url.QueryEscape(strings.Repeat("é", tt.length))
+		h.ListDetections(rec, httptest.NewRequest(http.MethodGet, "/v1/safety/detections?q="+q, nil))
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%d-character query: status = %d, want %d: %s", tt.length, rec.Code, tt.wantStatus, rec.Body)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/audit"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/response"
+	"github.com/akz4ol/gatewayops/gateway/internal/secretbox"
+	"github.com/rs/zerolog"
+)
+
+// SecretStore holds secrets sealed under the secrets encryption key and can
+// seal them again under its current version.
+type SecretStore interface {
+	Reencrypt(ctx context.Context) (int, error)
+}
+
+// SecretsHandler rotates the key stored secrets are encrypted with.
+type SecretsHandler struct {
+	logger      zerolog.Logger
+	keyring     *secretbox.Keyring     // Nil when no key is configured
+	stores      map[string]SecretStore // By name, as reported
+	auditLogger *audit.Logger
+}
+
+// NewSecretsHandler creates a new secrets handler re-encrypting stores.
+func NewSecretsHandler(logger zerolog.Logger, keyring *secretbox.Keyring, stores map[string]SecretStore, auditLogger *audit.Logger) *SecretsHandler {
+	return &SecretsHandler{
+		logger:      logger,
+		keyring:     keyring,
+		stores:      stores,
+		auditLogger: auditLogger,
+	}
+}
+
+// RotateKeyResponse reports a re-encryption under the current key.
+type RotateKeyResponse struct {
+	KeyVersion  uint32         `json:"key_version"`
+	Reencrypted map[string]int `json:"reencrypted"` // Secrets re-encrypted, by store
+}
+
+// RotateKey re-encrypts every stored secret not encrypted under the current
+// key, completing a rotation begun by making a new key current and keeping
+// the old one in SECRETS_RETIRED_KEYS. Once it succeeds, nothing is left
+// encrypted under a retired key, so they can be dropped. It is safe to
+// repeat after a failure.
+func (h *SecretsHandler) RotateKey(w http.ResponseWriter, r *http.Request) {
+	if h.keyring == nil {
+		WriteError(w, http.StatusServiceUnavailable, response.CodeSecretsUnavailable, "Secrets are not encrypted because SECRETS_ENCRYPTION_KEY is not set")
+		return
+	}
+
+	resp := RotateKeyResponse{KeyVersion: h.keyring.Current(), Reencrypted: make(map[string]int, len(h.stores))}
+	for name, store := range h.stores {
+		count, err := store.Reencrypt(r.Context())
+		resp.Reencrypted[name] = count
+		if err != nil {
+			h.logger.Error().Err(err).Str("store", name).Int("reencrypted", count).Msg("Failed to re-encrypt secrets")
+			logAuditEvent(r, h.auditLogger, domain.AuditActionSecretsRotate, "secrets", name, domain.AuditOutcomeFailure, map[string]interface{}{
+				"key_version": resp.KeyVersion,
+				"reencrypted": resp.Reencrypted,
+			})
+			WriteError(w, http.StatusInternalServerError, response.CodeInternalError, "Failed to re-encrypt "+name+"; retired keys are still needed")
+			return
+		}
+	}
+
+	recordAudit(r, h.auditLogger, domain.AuditActionSecretsRotate, "secrets", "", nil, resp)
+
+	WriteJSON(w, http.StatusOK, resp)
+}
@@ -2,29 +2,42 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"sync"
 	"time"
 
+	"github.com/akz4ol/gatewayops/gateway/internal/approval"
+	"github.com/akz4ol/gatewayops/gateway/internal/audit"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/middleware"
+	"github.com/akz4ol/gatewayops/gateway/internal/orgsettings"
+	"github.com/akz4ol/gatewayops/gateway/internal/response"
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 )
 
 // SettingsHandler handles organization settings HTTP requests.
 type SettingsHandler struct {
-	logger   zerolog.Logger
-	settings map[uuid.UUID]*OrgSettings
-	mu       sync.RWMutex
+	logger      zerolog.Logger
+	approvals   *approval.Service
+	orgSettings *orgsettings.Service
+	auditLogger *audit.Logger
+	settings    map[uuid.UUID]*OrgSettings
+	mu          sync.RWMutex
 }
 
 // OrgSettings represents organization-level settings.
 type OrgSettings struct {
-	ID               uuid.UUID        `json:"id"`
-	OrgID            uuid.UUID        `json:"org_id"`
-	OrgName          string           `json:"org_name"`
-	BillingEmail     string           `json:"billing_email"`
-	RateLimits       RateLimitConfig  `json:"rate_limits"`
-	UpdatedAt        time.Time        `json:"updated_at"`
+	ID                uuid.UUID                `json:"id"`
+	OrgID             uuid.UUID                `json:"org_id"`
+	OrgName           string                   `json:"org_name"`
+	BillingEmail      string                   `json:"billing_email"`
+	RateLimits        RateLimitConfig          `json:"rate_limits"`
+	UnknownToolPolicy domain.UnknownToolPolicy `json:"unknown_tool_policy"`
+	UpdatedAt         time.Time                `json:"updated_at"`
 }
 
 // RateLimitConfig holds default rate limit settings.
@@ -38,20 +51,27 @@ type UpdateSettingsInput struct {
 	OrgName      *string          `json:"org_name,omitempty"`
 	BillingEmail *string          `json:"billing_email,omitempty"`
 	RateLimits   *RateLimitConfig `json:"rate_limits,omitempty"`
+	// How tools the organization has not classified are treated: default,
+	// deny, approval or allow
+	UnknownToolPolicy *domain.UnknownToolPolicy `json:"unknown_tool_policy,omitempty"`
 }
 
-// NewSettingsHandler creates a new settings handler.
-func NewSettingsHandler(logger zerolog.Logger) *SettingsHandler {
+// NewSettingsHandler creates a new settings handler. The policy for
+// unclassified tools is kept by the approval service, which enforces it;
+// typed settings are kept by orgSettings.
+func NewSettingsHandler(logger zerolog.Logger, approvals *approval.Service, orgSettings *orgsettings.Service, auditLogger *audit.Logger) *SettingsHandler {
 	h := &SettingsHandler{
-		logger:   logger,
-		settings: make(map[uuid.UUID]*OrgSettings),
+		logger:      logger,
+		approvals:   approvals,
+		orgSettings: orgSettings,
+		auditLogger: auditLogger,
+		settings:    make(map[uuid.UUID]*OrgSettings),
 	}
 
 	// Initialize demo org settings
-	demoOrgID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
-	h.settings[demoOrgID] = &OrgSettings{
+	h.settings[middleware.DemoOrgID] = &OrgSettings{
 		ID:           uuid.MustParse("00000000-0000-0000-0000-000000000100"),
-		OrgID:        demoOrgID,
+		OrgID:        middleware.DemoOrgID,
 		OrgName:      "Acme Corp",
 		BillingEmail: "billing@acme.com",
 		RateLimits: RateLimitConfig{
@@ -66,38 +86,51 @@ func NewSettingsHandler(logger zerolog.Logger) *SettingsHandler {
 
 // GetSettings returns the organization settings.
 func (h *SettingsHandler) GetSettings(w http.ResponseWriter, r *http.Request) {
-	// Demo org
-	orgID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	orgID := middleware.GetOrgID(r.Context())
 
 	h.mu.RLock()
 	settings, ok := h.settings[orgID]
 	h.mu.RUnlock()
 
 	if !ok {
-		WriteError(w, http.StatusNotFound, "not_found", "Settings not found")
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Settings not found")
 		return
 	}
 
-	WriteJSON(w, http.StatusOK, settings)
+	WriteJSON(w, http.StatusOK, h.withToolPolicy(settings))
+}
+
+// withToolPolicy returns a copy of the settings holding the organization's
+// current policy for unclassified tools.
+func (h *SettingsHandler) withToolPolicy(settings *OrgSettings) OrgSettings {
+	result := *settings
+	result.UnknownToolPolicy = domain.UnknownToolDefault
+	if h.approvals != nil {
+		result.UnknownToolPolicy = h.approvals.UnknownToolPolicy(settings.OrgID)
+	}
+	return result
 }
 
 // UpdateSettings updates the organization settings.
 func (h *SettingsHandler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
 	var input UpdateSettingsInput
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_json", "Invalid request body")
+		writeDecodeError(w, err)
+		return
+	}
+	if input.UnknownToolPolicy != nil && !domain.ValidUnknownToolPolicy(*input.UnknownToolPolicy) {
+		WriteFieldError(w, "unknown_tool_policy", "unknown_tool_policy must be one of default, deny, approval, allow")
 		return
 	}
 
-	// Demo org
-	orgID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	orgID := middleware.GetOrgID(r.Context())
 
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
 	settings, ok := h.settings[orgID]
 	if !ok {
-		WriteError(w, http.StatusNotFound, "not_found", "Settings not found")
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Settings not found")
 		return
 	}
 
@@ -116,6 +149,9 @@ func (h *SettingsHandler) UpdateSettings(w http.ResponseWriter, r *http.Request)
 			settings.RateLimits.SandboxRPM = input.RateLimits.SandboxRPM
 		}
 	}
+	if input.UnknownToolPolicy != nil && h.approvals != nil {
+		h.approvals.SetUnknownToolPolicy(r.Context(), orgID, *input.UnknownToolPolicy)
+	}
 	settings.UpdatedAt = time.Now()
 
 	h.logger.Info().
@@ -123,5 +159,87 @@ func (h *SettingsHandler) UpdateSettings(w http.ResponseWriter, r *http.Request)
 		Str("org_name", settings.OrgName).
 		Msg("Organization settings updated")
 
-	WriteJSON(w, http.StatusOK, settings)
+	WriteJSON(w, http.StatusOK, h.withToolPolicy(settings))
+}
+
+// ListSettingValues returns the organization's value for every typed
+// setting, and whether it is the default.
+func (h *SettingsHandler) ListSettingValues(w http.ResponseWriter, r *http.Request) {
+	values := h.orgSettings.List(middleware.GetOrgID(r.Context()))
+	WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"settings": values,
+		"total":    len(values),
+	})
+}
+
+// GetSettingValue returns the organization's value for a typed setting.
+func (h *SettingsHandler) GetSettingValue(w http.ResponseWriter, r *http.Request) {
+	key := domain.SettingKey(chi.URLParam(r, "key"))
+	value, err := h.orgSettings.Get(middleware.GetOrgID(r.Context()), key)
+	if err != nil {
+		h.writeSettingError(w, err, key)
+		return
+	}
+	WriteJSON(w, http.StatusOK, value)
+}
+
+// SetSettingValue changes the organization's value for a typed setting.
+func (h *SettingsHandler) SetSettingValue(w http.ResponseWriter, r *http.Request) {
+	var input domain.SettingInput
+	if !decodeInput(w, r, &input) {
+		return
+	}
+
+	ctx := r.Context()
+	orgID := middleware.GetOrgID(ctx)
+	key := domain.SettingKey(chi.URLParam(r, "key"))
+	var updatedBy *uuid.UUID
+	if userID := middleware.GetUserID(ctx); userID != uuid.Nil {
+		updatedBy = &userID
+	}
+
+	before, _ := h.orgSettings.Get(orgID, key)
+	value, err := h.orgSettings.Set(ctx, orgID, key, input.Value, updatedBy)
+	if err != nil {
+		h.writeSettingError(w, err, key)
+		return
+	}
+
+	recordAudit(r, h.auditLogger, domain.AuditActionSettingSet, "setting", string(key), before.Value, value.Value)
+
+	WriteJSON(w, http.StatusOK, value)
+}
+
+// ResetSettingValue restores the default value of a typed setting for the
+// organization.
+func (h *SettingsHandler) ResetSettingValue(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	orgID := middleware.GetOrgID(ctx)
+	key := domain.SettingKey(chi.URLParam(r, "key"))
+
+	before, _ := h.orgSettings.Get(orgID, key)
+	value, err := h.orgSettings.Reset(ctx, orgID, key)
+	if err != nil {
+		h.writeSettingError(w, err, key)
+		return
+	}
+
+	recordAudit(r, h.auditLogger, domain.AuditActionSettingReset, "setting", string(key), before.Value, value.Value)
+
+	WriteJSON(w, http.StatusOK, value)
+}
+
+// writeSettingError writes the response for an error from the settings
+// service.
+func (h *SettingsHandler) writeSettingError(w http.ResponseWriter, err error, key domain.SettingKey) {
+	switch {
+	case errors.Is(err, orgsettings.ErrUnknownSetting):
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, fmt.Sprintf("Setting '%s' not found", key))
+	case errors.Is(err, orgsettings.ErrInvalidValue):
+		def, _ := domain.LookupSetting(key)
+		WriteFieldError(w, "value", fmt.Sprintf("value must be a %s", def.Type))
+	default:
+		h.logger.Error().Err(err).Str("key", string(key)).Msg("Failed to save organization setting")
+		WriteError(w, http.StatusInternalServerError, response.CodeInternalError, "Failed to save setting")
+	}
 }
@@ -0,0 +1,186 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/approval"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/middleware"
+	"github.com/akz4ol/gatewayops/gateway/internal/orgsettings"
+	"github.com/akz4ol/gatewayops/gateway/internal/pricing"
+	"github.com/akz4ol/gatewayops/gateway/internal/safety"
+	"github.com/google/uuid"
+)
+
+// ToolCallSimulator evaluates tool calls against classification, approval and
+// safety policy without forwarding them to an MCP server.
+type ToolCallSimulator struct {
+	approval  *approval.Service
+	detector  *safety.Detector
+	estimator *pricing.CostEstimator
+	settings  *orgsettings.Service
+}
+
+// NewToolCallSimulator creates a new tool call simulator.
+func NewToolCallSimulator(approvalService *approval.Service, detector *safety.Detector, estimator *pricing.CostEstimator, settings *orgsettings.Service) *ToolCallSimulator {
+	return &ToolCallSimulator{
+		approval:  approvalService,
+		detector:  detector,
+		estimator: estimator,
+		settings:  settings,
+	}
+}
+
+// Simulate returns the decision the gateway would make for a tool call.
+func (s *ToolCallSimulator) Simulate(ctx context.Context, authInfo *middleware.AuthInfo, server, tool string, args map[string]interface{}) domain.ToolCallDecision {
+	return s.evaluate(ctx, authInfo, server, tool, args, safety.DetectOptions{DryRun: true})
+}
+
+// Evaluate returns the decision for a tool call that is about to be
+// executed. Unlike Simulate, safety detections are recorded and count
+// towards escalation; detect carries the request's trace ID and client IP.
+func (s *ToolCallSimulator) Evaluate(ctx context.Context, authInfo *middleware.AuthInfo, server, tool string, args map[string]interface{}, detect safety.DetectOptions) domain.ToolCallDecision {
+	detect.DryRun = false
+	return s.evaluate(ctx, authInfo, server, tool, args, detect)
+}
+
+func (s *ToolCallSimulator) evaluate(ctx context.Context, authInfo *middleware.AuthInfo, server, tool string, args map[string]interface{}, detect safety.DetectOptions) domain.ToolCallDecision {
+	decision := domain.ToolCallDecision{
+		MCPServer:     server,
+		ToolName:      tool,
+		Allowed:       true,
+		EstimatedCost: s.estimator.Estimate(server, tool, args),
+	}
+
+	if authInfo != nil && !authInfo.ServerAllowed(server) {
+		decision.Allowed = false
+		decision.Reason = serverScopeReason(server)
+		return decision
+	}
+
+	if tool == "" {
+		return decision
+	}
+
+	orgID := middleware.DemoOrgID
+	userID := middleware.DemoUserID
+	var teamID *uuid.UUID
+	var apiKeyID *uuid.UUID
+	if authInfo != nil {
+		orgID = authInfo.OrgID
+		userID = authInfo.UserID
+		if authInfo.TeamID != uuid.Nil {
+			teamID = &authInfo.TeamID
+		}
+		if authInfo.APIKeyID != uuid.Nil {
+			apiKeyID = &authInfo.APIKeyID
+		}
+	}
+
+	// Classification and approval
+	if s.approval != nil {
+		decision.Classification, decision.RequiresApproval = s.Classification(orgID, server, tool)
+
+		allowed, reason := s.approval.CheckAccess(orgID, userID, teamID, server, tool)
+		if !allowed {
+			decision.Allowed = false
+			decision.Reason = reason
+		}
+	}
+
+	// Safety detection
+	if s.detector != nil {
+		input := middleware.ExtractTextContent(args)
+		if input != "" {
+			detect.Input = input
+			detect.OrgID = orgID
+			detect.MCPServer = server
+			detect.ToolName = tool
+			detect.Arguments = args
+			detect.APIKeyID = apiKeyID
+			result := s.detector.Detect(ctx, input, detect)
+			if result.Detected {
+				decision.Safety = &result
+				if result.Action == domain.SafetyModeBlock {
+					decision.Allowed = false
+					if decision.Reason == "" {
+						decision.Reason = "Request blocked: potential prompt injection detected"
+					}
+				}
+			}
+		}
+	}
+
+	return decision
+}
+
+// ScanOutput checks output returned by a tool for prompt injection, as
+// detect describes the call. It returns nil when nothing is detected, no
+// detector is configured or the organization has turned off output scanning.
+func (s *ToolCallSimulator) ScanOutput(ctx context.Context, output string, detect safety.DetectOptions) *domain.DetectionResult {
+	if s.detector == nil || output == "" {
+		return nil
+	}
+	if !s.settings.Bool(detect.OrgID, domain.SettingOutputScanning) {
+		return nil
+	}
+	detect.Input = output
+	result := s.detector.Detect(ctx, output, detect)
+	if !result.Detected {
+		return nil
+	}
+	return &result
+}
+
+// Discover returns what a principal calling a tool can expect: its risk
+// level and whether calls wait for approval, given the permissions and
+// approvals the principal already holds. It reports false for tools the
+// principal is blocked from, including every tool on a server outside its
+// key's scope.
+func (s *ToolCallSimulator) Discover(authInfo *middleware.AuthInfo, server, tool string) (domain.ToolRiskLevel, bool, bool) {
+	if authInfo != nil && !authInfo.ServerAllowed(server) {
+		return "", false, false
+	}
+	orgID, userID := middleware.DemoOrgID, middleware.DemoUserID
+	var teamID *uuid.UUID
+	if authInfo != nil {
+		orgID, userID = authInfo.OrgID, authInfo.UserID
+		if authInfo.TeamID != uuid.Nil {
+			teamID = &authInfo.TeamID
+		}
+	}
+
+	level, _ := s.Classification(orgID, server, tool)
+	if s.approval == nil {
+		return level, false, true
+	}
+	if allowed, _ := s.approval.CheckAccess(orgID, userID, teamID, server, tool); allowed {
+		return level, false, true
+	}
+	// Dangerous tools are only unblocked by an explicit grant
+	if level == domain.ToolRiskDangerous {
+		return level, false, false
+	}
+	return level, true, true
+}
+
+// serverScopeReason explains a call denied because the API key is scoped to
+// other MCP servers.
+func serverScopeReason(server string) string {
+	return fmt.Sprintf("API key is not scoped to MCP server '%s'", server)
+}
+
+// Classification returns the risk level of a tool for an organization and whether
+// it requires approval, falling back to the organization's policy for
+// unclassified tools.
+func (s *ToolCallSimulator) Classification(orgID uuid.UUID, server, tool string) (domain.ToolRiskLevel, bool) {
+	level := domain.GetDefaultClassification(tool)
+	if s.approval != nil {
+		if classification := s.approval.GetClassification(orgID, server, tool); classification != nil {
+			return classification.Classification, classification.RequiresApproval
+		}
+		level = s.approval.DefaultClassification(orgID, server, tool)
+	}
+	return level, level != domain.ToolRiskSafe
+}
@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/response"
+)
+
+// sseKeepAliveInterval is how often idle event streams send a comment frame.
+const sseKeepAliveInterval = 15 * time.Second
+
+// startSSE writes event stream headers and disables the server write deadline
+// so long-lived streams are not cut off.
+func startSSE(w http.ResponseWriter) (http.Flusher, bool) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		WriteError(w, http.StatusInternalServerError, response.CodeStreamingNotSupported, "Streaming not supported")
+		return nil, false
+	}
+
+	// Not all writers support deadlines; ignore the error
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	return flusher, true
+}
+
+// writeSSE writes a single Server-Sent Event.
+func writeSSE(w http.ResponseWriter, flusher http.Flusher, event string, data any) error {
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, jsonData); err != nil {
+		return err
+	}
+	flusher.Flush()
+	return nil
+}
+
+// writeSSEKeepAlive writes a comment frame to keep idle connections open.
+func writeSSEKeepAlive(w http.ResponseWriter, flusher http.Flusher) error {
+	if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+		return err
+	}
+	flusher.Flush()
+	return nil
+}
+
+// parseListParam splits a comma-separated query parameter into a set.
+func parseListParam(value string) map[string]bool {
+	if value == "" {
+		return nil
+	}
+	set := make(map[string]bool)
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			set[v] = true
+		}
+	}
+	return set
+}
@@ -1,13 +1,19 @@
 package handler
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 
+	"github.com/akz4ol/gatewayops/gateway/internal/audit"
 	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/middleware"
+	"github.com/akz4ol/gatewayops/gateway/internal/ratelimit"
+	"github.com/akz4ol/gatewayops/gateway/internal/response"
 	"github.com/akz4ol/gatewayops/gateway/internal/sso"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
@@ -16,17 +22,23 @@ import (
 
 // SSOHandler handles SSO-related HTTP requests.
 type SSOHandler struct {
-	logger     zerolog.Logger
-	service    *sso.Service
-	baseURL    string
+	logger      zerolog.Logger
+	service     *sso.Service
+	baseURL     string
+	auditLogger *audit.Logger
+	lockout     *ratelimit.Lockout   // Locks out IPs and accounts after repeated failed logins; may be nil
+	anomalies   *sso.AnomalyDetector // Flags suspicious logins; may be nil
 }
 
 // NewSSOHandler creates a new SSO handler.
-func NewSSOHandler(logger zerolog.Logger, service *sso.Service, baseURL string) *SSOHandler {
+func NewSSOHandler(logger zerolog.Logger, service *sso.Service, baseURL string, auditLogger *audit.Logger, lockout *ratelimit.Lockout, anomalies *sso.AnomalyDetector) *SSOHandler {
 	return &SSOHandler{
-		logger:  logger,
-		service: service,
-		baseURL: baseURL,
+		logger:      logger,
+		service:     service,
+		baseURL:     baseURL,
+		auditLogger: auditLogger,
+		lockout:     lockout,
+		anomalies:   anomalies,
 	}
 }
 
@@ -34,8 +46,7 @@ func NewSSOHandler(logger zerolog.Logger, service *sso.Service, baseURL string)
 func (h *SSOHandler) ListProviders(w http.ResponseWriter, r *http.Request) {
 	includeDisabled := r.URL.Query().Get("include_disabled") == "true"
 
-	// Demo organization
-	orgID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	orgID := middleware.GetOrgID(r.Context())
 
 	providers := h.service.ListProviders(orgID, includeDisabled)
 
@@ -56,13 +67,13 @@ func (h *SSOHandler) GetProvider(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "providerID")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid provider ID")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid provider ID")
 		return
 	}
 
-	provider := h.service.GetProvider(id)
+	provider := h.service.GetOrgProvider(middleware.GetOrgID(r.Context()), id)
 	if provider == nil {
-		WriteError(w, http.StatusNotFound, "not_found", "Provider not found")
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Provider not found")
 		return
 	}
 
@@ -72,36 +83,19 @@ func (h *SSOHandler) GetProvider(w http.ResponseWriter, r *http.Request) {
 // CreateProvider creates a new SSO provider.
 func (h *SSOHandler) CreateProvider(w http.ResponseWriter, r *http.Request) {
 	var input domain.SSOProviderInput
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_json", "Invalid request body")
+	if !decodeInput(w, r, &input) {
 		return
 	}
 
-	if input.Type == "" {
-		WriteError(w, http.StatusBadRequest, "validation_error", "Provider type is required")
-		return
-	}
-	if input.Name == "" {
-		WriteError(w, http.StatusBadRequest, "validation_error", "Name is required")
-		return
-	}
-	if input.IssuerURL == "" {
-		WriteError(w, http.StatusBadRequest, "validation_error", "Issuer URL is required")
-		return
-	}
-	if input.ClientID == "" {
-		WriteError(w, http.StatusBadRequest, "validation_error", "Client ID is required")
-		return
-	}
-	if input.ClientSecret == "" {
-		WriteError(w, http.StatusBadRequest, "validation_error", "Client secret is required")
+	orgID := middleware.GetOrgID(r.Context())
+
+	provider, err := h.service.CreateProvider(r.Context(), input, orgID)
+	if err != nil {
+		h.logger.Error().Err(err).Str("org_id", orgID.String()).Msg("Failed to encrypt SSO client secret")
+		WriteError(w, http.StatusInternalServerError, response.CodeInternalError, "Failed to save provider")
 		return
 	}
-
-	// Demo organization
-	orgID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
-
-	provider := h.service.CreateProvider(input, orgID)
+	recordAudit(r, h.auditLogger, domain.AuditActionSSOProviderCreate, "sso_provider", provider.ID.String(), nil, h.sanitizeProvider(*provider))
 	WriteJSON(w, http.StatusCreated, h.sanitizeProvider(*provider))
 }
 
@@ -110,22 +104,36 @@ func (h *SSOHandler) UpdateProvider(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "providerID")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid provider ID")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid provider ID")
 		return
 	}
 
+	// Fields left empty keep their current value
 	var input domain.SSOProviderInput
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_json", "Invalid request body")
+	if !decodePartialInput(w, r, &input) {
 		return
 	}
 
-	provider := h.service.UpdateProvider(id, input)
+	orgID := middleware.GetOrgID(r.Context())
+
+	var before map[string]interface{}
+	if existing := h.service.GetOrgProvider(orgID, id); existing != nil {
+		before = h.sanitizeProvider(*existing)
+	}
+
+	provider, err := h.service.UpdateProvider(r.Context(), orgID, id, input)
+	if err != nil {
+		h.logger.Error().Err(err).Str("provider_id", id.String()).Msg("Failed to encrypt SSO client secret")
+		WriteError(w, http.StatusInternalServerError, response.CodeInternalError, "Failed to save provider")
+		return
+	}
 	if provider == nil {
-		WriteError(w, http.StatusNotFound, "not_found", "Provider not found")
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Provider not found")
 		return
 	}
 
+	recordAudit(r, h.auditLogger, domain.AuditActionSSOProviderUpdate, "sso_provider", id.String(), before, h.sanitizeProvider(*provider))
+
 	WriteJSON(w, http.StatusOK, h.sanitizeProvider(*provider))
 }
 
@@ -134,35 +142,70 @@ func (h *SSOHandler) DeleteProvider(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "providerID")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid provider ID")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid provider ID")
 		return
 	}
 
-	if !h.service.DeleteProvider(id) {
-		WriteError(w, http.StatusNotFound, "not_found", "Provider not found")
+	orgID := middleware.GetOrgID(r.Context())
+
+	var before map[string]interface{}
+	if existing := h.service.GetOrgProvider(orgID, id); existing != nil {
+		before = h.sanitizeProvider(*existing)
+	}
+
+	if !h.service.DeleteProvider(orgID, id) {
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Provider not found")
 		return
 	}
 
+	recordAudit(r, h.auditLogger, domain.AuditActionSSOProviderDelete, "sso_provider", id.String(), before, nil)
+
 	WriteJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
 }
 
+// RotateSecretsKey gives the organization a new key for its providers'
+// client secrets and re-encrypts them under it.
+func (h *SSOHandler) RotateSecretsKey(w http.ResponseWriter, r *http.Request) {
+	orgID := middleware.GetOrgID(r.Context())
+
+	count, err := h.service.RotateSecretsKey(r.Context(), orgID)
+	if errors.Is(err, sso.ErrNoSecretsKey) {
+		WriteError(w, http.StatusServiceUnavailable, response.CodeSecretsUnavailable, "Client secrets are not encrypted because SECRETS_ENCRYPTION_KEY is not set")
+		return
+	}
+	if err != nil {
+		h.logger.Error().Err(err).Str("org_id", orgID.String()).Msg("Failed to rotate SSO client secret key")
+		WriteError(w, http.StatusInternalServerError, response.CodeInternalError, "Failed to rotate key")
+		return
+	}
+
+	recordAudit(r, h.auditLogger, domain.AuditActionSSOSecretsRotate, "sso_provider", "", nil, map[string]interface{}{
+		"reencrypted": count,
+	})
+
+	WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"status":      "rotated",
+		"reencrypted": count,
+	})
+}
+
 // Authorize initiates the OAuth authorization flow.
 func (h *SSOHandler) Authorize(w http.ResponseWriter, r *http.Request) {
 	providerIDStr := chi.URLParam(r, "providerID")
 	providerID, err := uuid.Parse(providerIDStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid provider ID")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid provider ID")
 		return
 	}
 
 	provider := h.service.GetProvider(providerID)
 	if provider == nil {
-		WriteError(w, http.StatusNotFound, "not_found", "Provider not found")
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Provider not found")
 		return
 	}
 
 	if !provider.Enabled {
-		WriteError(w, http.StatusBadRequest, "provider_disabled", "This SSO provider is disabled")
+		WriteError(w, http.StatusBadRequest, response.CodeProviderDisabled, "This SSO provider is disabled")
 		return
 	}
 
@@ -172,11 +215,23 @@ func (h *SSOHandler) Authorize(w http.ResponseWriter, r *http.Request) {
 		redirectURL = h.baseURL + "/dashboard"
 	}
 
+	// A client stepping up for a sensitive action passes on the max_age and
+	// acr_values from the step-up challenge, so the provider re-authenticates
+	// the user instead of reusing its own session
+	maxAge := r.URL.Query().Get("max_age")
+	if maxAge != "" {
+		if n, err := strconv.Atoi(maxAge); err != nil || n < 0 {
+			WriteFieldError(w, "max_age", "max_age must be a non-negative number of seconds")
+			return
+		}
+	}
+	acrValues := r.URL.Query().Get("acr_values")
+
 	// Generate state for CSRF protection
 	state, err := h.service.GenerateAuthState(providerID, redirectURL)
 	if err != nil {
 		h.logger.Error().Err(err).Msg("Failed to generate auth state")
-		WriteError(w, http.StatusInternalServerError, "state_error", "Failed to initiate login")
+		WriteError(w, http.StatusInternalServerError, response.CodeStateError, "Failed to initiate login")
 		return
 	}
 
@@ -205,9 +260,15 @@ func (h *SSOHandler) Authorize(w http.ResponseWriter, r *http.Request) {
 	authURL, err := h.service.GetAuthorizationURL(providerID, state, callbackURL)
 	if err != nil {
 		h.logger.Error().Err(err).Msg("Failed to get authorization URL")
-		WriteError(w, http.StatusInternalServerError, "auth_url_error", "Failed to initiate login")
+		WriteError(w, http.StatusInternalServerError, response.CodeAuthURLError, "Failed to initiate login")
 		return
 	}
+	if maxAge != "" {
+		authURL += "&max_age=" + maxAge
+	}
+	if acrValues != "" {
+		authURL += "&acr_values=" + url.QueryEscape(acrValues)
+	}
 
 	// For API calls, return the URL; for browser, redirect
 	if r.Header.Get("Accept") == "application/json" {
@@ -224,9 +285,15 @@ func (h *SSOHandler) Authorize(w http.ResponseWriter, r *http.Request) {
 
 // Callback handles the OAuth callback from the identity provider.
 func (h *SSOHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	ipKey := ipLockoutKey(r)
+	if !h.checkLockout(w, r, ipKey) {
+		return
+	}
+
 	providerIDStr := chi.URLParam(r, "providerID")
 	providerID, err := uuid.Parse(providerIDStr)
 	if err != nil {
+		h.recordFailure(r, middleware.DemoOrgID, ipKey)
 		h.renderError(w, r, "Invalid provider ID")
 		return
 	}
@@ -238,6 +305,7 @@ func (h *SSOHandler) Callback(w http.ResponseWriter, r *http.Request) {
 			Str("error", errCode).
 			Str("description", errDesc).
 			Msg("OAuth error from provider")
+		h.recordFailure(r, middleware.DemoOrgID, ipKey)
 		h.renderError(w, r, "Authentication failed: "+errDesc)
 		return
 	}
@@ -247,6 +315,7 @@ func (h *SSOHandler) Callback(w http.ResponseWriter, r *http.Request) {
 	state, err := h.service.ValidateAuthState(stateValue)
 	if err != nil {
 		h.logger.Warn().Err(err).Msg("Invalid OAuth state")
+		h.recordFailure(r, middleware.DemoOrgID, ipKey)
 		h.renderError(w, r, "Invalid or expired login session")
 		return
 	}
@@ -254,25 +323,70 @@ func (h *SSOHandler) Callback(w http.ResponseWriter, r *http.Request) {
 	// Get authorization code
 	code := r.URL.Query().Get("code")
 	if code == "" {
+		h.recordFailure(r, middleware.DemoOrgID, ipKey)
 		h.renderError(w, r, "No authorization code received")
 		return
 	}
 
 	// Exchange code for tokens
 	callbackURL := h.baseURL + "/v1/sso/callback/" + providerID.String()
-	tokenPair, claims, err := h.service.ExchangeCode(providerID, code, callbackURL)
+	tokenPair, claims, err := h.service.ExchangeCode(providerID, code, callbackURL, state.Nonce)
+	if errors.Is(err, sso.ErrNonceMismatch) {
+		h.logger.Warn().Str("provider_id", providerID.String()).Msg("SSO login refused: ID token nonce does not match the login")
+		h.recordFailure(r, middleware.DemoOrgID, ipKey)
+		h.renderError(w, r, "Invalid or expired login session")
+		return
+	}
 	if err != nil {
 		h.logger.Error().Err(err).Msg("Failed to exchange code")
+		h.recordFailure(r, middleware.DemoOrgID, ipKey)
 		h.renderError(w, r, "Failed to complete authentication")
 		return
 	}
 
+	accountKey := accountLockoutKey(providerID, claims.Subject)
+	if !h.checkLockout(w, r, accountKey) {
+		return
+	}
+
 	// Get or create user
 	provider := h.service.GetProvider(providerID)
 	user := h.service.GetOrCreateUser(provider.OrgID, providerID, claims)
+	if user.Status != domain.UserStatusActive {
+		h.logger.Warn().
+			Str("user_id", user.ID.String()).
+			Str("status", string(user.Status)).
+			Msg("SSO login refused for inactive user")
+		h.recordFailure(r, provider.OrgID, ipKey, accountKey)
+		h.renderError(w, r, "Your account is not active")
+		return
+	}
+	if h.lockout != nil {
+		h.lockout.Reset(r.Context(), accountKey)
+	}
+
+	// Flag suspicious logins, and send them back for MFA when policy asks
+	ip := middleware.RequestClientIP(r)
+	anomalies := h.anomalies.Check(user, ip, r.UserAgent(), len(h.service.ListUserSessions(user.ID)))
+	if h.anomalies.RequiresStepUp(anomalies, claims) {
+		h.logger.Warn().
+			Str("user_id", user.ID.String()).
+			Int("anomalies", len(anomalies)).
+			Msg("Anomalous SSO login refused without MFA")
+		if r.Header.Get("Accept") == "application/json" {
+			WriteError(w, http.StatusUnauthorized, response.CodeStepUpRequired, "This login looks unusual; sign in again with multi-factor authentication")
+			return
+		}
+		h.renderError(w, r, "This login looks unusual; sign in again with multi-factor authentication")
+		return
+	}
 
 	// Create session
-	session := h.service.CreateSession(user, r.RemoteAddr, r.UserAgent())
+	session := h.service.CreateSession(user, claims, ip, r.UserAgent())
+	if session == nil {
+		h.renderError(w, r, "Failed to create session")
+		return
+	}
 
 	h.logger.Info().
 		Str("user_id", user.ID.String()).
@@ -283,11 +397,13 @@ func (h *SSOHandler) Callback(w http.ResponseWriter, r *http.Request) {
 	// For API calls, return tokens; for browser, redirect with cookie
 	if r.Header.Get("Accept") == "application/json" {
 		WriteJSON(w, http.StatusOK, map[string]interface{}{
-			"user":         user,
-			"session":      session,
-			"access_token": tokenPair.AccessToken,
-			"token_type":   tokenPair.TokenType,
-			"expires_in":   tokenPair.ExpiresIn,
+			"user":          user,
+			"session":       session,
+			"access_token":  tokenPair.AccessToken,
+			"token_type":    tokenPair.TokenType,
+			"expires_in":    tokenPair.ExpiresIn,
+			"session_token": session.AccessToken,
+			"refresh_token": session.RefreshToken,
 		})
 		return
 	}
@@ -321,6 +437,8 @@ func (h *SSOHandler) Logout(w http.ResponseWriter, r *http.Request) {
 	var token string
 	if cookie, err := r.Cookie("session"); err == nil {
 		token = cookie.Value
+	} else if header := r.Header.Get(middleware.SessionHeader); header != "" {
+		token = header
 	} else if auth := r.Header.Get("Authorization"); len(auth) > 7 {
 		token = auth[7:] // Remove "Bearer "
 	}
@@ -328,7 +446,11 @@ func (h *SSOHandler) Logout(w http.ResponseWriter, r *http.Request) {
 	if token != "" {
 		session, _ := h.service.ValidateSession(token)
 		if session != nil {
-			h.service.RevokeSession(session.ID)
+			if _, err := h.service.RevokeSession(session.ID); err != nil {
+				h.logger.Error().Err(err).Str("session_id", session.ID.String()).Msg("Failed to revoke session")
+				WriteError(w, http.StatusInternalServerError, response.CodeInternalError, "Failed to revoke session")
+				return
+			}
 		}
 	}
 
@@ -350,10 +472,53 @@ func (h *SSOHandler) Logout(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/", http.StatusFound)
 }
 
-// ListSessions returns all active sessions for the current user.
+// RefreshSession exchanges a refresh token for a new session token. The
+// token it replaces stops working.
+func (h *SSOHandler) RefreshSession(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if !decodeInput(w, r, &req) {
+		return
+	}
+	if req.RefreshToken == "" {
+		WriteFieldError(w, "refresh_token", "Refresh token is required")
+		return
+	}
+
+	ipKey := ipLockoutKey(r)
+	if !h.checkLockout(w, r, ipKey) {
+		return
+	}
+
+	session, err := h.service.RefreshSession(req.RefreshToken)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to refresh session")
+		WriteError(w, http.StatusInternalServerError, response.CodeInternalError, "Failed to refresh session")
+		return
+	}
+	if session == nil {
+		h.recordFailure(r, middleware.DemoOrgID, ipKey)
+		WriteError(w, http.StatusUnauthorized, response.CodeInvalidSession, "Refresh token is invalid or revoked")
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"session":      session,
+		"access_token": session.AccessToken,
+		"token_type":   "Bearer",
+		"expires_at":   session.ExpiresAt,
+	})
+}
+
+// ListSessions returns all active sessions for the current user. Callers
+// with the users:admin permission can name another user of their
+// organization with the user_id query parameter.
 func (h *SSOHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
-	// Demo user
-	userID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	userID, ok := h.sessionOwner(w, r)
+	if !ok {
+		return
+	}
 
 	sessions := h.service.ListUserSessions(userID)
 
@@ -363,29 +528,63 @@ func (h *SSOHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// RevokeSession revokes a specific session.
+// RevokeSession revokes a specific session: one of the current user's, or,
+// for callers with the users:admin permission, any in their organization.
 func (h *SSOHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "sessionID")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid session ID")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid session ID")
 		return
 	}
 
-	if !h.service.RevokeSession(id) {
-		WriteError(w, http.StatusNotFound, "not_found", "Session not found")
+	session := h.service.GetSession(id)
+	if session == nil {
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Session not found")
 		return
 	}
+	own := session.UserID == middleware.GetUserID(r.Context())
+	admin := session.OrgID == middleware.GetOrgID(r.Context()) && middleware.HasPermission(r.Context(), domain.PermissionUsersAdmin)
+	if !own && !admin {
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Session not found")
+		return
+	}
+	revoked, err := h.service.RevokeSession(id)
+	if err != nil {
+		h.logger.Error().Err(err).Str("session_id", id.String()).Msg("Failed to revoke session")
+		WriteError(w, http.StatusInternalServerError, response.CodeInternalError, "Failed to revoke session")
+		return
+	}
+	if !revoked {
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Session not found")
+		return
+	}
+	if !own {
+		recordAudit(r, h.auditLogger, domain.AuditActionSessionRevoke, "session", id.String(), session, nil)
+	}
 
 	WriteJSON(w, http.StatusOK, map[string]string{"status": "revoked"})
 }
 
-// RevokeAllSessions revokes all sessions for the current user.
+// RevokeAllSessions revokes all sessions for the current user, or for the
+// user named by the user_id query parameter as in ListSessions.
 func (h *SSOHandler) RevokeAllSessions(w http.ResponseWriter, r *http.Request) {
-	// Demo user
-	userID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	userID, ok := h.sessionOwner(w, r)
+	if !ok {
+		return
+	}
 
-	count := h.service.RevokeAllUserSessions(userID)
+	count, err := h.service.RevokeAllUserSessions(userID)
+	if err != nil {
+		h.logger.Error().Err(err).Str("user_id", userID.String()).Int("sessions_revoked", count).Msg("Failed to revoke sessions")
+		WriteError(w, http.StatusInternalServerError, response.CodeInternalError, "Failed to revoke sessions")
+		return
+	}
+	if userID != middleware.GetUserID(r.Context()) {
+		recordAudit(r, h.auditLogger, domain.AuditActionSessionRevoke, "user", userID.String(), nil, map[string]interface{}{
+			"sessions_revoked": count,
+		})
+	}
 
 	WriteJSON(w, http.StatusOK, map[string]interface{}{
 		"status":           "revoked",
@@ -393,9 +592,40 @@ func (h *SSOHandler) RevokeAllSessions(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// GetStats returns SSO statistics.
+// sessionOwner returns the user whose sessions a request manages: the
+// caller, or the user named by the user_id query parameter. Managing
+// another user's sessions requires the users:admin permission, and the user
+// must belong to the caller's organization. It writes an error and returns
+// false when the request may not proceed.
+func (h *SSOHandler) sessionOwner(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	self := middleware.GetUserID(r.Context())
+	idStr := r.URL.Query().Get("user_id")
+	if idStr == "" {
+		return self, true
+	}
+	userID, err := uuid.Parse(idStr)
+	if err != nil {
+		WriteFieldError(w, "user_id", "Invalid user ID")
+		return uuid.Nil, false
+	}
+	if userID == self {
+		return self, true
+	}
+
+	if !middleware.HasPermission(r.Context(), domain.PermissionUsersAdmin) {
+		WriteError(w, http.StatusForbidden, response.CodeForbidden, "Managing another user's sessions requires the users:admin permission")
+		return uuid.Nil, false
+	}
+	if user := h.service.GetUser(userID); user == nil || user.OrgID != middleware.GetOrgID(r.Context()) {
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "User not found")
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+// GetStats returns SSO statistics for the organization.
 func (h *SSOHandler) GetStats(w http.ResponseWriter, r *http.Request) {
-	stats := h.service.ProviderStats()
+	stats := h.service.ProviderStats(middleware.GetOrgID(r.Context()))
 	WriteJSON(w, http.StatusOK, stats)
 }
 
@@ -451,13 +681,13 @@ func (h *SSOHandler) TestConnection(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "providerID")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid provider ID")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid provider ID")
 		return
 	}
 
-	provider := h.service.GetProvider(id)
+	provider := h.service.GetOrgProvider(middleware.GetOrgID(r.Context()), id)
 	if provider == nil {
-		WriteError(w, http.StatusNotFound, "not_found", "Provider not found")
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Provider not found")
 		return
 	}
 
@@ -492,15 +722,81 @@ func (h *SSOHandler) sanitizeProvider(p domain.SSOProvider) map[string]interface
 		"scopes":            p.Scopes,
 		"claim_mappings":    p.ClaimMappings,
 		"group_mappings":    p.GroupMappings,
+		"group_names":       p.GroupNames,
 		"enabled":           p.Enabled,
 		"created_at":        p.CreatedAt,
 		"updated_at":        p.UpdatedAt,
 	}
 }
 
+// ipLockoutKey and accountLockoutKey name the subjects of brute-force
+// lockouts: the client IP, and the identity-provider account.
+func ipLockoutKey(r *http.Request) string {
+	return "ip:" + middleware.RequestClientIP(r)
+}
+
+func accountLockoutKey(providerID uuid.UUID, subject string) string {
+	return "account:" + providerID.String() + ":" + subject
+}
+
+// checkLockout refuses the request if key is locked out after repeated
+// failed attempts, returning false once the response has been written.
+func (h *SSOHandler) checkLockout(w http.ResponseWriter, r *http.Request, key string) bool {
+	if h.lockout == nil {
+		return true
+	}
+	locked, remaining := h.lockout.Locked(r.Context(), key)
+	if !locked {
+		return true
+	}
+
+	seconds := int(math.Ceil(remaining.Seconds()))
+	message := fmt.Sprintf("Too many failed sign-in attempts. Try again in %d seconds", seconds)
+	if r.Header.Get("Accept") != "application/json" {
+		h.renderError(w, r, message)
+		return false
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	WriteError(w, http.StatusTooManyRequests, response.CodeAuthLocked, message)
+	return false
+}
+
+// recordFailure counts a failed attempt against each key, logging and
+// auditing any lockout it triggers.
+func (h *SSOHandler) recordFailure(r *http.Request, orgID uuid.UUID, keys ...string) {
+	if h.lockout == nil {
+		return
+	}
+	ctx := r.Context()
+	for _, key := range keys {
+		if !h.lockout.Fail(ctx, key) {
+			continue
+		}
+
+		h.logger.Warn().
+			Str("lockout_key", key).
+			Str("client_ip", middleware.RequestClientIP(r)).
+			Msg("Sign-in locked out after repeated failed attempts")
+		if h.auditLogger != nil {
+			h.auditLogger.LogEvent(ctx, audit.Event{
+				OrgID:      orgID,
+				TraceID:    middleware.GetTraceID(ctx),
+				Action:     domain.AuditActionUserLockout,
+				Resource:   "login",
+				ResourceID: key,
+				Outcome:    domain.AuditOutcomeBlocked,
+				Details:    map[string]interface{}{"path": r.URL.Path},
+				IPAddress:  middleware.RequestClientIP(r),
+				UserAgent:  r.UserAgent(),
+				RequestID:  middleware.GetRequestID(ctx),
+			})
+		}
+	}
+}
+
 func (h *SSOHandler) renderError(w http.ResponseWriter, r *http.Request, message string) {
 	if r.Header.Get("Accept") == "application/json" {
-		WriteError(w, http.StatusBadRequest, "auth_error", message)
+		WriteError(w, http.StatusBadRequest, response.CodeAuthError, message)
 		return
 	}
 
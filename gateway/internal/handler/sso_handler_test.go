@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/clock"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/middleware"
+	"github.com/akz4ol/gatewayops/gateway/internal/sso"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+func TestSessionsAreScopedToTheCaller(t *testing.T) {
+	service := sso.NewService(zerolog.Nop(), []byte("0123456789abcdef0123456789abcdef"), clock.Real, nil, nil, true)
+	h := NewSSOHandler(zerolog.Nop(), service, "https://gateway.example.com", nil, nil, nil)
+
+	providerID := service.ListProviders(middleware.DemoOrgID, true)[0].ID
+	demo := service.GetUser(middleware.DemoUserID)
+	colleague := service.GetOrCreateUser(middleware.DemoOrgID, providerID, &domain.OIDCClaims{Subject: "colleague", Email: "colleague@demo.gatewayops.io"})
+	outsider := service.GetOrCreateUser(uuid.New(), providerID, &domain.OIDCClaims{Subject: "outsider", Email: "someone@elsewhere.example"})
+	own := service.CreateSession(demo, &domain.OIDCClaims{}, "192.0.2.1", "test")
+	theirs := service.CreateSession(colleague, &domain.OIDCClaims{}, "192.0.2.2", "test")
+	service.CreateSession(colleague, &domain.OIDCClaims{}, "192.0.2.3", "test")
+
+	// serve calls handler as a user of the demo org with permissions
+	serve := func(handler http.HandlerFunc, method, target string, userID uuid.UUID, permissions ...domain.Permission) *httptest.ResponseRecorder {
+		info := &middleware.AuthInfo{OrgID: middleware.DemoOrgID, UserID: userID}
+		for _, p := range permissions {
+			info.Permissions = append(info.Permissions, string(p))
+		}
+		r := httptest.NewRequest(method, target, nil)
+		rec := httptest.NewRecorder()
+		handler(rec, r.WithContext(context.WithValue(r.Context(), middleware.AuthInfoKey, info)))
+		return rec
+	}
+	sessionIDs := func(rec *httptest.ResponseRecorder) []uuid.UUID {
+		t.Helper()
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status %d: %s", rec.Code, rec.Body)
+		}
+		var body struct {
+			Sessions []domain.UserSession `json:"sessions"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		var ids []uuid.UUID
+		for _, s := range body.Sessions {
+			ids = append(ids, s.ID)
+		}
+		return ids
+	}
+
+	if got := sessionIDs(serve(h.ListSessions, http.MethodGet, "/v1/sso/sessions", demo.ID, domain.PermissionUsersRead)); len(got) != 1 || got[0] != own.ID {
+		t.Errorf("own sessions = %v, want only %s", got, own.ID)
+	}
+	if got := sessionIDs(serve(h.ListSessions, http.MethodGet, "/v1/sso/sessions", colleague.ID, domain.PermissionUsersRead)); len(got) != 2 {
+		t.Errorf("colleague's own sessions = %v, want their 2", got)
+	}
+
+	otherUser := "/v1/sso/sessions?user_id=" + colleague.ID.String()
+	if rec := serve(h.ListSessions, http.MethodGet, otherUser, demo.ID, domain.PermissionUsersRead); rec.Code != http.StatusForbidden {
+		t.Errorf("listing another user's sessions without users:admin: status %d, want 403", rec.Code)
+	}
+	if rec := serve(h.RevokeAllSessions, http.MethodDelete, otherUser, demo.ID, domain.PermissionUsersRead); rec.Code != http.StatusForbidden {
+		t.Errorf("revoking another user's sessions without users:admin: status %d, want 403", rec.Code)
+	}
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("sessionID", theirs.ID.String())
+	req := asKey(httptest.NewRequest(http.MethodDelete, "/v1/sso/sessions/"+theirs.ID.String(), nil), domain.PermissionUsersRead)
+	rec := httptest.NewRecorder()
+	h.RevokeSession(rec, req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx)))
+	if rec.Code != http.StatusNotFound || service.GetSession(theirs.ID) == nil {
+		t.Errorf("revoking a colleague's session without users:admin: status %d, want 404 and the session kept", rec.Code)
+	}
+
+	if rec := serve(h.ListSessions, http.MethodGet, "/v1/sso/sessions?user_id="+outsider.ID.String(), demo.ID, domain.PermissionUsersAdmin); rec.Code != http.StatusNotFound {
+		t.Errorf("listing the sessions of another org's user: status %d, want 404", rec.Code)
+	}
+	if got := sessionIDs(serve(h.ListSessions, http.MethodGet, otherUser, demo.ID, domain.PermissionUsersAdmin)); len(got) != 2 {
+		t.Errorf("admin listing a colleague's sessions = %v, want their 2", got)
+	}
+	rec = serve(h.RevokeAllSessions, http.MethodDelete, otherUser, demo.ID, domain.PermissionUsersAdmin)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("admin revoking a colleague's sessions: status %d: %s", rec.Code, rec.Body)
+	}
+	if left := service.ListUserSessions(colleague.ID); len(left) != 0 {
+		t.Errorf("%d of the colleague's sessions left", len(left))
+	}
+	if left := service.ListUserSessions(demo.ID); len(left) != 1 {
+		t.Errorf("admin's own sessions were revoked too: %d left", len(left))
+	}
+}
@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/alerting"
+	"github.com/akz4ol/gatewayops/gateway/internal/approval"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/middleware"
+	"github.com/akz4ol/gatewayops/gateway/internal/otel"
+	"github.com/akz4ol/gatewayops/gateway/internal/safety"
+	"github.com/akz4ol/gatewayops/gateway/internal/sso"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// statsComponentTimeout bounds how long a single component may take before
+// the response is sent without it.
+const statsComponentTimeout = 2 * time.Second
+
+// statsComponent fetches one section of the aggregate stats response and
+// stores it with set.
+type statsComponent struct {
+	name  string
+	fetch func(ctx context.Context, orgID uuid.UUID) (interface{}, error)
+	set   func(stats *domain.GatewayStats, value interface{})
+}
+
+// StatsHandler serves the aggregate dashboard stats endpoint.
+type StatsHandler struct {
+	logger     zerolog.Logger
+	components []statsComponent
+	timeout    time.Duration
+}
+
+// NewStatsHandler creates a new stats handler. Nil services are omitted from
+// the response.
+func NewStatsHandler(logger zerolog.Logger, ssoService *sso.Service, detector *safety.Detector, approvals *approval.Service, alerts *alerting.Service, exporter *otel.Exporter, limiter *middleware.ConcurrencyLimiter) *StatsHandler {
+	h := &StatsHandler{
+		logger:  logger,
+		timeout: statsComponentTimeout,
+	}
+
+	if ssoService != nil {
+		h.components = append(h.components, statsComponent{
+			name: "sso",
+			fetch: func(ctx context.Context, orgID uuid.UUID) (interface{}, error) {
+				return ssoService.ProviderStats(orgID), nil
+			},
+			set: func(stats *domain.GatewayStats, value interface{}) {
+				stats.SSO = value.(map[string]interface{})
+			},
+		})
+	}
+	if detector != nil {
+		h.components = append(h.components, statsComponent{
+			name: "safety",
+			fetch: func(ctx context.Context, orgID uuid.UUID) (interface{}, error) {
+				summary := detector.GetSummary(orgID)
+				return &summary, nil
+			},
+			set: func(stats *domain.GatewayStats, value interface{}) {
+				stats.Safety = value.(*domain.SafetySummary)
+			},
+		})
+	}
+	if approvals != nil {
+		h.components = append(h.components, statsComponent{
+			name: "approvals",
+			fetch: func(ctx context.Context, orgID uuid.UUID) (interface{}, error) {
+				return &domain.ApprovalStats{PendingCount: approvals.GetPendingCount(orgID)}, nil
+			},
+			set: func(stats *domain.GatewayStats, value interface{}) {
+				stats.Approvals = value.(*domain.ApprovalStats)
+			},
+		})
+	}
+	if alerts != nil {
+		h.components = append(h.components, statsComponent{
+			name: "alerts",
+			fetch: func(ctx context.Context, orgID uuid.UUID) (interface{}, error) {
+				count, err := alerts.CountActiveAlerts(ctx, orgID)
+				if err != nil {
+					return nil, err
+				}
+				return &domain.AlertStats{ActiveCount: count}, nil
+			},
+			set: func(stats *domain.GatewayStats, value interface{}) {
+				stats.Alerts = value.(*domain.AlertStats)
+			},
+		})
+	}
+	if exporter != nil {
+		h.components = append(h.components, statsComponent{
+			name: "telemetry",
+			fetch: func(ctx context.Context, orgID uuid.UUID) (interface{}, error) {
+				stats := exporter.GetStats()
+				return &stats, nil
+			},
+			set: func(stats *domain.GatewayStats, value interface{}) {
+				stats.Telemetry = value.(*domain.TelemetryStats)
+			},
+		})
+	}
+	if limiter != nil {
+		h.components = append(h.components, statsComponent{
+			name: "concurrency",
+			fetch: func(ctx context.Context, orgID uuid.UUID) (interface{}, error) {
+				stats := limiter.Stats()
+				return &stats, nil
+			},
+			set: func(stats *domain.GatewayStats, value interface{}) {
+				stats.Concurrency = value.(*domain.ConcurrencyStats)
+			},
+		})
+	}
+
+	return h
+}
+
+// Get handles GET /v1/stats. Components are fetched concurrently; one that
+// fails, panics or exceeds its timeout is reported under errors and the rest
+// of the response is still returned.
+func (h *StatsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	WriteJSON(w, http.StatusOK, h.collect(r.Context(), middleware.GetOrgID(r.Context())))
+}
+
+type statsResult struct {
+	component statsComponent
+	value     interface{}
+	err       error
+}
+
+func (h *StatsHandler) collect(ctx context.Context, orgID uuid.UUID) domain.GatewayStats {
+	results := make(chan statsResult, len(h.components))
+	for _, c := range h.components {
+		go func(c statsComponent) {
+			results <- h.fetch(ctx, c, orgID)
+		}(c)
+	}
+
+	stats := domain.GatewayStats{GeneratedAt: time.Now().UTC()}
+	for range h.components {
+		res := <-results
+		if res.err != nil {
+			if stats.Errors == nil {
+				stats.Errors = make(map[string]string)
+			}
+			stats.Errors[res.component.name] = res.err.Error()
+			stats.Partial = true
+
+			h.logger.Warn().
+				Err(res.err).
+				Str("component", res.component.name).
+				Msg("Stats component unavailable")
+			continue
+		}
+		res.component.set(&stats, res.value)
+	}
+	return stats
+}
+
+// fetch runs one component under its own timeout. The component runs in a
+// separate goroutine so that one ignoring its context cannot hold up the
+// response; its late result is discarded.
+func (h *StatsHandler) fetch(ctx context.Context, c statsComponent, orgID uuid.UUID) statsResult {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	done := make(chan statsResult, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- statsResult{component: c, err: fmt.Errorf("panic: %v", p)}
+			}
+		}()
+		value, err := c.fetch(ctx, orgID)
+		done <- statsResult{component: c, value: value, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		return res
+	case <-ctx.Done():
+		err := ctx.Err()
+		if err == context.DeadlineExceeded {
+			err = fmt.Errorf("timed out after %s", h.timeout)
+		}
+		return statsResult{component: c, err: err}
+	}
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/approval"
+	"github.com/akz4ol/gatewayops/gateway/internal/clock"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/middleware"
+	"github.com/akz4ol/gatewayops/gateway/internal/safety"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+func TestStatsReturnsPartialDataWhenAComponentFails(t *testing.T) {
+	detector := safety.NewDetector(zerolog.Nop(), nil, 100, safety.EscalationPolicy{}, nil, nil)
+	approvals := approval.NewService(zerolog.Nop(), nil, nil, nil, nil, nil, 100, clock.Real)
+	approvals.RequestApproval(context.Background(), domain.ToolApprovalRequest{MCPServer: "shell", ToolName: "execute_command"}, middleware.DemoOrgID, middleware.DemoUserID)
+	approvals.RequestApproval(context.Background(), domain.ToolApprovalRequest{MCPServer: "shell", ToolName: "execute_command"}, uuid.New(), uuid.New())
+
+	h := NewStatsHandler(zerolog.Nop(), nil, detector, approvals, nil, nil, nil)
+	h.timeout = 50 * time.Millisecond
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+	h.components = append(h.components,
+		statsComponent{name: "alerts", fetch: func(context.Context, uuid.UUID) (interface{}, error) {
+			return nil, errors.New("database unavailable")
+		}},
+		statsComponent{name: "telemetry", fetch: func(context.Context, uuid.UUID) (interface{}, error) {
+			panic("exporter not started")
+		}},
+		// Ignores its context, so only the handler's own timeout ends it
+		statsComponent{name: "sso", fetch: func(context.Context, uuid.UUID) (interface{}, error) {
+			<-release
+			return map[string]interface{}{}, nil
+		}},
+	)
+
+	start := time.Now()
+	rec := httptest.NewRecorder()
+	h.Get(rec, httptest.NewRequest(http.MethodGet, "/v1/stats", nil))
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("stats took %s, want the slow component cut off", elapsed)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d, want 200 with partial data: %s", rec.Code, rec.Body)
+	}
+
+	var stats domain.GatewayStats
+	if err := json.Unmarshal(rec.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if stats.Approvals == nil || stats.Approvals.PendingCount != 1 {
+		t.Errorf("approvals = %+v, want the org's one pending approval", stats.Approvals)
+	}
+	if stats.Safety == nil {
+		t.Error("safety summary missing")
+	}
+	if !stats.Partial {
+		t.Error("response not marked partial")
+	}
+	for name, want := range map[string]string{
+		"alerts":    "database unavailable",
+		"telemetry": "panic: exporter not started",
+		"sso":       "timed out after 50ms",
+	} {
+		if got := stats.Errors[name]; got != want {
+			t.Errorf("%s error = %q, want %q", name, got, want)
+		}
+	}
+	if stats.Alerts != nil || stats.Telemetry != nil || stats.SSO != nil {
+		t.Errorf("failed components were set: %+v", stats)
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"net/http"
 
 	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/middleware"
 	"github.com/akz4ol/gatewayops/gateway/internal/otel"
+	"github.com/akz4ol/gatewayops/gateway/internal/response"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
@@ -27,7 +29,7 @@ func NewTelemetryHandler(logger zerolog.Logger, exporter *otel.Exporter) *Teleme
 
 // ListConfigs returns all telemetry configurations.
 func (h *TelemetryHandler) ListConfigs(w http.ResponseWriter, r *http.Request) {
-	configs := h.exporter.ListConfigs()
+	configs := h.exporter.ListConfigs(middleware.GetOrgID(r.Context()))
 	WriteJSON(w, http.StatusOK, map[string]interface{}{
 		"configs": configs,
 		"total":   len(configs),
@@ -39,13 +41,13 @@ func (h *TelemetryHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "configID")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid config ID")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid config ID")
 		return
 	}
 
-	config := h.exporter.GetConfig(id)
+	config := h.exporter.GetConfig(middleware.GetOrgID(r.Context()), id)
 	if config == nil {
-		WriteError(w, http.StatusNotFound, "not_found", "Configuration not found")
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Configuration not found")
 		return
 	}
 
@@ -56,16 +58,16 @@ func (h *TelemetryHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
 func (h *TelemetryHandler) CreateConfig(w http.ResponseWriter, r *http.Request) {
 	var input domain.TelemetryConfigInput
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_json", "Invalid request body")
+		writeDecodeError(w, err)
 		return
 	}
 
 	if input.Name == "" {
-		WriteError(w, http.StatusBadRequest, "validation_error", "Name is required")
+		WriteFieldError(w, "name", "Name is required")
 		return
 	}
 	if input.Endpoint == "" {
-		WriteError(w, http.StatusBadRequest, "validation_error", "Endpoint is required")
+		WriteFieldError(w, "endpoint", "Endpoint is required")
 		return
 	}
 	if input.ExporterType == "" {
@@ -75,10 +77,7 @@ func (h *TelemetryHandler) CreateConfig(w http.ResponseWriter, r *http.Request)
 		input.Protocol = domain.TelemetryProtocolHTTP
 	}
 
-	// Demo org
-	orgID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
-
-	config := h.exporter.CreateConfig(input, orgID)
+	config := h.exporter.CreateConfig(r.Context(), input, middleware.GetOrgID(r.Context()))
 	WriteJSON(w, http.StatusCreated, config)
 }
 
@@ -87,19 +86,19 @@ func (h *TelemetryHandler) UpdateConfig(w http.ResponseWriter, r *http.Request)
 	idStr := chi.URLParam(r, "configID")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid config ID")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid config ID")
 		return
 	}
 
 	var input domain.TelemetryConfigInput
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_json", "Invalid request body")
+		writeDecodeError(w, err)
 		return
 	}
 
-	config := h.exporter.UpdateConfig(id, input)
+	config := h.exporter.UpdateConfig(middleware.GetOrgID(r.Context()), id, input)
 	if config == nil {
-		WriteError(w, http.StatusNotFound, "not_found", "Configuration not found")
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Configuration not found")
 		return
 	}
 
@@ -111,12 +110,12 @@ func (h *TelemetryHandler) DeleteConfig(w http.ResponseWriter, r *http.Request)
 	idStr := chi.URLParam(r, "configID")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid config ID")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid config ID")
 		return
 	}
 
-	if !h.exporter.DeleteConfig(id) {
-		WriteError(w, http.StatusNotFound, "not_found", "Configuration not found")
+	if !h.exporter.DeleteConfig(middleware.GetOrgID(r.Context()), id) {
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Configuration not found")
 		return
 	}
 
@@ -128,11 +127,11 @@ func (h *TelemetryHandler) TestConfig(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "configID")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid config ID")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid config ID")
 		return
 	}
 
-	result := h.exporter.TestConfig(id)
+	result := h.exporter.TestConfig(r.Context(), middleware.GetOrgID(r.Context()), id)
 
 	status := http.StatusOK
 	if !result.Success {
@@ -152,7 +151,7 @@ func (h *TelemetryHandler) GetStats(w http.ResponseWriter, r *http.Request) {
 func (h *TelemetryHandler) ExportSpan(w http.ResponseWriter, r *http.Request) {
 	var span domain.TelemetrySpan
 	if err := json.NewDecoder(r.Body).Decode(&span); err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_json", "Invalid request body")
+		writeDecodeError(w, err)
 		return
 	}
 
@@ -168,7 +167,7 @@ func (h *TelemetryHandler) ExportSpan(w http.ResponseWriter, r *http.Request) {
 func (h *TelemetryHandler) ExportMetric(w http.ResponseWriter, r *http.Request) {
 	var metric domain.TelemetryMetric
 	if err := json.NewDecoder(r.Body).Decode(&metric); err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_json", "Invalid request body")
+		writeDecodeError(w, err)
 		return
 	}
 
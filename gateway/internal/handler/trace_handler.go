@@ -2,37 +2,43 @@ package handler
 
 import (
 	"net/http"
+	"sort"
 	"strconv"
 	"time"
 
+	"github.com/akz4ol/gatewayops/gateway/internal/response"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"github.com/akz4ol/gatewayops/gateway/internal/approval"
 	"github.com/akz4ol/gatewayops/gateway/internal/domain"
 	"github.com/akz4ol/gatewayops/gateway/internal/middleware"
 	"github.com/akz4ol/gatewayops/gateway/internal/repository"
+	"github.com/akz4ol/gatewayops/gateway/internal/safety"
 	"github.com/rs/zerolog"
 )
 
+// traceTimelineLimit caps how many records of each kind a trace timeline holds.
+const traceTimelineLimit = 500
+
 // TraceHandler handles trace-related HTTP requests.
 type TraceHandler struct {
-	logger   zerolog.Logger
-	repo     *repository.TraceRepository
-	demoMode bool
+	logger    zerolog.Logger
+	repo      *repository.TraceRepository
+	detector  *safety.Detector
+	approvals *approval.Service
+	demoMode  bool
 }
 
-// NewTraceHandler creates a new trace handler.
-func NewTraceHandler(logger zerolog.Logger, repo *repository.TraceRepository, demoMode bool) *TraceHandler {
-	return &TraceHandler{logger: logger, repo: repo, demoMode: demoMode}
+// NewTraceHandler creates a new trace handler. Detections and approvals
+// carrying a trace's ID are read from detector and approvals to build its
+// timeline.
+func NewTraceHandler(logger zerolog.Logger, repo *repository.TraceRepository, detector *safety.Detector, approvals *approval.Service, demoMode bool) *TraceHandler {
+	return &TraceHandler{logger: logger, repo: repo, detector: detector, approvals: approvals, demoMode: demoMode}
 }
 
 // List returns a list of traces for the authenticated organization.
 func (h *TraceHandler) List(w http.ResponseWriter, r *http.Request) {
-	authInfo := middleware.GetAuthInfo(r.Context())
-	// Use demo org ID if not authenticated
-	orgID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
-	if authInfo != nil {
-		orgID = authInfo.OrgID
-	}
+	orgID := middleware.GetOrgID(r.Context())
 
 	// Parse query parameters
 	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
@@ -56,7 +62,7 @@ func (h *TraceHandler) List(w http.ResponseWriter, r *http.Request) {
 		traces, total, err := h.repo.List(r.Context(), filter)
 		if err != nil {
 			h.logger.Error().Err(err).Msg("Failed to list traces")
-			WriteError(w, http.StatusInternalServerError, "internal_error", "Failed to list traces")
+			WriteError(w, http.StatusInternalServerError, response.CodeInternalError, "Failed to list traces")
 			return
 		}
 
@@ -93,50 +99,103 @@ func (h *TraceHandler) List(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// Get returns a single trace by ID.
+// Get returns a single trace by ID, with a timeline of the MCP calls,
+// safety detections and tool approvals recorded under it, oldest first.
 func (h *TraceHandler) Get(w http.ResponseWriter, r *http.Request) {
-	authInfo := middleware.GetAuthInfo(r.Context())
-	orgID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
-	if authInfo != nil {
-		orgID = authInfo.OrgID
-	}
+	orgID := middleware.GetOrgID(r.Context())
 
 	traceID := chi.URLParam(r, "traceID")
 	if traceID == "" {
-		WriteError(w, http.StatusBadRequest, "invalid_request", "Trace ID is required")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidRequest, "Trace ID is required")
 		return
 	}
 
+	var detail *domain.TraceDetail
+	var calls []domain.Trace
+
 	// Query from database if repository is available
 	if h.repo != nil {
-		detail, err := h.repo.GetByTraceID(r.Context(), orgID, traceID)
+		var err error
+		detail, err = h.repo.GetByTraceID(r.Context(), orgID, traceID)
+		if err == nil && detail != nil {
+			filter := domain.TraceFilter{OrgID: orgID, TraceID: traceID, Limit: traceTimelineLimit}
+			calls, _, err = h.repo.List(r.Context(), filter)
+		}
 		if err != nil {
 			h.logger.Error().Err(err).Str("trace_id", traceID).Msg("Failed to get trace")
-			WriteError(w, http.StatusInternalServerError, "internal_error", "Failed to get trace")
+			WriteError(w, http.StatusInternalServerError, response.CodeInternalError, "Failed to get trace")
 			return
 		}
-		if detail == nil {
-			WriteError(w, http.StatusNotFound, "not_found", "Trace not found")
+	} else {
+		// Fallback to sample data
+		sample := generateSampleTraceDetail(traceID, orgID)
+		detail = &sample
+		calls = []domain.Trace{sample.Trace}
+	}
+
+	timeline := h.timeline(orgID, traceID, calls)
+	if detail == nil {
+		if len(timeline) == 0 {
+			WriteError(w, http.StatusNotFound, response.CodeNotFound, "Trace not found")
 			return
 		}
+		// No call under this trace reached an MCP server, e.g. because a
+		// detection blocked it or it is waiting on approval
+		detail = &domain.TraceDetail{
+			Trace: domain.Trace{TraceID: traceID, OrgID: orgID},
+			Spans: []domain.TraceSpan{},
+		}
+	}
+	detail.Timeline = timeline
 
-		WriteJSON(w, http.StatusOK, detail)
-		return
+	WriteJSON(w, http.StatusOK, detail)
+}
+
+// timeline merges the calls, detections and approvals recorded under a trace
+// ID into one list ordered by when each was recorded.
+func (h *TraceHandler) timeline(orgID uuid.UUID, traceID string, calls []domain.Trace) []domain.TraceEvent {
+	events := make([]domain.TraceEvent, 0, len(calls))
+	for i := range calls {
+		events = append(events, domain.TraceEvent{
+			Type:   domain.TraceEventCall,
+			Time:   calls[i].CreatedAt,
+			SpanID: calls[i].SpanID,
+			Call:   &calls[i],
+		})
 	}
 
-	// Fallback to sample data
-	detail := generateSampleTraceDetail(traceID, orgID)
+	if h.detector != nil {
+		detections := h.detector.GetDetections(domain.DetectionFilter{OrgID: orgID, TraceID: traceID, Limit: traceTimelineLimit}).Detections
+		for i := range detections {
+			events = append(events, domain.TraceEvent{
+				Type:      domain.TraceEventDetection,
+				Time:      detections[i].CreatedAt,
+				SpanID:    detections[i].SpanID,
+				Detection: &detections[i],
+			})
+		}
+	}
 
-	WriteJSON(w, http.StatusOK, detail)
+	if h.approvals != nil {
+		approvals := h.approvals.ListApprovals(domain.ToolApprovalFilter{OrgID: orgID, TraceID: traceID, Limit: traceTimelineLimit}).Approvals
+		for i := range approvals {
+			events = append(events, domain.TraceEvent{
+				Type:     domain.TraceEventApproval,
+				Time:     approvals[i].RequestedAt,
+				Approval: &approvals[i],
+			})
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.Before(events[j].Time)
+	})
+	return events
 }
 
 // Stats returns aggregated trace statistics.
 func (h *TraceHandler) Stats(w http.ResponseWriter, r *http.Request) {
-	authInfo := middleware.GetAuthInfo(r.Context())
-	orgID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
-	if authInfo != nil {
-		orgID = authInfo.OrgID
-	}
+	orgID := middleware.GetOrgID(r.Context())
 
 	// Query from database if repository is available
 	if h.repo != nil {
@@ -144,7 +203,7 @@ func (h *TraceHandler) Stats(w http.ResponseWriter, r *http.Request) {
 		stats, err := h.repo.Stats(r.Context(), filter)
 		if err != nil {
 			h.logger.Error().Err(err).Msg("Failed to get trace stats")
-			WriteError(w, http.StatusInternalServerError, "internal_error", "Failed to get stats")
+			WriteError(w, http.StatusInternalServerError, response.CodeInternalError, "Failed to get stats")
 			return
 		}
 
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/response"
+)
+
+// maxUpstreamMessage bounds the length of an MCP server's error message
+// passed on to callers.
+const maxUpstreamMessage = 1024
+
+// upstreamURLPattern matches URLs in MCP server error messages, which may
+// name internal hosts.
+var upstreamURLPattern = regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]*://[^\s"'<>]+`)
+
+// upstreamError describes an error response from an MCP server.
+type upstreamError struct {
+	Status  int    `json:"upstream_status"`
+	Message string `json:"upstream_message,omitempty"`
+}
+
+// parseUpstreamError reads the message from an MCP server's error response.
+// JSON bodies may carry it as error.message, error or message; any other
+// body is taken as the message. URLs are redacted.
+func parseUpstreamError(status int, body []byte) upstreamError {
+	message := string(body)
+	var parsed struct {
+		Error   json.RawMessage `json:"error"`
+		Message string          `json:"message"`
+	}
+	if json.Unmarshal(body, &parsed) == nil {
+		var nested struct {
+			Message string `json:"message"`
+		}
+		var text string
+		switch {
+		case json.Unmarshal(parsed.Error, &nested) == nil && nested.Message != "":
+			message = nested.Message
+		case json.Unmarshal(parsed.Error, &text) == nil && text != "":
+			message = text
+		case parsed.Message != "":
+			message = parsed.Message
+		}
+	}
+
+	message = strings.TrimSpace(upstreamURLPattern.ReplaceAllString(message, "[redacted URL]"))
+	if len(message) > maxUpstreamMessage {
+		cut := maxUpstreamMessage
+		for cut > 0 && !utf8.RuneStart(message[cut]) {
+			cut--
+		}
+		message = message[:cut] + "…"
+	}
+	return upstreamError{Status: status, Message: message}
+}
+
+// summary describes the error for callers of the gateway.
+func (e upstreamError) summary() string {
+	if e.Message == "" {
+		return fmt.Sprintf("MCP server returned HTTP %d", e.Status)
+	}
+	return fmt.Sprintf("MCP server returned HTTP %d: %s", e.Status, e.Message)
+}
+
+// upstreamErrorStatus returns the status and code the gateway answers with
+// for an MCP server's error status. Client errors keep their status so
+// callers can correct the request. The server refusing the gateway's own
+// credentials, timeouts and server errors are reported as gateway errors.
+func upstreamErrorStatus(status int) (int, response.ErrorCode) {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden || status == http.StatusProxyAuthRequired:
+		return http.StatusBadGateway, response.CodeUpstreamError
+	case status == http.StatusRequestTimeout || status == http.StatusGatewayTimeout:
+		return http.StatusGatewayTimeout, response.CodeUpstreamError
+	case status >= 500:
+		return http.StatusBadGateway, response.CodeUpstreamError
+	default:
+		return status, response.CodeUpstreamRejected
+	}
+}
+
+// writeUpstreamError answers for an MCP server's error response.
+func writeUpstreamError(w http.ResponseWriter, upstream upstreamError) {
+	status, code := upstreamErrorStatus(upstream.Status)
+	response.WriteErrorDetails(w, status, code, upstream.summary(), upstream)
+}
+
+// upstreamFailure returns the MCP server error behind a failed tool call,
+// given either the proxy's error response or the server's own.
+func upstreamFailure(status int, body []byte) (upstreamError, bool) {
+	var errResp struct {
+		Error struct {
+			Code    response.ErrorCode `json:"code"`
+			Details json.RawMessage    `json:"details"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &errResp) == nil && errResp.Error.Code != "" {
+		if errResp.Error.Code != response.CodeUpstreamRejected && errResp.Error.Code != response.CodeUpstreamError {
+			return upstreamError{}, false
+		}
+		var upstream upstreamError
+		if json.Unmarshal(errResp.Error.Details, &upstream) != nil || upstream.Status == 0 {
+			return upstreamError{}, false
+		}
+		return upstream, true
+	}
+	return parseUpstreamError(status, body), true
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/response"
+)
+
+// mockToolServer answers tools/call like test/mock-mcp, which rejects tools
+// it does not know with a plain-text 400.
+func mockToolServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req MCPRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		switch req.Tool {
+		case "read_file":
+			WriteJSON(w, http.StatusOK, map[string]any{"content": []map[string]string{{"type": "text", "text": "contents"}}})
+		case "crash":
+			http.Error(w, "panic at http://10.0.0.7:9000/internal/handler", http.StatusInternalServerError)
+		default:
+			http.Error(w, fmt.Sprintf("Unknown tool: %s", req.Tool), http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestUpstreamErrorsAreSurfaced(t *testing.T) {
+	srv := mockToolServer(t)
+	h := newPromptTestHandler(srv.URL, false)
+
+	tests := []struct {
+		tool     string
+		status   int
+		code     response.ErrorCode
+		upstream upstreamError
+	}{
+		{"nonexistent_tool", http.StatusBadRequest, response.CodeUpstreamRejected, upstreamError{http.StatusBadRequest, "Unknown tool: nonexistent_tool"}},
+		{"crash", http.StatusBadGateway, response.CodeUpstreamError, upstreamError{http.StatusInternalServerError, "panic at [redacted URL]"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tool, func(t *testing.T) {
+			rec := serveMCP(h.ToolsCall, http.MethodPost, "/v1/mcp/code/tools/call", `{"tool":"`+tt.tool+`","arguments":{}}`)
+			if rec.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			var body struct {
+				Error struct {
+					Code    response.ErrorCode `json:"code"`
+					Message string             `json:"message"`
+					Details upstreamError      `json:"details"`
+				} `json:"error"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if body.Error.Code != tt.code || body.Error.Details != tt.upstream || body.Error.Message != tt.upstream.summary() {
+				t.Errorf("error = %+v, want %s with %+v", body.Error, tt.code, tt.upstream)
+			}
+			if strings.Contains(rec.Body.String(), srv.URL) || strings.Contains(rec.Body.String(), "10.0.0.7") {
+				t.Errorf("response leaks an upstream URL: %s", rec.Body)
+			}
+		})
+	}
+}
+
+func TestParseUpstreamError(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"plain text", "Unknown tool: x\n", "Unknown tool: x"},
+		{"nested error", `{"error":{"code":"bad","message":"path is required"}}`, "path is required"},
+		{"error string", `{"error":"quota exhausted"}`, "quota exhausted"},
+		{"message", `{"message":"not allowed"}`, "not allowed"},
+		{"empty", "", ""},
+		{"URL", "cannot reach https://db.internal:5432/x", "cannot reach [redacted URL]"},
+		{"too long", strings.Repeat("é", maxUpstreamMessage), strings.Repeat("é", maxUpstreamMessage/2) + "…"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseUpstreamError(http.StatusBadRequest, []byte(tt.body)); got.Message != tt.want || got.Status != http.StatusBadRequest {
+				t.Errorf("parsed %+v, want message %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -7,8 +7,10 @@ import (
 	"sync"
 	"time"
 
+	"github.com/akz4ol/gatewayops/gateway/internal/middleware"
 	"github.com/akz4ol/gatewayops/gateway/internal/rbac"
 	"github.com/akz4ol/gatewayops/gateway/internal/repository"
+	"github.com/akz4ol/gatewayops/gateway/internal/response"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
@@ -17,7 +19,7 @@ import (
 // UserHandler handles user-related HTTP requests.
 type UserHandler struct {
 	logger      zerolog.Logger
-	userRepo    *repository.UserRepository
+	userRepo    repository.UserStore
 	rbacService *rbac.Service
 	invites     map[uuid.UUID]*Invite
 	mu          sync.RWMutex
@@ -37,7 +39,7 @@ type Invite struct {
 }
 
 // NewUserHandler creates a new user handler.
-func NewUserHandler(logger zerolog.Logger, userRepo *repository.UserRepository, rbacService *rbac.Service) *UserHandler {
+func NewUserHandler(logger zerolog.Logger, userRepo repository.UserStore, rbacService *rbac.Service) *UserHandler {
 	h := &UserHandler{
 		logger:      logger,
 		userRepo:    userRepo,
@@ -48,10 +50,10 @@ func NewUserHandler(logger zerolog.Logger, userRepo *repository.UserRepository,
 	// Add demo invite
 	demoInvite := &Invite{
 		ID:          uuid.MustParse("00000000-0000-0000-0000-000000000010"),
-		OrgID:       uuid.MustParse("00000000-0000-0000-0000-000000000001"),
+		OrgID:       middleware.DemoOrgID,
 		Email:       "alex@acme.com",
 		Role:        "developer",
-		InvitedBy:   uuid.MustParse("00000000-0000-0000-0000-000000000001"),
+		InvitedBy:   middleware.DemoUserID,
 		InviterName: "Sarah Chen",
 		Status:      "pending",
 		CreatedAt:   time.Now().Add(-48 * time.Hour),
@@ -76,8 +78,7 @@ type UserResponse struct {
 
 // ListUsers returns all users in the organization.
 func (h *UserHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
-	// Demo org
-	orgID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	orgID := middleware.GetOrgID(r.Context())
 
 	// Parse pagination
 	limit := 50
@@ -96,17 +97,22 @@ func (h *UserHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
 	// Try to get from database
 	ctx := r.Context()
 	users, total, err := h.userRepo.ListUsersByOrg(ctx, orgID, limit, offset)
+	if err != nil && orgID != middleware.DemoOrgID {
+		h.logger.Error().Err(err).Msg("Failed to list users")
+		WriteError(w, http.StatusInternalServerError, response.CodeDBError, "Failed to list users")
+		return
+	}
 
-	var response []UserResponse
+	response := make([]UserResponse, 0, len(users))
 
-	if err != nil || len(users) == 0 {
+	if (err != nil || len(users) == 0) && orgID == middleware.DemoOrgID {
 		// Return demo users if database is empty or fails
 		response = h.getDemoUsers()
 		total = int64(len(response))
 	} else {
 		// Convert to response format with roles
 		for _, user := range users {
-			role := h.getUserRole(user.ID)
+			role := h.getUserRole(orgID, user.ID)
 			response = append(response, UserResponse{
 				ID:           user.ID,
 				Email:        user.Email,
@@ -133,7 +139,7 @@ func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "userID")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid user ID")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid user ID")
 		return
 	}
 
@@ -141,15 +147,15 @@ func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 	user, err := h.userRepo.GetUser(ctx, id)
 	if err != nil {
 		h.logger.Error().Err(err).Msg("Failed to get user")
-		WriteError(w, http.StatusInternalServerError, "db_error", "Failed to get user")
+		WriteError(w, http.StatusInternalServerError, response.CodeDBError, "Failed to get user")
 		return
 	}
-	if user == nil {
-		WriteError(w, http.StatusNotFound, "not_found", "User not found")
+	if user == nil || user.OrgID != middleware.GetOrgID(ctx) {
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "User not found")
 		return
 	}
 
-	role := h.getUserRole(user.ID)
+	role := h.getUserRole(user.OrgID, user.ID)
 	response := UserResponse{
 		ID:           user.ID,
 		Email:        user.Email,
@@ -174,21 +180,20 @@ type InviteInput struct {
 func (h *UserHandler) CreateInvite(w http.ResponseWriter, r *http.Request) {
 	var input InviteInput
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_json", "Invalid request body")
+		writeDecodeError(w, err)
 		return
 	}
 
 	if input.Email == "" {
-		WriteError(w, http.StatusBadRequest, "validation_error", "Email is required")
+		WriteFieldError(w, "email", "Email is required")
 		return
 	}
 	if input.Role == "" {
 		input.Role = "developer" // Default role
 	}
 
-	// Demo org and inviter
-	orgID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
-	inviterID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	orgID := middleware.GetOrgID(r.Context())
+	inviterID := middleware.GetUserID(r.Context())
 
 	invite := &Invite{
 		ID:          uuid.New(),
@@ -220,9 +225,10 @@ func (h *UserHandler) ListInvites(w http.ResponseWriter, r *http.Request) {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 
+	orgID := middleware.GetOrgID(r.Context())
 	var invites []*Invite
 	for _, inv := range h.invites {
-		if inv.Status == "pending" {
+		if inv.Status == "pending" && inv.OrgID == orgID {
 			invites = append(invites, inv)
 		}
 	}
@@ -238,7 +244,7 @@ func (h *UserHandler) CancelInvite(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "inviteID")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid invite ID")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid invite ID")
 		return
 	}
 
@@ -246,8 +252,8 @@ func (h *UserHandler) CancelInvite(w http.ResponseWriter, r *http.Request) {
 	defer h.mu.Unlock()
 
 	invite, ok := h.invites[id]
-	if !ok {
-		WriteError(w, http.StatusNotFound, "not_found", "Invite not found")
+	if !ok || invite.OrgID != middleware.GetOrgID(r.Context()) {
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Invite not found")
 		return
 	}
 
@@ -266,7 +272,7 @@ func (h *UserHandler) ResendInvite(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "inviteID")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid invite ID")
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidID, "Invalid invite ID")
 		return
 	}
 
@@ -274,8 +280,8 @@ func (h *UserHandler) ResendInvite(w http.ResponseWriter, r *http.Request) {
 	defer h.mu.Unlock()
 
 	invite, ok := h.invites[id]
-	if !ok {
-		WriteError(w, http.StatusNotFound, "not_found", "Invite not found")
+	if !ok || invite.OrgID != middleware.GetOrgID(r.Context()) {
+		WriteError(w, http.StatusNotFound, response.CodeNotFound, "Invite not found")
 		return
 	}
 
@@ -294,7 +300,7 @@ func (h *UserHandler) ResendInvite(w http.ResponseWriter, r *http.Request) {
 }
 
 // Helper to get user's primary role name
-func (h *UserHandler) getUserRole(userID uuid.UUID) string {
+func (h *UserHandler) getUserRole(orgID, userID uuid.UUID) string {
 	if h.rbacService == nil {
 		return "developer"
 	}
@@ -304,7 +310,7 @@ func (h *UserHandler) getUserRole(userID uuid.UUID) string {
 		return "developer"
 	}
 
-	role := h.rbacService.GetRole(assignments[0].RoleID)
+	role := h.rbacService.GetRole(orgID, assignments[0].RoleID)
 	if role == nil {
 		return "developer"
 	}
@@ -0,0 +1,316 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/response"
+)
+
+// Request inputs declare their constraints in validate struct tags, checked
+// after the body is decoded. Rules are comma-separated:
+//
+//	required     the field must be present and non-empty
+//	oneof=a b c  the value must be one of the listed values
+//	min=N max=N  bounds on a number, or on the length of a string or list
+//	url          an http or https URL with a host
+//	email        an email address
+//
+// Rules other than required only apply to non-empty values.
+
+// decodeInput decodes a JSON request body into input, rejecting unknown and
+// wrongly-typed fields, and checks input's validate tags. It writes a 400
+// response listing the offending fields and returns false if the body is
+// invalid.
+func decodeInput(w http.ResponseWriter, r *http.Request, input interface{}) bool {
+	if err := decodeStrict(r, input); err != nil {
+		writeDecodeError(w, err)
+		return false
+	}
+	return checkInput(w, input, false)
+}
+
+// decodePartialInput is decodeInput for partial updates, where empty fields
+// keep their current value: required fields may be left out.
+func decodePartialInput(w http.ResponseWriter, r *http.Request, input interface{}) bool {
+	if err := decodeStrict(r, input); err != nil {
+		writeDecodeError(w, err)
+		return false
+	}
+	return checkInput(w, input, true)
+}
+
+// decodeStrict decodes a JSON request body into input, rejecting fields that
+// input does not have. Bodies are limited in size by middleware.MaxBodySize;
+// reading past the limit fails with *http.MaxBytesError.
+func decodeStrict(r *http.Request, input interface{}) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(input)
+}
+
+// readBody reads a request body that is passed on rather than decoded,
+// writing an error response and returning false if it cannot be read.
+func readBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeBodyTooLarge(w, tooLarge.Limit)
+			return nil, false
+		}
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidBody, "Failed to read request body")
+		return nil, false
+	}
+	return body, true
+}
+
+// writeDecodeError writes the response for an error from decoding a JSON
+// request body: 413 for a body over the size limit, and otherwise 400 saying
+// whether the body is missing, is not valid JSON, or has a field that is
+// unknown or of the wrong type.
+func writeDecodeError(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeBodyTooLarge(w, tooLarge.Limit)
+		return
+	}
+	if errors.Is(err, io.EOF) {
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidBody, "Request body is required")
+		return
+	}
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidJSON, fmt.Sprintf("Request body is not valid JSON: %s at byte %d", syntaxErr, syntaxErr.Offset))
+		return
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		WriteError(w, http.StatusBadRequest, response.CodeInvalidJSON, "Request body is not valid JSON: unexpected end of input")
+		return
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		if typeErr.Field == "" {
+			WriteError(w, http.StatusBadRequest, response.CodeInvalidBody, fmt.Sprintf("Request body must be %s", jsonTypeName(typeErr.Type)))
+			return
+		}
+		WriteFieldError(w, typeErr.Field, fmt.Sprintf("%s must be %s", typeErr.Field, jsonTypeName(typeErr.Type)))
+		return
+	}
+	// The json package reports unknown fields only in the error text
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		field, _ = strconv.Unquote(field)
+		WriteFieldError(w, field, fmt.Sprintf("%s is not a known field", field))
+		return
+	}
+	WriteError(w, http.StatusBadRequest, response.CodeInvalidJSON, "Invalid request body")
+}
+
+// writeBodyTooLarge writes the 413 response for a body over limit bytes.
+func writeBodyTooLarge(w http.ResponseWriter, limit int64) {
+	WriteError(w, http.StatusRequestEntityTooLarge, response.CodeBodyTooLarge, fmt.Sprintf("Request body must not be larger than %d bytes", limit))
+}
+
+// jsonTypeName describes the JSON value expected for a Go type.
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	default:
+		return "an object"
+	}
+}
+
+// checkInput checks input's validate tags, writing a 400 response and
+// returning false if any fail.
+func checkInput(w http.ResponseWriter, input interface{}, partial bool) bool {
+	fields := validateInput(input, partial)
+	if len(fields) == 0 {
+		return true
+	}
+	message := "One or more fields are invalid"
+	if len(fields) == 1 {
+		message = fields[0].Message
+	}
+	WriteValidationError(w, message, fields)
+	return false
+}
+
+// validateInput returns the fields of input that fail their validate tags,
+// named by their JSON path. With partial set, required rules are skipped.
+func validateInput(input interface{}, partial bool) []FieldError {
+	var fields []FieldError
+	validateValue(reflect.ValueOf(input), "", partial, &fields)
+	return fields
+}
+
+func validateValue(v reflect.Value, prefix string, partial bool, fields *[]FieldError) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name := jsonFieldName(f)
+			if name == "-" {
+				continue
+			}
+			path := prefix + name
+			value := v.Field(i)
+			if msg := checkRules(value, f.Tag.Get("validate"), partial); msg != "" {
+				*fields = append(*fields, FieldError{Field: path, Message: path + " " + msg})
+				continue
+			}
+			validateValue(value, path+".", partial, fields)
+		}
+	case reflect.Slice:
+		// Only lists of structs carry nested tags
+		elem := v.Type().Elem()
+		if elem.Kind() == reflect.Ptr {
+			elem = elem.Elem()
+		}
+		if elem.Kind() != reflect.Struct {
+			return
+		}
+		prefix = strings.TrimSuffix(prefix, ".")
+		for i := 0; i < v.Len(); i++ {
+			validateValue(v.Index(i), fmt.Sprintf("%s[%d].", prefix, i), partial, fields)
+		}
+	}
+}
+
+// jsonFieldName returns the name a struct field is decoded from.
+func jsonFieldName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
+
+// checkRules applies a validate tag to a value, returning what is wrong with
+// it or "" if it passes.
+func checkRules(v reflect.Value, tag string, partial bool) string {
+	if tag == "" {
+		return ""
+	}
+	empty := isEmpty(v)
+	for _, rule := range strings.Split(tag, ",") {
+		name, arg, _ := strings.Cut(rule, "=")
+		if name == "required" {
+			if empty && !partial {
+				return "is required"
+			}
+			continue
+		}
+		if empty {
+			continue
+		}
+		switch name {
+		case "oneof":
+			allowed := strings.Fields(arg)
+			value := fmt.Sprint(v.Interface())
+			found := false
+			for _, a := range allowed {
+				if a == value {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return "must be one of " + strings.Join(allowed, ", ")
+			}
+		case "min", "max":
+			limit, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				panic(fmt.Sprintf("validate: bad %s bound %q", name, arg))
+			}
+			if msg := checkBound(v, name, limit); msg != "" {
+				return msg
+			}
+		case "url":
+			u, err := url.Parse(v.String())
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return "must be an http or https URL"
+			}
+		case "email":
+			if _, err := mail.ParseAddress(v.String()); err != nil {
+				return "must be an email address"
+			}
+		default:
+			panic(fmt.Sprintf("validate: unknown rule %q", name))
+		}
+	}
+	return ""
+}
+
+// checkBound checks a min or max rule against a number, or the length of a
+// string or list.
+func checkBound(v reflect.Value, rule string, limit float64) string {
+	var n float64
+	unit := ""
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n = float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		n = v.Float()
+	case reflect.String:
+		n, unit = float64(len(v.String())), " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		n, unit = float64(v.Len()), " items"
+	default:
+		return ""
+	}
+	bound := strconv.FormatFloat(limit, 'f', -1, 64)
+	if rule == "min" && n < limit {
+		if unit != "" {
+			return "must have at least " + bound + unit
+		}
+		return "must be at least " + bound
+	}
+	if rule == "max" && n > limit {
+		if unit != "" {
+			return "must have at most " + bound + unit
+		}
+		return "must be at most " + bound
+	}
+	return ""
+}
+
+// isEmpty reports whether a value is absent: zero, or an empty list or map.
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
+}
@@ -0,0 +1,229 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/alerting"
+	"github.com/akz4ol/gatewayops/gateway/internal/clock"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/middleware"
+	"github.com/akz4ol/gatewayops/gateway/internal/response"
+	"github.com/rs/zerolog"
+)
+
+func TestWriteBodiesAreValidatedStrictly(t *testing.T) {
+	alerts := alerting.NewService(zerolog.Nop(), nil, nil, 100, clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)))
+	h := NewAlertHandler(zerolog.Nop(), alerts, nil)
+	seeded := len(alerts.ListRules(middleware.DemoOrgID, false))
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		body    string
+		code    response.ErrorCode
+		message string
+		fields  []string
+	}{
+		{
+			name:    "unknown field",
+			handler: h.CreateRule,
+			body:    `{"name":"Errors","metric":"error_rate","condition":"gt","threshold":5,"severity":"warning","treshold":5}`,
+			code:    response.CodeValidationError,
+			message: "treshold is not a known field",
+			fields:  []string{"treshold"},
+		},
+		{
+			name:    "missing required field",
+			handler: h.CreateRule,
+			body:    `{"metric":"error_rate","condition":"gt","threshold":5,"severity":"warning"}`,
+			code:    response.CodeValidationError,
+			message: "name is required",
+			fields:  []string{"name"},
+		},
+		{
+			name:    "wrong type",
+			handler: h.CreateRule,
+			body:    `{"name":"Errors","metric":"error_rate","condition":"gt","threshold":"high","severity":"warning"}`,
+			code:    response.CodeValidationError,
+			message: "threshold must be a number",
+			fields:  []string{"threshold"},
+		},
+		{
+			name:    "several invalid fields",
+			handler: h.CreateChannel,
+			body:    `{"type":"carrier_pigeon","config":{}}`,
+			code:    response.CodeValidationError,
+			message: "One or more fields are invalid",
+			fields:  []string{"name", "type", "config"},
+		},
+		{
+			name:    "malformed JSON",
+			handler: h.CreateChannel,
+			body:    `{"name":`,
+			code:    response.CodeInvalidJSON,
+			message: "Request body is not valid JSON: unexpected end of input",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tt.handler(rec, asKey(httptest.NewRequest(http.MethodPost, "/v1/alerts", strings.NewReader(tt.body)), domain.PermissionAlertsAdmin))
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("status %d, want 400: %s", rec.Code, rec.Body)
+			}
+			var resp struct {
+				Error struct {
+					Code    response.ErrorCode    `json:"code"`
+					Message string                `json:"message"`
+					Details []response.FieldError `json:"details"`
+				} `json:"error"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if resp.Error.Code != tt.code || resp.Error.Message != tt.message {
+				t.Errorf("error = %s %q, want %s %q", resp.Error.Code, resp.Error.Message, tt.code, tt.message)
+			}
+			var fields []string
+			for _, d := range resp.Error.Details {
+				fields = append(fields, d.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.fields) {
+				t.Errorf("fields = %v, want %v", fields, tt.fields)
+			}
+		})
+	}
+	if rules := alerts.ListRules(middleware.DemoOrgID, false); len(rules) != seeded {
+		t.Errorf("invalid bodies created %d rules", len(rules)-seeded)
+	}
+}
+
+func TestValidateInputRules(t *testing.T) {
+	type item struct {
+		Label string `json:"label" validate:"required"`
+	}
+	type input struct {
+		Name    string  `json:"name" validate:"required,max=5"`
+		Kind    string  `json:"kind" validate:"oneof=a b"`
+		Count   int     `json:"count" validate:"min=1"`
+		Site    string  `json:"site" validate:"url"`
+		Contact string  `json:"contact" validate:"email"`
+		Items   []item  `json:"items"`
+		Ignored string  `json:"-" validate:"required"`
+		Ratio   float64 `json:"ratio" validate:"max=1"`
+	}
+
+	valid := input{Name: "ok", Kind: "a", Count: 2, Site: "https://example.com", Contact: "ops@example.com", Items: []item{{Label: "x"}}, Ratio: 0.5}
+	if got := validateInput(&valid, false); len(got) != 0 {
+		t.Errorf("valid input failed: %+v", got)
+	}
+
+	invalid := input{Name: "too long", Kind: "c", Count: -1, Site: "ftp://example.com", Contact: "ops", Items: []item{{Label: "x"}, {}}, Ratio: 2}
+	want := []FieldError{
+		{Field: "name", Message: "name must have at most 5 characters"},
+		{Field: "kind", Message: "kind must be one of a, b"},
+		{Field: "count", Message: "count must be at least 1"},
+		{Field: "site", Message: "site must be an http or https URL"},
+		{Field: "contact", Message: "contact must be an email address"},
+		{Field: "items[1].label", Message: "items[1].label is required"},
+		{Field: "ratio", Message: "ratio must be at most 1"},
+	}
+	if got := validateInput(&invalid, false); !reflect.DeepEqual(got, want) {
+		t.Errorf("fields = %+v\nwant %+v", got, want)
+	}
+
+	// A partial update may leave required fields out, but not break other rules
+	if got := validateInput(&input{Kind: "b"}, true); len(got) != 0 {
+		t.Errorf("partial input failed: %+v", got)
+	}
+	if got := validateInput(&input{Kind: "c"}, true); len(got) != 1 || got[0].Field != "kind" {
+		t.Errorf("partial input = %+v, want kind rejected", got)
+	}
+}
+
+func TestOversizedAndMalformedBodies(t *testing.T) {
+	alerts := alerting.NewService(zerolog.Nop(), nil, nil, 100, clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)))
+	h := NewAlertHandler(zerolog.Nop(), alerts, nil)
+	limited := middleware.MaxBodySize(64)
+	passOn := func(w http.ResponseWriter, r *http.Request) {
+		if body, ok := readBody(w, r); ok {
+			w.Write(body)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		handler http.Handler
+		body    string
+		status  int
+		code    response.ErrorCode
+		message string
+	}{
+		{
+			name:    "decoded body too large",
+			handler: limited(http.HandlerFunc(h.CreateRule)),
+			body:    `{"name":"` + strings.Repeat("x", 100) + `"}`,
+			status:  http.StatusRequestEntityTooLarge,
+			code:    response.CodeBodyTooLarge,
+			message: "Request body must not be larger than 64 bytes",
+		},
+		{
+			name:    "passed-on body too large",
+			handler: limited(http.HandlerFunc(passOn)),
+			body:    strings.Repeat("x", 100),
+			status:  http.StatusRequestEntityTooLarge,
+			code:    response.CodeBodyTooLarge,
+			message: "Request body must not be larger than 64 bytes",
+		},
+		{
+			name:    "syntax error",
+			handler: limited(http.HandlerFunc(h.CreateRule)),
+			body:    `{"name":}`,
+			status:  http.StatusBadRequest,
+			code:    response.CodeInvalidJSON,
+			message: "Request body is not valid JSON: invalid character '}' looking for beginning of value at byte 9",
+		},
+		{
+			name:    "empty body",
+			handler: limited(http.HandlerFunc(h.CreateRule)),
+			status:  http.StatusBadRequest,
+			code:    response.CodeInvalidBody,
+			message: "Request body is required",
+		},
+		{
+			name:    "not an object",
+			handler: limited(http.HandlerFunc(h.CreateRule)),
+			body:    `["Errors"]`,
+			status:  http.StatusBadRequest,
+			code:    response.CodeInvalidBody,
+			message: "Request body must be an object",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tt.handler.ServeHTTP(rec, asKey(httptest.NewRequest(http.MethodPost, "/v1/alerts/rules", strings.NewReader(tt.body)), domain.PermissionAlertsAdmin))
+			if rec.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			var resp struct {
+				Error struct {
+					Code    response.ErrorCode `json:"code"`
+					Message string             `json:"message"`
+				} `json:"error"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if resp.Error.Code != tt.code || resp.Error.Message != tt.message {
+				t.Errorf("error = %s %q, want %s %q", resp.Error.Code, resp.Error.Message, tt.code, tt.message)
+			}
+		})
+	}
+}
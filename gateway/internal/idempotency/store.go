@@ -0,0 +1,149 @@
+// Package idempotency remembers the responses to requests made with an
+// Idempotency-Key so a retried request can be answered without repeating it.
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/database"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+)
+
+// pendingTTL bounds how long a key stays claimed by a request that never
+// completes, e.g. because the instance serving it died.
+const pendingTTL = time.Minute
+
+// Record is what is kept for an idempotency key: the fingerprint of the
+// request that claimed it and, once that request completes, its response.
+type Record struct {
+	Fingerprint string `json:"fingerprint"`
+	Pending     bool   `json:"pending,omitempty"` // The first request is still being served
+	Status      int    `json:"status,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// Store keeps idempotency records in Redis so every instance sees them;
+// without Redis they are kept in memory.
+type Store struct {
+	redis  *database.Redis
+	logger zerolog.Logger
+	ttl    time.Duration // How long a completed response is kept for replay
+
+	mu    sync.Mutex
+	local map[string]localRecord
+}
+
+type localRecord struct {
+	record    Record
+	expiresAt time.Time
+}
+
+// NewStore creates a store that keeps completed responses for ttl.
+func NewStore(redis *database.Redis, logger zerolog.Logger, ttl time.Duration) *Store {
+	return &Store{
+		redis:  redis,
+		logger: logger,
+		ttl:    ttl,
+		local:  make(map[string]localRecord),
+	}
+}
+
+func (s *Store) useRedis() bool {
+	return s.redis != nil && s.redis.Client != nil
+}
+
+// Begin claims key for a request with the given fingerprint. It returns nil
+// when the key was free and is now claimed; the caller must then Complete or
+// Release it. Otherwise it returns the record of the request that holds the
+// key.
+func (s *Store) Begin(ctx context.Context, key, fingerprint string) (*Record, error) {
+	pending := Record{Fingerprint: fingerprint, Pending: true}
+	if s.useRedis() {
+		return s.beginRedis(ctx, key, pending)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if existing, ok := s.local[key]; ok && now.Before(existing.expiresAt) {
+		record := existing.record
+		return &record, nil
+	}
+	// Forget expired records
+	for k, e := range s.local {
+		if !now.Before(e.expiresAt) {
+			delete(s.local, k)
+		}
+	}
+	s.local[key] = localRecord{record: pending, expiresAt: now.Add(pendingTTL)}
+	return nil, nil
+}
+
+func (s *Store) beginRedis(ctx context.Context, key string, pending Record) (*Record, error) {
+	raw, err := json.Marshal(pending)
+	if err != nil {
+		return nil, err
+	}
+	redisKey := "idempotency:" + key
+	// The holder may expire between the two calls; try once more
+	for attempt := 0; attempt < 2; attempt++ {
+		claimed, err := s.redis.SetNX(ctx, redisKey, raw, pendingTTL)
+		if err != nil {
+			return nil, err
+		}
+		if claimed {
+			return nil, nil
+		}
+		value, err := s.redis.Get(ctx, redisKey)
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var record Record
+		if err := json.Unmarshal([]byte(value), &record); err != nil {
+			return nil, err
+		}
+		return &record, nil
+	}
+	return nil, errors.New("idempotency key changed hands while being claimed")
+}
+
+// Complete stores the response to the request that claimed key, to be
+// replayed to its retries.
+func (s *Store) Complete(ctx context.Context, key string, record Record) error {
+	record.Pending = false
+	if s.useRedis() {
+		raw, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		return s.redis.Set(ctx, "idempotency:"+key, raw, s.ttl)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.local[key] = localRecord{record: record, expiresAt: time.Now().Add(s.ttl)}
+	return nil
+}
+
+// Release frees key without storing a response, so the request can be
+// retried in full.
+func (s *Store) Release(ctx context.Context, key string) error {
+	if s.useRedis() {
+		return s.redis.Del(ctx, "idempotency:"+key)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.local, key)
+	return nil
+}
@@ -0,0 +1,60 @@
+package idempotency
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func TestStoreClaimCompleteRelease(t *testing.T) {
+	ctx := context.Background()
+	s := NewStore(nil, zerolog.Nop(), time.Hour)
+
+	if held, err := s.Begin(ctx, "key", "fp-1"); err != nil || held != nil {
+		t.Fatalf("Begin on a free key = %+v, %v; want it claimed", held, err)
+	}
+	held, err := s.Begin(ctx, "key", "fp-1")
+	if err != nil || held == nil || !held.Pending || held.Fingerprint != "fp-1" {
+		t.Fatalf("Begin on a claimed key = %+v, %v; want the pending claim", held, err)
+	}
+
+	if err := s.Complete(ctx, "key", Record{Fingerprint: "fp-1", Pending: true, Status: 201, Body: []byte(`{"id":1}`)}); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	held, err = s.Begin(ctx, "key", "fp-2")
+	if err != nil || held == nil || held.Pending || held.Status != 201 || string(held.Body) != `{"id":1}` {
+		t.Fatalf("Begin on a completed key = %+v, %v; want the stored response", held, err)
+	}
+
+	if err := s.Release(ctx, "key"); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if held, err := s.Begin(ctx, "key", "fp-2"); err != nil || held != nil {
+		t.Errorf("Begin on a released key = %+v, %v; want it claimed", held, err)
+	}
+}
+
+func TestStoreForgetsExpiredRecords(t *testing.T) {
+	ctx := context.Background()
+	s := NewStore(nil, zerolog.Nop(), time.Hour)
+	s.Begin(ctx, "abandoned", "fp")
+	s.Begin(ctx, "completed", "fp")
+	s.Complete(ctx, "completed", Record{Fingerprint: "fp", Status: 200})
+
+	// Age both records past their expiry
+	for key, r := range s.local {
+		r.expiresAt = time.Now().Add(-time.Second)
+		s.local[key] = r
+	}
+	for _, key := range []string{"abandoned", "completed"} {
+		if held, err := s.Begin(ctx, key, "fp"); err != nil || held != nil {
+			t.Errorf("Begin on expired %s key = %+v, %v; want it claimed", key, held, err)
+		}
+	}
+	s.Begin(ctx, "other", "fp")
+	if len(s.local) != 3 {
+		t.Errorf("%d records kept, want the 3 live ones", len(s.local))
+	}
+}
@@ -0,0 +1,327 @@
+// Package jsonschema validates decoded JSON values against the subset of JSON
+// Schema that MCP servers use to describe tool inputs.
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Schema is a compiled JSON Schema. Keywords outside the supported subset
+// (type, enum, const, properties, required, additionalProperties, items,
+// min/maxItems, min/maxLength, pattern, minimum, maximum) are ignored.
+type Schema struct {
+	Types                []string
+	Enum                 []interface{}
+	Const                interface{}
+	HasConst             bool
+	Properties           map[string]*Schema
+	Required             []string
+	AdditionalProperties *bool
+	AdditionalSchema     *Schema
+	Items                *Schema
+	MinItems, MaxItems   *int
+	MinLength, MaxLength *int
+	Pattern              *regexp.Regexp
+	Minimum, Maximum     *float64
+}
+
+// Options controls how strictly values are validated.
+type Options struct {
+	// RejectUnknown rejects object properties the schema does not declare,
+	// even where the schema leaves additionalProperties unset. Objects whose
+	// schema declares no properties at all remain free-form.
+	RejectUnknown bool
+}
+
+// ValidationError describes the first violation found in a value.
+type ValidationError struct {
+	Path    string // Dotted location of the offending value; empty for the root
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// Compile parses a JSON Schema document.
+func Compile(raw []byte) (*Schema, error) {
+	var doc interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	return compile(doc)
+}
+
+func compile(doc interface{}) (*Schema, error) {
+	s := &Schema{}
+	switch doc := doc.(type) {
+	case bool:
+		if !doc {
+			// false matches nothing: no type can satisfy an empty enum
+			s.Enum = []interface{}{}
+		}
+		return s, nil
+	case map[string]interface{}:
+		switch t := doc["type"].(type) {
+		case string:
+			s.Types = []string{t}
+		case []interface{}:
+			for _, item := range t {
+				if name, ok := item.(string); ok {
+					s.Types = append(s.Types, name)
+				}
+			}
+		}
+		if enum, ok := doc["enum"].([]interface{}); ok {
+			s.Enum = enum
+		}
+		if c, ok := doc["const"]; ok {
+			s.Const, s.HasConst = c, true
+		}
+		if props, ok := doc["properties"].(map[string]interface{}); ok {
+			s.Properties = make(map[string]*Schema, len(props))
+			for name, prop := range props {
+				child, err := compile(prop)
+				if err != nil {
+					return nil, fmt.Errorf("properties.%s: %w", name, err)
+				}
+				s.Properties[name] = child
+			}
+		}
+		if required, ok := doc["required"].([]interface{}); ok {
+			for _, item := range required {
+				if name, ok := item.(string); ok {
+					s.Required = append(s.Required, name)
+				}
+			}
+		}
+		switch ap := doc["additionalProperties"].(type) {
+		case bool:
+			s.AdditionalProperties = &ap
+		case map[string]interface{}:
+			child, err := compile(ap)
+			if err != nil {
+				return nil, fmt.Errorf("additionalProperties: %w", err)
+			}
+			s.AdditionalSchema = child
+		}
+		if items, ok := doc["items"]; ok {
+			if _, isArray := items.([]interface{}); !isArray {
+				child, err := compile(items)
+				if err != nil {
+					return nil, fmt.Errorf("items: %w", err)
+				}
+				s.Items = child
+			}
+		}
+		s.MinItems = intKeyword(doc, "minItems")
+		s.MaxItems = intKeyword(doc, "maxItems")
+		s.MinLength = intKeyword(doc, "minLength")
+		s.MaxLength = intKeyword(doc, "maxLength")
+		s.Minimum = numberKeyword(doc, "minimum")
+		s.Maximum = numberKeyword(doc, "maximum")
+		if pattern, ok := doc["pattern"].(string); ok {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("pattern: %w", err)
+			}
+			s.Pattern = re
+		}
+		return s, nil
+	default:
+		return nil, fmt.Errorf("schema must be an object or boolean")
+	}
+}
+
+func intKeyword(doc map[string]interface{}, key string) *int {
+	if f, ok := doc[key].(float64); ok {
+		n := int(f)
+		return &n
+	}
+	return nil
+}
+
+func numberKeyword(doc map[string]interface{}, key string) *float64 {
+	if f, ok := doc[key].(float64); ok {
+		return &f
+	}
+	return nil
+}
+
+// Validate checks a value decoded with encoding/json against the schema and
+// returns the first violation as a *ValidationError.
+func (s *Schema) Validate(value interface{}, opts Options) error {
+	return s.validate(value, "", opts)
+}
+
+func (s *Schema) validate(value interface{}, path string, opts Options) error {
+	if len(s.Types) > 0 && !matchesAnyType(value, s.Types) {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("must be of type %s, got %s", strings.Join(s.Types, " or "), typeOf(value))}
+	}
+	if s.Enum != nil && !containsValue(s.Enum, value) {
+		return &ValidationError{Path: path, Message: "must be one of the allowed values"}
+	}
+	if s.HasConst && !equalValues(s.Const, value) {
+		return &ValidationError{Path: path, Message: "must equal the constant value"}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return s.validateObject(v, path, opts)
+	case []interface{}:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			return &ValidationError{Path: path, Message: fmt.Sprintf("must have at least %d items", *s.MinItems)}
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			return &ValidationError{Path: path, Message: fmt.Sprintf("must have at most %d items", *s.MaxItems)}
+		}
+		if s.Items != nil {
+			for i, item := range v {
+				if err := s.Items.validate(item, joinPath(path, fmt.Sprintf("%d", i)), opts); err != nil {
+					return err
+				}
+			}
+		}
+	case string:
+		length := len([]rune(v))
+		if s.MinLength != nil && length < *s.MinLength {
+			return &ValidationError{Path: path, Message: fmt.Sprintf("must be at least %d characters", *s.MinLength)}
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			return &ValidationError{Path: path, Message: fmt.Sprintf("must be at most %d characters", *s.MaxLength)}
+		}
+		if s.Pattern != nil && !s.Pattern.MatchString(v) {
+			return &ValidationError{Path: path, Message: fmt.Sprintf("must match pattern %q", s.Pattern.String())}
+		}
+	case float64:
+		if s.Minimum != nil && v < *s.Minimum {
+			return &ValidationError{Path: path, Message: fmt.Sprintf("must be >= %v", *s.Minimum)}
+		}
+		if s.Maximum != nil && v > *s.Maximum {
+			return &ValidationError{Path: path, Message: fmt.Sprintf("must be <= %v", *s.Maximum)}
+		}
+	}
+	return nil
+}
+
+func (s *Schema) validateObject(obj map[string]interface{}, path string, opts Options) error {
+	for _, name := range s.Required {
+		if _, ok := obj[name]; !ok {
+			return &ValidationError{Path: joinPath(path, name), Message: fmt.Sprintf("missing required property %q", name)}
+		}
+	}
+
+	// Visit properties in a stable order so the reported violation is deterministic
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		childPath := joinPath(path, name)
+		if prop, ok := s.Properties[name]; ok {
+			if err := prop.validate(obj[name], childPath, opts); err != nil {
+				return err
+			}
+			continue
+		}
+		switch {
+		case s.AdditionalSchema != nil:
+			if err := s.AdditionalSchema.validate(obj[name], childPath, opts); err != nil {
+				return err
+			}
+		case s.AdditionalProperties != nil && !*s.AdditionalProperties,
+			s.AdditionalProperties == nil && s.Properties != nil && opts.RejectUnknown:
+			return &ValidationError{Path: childPath, Message: fmt.Sprintf("unknown property %q", name)}
+		}
+	}
+	return nil
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func matchesAnyType(value interface{}, types []string) bool {
+	for _, t := range types {
+		if matchesType(value, t) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesType(value interface{}, t string) bool {
+	switch t {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		f, ok := value.(float64)
+		return ok && f == math.Trunc(f)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	}
+	// Unknown type names are not enforced
+	return true
+}
+
+func typeOf(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if equalValues(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func equalValues(a, b interface{}) bool {
+	ab, err1 := json.Marshal(a)
+	bb, err2 := json.Marshal(b)
+	return err1 == nil && err2 == nil && string(ab) == string(bb)
+}
@@ -0,0 +1,97 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+const toolSchema = `{
+	"type": "object",
+	"properties": {
+		"path": {"type": "string", "minLength": 1, "maxLength": 8, "pattern": "^/"},
+		"mode": {"enum": ["read", "write"]},
+		"version": {"const": 2},
+		"count": {"type": "integer", "minimum": 1, "maximum": 10},
+		"tags": {"type": "array", "items": {"type": "string"}, "minItems": 1, "maxItems": 2},
+		"meta": {"type": "object", "additionalProperties": {"type": "number"}},
+		"strict": {"type": "object", "properties": {"a": {}}, "additionalProperties": false},
+		"free": {"type": "object"},
+		"nullable": {"type": ["string", "null"]}
+	},
+	"required": ["path"]
+}`
+
+func TestValidate(t *testing.T) {
+	schema, err := Compile([]byte(toolSchema))
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		value    string
+		opts     Options
+		wantErr  bool
+		wantPath string // Where the violation is reported
+	}{
+		{name: "valid", value: `{"path":"/tmp","mode":"read","version":2,"count":3,"tags":["a"],"meta":{"x":1},"nullable":null}`},
+		{name: "not an object", value: `[]`, wantErr: true},
+		{name: "missing required", value: `{}`, wantErr: true, wantPath: "path"},
+		{name: "wrong type", value: `{"path":1}`, wantErr: true, wantPath: "path"},
+		{name: "too short", value: `{"path":""}`, wantErr: true, wantPath: "path"},
+		{name: "too long in characters", value: `{"path":"/ééééééééé"}`, wantErr: true, wantPath: "path"},
+		{name: "multibyte within length", value: `{"path":"/ééé"}`},
+		{name: "pattern", value: `{"path":"tmp"}`, wantErr: true, wantPath: "path"},
+		{name: "enum", value: `{"path":"/","mode":"delete"}`, wantErr: true, wantPath: "mode"},
+		{name: "const", value: `{"path":"/","version":3}`, wantErr: true, wantPath: "version"},
+		{name: "not an integer", value: `{"path":"/","count":1.5}`, wantErr: true, wantPath: "count"},
+		{name: "below minimum", value: `{"path":"/","count":0}`, wantErr: true, wantPath: "count"},
+		{name: "above maximum", value: `{"path":"/","count":11}`, wantErr: true, wantPath: "count"},
+		{name: "too few items", value: `{"path":"/","tags":[]}`, wantErr: true, wantPath: "tags"},
+		{name: "too many items", value: `{"path":"/","tags":["a","b","c"]}`, wantErr: true, wantPath: "tags"},
+		{name: "bad item", value: `{"path":"/","tags":["a",1]}`, wantErr: true, wantPath: "tags.1"},
+		{name: "additional schema", value: `{"path":"/","meta":{"x":"one"}}`, wantErr: true, wantPath: "meta.x"},
+		{name: "additional properties false", value: `{"path":"/","strict":{"b":1}}`, wantErr: true, wantPath: "strict.b"},
+		{name: "unknown property allowed", value: `{"path":"/","extra":true}`},
+		{name: "unknown property rejected", value: `{"path":"/","extra":true}`, opts: Options{RejectUnknown: true}, wantErr: true, wantPath: "extra"},
+		{name: "free-form object under RejectUnknown", value: `{"path":"/","free":{"any":1}}`, opts: Options{RejectUnknown: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var value interface{}
+			if err := json.Unmarshal([]byte(tt.value), &value); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			err := schema.Validate(value, tt.opts)
+			if !tt.wantErr {
+				if err != nil {
+					t.Errorf("Validate = %v, want valid", err)
+				}
+				return
+			}
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("Validate = %v, want a *ValidationError", err)
+			}
+			if verr.Path != tt.wantPath {
+				t.Errorf("violation at %q (%v), want at %q", verr.Path, verr, tt.wantPath)
+			}
+		})
+	}
+}
+
+func TestCompile(t *testing.T) {
+	if s, err := Compile([]byte(`false`)); err != nil || s.Validate("anything", Options{}) == nil {
+		t.Errorf("schema false: %v; want it to reject every value", err)
+	}
+	if s, err := Compile([]byte(`true`)); err != nil || s.Validate("anything", Options{}) != nil {
+		t.Errorf("schema true: %v; want it to accept every value", err)
+	}
+	for _, bad := range []string{`{`, `"string"`, `{"pattern":"("}`, `{"properties":{"a":{"pattern":"["}}}`} {
+		if _, err := Compile([]byte(bad)); err == nil {
+			t.Errorf("Compile(%s) succeeded", bad)
+		}
+	}
+}
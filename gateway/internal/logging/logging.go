@@ -0,0 +1,181 @@
+// Package logging filters structured log output before it is written: it
+// scrubs sensitive fields and samples repeated events, so that a burst of
+// them, such as a detection storm, cannot flood the logs.
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/clock"
+)
+
+// Redacted replaces the value of a sensitive field.
+const Redacted = "[REDACTED]"
+
+// maxSampleKeys bounds the events tracked for sampling; past it, events
+// whose period has ended are forgotten.
+const maxSampleKeys = 10000
+
+// sampledLevels are the levels that are sampled. Errors are always written.
+var sampledLevels = map[string]bool{"trace": true, "debug": true, "info": true, "warn": true}
+
+// Options configures a Writer.
+type Options struct {
+	RedactKeys   []string      // Field names, matched case-insensitively at any depth
+	SampleBurst  int           // Events with the same level and message written per period; 0 disables sampling
+	SamplePeriod time.Duration // Length of a sampling period
+	Clock        clock.Clock   // Defaults to the system clock
+}
+
+// Writer filters JSON log lines from zerolog before passing them to the
+// underlying writer. Lines that are not JSON are passed through unchanged.
+// It is safe for concurrent use.
+type Writer struct {
+	out    io.Writer
+	redact map[string]bool
+	burst  int
+	period time.Duration
+	clock  clock.Clock
+
+	mu      sync.Mutex
+	windows map[string]*sampleWindow
+}
+
+// sampleWindow counts an event's occurrences in the current period.
+type sampleWindow struct {
+	start   time.Time
+	count   int
+	dropped int
+}
+
+// NewWriter creates a writer that filters log lines into out.
+func NewWriter(out io.Writer, opts Options) *Writer {
+	w := &Writer{
+		out:     out,
+		redact:  make(map[string]bool, len(opts.RedactKeys)),
+		burst:   opts.SampleBurst,
+		period:  opts.SamplePeriod,
+		clock:   opts.Clock,
+		windows: make(map[string]*sampleWindow),
+	}
+	for _, key := range opts.RedactKeys {
+		w.redact[strings.ToLower(key)] = true
+	}
+	if w.clock == nil {
+		w.clock = clock.Real
+	}
+	return w
+}
+
+// Write writes a log line unless sampling drops it, with its sensitive fields
+// redacted. A line written after others like it were dropped carries the
+// number dropped in sampled_dropped.
+func (w *Writer) Write(p []byte) (int, error) {
+	var entry map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(p))
+	decoder.UseNumber()
+	if err := decoder.Decode(&entry); err != nil {
+		return w.out.Write(p)
+	}
+
+	keep, dropped := w.sample(entry)
+	if !keep {
+		return len(p), nil
+	}
+
+	changed := w.scrub(entry)
+	if dropped > 0 {
+		entry["sampled_dropped"] = dropped
+		changed = true
+	}
+	if !changed {
+		return w.out.Write(p)
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := w.out.Write(append(line, '\n')); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// sample reports whether the entry is written, and how many entries like it
+// were dropped since the last one written.
+func (w *Writer) sample(entry map[string]interface{}) (bool, int) {
+	level, _ := entry["level"].(string)
+	if w.burst <= 0 || !sampledLevels[level] {
+		return true, 0
+	}
+	message, _ := entry["message"].(string)
+	key := level + "\x00" + message
+	now := w.clock.Now()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	window, ok := w.windows[key]
+	if !ok {
+		if len(w.windows) >= maxSampleKeys {
+			w.forgetExpired(now)
+		}
+		window = &sampleWindow{start: now}
+		w.windows[key] = window
+	}
+
+	dropped := 0
+	if now.Sub(window.start) >= w.period {
+		dropped = window.dropped
+		*window = sampleWindow{start: now}
+	}
+
+	window.count++
+	if window.count > w.burst {
+		window.dropped++
+		return false, 0
+	}
+	return true, dropped
+}
+
+// forgetExpired drops the windows whose period has ended. The caller must
+// hold w.mu.
+func (w *Writer) forgetExpired(now time.Time) {
+	for key, window := range w.windows {
+		if now.Sub(window.start) >= w.period {
+			delete(w.windows, key)
+		}
+	}
+}
+
+// scrub replaces the values of sensitive fields in value, at any depth, and
+// reports whether it replaced any.
+func (w *Writer) scrub(value interface{}) bool {
+	changed := false
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if w.redact[strings.ToLower(key)] {
+				v[key] = Redacted
+				changed = true
+				continue
+			}
+			if w.scrub(field) {
+				changed = true
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if w.scrub(item) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
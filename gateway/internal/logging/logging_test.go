@@ -0,0 +1,128 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/clock"
+	"github.com/rs/zerolog"
+)
+
+// lines decodes each JSON line written to buf.
+func lines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var out []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("line %q: %v", line, err)
+		}
+		out = append(out, entry)
+	}
+	return out
+}
+
+func TestWriterRedactsFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger := zerolog.New(NewWriter(&buf, Options{RedactKeys: []string{"password", "Authorization"}}))
+
+	logger.Info().
+		Str("password", "hunter2").
+		Str("AUTHORIZATION", "Bearer abc").
+		Interface("request", map[string]interface{}{
+			"user":    "alice",
+			"headers": []interface{}{map[string]interface{}{"authorization": "Bearer def"}},
+		}).
+		Int64("count", 9007199254740993).
+		Msg("login")
+	logger.Info().Str("user", "bob").Msg("untouched")
+
+	got := lines(t, &buf)
+	if len(got) != 2 {
+		t.Fatalf("%d lines written, want 2", len(got))
+	}
+	entry := got[0]
+	if entry["password"] != Redacted || entry["AUTHORIZATION"] != Redacted {
+		t.Errorf("top-level fields = %v, %v", entry["password"], entry["AUTHORIZATION"])
+	}
+	request := entry["request"].(map[string]interface{})
+	header := request["headers"].([]interface{})[0].(map[string]interface{})
+	if header["authorization"] != Redacted || request["user"] != "alice" {
+		t.Errorf("nested fields = %v", request)
+	}
+	if !strings.Contains(buf.String(), "9007199254740993") {
+		t.Error("a large integer lost precision when the line was rewritten")
+	}
+	if got[1]["user"] != "bob" {
+		t.Errorf("line without sensitive fields = %v", got[1])
+	}
+}
+
+func TestWriterPassesThroughNonJSON(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, Options{RedactKeys: []string{"password"}, SampleBurst: 1, SamplePeriod: time.Minute})
+	for i := 0; i < 3; i++ {
+		w.Write([]byte("password=plain text line\n"))
+	}
+	if got := strings.Count(buf.String(), "plain text line"); got != 3 {
+		t.Errorf("%d of 3 non-JSON lines written", got)
+	}
+}
+
+func TestWriterSamplesRepeatedEvents(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	var buf bytes.Buffer
+	logger := zerolog.New(NewWriter(&buf, Options{SampleBurst: 2, SamplePeriod: time.Second, Clock: clk}))
+
+	for i := 0; i < 5; i++ {
+		logger.Warn().Int("i", i).Msg("Prompt injection detected")
+		logger.Error().Int("i", i).Msg("Prompt injection detected")
+	}
+	logger.Warn().Msg("Another event")
+
+	count := func(level, message string) (n int) {
+		for _, entry := range lines(t, &buf) {
+			if entry["level"] == level && entry["message"] == message {
+				n++
+			}
+		}
+		return n
+	}
+	if n := count("warn", "Prompt injection detected"); n != 2 {
+		t.Errorf("%d repeated warnings written, want the burst of 2", n)
+	}
+	if n := count("error", "Prompt injection detected"); n != 5 {
+		t.Errorf("%d errors written, want all 5", n)
+	}
+	if n := count("warn", "Another event"); n != 1 {
+		t.Errorf("a different message was sampled with the first")
+	}
+
+	clk.Advance(time.Second)
+	buf.Reset()
+	logger.Warn().Msg("Prompt injection detected")
+	got := lines(t, &buf)
+	if len(got) != 1 {
+		t.Fatalf("%d lines in the next period, want 1", len(got))
+	}
+	if got[0]["sampled_dropped"] != float64(3) {
+		t.Errorf("sampled_dropped = %v, want 3", got[0]["sampled_dropped"])
+	}
+}
+
+func TestWriterWithoutSampling(t *testing.T) {
+	var buf bytes.Buffer
+	logger := zerolog.New(NewWriter(&buf, Options{}))
+	for i := 0; i < 100; i++ {
+		logger.Info().Msg("same")
+	}
+	if n := len(lines(t, &buf)); n != 100 {
+		t.Errorf("%d of 100 lines written with sampling disabled", n)
+	}
+}
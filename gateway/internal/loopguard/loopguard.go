@@ -0,0 +1,144 @@
+// Package loopguard detects runaway agents: principals stuck making the same
+// tool call, with the same arguments, over and over.
+package loopguard
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/alerting"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// Policy flags a principal that makes more than Threshold identical tool
+// calls within Window. Flagged calls are blocked when Block is set, and an
+// alert is raised when the threshold is first crossed. A zero Threshold
+// disables detection.
+type Policy struct {
+	Threshold int
+	Window    time.Duration
+	Block     bool
+}
+
+// Verdict is the outcome of checking a tool call.
+type Verdict struct {
+	Looping bool // The call repeats beyond the threshold
+	Blocked bool // The call must not be executed
+	Repeats int  // Identical calls within the window, this one included
+}
+
+// series tracks the recent repeats of one call by one principal.
+type series struct {
+	hits    []time.Time // Within the window, oldest first
+	alerted bool        // Alert raised for the current run of repeats
+}
+
+// Guard counts identical tool calls per principal.
+type Guard struct {
+	policy Policy
+	logger zerolog.Logger
+	alerts *alerting.Service
+
+	mu    sync.Mutex
+	calls map[string]*series
+}
+
+// New creates a guard enforcing policy.
+func New(policy Policy, logger zerolog.Logger, alerts *alerting.Service) *Guard {
+	return &Guard{
+		policy: policy,
+		logger: logger,
+		alerts: alerts,
+		calls:  make(map[string]*series),
+	}
+}
+
+// Check records a tool call by principal, an API key or client IP, and
+// reports whether it repeats beyond the policy's threshold.
+func (g *Guard) Check(orgID uuid.UUID, principal, server, tool string, args map[string]interface{}) Verdict {
+	if g == nil || g.policy.Threshold <= 0 || principal == "" {
+		return Verdict{}
+	}
+
+	now := time.Now()
+	cutoff := now.Add(-g.policy.Window)
+	key := orgID.String() + "/" + principal + "/" + server + "/" + tool + "/" + argsHash(args)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	s, ok := g.calls[key]
+	if !ok {
+		// Forget calls with no repeats left in the window
+		for k, other := range g.calls {
+			if len(recentHits(other.hits, cutoff)) == 0 {
+				delete(g.calls, k)
+			}
+		}
+		s = &series{}
+		g.calls[key] = s
+	}
+	s.hits = append(recentHits(s.hits, cutoff), now)
+
+	verdict := Verdict{Repeats: len(s.hits)}
+	if len(s.hits) <= g.policy.Threshold {
+		s.alerted = false
+		return verdict
+	}
+	verdict.Looping = true
+	verdict.Blocked = g.policy.Block
+
+	if !s.alerted {
+		s.alerted = true
+		g.logger.Warn().
+			Str("org_id", orgID.String()).
+			Str("principal", principal).
+			Str("server", server).
+			Str("tool", tool).
+			Int("repeats", len(s.hits)).
+			Dur("window", g.policy.Window).
+			Bool("blocked", verdict.Blocked).
+			Msg("Tool call loop detected")
+		if g.alerts != nil {
+			g.alerts.CreateSystemAlert(
+				orgID,
+				"Agent: tool call loop",
+				domain.AlertMetricToolLoop,
+				domain.AlertSeverityWarning,
+				float64(len(s.hits)),
+				float64(g.policy.Threshold),
+				fmt.Sprintf("%s called %s.%s with the same arguments %d times within %s",
+					principal, server, tool, len(s.hits), g.policy.Window),
+				nil,
+			)
+		}
+	}
+
+	return verdict
+}
+
+// argsHash identifies a call's arguments. Object keys are marshalled in
+// sorted order, so equal arguments hash the same.
+func argsHash(args map[string]interface{}) string {
+	data, err := json.Marshal(args)
+	if err != nil {
+		data = []byte(fmt.Sprint(args))
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// recentHits drops the hits that fall before cutoff.
+func recentHits(hits []time.Time, cutoff time.Time) []time.Time {
+	i := 0
+	for i < len(hits) && hits[i].Before(cutoff) {
+		i++
+	}
+	return hits[i:]
+}
@@ -0,0 +1,96 @@
+package loopguard
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/alerting"
+	"github.com/akz4ol/gatewayops/gateway/internal/clock"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+func TestCheckFlagsRepeatedCalls(t *testing.T) {
+	alerts := alerting.NewService(zerolog.Nop(), nil, nil, 100, clock.Real)
+	t.Cleanup(func() { alerts.Shutdown(context.Background()) })
+	g := New(Policy{Threshold: 3, Window: time.Minute, Block: true}, zerolog.Nop(), alerts)
+	orgID := uuid.New()
+	args := map[string]interface{}{"path": "/tmp/a", "options": map[string]interface{}{"b": 1, "a": 2}}
+
+	for i := 1; i <= 3; i++ {
+		if v := g.Check(orgID, "key-1", "filesystem", "read_file", args); v.Looping || v.Repeats != i {
+			t.Fatalf("call %d = %+v, want %d repeats and no loop", i, v, i)
+		}
+	}
+	// Equal arguments built in another order are the same call
+	same := map[string]interface{}{"options": map[string]interface{}{"a": 2, "b": 1}, "path": "/tmp/a"}
+	v := g.Check(orgID, "key-1", "filesystem", "read_file", same)
+	if !v.Looping || !v.Blocked || v.Repeats != 4 {
+		t.Fatalf("call past the threshold = %+v, want a blocked loop of 4", v)
+	}
+	g.Check(orgID, "key-1", "filesystem", "read_file", args)
+	if active := alerts.GetActiveAlerts(orgID); len(active) != 1 {
+		t.Errorf("%d alerts raised, want one for the run of repeats", len(active))
+	}
+
+	others := []struct {
+		name      string
+		principal string
+		tool      string
+		args      map[string]interface{}
+	}{
+		{"another principal", "key-2", "read_file", args},
+		{"another tool", "key-1", "write_file", args},
+		{"other arguments", "key-1", "read_file", map[string]interface{}{"path": "/tmp/b"}},
+	}
+	for _, o := range others {
+		if v := g.Check(orgID, o.principal, "filesystem", o.tool, o.args); v.Looping || v.Repeats != 1 {
+			t.Errorf("%s = %+v, want a first call", o.name, v)
+		}
+	}
+}
+
+func TestCheckForgetsCallsOutsideWindow(t *testing.T) {
+	g := New(Policy{Threshold: 2, Window: time.Minute}, zerolog.Nop(), nil)
+	orgID := uuid.New()
+	for i := 0; i < 3; i++ {
+		g.Check(orgID, "key-1", "filesystem", "read_file", nil)
+	}
+
+	// Age the recorded calls past the window
+	for _, s := range g.calls {
+		for i := range s.hits {
+			s.hits[i] = s.hits[i].Add(-2 * time.Minute)
+		}
+	}
+	if v := g.Check(orgID, "key-1", "filesystem", "read_file", nil); v.Looping || v.Repeats != 1 {
+		t.Errorf("call after the window = %+v, want a first call", v)
+	}
+	g.Check(orgID, "key-2", "filesystem", "read_file", nil)
+	if len(g.calls) != 2 {
+		t.Errorf("%d call series kept, want only the two in the window", len(g.calls))
+	}
+}
+
+func TestCheckDisabled(t *testing.T) {
+	orgID := uuid.New()
+	var nilGuard *Guard
+	guards := map[string]*Guard{
+		"nil guard":      nilGuard,
+		"zero threshold": New(Policy{Window: time.Minute, Block: true}, zerolog.Nop(), nil),
+	}
+	for name, g := range guards {
+		for i := 0; i < 5; i++ {
+			if v := g.Check(orgID, "key-1", "filesystem", "read_file", nil); v.Looping {
+				t.Errorf("%s: flagged a loop", name)
+			}
+		}
+	}
+	g := New(Policy{Threshold: 1, Window: time.Minute, Block: true}, zerolog.Nop(), nil)
+	for i := 0; i < 3; i++ {
+		if v := g.Check(orgID, "", "filesystem", "read_file", nil); v.Looping {
+			t.Error("flagged a loop for an unknown principal")
+		}
+	}
+}
@@ -0,0 +1,499 @@
+// Package mcpserver keeps the MCP servers the gateway proxies to: those
+// configured through the environment and those registered at runtime.
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/config"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/egress"
+	"github.com/akz4ol/gatewayops/gateway/internal/repository"
+	"github.com/akz4ol/gatewayops/gateway/internal/secretbox"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// Errors returned when registering, updating or removing a server.
+var (
+	ErrInvalidName       = errors.New("invalid MCP server name")
+	ErrServerExists      = errors.New("MCP server already exists")
+	ErrServerNotFound    = errors.New("MCP server not found")
+	ErrServerReadOnly    = errors.New("MCP server is configured through the environment")
+	ErrServerUnreachable = errors.New("MCP server is unreachable")
+	ErrInvalidAuth       = errors.New("invalid MCP server auth")
+	ErrNoSecretsKey      = errors.New("SECRETS_ENCRYPTION_KEY is not set, so credentials cannot be stored")
+)
+
+// defaultTimeoutSeconds is the request timeout of a registered server that
+// does not set one, matching configured servers.
+const defaultTimeoutSeconds = 30
+
+// probeTimeout bounds the reachability check made on registration.
+const probeTimeout = 5 * time.Second
+
+// validName matches server names usable in proxy paths and in the
+// server__tool function names of the OpenAI-compatible endpoint.
+var validName = regexp.MustCompile(`^[a-z0-9]+([_-][a-z0-9]+)*$`)
+
+// Registry looks up MCP servers by name. Configured servers are shared by
+// every organization, take precedence and cannot be changed through the
+// registry. Registered servers belong to the organization that registered
+// them and are only visible to it; they are persisted so they survive a
+// restart, with their credentials encrypted.
+type Registry struct {
+	config  *config.Config
+	repo    *repository.MCPServerRepository
+	secrets *secretbox.Keyring // Nil when no key is configured
+	egress  *egress.Policy
+	logger  zerolog.Logger
+	client  *http.Client
+
+	writeMu sync.Mutex // Serializes registrations, updates and removals
+	mu      sync.RWMutex
+	servers map[serverKey]domain.MCPServer
+	loaded  bool // Registered servers have been loaded from the database
+}
+
+// serverKey identifies a registered server: names are unique per
+// organization.
+type serverKey struct {
+	orgID uuid.UUID
+	name  string
+}
+
+// key returns the key of a registered server.
+func key(server domain.MCPServer) serverKey {
+	var orgID uuid.UUID
+	if server.OrgID != nil {
+		orgID = *server.OrgID
+	}
+	return serverKey{orgID: orgID, name: server.Name}
+}
+
+// NewRegistry creates a registry over the servers configured in cfg and
+// loads those registered earlier from repo. Credentials are sealed with
+// secrets; without it, servers can only be registered without one. Servers
+// can only be registered at destinations egressPolicy allows.
+func NewRegistry(cfg *config.Config, repo *repository.MCPServerRepository, secrets *secretbox.Keyring, egressPolicy *egress.Policy, logger zerolog.Logger) *Registry {
+	r := &Registry{
+		config:  cfg,
+		repo:    repo,
+		secrets: secrets,
+		egress:  egressPolicy,
+		logger:  logger,
+		client:  &http.Client{Timeout: probeTimeout, Transport: egressPolicy.Transport()},
+		servers: make(map[serverKey]domain.MCPServer),
+	}
+	r.load()
+	return r
+}
+
+// load reads the registered servers from the database, reporting whether
+// it succeeded.
+func (r *Registry) load() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	servers, err := r.repo.List(ctx)
+	if err != nil {
+		r.logger.Error().Err(err).Msg("Failed to load registered MCP servers")
+		return false
+	}
+
+	for i := range servers {
+		if err := r.openAuth(&servers[i]); err != nil {
+			r.logger.Error().Err(err).Str("server", servers[i].Name).
+				Msg("Failed to decrypt MCP server credential; requests are sent without it")
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.servers = make(map[serverKey]domain.MCPServer, len(servers))
+	for _, server := range servers {
+		r.servers[key(server)] = server
+	}
+	r.loaded = true
+	r.logger.Info().Int("count", len(servers)).Msg("Loaded registered MCP servers")
+	return true
+}
+
+// MCPServer returns the configuration for the named MCP server, configured
+// or registered by orgID.
+func (r *Registry) MCPServer(orgID uuid.UUID, name string) (config.MCPServerConfig, bool) {
+	if server, ok := r.config.MCPServer(name); ok {
+		return server, true
+	}
+
+	r.mu.RLock()
+	server, ok := r.servers[serverKey{orgID: orgID, name: name}]
+	r.mu.RUnlock()
+	if !ok {
+		return config.MCPServerConfig{}, false
+	}
+	serverConfig := config.NewMCPServerConfig(server.Name, server.URL)
+	serverConfig.OrgID = orgID
+	serverConfig.Timeout = time.Duration(server.TimeoutSeconds) * time.Second
+	serverConfig.Auth = outboundAuth(server.Auth)
+	return serverConfig, true
+}
+
+// List returns the MCP servers orgID can call, configured ones first, each
+// sorted by name.
+func (r *Registry) List(orgID uuid.UUID) []domain.MCPServer {
+	servers := make([]domain.MCPServer, 0)
+	configured := make(map[string]bool)
+	for _, name := range r.config.MCPServerNames() {
+		if server, ok := r.configured(name); ok {
+			servers = append(servers, server)
+			configured[name] = true
+		}
+	}
+
+	r.mu.RLock()
+	registered := make([]domain.MCPServer, 0, len(r.servers))
+	for k, server := range r.servers {
+		if k.orgID == orgID && !configured[server.Name] {
+			registered = append(registered, server)
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(registered, func(i, j int) bool {
+		return registered[i].Name < registered[j].Name
+	})
+	return append(servers, registered...)
+}
+
+// Get returns the named MCP server, configured or registered by orgID.
+func (r *Registry) Get(orgID uuid.UUID, name string) (domain.MCPServer, bool) {
+	if server, ok := r.configured(name); ok {
+		return server, true
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	server, ok := r.servers[serverKey{orgID: orgID, name: name}]
+	return server, ok
+}
+
+// configured returns the named server if it is configured through the
+// environment.
+func (r *Registry) configured(name string) (domain.MCPServer, bool) {
+	serverConfig, ok := r.config.MCPServer(name)
+	if !ok {
+		return domain.MCPServer{}, false
+	}
+	return domain.MCPServer{
+		Name:           name,
+		URL:            serverConfig.URL,
+		TimeoutSeconds: int(serverConfig.Timeout / time.Second),
+		AuthType:       domain.MCPAuthType(serverConfig.Auth.Type),
+		Source:         domain.MCPServerSourceConfig,
+	}, true
+}
+
+// DefaultClassification returns the risk level of the tools of a server
+// registered by orgID that have not been classified, if the server sets one.
+func (r *Registry) DefaultClassification(orgID uuid.UUID, server string) (domain.ToolRiskLevel, bool) {
+	if _, ok := r.config.MCPServer(server); ok {
+		return "", false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	s, ok := r.servers[serverKey{orgID: orgID, name: server}]
+	if !ok || s.DefaultClassification == "" {
+		return "", false
+	}
+	return s.DefaultClassification, true
+}
+
+// Register adds an MCP server for orgID after checking that it answers at
+// its URL.
+func (r *Registry) Register(ctx context.Context, orgID uuid.UUID, input domain.MCPServerInput, createdBy *uuid.UUID) (*domain.MCPServer, error) {
+	if !validName.MatchString(input.Name) {
+		return nil, ErrInvalidName
+	}
+
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
+	if _, ok := r.Get(orgID, input.Name); ok {
+		return nil, ErrServerExists
+	}
+
+	now := time.Now().UTC()
+	server := domain.MCPServer{
+		OrgID:                 &orgID,
+		Name:                  input.Name,
+		URL:                   strings.TrimRight(input.URL, "/"),
+		TimeoutSeconds:        input.TimeoutSeconds,
+		DefaultClassification: input.DefaultClassification,
+		Source:                domain.MCPServerSourceRegistry,
+		CreatedBy:             createdBy,
+		CreatedAt:             &now,
+		UpdatedAt:             &now,
+	}
+	if server.TimeoutSeconds == 0 {
+		server.TimeoutSeconds = defaultTimeoutSeconds
+	}
+	if err := r.sealAuth(&server, input.Auth); err != nil {
+		return nil, err
+	}
+	if err := r.checkReachable(ctx, server.URL, outboundAuth(server.Auth)); err != nil {
+		return nil, err
+	}
+	if err := r.repo.Save(ctx, &server); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.servers[key(server)] = server
+	r.mu.Unlock()
+
+	r.logger.Info().Str("server", server.Name).Str("org_id", orgID.String()).Str("url", server.URL).Msg("MCP server registered")
+	return &server, nil
+}
+
+// Update replaces the settings of an MCP server registered by orgID after
+// checking that it answers at its URL.
+func (r *Registry) Update(ctx context.Context, orgID uuid.UUID, name string, input domain.MCPServerUpdate) (*domain.MCPServer, error) {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
+	server, err := r.registered(orgID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	server.URL = strings.TrimRight(input.URL, "/")
+	server.TimeoutSeconds = input.TimeoutSeconds
+	if server.TimeoutSeconds == 0 {
+		server.TimeoutSeconds = defaultTimeoutSeconds
+	}
+	server.DefaultClassification = input.DefaultClassification
+	server.UpdatedAt = &now
+	if err := r.sealAuth(&server, input.Auth); err != nil {
+		return nil, err
+	}
+
+	if err := r.checkReachable(ctx, server.URL, outboundAuth(server.Auth)); err != nil {
+		return nil, err
+	}
+	if err := r.repo.Save(ctx, &server); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.servers[key(server)] = server
+	r.mu.Unlock()
+
+	r.logger.Info().Str("server", name).Str("org_id", orgID.String()).Str("url", server.URL).Msg("MCP server updated")
+	return &server, nil
+}
+
+// Remove removes an MCP server registered by orgID. Calls to it fail from
+// then on.
+func (r *Registry) Remove(ctx context.Context, orgID uuid.UUID, name string) (*domain.MCPServer, error) {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
+	server, err := r.registered(orgID, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.repo.Delete(ctx, orgID, name); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	delete(r.servers, key(server))
+	r.mu.Unlock()
+
+	r.logger.Info().Str("server", name).Str("org_id", orgID.String()).Msg("MCP server removed")
+	return &server, nil
+}
+
+// registered returns the named server if orgID registered it at runtime.
+// Another organization's server of the same name is not found.
+func (r *Registry) registered(orgID uuid.UUID, name string) (domain.MCPServer, error) {
+	if _, ok := r.config.MCPServer(name); ok {
+		return domain.MCPServer{}, ErrServerReadOnly
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	server, ok := r.servers[serverKey{orgID: orgID, name: name}]
+	if !ok {
+		return domain.MCPServer{}, ErrServerNotFound
+	}
+	return server, nil
+}
+
+// sealAuth sets a server's credential, encrypted for storage, or clears it
+// when auth is nil.
+func (r *Registry) sealAuth(server *domain.MCPServer, auth *domain.MCPServerAuth) error {
+	server.Auth, server.AuthType, server.AuthEncrypted = nil, "", nil
+	if auth == nil {
+		return nil
+	}
+	if problem := outboundAuth(auth).Problem(); problem != "" {
+		return fmt.Errorf("%w: %s", ErrInvalidAuth, problem)
+	}
+	if r.secrets == nil {
+		return ErrNoSecretsKey
+	}
+
+	raw, err := json.Marshal(auth)
+	if err != nil {
+		return err
+	}
+	sealed, err := r.secrets.Seal(raw)
+	if err != nil {
+		return err
+	}
+	server.Auth, server.AuthType, server.AuthEncrypted = auth, auth.Type, sealed
+	return nil
+}
+
+// openAuth decrypts the stored credential of a server loaded from the
+// database.
+func (r *Registry) openAuth(server *domain.MCPServer) error {
+	if len(server.AuthEncrypted) == 0 {
+		return nil
+	}
+	if r.secrets == nil {
+		return ErrNoSecretsKey
+	}
+	raw, err := r.secrets.Open(server.AuthEncrypted)
+	if err != nil {
+		return err
+	}
+	var auth domain.MCPServerAuth
+	if err := json.Unmarshal(raw, &auth); err != nil {
+		return fmt.Errorf("decode credential: %w", err)
+	}
+	server.Auth = &auth
+	return nil
+}
+
+// outboundAuth converts a registered credential to the form applied to
+// requests to the server.
+func outboundAuth(auth *domain.MCPServerAuth) config.MCPAuth {
+	if auth == nil {
+		return config.MCPAuth{}
+	}
+	return config.MCPAuth{
+		Type:     string(auth.Type),
+		Token:    auth.Token,
+		Username: auth.Username,
+		Password: auth.Password,
+		Header:   auth.Header,
+		Value:    auth.Value,
+	}
+}
+
+// checkReachable makes sure an MCP server answers at url by listing its
+// tools, sending auth. Any response short of a server error counts: the
+// server may want arguments the check does not send. A url the egress policy
+// refuses is never contacted; the error wraps egress.ErrDenied.
+func (r *Registry) checkReachable(ctx context.Context, url string, auth config.MCPAuth) error {
+	if err := r.egress.CheckURL(url); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url+"/tools/list", strings.NewReader("{}"))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrServerUnreachable, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	auth.Apply(req.Header)
+
+	resp, err := r.client.Do(req)
+	if errors.Is(err, egress.ErrDenied) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrServerUnreachable, err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("%w: listing tools returned HTTP %d", ErrServerUnreachable, resp.StatusCode)
+	}
+	return nil
+}
+
+// Reencrypt seals every stored credential not sealed under the current key
+// again under it, returning how many were re-encrypted. Once it succeeds, no
+// credential needs an older key.
+func (r *Registry) Reencrypt(ctx context.Context) (int, error) {
+	if r.secrets == nil {
+		return 0, ErrNoSecretsKey
+	}
+
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
+	r.mu.RLock()
+	var stale []domain.MCPServer
+	for _, server := range r.servers {
+		if len(server.AuthEncrypted) > 0 && r.secrets.Stale(server.AuthEncrypted) {
+			stale = append(stale, server)
+		}
+	}
+	r.mu.RUnlock()
+
+	for i, server := range stale {
+		raw, err := r.secrets.Open(server.AuthEncrypted)
+		if err != nil {
+			return i, fmt.Errorf("decrypt credential of %s: %w", server.Name, err)
+		}
+		if server.AuthEncrypted, err = r.secrets.Seal(raw); err != nil {
+			return i, err
+		}
+		if err := r.repo.Save(ctx, &server); err != nil {
+			return i, fmt.Errorf("save %s: %w", server.Name, err)
+		}
+
+		r.mu.Lock()
+		r.servers[key(server)] = server
+		r.mu.Unlock()
+	}
+	if len(stale) > 0 {
+		r.logger.Info().Int("count", len(stale)).Uint32("key_version", r.secrets.Current()).
+			Msg("Re-encrypted MCP server credentials")
+	}
+	return len(stale), nil
+}
+
+// Health reports the registry as live; it serves configured servers even
+// when registered ones could not be loaded.
+func (r *Registry) Health() bool {
+	return true
+}
+
+// Ready reports whether the registered servers have been loaded, retrying
+// the load if it failed at startup.
+func (r *Registry) Ready() bool {
+	r.mu.RLock()
+	loaded := r.loaded
+	r.mu.RUnlock()
+	if loaded {
+		return true
+	}
+	return r.load()
+}
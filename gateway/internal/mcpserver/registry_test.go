@@ -0,0 +1,347 @@
+package mcpserver
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/config"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/egress"
+	"github.com/akz4ol/gatewayops/gateway/internal/repository"
+	"github.com/akz4ol/gatewayops/gateway/internal/secretbox"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// newTestRegistry returns a registry over a config with one server,
+// "configured", and no database.
+func newTestRegistry(t *testing.T, secrets *secretbox.Keyring, policy *egress.Policy) *Registry {
+	t.Helper()
+	cfg, err := config.LoadFrom(func(key string) string {
+		return map[string]string{"MCP_SERVERS": "configured", "MCP_SERVER_CONFIGURED_URL": "http://configured:3000"}[key]
+	})
+	if err != nil {
+		t.Fatalf("LoadFrom: %v", err)
+	}
+	return NewRegistry(cfg, repository.NewMCPServerRepository(nil), secrets, policy, zerolog.Nop())
+}
+
+// mcpServer returns an MCP server that answers tools/list with status, and
+// the Authorization headers it was sent.
+func mcpServer(t *testing.T, status int) (*httptest.Server, func() []string) {
+	t.Helper()
+	var mu sync.Mutex
+	var auth []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		auth = append(auth, r.Header.Get("Authorization"))
+		mu.Unlock()
+		if r.URL.Path != "/tools/list" {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), auth...)
+	}
+}
+
+func TestRegisterUpdateRemove(t *testing.T) {
+	ctx, orgID := context.Background(), uuid.New()
+	r := newTestRegistry(t, nil, nil)
+	srv, _ := mcpServer(t, http.StatusOK)
+
+	server, err := r.Register(ctx, orgID, domain.MCPServerInput{Name: "search", URL: srv.URL + "/", DefaultClassification: domain.ToolRiskSensitive}, nil)
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if server.URL != srv.URL || server.TimeoutSeconds != defaultTimeoutSeconds || server.Source != domain.MCPServerSourceRegistry {
+		t.Errorf("registered server = %+v", server)
+	}
+	if cfg, ok := r.MCPServer(orgID, "search"); !ok || cfg.URL != srv.URL {
+		t.Errorf("MCPServer(search) = %+v, %v", cfg, ok)
+	}
+	if level, ok := r.DefaultClassification(orgID, "search"); !ok || level != domain.ToolRiskSensitive {
+		t.Errorf("DefaultClassification = %s, %v", level, ok)
+	}
+
+	list := r.List(orgID)
+	if len(list) != 2 || list[0].Name != "configured" || list[0].Source != domain.MCPServerSourceConfig || list[1].Name != "search" {
+		t.Errorf("List = %+v, want the configured server first", list)
+	}
+
+	if _, err := r.Register(ctx, orgID, domain.MCPServerInput{Name: "search", URL: srv.URL}, nil); !errors.Is(err, ErrServerExists) {
+		t.Errorf("registering a name twice: err = %v", err)
+	}
+	if _, err := r.Register(ctx, orgID, domain.MCPServerInput{Name: "configured", URL: srv.URL}, nil); !errors.Is(err, ErrServerExists) {
+		t.Errorf("registering a configured name: err = %v", err)
+	}
+	for _, name := range []string{"Search", "a b", "-search", "search__x", ""} {
+		if _, err := r.Register(ctx, orgID, domain.MCPServerInput{Name: name, URL: srv.URL}, nil); !errors.Is(err, ErrInvalidName) {
+			t.Errorf("Register(%q): err = %v, want ErrInvalidName", name, err)
+		}
+	}
+
+	updated, err := r.Update(ctx, orgID, "search", domain.MCPServerUpdate{URL: srv.URL, TimeoutSeconds: 5})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if updated.TimeoutSeconds != 5 || updated.DefaultClassification != "" {
+		t.Errorf("updated server = %+v, want its settings replaced", updated)
+	}
+	if _, err := r.Update(ctx, orgID, "configured", domain.MCPServerUpdate{URL: srv.URL}); !errors.Is(err, ErrServerReadOnly) {
+		t.Errorf("updating a configured server: err = %v", err)
+	}
+	if _, err := r.Remove(ctx, orgID, "configured"); !errors.Is(err, ErrServerReadOnly) {
+		t.Errorf("removing a configured server: err = %v", err)
+	}
+
+	if _, err := r.Remove(ctx, orgID, "search"); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if _, ok := r.MCPServer(orgID, "search"); ok {
+		t.Error("removed server is still served")
+	}
+	if _, err := r.Remove(ctx, orgID, "search"); !errors.Is(err, ErrServerNotFound) {
+		t.Errorf("removing twice: err = %v", err)
+	}
+}
+
+func TestRegisterChecksReachability(t *testing.T) {
+	ctx, orgID := context.Background(), uuid.New()
+	r := newTestRegistry(t, nil, nil)
+
+	failing, _ := mcpServer(t, http.StatusBadGateway)
+	if _, err := r.Register(ctx, orgID, domain.MCPServerInput{Name: "failing", URL: failing.URL}, nil); !errors.Is(err, ErrServerUnreachable) {
+		t.Errorf("server answering 502: err = %v", err)
+	}
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	if _, err := r.Register(ctx, orgID, domain.MCPServerInput{Name: "down", URL: down.URL}, nil); !errors.Is(err, ErrServerUnreachable) {
+		t.Errorf("server not listening: err = %v", err)
+	}
+	if _, ok := r.Get(orgID, "failing"); ok {
+		t.Error("an unreachable server was registered")
+	}
+
+	// Wanting arguments the check does not send still counts as reachable
+	picky, _ := mcpServer(t, http.StatusBadRequest)
+	if _, err := r.Register(ctx, orgID, domain.MCPServerInput{Name: "picky", URL: picky.URL}, nil); err != nil {
+		t.Errorf("server answering 400: %v", err)
+	}
+}
+
+// newTestBox returns a box with a random key.
+func newTestBox(t *testing.T) *secretbox.Box {
+	t.Helper()
+	key := make([]byte, secretbox.KeySize)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	box, err := secretbox.New(key)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return box
+}
+
+// newTestKeyring returns a keyring over boxes with current as its current
+// version.
+func newTestKeyring(t *testing.T, current uint32, boxes map[uint32]*secretbox.Box) *secretbox.Keyring {
+	t.Helper()
+	keyring, err := secretbox.NewKeyring(current, boxes)
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	return keyring
+}
+
+func TestRegisterWithAuth(t *testing.T) {
+	ctx, orgID := context.Background(), uuid.New()
+	srv, sent := mcpServer(t, http.StatusOK)
+	bearer := &domain.MCPServerAuth{Type: domain.MCPAuthBearer, Token: "mcp-token"}
+
+	withoutKey := newTestRegistry(t, nil, nil)
+	if _, err := withoutKey.Register(ctx, orgID, domain.MCPServerInput{Name: "search", URL: srv.URL, Auth: bearer}, nil); !errors.Is(err, ErrNoSecretsKey) {
+		t.Errorf("credential without a secrets key: err = %v", err)
+	}
+
+	r := newTestRegistry(t, newTestKeyring(t, 1, map[uint32]*secretbox.Box{1: newTestBox(t)}), nil)
+	if _, err := r.Register(ctx, orgID, domain.MCPServerInput{Name: "search", URL: srv.URL, Auth: &domain.MCPServerAuth{Type: domain.MCPAuthBearer}}, nil); !errors.Is(err, ErrInvalidAuth) {
+		t.Errorf("bearer auth without a token: err = %v", err)
+	}
+
+	server, err := r.Register(ctx, orgID, domain.MCPServerInput{Name: "search", URL: srv.URL, Auth: bearer}, nil)
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if server.AuthType != domain.MCPAuthBearer || len(server.AuthEncrypted) == 0 || bytes.Contains(server.AuthEncrypted, []byte("mcp-token")) {
+		t.Errorf("stored credential = %q, want it sealed", server.AuthEncrypted)
+	}
+	if got := sent(); len(got) == 0 || got[len(got)-1] != "Bearer mcp-token" {
+		t.Errorf("reachability check sent Authorization %q", got)
+	}
+	cfg, _ := r.MCPServer(orgID, "search")
+	header := http.Header{}
+	cfg.Auth.Apply(header)
+	if header.Get("Authorization") != "Bearer mcp-token" {
+		t.Errorf("requests to the server would send Authorization %q", header.Get("Authorization"))
+	}
+
+	// An update without a credential clears it
+	updated, err := r.Update(ctx, orgID, "search", domain.MCPServerUpdate{URL: srv.URL})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if updated.Auth != nil || updated.AuthType != "" || updated.AuthEncrypted != nil {
+		t.Errorf("credential kept after an update without one: %+v", updated)
+	}
+}
+
+func TestRegisterAppliesEgressPolicy(t *testing.T) {
+	ctx, orgID := context.Background(), uuid.New()
+	srv, sent := mcpServer(t, http.StatusOK)
+	port := srv.Listener.Addr().(*net.TCPAddr).Port
+
+	denied, err := egress.New(nil, []string{"127.0.0.0/8"})
+	if err != nil {
+		t.Fatalf("egress.New: %v", err)
+	}
+	r := newTestRegistry(t, nil, denied)
+	if _, err := r.Register(ctx, orgID, domain.MCPServerInput{Name: "search", URL: srv.URL}, nil); !errors.Is(err, egress.ErrDenied) {
+		t.Errorf("denied address: err = %v, want egress.ErrDenied", err)
+	}
+
+	// A host name is checked against the addresses it resolves to when the
+	// check connects
+	allowlisted, err := egress.New([]string{"mcp.example.com"}, nil)
+	if err != nil {
+		t.Fatalf("egress.New: %v", err)
+	}
+	r = newTestRegistry(t, nil, allowlisted)
+	if _, err := r.Register(ctx, orgID, domain.MCPServerInput{Name: "search", URL: fmt.Sprintf("http://localhost:%d", port)}, nil); !errors.Is(err, egress.ErrDenied) {
+		t.Errorf("host not on the allowlist: err = %v, want egress.ErrDenied", err)
+	}
+	if _, ok := r.Get(orgID, "search"); ok {
+		t.Error("a denied server was registered")
+	}
+	if got := sent(); len(got) != 0 {
+		t.Errorf("denied server was sent %d requests", len(got))
+	}
+
+	allowed, err := egress.New([]string{"127.0.0.1"}, nil)
+	if err != nil {
+		t.Fatalf("egress.New: %v", err)
+	}
+	r = newTestRegistry(t, nil, allowed)
+	if _, err := r.Register(ctx, orgID, domain.MCPServerInput{Name: "search", URL: srv.URL}, nil); err != nil {
+		t.Errorf("allowed address: %v", err)
+	}
+}
+
+func TestReencrypt(t *testing.T) {
+	ctx, orgID := context.Background(), uuid.New()
+	srv, sent := mcpServer(t, http.StatusOK)
+
+	if _, err := newTestRegistry(t, nil, nil).Reencrypt(ctx); !errors.Is(err, ErrNoSecretsKey) {
+		t.Errorf("Reencrypt without a secrets key: err = %v", err)
+	}
+
+	v1, v2 := newTestBox(t), newTestBox(t)
+	r := newTestRegistry(t, newTestKeyring(t, 1, map[uint32]*secretbox.Box{1: v1}), nil)
+	auth := &domain.MCPServerAuth{Type: domain.MCPAuthHeader, Header: "X-Api-Key", Value: "mcp-key"}
+	if _, err := r.Register(ctx, orgID, domain.MCPServerInput{Name: "search", URL: srv.URL, Auth: auth}, nil); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if _, err := r.Register(ctx, orgID, domain.MCPServerInput{Name: "open", URL: srv.URL}, nil); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	// Rotate: v2 seals, v1 still opens what it sealed
+	r.secrets = newTestKeyring(t, 2, map[uint32]*secretbox.Box{1: v1, 2: v2})
+	n, err := r.Reencrypt(ctx)
+	if err != nil || n != 1 {
+		t.Fatalf("Reencrypt = %d, %v, want 1", n, err)
+	}
+	if n, _ := r.Reencrypt(ctx); n != 0 {
+		t.Errorf("second Reencrypt = %d, want 0", n)
+	}
+
+	server, _ := r.Get(orgID, "search")
+	raw, err := newTestKeyring(t, 2, map[uint32]*secretbox.Box{2: v2}).Open(server.AuthEncrypted)
+	if err != nil {
+		t.Fatalf("credential does not open without the retired key: %v", err)
+	}
+	if !bytes.Contains(raw, []byte("mcp-key")) {
+		t.Errorf("re-encrypted credential = %s", raw)
+	}
+
+	cfg, _ := r.MCPServer(orgID, "search")
+	header := http.Header{}
+	cfg.Auth.Apply(header)
+	if header.Get("X-Api-Key") != "mcp-key" {
+		t.Errorf("requests to the server would send X-Api-Key %q", header.Get("X-Api-Key"))
+	}
+	if len(sent()) != 2 {
+		t.Errorf("reachability checked %d times, want once per registration", len(sent()))
+	}
+}
+
+func TestServersAreScopedToTheirOrganization(t *testing.T) {
+	ctx, orgID, otherOrg := context.Background(), uuid.New(), uuid.New()
+	r := newTestRegistry(t, nil, nil)
+	srv, _ := mcpServer(t, http.StatusOK)
+	elsewhere, _ := mcpServer(t, http.StatusOK)
+
+	if _, err := r.Register(ctx, orgID, domain.MCPServerInput{Name: "search", URL: srv.URL}, nil); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	if _, ok := r.Get(otherOrg, "search"); ok {
+		t.Error("another organization can see the server")
+	}
+	if _, ok := r.MCPServer(otherOrg, "search"); ok {
+		t.Error("another organization can call the server")
+	}
+	for _, server := range r.List(otherOrg) {
+		if server.Name == "search" {
+			t.Error("another organization lists the server")
+		}
+	}
+	if _, err := r.Update(ctx, otherOrg, "search", domain.MCPServerUpdate{URL: elsewhere.URL}); !errors.Is(err, ErrServerNotFound) {
+		t.Errorf("another organization updating the server: err = %v, want ErrServerNotFound", err)
+	}
+	if _, err := r.Remove(ctx, otherOrg, "search"); !errors.Is(err, ErrServerNotFound) {
+		t.Errorf("another organization removing the server: err = %v, want ErrServerNotFound", err)
+	}
+	if cfg, ok := r.MCPServer(orgID, "search"); !ok || cfg.URL != srv.URL {
+		t.Errorf("owner's server after another organization's attempts = %+v, %v", cfg, ok)
+	}
+
+	// Each organization may use the name for its own server
+	if _, err := r.Register(ctx, otherOrg, domain.MCPServerInput{Name: "search", URL: elsewhere.URL}, nil); err != nil {
+		t.Fatalf("Register for another organization: %v", err)
+	}
+	mine, _ := r.MCPServer(orgID, "search")
+	theirs, _ := r.MCPServer(otherOrg, "search")
+	if mine.URL != srv.URL || theirs.URL != elsewhere.URL || mine.CacheKey() == theirs.CacheKey() {
+		t.Errorf("servers of the same name: %s (%s) and %s (%s)", mine.URL, mine.CacheKey(), theirs.URL, theirs.CacheKey())
+	}
+
+	// Configured servers are shared
+	if _, ok := r.MCPServer(otherOrg, "configured"); !ok {
+		t.Error("configured server is not available to every organization")
+	}
+}
@@ -0,0 +1,146 @@
+// Package metrics exposes gateway internals in the Prometheus exposition format.
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/alerting"
+	"github.com/akz4ol/gatewayops/gateway/internal/approval"
+	"github.com/akz4ol/gatewayops/gateway/internal/middleware"
+	"github.com/akz4ol/gatewayops/gateway/internal/otel"
+	"github.com/akz4ol/gatewayops/gateway/internal/safety"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "gatewayops"
+
+// Sources are the components whose existing counters are read at scrape time.
+// Any of them may be nil.
+type Sources struct {
+	Telemetry   *otel.Exporter
+	Safety      *safety.Detector
+	Alerts      *alerting.Service
+	Approvals   *approval.Service
+	Concurrency *middleware.ConcurrencyLimiter
+}
+
+// Registry owns the gateway's Prometheus collectors. Collectors are registered
+// once in NewRegistry on a private registry, so creating a second Registry
+// never panics on duplicate registration.
+type Registry struct {
+	registry *prometheus.Registry
+
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+
+	mcpCalls    *prometheus.CounterVec
+	mcpDuration *prometheus.HistogramVec
+	mcpCost     *prometheus.CounterVec
+
+	rateLimitRejections prometheus.Counter
+}
+
+// NewRegistry creates a registry with all gateway collectors registered.
+func NewRegistry(src Sources) *Registry {
+	m := &Registry{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests handled, by route pattern, method and status code.",
+		}, []string{"route", "method", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by route pattern and method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method"}),
+		mcpCalls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "mcp_calls_total",
+			Help:      "MCP calls proxied, by server, tool and outcome.",
+		}, []string{"server", "tool", "status"}),
+		mcpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "mcp_call_duration_seconds",
+			Help:      "MCP call latency by server and tool.",
+			Buckets:   []float64{.01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+		}, []string{"server", "tool"}),
+		mcpCost: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "mcp_call_cost_dollars_total",
+			Help:      "Cost of MCP calls in dollars, by server and tool.",
+		}, []string{"server", "tool"}),
+		rateLimitRejections: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "rate_limit_rejections_total",
+			Help:      "Requests rejected by the rate limiter.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.mcpCalls,
+		m.mcpDuration,
+		m.mcpCost,
+		m.rateLimitRejections,
+		newSourceCollector(src),
+	)
+
+	return m
+}
+
+// Handler returns the HTTP handler serving the exposition format.
+func (m *Registry) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// ObserveRequest records a completed HTTP request. It implements
+// middleware.RequestObserver.
+func (m *Registry) ObserveRequest(method, route string, status int, duration time.Duration) {
+	m.httpRequests.WithLabelValues(route, method, strconv.Itoa(status)).Inc()
+	m.httpDuration.WithLabelValues(route, method).Observe(duration.Seconds())
+}
+
+// ObserveMCPCall records a completed MCP call. A nil Registry is a no-op so
+// handlers can be built without metrics.
+func (m *Registry) ObserveMCPCall(server, tool string, isError bool, duration time.Duration, cost float64) {
+	if m == nil {
+		return
+	}
+	status := "success"
+	if isError {
+		status = "error"
+	}
+	m.mcpCalls.WithLabelValues(server, tool, status).Inc()
+	m.mcpDuration.WithLabelValues(server, tool).Observe(duration.Seconds())
+	if cost > 0 {
+		m.mcpCost.WithLabelValues(server, tool).Add(cost)
+	}
+}
+
+// InstrumentRateLimiter wraps limiter so that rejected requests are counted.
+func (m *Registry) InstrumentRateLimiter(limiter middleware.RateLimiter) middleware.RateLimiter {
+	return &countingLimiter{limiter: limiter, rejections: m.rateLimitRejections}
+}
+
+type countingLimiter struct {
+	limiter    middleware.RateLimiter
+	rejections prometheus.Counter
+}
+
+func (l *countingLimiter) Allow(ctx context.Context, key string, limit int) (bool, int, int, error) {
+	allowed, remaining, reset, err := l.limiter.Allow(ctx, key, limit)
+	if err == nil && !allowed {
+		l.rejections.Inc()
+	}
+	return allowed, remaining, reset, err
+}
@@ -0,0 +1,153 @@
+package metrics
+
+import (
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/ringbuf"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	telemetryExportedDesc = prometheus.NewDesc(
+		namespace+"_otel_exported_total",
+		"Telemetry items exported over OTLP, by signal.",
+		[]string{"signal"}, nil,
+	)
+	telemetryErrorsDesc = prometheus.NewDesc(
+		namespace+"_otel_export_errors_total",
+		"Failed OTLP export attempts.",
+		nil, nil,
+	)
+	telemetryBytesDesc = prometheus.NewDesc(
+		namespace+"_otel_export_bytes_total",
+		"Bytes sent to OTLP collectors.",
+		nil, nil,
+	)
+	safetyDetectionsDesc = prometheus.NewDesc(
+		namespace+"_safety_detections_total",
+		"Prompt injection detections, by severity.",
+		[]string{"severity"}, nil,
+	)
+	activeAlertsDesc = prometheus.NewDesc(
+		namespace+"_alerts_active",
+		"Alerts currently firing, by severity.",
+		[]string{"severity"}, nil,
+	)
+	bufferItemsDesc = prometheus.NewDesc(
+		namespace+"_buffer_items",
+		"Items held in an in-memory buffer of recent items, by buffer.",
+		[]string{"buffer"}, nil,
+	)
+	bufferCapacityDesc = prometheus.NewDesc(
+		namespace+"_buffer_capacity",
+		"Maximum items an in-memory buffer holds, by buffer.",
+		[]string{"buffer"}, nil,
+	)
+	bufferDroppedDesc = prometheus.NewDesc(
+		namespace+"_buffer_dropped_total",
+		"Items evicted from a full in-memory buffer, by buffer.",
+		[]string{"buffer"}, nil,
+	)
+	inFlightDesc = prometheus.NewDesc(
+		namespace+"_http_requests_in_flight",
+		"HTTP requests being served, excluding health checks and scrapes.",
+		nil, nil,
+	)
+	shedDesc = prometheus.NewDesc(
+		namespace+"_http_requests_shed_total",
+		"HTTP requests rejected with 503 for exceeding a concurrency limit.",
+		nil, nil,
+	)
+	routeInFlightDesc = prometheus.NewDesc(
+		namespace+"_http_route_requests_in_flight",
+		"HTTP requests being served under a path prefix with its own concurrency limit, by prefix.",
+		[]string{"prefix"}, nil,
+	)
+	routeShedDesc = prometheus.NewDesc(
+		namespace+"_http_route_requests_shed_total",
+		"HTTP requests rejected with 503 for exceeding a path prefix's concurrency limit, by prefix.",
+		[]string{"prefix"}, nil,
+	)
+)
+
+// sourceCollector reads counters the gateway already keeps and reports them
+// at scrape time, so those components need no Prometheus-specific code.
+type sourceCollector struct {
+	src Sources
+}
+
+func newSourceCollector(src Sources) *sourceCollector {
+	return &sourceCollector{src: src}
+}
+
+// Describe implements prometheus.Collector.
+func (c *sourceCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- telemetryExportedDesc
+	ch <- telemetryErrorsDesc
+	ch <- telemetryBytesDesc
+	ch <- safetyDetectionsDesc
+	ch <- activeAlertsDesc
+	ch <- bufferItemsDesc
+	ch <- bufferCapacityDesc
+	ch <- bufferDroppedDesc
+	ch <- inFlightDesc
+	ch <- shedDesc
+	ch <- routeInFlightDesc
+	ch <- routeShedDesc
+}
+
+// Collect implements prometheus.Collector.
+func (c *sourceCollector) Collect(ch chan<- prometheus.Metric) {
+	if c.src.Telemetry != nil {
+		stats := c.src.Telemetry.GetStats()
+		ch <- prometheus.MustNewConstMetric(telemetryExportedDesc, prometheus.CounterValue, float64(stats.TracesExported), "traces")
+		ch <- prometheus.MustNewConstMetric(telemetryExportedDesc, prometheus.CounterValue, float64(stats.MetricsExported), "metrics")
+		ch <- prometheus.MustNewConstMetric(telemetryExportedDesc, prometheus.CounterValue, float64(stats.LogsExported), "logs")
+		ch <- prometheus.MustNewConstMetric(telemetryErrorsDesc, prometheus.CounterValue, float64(stats.ExportErrors))
+		ch <- prometheus.MustNewConstMetric(telemetryBytesDesc, prometheus.CounterValue, float64(stats.BytesSent))
+	}
+
+	if c.src.Safety != nil {
+		collectBuffer(ch, "detections", c.src.Safety.BufferStats())
+		counts := c.src.Safety.DetectionCounts()
+		for _, severity := range []domain.DetectionSeverity{
+			domain.DetectionSeverityLow,
+			domain.DetectionSeverityMedium,
+			domain.DetectionSeverityHigh,
+			domain.DetectionSeverityCritical,
+		} {
+			ch <- prometheus.MustNewConstMetric(safetyDetectionsDesc, prometheus.CounterValue, float64(counts[severity]), string(severity))
+		}
+	}
+
+	if c.src.Alerts != nil {
+		collectBuffer(ch, "alerts", c.src.Alerts.BufferStats())
+		counts := c.src.Alerts.ActiveAlertCounts()
+		for _, severity := range []domain.AlertSeverity{
+			domain.AlertSeverityInfo,
+			domain.AlertSeverityWarning,
+			domain.AlertSeverityCritical,
+		} {
+			ch <- prometheus.MustNewConstMetric(activeAlertsDesc, prometheus.GaugeValue, float64(counts[severity]), string(severity))
+		}
+	}
+
+	if c.src.Approvals != nil {
+		collectBuffer(ch, "approvals", c.src.Approvals.BufferStats())
+	}
+
+	if c.src.Concurrency != nil {
+		stats := c.src.Concurrency.Stats()
+		ch <- prometheus.MustNewConstMetric(inFlightDesc, prometheus.GaugeValue, float64(stats.InFlight))
+		ch <- prometheus.MustNewConstMetric(shedDesc, prometheus.CounterValue, float64(stats.Shed))
+		for _, route := range stats.Routes {
+			ch <- prometheus.MustNewConstMetric(routeInFlightDesc, prometheus.GaugeValue, float64(route.InFlight), route.Prefix)
+			ch <- prometheus.MustNewConstMetric(routeShedDesc, prometheus.CounterValue, float64(route.Shed), route.Prefix)
+		}
+	}
+}
+
+func collectBuffer(ch chan<- prometheus.Metric, buffer string, stats ringbuf.Stats) {
+	ch <- prometheus.MustNewConstMetric(bufferItemsDesc, prometheus.GaugeValue, float64(stats.Size), buffer)
+	ch <- prometheus.MustNewConstMetric(bufferCapacityDesc, prometheus.GaugeValue, float64(stats.Capacity), buffer)
+	ch <- prometheus.MustNewConstMetric(bufferDroppedDesc, prometheus.CounterValue, float64(stats.Dropped), buffer)
+}
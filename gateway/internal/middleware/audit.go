@@ -12,7 +12,6 @@ import (
 	"github.com/akz4ol/gatewayops/gateway/internal/audit"
 	"github.com/akz4ol/gatewayops/gateway/internal/domain"
 	"github.com/go-chi/chi/v5"
-	chimiddleware "github.com/go-chi/chi/v5/middleware"
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 )
@@ -79,28 +78,25 @@ func Audit(logger AuditLogger, zlog zerolog.Logger) func(http.Handler) http.Hand
 			outcome := determineOutcome(wrapped.statusCode)
 
 			// Get auth info
-			var userID, apiKeyID *uuid.UUID
-			orgID := uuid.MustParse("00000000-0000-0000-0000-000000000001") // Demo org
+			var userID *uuid.UUID
 			if authInfo := GetAuthInfo(r.Context()); authInfo != nil {
-				apiKeyID = &authInfo.APIKeyID
-				orgID = authInfo.OrgID
 				userID = &authInfo.UserID
 			}
 
 			// Log the event
 			event := audit.Event{
-				OrgID:      orgID,
+				OrgID:      GetOrgID(r.Context()),
 				UserID:     userID,
-				APIKeyID:   apiKeyID,
-				TraceID:    chimiddleware.GetReqID(r.Context()),
+				APIKeyID:   GetAPIKeyID(r.Context()),
+				TraceID:    GetTraceID(r.Context()),
 				Action:     action,
 				Resource:   resource,
 				ResourceID: resourceID,
 				Outcome:    outcome,
 				Details:    details,
-				IPAddress:  r.RemoteAddr,
+				IPAddress:  RequestClientIP(r),
 				UserAgent:  r.UserAgent(),
-				RequestID:  chimiddleware.GetReqID(r.Context()),
+				RequestID:  GetRequestID(r.Context()),
 				DurationMS: time.Since(start).Milliseconds(),
 			}
 
@@ -165,6 +161,19 @@ func determineAction(r *http.Request) (domain.AuditAction, string, string) {
 		}
 	}
 
+	// Pattern library endpoints
+	if strings.Contains(path, "/safety/patterns") {
+		libraryID := chi.URLParam(r, "libraryID")
+		switch r.Method {
+		case http.MethodPost:
+			return domain.AuditActionLibraryCreate, "pattern_library", ""
+		case http.MethodPut:
+			return domain.AuditActionLibraryUpdate, "pattern_library", libraryID
+		case http.MethodDelete:
+			return domain.AuditActionLibraryDelete, "pattern_library", libraryID
+		}
+	}
+
 	// Role endpoints
 	if strings.Contains(path, "/roles") {
 		roleID := chi.URLParam(r, "roleID")
@@ -186,7 +195,7 @@ func determineOutcome(statusCode int) domain.AuditOutcome {
 	switch {
 	case statusCode >= 200 && statusCode < 300:
 		return domain.AuditOutcomeSuccess
-	case statusCode == 400 && statusCode == 403:
+	case statusCode == 400 || statusCode == 403:
 		return domain.AuditOutcomeBlocked
 	default:
 		return domain.AuditOutcomeFailure
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+)
+
+func TestDetermineOutcome(t *testing.T) {
+	tests := []struct {
+		status int
+		want   domain.AuditOutcome
+	}{
+		{http.StatusOK, domain.AuditOutcomeSuccess},
+		{http.StatusCreated, domain.AuditOutcomeSuccess},
+		{http.StatusBadRequest, domain.AuditOutcomeBlocked},
+		{http.StatusForbidden, domain.AuditOutcomeBlocked},
+		{http.StatusUnauthorized, domain.AuditOutcomeFailure},
+		{http.StatusNotFound, domain.AuditOutcomeFailure},
+		{http.StatusInternalServerError, domain.AuditOutcomeFailure},
+	}
+	for _, tt := range tests {
+		if got := determineOutcome(tt.status); got != tt.want {
+			t.Errorf("determineOutcome(%d) = %s, want %s", tt.status, got, tt.want)
+		}
+	}
+}
@@ -2,15 +2,19 @@ package middleware
 
 import (
 	"context"
+	"net"
 	"net/http"
 	"strings"
 
+	"github.com/akz4ol/gatewayops/gateway/internal/audit"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
 	"github.com/akz4ol/gatewayops/gateway/internal/response"
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 )
 
-// AuthInfo contains authenticated API key information.
+// AuthInfo is the principal a request acts as: the API key or signed-in
+// user it was authenticated with, their org, and their permissions.
 type AuthInfo struct {
 	KeyID       string
 	APIKeyID    uuid.UUID
@@ -20,65 +24,200 @@ type AuthInfo struct {
 	Environment string
 	Permissions []string
 	RateLimit   int
+
+	// AllowedCIDRs and OrgAllowedCIDRs restrict the client IPs the key or
+	// session may be used from. An empty list allows every address.
+	AllowedCIDRs    []string
+	OrgAllowedCIDRs []string
 }
 
 // Context key for auth info.
 const AuthInfoKey contextKey = "auth_info"
 
-// AuthStore defines the interface for API key validation.
+// DemoOrgID and DemoUserID identify the organization and user that
+// unauthenticated requests act as in demo mode.
+var (
+	DemoOrgID  = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	DemoUserID = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+)
+
+// AuthStore defines the interface for API key and session validation.
 type AuthStore interface {
 	ValidateAPIKey(ctx context.Context, apiKey string) (*AuthInfo, error)
+	ValidateSession(ctx context.Context, token string) (*AuthInfo, error)
 }
 
-// Auth returns middleware that validates API keys.
-func Auth(store AuthStore, logger zerolog.Logger) func(http.Handler) http.Handler {
+// SessionHeader carries an SSO session token.
+const SessionHeader = "X-Session-Token"
+
+// Auth returns middleware that validates API keys. Requests already
+// authenticated by an earlier middleware pass through. Requests from an IP
+// outside the key's or org's allowlist are rejected and, when auditLogger is
+// set, recorded in the audit log.
+func Auth(store AuthStore, auditLogger AuditLogger, logger zerolog.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Extract API key from Authorization header
-			authHeader := r.Header.Get("Authorization")
-			if authHeader == "" {
-				response.WriteError(w, http.StatusUnauthorized, "missing_auth", "Authorization header is required")
+			if GetAuthInfo(r.Context()) != nil {
+				next.ServeHTTP(w, r)
 				return
 			}
 
-			// Expect "Bearer <api_key>" format
-			parts := strings.SplitN(authHeader, " ", 2)
-			if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-				response.WriteError(w, http.StatusUnauthorized, "invalid_auth", "Authorization header must be in format: Bearer <api_key>")
+			// Extract API key from Authorization header
+			if r.Header.Get("Authorization") == "" {
+				response.WriteError(w, http.StatusUnauthorized, response.CodeMissingAuth, "Authorization header is required")
 				return
 			}
 
-			apiKey := parts[1]
+			authenticate(w, r, next, store, auditLogger, logger)
+		})
+	}
+}
 
-			// Validate API key format: gwo_{env}_{32chars}
-			if !isValidAPIKeyFormat(apiKey) {
-				response.WriteError(w, http.StatusUnauthorized, "invalid_api_key", "Invalid API key format")
+// Authenticate returns middleware that resolves, once per request, who the
+// request acts as. A bearer API key in the Authorization header is validated
+// as by Auth; without one, an SSO session token in the X-Session-Token header
+// signs the request in as the session's user, within the org's IP allowlist.
+// Requests with neither act as the demo org when demoMode is set and are
+// rejected otherwise. Handlers read the result through GetAuthInfo, GetOrgID
+// and GetUserID.
+func Authenticate(store AuthStore, auditLogger AuditLogger, logger zerolog.Logger, demoMode bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "" {
+				authenticate(w, r, next, store, auditLogger, logger)
 				return
 			}
 
-			// Validate against store
-			authInfo, err := store.ValidateAPIKey(r.Context(), apiKey)
-			if err != nil {
-				logger.Warn().
-					Err(err).
-					Str("api_key_prefix", apiKey[:12]+"...").
-					Msg("API key validation failed")
-				response.WriteError(w, http.StatusUnauthorized, "invalid_api_key", "Invalid or expired API key")
+			// The session cookie is not accepted here, so that other sites
+			// cannot make requests on a signed-in browser's behalf
+			if token := r.Header.Get(SessionHeader); token != "" {
+				authInfo, err := store.ValidateSession(r.Context(), token)
+				if err != nil {
+					response.WriteError(w, http.StatusUnauthorized, response.CodeInvalidAuth, "Invalid or expired session")
+					return
+				}
+				if !clientIPAllowed(w, r, authInfo, auditLogger, logger) {
+					return
+				}
+				logger.Debug().
+					Str("user_id", authInfo.UserID.String()).
+					Str("org_id", authInfo.OrgID.String()).
+					Msg("Request authenticated by session")
+				next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), AuthInfoKey, authInfo)))
 				return
 			}
 
-			// Add auth info to context
-			ctx := context.WithValue(r.Context(), AuthInfoKey, authInfo)
+			if !demoMode {
+				response.WriteError(w, http.StatusUnauthorized, response.CodeMissingAuth, "Authorization header is required")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
 
-			logger.Debug().
-				Str("key_id", authInfo.KeyID).
-				Str("org_id", authInfo.OrgID.String()).
-				Str("env", authInfo.Environment).
-				Msg("Request authenticated")
+// authenticate validates the request's bearer API key and calls next with the
+// key's auth info in the context, or writes a 401, or a 403 when the client
+// IP is not allowed to use the key.
+func authenticate(w http.ResponseWriter, r *http.Request, next http.Handler, store AuthStore, auditLogger AuditLogger, logger zerolog.Logger) {
+	// Expect "Bearer <api_key>" format
+	parts := strings.SplitN(r.Header.Get("Authorization"), " ", 2)
+	if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
+		response.WriteError(w, http.StatusUnauthorized, response.CodeInvalidAuth, "Authorization header must be in format: Bearer <api_key>")
+		return
+	}
 
-			next.ServeHTTP(w, r.WithContext(ctx))
-		})
+	apiKey := parts[1]
+
+	// Validate API key format: gwo_{env}_{32chars}
+	if !isValidAPIKeyFormat(apiKey) {
+		response.WriteError(w, http.StatusUnauthorized, response.CodeInvalidAPIKey, "Invalid API key format")
+		return
+	}
+
+	// Validate against store
+	authInfo, err := store.ValidateAPIKey(r.Context(), apiKey)
+	if err != nil {
+		logger.Warn().
+			Err(err).
+			Str("api_key_prefix", apiKey[:12]+"...").
+			Msg("API key validation failed")
+		response.WriteError(w, http.StatusUnauthorized, response.CodeInvalidAPIKey, "Invalid or expired API key")
+		return
+	}
+
+	if !clientIPAllowed(w, r, authInfo, auditLogger, logger) {
+		return
 	}
+
+	// Add auth info to context
+	ctx := context.WithValue(r.Context(), AuthInfoKey, authInfo)
+
+	logger.Debug().
+		Str("key_id", authInfo.KeyID).
+		Str("org_id", authInfo.OrgID.String()).
+		Str("env", authInfo.Environment).
+		Msg("Request authenticated")
+
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// clientIPAllowed reports whether the request's client IP is within the
+// allowlists of the key and org it authenticated as. When it is not, the
+// denial is logged and audited and a 403 is written.
+func clientIPAllowed(w http.ResponseWriter, r *http.Request, authInfo *AuthInfo, auditLogger AuditLogger, logger zerolog.Logger) bool {
+	clientIP := RequestClientIP(r)
+	if ipAllowed(clientIP, authInfo.AllowedCIDRs) && ipAllowed(clientIP, authInfo.OrgAllowedCIDRs) {
+		return true
+	}
+
+	event := audit.Event{
+		OrgID:     authInfo.OrgID,
+		UserID:    &authInfo.UserID,
+		TraceID:   GetTraceID(r.Context()),
+		Outcome:   domain.AuditOutcomeBlocked,
+		Details:   map[string]interface{}{"path": r.URL.Path},
+		IPAddress: clientIP,
+		UserAgent: r.UserAgent(),
+		RequestID: GetRequestID(r.Context()),
+	}
+	message := "This API key cannot be used from your IP address"
+	if authInfo.APIKeyID != uuid.Nil {
+		event.APIKeyID = &authInfo.APIKeyID
+		event.Action = domain.AuditActionAPIKeyIPDenied
+		event.Resource = "api_key"
+		event.ResourceID = authInfo.APIKeyID.String()
+	} else {
+		event.Action = domain.AuditActionSessionIPDenied
+		event.Resource = "session"
+		event.ResourceID = strings.TrimPrefix(authInfo.KeyID, "session:")
+		message = "Your organization does not allow sign-in from your IP address"
+	}
+
+	logger.Warn().
+		Str("key_id", authInfo.KeyID).
+		Str("org_id", authInfo.OrgID.String()).
+		Str("client_ip", clientIP).
+		Msg("Request from a disallowed IP address")
+	if auditLogger != nil {
+		auditLogger.LogEvent(r.Context(), event)
+	}
+	response.WriteError(w, http.StatusForbidden, response.CodeIPNotAllowed, message)
+	return false
+}
+
+// ipAllowed reports whether ip falls within the allowlist. An empty list
+// allows every address; a list that does not parse allows none.
+func ipAllowed(ip string, allowlist []string) bool {
+	if len(allowlist) == 0 {
+		return true
+	}
+	nets, err := ParseCIDRs(allowlist)
+	if err != nil {
+		return false
+	}
+	parsed := net.ParseIP(ip)
+	return parsed != nil && containsIP(nets, parsed)
 }
 
 // isValidAPIKeyFormat checks if API key matches expected format.
@@ -106,6 +245,61 @@ func isValidAPIKeyFormat(key string) bool {
 	return hasValidEnv
 }
 
+// HasPermission reports whether the API key grants the permission,
+// honoring "*" and prefix wildcards such as "costs:*".
+func (a *AuthInfo) HasPermission(perm domain.Permission) bool {
+	role := domain.Role{Permissions: make([]domain.Permission, 0, len(a.Permissions))}
+	for _, p := range a.Permissions {
+		role.Permissions = append(role.Permissions, domain.Permission(p))
+	}
+	return role.HasPermission(perm)
+}
+
+// ServerAllowed reports whether the principal may use an MCP server. A key
+// holding mcp:call:<server> permissions is scoped to those servers; any
+// other principal may use every server.
+func (a *AuthInfo) ServerAllowed(server string) bool {
+	prefix := string(domain.PermissionMCPCall) + ":"
+	scoped := false
+	for _, p := range a.Permissions {
+		if !strings.HasPrefix(p, prefix) || p == prefix+"*" {
+			continue
+		}
+		if strings.TrimPrefix(p, prefix) == server {
+			return true
+		}
+		scoped = true
+	}
+	return !scoped
+}
+
+// HasPermission reports whether the request may use perm. Unauthenticated
+// requests only reach handlers in demo mode, where they act with full access
+// to the demo org.
+func HasPermission(ctx context.Context, perm domain.Permission) bool {
+	authInfo := GetAuthInfo(ctx)
+	return authInfo == nil || authInfo.HasPermission(perm)
+}
+
+// RequirePermission returns middleware that rejects requests whose API key
+// lacks the permission. It must run after Auth.
+func RequirePermission(perm domain.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authInfo := GetAuthInfo(r.Context())
+			if authInfo == nil {
+				response.WriteError(w, http.StatusUnauthorized, response.CodeMissingAuth, "Authentication is required")
+				return
+			}
+			if !authInfo.HasPermission(perm) {
+				response.WriteError(w, http.StatusForbidden, response.CodeForbidden, "API key lacks the "+string(perm)+" permission")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 // GetAuthInfo extracts auth info from context.
 func GetAuthInfo(ctx context.Context) *AuthInfo {
 	if info, ok := ctx.Value(AuthInfoKey).(*AuthInfo); ok {
@@ -113,3 +307,31 @@ func GetAuthInfo(ctx context.Context) *AuthInfo {
 	}
 	return nil
 }
+
+// GetOrgID returns the authenticated organization, or the demo org for
+// unauthenticated requests.
+func GetOrgID(ctx context.Context) uuid.UUID {
+	if info := GetAuthInfo(ctx); info != nil {
+		return info.OrgID
+	}
+	return DemoOrgID
+}
+
+// GetUserID returns the authenticated user, or the demo user for
+// unauthenticated requests.
+func GetUserID(ctx context.Context) uuid.UUID {
+	if info := GetAuthInfo(ctx); info != nil {
+		return info.UserID
+	}
+	return DemoUserID
+}
+
+// GetAPIKeyID returns the API key the request was authenticated with, or nil
+// for session and unauthenticated requests.
+func GetAPIKeyID(ctx context.Context) *uuid.UUID {
+	if info := GetAuthInfo(ctx); info != nil && info.APIKeyID != uuid.Nil {
+		id := info.APIKeyID
+		return &id
+	}
+	return nil
+}
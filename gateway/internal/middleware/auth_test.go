@@ -0,0 +1,225 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/audit"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+const testAPIKey = "gwo_prd_0123456789abcdef0123456789abcdef"
+
+// fakeAuthStore resolves a fixed API key and session token.
+type fakeAuthStore struct {
+	key     *AuthInfo
+	session *AuthInfo
+}
+
+func (s *fakeAuthStore) ValidateAPIKey(ctx context.Context, apiKey string) (*AuthInfo, error) {
+	if apiKey != testAPIKey || s.key == nil {
+		return nil, errors.New("invalid API key")
+	}
+	return s.key, nil
+}
+
+func (s *fakeAuthStore) ValidateSession(ctx context.Context, token string) (*AuthInfo, error) {
+	if token != "session-token" || s.session == nil {
+		return nil, errors.New("invalid session")
+	}
+	return s.session, nil
+}
+
+// recordingAuditLogger keeps the events it is given.
+type recordingAuditLogger struct {
+	mu     sync.Mutex
+	events []audit.Event
+}
+
+func (l *recordingAuditLogger) LogEvent(ctx context.Context, event audit.Event) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, event)
+}
+
+// serveAuthenticated sends req through ClientIP and Authenticate, and reports
+// the response status and whether the handler was reached.
+func serveAuthenticated(t *testing.T, store AuthStore, auditLogger AuditLogger, trusted []string, req *http.Request) (int, bool) {
+	t.Helper()
+	proxies, err := ParseCIDRs(trusted)
+	if err != nil {
+		t.Fatalf("ParseCIDRs: %v", err)
+	}
+	reached := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+		w.WriteHeader(http.StatusOK)
+	})
+	h := ClientIP(proxies)(Authenticate(store, auditLogger, zerolog.Nop(), false)(next))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec.Code, reached
+}
+
+func TestAuthenticateOrgIPAllowlist(t *testing.T) {
+	orgID := uuid.New()
+	key := &AuthInfo{
+		KeyID:           "gwo_prd_01234567",
+		APIKeyID:        uuid.New(),
+		OrgID:           orgID,
+		OrgAllowedCIDRs: []string{"203.0.113.0/24"},
+	}
+	session := &AuthInfo{
+		KeyID:           "session:" + uuid.NewString(),
+		UserID:          uuid.New(),
+		OrgID:           orgID,
+		OrgAllowedCIDRs: []string{"203.0.113.0/24"},
+	}
+	trustedProxy := []string{"10.0.0.1"}
+
+	tests := []struct {
+		name          string
+		session       bool
+		remoteAddr    string
+		forwardedFor  string
+		wantStatus    int
+		wantAuditedAs domain.AuditAction
+	}{
+		{name: "key inside range", remoteAddr: "203.0.113.7:5000", wantStatus: http.StatusOK},
+		{name: "key outside range", remoteAddr: "198.51.100.7:5000", wantStatus: http.StatusForbidden, wantAuditedAs: domain.AuditActionAPIKeyIPDenied},
+		{name: "key via trusted proxy", remoteAddr: "10.0.0.1:5000", forwardedFor: "203.0.113.7", wantStatus: http.StatusOK},
+		{name: "key spoofing forwarded-for", remoteAddr: "198.51.100.7:5000", forwardedFor: "203.0.113.7", wantStatus: http.StatusForbidden, wantAuditedAs: domain.AuditActionAPIKeyIPDenied},
+		{name: "session inside range", session: true, remoteAddr: "203.0.113.7:5000", wantStatus: http.StatusOK},
+		{name: "session outside range", session: true, remoteAddr: "198.51.100.7:5000", wantStatus: http.StatusForbidden, wantAuditedAs: domain.AuditActionSessionIPDenied},
+		{name: "session spoofing forwarded-for", session: true, remoteAddr: "198.51.100.7:5000", forwardedFor: "203.0.113.7", wantStatus: http.StatusForbidden, wantAuditedAs: domain.AuditActionSessionIPDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/traces", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+			if tt.session {
+				req.Header.Set(SessionHeader, "session-token")
+			} else {
+				req.Header.Set("Authorization", "Bearer "+testAPIKey)
+			}
+
+			auditLogger := &recordingAuditLogger{}
+			status, reached := serveAuthenticated(t, &fakeAuthStore{key: key, session: session}, auditLogger, trustedProxy, req)
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", status, tt.wantStatus)
+			}
+			if reached != (tt.wantStatus == http.StatusOK) {
+				t.Fatalf("handler reached = %v with status %d", reached, status)
+			}
+
+			if tt.wantAuditedAs == "" {
+				if len(auditLogger.events) != 0 {
+					t.Fatalf("audited %d events for an allowed request", len(auditLogger.events))
+				}
+				return
+			}
+			if len(auditLogger.events) != 1 {
+				t.Fatalf("audited %d events, want 1", len(auditLogger.events))
+			}
+			event := auditLogger.events[0]
+			if event.Action != tt.wantAuditedAs || event.Outcome != domain.AuditOutcomeBlocked {
+				t.Errorf("audited %s/%s, want %s/%s", event.Action, event.Outcome, tt.wantAuditedAs, domain.AuditOutcomeBlocked)
+			}
+			if event.IPAddress != "198.51.100.7" {
+				t.Errorf("audited IP %q, want the peer address", event.IPAddress)
+			}
+		})
+	}
+}
+
+func TestAuthenticateKeyAllowlist(t *testing.T) {
+	key := &AuthInfo{
+		KeyID:        "gwo_prd_01234567",
+		APIKeyID:     uuid.New(),
+		OrgID:        uuid.New(),
+		AllowedCIDRs: []string{"2001:db8::/32", "192.0.2.10"},
+	}
+
+	for _, tt := range []struct {
+		remoteAddr string
+		want       int
+	}{
+		{"192.0.2.10:443", http.StatusOK},
+		{"192.0.2.11:443", http.StatusForbidden},
+		{net.JoinHostPort("2001:db8::1", "443"), http.StatusOK},
+		{net.JoinHostPort("2001:db9::1", "443"), http.StatusForbidden},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/v1/traces", nil)
+		req.RemoteAddr = tt.remoteAddr
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+		if status, _ := serveAuthenticated(t, &fakeAuthStore{key: key}, nil, nil, req); status != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.remoteAddr, status, tt.want)
+		}
+	}
+}
+
+func TestIPAllowedRejectsInvalidAllowlist(t *testing.T) {
+	if !ipAllowed("198.51.100.7", nil) {
+		t.Error("empty allowlist should allow every address")
+	}
+	if ipAllowed("198.51.100.7", []string{"not-a-cidr"}) {
+		t.Error("an allowlist that does not parse should allow no address")
+	}
+}
+
+func TestAuthenticateResolvesPrincipal(t *testing.T) {
+	key := &AuthInfo{KeyID: "gwo_prd_01234567", APIKeyID: uuid.New(), OrgID: uuid.New(), UserID: uuid.New()}
+	session := &AuthInfo{KeyID: "session:" + uuid.NewString(), OrgID: uuid.New(), UserID: uuid.New()}
+	store := &fakeAuthStore{key: key, session: session}
+
+	tests := []struct {
+		name       string
+		demoMode   bool
+		header     string
+		value      string
+		wantStatus int
+		wantOrg    uuid.UUID
+		wantUser   uuid.UUID
+	}{
+		{name: "api key", header: "Authorization", value: "Bearer " + testAPIKey, wantStatus: http.StatusOK, wantOrg: key.OrgID, wantUser: key.UserID},
+		{name: "session token", header: SessionHeader, value: "session-token", wantStatus: http.StatusOK, wantOrg: session.OrgID, wantUser: session.UserID},
+		{name: "session token in demo mode", demoMode: true, header: SessionHeader, value: "session-token", wantStatus: http.StatusOK, wantOrg: session.OrgID, wantUser: session.UserID},
+		{name: "invalid session", header: SessionHeader, value: "expired", wantStatus: http.StatusUnauthorized},
+		{name: "session token as bearer", header: "Authorization", value: "Bearer session-token", wantStatus: http.StatusUnauthorized},
+		{name: "no credentials", wantStatus: http.StatusUnauthorized},
+		{name: "no credentials in demo mode", demoMode: true, wantStatus: http.StatusOK, wantOrg: DemoOrgID, wantUser: DemoUserID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotOrg, gotUser uuid.UUID
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotOrg, gotUser = GetOrgID(r.Context()), GetUserID(r.Context())
+			})
+			req := httptest.NewRequest(http.MethodGet, "/v1/rbac/me", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			rec := httptest.NewRecorder()
+			Authenticate(store, nil, zerolog.Nop(), tt.demoMode)(next).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if gotOrg != tt.wantOrg || gotUser != tt.wantUser {
+				t.Errorf("handler saw org %s user %s, want org %s user %s", gotOrg, gotUser, tt.wantOrg, tt.wantUser)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/response"
+)
+
+// MaxBodySize returns middleware that limits request bodies to limit bytes.
+// Reading past the limit fails with *http.MaxBytesError, which handlers
+// report as 413, and the connection is closed rather than drained. A limit
+// of 0 leaves bodies unlimited.
+func MaxBodySize(limit int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if limit <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Body != nil {
+				r.Body = http.MaxBytesReader(w, r.Body, limit)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// writeBodyTooLarge writes the 413 response for a body over limit bytes.
+func writeBodyTooLarge(w http.ResponseWriter, limit int64) {
+	response.WriteError(w, http.StatusRequestEntityTooLarge, response.CodeBodyTooLarge, fmt.Sprintf("Request body must not be larger than %d bytes", limit))
+}
+
+// writeBodyReadError writes the response for a request body that could not
+// be read.
+func writeBodyReadError(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeBodyTooLarge(w, tooLarge.Limit)
+		return
+	}
+	response.WriteError(w, http.StatusBadRequest, response.CodeInvalidBody, "Failed to read request body")
+}
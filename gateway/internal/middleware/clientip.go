@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ClientIPKey is the context key for the resolved client IP.
+const ClientIPKey contextKey = "client_ip"
+
+// ParseCIDRs parses a list of CIDR blocks. A bare IP address is taken as a
+// block holding just that address.
+func ParseCIDRs(values []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return nil, fmt.Errorf("%q is not an IP address or CIDR block", value)
+			}
+			bits := 8 * net.IPv4len
+			if ip.To4() == nil {
+				bits = 8 * net.IPv6len
+			}
+			value = fmt.Sprintf("%s/%d", value, bits)
+		}
+		_, n, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an IP address or CIDR block", value)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns middleware that resolves the client's IP address. The
+// X-Forwarded-For and X-Real-IP headers are honoured only when the immediate
+// peer is one of the trusted proxies, so clients cannot spoof their address
+// by sending the headers directly. The resolved address replaces r.RemoteAddr and is
+// available from GetClientIP.
+func ClientIP(trustedProxies []*net.IPNet) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := resolveClientIP(r, trustedProxies)
+			if ip != "" {
+				r.RemoteAddr = ip
+				r = r.WithContext(context.WithValue(r.Context(), ClientIPKey, ip))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// resolveClientIP walks X-Forwarded-For from the nearest hop outwards while
+// each hop is a trusted proxy, returning the first untrusted address. A
+// trusted peer that sends no X-Forwarded-For may name the client in
+// X-Real-IP instead. It returns the peer address when the peer is not trusted.
+func resolveClientIP(r *http.Request, trustedProxies []*net.IPNet) string {
+	peer := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(peer); err == nil {
+		peer = host
+	}
+	peerIP := net.ParseIP(peer)
+	if peerIP == nil {
+		return peer
+	}
+	if !containsIP(trustedProxies, peerIP) {
+		return peerIP.String()
+	}
+
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(header, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+	if len(hops) == 0 {
+		if ip := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); ip != nil {
+			return ip.String()
+		}
+		return peerIP.String()
+	}
+
+	client := peerIP
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(hops[i])
+		if ip == nil {
+			// A malformed hop was not written by a trusted proxy; stop at
+			// the last address we can vouch for
+			break
+		}
+		client = ip
+		if !containsIP(trustedProxies, ip) {
+			break
+		}
+	}
+	return client.String()
+}
+
+// GetClientIP returns the client IP resolved by ClientIP.
+func GetClientIP(ctx context.Context) string {
+	if ip, ok := ctx.Value(ClientIPKey).(string); ok {
+		return ip
+	}
+	return ""
+}
+
+// RequestClientIP returns the client IP resolved by ClientIP, falling back to
+// the peer address for requests that did not pass through it. Use it wherever
+// a client address is recorded.
+func RequestClientIP(r *http.Request) string {
+	if ip := GetClientIP(r.Context()); ip != "" {
+		return ip
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	trusted, err := ParseCIDRs([]string{"10.0.0.0/8", "192.168.1.1"})
+	if err != nil {
+		t.Fatalf("ParseCIDRs: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		peer    string
+		headers map[string][]string
+		want    string
+	}{
+		{
+			name: "direct client",
+			peer: "203.0.113.7:51234",
+			want: "203.0.113.7",
+		},
+		{
+			name:    "spoofed headers from an untrusted peer",
+			peer:    "203.0.113.7:51234",
+			headers: map[string][]string{"X-Forwarded-For": {"1.2.3.4"}, "X-Real-IP": {"5.6.7.8"}},
+			want:    "203.0.113.7",
+		},
+		{
+			name:    "one trusted proxy",
+			peer:    "10.0.0.5:443",
+			headers: map[string][]string{"X-Forwarded-For": {"198.51.100.20"}},
+			want:    "198.51.100.20",
+		},
+		{
+			name:    "chain of trusted proxies",
+			peer:    "10.0.0.5:443",
+			headers: map[string][]string{"X-Forwarded-For": {"198.51.100.20, 192.168.1.1", "10.1.2.3"}},
+			want:    "198.51.100.20",
+		},
+		{
+			name:    "address the client forged before the chain",
+			peer:    "10.0.0.5:443",
+			headers: map[string][]string{"X-Forwarded-For": {"1.2.3.4, 198.51.100.20, 10.1.2.3"}},
+			want:    "198.51.100.20",
+		},
+		{
+			name:    "malformed hop",
+			peer:    "10.0.0.5:443",
+			headers: map[string][]string{"X-Forwarded-For": {"not-an-ip, 10.1.2.3"}},
+			want:    "10.1.2.3",
+		},
+		{
+			name:    "X-Real-IP from a trusted proxy",
+			peer:    "192.168.1.1:443",
+			headers: map[string][]string{"X-Real-IP": {"198.51.100.20"}},
+			want:    "198.51.100.20",
+		},
+		{
+			name: "trusted proxy without headers",
+			peer: "10.0.0.5:443",
+			want: "10.0.0.5",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.peer
+			for name, values := range tt.headers {
+				for _, v := range values {
+					req.Header.Add(name, v)
+				}
+			}
+
+			var recorded, remoteAddr string
+			ClientIP(trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				recorded = RequestClientIP(r)
+				remoteAddr = r.RemoteAddr
+			})).ServeHTTP(httptest.NewRecorder(), req)
+
+			if recorded != tt.want || remoteAddr != tt.want {
+				t.Errorf("client IP = %q (RemoteAddr %q), want %q", recorded, remoteAddr, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCIDRsRejectsInvalidEntries(t *testing.T) {
+	for _, value := range []string{"10.0.0.0/33", "proxy.internal", ""} {
+		if _, err := ParseCIDRs([]string{value}); err == nil {
+			t.Errorf("%q was accepted", value)
+		}
+	}
+}
+
+func TestRequestClientIPFallsBackToThePeer(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "203.0.113.7:51234"
+	req.Header.Set("X-Forwarded-For", "1.2.3.4")
+	if got := RequestClientIP(req); got != "203.0.113.7" {
+		t.Errorf("RequestClientIP = %q, want the peer", got)
+	}
+}
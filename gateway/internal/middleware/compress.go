@@ -0,0 +1,252 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Compression configures response compression.
+type Compression struct {
+	Level        int      // 1 (fastest) to 9 (smallest)
+	MinSize      int      // Smallest response compressed, in bytes
+	ContentTypes []string // Media types compressed, such as application/json
+}
+
+// Compress returns middleware that compresses responses with gzip or deflate,
+// as negotiated by Accept-Encoding. Only responses of the allowed content
+// types are compressed, and only once they reach MinSize bytes; a response
+// flushed before then is taken to be a stream, such as an export, and is
+// compressed as it is written. Event streams are never compressed, so events
+// are not held back in the compressor.
+func Compress(c Compression) func(http.Handler) http.Handler {
+	types := make(map[string]bool, len(c.ContentTypes))
+	for _, t := range c.ContentTypes {
+		types[strings.ToLower(strings.TrimSpace(t))] = true
+	}
+	delete(types, "text/event-stream")
+
+	gzipPool := sync.Pool{New: func() interface{} {
+		zw, _ := gzip.NewWriterLevel(io.Discard, c.Level)
+		return zw
+	}}
+	zlibPool := sync.Pool{New: func() interface{} {
+		zw, _ := zlib.NewWriterLevel(io.Discard, c.Level)
+		return zw
+	}}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			encoding := acceptedEncoding(r.Header.Get("Accept-Encoding"))
+			if encoding == "" || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressWriter{
+				ResponseWriter: w,
+				encoding:       encoding,
+				types:          types,
+				minSize:        c.MinSize,
+				gzipPool:       &gzipPool,
+				zlibPool:       &zlibPool,
+			}
+			defer cw.Close()
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// compressWriter holds a response back until it can tell whether to
+// compress it, then writes it through the compressor or as it is.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	types    map[string]bool
+	minSize  int
+	gzipPool *sync.Pool
+	zlibPool *sync.Pool
+
+	status  int          // Status held back until decided
+	buf     bytes.Buffer // Body held back until decided
+	decided bool
+	zw      compressor // Nil when the response is not compressed
+}
+
+// compressor is a *gzip.Writer, or a *zlib.Writer for deflate, which in
+// HTTP means the zlib format.
+type compressor interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+func (cw *compressWriter) WriteHeader(code int) {
+	if code < http.StatusOK {
+		cw.ResponseWriter.WriteHeader(code) // Informational; the final status follows
+		return
+	}
+	if cw.decided || cw.status != 0 {
+		return
+	}
+	cw.status = code
+	// Bodiless responses go out as they are
+	if code == http.StatusNoContent || code == http.StatusNotModified {
+		cw.decide(false)
+	}
+}
+
+func (cw *compressWriter) Write(b []byte) (int, error) {
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+	if cw.decided {
+		if cw.zw != nil {
+			return cw.zw.Write(b)
+		}
+		return cw.ResponseWriter.Write(b)
+	}
+
+	if !cw.compressible() {
+		cw.decide(false)
+		return cw.ResponseWriter.Write(b)
+	}
+	cw.buf.Write(b)
+	if cw.buf.Len() >= cw.minSize {
+		if err := cw.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+// Flush implements http.Flusher. A response flushed before it is decided is
+// a stream, and is compressed if its content type allows.
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		if cw.status == 0 {
+			cw.status = http.StatusOK
+		}
+		cw.decide(cw.compressible())
+	}
+	if cw.zw != nil {
+		cw.zw.Flush()
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// Close writes out what is held back, uncompressed if it is still below the
+// minimum size, and ends the compressed stream.
+func (cw *compressWriter) Close() error {
+	if !cw.decided {
+		if cw.status == 0 && cw.buf.Len() == 0 {
+			return nil // Nothing was written; leave the default response alone
+		}
+		if cw.status == 0 {
+			cw.status = http.StatusOK
+		}
+		if err := cw.decide(false); err != nil {
+			return err
+		}
+	}
+	if cw.zw == nil {
+		return nil
+	}
+	err := cw.zw.Close()
+	cw.zw.Reset(io.Discard)
+	if cw.encoding == "gzip" {
+		cw.gzipPool.Put(cw.zw)
+	} else {
+		cw.zlibPool.Put(cw.zw)
+	}
+	cw.zw = nil
+	return err
+}
+
+// compressible reports whether the response may be compressed, going by its
+// headers.
+func (cw *compressWriter) compressible() bool {
+	h := cw.Header()
+	if h.Get("Content-Encoding") != "" {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(h.Get("Content-Type"))
+	return err == nil && cw.types[mediaType]
+}
+
+// decide sends the headers, compressed or not, and what is held back.
+func (cw *compressWriter) decide(compress bool) error {
+	cw.decided = true
+	h := cw.Header()
+	if cw.compressible() {
+		h.Add("Vary", "Accept-Encoding")
+	}
+	if compress {
+		h.Set("Content-Encoding", cw.encoding)
+		h.Del("Content-Length")
+		if cw.encoding == "gzip" {
+			cw.zw = cw.gzipPool.Get().(*gzip.Writer)
+		} else {
+			cw.zw = cw.zlibPool.Get().(*zlib.Writer)
+		}
+		cw.zw.Reset(cw.ResponseWriter)
+	}
+	if cw.status != 0 {
+		cw.ResponseWriter.WriteHeader(cw.status)
+	}
+	if cw.buf.Len() == 0 {
+		return nil
+	}
+	var err error
+	if cw.zw != nil {
+		_, err = cw.zw.Write(cw.buf.Bytes())
+	} else {
+		_, err = cw.ResponseWriter.Write(cw.buf.Bytes())
+	}
+	cw.buf.Reset()
+	return err
+}
+
+// acceptedEncoding returns the encoding to compress with for an
+// Accept-Encoding header, gzip before deflate, or "" for none.
+func acceptedEncoding(header string) string {
+	if header == "" {
+		return ""
+	}
+	weights := make(map[string]float64)
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		weight := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(q, 64); err == nil {
+				weight = parsed
+			}
+		}
+		weights[strings.ToLower(strings.TrimSpace(coding))] = weight
+	}
+
+	best, bestWeight := "", 0.0
+	for _, coding := range []string{"gzip", "deflate"} {
+		weight, ok := weights[coding]
+		if !ok {
+			weight, ok = weights["*"]
+		}
+		if ok && weight > bestWeight {
+			best, bestWeight = coding, weight
+		}
+	}
+	return best
+}
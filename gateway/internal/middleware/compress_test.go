@@ -0,0 +1,170 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var testCompression = Compression{
+	Level:        5,
+	MinSize:      1024,
+	ContentTypes: []string{"application/json", "text/csv", "text/event-stream"},
+}
+
+// decode returns the response body, decompressed per its Content-Encoding.
+func decode(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+	var r io.Reader = rec.Body
+	switch rec.Header().Get("Content-Encoding") {
+	case "gzip":
+		zr, err := gzip.NewReader(rec.Body)
+		if err != nil {
+			t.Fatalf("gzip: %v", err)
+		}
+		r = zr
+	case "deflate":
+		zr, err := zlib.NewReader(rec.Body)
+		if err != nil {
+			t.Fatalf("deflate: %v", err)
+		}
+		r = zr
+	}
+	body, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+	return string(body)
+}
+
+func TestAcceptedEncoding(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", ""},
+		{"gzip", "gzip"},
+		{"deflate", "deflate"},
+		{"deflate, gzip", "gzip"},
+		{"gzip;q=0.5, deflate", "deflate"},
+		{"gzip;q=0", ""},
+		{"br", ""},
+		{"*", "gzip"},
+		{"GZIP", "gzip"},
+	}
+	for _, tt := range tests {
+		if got := acceptedEncoding(tt.header); got != tt.want {
+			t.Errorf("acceptedEncoding(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestCompress(t *testing.T) {
+	large := `{"data":"` + strings.Repeat("x", 2048) + `"}`
+	small := `{"data":"x"}`
+
+	tests := []struct {
+		name           string
+		method         string
+		acceptEncoding string
+		contentType    string
+		contentEncode  string
+		status         int
+		body           string
+		wantEncoding   string
+	}{
+		{name: "large json gzip", acceptEncoding: "gzip", contentType: "application/json", body: large, wantEncoding: "gzip"},
+		{name: "large json deflate", acceptEncoding: "deflate", contentType: "application/json; charset=utf-8", body: large, wantEncoding: "deflate"},
+		{name: "small json", acceptEncoding: "gzip", contentType: "application/json", body: small},
+		{name: "no accept-encoding", contentType: "application/json", body: large},
+		{name: "other content type", acceptEncoding: "gzip", contentType: "image/png", body: large},
+		{name: "event stream", acceptEncoding: "gzip", contentType: "text/event-stream", body: large},
+		{name: "already encoded", acceptEncoding: "gzip", contentType: "application/json", contentEncode: "br", body: large, wantEncoding: "br"},
+		{name: "head", method: http.MethodHead, acceptEncoding: "gzip", contentType: "application/json"},
+		{name: "no content", acceptEncoding: "gzip", contentType: "application/json", status: http.StatusNoContent},
+		{name: "error status", acceptEncoding: "gzip", contentType: "application/json", status: http.StatusInternalServerError, body: large, wantEncoding: "gzip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := Compress(testCompression)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				if tt.contentEncode != "" {
+					w.Header().Set("Content-Encoding", tt.contentEncode)
+				}
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+				}
+				// Written in pieces, so the size is only known at the end
+				for i := 0; i < len(tt.body); i += 100 {
+					io.WriteString(w, tt.body[i:min(i+100, len(tt.body))])
+				}
+			}))
+
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, "/v1/traces", nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			wantStatus := tt.status
+			if wantStatus == 0 {
+				wantStatus = http.StatusOK
+			}
+			if rec.Code != wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, wantStatus)
+			}
+			if got := rec.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Fatalf("Content-Encoding = %q, want %q", got, tt.wantEncoding)
+			}
+			if tt.wantEncoding == "gzip" || tt.wantEncoding == "deflate" {
+				if rec.Body.Len() >= len(tt.body) {
+					t.Errorf("compressed body is %d bytes, no smaller than %d", rec.Body.Len(), len(tt.body))
+				}
+				if got := rec.Header().Get("Vary"); got != "Accept-Encoding" {
+					t.Errorf("Vary = %q, want Accept-Encoding", got)
+				}
+				if got := decode(t, rec); got != tt.body {
+					t.Errorf("decompressed body differs from the original")
+				}
+				return
+			}
+			if got := rec.Body.String(); got != tt.body {
+				t.Errorf("body = %.40q, want it unchanged", got)
+			}
+		})
+	}
+}
+
+func TestCompressFlushedStream(t *testing.T) {
+	h := Compress(testCompression)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/csv")
+		io.WriteString(w, "id,action\n")
+		w.(http.Flusher).Flush()
+		io.WriteString(w, "1,mcp.tool.call\n")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/audit-logs/export", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if !rec.Flushed {
+		t.Error("flush did not reach the client")
+	}
+	if got := rec.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("a flushed stream below the minimum size: Content-Encoding = %q, want gzip", got)
+	}
+	if got := decode(t, rec); got != "id,action\n1,mcp.tool.call\n" {
+		t.Errorf("body = %q", got)
+	}
+}
@@ -0,0 +1,153 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/response"
+	"github.com/rs/zerolog"
+)
+
+// unlimitedPaths are never limited or counted, so health checks and scrapes
+// still succeed while the gateway is shedding load.
+var unlimitedPaths = map[string]bool{
+	"/health":  true,
+	"/ready":   true,
+	"/metrics": true,
+}
+
+// ConcurrencyLimiter caps the requests served at once, overall and under
+// path prefixes with their own limit. Requests beyond a limit are shed
+// rather than queued. It is safe for concurrent use.
+type ConcurrencyLimiter struct {
+	limit      int64
+	retryAfter int           // Seconds
+	routes     []*routeLimit // Longest prefix first
+
+	inFlight int64
+	shed     int64 // All shed requests, including those shed by a prefix's limit
+}
+
+// routeLimit counts the requests under one path prefix.
+type routeLimit struct {
+	prefix   string
+	limit    int64
+	inFlight int64
+	shed     int64
+}
+
+// NewConcurrencyLimiter creates a limiter allowing limit requests at once,
+// and routeLimits[prefix] at once under each path prefix. A limit of 0 is
+// unlimited. Shed requests are told to retry after retryAfter.
+func NewConcurrencyLimiter(limit int, routeLimits map[string]int, retryAfter time.Duration) *ConcurrencyLimiter {
+	seconds := int(retryAfter.Round(time.Second) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	l := &ConcurrencyLimiter{
+		limit:      int64(limit),
+		retryAfter: seconds,
+	}
+	for prefix, n := range routeLimits {
+		l.routes = append(l.routes, &routeLimit{prefix: strings.TrimSuffix(prefix, "/"), limit: int64(n)})
+	}
+	sort.Slice(l.routes, func(i, j int) bool {
+		return len(l.routes[i].prefix) > len(l.routes[j].prefix)
+	})
+	return l
+}
+
+// route returns the limit for the longest prefix of path, or nil.
+func (l *ConcurrencyLimiter) route(path string) *routeLimit {
+	for _, route := range l.routes {
+		if path == route.prefix || strings.HasPrefix(path, route.prefix+"/") {
+			return route
+		}
+	}
+	return nil
+}
+
+// acquire counts a request against the limits, reporting whether it may be
+// served. A request that may not is counted as shed, and against the prefix
+// whose limit it exceeded.
+func (l *ConcurrencyLimiter) acquire(route *routeLimit) bool {
+	if n := atomic.AddInt64(&l.inFlight, 1); l.limit > 0 && n > l.limit {
+		atomic.AddInt64(&l.inFlight, -1)
+		atomic.AddInt64(&l.shed, 1)
+		return false
+	}
+	if route == nil {
+		return true
+	}
+	if n := atomic.AddInt64(&route.inFlight, 1); route.limit > 0 && n > route.limit {
+		atomic.AddInt64(&route.inFlight, -1)
+		atomic.AddInt64(&l.inFlight, -1)
+		atomic.AddInt64(&route.shed, 1)
+		atomic.AddInt64(&l.shed, 1)
+		return false
+	}
+	return true
+}
+
+func (l *ConcurrencyLimiter) release(route *routeLimit) {
+	if route != nil {
+		atomic.AddInt64(&route.inFlight, -1)
+	}
+	atomic.AddInt64(&l.inFlight, -1)
+}
+
+// Stats returns the requests in flight and shed, overall and per prefix.
+func (l *ConcurrencyLimiter) Stats() domain.ConcurrencyStats {
+	stats := domain.ConcurrencyStats{
+		InFlight: atomic.LoadInt64(&l.inFlight),
+		Limit:    int(l.limit),
+		Shed:     atomic.LoadInt64(&l.shed),
+	}
+	for _, route := range l.routes {
+		stats.Routes = append(stats.Routes, domain.RouteConcurrencyStats{
+			Prefix:   route.prefix,
+			InFlight: atomic.LoadInt64(&route.inFlight),
+			Limit:    int(route.limit),
+			Shed:     atomic.LoadInt64(&route.shed),
+		})
+	}
+	sort.Slice(stats.Routes, func(i, j int) bool { return stats.Routes[i].Prefix < stats.Routes[j].Prefix })
+	return stats
+}
+
+// ConcurrencyLimit returns middleware that sheds requests beyond the
+// limiter's limits with 503 and a Retry-After header. Health checks and
+// metrics scrapes are exempt.
+func ConcurrencyLimit(limiter *ConcurrencyLimiter, logger zerolog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if unlimitedPaths[r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			route := limiter.route(r.URL.Path)
+			if !limiter.acquire(route) {
+				event := logger.Warn().Str("path", r.URL.Path)
+				if route != nil {
+					event = event.Str("prefix", route.prefix)
+				}
+				event.Msg("Request shed: gateway at concurrency limit")
+
+				w.Header().Set("Retry-After", strconv.Itoa(limiter.retryAfter))
+				response.WriteError(w, http.StatusServiceUnavailable, response.CodeOverloaded,
+					fmt.Sprintf("Gateway is at capacity. Try again in %d seconds", limiter.retryAfter))
+				return
+			}
+			defer limiter.release(route)
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func TestRequestsBeyondTheLimitAreShed(t *testing.T) {
+	limiter := NewConcurrencyLimiter(2, map[string]int{"/v1/mcp": 1}, 5*time.Second)
+	entered := make(chan struct{})
+	unblock := make(chan struct{})
+	handler := ConcurrencyLimit(limiter, zerolog.Nop())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("block") != "" {
+			entered <- struct{}{}
+			<-unblock
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	serve := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	// Hold one request under /v1/mcp and one elsewhere, filling both limits
+	done := make(chan int, 2)
+	for _, path := range []string{"/v1/mcp/github/tools/call?block=1", "/v1/traces?block=1"} {
+		go func(path string) { done <- serve(path).Code }(path)
+		<-entered
+	}
+
+	for _, path := range []string{"/v1/traces", "/v1/mcp/filesystem/tools/list"} {
+		rec := serve(path)
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("%s at capacity: status %d, want %d", path, rec.Code, http.StatusServiceUnavailable)
+		}
+		if got := rec.Header().Get("Retry-After"); got != "5" {
+			t.Errorf("%s: Retry-After = %q, want 5", path, got)
+		}
+	}
+	for _, path := range []string{"/health", "/ready", "/metrics"} {
+		if rec := serve(path); rec.Code != http.StatusOK {
+			t.Errorf("%s at capacity: status %d, want %d", path, rec.Code, http.StatusOK)
+		}
+	}
+
+	stats := limiter.Stats()
+	if stats.InFlight != 2 || stats.Shed != 2 || len(stats.Routes) != 1 || stats.Routes[0].InFlight != 1 || stats.Routes[0].Shed != 0 {
+		t.Errorf("stats at capacity = %+v", stats)
+	}
+
+	close(unblock)
+	for i := 0; i < 2; i++ {
+		if code := <-done; code != http.StatusOK {
+			t.Errorf("held request: status %d, want %d", code, http.StatusOK)
+		}
+	}
+	if rec := serve("/v1/traces"); rec.Code != http.StatusOK {
+		t.Errorf("after the held requests finished: status %d, want %d", rec.Code, http.StatusOK)
+	}
+	if stats := limiter.Stats(); stats.InFlight != 0 || stats.Routes[0].InFlight != 0 {
+		t.Errorf("stats after release = %+v, want nothing in flight", stats)
+	}
+}
+
+func TestRouteLimitShedsOnlyItsPrefix(t *testing.T) {
+	limiter := NewConcurrencyLimiter(0, map[string]int{"/v1/mcp/": 1}, time.Second)
+	route := limiter.route("/v1/mcp/github/tools/call")
+	if route == nil || limiter.route("/v1/mcpx") != nil || limiter.route("/v1/traces") != nil {
+		t.Fatalf("route matching is wrong")
+	}
+	if !limiter.acquire(route) {
+		t.Fatal("first request under the prefix was shed")
+	}
+	if limiter.acquire(route) {
+		t.Error("second request under the prefix was served")
+	}
+	if !limiter.acquire(nil) {
+		t.Error("request outside the prefix was shed with no global limit")
+	}
+	if stats := limiter.Stats(); stats.Shed != 1 || stats.Routes[0].Shed != 1 || stats.Routes[0].Prefix != "/v1/mcp" {
+		t.Errorf("stats = %+v", stats)
+	}
+}
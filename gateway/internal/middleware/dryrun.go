@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// IsDryRun reports whether the request asks for a dry run, either via the
+// dry_run query parameter or the X-Dry-Run header.
+func IsDryRun(r *http.Request) bool {
+	value := r.URL.Query().Get("dry_run")
+	if value == "" {
+		value = r.Header.Get("X-Dry-Run")
+	}
+	if value == "" {
+		return false
+	}
+	dryRun, err := strconv.ParseBool(value)
+	return err == nil && dryRun
+}
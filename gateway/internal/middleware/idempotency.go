@@ -0,0 +1,162 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/idempotency"
+	"github.com/akz4ol/gatewayops/gateway/internal/response"
+	"github.com/rs/zerolog"
+)
+
+// IdempotencyKeyHeader carries the client's key for a retryable request.
+// Replayed responses carry IdempotentReplayedHeader.
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// maxIdempotencyKey caps the length of an Idempotency-Key.
+const maxIdempotencyKey = 255
+
+// maxIdempotentBody caps the request body read to fingerprint a request.
+const maxIdempotentBody = 1 << 20
+
+// IdempotencyStore defines the interface for storing idempotency records.
+type IdempotencyStore interface {
+	Begin(ctx context.Context, key, fingerprint string) (*idempotency.Record, error)
+	Complete(ctx context.Context, key string, record idempotency.Record) error
+	Release(ctx context.Context, key string) error
+}
+
+// idempotencyResponseWriter copies the response it writes so it can be
+// stored for replay.
+type idempotencyResponseWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *idempotencyResponseWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *idempotencyResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// Idempotency returns middleware that makes requests carrying an
+// Idempotency-Key safe to retry. Keys are scoped to the caller's org and the
+// request's method and path. The first request with a key is served and its
+// response stored; a retry with the same body gets that response back
+// instead of being served again. Reusing a key with a different body is
+// rejected with 422, and retrying while the first request is still being
+// served with 409. Server errors and panics are not stored, so such
+// requests can be retried in full. Requests without the header are served as
+// usual, as are all requests when the store fails.
+func Idempotency(store IdempotencyStore, logger zerolog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			clientKey := r.Header.Get(IdempotencyKeyHeader)
+			if clientKey == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(clientKey) > maxIdempotencyKey {
+				response.WriteError(w, http.StatusBadRequest, response.CodeInvalidRequest, "Idempotency-Key must be at most 255 characters")
+				return
+			}
+
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxIdempotentBody+1))
+			if err != nil {
+				writeBodyReadError(w, err)
+				return
+			}
+			if len(body) > maxIdempotentBody {
+				writeBodyTooLarge(w, maxIdempotentBody)
+				return
+			}
+			// Restore the body for the handler
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			sum := sha256.Sum256(body)
+			fingerprint := hex.EncodeToString(sum[:])
+			key := GetOrgID(r.Context()).String() + ":" + r.Method + " " + r.URL.Path + ":" + clientKey
+
+			ctx := r.Context()
+			existing, err := store.Begin(ctx, key, fingerprint)
+			if err != nil {
+				logger.Error().Err(err).Str("idempotency_key", clientKey).Msg("Idempotency store error")
+				next.ServeHTTP(w, r)
+				return
+			}
+			if existing != nil {
+				switch {
+				case existing.Fingerprint != fingerprint:
+					response.WriteError(w, http.StatusUnprocessableEntity, response.CodeIdempotencyConflict,
+						"Idempotency-Key was already used with a different request body")
+				case existing.Pending:
+					response.WriteError(w, http.StatusConflict, response.CodeIdempotencyInProgress,
+						"A request with this Idempotency-Key is still being processed")
+				default:
+					if existing.ContentType != "" {
+						w.Header().Set("Content-Type", existing.ContentType)
+					}
+					w.Header().Set(IdempotentReplayedHeader, "true")
+					w.WriteHeader(existing.Status)
+					w.Write(existing.Body)
+				}
+				return
+			}
+
+			// A handler that panics would leave the key pending, answering
+			// every retry with 409 until it expires. Release it and let the
+			// panic carry on to the recoverer.
+			defer func() {
+				if p := recover(); p != nil {
+					if err := store.Release(context.WithoutCancel(ctx), key); err != nil {
+						logger.Error().Err(err).Str("idempotency_key", clientKey).Msg("Failed to release idempotency key")
+					}
+					panic(p)
+				}
+			}()
+
+			recorder := &idempotencyResponseWriter{ResponseWriter: w}
+			next.ServeHTTP(recorder, r)
+
+			// Store the outcome even if the client has gone away, so its
+			// retry is answered
+			storeCtx := context.WithoutCancel(ctx)
+			status := recorder.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			if status >= 500 {
+				if err := store.Release(storeCtx, key); err != nil {
+					logger.Error().Err(err).Str("idempotency_key", clientKey).Msg("Failed to release idempotency key")
+				}
+				return
+			}
+			record := idempotency.Record{
+				Fingerprint: fingerprint,
+				Status:      status,
+				ContentType: w.Header().Get("Content-Type"),
+				Body:        recorder.body.Bytes(),
+			}
+			if err := store.Complete(storeCtx, key, record); err != nil {
+				logger.Error().Err(err).Str("idempotency_key", clientKey).Msg("Failed to store idempotent response")
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package middleware
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/idempotency"
+	"github.com/rs/zerolog"
+)
+
+// countingHandler answers each request with its body and the number of
+// requests served so far, failing those whose body is "fail".
+func countingHandler(served *atomic.Int32) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		n := served.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		if string(body) == "fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"body":%q,"served":%d}`, body, n)
+	})
+}
+
+func idempotentPost(h http.Handler, path, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotencyReplaysResponse(t *testing.T) {
+	var served atomic.Int32
+	h := Idempotency(idempotency.NewStore(nil, zerolog.Nop(), time.Hour), zerolog.Nop())(countingHandler(&served))
+
+	first := idempotentPost(h, "/v1/budgets", "key-1", "a")
+	if first.Code != http.StatusCreated {
+		t.Fatalf("first request: status %d", first.Code)
+	}
+
+	retry := idempotentPost(h, "/v1/budgets", "key-1", "a")
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() {
+		t.Errorf("retry = %d %s, want the first response %s", retry.Code, retry.Body, first.Body)
+	}
+	if retry.Header().Get(IdempotentReplayedHeader) != "true" || retry.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("retry headers = %v, want a replayed JSON response", retry.Header())
+	}
+	if n := served.Load(); n != 1 {
+		t.Errorf("handler served %d requests, want the retry answered without it", n)
+	}
+
+	tests := []struct {
+		name       string
+		path, key  string
+		body       string
+		wantStatus int
+		wantServed bool
+	}{
+		{"different body", "/v1/budgets", "key-1", "b", http.StatusUnprocessableEntity, false},
+		{"another path", "/v1/alerts/rules", "key-1", "a", http.StatusCreated, true},
+		{"another key", "/v1/budgets", "key-2", "a", http.StatusCreated, true},
+		{"no key", "/v1/budgets", "", "a", http.StatusCreated, true},
+		{"key too long", "/v1/budgets", strings.Repeat("k", 256), "a", http.StatusBadRequest, false},
+	}
+	for _, tt := range tests {
+		before := served.Load()
+		rec := idempotentPost(h, tt.path, tt.key, tt.body)
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.wantStatus)
+		}
+		if got := served.Load() > before; got != tt.wantServed {
+			t.Errorf("%s: served = %v, want %v", tt.name, got, tt.wantServed)
+		}
+	}
+}
+
+func TestIdempotencyRetriesServerErrors(t *testing.T) {
+	var served atomic.Int32
+	h := Idempotency(idempotency.NewStore(nil, zerolog.Nop(), time.Hour), zerolog.Nop())(countingHandler(&served))
+
+	for i := 0; i < 2; i++ {
+		if rec := idempotentPost(h, "/v1/budgets", "key-1", "fail"); rec.Code != http.StatusInternalServerError {
+			t.Fatalf("attempt %d: status %d, want 500", i, rec.Code)
+		}
+	}
+	if n := served.Load(); n != 2 {
+		t.Errorf("handler served %d requests, want a failed request retried in full", n)
+	}
+}
+
+func TestIdempotencyReleasesKeyWhenHandlerPanics(t *testing.T) {
+	var served atomic.Int32
+	panicking := true
+	h := Idempotency(idempotency.NewStore(nil, zerolog.Nop(), time.Hour), zerolog.Nop())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served.Add(1)
+		if panicking {
+			panic("boom")
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	func() {
+		defer func() {
+			if p := recover(); p != "boom" {
+				t.Errorf("recovered %v, want the handler's panic to propagate", p)
+			}
+		}()
+		idempotentPost(h, "/v1/budgets", "key-1", "a")
+	}()
+
+	panicking = false
+	if rec := idempotentPost(h, "/v1/budgets", "key-1", "a"); rec.Code != http.StatusCreated || rec.Header().Get(IdempotentReplayedHeader) != "" {
+		t.Errorf("retry after a panic: status %d, replayed %q; want it served again", rec.Code, rec.Header().Get(IdempotentReplayedHeader))
+	}
+	if n := served.Load(); n != 2 {
+		t.Errorf("handler served %d requests, want 2", n)
+	}
+}
+
+func TestIdempotencyRejectsConcurrentRetry(t *testing.T) {
+	store := idempotency.NewStore(nil, zerolog.Nop(), time.Hour)
+	entered, release := make(chan struct{}), make(chan struct{})
+	h := Idempotency(store, zerolog.Nop())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-release
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- idempotentPost(h, "/v1/budgets", "key-1", "a") }()
+	<-entered
+
+	if rec := idempotentPost(h, "/v1/budgets", "key-1", "a"); rec.Code != http.StatusConflict {
+		t.Errorf("retry while the first request is served: status %d, want 409", rec.Code)
+	}
+	close(release)
+	if rec := <-done; rec.Code != http.StatusCreated {
+		t.Errorf("first request: status %d", rec.Code)
+	}
+	if rec := idempotentPost(h, "/v1/budgets", "key-1", "a"); rec.Code != http.StatusCreated || rec.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Errorf("retry after the first request finished: %d, replayed %q", rec.Code, rec.Header().Get(IdempotentReplayedHeader))
+	}
+}
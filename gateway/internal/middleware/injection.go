@@ -2,22 +2,22 @@ package middleware
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"io"
 	"net/http"
 	"strings"
 
 	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/response"
 	"github.com/akz4ol/gatewayops/gateway/internal/safety"
 	"github.com/go-chi/chi/v5"
-	chimiddleware "github.com/go-chi/chi/v5/middleware"
-	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 )
 
 // InjectionDetector defines the interface for injection detection.
 type InjectionDetector interface {
-	Detect(input string, opts safety.DetectOptions) domain.DetectionResult
+	Detect(ctx context.Context, input string, opts safety.DetectOptions) domain.DetectionResult
 }
 
 // Injection returns middleware that detects prompt injection attempts.
@@ -30,10 +30,16 @@ func Injection(detector InjectionDetector, logger zerolog.Logger) func(http.Hand
 				return
 			}
 
+			// Dry-run requests are evaluated by the handler without side effects
+			if IsDryRun(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
 			// Read body
 			body, err := io.ReadAll(r.Body)
 			if err != nil {
-				next.ServeHTTP(w, r)
+				writeBodyReadError(w, err)
 				return
 			}
 			// Restore body for downstream handlers
@@ -50,7 +56,7 @@ func Injection(detector InjectionDetector, logger zerolog.Logger) func(http.Hand
 			}
 
 			// Extract text content from arguments
-			inputText := extractTextContent(toolCall.Arguments)
+			inputText := ExtractTextContent(toolCall.Arguments)
 			if inputText == "" {
 				next.ServeHTTP(w, r)
 				return
@@ -58,30 +64,21 @@ func Injection(detector InjectionDetector, logger zerolog.Logger) func(http.Hand
 
 			// Get context info
 			mcpServer := chi.URLParam(r, "server")
-			reqID := chimiddleware.GetReqID(r.Context())
-
-			// Get auth info if available
-			var apiKeyID *uuid.UUID
-			var orgID uuid.UUID
-			if authInfo := GetAuthInfo(r.Context()); authInfo != nil {
-				apiKeyID = &authInfo.APIKeyID
-				orgID = authInfo.OrgID
-			} else {
-				orgID = uuid.MustParse("00000000-0000-0000-0000-000000000001") // Demo org
-			}
+			traceID := GetTraceID(r.Context())
 
 			// Detect injection
 			opts := safety.DetectOptions{
 				Input:     inputText,
-				OrgID:     orgID,
-				TraceID:   reqID,
+				OrgID:     GetOrgID(r.Context()),
+				TraceID:   traceID,
 				MCPServer: mcpServer,
 				ToolName:  toolCall.Name,
-				APIKeyID:  apiKeyID,
-				IPAddress: r.RemoteAddr,
+				Arguments: toolCall.Arguments,
+				APIKeyID:  GetAPIKeyID(r.Context()),
+				IPAddress: RequestClientIP(r),
 			}
 
-			result := detector.Detect(inputText, opts)
+			result := detector.Detect(r.Context(), inputText, opts)
 
 			// Handle detection result
 			if result.Detected {
@@ -94,18 +91,13 @@ func Injection(detector InjectionDetector, logger zerolog.Logger) func(http.Hand
 						Str("tool", toolCall.Name).
 						Msg("Blocked request due to prompt injection detection")
 
-					w.Header().Set("Content-Type", "application/json")
-					w.WriteHeader(http.StatusBadRequest)
-					json.NewEncoder(w).Encode(map[string]interface{}{
-						"error": map[string]interface{}{
-							"code":    "injection_detected",
-							"message": "Request blocked: potential prompt injection detected",
-							"details": map[string]interface{}{
-								"severity": result.Severity,
-								"type":     result.Type,
-							},
-						},
-					})
+					response.WriteErrorDetails(w, http.StatusBadRequest, response.CodeInjectionDetected,
+						"Request blocked: potential prompt injection detected",
+						map[string]interface{}{
+							"severity":  result.Severity,
+							"type":      result.Type,
+							"escalated": result.Escalated,
+						})
 					return
 
 				case domain.SafetyModeWarn:
@@ -117,7 +109,7 @@ func Injection(detector InjectionDetector, logger zerolog.Logger) func(http.Hand
 						Str("pattern", result.PatternMatched).
 						Msg("Warning: potential prompt injection detected (allowed)")
 
-				case domain.SafetyModeLog:
+				case domain.SafetyModeLog, domain.SafetyModeShadow:
 					// Just log, no action
 					logger.Debug().
 						Str("severity", string(result.Severity)).
@@ -131,8 +123,8 @@ func Injection(detector InjectionDetector, logger zerolog.Logger) func(http.Hand
 	}
 }
 
-// extractTextContent extracts text content from tool arguments for analysis.
-func extractTextContent(args map[string]interface{}) string {
+// ExtractTextContent extracts text content from tool arguments for analysis.
+func ExtractTextContent(args map[string]interface{}) string {
 	var texts []string
 
 	for key, value := range args {
@@ -144,14 +136,14 @@ func extractTextContent(args map[string]interface{}) string {
 			}
 		case map[string]interface{}:
 			// Recursively extract from nested objects
-			texts = append(texts, extractTextContent(v))
+			texts = append(texts, ExtractTextContent(v))
 		case []interface{}:
 			// Extract from arrays
 			for _, item := range v {
 				if str, ok := item.(string); ok {
 					texts = append(texts, str)
 				} else if obj, ok := item.(map[string]interface{}); ok {
-					texts = append(texts, extractTextContent(obj))
+					texts = append(texts, ExtractTextContent(obj))
 				}
 			}
 		}
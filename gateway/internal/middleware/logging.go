@@ -4,7 +4,6 @@ import (
 	"net/http"
 	"time"
 
-	chimiddleware "github.com/go-chi/chi/v5/middleware"
 	"github.com/rs/zerolog"
 )
 
@@ -38,6 +37,21 @@ func (rw *responseWriter) Write(b []byte) (int, error) {
 	return n, err
 }
 
+// Flush implements http.Flusher so streaming handlers work behind the logger.
+func (rw *responseWriter) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		if !rw.wroteHeader {
+			rw.WriteHeader(http.StatusOK)
+		}
+		f.Flush()
+	}
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 // Logger returns middleware that logs HTTP requests.
 func Logger(logger zerolog.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
@@ -45,12 +59,11 @@ func Logger(logger zerolog.Logger) func(http.Handler) http.Handler {
 			start := time.Now()
 			wrapped := wrapResponseWriter(w)
 
-			// Get request ID from context (set by chi middleware)
-			requestID := chimiddleware.GetReqID(r.Context())
+			// Use the request-scoped logger (carries request_id)
+			reqLogger := RequestLogger(r.Context(), logger)
 
 			// Log request start
-			logger.Debug().
-				Str("request_id", requestID).
+			reqLogger.Debug().
 				Str("method", r.Method).
 				Str("path", r.URL.Path).
 				Str("remote_addr", r.RemoteAddr).
@@ -60,17 +73,16 @@ func Logger(logger zerolog.Logger) func(http.Handler) http.Handler {
 
 			// Log request completion
 			duration := time.Since(start)
-			event := logger.Info()
+			event := reqLogger.Info()
 
 			// Use different log levels based on status code
 			if wrapped.status >= 500 {
-				event = logger.Error()
+				event = reqLogger.Error()
 			} else if wrapped.status >= 400 {
-				event = logger.Warn()
+				event = reqLogger.Warn()
 			}
 
 			event.
-				Str("request_id", requestID).
 				Str("method", r.Method).
 				Str("path", r.URL.Path).
 				Int("status", wrapped.status).
@@ -6,7 +6,10 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/akz4ol/gatewayops/gateway/internal/audit"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
 	"github.com/akz4ol/gatewayops/gateway/internal/response"
+	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 )
 
@@ -17,8 +20,11 @@ type RateLimiter interface {
 	Allow(ctx context.Context, key string, limit int) (bool, int, int, error)
 }
 
-// RateLimit returns middleware that enforces rate limits.
-func RateLimit(limiter RateLimiter, logger zerolog.Logger) func(http.Handler) http.Handler {
+// RateLimit returns middleware that enforces rate limits. Keys without their
+// own limit use defaultLimit, which is read on every request so it can change
+// at runtime. Requests matching allowlist skip the limit or use the higher
+// one it sets; skipped requests are audited through auditLogger when set.
+func RateLimit(limiter RateLimiter, logger zerolog.Logger, defaultLimit func() int, allowlist *RateLimitAllowlist, auditLogger AuditLogger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Get auth info for rate limit key
@@ -29,11 +35,17 @@ func RateLimit(limiter RateLimiter, logger zerolog.Logger) func(http.Handler) ht
 				return
 			}
 
-			// Rate limit key: org_id:key_id
-			key := fmt.Sprintf("%s:%s", authInfo.OrgID, authInfo.KeyID)
-			limit := authInfo.RateLimit
-			if limit == 0 {
-				limit = 1000 // Default 1000 requests per minute
+			key := RateLimitKey(authInfo)
+			limit := KeyRateLimit(authInfo, defaultLimit)
+			if name, allowed, bypass, ok := allowlist.Match(authInfo, RequestClientIP(r)); ok {
+				if bypass {
+					auditBypass(r, auditLogger, authInfo, name)
+					next.ServeHTTP(w, r)
+					return
+				}
+				if allowed > limit {
+					limit = allowed
+				}
 			}
 
 			allowed, remaining, resetSeconds, err := limiter.Allow(r.Context(), key, limit)
@@ -59,7 +71,7 @@ func RateLimit(limiter RateLimiter, logger zerolog.Logger) func(http.Handler) ht
 					Msg("Rate limit exceeded")
 
 				w.Header().Set("Retry-After", strconv.Itoa(resetSeconds))
-				response.WriteError(w, http.StatusTooManyRequests, "rate_limit_exceeded",
+				response.WriteError(w, http.StatusTooManyRequests, response.CodeRateLimitExceeded,
 					fmt.Sprintf("Rate limit exceeded. Try again in %d seconds", resetSeconds))
 				return
 			}
@@ -68,3 +80,82 @@ func RateLimit(limiter RateLimiter, logger zerolog.Logger) func(http.Handler) ht
 		})
 	}
 }
+
+// auditBypass records a request that skipped the rate limit through the
+// named allowance.
+func auditBypass(r *http.Request, auditLogger AuditLogger, authInfo *AuthInfo, allowance string) {
+	if auditLogger == nil {
+		return
+	}
+	var apiKeyID *uuid.UUID
+	if authInfo.APIKeyID != uuid.Nil {
+		apiKeyID = &authInfo.APIKeyID
+	}
+	auditLogger.LogEvent(r.Context(), audit.Event{
+		OrgID:      authInfo.OrgID,
+		UserID:     &authInfo.UserID,
+		APIKeyID:   apiKeyID,
+		TraceID:    GetTraceID(r.Context()),
+		Action:     domain.AuditActionRateLimitBypass,
+		Resource:   "api_key",
+		ResourceID: authInfo.KeyID,
+		Outcome:    domain.AuditOutcomeSuccess,
+		Details:    map[string]interface{}{"allowance": allowance, "method": r.Method, "path": r.URL.Path},
+		IPAddress:  RequestClientIP(r),
+		UserAgent:  r.UserAgent(),
+		RequestID:  GetRequestID(r.Context()),
+	})
+}
+
+// RateLimitKey returns the key an API key's requests are counted under:
+// org_id:key_id.
+func RateLimitKey(authInfo *AuthInfo) string {
+	return fmt.Sprintf("%s:%s", authInfo.OrgID, authInfo.KeyID)
+}
+
+// KeyRateLimit returns the requests per minute allowed for an API key: its
+// own limit, else defaultLimit, else 1000.
+func KeyRateLimit(authInfo *AuthInfo, defaultLimit func() int) int {
+	limit := authInfo.RateLimit
+	if limit == 0 && defaultLimit != nil {
+		limit = defaultLimit()
+	}
+	if limit == 0 {
+		limit = 1000 // Default 1000 requests per minute
+	}
+	return limit
+}
+
+// AuthRateLimit returns middleware that limits requests to authentication
+// endpoints per client IP, slowing credential stuffing and state guessing.
+// These requests carry no API key, so RateLimit cannot key them.
+func AuthRateLimit(limiter RateLimiter, logger zerolog.Logger, limit int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := "auth:" + RequestClientIP(r)
+			allowed, _, resetSeconds, err := limiter.Allow(r.Context(), key, limit)
+			if err != nil {
+				logger.Error().
+					Err(err).
+					Str("rate_limit_key", key).
+					Msg("Rate limiter error")
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !allowed {
+				logger.Warn().
+					Str("rate_limit_key", key).
+					Int("limit", limit).
+					Msg("Authentication rate limit exceeded")
+
+				w.Header().Set("Retry-After", strconv.Itoa(resetSeconds))
+				response.WriteError(w, http.StatusTooManyRequests, response.CodeRateLimitExceeded,
+					fmt.Sprintf("Too many authentication attempts. Try again in %d seconds", resetSeconds))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/google/uuid"
+)
+
+// RateLimitAllowance exempts the requests it matches from the rate limit, or
+// gives them a higher one. A request matches when every condition set holds.
+type RateLimitAllowance struct {
+	Name           string
+	APIKeys        []string // API key IDs
+	ServiceAccount bool     // Keys granted the service_account permission explicitly
+	CIDRs          []string // Client IPs or CIDR blocks
+	Limit          int      // Requests per minute; 0 skips the rate limit
+}
+
+// RateLimitAllowlist holds the allowances the rate limit consults before
+// counting a request. A nil allowlist matches nothing.
+type RateLimitAllowlist struct {
+	entries []allowance
+}
+
+type allowance struct {
+	name           string
+	keys           map[uuid.UUID]bool
+	serviceAccount bool
+	nets           []*net.IPNet
+	limit          int
+}
+
+// NewRateLimitAllowlist parses allowances. An allowance must match on API
+// keys, the service account permission or CIDRs, and one matching on CIDRs
+// alone must set a limit, so no key is left unlimited merely by where it is
+// used from.
+func NewRateLimitAllowlist(allowances []RateLimitAllowance) (*RateLimitAllowlist, error) {
+	l := &RateLimitAllowlist{entries: make([]allowance, 0, len(allowances))}
+	for _, a := range allowances {
+		entry := allowance{name: a.Name, serviceAccount: a.ServiceAccount, limit: a.Limit}
+		if len(a.APIKeys) > 0 {
+			entry.keys = make(map[uuid.UUID]bool, len(a.APIKeys))
+			for _, key := range a.APIKeys {
+				id, err := uuid.Parse(key)
+				if err != nil {
+					return nil, fmt.Errorf("rate limit allowance %q: api key %q is not a UUID", a.Name, key)
+				}
+				entry.keys[id] = true
+			}
+		}
+		nets, err := ParseCIDRs(a.CIDRs)
+		if err != nil {
+			return nil, fmt.Errorf("rate limit allowance %q: %w", a.Name, err)
+		}
+		entry.nets = nets
+
+		switch {
+		case a.Limit < 0:
+			return nil, fmt.Errorf("rate limit allowance %q: limit must not be negative", a.Name)
+		case entry.keys == nil && !entry.serviceAccount && len(entry.nets) == 0:
+			return nil, fmt.Errorf("rate limit allowance %q: matches every request", a.Name)
+		case entry.keys == nil && !entry.serviceAccount && entry.limit == 0:
+			return nil, fmt.Errorf("rate limit allowance %q: matching on CIDRs alone needs a limit", a.Name)
+		}
+		l.entries = append(l.entries, entry)
+	}
+	return l, nil
+}
+
+// Match returns the first allowance matching a request by authInfo from
+// clientIP: its name, its limit, and whether the rate limit is skipped.
+func (l *RateLimitAllowlist) Match(authInfo *AuthInfo, clientIP string) (name string, limit int, bypass, ok bool) {
+	if l == nil || authInfo == nil {
+		return "", 0, false, false
+	}
+	ip := net.ParseIP(clientIP)
+	for _, entry := range l.entries {
+		if entry.keys != nil && !entry.keys[authInfo.APIKeyID] {
+			continue
+		}
+		if entry.serviceAccount && !isServiceAccount(authInfo) {
+			continue
+		}
+		if len(entry.nets) > 0 && (ip == nil || !containsIP(entry.nets, ip)) {
+			continue
+		}
+		return entry.name, entry.limit, entry.limit == 0, true
+	}
+	return "", 0, false, false
+}
+
+// isServiceAccount reports whether the key was granted the service_account
+// permission by name; "*" and other wildcards do not count.
+func isServiceAccount(authInfo *AuthInfo) bool {
+	for _, p := range authInfo.Permissions {
+		if p == string(domain.PermissionServiceAccount) {
+			return true
+		}
+	}
+	return false
+}
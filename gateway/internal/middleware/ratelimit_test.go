@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// fixedLimiter refuses every request, recording the limit it was asked to
+// apply.
+type fixedLimiter struct {
+	limits []int
+}
+
+func (l *fixedLimiter) Allow(ctx context.Context, key string, limit int) (bool, int, int, error) {
+	l.limits = append(l.limits, limit)
+	return false, 0, 30, nil
+}
+
+var (
+	internalKey = uuid.MustParse("11111111-1111-1111-1111-111111111111")
+	batchKey    = uuid.MustParse("22222222-2222-2222-2222-222222222222")
+)
+
+func newTestAllowlist(t *testing.T) *RateLimitAllowlist {
+	t.Helper()
+	l, err := NewRateLimitAllowlist([]RateLimitAllowance{
+		{Name: "internal", APIKeys: []string{internalKey.String()}},
+		{Name: "batch", APIKeys: []string{batchKey.String()}, CIDRs: []string{"10.0.0.0/8"}, Limit: 5000},
+		{Name: "services", ServiceAccount: true},
+		{Name: "office", CIDRs: []string{"192.0.2.0/24"}, Limit: 3000},
+	})
+	if err != nil {
+		t.Fatalf("NewRateLimitAllowlist: %v", err)
+	}
+	return l
+}
+
+func TestRateLimitAllowlistMatch(t *testing.T) {
+	l := newTestAllowlist(t)
+	tests := []struct {
+		name       string
+		auth       *AuthInfo
+		ip         string
+		wantName   string
+		wantLimit  int
+		wantBypass bool
+	}{
+		{"listed key", &AuthInfo{APIKeyID: internalKey}, "203.0.113.1", "internal", 0, true},
+		{"key in its range", &AuthInfo{APIKeyID: batchKey}, "10.1.2.3", "batch", 5000, false},
+		{"key outside its range", &AuthInfo{APIKeyID: batchKey}, "203.0.113.1", "", 0, false},
+		{"service account", &AuthInfo{APIKeyID: uuid.New(), Permissions: []string{"mcp:read", string(domain.PermissionServiceAccount)}}, "203.0.113.1", "services", 0, true},
+		{"wildcard is not a service account", &AuthInfo{APIKeyID: uuid.New(), Permissions: []string{"*"}}, "203.0.113.1", "", 0, false},
+		{"any key from the office", &AuthInfo{APIKeyID: uuid.New()}, "192.0.2.9", "office", 3000, false},
+		{"unparsable address", &AuthInfo{APIKeyID: uuid.New()}, "unknown", "", 0, false},
+		{"no auth", nil, "192.0.2.9", "", 0, false},
+	}
+	for _, tt := range tests {
+		name, limit, bypass, ok := l.Match(tt.auth, tt.ip)
+		if name != tt.wantName || limit != tt.wantLimit || bypass != tt.wantBypass || ok != (tt.wantName != "") {
+			t.Errorf("%s: Match = %q, %d, bypass %v, ok %v; want %q, %d, bypass %v", tt.name, name, limit, bypass, ok, tt.wantName, tt.wantLimit, tt.wantBypass)
+		}
+	}
+
+	var none *RateLimitAllowlist
+	if _, _, _, ok := none.Match(&AuthInfo{APIKeyID: internalKey}, "10.0.0.1"); ok {
+		t.Error("nil allowlist matched a request")
+	}
+}
+
+func TestNewRateLimitAllowlistRejects(t *testing.T) {
+	tests := map[string]RateLimitAllowance{
+		"key not a UUID":       {Name: "a", APIKeys: []string{"key-1"}},
+		"bad CIDR":             {Name: "a", ServiceAccount: true, CIDRs: []string{"10.0.0.0/40"}},
+		"negative limit":       {Name: "a", ServiceAccount: true, Limit: -1},
+		"matches everything":   {Name: "a", Limit: 100},
+		"CIDRs without limits": {Name: "a", CIDRs: []string{"10.0.0.0/8"}},
+	}
+	for name, a := range tests {
+		if _, err := NewRateLimitAllowlist([]RateLimitAllowance{a}); err == nil {
+			t.Errorf("%s: NewRateLimitAllowlist accepted %+v", name, a)
+		}
+	}
+}
+
+func TestRateLimitAppliesAllowlist(t *testing.T) {
+	tests := []struct {
+		name        string
+		auth        *AuthInfo
+		remote      string
+		wantStatus  int
+		wantLimit   int // Limit passed to the limiter; 0 when it is skipped
+		wantAudited bool
+	}{
+		{"bypassed", &AuthInfo{APIKeyID: internalKey, OrgID: uuid.New(), KeyID: "gwo_int"}, "203.0.113.1:4000", http.StatusOK, 0, true},
+		{"raised limit", &AuthInfo{APIKeyID: batchKey, RateLimit: 100}, "10.0.0.1:4000", http.StatusTooManyRequests, 5000, false},
+		{"key's own limit is higher", &AuthInfo{APIKeyID: uuid.New(), RateLimit: 9000}, "192.0.2.1:4000", http.StatusTooManyRequests, 9000, false},
+		{"not listed", &AuthInfo{APIKeyID: uuid.New(), RateLimit: 100}, "203.0.113.1:4000", http.StatusTooManyRequests, 100, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := &fixedLimiter{}
+			auditLogger := &recordingAuditLogger{}
+			h := RateLimit(limiter, zerolog.Nop(), nil, newTestAllowlist(t), auditLogger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/mcp/search/tools/call", nil)
+			req.RemoteAddr = tt.remote
+			req = req.WithContext(context.WithValue(req.Context(), AuthInfoKey, tt.auth))
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantLimit == 0 {
+				if len(limiter.limits) != 0 {
+					t.Errorf("limiter consulted with %v, want it skipped", limiter.limits)
+				}
+			} else if len(limiter.limits) != 1 || limiter.limits[0] != tt.wantLimit {
+				t.Errorf("limits = %v, want %d", limiter.limits, tt.wantLimit)
+			} else if got := rec.Header().Get("X-RateLimit-Limit"); got != strconv.Itoa(tt.wantLimit) {
+				t.Errorf("X-RateLimit-Limit = %s, want %d", got, tt.wantLimit)
+			}
+
+			if audited := len(auditLogger.events) > 0; audited != tt.wantAudited {
+				t.Fatalf("audited = %v, want %v", audited, tt.wantAudited)
+			}
+			if tt.wantAudited {
+				e := auditLogger.events[0]
+				if e.Action != domain.AuditActionRateLimitBypass || e.OrgID != tt.auth.OrgID || e.Details["allowance"] != "internal" || e.IPAddress != "203.0.113.1" {
+					t.Errorf("audit event = %+v, want the bypass through internal", e)
+				}
+			}
+		})
+	}
+}
@@ -16,14 +16,15 @@ func Recoverer(logger zerolog.Logger) func(http.Handler) http.Handler {
 			defer func() {
 				if rec := recover(); rec != nil {
 					stack := debug.Stack()
-					logger.Error().
+					reqLogger := RequestLogger(r.Context(), logger)
+					reqLogger.Error().
 						Interface("panic", rec).
 						Bytes("stack", stack).
 						Str("method", r.Method).
 						Str("path", r.URL.Path).
 						Msg("Panic recovered")
 
-					response.WriteError(w, http.StatusInternalServerError, "internal_error", "An internal error occurred")
+					response.WriteError(w, http.StatusInternalServerError, response.CodeInternalError, "An internal error occurred")
 				}
 			}()
 
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/rs/zerolog"
+)
+
+// RequestIDHeader is the header used to propagate request IDs.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds client-supplied request IDs.
+const maxRequestIDLength = 128
+
+// RequestID returns middleware that propagates or generates a request ID,
+// echoes it in the response and attaches a request-scoped child logger to the
+// request context.
+func RequestID(logger zerolog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID := r.Header.Get(RequestIDHeader)
+			if !isValidRequestID(requestID) {
+				requestID = generateRequestID()
+			}
+
+			// Keep chi's request ID in sync so GetReqID returns the same value
+			ctx := context.WithValue(r.Context(), chimiddleware.RequestIDKey, requestID)
+
+			reqLogger := logger.With().Str("request_id", requestID).Logger()
+			ctx = reqLogger.WithContext(ctx)
+
+			w.Header().Set(RequestIDHeader, requestID)
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// GetRequestID extracts the request ID from context.
+func GetRequestID(ctx context.Context) string {
+	return chimiddleware.GetReqID(ctx)
+}
+
+// RequestLogger returns the request-scoped logger from context, falling back
+// to the given logger when none is attached.
+func RequestLogger(ctx context.Context, fallback zerolog.Logger) zerolog.Logger {
+	if l := zerolog.Ctx(ctx); l != nil && l.GetLevel() != zerolog.Disabled {
+		return *l
+	}
+	return fallback
+}
+
+// generateRequestID creates a request ID in format: req_{random}
+func generateRequestID() string {
+	random := make([]byte, 12)
+	rand.Read(random)
+	return "req_" + hex.EncodeToString(random)
+}
+
+// isValidRequestID checks that a client-supplied request ID is safe to log and echo.
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-' || c == '_' || c == '.' || c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
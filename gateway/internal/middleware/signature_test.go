@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+)
+
+func TestVerifySignature(t *testing.T) {
+	secrets := map[string]string{"pagerduty": "s3cret"}
+	newRouter := func(required bool) http.Handler {
+		r := chi.NewRouter()
+		r.With(VerifySignature(secrets, "integration", 5*time.Minute, required, zerolog.Nop())).
+			Post("/webhooks/{integration}", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			})
+		return r
+	}
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	stale := strconv.FormatInt(time.Now().Add(-10*time.Minute).Unix(), 10)
+	body := `{"event":"resolve"}`
+
+	tests := []struct {
+		name        string
+		integration string
+		required    bool
+		body        string
+		timestamp   string
+		signature   string
+		want        int
+	}{
+		{name: "valid signature", integration: "pagerduty", body: body, timestamp: now, signature: SignCallback("s3cret", now, []byte(body)), want: http.StatusNoContent},
+		{name: "tampered body", integration: "pagerduty", body: `{"event":"trigger"}`, timestamp: now, signature: SignCallback("s3cret", now, []byte(body)), want: http.StatusUnauthorized},
+		{name: "wrong secret", integration: "pagerduty", body: body, timestamp: now, signature: SignCallback("guess", now, []byte(body)), want: http.StatusUnauthorized},
+		{name: "stale timestamp", integration: "pagerduty", body: body, timestamp: stale, signature: SignCallback("s3cret", stale, []byte(body)), want: http.StatusUnauthorized},
+		{name: "unsigned", integration: "pagerduty", body: body, want: http.StatusUnauthorized},
+		{name: "no secret, optional", integration: "opsgenie", body: body, want: http.StatusNoContent},
+		{name: "no secret, required", integration: "opsgenie", required: true, body: body, want: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/webhooks/"+tt.integration, strings.NewReader(tt.body))
+			if tt.signature != "" {
+				req.Header.Set(SignatureHeader, tt.signature)
+				req.Header.Set(SignatureTimestampHeader, tt.timestamp)
+			}
+			rec := httptest.NewRecorder()
+			newRouter(tt.required).ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"encoding/hex"
 	"net/http"
 	"time"
+
+	"github.com/rs/zerolog"
 )
 
 // Context keys for trace information.
@@ -39,6 +41,12 @@ func Trace() func(http.Handler) http.Handler {
 			ctx = context.WithValue(ctx, SpanIDKey, spanID)
 			ctx = context.WithValue(ctx, StartTimeKey, time.Now())
 
+			// Correlate request-scoped log lines with the trace
+			if l := zerolog.Ctx(ctx); l.GetLevel() != zerolog.Disabled {
+				traceLogger := l.With().Str("trace_id", traceID).Logger()
+				ctx = traceLogger.WithContext(ctx)
+			}
+
 			// Add trace headers to response
 			w.Header().Set("X-Trace-ID", traceID)
 			w.Header().Set("X-Span-ID", spanID)
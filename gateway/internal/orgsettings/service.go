@@ -0,0 +1,194 @@
+// Package orgsettings keeps per-organization settings: typed values with
+// defaults that other services read to decide how to treat an organization.
+package orgsettings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/clock"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/repository"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// Errors returned when reading or changing a setting.
+var (
+	ErrUnknownSetting = errors.New("unknown setting")
+	ErrInvalidValue   = errors.New("invalid setting value")
+)
+
+type settingKey struct {
+	orgID uuid.UUID
+	key   domain.SettingKey
+}
+
+// Service holds the settings organizations have set. Reads are served from
+// memory; changes are written to the store first when there is one.
+type Service struct {
+	store  repository.SettingsStore // Nil keeps settings in memory only
+	logger zerolog.Logger
+	clock  clock.Clock
+
+	mu       sync.RWMutex
+	settings map[settingKey]domain.OrgSetting
+}
+
+// NewService creates a settings service and loads the settings saved in
+// store.
+func NewService(store repository.SettingsStore, logger zerolog.Logger, clk clock.Clock) *Service {
+	s := &Service{
+		store:    store,
+		logger:   logger,
+		clock:    clk,
+		settings: make(map[settingKey]domain.OrgSetting),
+	}
+	s.load()
+	return s
+}
+
+// load reads the saved settings from the store.
+func (s *Service) load() {
+	if s.store == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	settings, err := s.store.List(ctx)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to load organization settings; defaults apply")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, setting := range settings {
+		def, ok := domain.LookupSetting(setting.Key)
+		if !ok || !def.Valid(setting.Value) {
+			s.logger.Warn().Str("org_id", setting.OrgID.String()).Str("key", string(setting.Key)).
+				Msg("Ignoring saved organization setting that is unknown or of the wrong type")
+			continue
+		}
+		s.settings[settingKey{setting.OrgID, setting.Key}] = setting
+	}
+	s.logger.Info().Int("count", len(s.settings)).Msg("Loaded organization settings")
+}
+
+// List returns the organization's value for every setting.
+func (s *Service) List(orgID uuid.UUID) []domain.SettingValue {
+	values := make([]domain.SettingValue, 0, len(domain.SettingDefinitions))
+	for _, def := range domain.SettingDefinitions {
+		values = append(values, s.value(orgID, def))
+	}
+	return values
+}
+
+// Get returns the organization's value for a setting.
+func (s *Service) Get(orgID uuid.UUID, key domain.SettingKey) (domain.SettingValue, error) {
+	def, ok := domain.LookupSetting(key)
+	if !ok {
+		return domain.SettingValue{}, fmt.Errorf("%w: %s", ErrUnknownSetting, key)
+	}
+	return s.value(orgID, def), nil
+}
+
+// Set changes the organization's value for a setting.
+func (s *Service) Set(ctx context.Context, orgID uuid.UUID, key domain.SettingKey, value any, updatedBy *uuid.UUID) (domain.SettingValue, error) {
+	def, ok := domain.LookupSetting(key)
+	if !ok {
+		return domain.SettingValue{}, fmt.Errorf("%w: %s", ErrUnknownSetting, key)
+	}
+	if !def.Valid(value) {
+		return domain.SettingValue{}, fmt.Errorf("%w: %s must be a %s", ErrInvalidValue, key, def.Type)
+	}
+
+	setting := domain.OrgSetting{
+		OrgID:     orgID,
+		Key:       key,
+		Value:     value,
+		UpdatedBy: updatedBy,
+		UpdatedAt: s.clock.Now(),
+	}
+	if s.store != nil {
+		if err := s.store.Save(ctx, &setting); err != nil {
+			return domain.SettingValue{}, err
+		}
+	}
+
+	s.mu.Lock()
+	s.settings[settingKey{orgID, key}] = setting
+	s.mu.Unlock()
+
+	s.logger.Info().
+		Str("org_id", orgID.String()).
+		Str("key", string(key)).
+		Interface("value", value).
+		Msg("Organization setting changed")
+
+	return s.value(orgID, def), nil
+}
+
+// Reset restores the default value of a setting for the organization.
+func (s *Service) Reset(ctx context.Context, orgID uuid.UUID, key domain.SettingKey) (domain.SettingValue, error) {
+	def, ok := domain.LookupSetting(key)
+	if !ok {
+		return domain.SettingValue{}, fmt.Errorf("%w: %s", ErrUnknownSetting, key)
+	}
+
+	if s.store != nil {
+		if err := s.store.Delete(ctx, orgID, key); err != nil {
+			return domain.SettingValue{}, err
+		}
+	}
+
+	s.mu.Lock()
+	delete(s.settings, settingKey{orgID, key})
+	s.mu.Unlock()
+
+	s.logger.Info().
+		Str("org_id", orgID.String()).
+		Str("key", string(key)).
+		Msg("Organization setting reset to default")
+
+	return s.value(orgID, def), nil
+}
+
+// Bool returns the organization's value for a boolean setting. It is safe
+// to call on a nil service, which answers with the setting's default.
+func (s *Service) Bool(orgID uuid.UUID, key domain.SettingKey) bool {
+	def, ok := domain.LookupSetting(key)
+	if !ok {
+		return false
+	}
+	var value any = def.Default
+	if s != nil {
+		value = s.value(orgID, def).Value
+	}
+	b, _ := value.(bool)
+	return b
+}
+
+// value returns the organization's value for the setting, falling back to
+// its default.
+func (s *Service) value(orgID uuid.UUID, def domain.SettingDefinition) domain.SettingValue {
+	s.mu.RLock()
+	setting, ok := s.settings[settingKey{orgID, def.Key}]
+	s.mu.RUnlock()
+
+	if !ok {
+		return domain.SettingValue{SettingDefinition: def, Value: def.Default, IsDefault: true}
+	}
+	updatedAt := setting.UpdatedAt
+	return domain.SettingValue{
+		SettingDefinition: def,
+		Value:             setting.Value,
+		UpdatedBy:         setting.UpdatedBy,
+		UpdatedAt:         &updatedAt,
+	}
+}
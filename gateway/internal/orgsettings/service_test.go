@@ -0,0 +1,149 @@
+package orgsettings
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/clock"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// memoryStore is a SettingsStore kept in a map, failing writes when err is
+// set.
+type memoryStore struct {
+	mu       sync.Mutex
+	settings map[settingKey]domain.OrgSetting
+	err      error
+}
+
+func newMemoryStore(settings ...domain.OrgSetting) *memoryStore {
+	m := &memoryStore{settings: make(map[settingKey]domain.OrgSetting)}
+	for _, s := range settings {
+		m.settings[settingKey{s.OrgID, s.Key}] = s
+	}
+	return m
+}
+
+func (m *memoryStore) List(ctx context.Context) ([]domain.OrgSetting, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []domain.OrgSetting
+	for _, s := range m.settings {
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+func (m *memoryStore) Save(ctx context.Context, setting *domain.OrgSetting) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return m.err
+	}
+	m.settings[settingKey{setting.OrgID, setting.Key}] = *setting
+	return nil
+}
+
+func (m *memoryStore) Delete(ctx context.Context, orgID uuid.UUID, key domain.SettingKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return m.err
+	}
+	delete(m.settings, settingKey{orgID, key})
+	return nil
+}
+
+func TestSetAndReset(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	store := newMemoryStore()
+	s := NewService(store, zerolog.Nop(), clk)
+	orgID, otherID, userID := uuid.New(), uuid.New(), uuid.New()
+
+	if !s.Bool(orgID, domain.SettingOutputScanning) {
+		t.Fatal("output scanning is off before being set, want its default")
+	}
+	value, err := s.Set(ctx, orgID, domain.SettingOutputScanning, false, &userID)
+	if err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if value.Value != false || value.IsDefault || value.UpdatedBy == nil || *value.UpdatedBy != userID || !value.UpdatedAt.Equal(clk.Now()) {
+		t.Errorf("Set = %+v, want false set by the user now", value)
+	}
+	if s.Bool(orgID, domain.SettingOutputScanning) || !s.Bool(otherID, domain.SettingOutputScanning) {
+		t.Error("setting did not apply to exactly its organization")
+	}
+
+	// A new instance reads what was saved
+	if NewService(store, zerolog.Nop(), clk).Bool(orgID, domain.SettingOutputScanning) {
+		t.Error("saved setting was not loaded from the store")
+	}
+
+	value, err = s.Reset(ctx, orgID, domain.SettingOutputScanning)
+	if err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+	if !value.IsDefault || value.Value != true || !s.Bool(orgID, domain.SettingOutputScanning) {
+		t.Errorf("Reset = %+v, want the default back", value)
+	}
+	if len(store.settings) != 0 {
+		t.Errorf("store holds %d settings after the reset", len(store.settings))
+	}
+}
+
+func TestSetRejects(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	s := NewService(store, zerolog.Nop(), clock.Real)
+	orgID := uuid.New()
+
+	if _, err := s.Set(ctx, orgID, "no_such_setting", true, nil); !errors.Is(err, ErrUnknownSetting) {
+		t.Errorf("unknown setting: err = %v, want ErrUnknownSetting", err)
+	}
+	if _, err := s.Set(ctx, orgID, domain.SettingBudgetEnforcement, "off", nil); !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("string for a bool: err = %v, want ErrInvalidValue", err)
+	}
+	if _, err := s.Get(orgID, "no_such_setting"); !errors.Is(err, ErrUnknownSetting) {
+		t.Errorf("Get unknown setting: err = %v, want ErrUnknownSetting", err)
+	}
+
+	// A failed save leaves the setting as it was
+	store.err = errors.New("database unavailable")
+	if _, err := s.Set(ctx, orgID, domain.SettingBudgetEnforcement, false, nil); err == nil {
+		t.Fatal("Set succeeded although the store failed")
+	}
+	if !s.Bool(orgID, domain.SettingBudgetEnforcement) {
+		t.Error("setting changed although it was not saved")
+	}
+}
+
+func TestLoadSkipsInvalidSettings(t *testing.T) {
+	orgID := uuid.New()
+	store := newMemoryStore(
+		domain.OrgSetting{OrgID: orgID, Key: domain.SettingBudgetEnforcement, Value: "false"},
+		domain.OrgSetting{OrgID: orgID, Key: "retired_setting", Value: true},
+		domain.OrgSetting{OrgID: orgID, Key: domain.SettingOutputScanning, Value: false},
+	)
+	s := NewService(store, zerolog.Nop(), clock.Real)
+
+	if !s.Bool(orgID, domain.SettingBudgetEnforcement) {
+		t.Error("a saved value of the wrong type was used")
+	}
+	if s.Bool(orgID, domain.SettingOutputScanning) {
+		t.Error("a valid saved value was not loaded")
+	}
+	if n := len(s.List(orgID)); n != len(domain.SettingDefinitions) {
+		t.Errorf("List returned %d settings, want one per definition", n)
+	}
+
+	var none *Service
+	if !none.Bool(orgID, domain.SettingBudgetEnforcement) {
+		t.Error("nil service did not answer with the default")
+	}
+}
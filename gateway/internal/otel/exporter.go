@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/middleware"
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 )
@@ -38,6 +39,9 @@ type Exporter struct {
 	spanQueue   []domain.TelemetrySpan
 	metricQueue []domain.TelemetryMetric
 	queueMu     sync.Mutex
+
+	stop     chan struct{}
+	stopOnce sync.Once
 }
 
 // NewExporter creates a new OpenTelemetry exporter.
@@ -48,6 +52,7 @@ func NewExporter(logger zerolog.Logger) *Exporter {
 		client:      &http.Client{Timeout: 30 * time.Second},
 		spanQueue:   make([]domain.TelemetrySpan, 0),
 		metricQueue: make([]domain.TelemetryMetric, 0),
+		stop:        make(chan struct{}),
 	}
 
 	// Create demo config
@@ -63,7 +68,7 @@ func NewExporter(logger zerolog.Logger) *Exporter {
 func (e *Exporter) createDemoConfig() {
 	config := &domain.TelemetryConfig{
 		ID:            uuid.MustParse("00000000-0000-0000-0000-000000000001"),
-		OrgID:         uuid.MustParse("00000000-0000-0000-0000-000000000001"),
+		OrgID:         uuid.MustParse("00000000-0000-0000-0000-000000000001"), // Demo org
 		Name:          "Demo OTLP Exporter",
 		ExporterType:  domain.TelemetryExporterOTLP,
 		Endpoint:      "https://otel-collector.example.com:4318",
@@ -84,7 +89,9 @@ func (e *Exporter) createDemoConfig() {
 }
 
 // CreateConfig creates a new telemetry configuration.
-func (e *Exporter) CreateConfig(input domain.TelemetryConfigInput, orgID uuid.UUID) *domain.TelemetryConfig {
+func (e *Exporter) CreateConfig(ctx context.Context, input domain.TelemetryConfigInput, orgID uuid.UUID) *domain.TelemetryConfig {
+	logger := middleware.RequestLogger(ctx, e.logger)
+
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
@@ -120,7 +127,7 @@ func (e *Exporter) CreateConfig(input domain.TelemetryConfigInput, orgID uuid.UU
 
 	e.configs[config.ID] = config
 
-	e.logger.Info().
+	logger.Info().
 		Str("config_id", config.ID.String()).
 		Str("name", config.Name).
 		Str("endpoint", config.Endpoint).
@@ -129,32 +136,40 @@ func (e *Exporter) CreateConfig(input domain.TelemetryConfigInput, orgID uuid.UU
 	return config
 }
 
-// GetConfig returns a config by ID.
-func (e *Exporter) GetConfig(id uuid.UUID) *domain.TelemetryConfig {
+// GetConfig returns an organization's config by ID.
+func (e *Exporter) GetConfig(orgID, id uuid.UUID) *domain.TelemetryConfig {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
-	return e.configs[id]
+
+	config, exists := e.configs[id]
+	if !exists || config.OrgID != orgID {
+		return nil
+	}
+	return config
 }
 
-// ListConfigs returns all configs.
-func (e *Exporter) ListConfigs() []domain.TelemetryConfig {
+// ListConfigs returns an organization's configs.
+func (e *Exporter) ListConfigs(orgID uuid.UUID) []domain.TelemetryConfig {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
 
 	configs := make([]domain.TelemetryConfig, 0, len(e.configs))
 	for _, c := range e.configs {
+		if c.OrgID != orgID {
+			continue
+		}
 		configs = append(configs, *c)
 	}
 	return configs
 }
 
 // UpdateConfig updates an existing config.
-func (e *Exporter) UpdateConfig(id uuid.UUID, input domain.TelemetryConfigInput) *domain.TelemetryConfig {
+func (e *Exporter) UpdateConfig(orgID, id uuid.UUID, input domain.TelemetryConfigInput) *domain.TelemetryConfig {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
 	config, exists := e.configs[id]
-	if !exists {
+	if !exists || config.OrgID != orgID {
 		return nil
 	}
 
@@ -177,11 +192,11 @@ func (e *Exporter) UpdateConfig(id uuid.UUID, input domain.TelemetryConfigInput)
 }
 
 // DeleteConfig deletes a config.
-func (e *Exporter) DeleteConfig(id uuid.UUID) bool {
+func (e *Exporter) DeleteConfig(orgID, id uuid.UUID) bool {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
-	if _, exists := e.configs[id]; exists {
+	if config, exists := e.configs[id]; exists && config.OrgID == orgID {
 		delete(e.configs, id)
 		return true
 	}
@@ -189,12 +204,12 @@ func (e *Exporter) DeleteConfig(id uuid.UUID) bool {
 }
 
 // TestConfig tests connectivity to the OTLP endpoint.
-func (e *Exporter) TestConfig(id uuid.UUID) domain.TelemetryTestResult {
+func (e *Exporter) TestConfig(ctx context.Context, orgID, id uuid.UUID) domain.TelemetryTestResult {
 	e.mu.RLock()
 	config, exists := e.configs[id]
 	e.mu.RUnlock()
 
-	if !exists {
+	if !exists || config.OrgID != orgID {
 		return domain.TelemetryTestResult{
 			Success: false,
 			Message: "Configuration not found",
@@ -216,7 +231,7 @@ func (e *Exporter) TestConfig(id uuid.UUID) domain.TelemetryTestResult {
 	}
 
 	start := time.Now()
-	err := e.exportSpans(*config, []domain.TelemetrySpan{testSpan})
+	err := e.exportSpans(ctx, *config, []domain.TelemetrySpan{testSpan})
 	latency := time.Since(start).Milliseconds()
 
 	if err != nil {
@@ -272,8 +287,31 @@ func (e *Exporter) exportLoop() {
 	ticker := time.NewTicker(5 * time.Second)
 	defer ticker.Stop()
 
-	for range ticker.C {
+	for {
+		select {
+		case <-ticker.C:
+			e.flush()
+		case <-e.stop:
+			return
+		}
+	}
+}
+
+// Shutdown stops the background export loop and flushes any queued spans and metrics.
+func (e *Exporter) Shutdown(ctx context.Context) error {
+	e.stopOnce.Do(func() { close(e.stop) })
+
+	done := make(chan struct{})
+	go func() {
 		e.flush()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("flush telemetry queue: %w", ctx.Err())
 	}
 }
 
@@ -304,7 +342,7 @@ func (e *Exporter) flush() {
 			// Apply sampling
 			sampled := e.sampleSpans(spans, config.SampleRate)
 			if len(sampled) > 0 {
-				if err := e.exportSpans(*config, sampled); err != nil {
+				if err := e.exportSpans(context.Background(), *config, sampled); err != nil {
 					e.logger.Error().
 						Err(err).
 						Str("config_id", config.ID.String()).
@@ -317,7 +355,7 @@ func (e *Exporter) flush() {
 		}
 
 		if config.ExportMetrics && len(metrics) > 0 {
-			if err := e.exportMetrics(*config, metrics); err != nil {
+			if err := e.exportMetrics(context.Background(), *config, metrics); err != nil {
 				e.logger.Error().
 					Err(err).
 					Str("config_id", config.ID.String()).
@@ -347,10 +385,12 @@ func (e *Exporter) sampleSpans(spans []domain.TelemetrySpan, rate float64) []dom
 	return sampled
 }
 
-func (e *Exporter) exportSpans(config domain.TelemetryConfig, spans []domain.TelemetrySpan) error {
+func (e *Exporter) exportSpans(ctx context.Context, config domain.TelemetryConfig, spans []domain.TelemetrySpan) error {
+	logger := middleware.RequestLogger(ctx, e.logger)
+
 	// In demo mode, just log
 	if config.Endpoint == "https://otel-collector.example.com:4318" {
-		e.logger.Debug().
+		logger.Debug().
 			Int("span_count", len(spans)).
 			Msg("Demo mode: Would export spans to OTLP endpoint")
 		return nil
@@ -371,7 +411,7 @@ func (e *Exporter) exportSpans(config domain.TelemetryConfig, spans []domain.Tel
 	}
 
 	start := time.Now()
-	req, err := http.NewRequestWithContext(context.Background(), "POST", endpoint, bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(body))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -399,17 +439,19 @@ func (e *Exporter) exportSpans(config domain.TelemetryConfig, spans []domain.Tel
 	return nil
 }
 
-func (e *Exporter) exportMetrics(config domain.TelemetryConfig, metrics []domain.TelemetryMetric) error {
+func (e *Exporter) exportMetrics(ctx context.Context, config domain.TelemetryConfig, metrics []domain.TelemetryMetric) error {
+	logger := middleware.RequestLogger(ctx, e.logger)
+
 	// In demo mode, just log
 	if config.Endpoint == "https://otel-collector.example.com:4318" {
-		e.logger.Debug().
+		logger.Debug().
 			Int("metric_count", len(metrics)).
 			Msg("Demo mode: Would export metrics to OTLP endpoint")
 		return nil
 	}
 
 	// For now, just log - full metrics export would require more complex OTLP metrics format
-	e.logger.Debug().
+	logger.Debug().
 		Int("metric_count", len(metrics)).
 		Str("endpoint", config.Endpoint).
 		Msg("Exporting metrics")
@@ -0,0 +1,78 @@
+// Package pricing prices MCP tool calls from the configured pricing table.
+package pricing
+
+import (
+	"encoding/json"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/config"
+)
+
+// DefaultCallCost is the per-call cost used for servers without configured pricing.
+const DefaultCallCost = 0.0001
+
+// bytesPerToken approximates the token count of a payload from its size.
+const bytesPerToken = 4
+
+// CostEstimator prices tool calls from each server's pricing table. Estimate
+// gives the cost known before a call is made, for budget checks, dry runs and
+// approval review; Actual gives the cost once the response size is known,
+// which is what gets recorded.
+type CostEstimator struct {
+	config *config.Config
+}
+
+// NewCostEstimator creates a cost estimator over the servers in cfg.
+func NewCostEstimator(cfg *config.Config) *CostEstimator {
+	return &CostEstimator{config: cfg}
+}
+
+// Estimate returns the cost of calling tool on server with args before the
+// call is made. Output-based rates are left out because the response size is
+// not yet known.
+func (e *CostEstimator) Estimate(server, tool string, args map[string]interface{}) float64 {
+	pricing, ok := e.pricing(server, tool)
+	if !ok {
+		return DefaultCallCost
+	}
+	inputSize := 0
+	if len(args) > 0 {
+		if raw, err := json.Marshal(args); err == nil {
+			inputSize = len(raw)
+		}
+	}
+	return cost(pricing, inputSize, 0)
+}
+
+// Actual returns the cost of a completed call from the sizes of the request
+// sent and the response received.
+func (e *CostEstimator) Actual(server, tool string, requestSize, responseSize int) float64 {
+	pricing, ok := e.pricing(server, tool)
+	if !ok {
+		return DefaultCallCost
+	}
+	return cost(pricing, requestSize, responseSize)
+}
+
+func (e *CostEstimator) pricing(server, tool string) (config.MCPPricing, bool) {
+	if e == nil || e.config == nil {
+		return config.MCPPricing{}, false
+	}
+	serverConfig, ok := e.config.MCPServer(server)
+	if !ok {
+		return config.MCPPricing{}, false
+	}
+	return serverConfig.Pricing.ForTool(tool), true
+}
+
+func cost(p config.MCPPricing, inputSize, outputSize int) float64 {
+	return p.PerCall +
+		float64(inputSize)*p.PerInputByte +
+		float64(outputSize)*p.PerOutputByte +
+		float64(tokens(inputSize))*p.PerInputToken +
+		float64(tokens(outputSize))*p.PerOutputToken
+}
+
+// tokens estimates the number of tokens in a payload of size bytes, rounding up.
+func tokens(size int) int {
+	return (size + bytesPerToken - 1) / bytesPerToken
+}
@@ -0,0 +1,46 @@
+package pricing
+
+import (
+	"math"
+	"testing"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/config"
+)
+
+func TestCostEstimator(t *testing.T) {
+	cfg := &config.Config{MCPServers: map[string]config.MCPServerConfig{
+		"search": {Pricing: config.MCPPricing{
+			PerCall:        0.01,
+			PerInputToken:  0.001,
+			PerOutputToken: 0.002,
+			Tools: map[string]config.MCPPricing{
+				"deep_search": {PerCall: 0.5, PerOutputByte: 0.0001},
+			},
+		}},
+	}}
+	e := NewCostEstimator(cfg)
+	args := map[string]interface{}{"q": "gateway"} // {"q":"gateway"}: 13 bytes, 4 tokens
+
+	tests := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"estimate uses input only", e.Estimate("search", "web_search", args), 0.01 + 4*0.001},
+		{"estimate without arguments", e.Estimate("search", "web_search", nil), 0.01},
+		{"actual adds output", e.Actual("search", "web_search", 13, 9), 0.01 + 4*0.001 + 3*0.002},
+		{"tool pricing replaces the server's", e.Actual("search", "deep_search", 13, 1000), 0.5 + 1000*0.0001},
+		{"unpriced server", e.Estimate("files", "read_file", args), DefaultCallCost},
+		{"unpriced server actual", e.Actual("files", "read_file", 100, 100), DefaultCallCost},
+	}
+	for _, tt := range tests {
+		if math.Abs(tt.got-tt.want) > 1e-12 {
+			t.Errorf("%s: cost = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+
+	var none *CostEstimator
+	if got := none.Estimate("search", "web_search", args); got != DefaultCallCost {
+		t.Errorf("nil estimator: cost = %v, want the default", got)
+	}
+}
@@ -5,40 +5,63 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"sync"
 	"time"
 
+	"github.com/akz4ol/gatewayops/gateway/internal/clock"
 	"github.com/akz4ol/gatewayops/gateway/internal/database"
 	"github.com/rs/zerolog"
 )
 
-// Limiter implements rate limiting using Redis.
+// Limiter implements rate limiting using Redis. Without Redis, requests are
+// counted in memory, which only limits the requests this instance serves.
 type Limiter struct {
 	redis  *database.Redis
 	logger zerolog.Logger
+	clock  clock.Clock
 	window time.Duration
+
+	mu    sync.Mutex
+	local map[string]*localCounter
+}
+
+// localCounter counts requests for a key until the window resets.
+type localCounter struct {
+	count   int
+	resetAt time.Time
 }
 
-// NewLimiter creates a new Redis-backed rate limiter.
-func NewLimiter(redis *database.Redis, logger zerolog.Logger) *Limiter {
-	logger.Info().Msg("Rate limiter initialized with Redis backend")
-	return &Limiter{
+// NewLimiter creates a new Redis-backed rate limiter. Windows are timed by
+// clk.
+func NewLimiter(redis *database.Redis, logger zerolog.Logger, clk clock.Clock) *Limiter {
+	l := &Limiter{
 		redis:  redis,
 		logger: logger,
+		clock:  clk,
 		window: time.Minute,
+		local:  make(map[string]*localCounter),
+	}
+	if l.useRedis() {
+		logger.Info().Msg("Rate limiter initialized with Redis backend")
+	} else {
+		logger.Info().Msg("Rate limiter initialized with in-memory backend")
 	}
+	return l
+}
+
+func (l *Limiter) useRedis() bool {
+	return l.redis != nil && l.redis.Client != nil
 }
 
 // Allow checks if a request is allowed under the rate limit.
 // Returns: allowed, remaining, reset (seconds), error
 func (l *Limiter) Allow(ctx context.Context, key string, limit int) (bool, int, int, error) {
-	if l.redis == nil || l.redis.Client == nil {
-		// Fallback: allow all requests if Redis is unavailable
-		l.logger.Warn().Msg("Redis unavailable, allowing request")
-		return true, limit, 60, nil
+	if !l.useRedis() {
+		return l.allowLocal(key, limit)
 	}
 
 	redisKey := fmt.Sprintf("ratelimit:%s", key)
-	now := time.Now()
+	now := l.clock.Now()
 	windowStart := now.Truncate(l.window)
 	windowEnd := windowStart.Add(l.window)
 	resetSeconds := int(windowEnd.Sub(now).Seconds())
@@ -76,6 +99,33 @@ func (l *Limiter) Allow(ctx context.Context, key string, limit int) (bool, int,
 	return true, remaining, resetSeconds, nil
 }
 
+// allowLocal counts a request for key in memory. As with Redis, the window
+// starts at a key's first request.
+func (l *Limiter) allowLocal(key string, limit int) (bool, int, int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	counter, ok := l.local[key]
+	if !ok || !now.Before(counter.resetAt) {
+		// Forget keys whose window has passed
+		for k, other := range l.local {
+			if !now.Before(other.resetAt) {
+				delete(l.local, k)
+			}
+		}
+		counter = &localCounter{resetAt: now.Add(l.window)}
+		l.local[key] = counter
+	}
+	counter.count++
+
+	resetSeconds := int(counter.resetAt.Sub(now).Seconds())
+	if counter.count > limit {
+		return false, 0, resetSeconds, nil
+	}
+	return true, limit - counter.count, resetSeconds, nil
+}
+
 // AllowWithBurst checks rate limit with burst capacity.
 func (l *Limiter) AllowWithBurst(ctx context.Context, key string, limit, burst int) (bool, int, int, error) {
 	// For burst, we use a token bucket algorithm
@@ -85,7 +135,12 @@ func (l *Limiter) AllowWithBurst(ctx context.Context, key string, limit, burst i
 
 // GetUsage returns current usage for a key.
 func (l *Limiter) GetUsage(ctx context.Context, key string) (int, error) {
-	if l.redis == nil || l.redis.Client == nil {
+	if !l.useRedis() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if counter, ok := l.local[key]; ok && l.clock.Now().Before(counter.resetAt) {
+			return counter.count, nil
+		}
 		return 0, nil
 	}
 
@@ -105,7 +160,10 @@ func (l *Limiter) GetUsage(ctx context.Context, key string) (int, error) {
 
 // Reset clears the rate limit for a key.
 func (l *Limiter) Reset(ctx context.Context, key string) error {
-	if l.redis == nil || l.redis.Client == nil {
+	if !l.useRedis() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		delete(l.local, key)
 		return nil
 	}
 
@@ -113,18 +171,18 @@ func (l *Limiter) Reset(ctx context.Context, key string) error {
 	return l.redis.Del(ctx, redisKey)
 }
 
-// Health checks if rate limiter is healthy.
+// Health checks if rate limiter is healthy. Counting in memory always is.
 func (l *Limiter) Health() bool {
-	if l.redis == nil {
-		return false
+	if !l.useRedis() {
+		return true
 	}
 	return l.redis.Health()
 }
 
 // Ready checks if rate limiter is ready.
 func (l *Limiter) Ready() bool {
-	if l.redis == nil {
-		return false
+	if !l.useRedis() {
+		return true
 	}
 	return l.redis.Ready()
 }
@@ -0,0 +1,151 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/clock"
+	"github.com/akz4ol/gatewayops/gateway/internal/database"
+	"github.com/rs/zerolog"
+)
+
+// Lockout locks out a key, such as a client IP or an account, after repeated
+// failed authentication attempts. Failures are counted in Redis so every
+// instance sees them; without Redis they are counted in memory.
+type Lockout struct {
+	redis     *database.Redis
+	logger    zerolog.Logger
+	clock     clock.Clock
+	threshold int           // Failures within window that trigger a lockout
+	window    time.Duration // Period over which failures are counted
+	duration  time.Duration // How long a lockout lasts
+
+	mu    sync.Mutex
+	local map[string]*lockoutEntry
+}
+
+type lockoutEntry struct {
+	failures    int
+	windowEnd   time.Time
+	lockedUntil time.Time
+}
+
+// NewLockout creates a lockout that locks a key for duration once it has
+// failed threshold times within window. In-memory windows and lockouts are
+// timed by clk.
+func NewLockout(redis *database.Redis, logger zerolog.Logger, clk clock.Clock, threshold int, window, duration time.Duration) *Lockout {
+	return &Lockout{
+		redis:     redis,
+		logger:    logger,
+		clock:     clk,
+		threshold: threshold,
+		window:    window,
+		duration:  duration,
+		local:     make(map[string]*lockoutEntry),
+	}
+}
+
+func (l *Lockout) useRedis() bool {
+	return l.redis != nil && l.redis.Client != nil
+}
+
+// Locked reports whether key is locked out and, if so, for how much longer.
+func (l *Lockout) Locked(ctx context.Context, key string) (bool, time.Duration) {
+	if l.useRedis() {
+		ttl, err := l.redis.TTL(ctx, "lockout:lock:"+key)
+		if err != nil {
+			l.logger.Error().Err(err).Str("key", key).Msg("Failed to read lockout")
+			return false, 0
+		}
+		return ttl > 0, ttl
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entry, ok := l.local[key]
+	if !ok {
+		return false, 0
+	}
+	remaining := entry.lockedUntil.Sub(l.clock.Now())
+	return remaining > 0, remaining
+}
+
+// Fail records a failed attempt for key. It returns true when this failure
+// locks the key out.
+func (l *Lockout) Fail(ctx context.Context, key string) bool {
+	if l.useRedis() {
+		return l.failRedis(ctx, key)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	entry, ok := l.local[key]
+	if !ok {
+		// Forget entries whose window and lockout have both lapsed
+		for k, e := range l.local {
+			if now.After(e.windowEnd) && now.After(e.lockedUntil) {
+				delete(l.local, k)
+			}
+		}
+		entry = &lockoutEntry{}
+		l.local[key] = entry
+	}
+	if now.After(entry.windowEnd) {
+		entry.failures = 0
+		entry.windowEnd = now.Add(l.window)
+	}
+	entry.failures++
+	if entry.failures < l.threshold {
+		return false
+	}
+	entry.failures = 0
+	entry.windowEnd = now.Add(l.window)
+	entry.lockedUntil = now.Add(l.duration)
+	return true
+}
+
+func (l *Lockout) failRedis(ctx context.Context, key string) bool {
+	failKey := "lockout:fail:" + key
+	count, err := l.redis.Incr(ctx, failKey)
+	if err != nil {
+		l.logger.Error().Err(err).Str("key", key).Msg("Failed to count failed attempt")
+		return false
+	}
+	if count == 1 {
+		if err := l.redis.Expire(ctx, failKey, l.window); err != nil {
+			l.logger.Error().Err(err).Str("key", key).Msg("Failed to set expiration on failed attempt counter")
+		}
+	}
+	if int(count) < l.threshold {
+		return false
+	}
+
+	if err := l.redis.Set(ctx, "lockout:lock:"+key, 1, l.duration); err != nil {
+		l.logger.Error().Err(err).Str("key", key).Msg("Failed to set lockout")
+		return false
+	}
+	if err := l.redis.Del(ctx, failKey); err != nil {
+		l.logger.Error().Err(err).Str("key", key).Msg("Failed to clear failed attempt counter")
+	}
+	return true
+}
+
+// Reset forgets the failed attempts recorded for key. It does not lift a
+// lockout already in force.
+func (l *Lockout) Reset(ctx context.Context, key string) {
+	if l.useRedis() {
+		if err := l.redis.Del(ctx, "lockout:fail:"+key); err != nil {
+			l.logger.Error().Err(err).Str("key", key).Msg("Failed to clear failed attempt counter")
+		}
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if entry, ok := l.local[key]; ok {
+		entry.failures = 0
+	}
+}
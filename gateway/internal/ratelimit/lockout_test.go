@@ -0,0 +1,67 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/clock"
+	"github.com/rs/zerolog"
+)
+
+func newTestLockout(clk clock.Clock) *Lockout {
+	return NewLockout(nil, zerolog.Nop(), clk, 3, time.Minute, 5*time.Minute)
+}
+
+func TestLockoutExpires(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	l := newTestLockout(clk)
+
+	for i := 1; i < 3; i++ {
+		if l.Fail(ctx, "203.0.113.7") {
+			t.Fatalf("failure %d locked the key out before the threshold", i)
+		}
+	}
+	if !l.Fail(ctx, "203.0.113.7") {
+		t.Fatal("third failure did not lock the key out")
+	}
+	if locked, remaining := l.Locked(ctx, "203.0.113.7"); !locked || remaining != 5*time.Minute {
+		t.Fatalf("Locked = %v, %s; want true, 5m", locked, remaining)
+	}
+	if locked, _ := l.Locked(ctx, "203.0.113.8"); locked {
+		t.Error("another key is locked out")
+	}
+
+	clk.Advance(4 * time.Minute)
+	if locked, remaining := l.Locked(ctx, "203.0.113.7"); !locked || remaining != time.Minute {
+		t.Fatalf("after 4m: Locked = %v, %s; want true, 1m", locked, remaining)
+	}
+
+	clk.Advance(time.Minute)
+	if locked, _ := l.Locked(ctx, "203.0.113.7"); locked {
+		t.Error("lockout did not lift once its duration passed")
+	}
+}
+
+func TestLockoutCountsFailuresWithinWindow(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	l := newTestLockout(clk)
+
+	l.Fail(ctx, "alice")
+	l.Fail(ctx, "alice")
+	clk.Advance(time.Minute + time.Second)
+	if l.Fail(ctx, "alice") {
+		t.Fatal("failures from a lapsed window counted toward the lockout")
+	}
+
+	l.Reset(ctx, "alice")
+	l.Fail(ctx, "alice")
+	if l.Fail(ctx, "alice") {
+		t.Error("failures before Reset counted toward the lockout")
+	}
+	if !l.Fail(ctx, "alice") {
+		t.Error("third failure after Reset did not lock the key out")
+	}
+}
@@ -97,28 +97,39 @@ func (s *Service) CreateRole(input domain.RoleInput, orgID uuid.UUID) *domain.Ro
 	return role
 }
 
-// GetRole returns a role by ID.
-func (s *Service) GetRole(id uuid.UUID) *domain.Role {
+// visibleTo reports whether an organization can see a role. Built-in roles
+// are shared by every organization.
+func visibleTo(role *domain.Role, orgID uuid.UUID) bool {
+	return role.OrgID == nil || *role.OrgID == orgID
+}
+
+// GetRole returns a role by ID if it is visible to the organization.
+func (s *Service) GetRole(orgID, id uuid.UUID) *domain.Role {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	return s.roles[id]
+
+	role, exists := s.roles[id]
+	if !exists || !visibleTo(role, orgID) {
+		return nil
+	}
+	return role
 }
 
-// GetRoleByName returns a role by name.
-func (s *Service) GetRoleByName(name string) *domain.Role {
+// GetRoleByName returns a role visible to the organization by name.
+func (s *Service) GetRoleByName(orgID uuid.UUID, name string) *domain.Role {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	for _, role := range s.roles {
-		if role.Name == name {
+		if role.Name == name && visibleTo(role, orgID) {
 			return role
 		}
 	}
 	return nil
 }
 
-// ListRoles returns all roles.
-func (s *Service) ListRoles(includeBuiltin bool) []domain.Role {
+// ListRoles returns the built-in roles and the organization's custom roles.
+func (s *Service) ListRoles(orgID uuid.UUID, includeBuiltin bool) []domain.Role {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -127,18 +138,21 @@ func (s *Service) ListRoles(includeBuiltin bool) []domain.Role {
 		if !includeBuiltin && r.IsBuiltin {
 			continue
 		}
+		if !visibleTo(r, orgID) {
+			continue
+		}
 		roles = append(roles, *r)
 	}
 	return roles
 }
 
 // UpdateRole updates an existing role.
-func (s *Service) UpdateRole(id uuid.UUID, input domain.RoleInput) *domain.Role {
+func (s *Service) UpdateRole(orgID, id uuid.UUID, input domain.RoleInput) *domain.Role {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	role, exists := s.roles[id]
-	if !exists {
+	if !exists || !visibleTo(role, orgID) {
 		return nil
 	}
 
@@ -161,12 +175,12 @@ func (s *Service) UpdateRole(id uuid.UUID, input domain.RoleInput) *domain.Role
 }
 
 // DeleteRole deletes a custom role.
-func (s *Service) DeleteRole(id uuid.UUID) bool {
+func (s *Service) DeleteRole(orgID, id uuid.UUID) bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	role, exists := s.roles[id]
-	if !exists {
+	if !exists || !visibleTo(role, orgID) {
 		return false
 	}
 
@@ -387,6 +401,7 @@ func (s *Service) GetAllPermissions() []PermissionInfo {
 		{Permission: domain.PermissionKeysCreate, Category: "keys", Description: "Create API keys"},
 		{Permission: domain.PermissionKeysRevoke, Category: "keys", Description: "Revoke API keys"},
 		{Permission: domain.PermissionKeysRotate, Category: "keys", Description: "Rotate API keys"},
+		{Permission: domain.PermissionServiceAccount, Category: "keys", Description: "Mark a key as an internal service account"},
 		{Permission: domain.PermissionRBACRead, Category: "rbac", Description: "View roles and permissions"},
 		{Permission: domain.PermissionRBACAdmin, Category: "rbac", Description: "Manage roles and permissions"},
 		{Permission: domain.PermissionUsersRead, Category: "users", Description: "View user information"},
@@ -0,0 +1,102 @@
+package rbac
+
+import (
+	"testing"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+func TestCustomRolesAreScopedToTheirOrganization(t *testing.T) {
+	s := NewService(zerolog.Nop())
+	orgA, orgB := uuid.New(), uuid.New()
+	input := domain.RoleInput{Name: "auditor", Permissions: []domain.Permission{domain.PermissionAuditRead}}
+	role := s.CreateRole(input, orgA)
+
+	if s.GetRole(orgA, role.ID) == nil {
+		t.Error("GetRole: the owning organization cannot see its role")
+	}
+	if s.GetRole(orgB, role.ID) != nil {
+		t.Error("GetRole: another organization can see the role")
+	}
+	if s.GetRoleByName(orgB, "auditor") != nil {
+		t.Error("GetRoleByName: another organization can see the role")
+	}
+	for _, r := range s.ListRoles(orgB, true) {
+		if r.ID == role.ID {
+			t.Error("ListRoles: another organization lists the role")
+		}
+	}
+	if s.UpdateRole(orgB, role.ID, domain.RoleInput{Name: "renamed"}) != nil {
+		t.Error("UpdateRole: another organization changed the role")
+	}
+	if s.DeleteRole(orgB, role.ID) {
+		t.Error("DeleteRole: another organization deleted the role")
+	}
+	if got := s.GetRole(orgA, role.ID); got == nil || got.Name != "auditor" {
+		t.Errorf("role after another organization's attempts = %+v", got)
+	}
+
+	custom := s.ListRoles(orgA, false)
+	if len(custom) != 1 || custom[0].ID != role.ID {
+		t.Errorf("ListRoles without built-ins = %+v, want only the custom role", custom)
+	}
+}
+
+func TestBuiltinRolesAreSharedAndReadOnly(t *testing.T) {
+	s := NewService(zerolog.Nop())
+	orgA, orgB := uuid.New(), uuid.New()
+
+	admin := s.GetRoleByName(orgA, "admin")
+	if admin == nil {
+		t.Fatal("built-in admin role not found")
+	}
+	if s.GetRole(orgB, admin.ID) == nil {
+		t.Error("a built-in role is not visible to every organization")
+	}
+	if s.UpdateRole(orgA, admin.ID, domain.RoleInput{Name: "root"}) != nil {
+		t.Error("a built-in role was updated")
+	}
+	if s.DeleteRole(orgA, admin.ID) {
+		t.Error("a built-in role was deleted")
+	}
+}
+
+func TestRoleAssignments(t *testing.T) {
+	s := NewService(zerolog.Nop())
+	orgID, userID, by := uuid.New(), uuid.New(), uuid.New()
+	role := s.CreateRole(domain.RoleInput{
+		Name:        "exporter",
+		Permissions: []domain.Permission{domain.PermissionTracesExport},
+	}, orgID)
+
+	first := s.AssignRole(userID, domain.RoleAssignmentInput{RoleID: role.ID}, by)
+	again := s.AssignRole(userID, domain.RoleAssignmentInput{RoleID: role.ID}, by)
+	if first == nil || again == nil || first.ID != again.ID {
+		t.Fatalf("assigning a role twice = %v, %v, want the same assignment", first, again)
+	}
+	if n := len(s.GetUserRoles(userID)); n != 1 {
+		t.Errorf("%d assignments, want 1", n)
+	}
+	if s.AssignRole(userID, domain.RoleAssignmentInput{RoleID: uuid.New()}, by) != nil {
+		t.Error("assigned a role that does not exist")
+	}
+
+	if !s.HasPermission(userID, domain.PermissionTracesExport, domain.ScopeTypeGlobal, nil) {
+		t.Error("assigned permission not granted")
+	}
+	if s.HasPermission(userID, domain.PermissionKeysCreate, domain.ScopeTypeGlobal, nil) {
+		t.Error("permission granted without a role holding it")
+	}
+
+	if !s.DeleteRole(orgID, role.ID) {
+		t.Fatal("DeleteRole failed")
+	}
+	if n := len(s.GetUserRoles(userID)); n != 0 {
+		t.Errorf("%d assignments left after the role was deleted", n)
+	}
+	if s.HasPermission(userID, domain.PermissionTracesExport, domain.ScopeTypeGlobal, nil) {
+		t.Error("permission still granted after the role was deleted")
+	}
+}
@@ -0,0 +1,188 @@
+// Package redact scrubs secrets and personal data from tool arguments before
+// they are stored with approval requests and safety detections.
+package redact
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/config"
+)
+
+// Placeholder replaces the value of a sensitive argument. Text matched by a
+// pattern is replaced with the pattern's name in the same form, such as
+// [REDACTED:email], so a reviewer can still tell what was there.
+const Placeholder = "[REDACTED]"
+
+// Redactor applies the configured rules. A nil Redactor redacts nothing.
+type Redactor struct {
+	rules []rule
+}
+
+// rule is the keys and patterns that apply to matching calls.
+type rule struct {
+	server   string // Empty matches every server
+	tool     string // Empty matches every tool
+	keys     map[string]bool
+	patterns []pattern
+}
+
+type pattern struct {
+	re          *regexp.Regexp
+	placeholder string
+}
+
+// New creates a redactor from cfg: its keys and patterns apply to every
+// tool, and each of its rules to the calls it matches.
+func New(cfg config.RedactionConfig) (*Redactor, error) {
+	global, err := newRule("", "", cfg.Keys, cfg.Patterns)
+	if err != nil {
+		return nil, err
+	}
+	r := &Redactor{rules: []rule{global}}
+	for _, spec := range cfg.Rules {
+		tool, err := newRule(spec.Server, spec.Tool, spec.Keys, spec.Patterns)
+		if err != nil {
+			return nil, fmt.Errorf("rule for %s/%s: %w", spec.Server, spec.Tool, err)
+		}
+		r.rules = append(r.rules, tool)
+	}
+	return r, nil
+}
+
+func newRule(server, tool string, keys []string, patterns map[string]string) (rule, error) {
+	if server == "*" {
+		server = ""
+	}
+	if tool == "*" {
+		tool = ""
+	}
+	ru := rule{server: server, tool: tool, keys: make(map[string]bool, len(keys))}
+	for _, key := range keys {
+		ru.keys[strings.ToLower(key)] = true
+	}
+
+	names := make([]string, 0, len(patterns))
+	for name := range patterns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		re, err := regexp.Compile(patterns[name])
+		if err != nil {
+			return rule{}, fmt.Errorf("pattern %q: %w", name, err)
+		}
+		ru.patterns = append(ru.patterns, pattern{re: re, placeholder: "[REDACTED:" + name + "]"})
+	}
+	return ru, nil
+}
+
+func (ru rule) matches(server, tool string) bool {
+	return (ru.server == "" || ru.server == server) && (ru.tool == "" || ru.tool == tool)
+}
+
+// applicable returns the sensitive keys and patterns for a call.
+func (r *Redactor) applicable(server, tool string) (map[string]bool, []pattern) {
+	keys := make(map[string]bool)
+	var patterns []pattern
+	for _, ru := range r.rules {
+		if !ru.matches(server, tool) {
+			continue
+		}
+		for key := range ru.keys {
+			keys[key] = true
+		}
+		patterns = append(patterns, ru.patterns...)
+	}
+	return keys, patterns
+}
+
+// Arguments returns a copy of a call's arguments with the values of
+// sensitive keys replaced at any depth and pattern matches replaced in the
+// remaining strings, and whether anything was replaced. args is not
+// modified.
+func (r *Redactor) Arguments(server, tool string, args map[string]interface{}) (map[string]interface{}, bool) {
+	if r == nil || args == nil {
+		return args, false
+	}
+	keys, patterns := r.applicable(server, tool)
+	redacted := false
+	result := scrubValue(args, keys, patterns, &redacted).(map[string]interface{})
+	return result, redacted
+}
+
+// Text returns text with pattern matches replaced, and with the values of
+// the sensitive keys in args, when given, replaced wherever they appear.
+// Safety detections store text taken from a call's arguments, so the keys
+// themselves are no longer there to go by.
+func (r *Redactor) Text(server, tool, text string, args map[string]interface{}) string {
+	if r == nil || text == "" {
+		return text
+	}
+	keys, patterns := r.applicable(server, tool)
+
+	var secrets []string
+	collectSecrets(args, keys, false, &secrets)
+	// Longest first, so a secret containing another is replaced whole
+	sort.Slice(secrets, func(i, j int) bool { return len(secrets[i]) > len(secrets[j]) })
+	for _, secret := range secrets {
+		text = strings.ReplaceAll(text, secret, Placeholder)
+	}
+	return scrubString(text, patterns, new(bool))
+}
+
+func scrubValue(value interface{}, keys map[string]bool, patterns []pattern, redacted *bool) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, field := range v {
+			if keys[strings.ToLower(key)] {
+				out[key] = Placeholder
+				*redacted = true
+				continue
+			}
+			out[key] = scrubValue(field, keys, patterns, redacted)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = scrubValue(item, keys, patterns, redacted)
+		}
+		return out
+	case string:
+		return scrubString(v, patterns, redacted)
+	default:
+		return v
+	}
+}
+
+func scrubString(s string, patterns []pattern, redacted *bool) string {
+	for _, p := range patterns {
+		if p.re.MatchString(s) {
+			s = p.re.ReplaceAllLiteralString(s, p.placeholder)
+			*redacted = true
+		}
+	}
+	return s
+}
+
+// collectSecrets appends the non-empty strings held under sensitive keys.
+func collectSecrets(value interface{}, keys map[string]bool, sensitive bool, secrets *[]string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			collectSecrets(field, keys, sensitive || keys[strings.ToLower(key)], secrets)
+		}
+	case []interface{}:
+		for _, item := range v {
+			collectSecrets(item, keys, sensitive, secrets)
+		}
+	case string:
+		if sensitive && v != "" {
+			*secrets = append(*secrets, v)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package redact
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/config"
+)
+
+func newTestRedactor(t *testing.T) *Redactor {
+	t.Helper()
+	r, err := New(config.RedactionConfig{
+		Keys:     []string{"password", "api_key"},
+		Patterns: map[string]string{"email": `[\w.]+@[\w.]+\.\w+`},
+		Rules: []config.ToolRedactionRule{
+			{Server: "database", Tool: "query", Keys: []string{"connection"}},
+			{Server: "*", Tool: "send_sms", Patterns: map[string]string{"phone": `\+\d{6,}`}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return r
+}
+
+func TestArguments(t *testing.T) {
+	r := newTestRedactor(t)
+	args := map[string]interface{}{
+		"Password":   "hunter2",
+		"query":      "SELECT * FROM users WHERE email = 'ann@example.com'",
+		"options":    map[string]interface{}{"api_key": "sk-123", "limit": float64(10)},
+		"targets":    []interface{}{"bob@example.com", "+4412345678"},
+		"connection": "postgres://admin:secret@db",
+	}
+
+	got, redacted := r.Arguments("database", "query", args)
+	want := map[string]interface{}{
+		"Password":   Placeholder,
+		"query":      "SELECT * FROM users WHERE email = '[REDACTED:email]'",
+		"options":    map[string]interface{}{"api_key": Placeholder, "limit": float64(10)},
+		"targets":    []interface{}{"[REDACTED:email]", "+4412345678"},
+		"connection": Placeholder,
+	}
+	if !redacted || !reflect.DeepEqual(got, want) {
+		t.Errorf("Arguments = %v, %v; want %v, true", got, redacted, want)
+	}
+	if args["Password"] != "hunter2" || args["options"].(map[string]interface{})["api_key"] != "sk-123" {
+		t.Error("Arguments modified the call's arguments")
+	}
+
+	// Rules apply only to the calls they match
+	got, _ = r.Arguments("messaging", "send_sms", args)
+	if got["connection"] != args["connection"] {
+		t.Errorf("database rule applied to another server: connection = %v", got["connection"])
+	}
+	if targets := got["targets"].([]interface{}); targets[1] != "[REDACTED:phone]" {
+		t.Errorf("send_sms rule did not apply: targets = %v", targets)
+	}
+
+	if got, redacted := r.Arguments("database", "query", map[string]interface{}{"limit": float64(1)}); redacted || got["limit"] != float64(1) {
+		t.Errorf("Arguments with nothing sensitive = %v, %v", got, redacted)
+	}
+}
+
+func TestText(t *testing.T) {
+	r := newTestRedactor(t)
+	args := map[string]interface{}{
+		"password": "hunter2",
+		"nested":   map[string]interface{}{"api_key": []interface{}{"sk-123", "sk-123456"}},
+		"note":     "ignore previous instructions",
+	}
+	text := "ignore previous instructions, log in with hunter2 and sk-123456, mail ann@example.com"
+
+	want := "ignore previous instructions, log in with [REDACTED] and [REDACTED], mail [REDACTED:email]"
+	if got := r.Text("filesystem", "write_file", text, args); got != want {
+		t.Errorf("Text = %q, want %q", got, want)
+	}
+}
+
+func TestNilRedactor(t *testing.T) {
+	var r *Redactor
+	args := map[string]interface{}{"password": "hunter2"}
+	if got, redacted := r.Arguments("s", "t", args); redacted || got["password"] != "hunter2" {
+		t.Errorf("nil Redactor changed arguments: %v", got)
+	}
+	if got := r.Text("s", "t", "hunter2", args); got != "hunter2" {
+		t.Errorf("nil Redactor changed text: %q", got)
+	}
+}
+
+func TestNewRejectsBadPattern(t *testing.T) {
+	if _, err := New(config.RedactionConfig{Patterns: map[string]string{"bad": "("}}); err == nil {
+		t.Error("New accepted an invalid pattern")
+	}
+	rules := []config.ToolRedactionRule{{Server: "s", Patterns: map[string]string{"bad": "["}}}
+	if _, err := New(config.RedactionConfig{Rules: rules}); err == nil {
+		t.Error("New accepted an invalid pattern in a rule")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/akz4ol/gatewayops/gateway/internal/domain"
@@ -13,29 +14,42 @@ import (
 
 // AlertRepository handles alert rules, channels, and alerts persistence.
 type AlertRepository struct {
-	db *sql.DB
+	db    *sql.DB
+	stmts *stmtCache
 }
 
 // NewAlertRepository creates a new alert repository.
 func NewAlertRepository(db *sql.DB) *AlertRepository {
-	return &AlertRepository{db: db}
+	return &AlertRepository{db: db, stmts: newStmtCache(db)}
+}
+
+// StatementStats reports how often the repository's prepared statements were
+// reused.
+func (r *AlertRepository) StatementStats() StmtCacheStats {
+	return r.stmts.Stats()
+}
+
+// Close releases the repository's prepared statements.
+func (r *AlertRepository) Close() error {
+	return r.stmts.Close()
 }
 
 // CreateRule inserts a new alert rule.
 func (r *AlertRepository) CreateRule(ctx context.Context, rule *domain.AlertRule) error {
 	channels, _ := json.Marshal(rule.Channels)
 	filters, _ := json.Marshal(rule.Filters)
+	templates, _ := json.Marshal(rule.Templates)
 
 	query := `
 		INSERT INTO alert_rules (
 			id, org_id, name, description, metric, condition,
-			threshold, window_minutes, severity, channels, filters,
+			threshold, window_minutes, severity, channels, filters, templates,
 			enabled, created_at, updated_at, created_by
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)`
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)`
 
 	_, err := r.db.ExecContext(ctx, query,
 		rule.ID, rule.OrgID, rule.Name, rule.Description, rule.Metric, rule.Condition,
-		rule.Threshold, rule.WindowMinutes, rule.Severity, channels, filters,
+		rule.Threshold, rule.WindowMinutes, rule.Severity, channels, filters, templates,
 		rule.Enabled, rule.CreatedAt, rule.UpdatedAt, rule.CreatedBy,
 	)
 	if err != nil {
@@ -49,17 +63,17 @@ func (r *AlertRepository) CreateRule(ctx context.Context, rule *domain.AlertRule
 func (r *AlertRepository) GetRule(ctx context.Context, id uuid.UUID) (*domain.AlertRule, error) {
 	query := `
 		SELECT id, org_id, name, description, metric, condition,
-			   threshold, window_minutes, severity, channels, filters,
+			   threshold, window_minutes, severity, channels, filters, COALESCE(templates, '{}'),
 			   enabled, created_at, updated_at, created_by
 		FROM alert_rules
-		WHERE id = $1`
+		WHERE id = $1 AND deleted_at IS NULL`
 
 	var rule domain.AlertRule
-	var channels, filters []byte
+	var channels, filters, templates []byte
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err := r.stmts.QueryRowContext(ctx, query, id).Scan(
 		&rule.ID, &rule.OrgID, &rule.Name, &rule.Description, &rule.Metric, &rule.Condition,
-		&rule.Threshold, &rule.WindowMinutes, &rule.Severity, &channels, &filters,
+		&rule.Threshold, &rule.WindowMinutes, &rule.Severity, &channels, &filters, &templates,
 		&rule.Enabled, &rule.CreatedAt, &rule.UpdatedAt, &rule.CreatedBy,
 	)
 	if err == sql.ErrNoRows {
@@ -69,8 +83,15 @@ func (r *AlertRepository) GetRule(ctx context.Context, id uuid.UUID) (*domain.Al
 		return nil, fmt.Errorf("query alert rule: %w", err)
 	}
 
-	json.Unmarshal(channels, &rule.Channels)
-	json.Unmarshal(filters, &rule.Filters)
+	if err := decodeRequiredJSON("alert_rules.channels", rule.ID, channels, &rule.Channels); err != nil {
+		return nil, err
+	}
+	if err := decodeJSON("alert_rules.filters", rule.ID, filters, &rule.Filters); err != nil {
+		return nil, err
+	}
+	if err := decodeJSON("alert_rules.templates", rule.ID, templates, &rule.Templates); err != nil {
+		return nil, err
+	}
 
 	return &rule, nil
 }
@@ -83,24 +104,24 @@ func (r *AlertRepository) ListRules(ctx context.Context, orgID uuid.UUID, enable
 	if enabledOnly {
 		query = `
 			SELECT id, org_id, name, description, metric, condition,
-				   threshold, window_minutes, severity, channels, filters,
+				   threshold, window_minutes, severity, channels, filters, COALESCE(templates, '{}'),
 				   enabled, created_at, updated_at, created_by
 			FROM alert_rules
-			WHERE org_id = $1 AND enabled = true
+			WHERE org_id = $1 AND enabled = true AND deleted_at IS NULL
 			ORDER BY created_at DESC`
 		args = []interface{}{orgID}
 	} else {
 		query = `
 			SELECT id, org_id, name, description, metric, condition,
-				   threshold, window_minutes, severity, channels, filters,
+				   threshold, window_minutes, severity, channels, filters, COALESCE(templates, '{}'),
 				   enabled, created_at, updated_at, created_by
 			FROM alert_rules
-			WHERE org_id = $1
+			WHERE org_id = $1 AND deleted_at IS NULL
 			ORDER BY created_at DESC`
 		args = []interface{}{orgID}
 	}
 
-	rows, err := r.db.QueryContext(ctx, query, args...)
+	rows, err := r.stmts.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("query alert rules: %w", err)
 	}
@@ -109,19 +130,26 @@ func (r *AlertRepository) ListRules(ctx context.Context, orgID uuid.UUID, enable
 	var rules []domain.AlertRule
 	for rows.Next() {
 		var rule domain.AlertRule
-		var channels, filters []byte
+		var channels, filters, templates []byte
 
 		err := rows.Scan(
 			&rule.ID, &rule.OrgID, &rule.Name, &rule.Description, &rule.Metric, &rule.Condition,
-			&rule.Threshold, &rule.WindowMinutes, &rule.Severity, &channels, &filters,
+			&rule.Threshold, &rule.WindowMinutes, &rule.Severity, &channels, &filters, &templates,
 			&rule.Enabled, &rule.CreatedAt, &rule.UpdatedAt, &rule.CreatedBy,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("scan alert rule: %w", err)
 		}
 
-		json.Unmarshal(channels, &rule.Channels)
-		json.Unmarshal(filters, &rule.Filters)
+		if err := decodeRequiredJSON("alert_rules.channels", rule.ID, channels, &rule.Channels); err != nil {
+			return nil, err
+		}
+		if err := decodeJSON("alert_rules.filters", rule.ID, filters, &rule.Filters); err != nil {
+			return nil, err
+		}
+		if err := decodeJSON("alert_rules.templates", rule.ID, templates, &rule.Templates); err != nil {
+			return nil, err
+		}
 
 		rules = append(rules, rule)
 	}
@@ -133,18 +161,19 @@ func (r *AlertRepository) ListRules(ctx context.Context, orgID uuid.UUID, enable
 func (r *AlertRepository) UpdateRule(ctx context.Context, rule *domain.AlertRule) error {
 	channels, _ := json.Marshal(rule.Channels)
 	filters, _ := json.Marshal(rule.Filters)
+	templates, _ := json.Marshal(rule.Templates)
 
 	query := `
 		UPDATE alert_rules SET
 			name = $2, description = $3, metric = $4, condition = $5,
 			threshold = $6, window_minutes = $7, severity = $8, channels = $9,
-			filters = $10, enabled = $11, updated_at = $12
-		WHERE id = $1`
+			filters = $10, templates = $11, enabled = $12, updated_at = $13
+		WHERE id = $1 AND deleted_at IS NULL`
 
 	_, err := r.db.ExecContext(ctx, query,
 		rule.ID, rule.Name, rule.Description, rule.Metric, rule.Condition,
 		rule.Threshold, rule.WindowMinutes, rule.Severity, channels,
-		filters, rule.Enabled, rule.UpdatedAt,
+		filters, templates, rule.Enabled, rule.UpdatedAt,
 	)
 	if err != nil {
 		return fmt.Errorf("update alert rule: %w", err)
@@ -153,9 +182,11 @@ func (r *AlertRepository) UpdateRule(ctx context.Context, rule *domain.AlertRule
 	return nil
 }
 
-// DeleteRule deletes an alert rule.
+// DeleteRule soft-deletes an alert rule. The row is kept so alerts it raised
+// still resolve to it, and it can be restored.
 func (r *AlertRepository) DeleteRule(ctx context.Context, id uuid.UUID) error {
-	_, err := r.db.ExecContext(ctx, "DELETE FROM alert_rules WHERE id = $1", id)
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE alert_rules SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL", id)
 	if err != nil {
 		return fmt.Errorf("delete alert rule: %w", err)
 	}
@@ -163,18 +194,78 @@ func (r *AlertRepository) DeleteRule(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
+// RestoreRule clears the soft-delete mark of an alert rule.
+func (r *AlertRepository) RestoreRule(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE alert_rules SET deleted_at = NULL, updated_at = NOW() WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("restore alert rule: %w", err)
+	}
+
+	return nil
+}
+
+// ListDeletedRules retrieves an organization's soft-deleted alert rules.
+func (r *AlertRepository) ListDeletedRules(ctx context.Context, orgID uuid.UUID) ([]domain.AlertRule, error) {
+	query := `
+		SELECT id, org_id, name, description, metric, condition,
+			   threshold, window_minutes, severity, channels, filters, COALESCE(templates, '{}'),
+			   enabled, created_at, updated_at, created_by, deleted_at
+		FROM alert_rules
+		WHERE org_id = $1 AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC`
+
+	rows, err := r.stmts.QueryContext(ctx, query, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("query deleted alert rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []domain.AlertRule
+	for rows.Next() {
+		var rule domain.AlertRule
+		var channels, filters, templates []byte
+		var deletedAt time.Time
+
+		err := rows.Scan(
+			&rule.ID, &rule.OrgID, &rule.Name, &rule.Description, &rule.Metric, &rule.Condition,
+			&rule.Threshold, &rule.WindowMinutes, &rule.Severity, &channels, &filters, &templates,
+			&rule.Enabled, &rule.CreatedAt, &rule.UpdatedAt, &rule.CreatedBy, &deletedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scan alert rule: %w", err)
+		}
+		rule.DeletedAt = &deletedAt
+
+		if err := decodeRequiredJSON("alert_rules.channels", rule.ID, channels, &rule.Channels); err != nil {
+			return nil, err
+		}
+		if err := decodeJSON("alert_rules.filters", rule.ID, filters, &rule.Filters); err != nil {
+			return nil, err
+		}
+		if err := decodeJSON("alert_rules.templates", rule.ID, templates, &rule.Templates); err != nil {
+			return nil, err
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
 // CreateChannel inserts a new alert channel.
 func (r *AlertRepository) CreateChannel(ctx context.Context, channel *domain.AlertChannel) error {
 	config, _ := json.Marshal(channel.Config)
+	grouping, _ := json.Marshal(channel.Grouping)
 
 	query := `
 		INSERT INTO alert_channels (
-			id, org_id, name, type, config, enabled, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+			id, org_id, name, type, config, grouping, enabled, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
 
 	_, err := r.db.ExecContext(ctx, query,
 		channel.ID, channel.OrgID, channel.Name, channel.Type,
-		config, channel.Enabled, channel.CreatedAt, channel.UpdatedAt,
+		config, grouping, channel.Enabled, channel.CreatedAt, channel.UpdatedAt,
 	)
 	if err != nil {
 		return fmt.Errorf("insert alert channel: %w", err)
@@ -186,16 +277,16 @@ func (r *AlertRepository) CreateChannel(ctx context.Context, channel *domain.Ale
 // GetChannel retrieves an alert channel by ID.
 func (r *AlertRepository) GetChannel(ctx context.Context, id uuid.UUID) (*domain.AlertChannel, error) {
 	query := `
-		SELECT id, org_id, name, type, config, enabled, created_at, updated_at
+		SELECT id, org_id, name, type, config, COALESCE(grouping, '{}'), enabled, created_at, updated_at
 		FROM alert_channels
 		WHERE id = $1`
 
 	var channel domain.AlertChannel
-	var config []byte
+	var config, grouping []byte
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err := r.stmts.QueryRowContext(ctx, query, id).Scan(
 		&channel.ID, &channel.OrgID, &channel.Name, &channel.Type,
-		&config, &channel.Enabled, &channel.CreatedAt, &channel.UpdatedAt,
+		&config, &grouping, &channel.Enabled, &channel.CreatedAt, &channel.UpdatedAt,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -204,7 +295,12 @@ func (r *AlertRepository) GetChannel(ctx context.Context, id uuid.UUID) (*domain
 		return nil, fmt.Errorf("query alert channel: %w", err)
 	}
 
-	json.Unmarshal(config, &channel.Config)
+	if err := decodeRequiredJSON("alert_channels.config", channel.ID, config, &channel.Config); err != nil {
+		return nil, err
+	}
+	if err := decodeJSON("alert_channels.grouping", channel.ID, grouping, &channel.Grouping); err != nil {
+		return nil, err
+	}
 
 	return &channel, nil
 }
@@ -212,12 +308,12 @@ func (r *AlertRepository) GetChannel(ctx context.Context, id uuid.UUID) (*domain
 // ListChannels retrieves all alert channels for an organization.
 func (r *AlertRepository) ListChannels(ctx context.Context, orgID uuid.UUID) ([]domain.AlertChannel, error) {
 	query := `
-		SELECT id, org_id, name, type, config, enabled, created_at, updated_at
+		SELECT id, org_id, name, type, config, COALESCE(grouping, '{}'), enabled, created_at, updated_at
 		FROM alert_channels
 		WHERE org_id = $1
 		ORDER BY created_at DESC`
 
-	rows, err := r.db.QueryContext(ctx, query, orgID)
+	rows, err := r.stmts.QueryContext(ctx, query, orgID)
 	if err != nil {
 		return nil, fmt.Errorf("query alert channels: %w", err)
 	}
@@ -226,17 +322,22 @@ func (r *AlertRepository) ListChannels(ctx context.Context, orgID uuid.UUID) ([]
 	var channels []domain.AlertChannel
 	for rows.Next() {
 		var channel domain.AlertChannel
-		var config []byte
+		var config, grouping []byte
 
 		err := rows.Scan(
 			&channel.ID, &channel.OrgID, &channel.Name, &channel.Type,
-			&config, &channel.Enabled, &channel.CreatedAt, &channel.UpdatedAt,
+			&config, &grouping, &channel.Enabled, &channel.CreatedAt, &channel.UpdatedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("scan alert channel: %w", err)
 		}
 
-		json.Unmarshal(config, &channel.Config)
+		if err := decodeRequiredJSON("alert_channels.config", channel.ID, config, &channel.Config); err != nil {
+			return nil, err
+		}
+		if err := decodeJSON("alert_channels.grouping", channel.ID, grouping, &channel.Grouping); err != nil {
+			return nil, err
+		}
 		channels = append(channels, channel)
 	}
 
@@ -246,14 +347,15 @@ func (r *AlertRepository) ListChannels(ctx context.Context, orgID uuid.UUID) ([]
 // UpdateChannel updates an alert channel.
 func (r *AlertRepository) UpdateChannel(ctx context.Context, channel *domain.AlertChannel) error {
 	config, _ := json.Marshal(channel.Config)
+	grouping, _ := json.Marshal(channel.Grouping)
 
 	query := `
 		UPDATE alert_channels SET
-			name = $2, type = $3, config = $4, enabled = $5, updated_at = $6
+			name = $2, type = $3, config = $4, grouping = $5, enabled = $6, updated_at = $7
 		WHERE id = $1`
 
 	_, err := r.db.ExecContext(ctx, query,
-		channel.ID, channel.Name, channel.Type, config, channel.Enabled, channel.UpdatedAt,
+		channel.ID, channel.Name, channel.Type, config, grouping, channel.Enabled, channel.UpdatedAt,
 	)
 	if err != nil {
 		return fmt.Errorf("update alert channel: %w", err)
@@ -272,6 +374,101 @@ func (r *AlertRepository) DeleteChannel(ctx context.Context, id uuid.UUID) error
 	return nil
 }
 
+// CreateInhibitRule inserts a new inhibit rule.
+func (r *AlertRepository) CreateInhibitRule(ctx context.Context, rule *domain.InhibitRule) error {
+	sourceMatch, _ := json.Marshal(rule.SourceMatch)
+	targetMatch, _ := json.Marshal(rule.TargetMatch)
+	equal, _ := json.Marshal(rule.Equal)
+
+	query := `
+		INSERT INTO alert_inhibit_rules (
+			id, org_id, name, source_match, target_match, equal, enabled, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	_, err := r.db.ExecContext(ctx, query,
+		rule.ID, rule.OrgID, rule.Name, sourceMatch, targetMatch, equal,
+		rule.Enabled, rule.CreatedAt, rule.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("insert inhibit rule: %w", err)
+	}
+
+	return nil
+}
+
+// ListInhibitRules retrieves all inhibit rules for an organization.
+func (r *AlertRepository) ListInhibitRules(ctx context.Context, orgID uuid.UUID) ([]domain.InhibitRule, error) {
+	query := `
+		SELECT id, org_id, name, source_match, target_match, equal, enabled, created_at, updated_at
+		FROM alert_inhibit_rules
+		WHERE org_id = $1
+		ORDER BY created_at DESC`
+
+	rows, err := r.stmts.QueryContext(ctx, query, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("query inhibit rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []domain.InhibitRule
+	for rows.Next() {
+		var rule domain.InhibitRule
+		var sourceMatch, targetMatch, equal []byte
+
+		err := rows.Scan(
+			&rule.ID, &rule.OrgID, &rule.Name, &sourceMatch, &targetMatch, &equal,
+			&rule.Enabled, &rule.CreatedAt, &rule.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scan inhibit rule: %w", err)
+		}
+
+		if err := decodeRequiredJSON("alert_inhibit_rules.source_match", rule.ID, sourceMatch, &rule.SourceMatch); err != nil {
+			return nil, err
+		}
+		if err := decodeRequiredJSON("alert_inhibit_rules.target_match", rule.ID, targetMatch, &rule.TargetMatch); err != nil {
+			return nil, err
+		}
+		if err := decodeJSON("alert_inhibit_rules.equal", rule.ID, equal, &rule.Equal); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// UpdateInhibitRule updates an inhibit rule.
+func (r *AlertRepository) UpdateInhibitRule(ctx context.Context, rule *domain.InhibitRule) error {
+	sourceMatch, _ := json.Marshal(rule.SourceMatch)
+	targetMatch, _ := json.Marshal(rule.TargetMatch)
+	equal, _ := json.Marshal(rule.Equal)
+
+	query := `
+		UPDATE alert_inhibit_rules SET
+			name = $2, source_match = $3, target_match = $4, equal = $5, enabled = $6, updated_at = $7
+		WHERE id = $1`
+
+	_, err := r.db.ExecContext(ctx, query,
+		rule.ID, rule.Name, sourceMatch, targetMatch, equal, rule.Enabled, rule.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("update inhibit rule: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteInhibitRule deletes an inhibit rule.
+func (r *AlertRepository) DeleteInhibitRule(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM alert_inhibit_rules WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("delete inhibit rule: %w", err)
+	}
+
+	return nil
+}
+
 // CreateAlert inserts a new alert.
 func (r *AlertRepository) CreateAlert(ctx context.Context, alert *domain.Alert) error {
 	labels, _ := json.Marshal(alert.Labels)
@@ -279,12 +476,13 @@ func (r *AlertRepository) CreateAlert(ctx context.Context, alert *domain.Alert)
 	query := `
 		INSERT INTO alerts (
 			id, org_id, rule_id, status, severity, message,
-			value, threshold, labels, started_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+			value, threshold, labels, started_at, inhibited_by
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
 
 	_, err := r.db.ExecContext(ctx, query,
 		alert.ID, alert.OrgID, alert.RuleID, alert.Status, alert.Severity,
 		alert.Message, alert.Value, alert.Threshold, labels, alert.StartedAt,
+		alert.InhibitedBy,
 	)
 	if err != nil {
 		return fmt.Errorf("insert alert: %w", err)
@@ -297,19 +495,19 @@ func (r *AlertRepository) CreateAlert(ctx context.Context, alert *domain.Alert)
 func (r *AlertRepository) GetAlert(ctx context.Context, id uuid.UUID) (*domain.Alert, error) {
 	query := `
 		SELECT id, org_id, rule_id, status, severity, message,
-			   value, threshold, labels, started_at, resolved_at, acked_at, acked_by
+			   value, threshold, labels, started_at, resolved_at, acked_at, acked_by, inhibited_by
 		FROM alerts
 		WHERE id = $1`
 
 	var alert domain.Alert
 	var labels []byte
 	var resolvedAt, ackedAt sql.NullTime
-	var ackedBy sql.NullString
+	var ackedBy, inhibitedBy sql.NullString
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err := r.stmts.QueryRowContext(ctx, query, id).Scan(
 		&alert.ID, &alert.OrgID, &alert.RuleID, &alert.Status, &alert.Severity,
 		&alert.Message, &alert.Value, &alert.Threshold, &labels,
-		&alert.StartedAt, &resolvedAt, &ackedAt, &ackedBy,
+		&alert.StartedAt, &resolvedAt, &ackedAt, &ackedBy, &inhibitedBy,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -318,7 +516,9 @@ func (r *AlertRepository) GetAlert(ctx context.Context, id uuid.UUID) (*domain.A
 		return nil, fmt.Errorf("query alert: %w", err)
 	}
 
-	json.Unmarshal(labels, &alert.Labels)
+	if err := decodeJSON("alerts.labels", alert.ID, labels, &alert.Labels); err != nil {
+		return nil, err
+	}
 
 	if resolvedAt.Valid {
 		alert.ResolvedAt = &resolvedAt.Time
@@ -330,6 +530,10 @@ func (r *AlertRepository) GetAlert(ctx context.Context, id uuid.UUID) (*domain.A
 		aid, _ := uuid.Parse(ackedBy.String)
 		alert.AckedBy = &aid
 	}
+	if inhibitedBy.Valid {
+		iid, _ := uuid.Parse(inhibitedBy.String)
+		alert.InhibitedBy = &iid
+	}
 
 	return &alert, nil
 }
@@ -338,11 +542,11 @@ func (r *AlertRepository) GetAlert(ctx context.Context, id uuid.UUID) (*domain.A
 func (r *AlertRepository) UpdateAlert(ctx context.Context, alert *domain.Alert) error {
 	query := `
 		UPDATE alerts SET
-			status = $2, resolved_at = $3, acked_at = $4, acked_by = $5
+			status = $2, resolved_at = $3, acked_at = $4, acked_by = $5, inhibited_by = $6
 		WHERE id = $1`
 
 	_, err := r.db.ExecContext(ctx, query,
-		alert.ID, alert.Status, alert.ResolvedAt, alert.AckedAt, alert.AckedBy,
+		alert.ID, alert.Status, alert.ResolvedAt, alert.AckedAt, alert.AckedBy, alert.InhibitedBy,
 	)
 	if err != nil {
 		return fmt.Errorf("update alert: %w", err)
@@ -351,6 +555,71 @@ func (r *AlertRepository) UpdateAlert(ctx context.Context, alert *domain.Alert)
 	return nil
 }
 
+// AppendAlertEvent records a step in an alert's lifecycle. Events are never
+// updated or deleted.
+func (r *AlertRepository) AppendAlertEvent(ctx context.Context, event *domain.AlertEvent) error {
+	details, _ := json.Marshal(event.Details)
+
+	query := `
+		INSERT INTO alert_events (
+			id, alert_id, org_id, type, actor_type, actor_user_id, actor_integration, details, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	_, err := r.db.ExecContext(ctx, query,
+		event.ID, event.AlertID, event.OrgID, event.Type, event.Actor.Type,
+		event.Actor.UserID, event.Actor.Integration, details, event.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("insert alert event: %w", err)
+	}
+
+	return nil
+}
+
+// GetAlertEvents retrieves an alert's events, oldest first.
+func (r *AlertRepository) GetAlertEvents(ctx context.Context, alertID uuid.UUID) ([]domain.AlertEvent, error) {
+	query := `
+		SELECT id, alert_id, org_id, type, actor_type, actor_user_id, actor_integration, details, created_at
+		FROM alert_events
+		WHERE alert_id = $1
+		ORDER BY created_at, seq`
+
+	rows, err := r.stmts.QueryContext(ctx, query, alertID)
+	if err != nil {
+		return nil, fmt.Errorf("query alert events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []domain.AlertEvent
+	for rows.Next() {
+		var event domain.AlertEvent
+		var actorUserID sql.NullString
+		var details []byte
+
+		err := rows.Scan(
+			&event.ID, &event.AlertID, &event.OrgID, &event.Type, &event.Actor.Type,
+			&actorUserID, &event.Actor.Integration, &details, &event.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scan alert event: %w", err)
+		}
+
+		if actorUserID.Valid {
+			uid, _ := uuid.Parse(actorUserID.String)
+			event.Actor.UserID = &uid
+		}
+		if err := decodeJSON("alert_events.details", event.ID, details, &event.Details); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate alert events: %w", err)
+	}
+
+	return events, nil
+}
+
 // ListAlerts retrieves alerts with filtering.
 func (r *AlertRepository) ListAlerts(ctx context.Context, filter domain.AlertFilter) (*domain.AlertPage, error) {
 	var conditions []string
@@ -404,7 +673,7 @@ func (r *AlertRepository) ListAlerts(ctx context.Context, filter domain.AlertFil
 	// Count total
 	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM alerts WHERE %s", whereClause)
 	var total int64
-	if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+	if err := r.stmts.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
 		return nil, fmt.Errorf("count alerts: %w", err)
 	}
 
@@ -419,18 +688,29 @@ func (r *AlertRepository) ListAlerts(ctx context.Context, filter domain.AlertFil
 		offset = 0
 	}
 
+	// Keyset pagination takes precedence over offset when a cursor is given.
+	// One extra row is fetched to tell whether another page follows.
+	pageClause := fmt.Sprintf("LIMIT $%d OFFSET $%d", argNum, argNum+1)
+	pageArgs := []interface{}{limit, offset}
+	if filter.Cursor != nil {
+		whereClause += fmt.Sprintf(" AND (started_at, id) < ($%d, $%d)", argNum, argNum+1)
+		pageClause = fmt.Sprintf("LIMIT $%d", argNum+2)
+		pageArgs = []interface{}{filter.Cursor.CreatedAt, filter.Cursor.ID, limit + 1}
+		offset = 0
+	}
+
 	query := fmt.Sprintf(`
 		SELECT id, org_id, rule_id, status, severity, message,
-			   value, threshold, labels, started_at, resolved_at, acked_at, acked_by
+			   value, threshold, labels, started_at, resolved_at, acked_at, acked_by, inhibited_by
 		FROM alerts
 		WHERE %s
-		ORDER BY started_at DESC
-		LIMIT $%d OFFSET $%d`,
-		whereClause, argNum, argNum+1)
+		ORDER BY started_at DESC, id DESC
+		%s`,
+		whereClause, pageClause)
 
-	args = append(args, limit, offset)
+	args = append(args, pageArgs...)
 
-	rows, err := r.db.QueryContext(ctx, query, args...)
+	rows, err := r.stmts.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("query alerts: %w", err)
 	}
@@ -441,18 +721,20 @@ func (r *AlertRepository) ListAlerts(ctx context.Context, filter domain.AlertFil
 		var alert domain.Alert
 		var labels []byte
 		var resolvedAt, ackedAt sql.NullTime
-		var ackedBy sql.NullString
+		var ackedBy, inhibitedBy sql.NullString
 
 		err := rows.Scan(
 			&alert.ID, &alert.OrgID, &alert.RuleID, &alert.Status, &alert.Severity,
 			&alert.Message, &alert.Value, &alert.Threshold, &labels,
-			&alert.StartedAt, &resolvedAt, &ackedAt, &ackedBy,
+			&alert.StartedAt, &resolvedAt, &ackedAt, &ackedBy, &inhibitedBy,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("scan alert: %w", err)
 		}
 
-		json.Unmarshal(labels, &alert.Labels)
+		if err := decodeJSON("alerts.labels", alert.ID, labels, &alert.Labels); err != nil {
+			return nil, err
+		}
 
 		if resolvedAt.Valid {
 			alert.ResolvedAt = &resolvedAt.Time
@@ -464,24 +746,40 @@ func (r *AlertRepository) ListAlerts(ctx context.Context, filter domain.AlertFil
 			aid, _ := uuid.Parse(ackedBy.String)
 			alert.AckedBy = &aid
 		}
+		if inhibitedBy.Valid {
+			iid, _ := uuid.Parse(inhibitedBy.String)
+			alert.InhibitedBy = &iid
+		}
 
 		alerts = append(alerts, alert)
 	}
 
-	return &domain.AlertPage{
+	page := &domain.AlertPage{
 		Alerts:  alerts,
 		Total:   total,
 		Limit:   limit,
 		Offset:  offset,
 		HasMore: int64(offset+len(alerts)) < total,
-	}, nil
+	}
+	if filter.Cursor != nil {
+		page.HasMore = len(alerts) > limit
+		if page.HasMore {
+			page.Alerts = alerts[:limit]
+		}
+	}
+	if page.HasMore && len(page.Alerts) > 0 {
+		last := page.Alerts[len(page.Alerts)-1]
+		page.NextCursor = domain.NewCursor(last.StartedAt, last.ID).Encode()
+	}
+
+	return page, nil
 }
 
 // GetFiringAlertByRule retrieves a firing alert for a specific rule.
 func (r *AlertRepository) GetFiringAlertByRule(ctx context.Context, ruleID uuid.UUID) (*domain.Alert, error) {
 	query := `
 		SELECT id, org_id, rule_id, status, severity, message,
-			   value, threshold, labels, started_at, resolved_at, acked_at, acked_by
+			   value, threshold, labels, started_at, resolved_at, acked_at, acked_by, inhibited_by
 		FROM alerts
 		WHERE rule_id = $1 AND status = 'firing'
 		ORDER BY started_at DESC
@@ -490,12 +788,12 @@ func (r *AlertRepository) GetFiringAlertByRule(ctx context.Context, ruleID uuid.
 	var alert domain.Alert
 	var labels []byte
 	var resolvedAt, ackedAt sql.NullTime
-	var ackedBy sql.NullString
+	var ackedBy, inhibitedBy sql.NullString
 
-	err := r.db.QueryRowContext(ctx, query, ruleID).Scan(
+	err := r.stmts.QueryRowContext(ctx, query, ruleID).Scan(
 		&alert.ID, &alert.OrgID, &alert.RuleID, &alert.Status, &alert.Severity,
 		&alert.Message, &alert.Value, &alert.Threshold, &labels,
-		&alert.StartedAt, &resolvedAt, &ackedAt, &ackedBy,
+		&alert.StartedAt, &resolvedAt, &ackedAt, &ackedBy, &inhibitedBy,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -504,7 +802,9 @@ func (r *AlertRepository) GetFiringAlertByRule(ctx context.Context, ruleID uuid.
 		return nil, fmt.Errorf("query firing alert: %w", err)
 	}
 
-	json.Unmarshal(labels, &alert.Labels)
+	if err := decodeJSON("alerts.labels", alert.ID, labels, &alert.Labels); err != nil {
+		return nil, err
+	}
 
 	if resolvedAt.Valid {
 		alert.ResolvedAt = &resolvedAt.Time
@@ -516,6 +816,10 @@ func (r *AlertRepository) GetFiringAlertByRule(ctx context.Context, ruleID uuid.
 		aid, _ := uuid.Parse(ackedBy.String)
 		alert.AckedBy = &aid
 	}
+	if inhibitedBy.Valid {
+		iid, _ := uuid.Parse(inhibitedBy.String)
+		alert.InhibitedBy = &iid
+	}
 
 	return &alert, nil
 }
@@ -523,7 +827,7 @@ func (r *AlertRepository) GetFiringAlertByRule(ctx context.Context, ruleID uuid.
 // CountActiveAlerts counts firing alerts for an organization.
 func (r *AlertRepository) CountActiveAlerts(ctx context.Context, orgID uuid.UUID) (int64, error) {
 	var count int64
-	err := r.db.QueryRowContext(ctx,
+	err := r.stmts.QueryRowContext(ctx,
 		"SELECT COUNT(*) FROM alerts WHERE org_id = $1 AND status = 'firing'",
 		orgID,
 	).Scan(&count)
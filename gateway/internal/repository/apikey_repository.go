@@ -38,19 +38,23 @@ func (r *APIKeyRepository) Create(ctx context.Context, key *domain.APIKey, rawKe
 	if err != nil {
 		permissions = []byte(`["*"]`)
 	}
+	allowedCIDRs, err := json.Marshal(key.AllowedCIDRs)
+	if err != nil || key.AllowedCIDRs == nil {
+		allowedCIDRs = []byte(`[]`)
+	}
 
 	query := `
 		INSERT INTO api_keys (
 			id, org_id, team_id, name, key_prefix, key_hash,
-			environment, permissions, rate_limit, expires_at,
+			environment, permissions, rate_limit, allowed_cidrs, expires_at,
 			created_at, created_by, revoked
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
 		)`
 
 	_, err = r.db.ExecContext(ctx, query,
 		key.ID, key.OrgID, key.TeamID, key.Name, key.KeyPrefix, keyHash,
-		key.Environment, permissions, key.RateLimit, key.ExpiresAt,
+		key.Environment, permissions, key.RateLimit, allowedCIDRs, key.ExpiresAt,
 		key.CreatedAt, key.CreatedBy, key.Revoked,
 	)
 	if err != nil {
@@ -68,19 +72,19 @@ func (r *APIKeyRepository) Get(ctx context.Context, orgID, id uuid.UUID) (*domai
 
 	query := `
 		SELECT id, org_id, team_id, name, key_prefix, environment,
-			   permissions, rate_limit, expires_at, last_used_at,
+			   permissions, rate_limit, allowed_cidrs, expires_at, last_used_at,
 			   created_at, created_by, revoked, revoked_at
 		FROM api_keys
 		WHERE id = $1 AND org_id = $2`
 
 	var key domain.APIKey
 	var teamID sql.NullString
-	var permissions []byte
+	var permissions, allowedCIDRs []byte
 	var expiresAt, lastUsedAt, revokedAt sql.NullTime
 
 	err := r.db.QueryRowContext(ctx, query, id, orgID).Scan(
 		&key.ID, &key.OrgID, &teamID, &key.Name, &key.KeyPrefix, &key.Environment,
-		&permissions, &key.RateLimit, &expiresAt, &lastUsedAt,
+		&permissions, &key.RateLimit, &allowedCIDRs, &expiresAt, &lastUsedAt,
 		&key.CreatedAt, &key.CreatedBy, &key.Revoked, &revokedAt,
 	)
 	if err == sql.ErrNoRows {
@@ -103,8 +107,11 @@ func (r *APIKeyRepository) Get(ctx context.Context, orgID, id uuid.UUID) (*domai
 	if revokedAt.Valid {
 		key.RevokedAt = &revokedAt.Time
 	}
-	if len(permissions) > 0 {
-		json.Unmarshal(permissions, &key.Permissions)
+	if err := decodeJSON("api_keys.permissions", key.ID, permissions, &key.Permissions); err != nil {
+		return nil, err
+	}
+	if err := decodeJSON("api_keys.allowed_cidrs", key.ID, allowedCIDRs, &key.AllowedCIDRs); err != nil {
+		return nil, err
 	}
 
 	return &key, nil
@@ -121,19 +128,19 @@ func (r *APIKeyRepository) GetByHash(ctx context.Context, rawKey string) (*domai
 
 	query := `
 		SELECT id, org_id, team_id, name, key_prefix, environment,
-			   permissions, rate_limit, expires_at, last_used_at,
+			   permissions, rate_limit, allowed_cidrs, expires_at, last_used_at,
 			   created_at, created_by, revoked, revoked_at
 		FROM api_keys
 		WHERE key_hash = $1 AND revoked = false`
 
 	var key domain.APIKey
 	var teamID sql.NullString
-	var permissions []byte
+	var permissions, allowedCIDRs []byte
 	var expiresAt, lastUsedAt, revokedAt sql.NullTime
 
 	err := r.db.QueryRowContext(ctx, query, keyHash).Scan(
 		&key.ID, &key.OrgID, &teamID, &key.Name, &key.KeyPrefix, &key.Environment,
-		&permissions, &key.RateLimit, &expiresAt, &lastUsedAt,
+		&permissions, &key.RateLimit, &allowedCIDRs, &expiresAt, &lastUsedAt,
 		&key.CreatedAt, &key.CreatedBy, &key.Revoked, &revokedAt,
 	)
 	if err == sql.ErrNoRows {
@@ -156,13 +163,39 @@ func (r *APIKeyRepository) GetByHash(ctx context.Context, rawKey string) (*domai
 	if revokedAt.Valid {
 		key.RevokedAt = &revokedAt.Time
 	}
-	if len(permissions) > 0 {
-		json.Unmarshal(permissions, &key.Permissions)
+	if err := decodeJSON("api_keys.permissions", key.ID, permissions, &key.Permissions); err != nil {
+		return nil, err
+	}
+	if err := decodeJSON("api_keys.allowed_cidrs", key.ID, allowedCIDRs, &key.AllowedCIDRs); err != nil {
+		return nil, err
 	}
 
 	return &key, nil
 }
 
+// GetOrgAllowedCIDRs returns the client IPs an organization's keys and
+// sessions may be used from. An empty list allows every address.
+func (r *APIKeyRepository) GetOrgAllowedCIDRs(ctx context.Context, orgID uuid.UUID) ([]string, error) {
+	if r.db == nil {
+		return nil, nil
+	}
+
+	var raw []byte
+	err := r.db.QueryRowContext(ctx, "SELECT allowed_cidrs FROM organizations WHERE id = $1", orgID).Scan(&raw)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("query organization allowed cidrs: %w", err)
+	}
+
+	var cidrs []string
+	if err := decodeJSON("organizations.allowed_cidrs", orgID, raw, &cidrs); err != nil {
+		return nil, err
+	}
+	return cidrs, nil
+}
+
 // List retrieves API keys with filtering and pagination.
 func (r *APIKeyRepository) List(ctx context.Context, filter domain.APIKeyFilter) ([]domain.APIKey, int64, error) {
 	if r.db == nil {
@@ -218,7 +251,7 @@ func (r *APIKeyRepository) List(ctx context.Context, filter domain.APIKeyFilter)
 
 	query := fmt.Sprintf(`
 		SELECT id, org_id, team_id, name, key_prefix, environment,
-			   permissions, rate_limit, expires_at, last_used_at,
+			   permissions, rate_limit, allowed_cidrs, expires_at, last_used_at,
 			   created_at, created_by, revoked, revoked_at
 		FROM api_keys
 		WHERE %s
@@ -238,12 +271,12 @@ func (r *APIKeyRepository) List(ctx context.Context, filter domain.APIKeyFilter)
 	for rows.Next() {
 		var key domain.APIKey
 		var teamID sql.NullString
-		var permissions []byte
+		var permissions, allowedCIDRs []byte
 		var expiresAt, lastUsedAt, revokedAt sql.NullTime
 
 		err := rows.Scan(
 			&key.ID, &key.OrgID, &teamID, &key.Name, &key.KeyPrefix, &key.Environment,
-			&permissions, &key.RateLimit, &expiresAt, &lastUsedAt,
+			&permissions, &key.RateLimit, &allowedCIDRs, &expiresAt, &lastUsedAt,
 			&key.CreatedAt, &key.CreatedBy, &key.Revoked, &revokedAt,
 		)
 		if err != nil {
@@ -263,8 +296,11 @@ func (r *APIKeyRepository) List(ctx context.Context, filter domain.APIKeyFilter)
 		if revokedAt.Valid {
 			key.RevokedAt = &revokedAt.Time
 		}
-		if len(permissions) > 0 {
-			json.Unmarshal(permissions, &key.Permissions)
+		if err := decodeJSON("api_keys.permissions", key.ID, permissions, &key.Permissions); err != nil {
+			return nil, 0, err
+		}
+		if err := decodeJSON("api_keys.allowed_cidrs", key.ID, allowedCIDRs, &key.AllowedCIDRs); err != nil {
+			return nil, 0, err
 		}
 
 		keys = append(keys, key)
@@ -23,38 +23,167 @@ func NewAuditRepository(db *sql.DB) *AuditRepository {
 	return &AuditRepository{db: db}
 }
 
+// auditInsertQuery inserts one audit log entry. An empty IP address is
+// stored as NULL, since INET has no empty value.
+const auditInsertQuery = `
+		INSERT INTO audit_logs (
+			id, org_id, team_id, user_id, api_key_id, trace_id,
+			action, resource, resource_id, outcome, details,
+			ip_address, user_agent, request_id, duration_ms, created_at,
+			prev_hash, hash
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NULLIF($12::text, '')::inet, $13, $14, $15, $16, $17, $18
+		)`
+
+// auditInsertArgs returns the arguments of auditInsertQuery for the entry.
+func auditInsertArgs(log *domain.AuditLog) ([]interface{}, error) {
+	details, err := json.Marshal(log.Details)
+	if err != nil {
+		return nil, fmt.Errorf("marshal details: %w", err)
+	}
+
+	return []interface{}{
+		log.ID, log.OrgID, log.TeamID, log.UserID, log.APIKeyID, log.TraceID,
+		log.Action, log.Resource, log.ResourceID, log.Outcome, details,
+		log.IPAddress, log.UserAgent, log.RequestID, log.DurationMS, log.CreatedAt,
+		log.PrevHash, log.Hash,
+	}, nil
+}
+
 // Create inserts a new audit log entry.
 func (r *AuditRepository) Create(ctx context.Context, log *domain.AuditLog) error {
 	if r.db == nil {
 		return nil // Silently skip if no DB
 	}
 
-	details, err := json.Marshal(log.Details)
+	args, err := auditInsertArgs(log)
 	if err != nil {
-		return fmt.Errorf("marshal details: %w", err)
+		return err
 	}
 
-	query := `
-		INSERT INTO audit_logs (
-			id, org_id, team_id, user_id, api_key_id, trace_id,
-			action, resource, resource_id, outcome, details,
-			ip_address, user_agent, request_id, duration_ms, created_at
-		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16
-		)`
+	if _, err := r.db.ExecContext(ctx, auditInsertQuery, args...); err != nil {
+		return fmt.Errorf("insert audit log: %w", err)
+	}
 
-	_, err = r.db.ExecContext(ctx, query,
-		log.ID, log.OrgID, log.TeamID, log.UserID, log.APIKeyID, log.TraceID,
-		log.Action, log.Resource, log.ResourceID, log.Outcome, details,
-		log.IPAddress, log.UserAgent, log.RequestID, log.DurationMS, log.CreatedAt,
-	)
+	return nil
+}
+
+// AppendChained inserts an audit log entry at the head of its org's hash
+// chain. Within one transaction it locks the org's chain, sets the entry's
+// PrevHash to the latest stored hash and calls seal to hash the entry, so
+// writers on every replica extend the same chain.
+func (r *AuditRepository) AppendChained(ctx context.Context, log *domain.AuditLog, seal func(*domain.AuditLog) error) error {
+	if r.db == nil {
+		return seal(log)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// The lock is released when the transaction ends
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", log.OrgID.String()); err != nil {
+		return fmt.Errorf("lock audit chain: %w", err)
+	}
+
+	err = tx.QueryRowContext(ctx,
+		"SELECT COALESCE(hash, '') FROM audit_logs WHERE org_id = $1 ORDER BY seq DESC LIMIT 1",
+		log.OrgID,
+	).Scan(&log.PrevHash)
+	if err == sql.ErrNoRows {
+		log.PrevHash = ""
+	} else if err != nil {
+		return fmt.Errorf("query audit chain head: %w", err)
+	}
+
+	if err := seal(log); err != nil {
+		return err
+	}
+
+	args, err := auditInsertArgs(log)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, auditInsertQuery, args...); err != nil {
 		return fmt.Errorf("insert audit log: %w", err)
 	}
 
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit audit log: %w", err)
+	}
 	return nil
 }
 
+// ListChain retrieves up to limit of an org's audit logs in chain order,
+// starting after the given sequence number. It returns the sequence number
+// to continue from.
+func (r *AuditRepository) ListChain(ctx context.Context, orgID uuid.UUID, after int64, limit int) ([]domain.AuditLog, int64, error) {
+	if r.db == nil {
+		return nil, after, nil
+	}
+
+	query := `
+		SELECT seq, id, org_id, team_id, user_id, api_key_id, trace_id,
+			   action, resource, resource_id, outcome, details,
+			   COALESCE(host(ip_address), ''), user_agent, request_id, duration_ms, created_at,
+			   COALESCE(prev_hash, ''), COALESCE(hash, '')
+		FROM audit_logs
+		WHERE org_id = $1 AND seq > $2
+		ORDER BY seq
+		LIMIT $3`
+
+	rows, err := r.db.QueryContext(ctx, query, orgID, after, limit)
+	if err != nil {
+		return nil, after, fmt.Errorf("query audit chain: %w", err)
+	}
+	defer rows.Close()
+
+	var logs []domain.AuditLog
+	for rows.Next() {
+		var log domain.AuditLog
+		var details []byte
+		var teamID, userID, apiKeyID sql.NullString
+
+		err := rows.Scan(
+			&after, &log.ID, &log.OrgID, &teamID, &userID, &apiKeyID, &log.TraceID,
+			&log.Action, &log.Resource, &log.ResourceID, &log.Outcome, &details,
+			&log.IPAddress, &log.UserAgent, &log.RequestID, &log.DurationMS, &log.CreatedAt,
+			&log.PrevHash, &log.Hash,
+		)
+		if err != nil {
+			return nil, after, fmt.Errorf("scan audit log: %w", err)
+		}
+		// Hashes were computed over UTC timestamps
+		log.CreatedAt = log.CreatedAt.UTC()
+
+		if teamID.Valid {
+			tid, _ := uuid.Parse(teamID.String)
+			log.TeamID = &tid
+		}
+		if userID.Valid {
+			uid, _ := uuid.Parse(userID.String)
+			log.UserID = &uid
+		}
+		if apiKeyID.Valid {
+			kid, _ := uuid.Parse(apiKeyID.String)
+			log.APIKeyID = &kid
+		}
+
+		if err := decodeJSON("audit_logs.details", log.ID, details, &log.Details); err != nil {
+			return nil, after, err
+		}
+
+		logs = append(logs, log)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, after, fmt.Errorf("iterate audit chain: %w", err)
+	}
+
+	return logs, after, nil
+}
+
 // Get retrieves an audit log by ID.
 func (r *AuditRepository) Get(ctx context.Context, orgID, id uuid.UUID) (*domain.AuditLog, error) {
 	if r.db == nil {
@@ -64,7 +193,8 @@ func (r *AuditRepository) Get(ctx context.Context, orgID, id uuid.UUID) (*domain
 	query := `
 		SELECT id, org_id, team_id, user_id, api_key_id, trace_id,
 			   action, resource, resource_id, outcome, details,
-			   ip_address, user_agent, request_id, duration_ms, created_at
+			   COALESCE(host(ip_address), ''), user_agent, request_id, duration_ms, created_at,
+			   COALESCE(prev_hash, ''), COALESCE(hash, '')
 		FROM audit_logs
 		WHERE id = $1 AND org_id = $2`
 
@@ -76,6 +206,7 @@ func (r *AuditRepository) Get(ctx context.Context, orgID, id uuid.UUID) (*domain
 		&log.ID, &log.OrgID, &teamID, &userID, &apiKeyID, &log.TraceID,
 		&log.Action, &log.Resource, &log.ResourceID, &log.Outcome, &details,
 		&log.IPAddress, &log.UserAgent, &log.RequestID, &log.DurationMS, &log.CreatedAt,
+		&log.PrevHash, &log.Hash,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -202,7 +333,8 @@ func (r *AuditRepository) List(ctx context.Context, filter domain.AuditLogFilter
 	query := fmt.Sprintf(`
 		SELECT id, org_id, team_id, user_id, api_key_id, trace_id,
 			   action, resource, resource_id, outcome, details,
-			   ip_address, user_agent, request_id, duration_ms, created_at
+			   COALESCE(host(ip_address), ''), user_agent, request_id, duration_ms, created_at,
+			   COALESCE(prev_hash, ''), COALESCE(hash, '')
 		FROM audit_logs
 		WHERE %s
 		ORDER BY created_at DESC
@@ -227,6 +359,7 @@ func (r *AuditRepository) List(ctx context.Context, filter domain.AuditLogFilter
 			&log.ID, &log.OrgID, &teamID, &userID, &apiKeyID, &log.TraceID,
 			&log.Action, &log.Resource, &log.ResourceID, &log.Outcome, &details,
 			&log.IPAddress, &log.UserAgent, &log.RequestID, &log.DurationMS, &log.CreatedAt,
+			&log.PrevHash, &log.Hash,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("scan audit log: %w", err)
@@ -245,8 +378,8 @@ func (r *AuditRepository) List(ctx context.Context, filter domain.AuditLogFilter
 			log.APIKeyID = &kid
 		}
 
-		if len(details) > 0 {
-			json.Unmarshal(details, &log.Details)
+		if err := decodeJSON("audit_logs.details", log.ID, details, &log.Details); err != nil {
+			return nil, err
 		}
 
 		logs = append(logs, log)
@@ -280,3 +280,125 @@ func (r *CostRepository) GetByDay(ctx context.Context, filter domain.CostFilter)
 
 	return results, rows.Err()
 }
+
+// RecordEvent stores the cost of a single MCP call.
+func (r *CostRepository) RecordEvent(ctx context.Context, event *domain.CostEvent) error {
+	if r.db == nil {
+		return nil
+	}
+
+	query := `
+		INSERT INTO cost_events (
+			id, org_id, team_id, user_id, api_key_id, trace_id,
+			mcp_server, tool_name, operation, cost, created_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+		)`
+
+	_, err := r.db.ExecContext(ctx, query,
+		event.ID, event.OrgID, event.TeamID, event.UserID, event.APIKeyID, event.TraceID,
+		event.MCPServer, event.ToolName, event.Operation, event.Cost, event.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("insert cost event: %w", err)
+	}
+
+	return nil
+}
+
+// costGroupColumns maps report dimensions to SQL expressions.
+var costGroupColumns = map[domain.CostGroupBy]string{
+	domain.CostGroupByTeam:      "COALESCE(tm.name, ce.team_id::text, '')",
+	domain.CostGroupByUser:      "COALESCE(u.email, ce.user_id::text, '')",
+	domain.CostGroupByMCPServer: "ce.mcp_server",
+	domain.CostGroupByTool:      "COALESCE(ce.tool_name, '')",
+}
+
+// GetReport returns summed cost and call counts grouped by the requested dimensions.
+func (r *CostRepository) GetReport(ctx context.Context, filter domain.CostReportFilter) ([]domain.CostReportRow, error) {
+	if r.db == nil {
+		return nil, nil
+	}
+
+	var conditions []string
+	var args []interface{}
+	argNum := 1
+
+	conditions = append(conditions, fmt.Sprintf("ce.org_id = $%d", argNum))
+	args = append(args, filter.OrgID)
+	argNum++
+
+	conditions = append(conditions, fmt.Sprintf("ce.created_at >= $%d", argNum))
+	args = append(args, filter.StartDate)
+	argNum++
+
+	conditions = append(conditions, fmt.Sprintf("ce.created_at <= $%d", argNum))
+	args = append(args, filter.EndDate)
+	argNum++
+
+	if filter.TeamID != nil {
+		conditions = append(conditions, fmt.Sprintf("ce.team_id = $%d", argNum))
+		args = append(args, *filter.TeamID)
+	}
+
+	var columns []string
+	for _, g := range filter.GroupBy {
+		col, ok := costGroupColumns[g]
+		if !ok {
+			return nil, fmt.Errorf("unsupported group by: %s", g)
+		}
+		columns = append(columns, col)
+	}
+
+	selectCols := ""
+	groupClause := ""
+	if len(columns) > 0 {
+		selectCols = strings.Join(columns, ", ") + ","
+		groupClause = "GROUP BY " + strings.Join(columns, ", ")
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			%s
+			COALESCE(SUM(ce.cost), 0) as total_cost,
+			COUNT(*) as call_count
+		FROM cost_events ce
+		LEFT JOIN teams tm ON ce.team_id = tm.id
+		LEFT JOIN users u ON ce.user_id = u.id
+		WHERE %s
+		%s
+		ORDER BY total_cost DESC`,
+		selectCols, strings.Join(conditions, " AND "), groupClause)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query cost report: %w", err)
+	}
+	defer rows.Close()
+
+	var results []domain.CostReportRow
+	for rows.Next() {
+		var row domain.CostReportRow
+		dest := make([]interface{}, 0, len(filter.GroupBy)+2)
+		for _, g := range filter.GroupBy {
+			switch g {
+			case domain.CostGroupByTeam:
+				dest = append(dest, &row.Team)
+			case domain.CostGroupByUser:
+				dest = append(dest, &row.User)
+			case domain.CostGroupByMCPServer:
+				dest = append(dest, &row.MCPServer)
+			case domain.CostGroupByTool:
+				dest = append(dest, &row.Tool)
+			}
+		}
+		dest = append(dest, &row.TotalCost, &row.CallCount)
+
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("scan cost report: %w", err)
+		}
+		results = append(results, row)
+	}
+
+	return results, rows.Err()
+}
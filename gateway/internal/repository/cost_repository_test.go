@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/google/uuid"
+)
+
+func TestCostReportGroupsByServer(t *testing.T) {
+	// The grouped sums Postgres returns for the seeded calls: three to
+	// filesystem, one to github
+	db, d := newScriptedDB(t, script{
+		match:   "FROM cost_events",
+		columns: []string{"mcp_server", "total_cost", "call_count"},
+		rows: [][]driver.Value{
+			{"filesystem", 0.03, int64(3)},
+			{"github", 0.05, int64(1)},
+		},
+	})
+	repo := NewCostRepository(db)
+	ctx := context.Background()
+	orgID, teamID := uuid.New(), uuid.New()
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+
+	for _, call := range []struct {
+		server string
+		cost   float64
+	}{{"filesystem", 0.01}, {"filesystem", 0.01}, {"github", 0.05}, {"filesystem", 0.01}} {
+		event := &domain.CostEvent{ID: uuid.New(), OrgID: orgID, TeamID: &teamID, MCPServer: call.server, ToolName: "read", Operation: "tools/call", Cost: call.cost, CreatedAt: start.Add(time.Hour)}
+		if err := repo.RecordEvent(ctx, event); err != nil {
+			t.Fatalf("RecordEvent: %v", err)
+		}
+	}
+	inserts := d.statements("INSERT INTO cost_events")
+	if len(inserts) != 4 || inserts[2].args[6] != "github" || inserts[2].args[9] != 0.05 {
+		t.Fatalf("inserted %d cost events, want 4 with each call's server and cost", len(inserts))
+	}
+
+	rows, err := repo.GetReport(ctx, domain.CostReportFilter{
+		OrgID:     orgID,
+		TeamID:    &teamID,
+		StartDate: start,
+		EndDate:   end,
+		GroupBy:   []domain.CostGroupBy{domain.CostGroupByMCPServer},
+	})
+	if err != nil {
+		t.Fatalf("GetReport: %v", err)
+	}
+	want := []domain.CostReportRow{
+		{MCPServer: "filesystem", TotalCost: 0.03, CallCount: 3},
+		{MCPServer: "github", TotalCost: 0.05, CallCount: 1},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("rows = %+v, want %+v", rows, want)
+	}
+	for i := range want {
+		if rows[i] != want[i] {
+			t.Errorf("row %d = %+v, want %+v", i, rows[i], want[i])
+		}
+	}
+
+	report := d.statements("FROM cost_events")
+	if len(report) != 1 {
+		t.Fatalf("ran %d report queries, want 1", len(report))
+	}
+	query, args := report[0].query, report[0].args
+	if !strings.Contains(query, "GROUP BY ce.mcp_server") || !strings.Contains(query, "SUM(ce.cost)") {
+		t.Errorf("report query does not sum cost by server:\n%s", query)
+	}
+	if len(args) != 4 || args[0] != orgID.String() || args[3] != teamID.String() {
+		t.Errorf("report args = %v, want the org, the period and the team", args)
+	}
+}
+
+func TestCostReportRejectsUnknownDimension(t *testing.T) {
+	db, d := newScriptedDB(t)
+	_, err := NewCostRepository(db).GetReport(context.Background(), domain.CostReportFilter{
+		OrgID:   uuid.New(),
+		GroupBy: []domain.CostGroupBy{"region"},
+	})
+	if err == nil {
+		t.Error("a report grouped by an unknown dimension was run")
+	}
+	if ran := d.statements("FROM cost_events"); len(ran) != 0 {
+		t.Errorf("ran %d queries for an unknown dimension", len(ran))
+	}
+}
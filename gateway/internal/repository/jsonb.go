@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// decodeJSON decodes a JSONB column into dest. A NULL or empty column leaves
+// dest unchanged; malformed JSON is reported with the column and row ID so a
+// corrupt row is not mistaken for one with empty fields.
+func decodeJSON(column string, rowID interface{}, data []byte, dest interface{}) error {
+	if len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, dest); err != nil {
+		return fmt.Errorf("decode %s for row %v: %w", column, rowID, err)
+	}
+	return nil
+}
+
+// decodeRequiredJSON decodes a NOT NULL JSONB column into dest. A NULL, empty
+// or JSON null value is an error, as for malformed JSON.
+func decodeRequiredJSON(column string, rowID interface{}, data []byte, dest interface{}) error {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")) {
+		return fmt.Errorf("decode %s for row %v: column is empty", column, rowID)
+	}
+	return decodeJSON(column, rowID, data, dest)
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestDecodeJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		required bool
+		want     []string
+		err      string
+	}{
+		{name: "valid", data: `["a","b"]`, want: []string{"a", "b"}},
+		{name: "optional NULL", data: "", want: nil},
+		{name: "malformed", data: `["a",`, err: "decode rules.channels for row 7: unexpected end of JSON input"},
+		{name: "wrong type", data: `{"a":1}`, err: "decode rules.channels for row 7: json: cannot unmarshal"},
+		{name: "required NULL", data: "", required: true, err: "decode rules.channels for row 7: column is empty"},
+		{name: "required JSON null", data: " null ", required: true, err: "column is empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			decode := decodeJSON
+			if tt.required {
+				decode = decodeRequiredJSON
+			}
+			err := decode("rules.channels", 7, []byte(tt.data), &got)
+			if tt.err == "" {
+				if err != nil {
+					t.Fatalf("err = %v", err)
+				}
+				if strings.Join(got, ",") != strings.Join(tt.want, ",") || (got == nil) != (tt.want == nil) {
+					t.Errorf("decoded %q, want %q", got, tt.want)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("err = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestMalformedJSONBIsReported(t *testing.T) {
+	id := uuid.New()
+	now := time.Now()
+	tests := []struct {
+		name   string
+		script script
+		get    func(*sql.DB) (interface{}, error)
+		list   func(*sql.DB) error
+		err    string
+	}{
+		{
+			name: "alert rule channels",
+			script: script{
+				match: "FROM alert_rules",
+				columns: []string{"id", "org_id", "name", "description", "metric", "condition",
+					"threshold", "window_minutes", "severity", "channels", "filters", "templates",
+					"enabled", "created_at", "updated_at", "created_by"},
+				rows: [][]driver.Value{{id.String(), uuid.NewString(), "Errors", "", "error_rate", "gt",
+					0.05, int64(5), "critical", []byte(`[{"type":"slack",`), []byte(`{}`), []byte(`{}`),
+					true, now, now, uuid.NewString()}},
+			},
+			get: func(db *sql.DB) (interface{}, error) {
+				return NewAlertRepository(db).GetRule(context.Background(), id)
+			},
+			list: func(db *sql.DB) error {
+				_, err := NewAlertRepository(db).ListRules(context.Background(), uuid.New(), false)
+				return err
+			},
+			err: "decode alert_rules.channels for row " + id.String(),
+		},
+		{
+			name: "safety policy patterns",
+			script: script{
+				match: "FROM safety_policies",
+				columns: []string{"id", "org_id", "name", "description", "sensitivity", "mode",
+					"patterns", "mcp_servers", "enabled", "created_at", "updated_at", "created_by"},
+				rows: [][]driver.Value{{id.String(), uuid.NewString(), "Default", "", "moderate", "block",
+					nil, []byte(`[]`), true, now, now, uuid.NewString()}},
+			},
+			get: func(db *sql.DB) (interface{}, error) {
+				return NewSafetyRepository(db).GetPolicy(context.Background(), id)
+			},
+			list: func(db *sql.DB) error {
+				_, err := NewSafetyRepository(db).ListPolicies(context.Background(), uuid.New(), false)
+				return err
+			},
+			err: "decode safety_policies.patterns for row " + id.String() + ": column is empty",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, _ := newScriptedDB(t, tt.script)
+			got, getErr := tt.get(db)
+			if getErr == nil {
+				t.Errorf("got %+v from a corrupt row", got)
+			}
+			listErr := tt.list(db)
+			for op, err := range map[string]error{"get": getErr, "list": listErr} {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("%s: err = %v, want %q", op, err, tt.err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/google/uuid"
+)
+
+// MCPServerRepository handles persistence of MCP servers registered at
+// runtime.
+type MCPServerRepository struct {
+	db *sql.DB
+}
+
+// NewMCPServerRepository creates a new MCP server repository.
+func NewMCPServerRepository(db *sql.DB) *MCPServerRepository {
+	return &MCPServerRepository{db: db}
+}
+
+// List returns all registered MCP servers.
+func (r *MCPServerRepository) List(ctx context.Context) ([]domain.MCPServer, error) {
+	if r.db == nil {
+		return nil, nil
+	}
+
+	query := `
+		SELECT org_id, name, url, timeout_seconds, default_classification,
+			   auth_type, auth_encrypted, created_by, created_at, updated_at
+		FROM mcp_servers
+		ORDER BY org_id, name`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("query mcp servers: %w", err)
+	}
+	defer rows.Close()
+
+	var servers []domain.MCPServer
+	for rows.Next() {
+		var server domain.MCPServer
+		var classification, authType sql.NullString
+		if err := rows.Scan(
+			&server.OrgID, &server.Name, &server.URL, &server.TimeoutSeconds, &classification,
+			&authType, &server.AuthEncrypted, &server.CreatedBy, &server.CreatedAt, &server.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan mcp server: %w", err)
+		}
+		server.DefaultClassification = domain.ToolRiskLevel(classification.String)
+		server.AuthType = domain.MCPAuthType(authType.String)
+		server.Source = domain.MCPServerSourceRegistry
+		servers = append(servers, server)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate mcp servers: %w", err)
+	}
+
+	return servers, nil
+}
+
+// Save inserts a registered MCP server, or replaces the settings of the
+// organization's one with the same name. Only the server's encrypted
+// credential is stored.
+func (r *MCPServerRepository) Save(ctx context.Context, server *domain.MCPServer) error {
+	if r.db == nil {
+		return nil
+	}
+
+	query := `
+		INSERT INTO mcp_servers (
+			org_id, name, url, timeout_seconds, default_classification,
+			auth_type, auth_encrypted, created_by, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (org_id, name)
+		DO UPDATE SET
+			url = EXCLUDED.url,
+			timeout_seconds = EXCLUDED.timeout_seconds,
+			default_classification = EXCLUDED.default_classification,
+			auth_type = EXCLUDED.auth_type,
+			auth_encrypted = EXCLUDED.auth_encrypted,
+			updated_at = EXCLUDED.updated_at`
+
+	classification := sql.NullString{
+		String: string(server.DefaultClassification),
+		Valid:  server.DefaultClassification != "",
+	}
+	authType := sql.NullString{
+		String: string(server.AuthType),
+		Valid:  server.AuthType != "",
+	}
+	_, err := r.db.ExecContext(ctx, query,
+		server.OrgID, server.Name, server.URL, server.TimeoutSeconds, classification,
+		authType, server.AuthEncrypted, server.CreatedBy, server.CreatedAt, server.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("save mcp server: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes an MCP server registered by an organization.
+func (r *MCPServerRepository) Delete(ctx context.Context, orgID uuid.UUID, name string) error {
+	if r.db == nil {
+		return nil
+	}
+
+	_, err := r.db.ExecContext(ctx, `DELETE FROM mcp_servers WHERE org_id = $1 AND name = $2`, orgID, name)
+	if err != nil {
+		return fmt.Errorf("delete mcp server: %w", err)
+	}
+
+	return nil
+}
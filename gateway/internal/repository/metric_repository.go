@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/google/uuid"
+)
+
+// MetricRepository persists per-call samples used for alert evaluation.
+type MetricRepository struct {
+	db *sql.DB
+}
+
+// NewMetricRepository creates a new metric repository.
+func NewMetricRepository(db *sql.DB) *MetricRepository {
+	return &MetricRepository{db: db}
+}
+
+// RecordSample inserts a call sample.
+func (r *MetricRepository) RecordSample(ctx context.Context, sample *domain.CallSample) error {
+	if r.db == nil {
+		return nil
+	}
+
+	query := `
+		INSERT INTO call_samples (
+			id, org_id, team_id, environment, mcp_server, tool_name,
+			is_error, duration_ms, cost, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+
+	_, err := r.db.ExecContext(ctx, query,
+		sample.ID, sample.OrgID, sample.TeamID, sample.Environment, sample.MCPServer, sample.ToolName,
+		sample.IsError, sample.DurationMs, sample.Cost, sample.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("insert call sample: %w", err)
+	}
+
+	return nil
+}
+
+// ListSamplesSince returns call samples recorded at or after since, oldest first.
+func (r *MetricRepository) ListSamplesSince(ctx context.Context, since time.Time, limit int) ([]domain.CallSample, error) {
+	if r.db == nil {
+		return nil, nil
+	}
+
+	query := `
+		SELECT id, org_id, team_id, COALESCE(environment, ''), mcp_server, tool_name,
+			   is_error, duration_ms, cost, created_at
+		FROM (
+			SELECT * FROM call_samples
+			WHERE created_at >= $1
+			ORDER BY created_at DESC
+			LIMIT $2
+		) recent
+		ORDER BY created_at ASC`
+
+	rows, err := r.db.QueryContext(ctx, query, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query call samples: %w", err)
+	}
+	defer rows.Close()
+
+	var samples []domain.CallSample
+	for rows.Next() {
+		var sample domain.CallSample
+		var teamID sql.NullString
+		var toolName sql.NullString
+
+		err := rows.Scan(
+			&sample.ID, &sample.OrgID, &teamID, &sample.Environment, &sample.MCPServer, &toolName,
+			&sample.IsError, &sample.DurationMs, &sample.Cost, &sample.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scan call sample: %w", err)
+		}
+
+		if teamID.Valid {
+			tid, _ := uuid.Parse(teamID.String)
+			sample.TeamID = &tid
+		}
+		sample.ToolName = toolName.String
+
+		samples = append(samples, sample)
+	}
+
+	return samples, rows.Err()
+}
+
+// DeleteSamplesBefore removes call samples older than before.
+func (r *MetricRepository) DeleteSamplesBefore(ctx context.Context, before time.Time) (int64, error) {
+	if r.db == nil {
+		return 0, nil
+	}
+
+	result, err := r.db.ExecContext(ctx, "DELETE FROM call_samples WHERE created_at < $1", before)
+	if err != nil {
+		return 0, fmt.Errorf("delete call samples: %w", err)
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("get rows affected: %w", err)
+	}
+
+	return count, nil
+}
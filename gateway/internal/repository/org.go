@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// listOrgIDs returns the ID of every organization, oldest first.
+func listOrgIDs(ctx context.Context, db *sql.DB) ([]uuid.UUID, error) {
+	rows, err := db.QueryContext(ctx, "SELECT id FROM organizations ORDER BY created_at")
+	if err != nil {
+		return nil, fmt.Errorf("query organizations: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan organization: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ListOrgIDs returns the ID of every organization, for loading each org's
+// alert configuration at startup.
+func (r *AlertRepository) ListOrgIDs(ctx context.Context) ([]uuid.UUID, error) {
+	return listOrgIDs(ctx, r.db)
+}
+
+// ListOrgIDs returns the ID of every organization, for loading each org's
+// safety policies at startup.
+func (r *SafetyRepository) ListOrgIDs(ctx context.Context) ([]uuid.UUID, error) {
+	return listOrgIDs(ctx, r.db)
+}
+
+// ListOrgIDs returns the ID of every organization, for loading each org's
+// tool classifications and approvals at startup.
+func (r *ToolRepository) ListOrgIDs(ctx context.Context) ([]uuid.UUID, error) {
+	return listOrgIDs(ctx, r.db)
+}
@@ -158,7 +158,9 @@ func (r *RoleRepository) ListRoles(ctx context.Context, orgID uuid.UUID) ([]doma
 			role.OrgID = &ouid
 		}
 
-		json.Unmarshal(permissions, &role.Permissions)
+		if err := decodeRequiredJSON("roles.permissions", role.ID, permissions, &role.Permissions); err != nil {
+			return nil, err
+		}
 		roles = append(roles, role)
 	}
 
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/akz4ol/gatewayops/gateway/internal/domain"
@@ -13,12 +14,24 @@ import (
 
 // SafetyRepository handles safety policy and detection persistence.
 type SafetyRepository struct {
-	db *sql.DB
+	db    *sql.DB
+	stmts *stmtCache
 }
 
 // NewSafetyRepository creates a new safety repository.
 func NewSafetyRepository(db *sql.DB) *SafetyRepository {
-	return &SafetyRepository{db: db}
+	return &SafetyRepository{db: db, stmts: newStmtCache(db)}
+}
+
+// StatementStats reports how often the repository's prepared statements were
+// reused.
+func (r *SafetyRepository) StatementStats() StmtCacheStats {
+	return r.stmts.Stats()
+}
+
+// Close releases the repository's prepared statements.
+func (r *SafetyRepository) Close() error {
+	return r.stmts.Close()
 }
 
 // CreatePolicy inserts a new safety policy.
@@ -50,12 +63,12 @@ func (r *SafetyRepository) GetPolicy(ctx context.Context, id uuid.UUID) (*domain
 		SELECT id, org_id, name, description, sensitivity, mode,
 			   patterns, mcp_servers, enabled, created_at, updated_at, created_by
 		FROM safety_policies
-		WHERE id = $1`
+		WHERE id = $1 AND deleted_at IS NULL`
 
 	var policy domain.SafetyPolicy
 	var patterns, mcpServers []byte
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err := r.stmts.QueryRowContext(ctx, query, id).Scan(
 		&policy.ID, &policy.OrgID, &policy.Name, &policy.Description, &policy.Sensitivity,
 		&policy.Mode, &patterns, &mcpServers, &policy.Enabled,
 		&policy.CreatedAt, &policy.UpdatedAt, &policy.CreatedBy,
@@ -67,8 +80,12 @@ func (r *SafetyRepository) GetPolicy(ctx context.Context, id uuid.UUID) (*domain
 		return nil, fmt.Errorf("query safety policy: %w", err)
 	}
 
-	json.Unmarshal(patterns, &policy.Patterns)
-	json.Unmarshal(mcpServers, &policy.MCPServers)
+	if err := decodeRequiredJSON("safety_policies.patterns", policy.ID, patterns, &policy.Patterns); err != nil {
+		return nil, err
+	}
+	if err := decodeJSON("safety_policies.mcp_servers", policy.ID, mcpServers, &policy.MCPServers); err != nil {
+		return nil, err
+	}
 
 	return &policy, nil
 }
@@ -83,7 +100,7 @@ func (r *SafetyRepository) ListPolicies(ctx context.Context, orgID uuid.UUID, en
 			SELECT id, org_id, name, description, sensitivity, mode,
 				   patterns, mcp_servers, enabled, created_at, updated_at, created_by
 			FROM safety_policies
-			WHERE org_id = $1 AND enabled = true
+			WHERE org_id = $1 AND enabled = true AND deleted_at IS NULL
 			ORDER BY created_at DESC`
 		args = []interface{}{orgID}
 	} else {
@@ -91,12 +108,12 @@ func (r *SafetyRepository) ListPolicies(ctx context.Context, orgID uuid.UUID, en
 			SELECT id, org_id, name, description, sensitivity, mode,
 				   patterns, mcp_servers, enabled, created_at, updated_at, created_by
 			FROM safety_policies
-			WHERE org_id = $1
+			WHERE org_id = $1 AND deleted_at IS NULL
 			ORDER BY created_at DESC`
 		args = []interface{}{orgID}
 	}
 
-	rows, err := r.db.QueryContext(ctx, query, args...)
+	rows, err := r.stmts.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("query safety policies: %w", err)
 	}
@@ -116,8 +133,12 @@ func (r *SafetyRepository) ListPolicies(ctx context.Context, orgID uuid.UUID, en
 			return nil, fmt.Errorf("scan safety policy: %w", err)
 		}
 
-		json.Unmarshal(patterns, &policy.Patterns)
-		json.Unmarshal(mcpServers, &policy.MCPServers)
+		if err := decodeRequiredJSON("safety_policies.patterns", policy.ID, patterns, &policy.Patterns); err != nil {
+			return nil, err
+		}
+		if err := decodeJSON("safety_policies.mcp_servers", policy.ID, mcpServers, &policy.MCPServers); err != nil {
+			return nil, err
+		}
 
 		policies = append(policies, policy)
 	}
@@ -131,13 +152,13 @@ func (r *SafetyRepository) GetPoliciesForServer(ctx context.Context, orgID uuid.
 		SELECT id, org_id, name, description, sensitivity, mode,
 			   patterns, mcp_servers, enabled, created_at, updated_at, created_by
 		FROM safety_policies
-		WHERE org_id = $1 AND enabled = true
+		WHERE org_id = $1 AND enabled = true AND deleted_at IS NULL
 			  AND (mcp_servers IS NULL OR mcp_servers = '[]' OR mcp_servers @> $2)
 		ORDER BY created_at DESC`
 
 	serverJSON, _ := json.Marshal([]string{mcpServer})
 
-	rows, err := r.db.QueryContext(ctx, query, orgID, serverJSON)
+	rows, err := r.stmts.QueryContext(ctx, query, orgID, serverJSON)
 	if err != nil {
 		return nil, fmt.Errorf("query policies for server: %w", err)
 	}
@@ -157,8 +178,12 @@ func (r *SafetyRepository) GetPoliciesForServer(ctx context.Context, orgID uuid.
 			return nil, fmt.Errorf("scan safety policy: %w", err)
 		}
 
-		json.Unmarshal(patterns, &policy.Patterns)
-		json.Unmarshal(mcpServers, &policy.MCPServers)
+		if err := decodeRequiredJSON("safety_policies.patterns", policy.ID, patterns, &policy.Patterns); err != nil {
+			return nil, err
+		}
+		if err := decodeJSON("safety_policies.mcp_servers", policy.ID, mcpServers, &policy.MCPServers); err != nil {
+			return nil, err
+		}
 
 		policies = append(policies, policy)
 	}
@@ -175,7 +200,7 @@ func (r *SafetyRepository) UpdatePolicy(ctx context.Context, policy *domain.Safe
 		UPDATE safety_policies SET
 			name = $2, description = $3, sensitivity = $4, mode = $5,
 			patterns = $6, mcp_servers = $7, enabled = $8, updated_at = $9
-		WHERE id = $1`
+		WHERE id = $1 AND deleted_at IS NULL`
 
 	_, err := r.db.ExecContext(ctx, query,
 		policy.ID, policy.Name, policy.Description, policy.Sensitivity, policy.Mode,
@@ -188,9 +213,11 @@ func (r *SafetyRepository) UpdatePolicy(ctx context.Context, policy *domain.Safe
 	return nil
 }
 
-// DeletePolicy deletes a safety policy.
+// DeletePolicy soft-deletes a safety policy. The row is kept so detections
+// made under it still resolve to it, and it can be restored.
 func (r *SafetyRepository) DeletePolicy(ctx context.Context, id uuid.UUID) error {
-	_, err := r.db.ExecContext(ctx, "DELETE FROM safety_policies WHERE id = $1", id)
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE safety_policies SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL", id)
 	if err != nil {
 		return fmt.Errorf("delete safety policy: %w", err)
 	}
@@ -198,6 +225,151 @@ func (r *SafetyRepository) DeletePolicy(ctx context.Context, id uuid.UUID) error
 	return nil
 }
 
+// RestorePolicy clears the soft-delete mark of a safety policy.
+func (r *SafetyRepository) RestorePolicy(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE safety_policies SET deleted_at = NULL, updated_at = NOW() WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("restore safety policy: %w", err)
+	}
+
+	return nil
+}
+
+// ListDeletedPolicies retrieves an organization's soft-deleted safety policies.
+func (r *SafetyRepository) ListDeletedPolicies(ctx context.Context, orgID uuid.UUID) ([]domain.SafetyPolicy, error) {
+	query := `
+		SELECT id, org_id, name, description, sensitivity, mode,
+			   patterns, mcp_servers, enabled, created_at, updated_at, created_by, deleted_at
+		FROM safety_policies
+		WHERE org_id = $1 AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC`
+
+	rows, err := r.stmts.QueryContext(ctx, query, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("query deleted safety policies: %w", err)
+	}
+	defer rows.Close()
+
+	var policies []domain.SafetyPolicy
+	for rows.Next() {
+		var policy domain.SafetyPolicy
+		var patterns, mcpServers []byte
+		var deletedAt time.Time
+
+		err := rows.Scan(
+			&policy.ID, &policy.OrgID, &policy.Name, &policy.Description, &policy.Sensitivity,
+			&policy.Mode, &patterns, &mcpServers, &policy.Enabled,
+			&policy.CreatedAt, &policy.UpdatedAt, &policy.CreatedBy, &deletedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scan safety policy: %w", err)
+		}
+		policy.DeletedAt = &deletedAt
+
+		if err := decodeRequiredJSON("safety_policies.patterns", policy.ID, patterns, &policy.Patterns); err != nil {
+			return nil, err
+		}
+		if err := decodeJSON("safety_policies.mcp_servers", policy.ID, mcpServers, &policy.MCPServers); err != nil {
+			return nil, err
+		}
+
+		policies = append(policies, policy)
+	}
+
+	return policies, nil
+}
+
+// CreatePatternLibrary inserts a new pattern library.
+func (r *SafetyRepository) CreatePatternLibrary(ctx context.Context, library *domain.PatternLibrary) error {
+	patterns, _ := json.Marshal(library.Patterns)
+
+	query := `
+		INSERT INTO safety_pattern_libraries (
+			id, org_id, name, description, category, patterns,
+			version, created_at, updated_at, created_by
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+
+	_, err := r.db.ExecContext(ctx, query,
+		library.ID, library.OrgID, library.Name, library.Description, library.Category,
+		patterns, library.Version, library.CreatedAt, library.UpdatedAt, library.CreatedBy,
+	)
+	if err != nil {
+		return fmt.Errorf("insert pattern library: %w", err)
+	}
+
+	return nil
+}
+
+// ListPatternLibraries retrieves all pattern libraries for an organization.
+func (r *SafetyRepository) ListPatternLibraries(ctx context.Context, orgID uuid.UUID) ([]domain.PatternLibrary, error) {
+	query := `
+		SELECT id, org_id, name, description, category, patterns,
+			   version, created_at, updated_at, created_by
+		FROM safety_pattern_libraries
+		WHERE org_id = $1
+		ORDER BY created_at`
+
+	rows, err := r.stmts.QueryContext(ctx, query, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("query pattern libraries: %w", err)
+	}
+	defer rows.Close()
+
+	var libraries []domain.PatternLibrary
+	for rows.Next() {
+		var library domain.PatternLibrary
+		var patterns []byte
+
+		err := rows.Scan(
+			&library.ID, &library.OrgID, &library.Name, &library.Description, &library.Category,
+			&patterns, &library.Version, &library.CreatedAt, &library.UpdatedAt, &library.CreatedBy,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scan pattern library: %w", err)
+		}
+
+		if err := decodeRequiredJSON("safety_pattern_libraries.patterns", library.ID, patterns, &library.Patterns); err != nil {
+			return nil, err
+		}
+
+		libraries = append(libraries, library)
+	}
+
+	return libraries, nil
+}
+
+// UpdatePatternLibrary updates a pattern library, including its version.
+func (r *SafetyRepository) UpdatePatternLibrary(ctx context.Context, library *domain.PatternLibrary) error {
+	patterns, _ := json.Marshal(library.Patterns)
+
+	query := `
+		UPDATE safety_pattern_libraries SET
+			name = $2, description = $3, category = $4, patterns = $5,
+			version = $6, updated_at = $7
+		WHERE id = $1`
+
+	_, err := r.db.ExecContext(ctx, query,
+		library.ID, library.Name, library.Description, library.Category, patterns,
+		library.Version, library.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("update pattern library: %w", err)
+	}
+
+	return nil
+}
+
+// DeletePatternLibrary deletes a pattern library.
+func (r *SafetyRepository) DeletePatternLibrary(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM safety_pattern_libraries WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("delete pattern library: %w", err)
+	}
+
+	return nil
+}
+
 // CreateDetection inserts a new injection detection.
 func (r *SafetyRepository) CreateDetection(ctx context.Context, detection *domain.InjectionDetection) error {
 	query := `
@@ -239,7 +411,7 @@ func (r *SafetyRepository) GetDetection(ctx context.Context, id uuid.UUID) (*dom
 	var detection domain.InjectionDetection
 	var policyID, apiKeyID sql.NullString
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err := r.stmts.QueryRowContext(ctx, query, id).Scan(
 		&detection.ID, &detection.OrgID, &detection.TraceID, &detection.SpanID,
 		&policyID, &detection.Type, &detection.Severity,
 		&detection.PatternMatched, &detection.Input, &detection.ActionTaken,
@@ -311,6 +483,12 @@ func (r *SafetyRepository) ListDetections(ctx context.Context, filter domain.Det
 		argNum++
 	}
 
+	if filter.TraceID != "" {
+		conditions = append(conditions, fmt.Sprintf("trace_id = $%d", argNum))
+		args = append(args, filter.TraceID)
+		argNum++
+	}
+
 	if filter.StartTime != nil {
 		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", argNum))
 		args = append(args, *filter.StartTime)
@@ -323,12 +501,19 @@ func (r *SafetyRepository) ListDetections(ctx context.Context, filter domain.Det
 		argNum++
 	}
 
+	if filter.Query != "" {
+		condition, pattern := searchCondition(filter.Query, argNum, "input", "pattern_matched", "tool_name", "mcp_server")
+		conditions = append(conditions, condition)
+		args = append(args, pattern)
+		argNum++
+	}
+
 	whereClause := strings.Join(conditions, " AND ")
 
 	// Count total
 	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM injection_detections WHERE %s", whereClause)
 	var total int64
-	if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+	if err := r.stmts.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
 		return nil, fmt.Errorf("count detections: %w", err)
 	}
 
@@ -343,19 +528,30 @@ func (r *SafetyRepository) ListDetections(ctx context.Context, filter domain.Det
 		offset = 0
 	}
 
+	// Keyset pagination takes precedence over offset when a cursor is given.
+	// One extra row is fetched to tell whether another page follows.
+	pageClause := fmt.Sprintf("LIMIT $%d OFFSET $%d", argNum, argNum+1)
+	pageArgs := []interface{}{limit, offset}
+	if filter.Cursor != nil {
+		whereClause += fmt.Sprintf(" AND (created_at, id) < ($%d, $%d)", argNum, argNum+1)
+		pageClause = fmt.Sprintf("LIMIT $%d", argNum+2)
+		pageArgs = []interface{}{filter.Cursor.CreatedAt, filter.Cursor.ID, limit + 1}
+		offset = 0
+	}
+
 	query := fmt.Sprintf(`
 		SELECT id, org_id, trace_id, span_id, policy_id, type, severity,
 			   pattern_matched, input, action_taken, mcp_server, tool_name,
 			   api_key_id, ip_address, created_at
 		FROM injection_detections
 		WHERE %s
-		ORDER BY created_at DESC
-		LIMIT $%d OFFSET $%d`,
-		whereClause, argNum, argNum+1)
+		ORDER BY created_at DESC, id DESC
+		%s`,
+		whereClause, pageClause)
 
-	args = append(args, limit, offset)
+	args = append(args, pageArgs...)
 
-	rows, err := r.db.QueryContext(ctx, query, args...)
+	rows, err := r.stmts.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("query detections: %w", err)
 	}
@@ -389,13 +585,25 @@ func (r *SafetyRepository) ListDetections(ctx context.Context, filter domain.Det
 		detections = append(detections, detection)
 	}
 
-	return &domain.DetectionPage{
+	page := &domain.DetectionPage{
 		Detections: detections,
 		Total:      total,
 		Limit:      limit,
 		Offset:     offset,
 		HasMore:    int64(offset+len(detections)) < total,
-	}, nil
+	}
+	if filter.Cursor != nil {
+		page.HasMore = len(detections) > limit
+		if page.HasMore {
+			page.Detections = detections[:limit]
+		}
+	}
+	if page.HasMore && len(page.Detections) > 0 {
+		last := page.Detections[len(page.Detections)-1]
+		page.NextCursor = domain.NewCursor(last.CreatedAt, last.ID).Encode()
+	}
+
+	return page, nil
 }
 
 // GetSummary retrieves a summary of safety detections.
@@ -415,7 +623,7 @@ func (r *SafetyRepository) GetSummary(ctx context.Context, orgID uuid.UUID, peri
 
 	// Total count
 	var total int64
-	err := r.db.QueryRowContext(ctx,
+	err := r.stmts.QueryRowContext(ctx,
 		fmt.Sprintf("SELECT COUNT(*) FROM injection_detections WHERE org_id = $1 AND created_at >= NOW() - INTERVAL '%s'", interval),
 		orgID,
 	).Scan(&total)
@@ -425,7 +633,7 @@ func (r *SafetyRepository) GetSummary(ctx context.Context, orgID uuid.UUID, peri
 
 	// By type
 	byType := make(map[string]int64)
-	rows, err := r.db.QueryContext(ctx,
+	rows, err := r.stmts.QueryContext(ctx,
 		fmt.Sprintf("SELECT type, COUNT(*) FROM injection_detections WHERE org_id = $1 AND created_at >= NOW() - INTERVAL '%s' GROUP BY type", interval),
 		orgID,
 	)
@@ -442,7 +650,7 @@ func (r *SafetyRepository) GetSummary(ctx context.Context, orgID uuid.UUID, peri
 
 	// By severity
 	bySeverity := make(map[string]int64)
-	rows, err = r.db.QueryContext(ctx,
+	rows, err = r.stmts.QueryContext(ctx,
 		fmt.Sprintf("SELECT severity, COUNT(*) FROM injection_detections WHERE org_id = $1 AND created_at >= NOW() - INTERVAL '%s' GROUP BY severity", interval),
 		orgID,
 	)
@@ -459,7 +667,7 @@ func (r *SafetyRepository) GetSummary(ctx context.Context, orgID uuid.UUID, peri
 
 	// By action
 	byAction := make(map[string]int64)
-	rows, err = r.db.QueryContext(ctx,
+	rows, err = r.stmts.QueryContext(ctx,
 		fmt.Sprintf("SELECT action_taken, COUNT(*) FROM injection_detections WHERE org_id = $1 AND created_at >= NOW() - INTERVAL '%s' GROUP BY action_taken", interval),
 		orgID,
 	)
@@ -476,7 +684,7 @@ func (r *SafetyRepository) GetSummary(ctx context.Context, orgID uuid.UUID, peri
 
 	// Top patterns
 	var topPatterns []domain.PatternCount
-	rows, err = r.db.QueryContext(ctx,
+	rows, err = r.stmts.QueryContext(ctx,
 		fmt.Sprintf("SELECT pattern_matched, COUNT(*) as cnt FROM injection_detections WHERE org_id = $1 AND created_at >= NOW() - INTERVAL '%s' AND pattern_matched IS NOT NULL GROUP BY pattern_matched ORDER BY cnt DESC LIMIT 10", interval),
 		orgID,
 	)
@@ -499,3 +707,97 @@ func (r *SafetyRepository) GetSummary(ctx context.Context, orgID uuid.UUID, peri
 		Period:          period,
 	}, nil
 }
+
+// detectionKey identifies the request a detection was recorded for, so a
+// request detected more than once is counted once.
+const detectionKey = "COALESCE(NULLIF(trace_id, ''), id::text)"
+
+// GetTopOffenders ranks the API keys, client IPs, MCP servers and tools with
+// the most detections over a period, up to limit of each, and counts the
+// period's detections per bucket.
+func (r *SafetyRepository) GetTopOffenders(ctx context.Context, orgID uuid.UUID, period string, limit int) (*domain.SafetyOffenders, error) {
+	p := domain.ParseSafetyPeriod(period)
+	now := time.Now().UTC()
+	since := now.Add(-p.Window)
+
+	offenders := &domain.SafetyOffenders{Period: p.Name, Bucket: p.BucketName}
+
+	// Each ranking groups by its column, and tools also by their server
+	rankings := []struct {
+		name   string
+		column string
+		server string
+		target *[]domain.SafetyOffender
+	}{
+		{"api keys", "api_key_id::text", "NULL", &offenders.APIKeys},
+		{"ips", "ip_address", "NULL", &offenders.IPs},
+		{"mcp servers", "mcp_server", "NULL", &offenders.Servers},
+		{"tools", "tool_name", "mcp_server", &offenders.Tools},
+	}
+	for _, ranking := range rankings {
+		groupBy := ranking.column
+		if ranking.server != "NULL" {
+			groupBy += ", " + ranking.server
+		}
+		query := fmt.Sprintf(`
+			SELECT %[1]s, %[2]s,
+				COUNT(DISTINCT %[3]s) AS detections,
+				COUNT(DISTINCT %[3]s) FILTER (WHERE action_taken = 'block'),
+				MAX(created_at) AS last_seen
+			FROM injection_detections
+			WHERE org_id = $1 AND created_at >= $2 AND %[1]s IS NOT NULL AND %[1]s <> ''
+			GROUP BY %[4]s
+			ORDER BY detections DESC, last_seen DESC
+			LIMIT $3`, ranking.column, ranking.server, detectionKey, groupBy)
+
+		rows, err := r.stmts.QueryContext(ctx, query, orgID, since, limit)
+		if err != nil {
+			return nil, fmt.Errorf("query top %s: %w", ranking.name, err)
+		}
+		ranked := make([]domain.SafetyOffender, 0, limit)
+		for rows.Next() {
+			var o domain.SafetyOffender
+			var server sql.NullString
+			if err := rows.Scan(&o.Value, &server, &o.Detections, &o.Blocked, &o.LastSeen); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("scan top %s: %w", ranking.name, err)
+			}
+			o.MCPServer = server.String
+			ranked = append(ranked, o)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("query top %s: %w", ranking.name, err)
+		}
+		*ranking.target = ranked
+	}
+
+	rows, err := r.stmts.QueryContext(ctx, fmt.Sprintf(`
+		SELECT date_trunc($3, created_at AT TIME ZONE 'UTC') AS bucket, COUNT(DISTINCT %s)
+		FROM injection_detections
+		WHERE org_id = $1 AND created_at >= $2
+		GROUP BY bucket`, detectionKey),
+		orgID, since, offenders.Bucket,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query detection series: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[time.Time]int64)
+	for rows.Next() {
+		var t time.Time
+		var count int64
+		if err := rows.Scan(&t, &count); err != nil {
+			return nil, fmt.Errorf("scan detection series: %w", err)
+		}
+		counts[time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, time.UTC)] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("query detection series: %w", err)
+	}
+	offenders.Series = domain.DetectionSeries(since, now, p.Bucket, counts)
+
+	return offenders, nil
+}
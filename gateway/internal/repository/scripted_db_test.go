@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// scriptedDriver is a database/sql driver that answers each query with the
+// result of the first script whose match the query contains, and records
+// the statements it ran with their arguments. Queries no script matches
+// return no rows.
+type scriptedDriver struct {
+	mu      sync.Mutex
+	scripts []script
+	ran     []ranStatement
+}
+
+// script is the canned result of the queries containing match.
+type script struct {
+	match   string
+	columns []string
+	rows    [][]driver.Value
+	err     error
+}
+
+type ranStatement struct {
+	query string
+	args  []driver.Value
+}
+
+func newScriptedDB(t *testing.T, scripts ...script) (*sql.DB, *scriptedDriver) {
+	t.Helper()
+	d := &scriptedDriver{scripts: scripts}
+	db := sql.OpenDB(d)
+	t.Cleanup(func() { db.Close() })
+	return db, d
+}
+
+// statements returns the statements run whose query contains match.
+func (d *scriptedDriver) statements(match string) []ranStatement {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var ran []ranStatement
+	for _, s := range d.ran {
+		if strings.Contains(s.query, match) {
+			ran = append(ran, s)
+		}
+	}
+	return ran
+}
+
+func (d *scriptedDriver) run(query string, args []driver.Value) (script, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.ran = append(d.ran, ranStatement{query: query, args: args})
+	for _, s := range d.scripts {
+		if strings.Contains(query, s.match) {
+			return s, s.err
+		}
+	}
+	return script{}, nil
+}
+
+func (d *scriptedDriver) Connect(context.Context) (driver.Conn, error) { return scriptedConn{d}, nil }
+func (d *scriptedDriver) Driver() driver.Driver                        { return d }
+func (d *scriptedDriver) Open(string) (driver.Conn, error)             { return scriptedConn{d}, nil }
+
+type scriptedConn struct{ d *scriptedDriver }
+
+func (c scriptedConn) Prepare(query string) (driver.Stmt, error) {
+	return scriptedStmt{d: c.d, query: query}, nil
+}
+func (c scriptedConn) Close() error { return nil }
+func (c scriptedConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions are not supported")
+}
+
+type scriptedStmt struct {
+	d     *scriptedDriver
+	query string
+}
+
+func (s scriptedStmt) Close() error  { return nil }
+func (s scriptedStmt) NumInput() int { return -1 }
+
+func (s scriptedStmt) Exec(args []driver.Value) (driver.Result, error) {
+	sc, err := s.d.run(s.query, args)
+	if err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(len(sc.rows)), nil
+}
+
+func (s scriptedStmt) Query(args []driver.Value) (driver.Rows, error) {
+	sc, err := s.d.run(s.query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &scriptedRows{columns: sc.columns, rows: sc.rows}, nil
+}
+
+type scriptedRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *scriptedRows) Columns() []string { return r.columns }
+func (r *scriptedRows) Close() error      { return nil }
+func (r *scriptedRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+)
+
+// likeEscaper escapes the LIKE wildcards so user input is matched literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// searchCondition returns a condition matching the placeholder against any
+// of the given columns case-insensitively, along with the bound pattern.
+func searchCondition(query string, argNum int, columns ...string) (string, string) {
+	matches := make([]string, len(columns))
+	for i, column := range columns {
+		matches[i] = fmt.Sprintf("%s ILIKE $%d", column, argNum)
+	}
+	return "(" + strings.Join(matches, " OR ") + ")", "%" + likeEscaper.Replace(query) + "%"
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/google/uuid"
+)
+
+func TestSearchCondition(t *testing.T) {
+	condition, pattern := searchCondition(`50%_off\now`, 3, "reason", "tool_name")
+	if condition != "(reason ILIKE $3 OR tool_name ILIKE $3)" {
+		t.Errorf("condition = %q", condition)
+	}
+	if pattern != `%50\%\_off\\now%` {
+		t.Errorf("pattern = %q, want the wildcards escaped", pattern)
+	}
+}
+
+func TestListSearchCombinesWithFilters(t *testing.T) {
+	orgID := uuid.New()
+	tests := []struct {
+		name   string
+		count  string
+		list   func(*sql.DB) error
+		clause string
+		args   []driver.Value
+	}{
+		{
+			name:  "approvals",
+			count: "SELECT COUNT(*) FROM tool_approvals",
+			list: func(db *sql.DB) error {
+				_, err := NewToolRepository(db).ListApprovals(context.Background(), domain.ToolApprovalFilter{
+					OrgID:     orgID,
+					MCPServer: "shell",
+					Statuses:  []domain.ApprovalStatus{domain.ApprovalStatusPending},
+					Query:     "Deploy",
+				})
+				return err
+			},
+			clause: "org_id = $1 AND mcp_server = $2 AND status IN ($3) AND (reason ILIKE $4 OR tool_name ILIKE $4 OR mcp_server ILIKE $4)",
+			args:   []driver.Value{orgID.String(), "shell", "pending", "%Deploy%"},
+		},
+		{
+			name:  "detections",
+			count: "SELECT COUNT(*) FROM injection_detections",
+			list: func(db *sql.DB) error {
+				_, err := NewSafetyRepository(db).ListDetections(context.Background(), domain.DetectionFilter{
+					OrgID:     orgID,
+					MCPServer: "filesystem",
+					Query:     "ignore previous",
+				})
+				return err
+			},
+			clause: "org_id = $1 AND mcp_server = $2 AND (input ILIKE $3 OR pattern_matched ILIKE $3 OR tool_name ILIKE $3 OR mcp_server ILIKE $3)",
+			args:   []driver.Value{orgID.String(), "filesystem", "%ignore previous%"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, d := newScriptedDB(t, script{match: tt.count, columns: []string{"count"}, rows: [][]driver.Value{{int64(0)}}})
+			if err := tt.list(db); err != nil {
+				t.Fatalf("list: %v", err)
+			}
+
+			counts := d.statements(tt.count)
+			if len(counts) != 1 {
+				t.Fatalf("%d count queries, want 1", len(counts))
+			}
+			if !strings.HasSuffix(counts[0].query, "WHERE "+tt.clause) {
+				t.Errorf("count query = %q, want the search combined with the filters: %q", counts[0].query, tt.clause)
+			}
+			if len(counts[0].args) != len(tt.args) {
+				t.Fatalf("args = %v, want %v", counts[0].args, tt.args)
+			}
+			for i, arg := range counts[0].args {
+				if arg != tt.args[i] {
+					t.Errorf("arg %d = %v, want %v", i+1, arg, tt.args[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/google/uuid"
+)
+
+// SettingsRepository handles persistence of organization settings.
+type SettingsRepository struct {
+	db *sql.DB
+}
+
+// NewSettingsRepository creates a new settings repository.
+func NewSettingsRepository(db *sql.DB) *SettingsRepository {
+	return &SettingsRepository{db: db}
+}
+
+// List returns the settings every organization has set.
+func (r *SettingsRepository) List(ctx context.Context) ([]domain.OrgSetting, error) {
+	if r.db == nil {
+		return nil, nil
+	}
+
+	query := `
+		SELECT org_id, key, value, updated_by, updated_at
+		FROM org_settings
+		ORDER BY org_id, key`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("query org settings: %w", err)
+	}
+	defer rows.Close()
+
+	var settings []domain.OrgSetting
+	for rows.Next() {
+		var setting domain.OrgSetting
+		var value []byte
+		if err := rows.Scan(&setting.OrgID, &setting.Key, &value, &setting.UpdatedBy, &setting.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan org setting: %w", err)
+		}
+		if err := json.Unmarshal(value, &setting.Value); err != nil {
+			return nil, fmt.Errorf("decode org setting %s: %w", setting.Key, err)
+		}
+		settings = append(settings, setting)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate org settings: %w", err)
+	}
+
+	return settings, nil
+}
+
+// Save inserts an organization's setting, or replaces its value.
+func (r *SettingsRepository) Save(ctx context.Context, setting *domain.OrgSetting) error {
+	if r.db == nil {
+		return nil
+	}
+
+	value, err := json.Marshal(setting.Value)
+	if err != nil {
+		return fmt.Errorf("encode org setting: %w", err)
+	}
+
+	query := `
+		INSERT INTO org_settings (org_id, key, value, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (org_id, key)
+		DO UPDATE SET
+			value = EXCLUDED.value,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = EXCLUDED.updated_at`
+
+	_, err = r.db.ExecContext(ctx, query,
+		setting.OrgID, setting.Key, value, setting.UpdatedBy, setting.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("save org setting: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes an organization's setting, restoring its default.
+func (r *SettingsRepository) Delete(ctx context.Context, orgID uuid.UUID, key domain.SettingKey) error {
+	if r.db == nil {
+		return nil
+	}
+
+	_, err := r.db.ExecContext(ctx, `DELETE FROM org_settings WHERE org_id = $1 AND key = $2`, orgID, key)
+	if err != nil {
+		return fmt.Errorf("delete org setting: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestDeleteMarksRowsDeleted(t *testing.T) {
+	db, d := newScriptedDB(t)
+	ctx := context.Background()
+	id := uuid.New()
+
+	if err := NewAlertRepository(db).DeleteRule(ctx, id); err != nil {
+		t.Fatalf("DeleteRule: %v", err)
+	}
+	if err := NewSafetyRepository(db).DeletePolicy(ctx, id); err != nil {
+		t.Fatalf("DeletePolicy: %v", err)
+	}
+	if ran := d.statements("DELETE"); len(ran) != 0 {
+		t.Errorf("rows hard-deleted: %+v", ran)
+	}
+	for _, table := range []string{"alert_rules", "safety_policies"} {
+		ran := d.statements("UPDATE " + table)
+		if len(ran) != 1 || !strings.Contains(ran[0].query, "SET deleted_at = NOW()") || ran[0].args[0] != id.String() {
+			t.Errorf("%s: ran %+v, want deleted_at set", table, ran)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"sync/atomic"
+)
+
+// maxCachedStatements bounds how many distinct queries a repository keeps
+// prepared. Queries built from filters have a bounded set of shapes; once the
+// cache is full further shapes run unprepared.
+const maxCachedStatements = 256
+
+// stmtCache prepares each distinct query once and reuses the statement for
+// later calls. Queries bind org_id as a parameter, so one statement serves
+// every organization. database/sql re-prepares a statement transparently on
+// each pooled connection it is first used on.
+type stmtCache struct {
+	db    *sql.DB
+	mu    sync.RWMutex
+	stmts map[string]*sql.Stmt
+
+	hits   int64
+	misses int64
+}
+
+// StmtCacheStats reports prepared statement reuse for a repository.
+type StmtCacheStats struct {
+	Prepared int   `json:"prepared"`
+	Hits     int64 `json:"hits"`
+	Misses   int64 `json:"misses"`
+}
+
+func newStmtCache(db *sql.DB) *stmtCache {
+	return &stmtCache{
+		db:    db,
+		stmts: make(map[string]*sql.Stmt),
+	}
+}
+
+// prepare returns the cached statement for query, preparing it on first use.
+// It returns nil when the cache is full or the query fails to prepare, and
+// the caller then runs the query directly.
+func (c *stmtCache) prepare(ctx context.Context, query string) *sql.Stmt {
+	c.mu.RLock()
+	stmt, ok := c.stmts[query]
+	c.mu.RUnlock()
+	if ok {
+		atomic.AddInt64(&c.hits, 1)
+		return stmt
+	}
+	atomic.AddInt64(&c.misses, 1)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if stmt, ok := c.stmts[query]; ok {
+		return stmt
+	}
+	if len(c.stmts) >= maxCachedStatements {
+		return nil
+	}
+	stmt, err := c.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil
+	}
+	c.stmts[query] = stmt
+	return stmt
+}
+
+// QueryContext runs a query that returns rows through a cached statement.
+func (c *stmtCache) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if stmt := c.prepare(ctx, query); stmt != nil {
+		return stmt.QueryContext(ctx, args...)
+	}
+	return c.db.QueryContext(ctx, query, args...)
+}
+
+// QueryRowContext runs a query that returns at most one row through a cached
+// statement.
+func (c *stmtCache) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	if stmt := c.prepare(ctx, query); stmt != nil {
+		return stmt.QueryRowContext(ctx, args...)
+	}
+	return c.db.QueryRowContext(ctx, query, args...)
+}
+
+// Stats returns how often cached statements were reused.
+func (c *stmtCache) Stats() StmtCacheStats {
+	c.mu.RLock()
+	prepared := len(c.stmts)
+	c.mu.RUnlock()
+	return StmtCacheStats{
+		Prepared: prepared,
+		Hits:     atomic.LoadInt64(&c.hits),
+		Misses:   atomic.LoadInt64(&c.misses),
+	}
+}
+
+// Close releases every cached statement.
+func (c *stmtCache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var firstErr error
+	for query, stmt := range c.stmts {
+		if err := stmt.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(c.stmts, query)
+	}
+	return firstErr
+}
@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeDriver is a database/sql driver whose queries all return one row
+// holding 1. It counts the statements prepared and closed, and fails to
+// prepare queries containing "syntax error".
+type fakeDriver struct {
+	mu       sync.Mutex
+	prepared map[string]int
+	closed   map[string]int
+}
+
+func newFakeDB(t testing.TB) (*sql.DB, *fakeDriver) {
+	t.Helper()
+	d := &fakeDriver{prepared: make(map[string]int), closed: make(map[string]int)}
+	db := sql.OpenDB(d)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db, d
+}
+
+func (d *fakeDriver) counts(query string) (prepared, closed int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.prepared[query], d.closed[query]
+}
+
+func (d *fakeDriver) Connect(context.Context) (driver.Conn, error) { return fakeConn{d}, nil }
+func (d *fakeDriver) Driver() driver.Driver                        { return d }
+func (d *fakeDriver) Open(string) (driver.Conn, error)             { return fakeConn{d}, nil }
+
+type fakeConn struct{ d *fakeDriver }
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) {
+	if strings.Contains(query, "syntax error") {
+		return nil, errors.New("syntax error")
+	}
+	c.d.mu.Lock()
+	c.d.prepared[query]++
+	c.d.mu.Unlock()
+	return &fakeStmt{d: c.d, query: query}, nil
+}
+func (c fakeConn) Close() error { return nil }
+func (c fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions are not supported")
+}
+
+type fakeStmt struct {
+	d     *fakeDriver
+	query string
+}
+
+func (s *fakeStmt) Close() error {
+	s.d.mu.Lock()
+	s.d.closed[s.query]++
+	s.d.mu.Unlock()
+	return nil
+}
+func (s *fakeStmt) NumInput() int                              { return -1 }
+func (s *fakeStmt) Exec([]driver.Value) (driver.Result, error) { return driver.RowsAffected(0), nil }
+func (s *fakeStmt) Query([]driver.Value) (driver.Rows, error)  { return &fakeRows{}, nil }
+
+type fakeRows struct{ done bool }
+
+func (r *fakeRows) Columns() []string { return []string{"n"} }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = int64(1)
+	return nil
+}
+
+func TestStmtCacheReusesStatement(t *testing.T) {
+	ctx := context.Background()
+	db, d := newFakeDB(t)
+	c := newStmtCache(db)
+
+	const query = "SELECT 1 FROM alerts WHERE org_id = $1"
+	first := c.prepare(ctx, query)
+	if first == nil {
+		t.Fatal("prepare returned nil")
+	}
+	if again := c.prepare(ctx, query); again != first {
+		t.Error("the same query was given a different statement")
+	}
+	if other := c.prepare(ctx, query+" AND status = $2"); other == nil || other == first {
+		t.Error("a different query shared the statement")
+	}
+
+	for i := 0; i < 3; i++ {
+		var n int
+		if err := c.QueryRowContext(ctx, query, "org").Scan(&n); err != nil || n != 1 {
+			t.Fatalf("QueryRowContext = %d, %v", n, err)
+		}
+	}
+	if prepared, _ := d.counts(query); prepared != 1 {
+		t.Errorf("query prepared %d times, want once", prepared)
+	}
+	if stats := c.Stats(); stats.Prepared != 2 || stats.Hits != 4 || stats.Misses != 2 {
+		t.Errorf("Stats = %+v, want 2 prepared, 4 hits and 2 misses", stats)
+	}
+}
+
+func TestStmtCacheCloseClosesStatements(t *testing.T) {
+	ctx := context.Background()
+	db, d := newFakeDB(t)
+	c := newStmtCache(db)
+
+	queries := []string{"SELECT 1 FROM alerts", "SELECT 1 FROM alert_rules"}
+	for _, q := range queries {
+		rows, err := c.QueryContext(ctx, q)
+		if err != nil {
+			t.Fatalf("QueryContext: %v", err)
+		}
+		rows.Close()
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	for _, q := range queries {
+		if prepared, closed := d.counts(q); closed != prepared {
+			t.Errorf("%q: %d statements prepared but %d closed", q, prepared, closed)
+		}
+	}
+	if stats := c.Stats(); stats.Prepared != 0 {
+		t.Errorf("%d statements still cached after Close", stats.Prepared)
+	}
+}
+
+func TestStmtCacheFallsBackToUnpreparedQueries(t *testing.T) {
+	ctx := context.Background()
+	db, _ := newFakeDB(t)
+	c := newStmtCache(db)
+
+	if stmt := c.prepare(ctx, "SELECT syntax error"); stmt != nil {
+		t.Error("a query that failed to prepare was cached")
+	}
+
+	for i := 0; i < maxCachedStatements; i++ {
+		if c.prepare(ctx, fmt.Sprintf("SELECT %d", i)) == nil {
+			t.Fatalf("query %d was not cached", i)
+		}
+	}
+	const extra = "SELECT 1 FROM one_too_many"
+	if stmt := c.prepare(ctx, extra); stmt != nil {
+		t.Error("a full cache prepared another query")
+	}
+	var n int
+	if err := c.QueryRowContext(ctx, extra).Scan(&n); err != nil || n != 1 {
+		t.Errorf("query past a full cache = %d, %v", n, err)
+	}
+}
+
+// BenchmarkStmtCache compares a cached statement with preparing and closing
+// one per query, as database/sql does for a driver without direct queries.
+// The fake driver prepares for free; against Postgres each prepare is also a
+// round trip.
+func BenchmarkStmtCache(b *testing.B) {
+	ctx := context.Background()
+	const query = "SELECT 1 FROM alerts WHERE org_id = $1"
+
+	b.Run("cached", func(b *testing.B) {
+		db, _ := newFakeDB(b)
+		c := newStmtCache(db)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			var n int
+			if err := c.QueryRowContext(ctx, query, "org").Scan(&n); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("unprepared", func(b *testing.B) {
+		db, _ := newFakeDB(b)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			var n int
+			if err := db.QueryRowContext(ctx, query, "org").Scan(&n); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/google/uuid"
+)
+
+// The stores below are what services depend on instead of the Postgres
+// repositories, so that they can run against fakes or another backend.
+
+// AlertStore persists alert rules, channels and alerts.
+type AlertStore interface {
+	ListOrgIDs(ctx context.Context) ([]uuid.UUID, error)
+	CreateRule(ctx context.Context, rule *domain.AlertRule) error
+	GetRule(ctx context.Context, id uuid.UUID) (*domain.AlertRule, error)
+	ListRules(ctx context.Context, orgID uuid.UUID, enabledOnly bool) ([]domain.AlertRule, error)
+	UpdateRule(ctx context.Context, rule *domain.AlertRule) error
+	DeleteRule(ctx context.Context, id uuid.UUID) error
+	RestoreRule(ctx context.Context, id uuid.UUID) error
+	ListDeletedRules(ctx context.Context, orgID uuid.UUID) ([]domain.AlertRule, error)
+	CreateChannel(ctx context.Context, channel *domain.AlertChannel) error
+	GetChannel(ctx context.Context, id uuid.UUID) (*domain.AlertChannel, error)
+	ListChannels(ctx context.Context, orgID uuid.UUID) ([]domain.AlertChannel, error)
+	UpdateChannel(ctx context.Context, channel *domain.AlertChannel) error
+	DeleteChannel(ctx context.Context, id uuid.UUID) error
+	CreateInhibitRule(ctx context.Context, rule *domain.InhibitRule) error
+	ListInhibitRules(ctx context.Context, orgID uuid.UUID) ([]domain.InhibitRule, error)
+	UpdateInhibitRule(ctx context.Context, rule *domain.InhibitRule) error
+	DeleteInhibitRule(ctx context.Context, id uuid.UUID) error
+	CreateAlert(ctx context.Context, alert *domain.Alert) error
+	GetAlert(ctx context.Context, id uuid.UUID) (*domain.Alert, error)
+	UpdateAlert(ctx context.Context, alert *domain.Alert) error
+	AppendAlertEvent(ctx context.Context, event *domain.AlertEvent) error
+	GetAlertEvents(ctx context.Context, alertID uuid.UUID) ([]domain.AlertEvent, error)
+	ListAlerts(ctx context.Context, filter domain.AlertFilter) (*domain.AlertPage, error)
+	GetFiringAlertByRule(ctx context.Context, ruleID uuid.UUID) (*domain.Alert, error)
+	CountActiveAlerts(ctx context.Context, orgID uuid.UUID) (int64, error)
+}
+
+// SafetyStore persists safety policies, pattern libraries and detections.
+type SafetyStore interface {
+	ListOrgIDs(ctx context.Context) ([]uuid.UUID, error)
+	CreatePolicy(ctx context.Context, policy *domain.SafetyPolicy) error
+	GetPolicy(ctx context.Context, id uuid.UUID) (*domain.SafetyPolicy, error)
+	ListPolicies(ctx context.Context, orgID uuid.UUID, enabledOnly bool) ([]domain.SafetyPolicy, error)
+	GetPoliciesForServer(ctx context.Context, orgID uuid.UUID, mcpServer string) ([]domain.SafetyPolicy, error)
+	UpdatePolicy(ctx context.Context, policy *domain.SafetyPolicy) error
+	DeletePolicy(ctx context.Context, id uuid.UUID) error
+	RestorePolicy(ctx context.Context, id uuid.UUID) error
+	ListDeletedPolicies(ctx context.Context, orgID uuid.UUID) ([]domain.SafetyPolicy, error)
+	CreatePatternLibrary(ctx context.Context, library *domain.PatternLibrary) error
+	ListPatternLibraries(ctx context.Context, orgID uuid.UUID) ([]domain.PatternLibrary, error)
+	UpdatePatternLibrary(ctx context.Context, library *domain.PatternLibrary) error
+	DeletePatternLibrary(ctx context.Context, id uuid.UUID) error
+	CreateDetection(ctx context.Context, detection *domain.InjectionDetection) error
+	GetDetection(ctx context.Context, id uuid.UUID) (*domain.InjectionDetection, error)
+	ListDetections(ctx context.Context, filter domain.DetectionFilter) (*domain.DetectionPage, error)
+	GetSummary(ctx context.Context, orgID uuid.UUID, period string) (*domain.SafetySummary, error)
+	GetTopOffenders(ctx context.Context, orgID uuid.UUID, period string, limit int) (*domain.SafetyOffenders, error)
+}
+
+// ToolStore persists tool classifications, unknown tool policies and
+// approvals.
+type ToolStore interface {
+	ListOrgIDs(ctx context.Context) ([]uuid.UUID, error)
+	CreateClassification(ctx context.Context, classification *domain.ToolClassification) error
+	GetClassification(ctx context.Context, orgID uuid.UUID, mcpServer, toolName string) (*domain.ToolClassification, error)
+	ListClassifications(ctx context.Context, orgID uuid.UUID, mcpServer string) ([]domain.ToolClassification, error)
+	DeleteClassification(ctx context.Context, orgID uuid.UUID, mcpServer, toolName string) error
+	GetUnknownToolPolicy(ctx context.Context, orgID uuid.UUID) (domain.UnknownToolPolicy, error)
+	SetUnknownToolPolicy(ctx context.Context, orgID uuid.UUID, policy domain.UnknownToolPolicy) error
+	CreateApproval(ctx context.Context, approval *domain.ToolApproval) error
+	GetApproval(ctx context.Context, id uuid.UUID) (*domain.ToolApproval, error)
+	UpdateApproval(ctx context.Context, approval *domain.ToolApproval) error
+	UpdateApprovals(ctx context.Context, approvals []domain.ToolApproval) error
+	ListApprovals(ctx context.Context, filter domain.ToolApprovalFilter) (*domain.ToolApprovalPage, error)
+	GetActiveApproval(ctx context.Context, orgID uuid.UUID, mcpServer, toolName string, userID uuid.UUID) (*domain.ToolApproval, error)
+	ExpireApprovals(ctx context.Context) (int64, error)
+}
+
+// UserStore persists users, sessions and SSO providers.
+type UserStore interface {
+	CreateUser(ctx context.Context, user *domain.User) error
+	GetUser(ctx context.Context, id uuid.UUID) (*domain.User, error)
+	GetUserByEmail(ctx context.Context, orgID uuid.UUID, email string) (*domain.User, error)
+	GetUserBySSOExternalID(ctx context.Context, providerID uuid.UUID, externalID string) (*domain.User, error)
+	UpdateUser(ctx context.Context, user *domain.User) error
+	ListUsersByOrg(ctx context.Context, orgID uuid.UUID, limit, offset int) ([]domain.User, int64, error)
+	CreateSession(ctx context.Context, session *domain.UserSession) error
+	GetSession(ctx context.Context, id uuid.UUID) (*domain.UserSession, error)
+	UpdateSessionActivity(ctx context.Context, id uuid.UUID) error
+	DeleteSession(ctx context.Context, id uuid.UUID) error
+	DeleteExpiredSessions(ctx context.Context) (int64, error)
+	CreateSSOProvider(ctx context.Context, provider *domain.SSOProvider) error
+	GetSSOProvider(ctx context.Context, id uuid.UUID) (*domain.SSOProvider, error)
+	ListSSOProviders(ctx context.Context, orgID uuid.UUID) ([]domain.SSOProvider, error)
+	UpdateSSOProvider(ctx context.Context, provider *domain.SSOProvider) error
+	DeleteSSOProvider(ctx context.Context, id uuid.UUID) error
+}
+
+// RoleStore persists roles and role assignments.
+type RoleStore interface {
+	CreateRole(ctx context.Context, role *domain.Role) error
+	GetRole(ctx context.Context, id uuid.UUID) (*domain.Role, error)
+	GetRoleByName(ctx context.Context, orgID *uuid.UUID, name string) (*domain.Role, error)
+	ListRoles(ctx context.Context, orgID uuid.UUID) ([]domain.Role, error)
+	UpdateRole(ctx context.Context, role *domain.Role) error
+	DeleteRole(ctx context.Context, id uuid.UUID) error
+	CreateRoleAssignment(ctx context.Context, assignment *domain.RoleAssignment) error
+	GetRoleAssignment(ctx context.Context, id uuid.UUID) (*domain.RoleAssignment, error)
+	ListUserRoles(ctx context.Context, userID uuid.UUID) ([]domain.RoleAssignment, error)
+	DeleteRoleAssignment(ctx context.Context, userID, roleID uuid.UUID) error
+	GetUserPermissions(ctx context.Context, userID uuid.UUID) ([]domain.Permission, error)
+	HasPermission(ctx context.Context, userID uuid.UUID, permission domain.Permission, scopeType domain.ScopeType, scopeID *uuid.UUID) (bool, error)
+	SeedBuiltinRoles(ctx context.Context) error
+}
+
+// SettingsStore persists organization settings.
+type SettingsStore interface {
+	List(ctx context.Context) ([]domain.OrgSetting, error)
+	Save(ctx context.Context, setting *domain.OrgSetting) error
+	Delete(ctx context.Context, orgID uuid.UUID, key domain.SettingKey) error
+}
+
+// AuditStore persists the audit log and its per-org hash chain.
+type AuditStore interface {
+	AppendChained(ctx context.Context, log *domain.AuditLog, seal func(*domain.AuditLog) error) error
+	ListChain(ctx context.Context, orgID uuid.UUID, after int64, limit int) ([]domain.AuditLog, int64, error)
+}
+
+var (
+	_ AuditStore    = (*AuditRepository)(nil)
+	_ AlertStore    = (*AlertRepository)(nil)
+	_ SafetyStore   = (*SafetyRepository)(nil)
+	_ ToolStore     = (*ToolRepository)(nil)
+	_ UserStore     = (*UserRepository)(nil)
+	_ RoleStore     = (*RoleRepository)(nil)
+	_ SettingsStore = (*SettingsRepository)(nil)
+)
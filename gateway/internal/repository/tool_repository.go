@@ -14,12 +14,24 @@ import (
 
 // ToolRepository handles tool classification and approval persistence.
 type ToolRepository struct {
-	db *sql.DB
+	db    *sql.DB
+	stmts *stmtCache
 }
 
 // NewToolRepository creates a new tool repository.
 func NewToolRepository(db *sql.DB) *ToolRepository {
-	return &ToolRepository{db: db}
+	return &ToolRepository{db: db, stmts: newStmtCache(db)}
+}
+
+// StatementStats reports how often the repository's prepared statements were
+// reused.
+func (r *ToolRepository) StatementStats() StmtCacheStats {
+	return r.stmts.Stats()
+}
+
+// Close releases the repository's prepared statements.
+func (r *ToolRepository) Close() error {
+	return r.stmts.Close()
 }
 
 // CreateClassification inserts a new tool classification.
@@ -57,7 +69,7 @@ func (r *ToolRepository) GetClassification(ctx context.Context, orgID uuid.UUID,
 		WHERE org_id = $1 AND mcp_server = $2 AND tool_name = $3`
 
 	var classification domain.ToolClassification
-	err := r.db.QueryRowContext(ctx, query, orgID, mcpServer, toolName).Scan(
+	err := r.stmts.QueryRowContext(ctx, query, orgID, mcpServer, toolName).Scan(
 		&classification.ID, &classification.OrgID, &classification.MCPServer,
 		&classification.ToolName, &classification.Classification, &classification.RequiresApproval,
 		&classification.Description, &classification.CreatedAt, &classification.UpdatedAt, &classification.CreatedBy,
@@ -95,7 +107,7 @@ func (r *ToolRepository) ListClassifications(ctx context.Context, orgID uuid.UUI
 		args = []interface{}{orgID}
 	}
 
-	rows, err := r.db.QueryContext(ctx, query, args...)
+	rows, err := r.stmts.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("query tool classifications: %w", err)
 	}
@@ -130,6 +142,41 @@ func (r *ToolRepository) DeleteClassification(ctx context.Context, orgID uuid.UU
 	return nil
 }
 
+// GetUnknownToolPolicy retrieves an organization's policy for unclassified
+// tools from its settings. It returns an empty policy if none is set.
+func (r *ToolRepository) GetUnknownToolPolicy(ctx context.Context, orgID uuid.UUID) (domain.UnknownToolPolicy, error) {
+	var policy string
+	err := r.stmts.QueryRowContext(ctx,
+		"SELECT COALESCE(settings->>'unknown_tool_policy', '') FROM organizations WHERE id = $1",
+		orgID,
+	).Scan(&policy)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("query unknown tool policy: %w", err)
+	}
+
+	return domain.UnknownToolPolicy(policy), nil
+}
+
+// SetUnknownToolPolicy stores an organization's policy for unclassified tools
+// in its settings.
+func (r *ToolRepository) SetUnknownToolPolicy(ctx context.Context, orgID uuid.UUID, policy domain.UnknownToolPolicy) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE organizations
+		SET settings = jsonb_set(COALESCE(settings, '{}'::jsonb), '{unknown_tool_policy}', to_jsonb($2::text)),
+			updated_at = NOW()
+		WHERE id = $1`,
+		orgID, string(policy),
+	)
+	if err != nil {
+		return fmt.Errorf("update unknown tool policy: %w", err)
+	}
+
+	return nil
+}
+
 // CreateApproval inserts a new tool approval request.
 func (r *ToolRepository) CreateApproval(ctx context.Context, approval *domain.ToolApproval) error {
 	arguments, _ := json.Marshal(approval.Arguments)
@@ -137,14 +184,14 @@ func (r *ToolRepository) CreateApproval(ctx context.Context, approval *domain.To
 	query := `
 		INSERT INTO tool_approvals (
 			id, org_id, team_id, mcp_server, tool_name,
-			requested_by, requested_at, reason, arguments,
-			status, trace_id
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+			requested_by, requested_at, reason, arguments, arguments_redacted,
+			status, reviewed_at, review_note, expires_at, auto_approval_rule, trace_id
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)`
 
 	_, err := r.db.ExecContext(ctx, query,
 		approval.ID, approval.OrgID, approval.TeamID, approval.MCPServer, approval.ToolName,
-		approval.RequestedBy, approval.RequestedAt, approval.Reason, arguments,
-		approval.Status, approval.TraceID,
+		approval.RequestedBy, approval.RequestedAt, approval.Reason, arguments, approval.Redacted,
+		approval.Status, approval.ReviewedAt, approval.ReviewNote, approval.ExpiresAt, approval.AutoApproval, approval.TraceID,
 	)
 	if err != nil {
 		return fmt.Errorf("insert tool approval: %w", err)
@@ -157,8 +204,8 @@ func (r *ToolRepository) CreateApproval(ctx context.Context, approval *domain.To
 func (r *ToolRepository) GetApproval(ctx context.Context, id uuid.UUID) (*domain.ToolApproval, error) {
 	query := `
 		SELECT id, org_id, team_id, mcp_server, tool_name,
-			   requested_by, requested_at, reason, arguments,
-			   status, reviewed_by, reviewed_at, review_note, expires_at, trace_id
+			   requested_by, requested_at, reason, arguments, arguments_redacted,
+			   status, reviewed_by, reviewed_at, review_note, expires_at, auto_approval_rule, trace_id
 		FROM tool_approvals
 		WHERE id = $1`
 
@@ -167,10 +214,10 @@ func (r *ToolRepository) GetApproval(ctx context.Context, id uuid.UUID) (*domain
 	var reviewedAt, expiresAt sql.NullTime
 	var arguments []byte
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err := r.stmts.QueryRowContext(ctx, query, id).Scan(
 		&approval.ID, &approval.OrgID, &teamID, &approval.MCPServer, &approval.ToolName,
-		&approval.RequestedBy, &approval.RequestedAt, &approval.Reason, &arguments,
-		&approval.Status, &reviewedBy, &reviewedAt, &approval.ReviewNote, &expiresAt, &approval.TraceID,
+		&approval.RequestedBy, &approval.RequestedAt, &approval.Reason, &arguments, &approval.Redacted,
+		&approval.Status, &reviewedBy, &reviewedAt, &approval.ReviewNote, &expiresAt, &approval.AutoApproval, &approval.TraceID,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -193,8 +240,8 @@ func (r *ToolRepository) GetApproval(ctx context.Context, id uuid.UUID) (*domain
 	if expiresAt.Valid {
 		approval.ExpiresAt = &expiresAt.Time
 	}
-	if len(arguments) > 0 {
-		json.Unmarshal(arguments, &approval.Arguments)
+	if err := decodeJSON("tool_approvals.arguments", approval.ID, arguments, &approval.Arguments); err != nil {
+		return nil, err
 	}
 
 	return &approval, nil
@@ -219,6 +266,38 @@ func (r *ToolRepository) UpdateApproval(ctx context.Context, approval *domain.To
 	return nil
 }
 
+// UpdateApprovals updates the reviews of several tool approvals in one
+// transaction, so either every update is stored or none is.
+func (r *ToolRepository) UpdateApprovals(ctx context.Context, approvals []domain.ToolApproval) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE tool_approvals SET
+			status = $2, reviewed_by = $3, reviewed_at = $4,
+			review_note = $5, expires_at = $6
+		WHERE id = $1`
+
+	for i := range approvals {
+		approval := &approvals[i]
+		_, err := tx.ExecContext(ctx, query,
+			approval.ID, approval.Status, approval.ReviewedBy, approval.ReviewedAt,
+			approval.ReviewNote, approval.ExpiresAt,
+		)
+		if err != nil {
+			return fmt.Errorf("update tool approval %s: %w", approval.ID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit tool approval updates: %w", err)
+	}
+	return nil
+}
+
 // ListApprovals retrieves tool approvals with filtering.
 func (r *ToolRepository) ListApprovals(ctx context.Context, filter domain.ToolApprovalFilter) (*domain.ToolApprovalPage, error) {
 	var conditions []string
@@ -253,6 +332,12 @@ func (r *ToolRepository) ListApprovals(ctx context.Context, filter domain.ToolAp
 		argNum++
 	}
 
+	if filter.TraceID != "" {
+		conditions = append(conditions, fmt.Sprintf("trace_id = $%d", argNum))
+		args = append(args, filter.TraceID)
+		argNum++
+	}
+
 	if len(filter.Statuses) > 0 {
 		placeholders := make([]string, len(filter.Statuses))
 		for i, status := range filter.Statuses {
@@ -263,12 +348,19 @@ func (r *ToolRepository) ListApprovals(ctx context.Context, filter domain.ToolAp
 		conditions = append(conditions, fmt.Sprintf("status IN (%s)", strings.Join(placeholders, ",")))
 	}
 
+	if filter.Query != "" {
+		condition, pattern := searchCondition(filter.Query, argNum, "reason", "tool_name", "mcp_server")
+		conditions = append(conditions, condition)
+		args = append(args, pattern)
+		argNum++
+	}
+
 	whereClause := strings.Join(conditions, " AND ")
 
 	// Count total
 	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM tool_approvals WHERE %s", whereClause)
 	var total int64
-	if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+	if err := r.stmts.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
 		return nil, fmt.Errorf("count tool approvals: %w", err)
 	}
 
@@ -283,19 +375,30 @@ func (r *ToolRepository) ListApprovals(ctx context.Context, filter domain.ToolAp
 		offset = 0
 	}
 
+	// Keyset pagination takes precedence over offset when a cursor is given.
+	// One extra row is fetched to tell whether another page follows.
+	pageClause := fmt.Sprintf("LIMIT $%d OFFSET $%d", argNum, argNum+1)
+	pageArgs := []interface{}{limit, offset}
+	if filter.Cursor != nil {
+		whereClause += fmt.Sprintf(" AND (requested_at, id) < ($%d, $%d)", argNum, argNum+1)
+		pageClause = fmt.Sprintf("LIMIT $%d", argNum+2)
+		pageArgs = []interface{}{filter.Cursor.CreatedAt, filter.Cursor.ID, limit + 1}
+		offset = 0
+	}
+
 	query := fmt.Sprintf(`
 		SELECT id, org_id, team_id, mcp_server, tool_name,
-			   requested_by, requested_at, reason, arguments,
-			   status, reviewed_by, reviewed_at, review_note, expires_at, trace_id
+			   requested_by, requested_at, reason, arguments, arguments_redacted,
+			   status, reviewed_by, reviewed_at, review_note, expires_at, auto_approval_rule, trace_id
 		FROM tool_approvals
 		WHERE %s
-		ORDER BY requested_at DESC
-		LIMIT $%d OFFSET $%d`,
-		whereClause, argNum, argNum+1)
+		ORDER BY requested_at DESC, id DESC
+		%s`,
+		whereClause, pageClause)
 
-	args = append(args, limit, offset)
+	args = append(args, pageArgs...)
 
-	rows, err := r.db.QueryContext(ctx, query, args...)
+	rows, err := r.stmts.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("query tool approvals: %w", err)
 	}
@@ -310,8 +413,8 @@ func (r *ToolRepository) ListApprovals(ctx context.Context, filter domain.ToolAp
 
 		err := rows.Scan(
 			&approval.ID, &approval.OrgID, &teamID, &approval.MCPServer, &approval.ToolName,
-			&approval.RequestedBy, &approval.RequestedAt, &approval.Reason, &arguments,
-			&approval.Status, &reviewedBy, &reviewedAt, &approval.ReviewNote, &expiresAt, &approval.TraceID,
+			&approval.RequestedBy, &approval.RequestedAt, &approval.Reason, &arguments, &approval.Redacted,
+			&approval.Status, &reviewedBy, &reviewedAt, &approval.ReviewNote, &expiresAt, &approval.AutoApproval, &approval.TraceID,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("scan tool approval: %w", err)
@@ -331,28 +434,40 @@ func (r *ToolRepository) ListApprovals(ctx context.Context, filter domain.ToolAp
 		if expiresAt.Valid {
 			approval.ExpiresAt = &expiresAt.Time
 		}
-		if len(arguments) > 0 {
-			json.Unmarshal(arguments, &approval.Arguments)
+		if err := decodeJSON("tool_approvals.arguments", approval.ID, arguments, &approval.Arguments); err != nil {
+			return nil, err
 		}
 
 		approvals = append(approvals, approval)
 	}
 
-	return &domain.ToolApprovalPage{
+	page := &domain.ToolApprovalPage{
 		Approvals: approvals,
 		Total:     total,
 		Limit:     limit,
 		Offset:    offset,
 		HasMore:   int64(offset+len(approvals)) < total,
-	}, nil
+	}
+	if filter.Cursor != nil {
+		page.HasMore = len(approvals) > limit
+		if page.HasMore {
+			page.Approvals = approvals[:limit]
+		}
+	}
+	if page.HasMore && len(page.Approvals) > 0 {
+		last := page.Approvals[len(page.Approvals)-1]
+		page.NextCursor = domain.NewCursor(last.RequestedAt, last.ID).Encode()
+	}
+
+	return page, nil
 }
 
 // GetActiveApproval retrieves an active (non-expired) approval for a tool.
 func (r *ToolRepository) GetActiveApproval(ctx context.Context, orgID uuid.UUID, mcpServer, toolName string, userID uuid.UUID) (*domain.ToolApproval, error) {
 	query := `
 		SELECT id, org_id, team_id, mcp_server, tool_name,
-			   requested_by, requested_at, reason, arguments,
-			   status, reviewed_by, reviewed_at, review_note, expires_at, trace_id
+			   requested_by, requested_at, reason, arguments, arguments_redacted,
+			   status, reviewed_by, reviewed_at, review_note, expires_at, auto_approval_rule, trace_id
 		FROM tool_approvals
 		WHERE org_id = $1 AND mcp_server = $2 AND tool_name = $3
 			  AND requested_by = $4 AND status = 'approved'
@@ -365,10 +480,10 @@ func (r *ToolRepository) GetActiveApproval(ctx context.Context, orgID uuid.UUID,
 	var reviewedAt, expiresAt sql.NullTime
 	var arguments []byte
 
-	err := r.db.QueryRowContext(ctx, query, orgID, mcpServer, toolName, userID, time.Now()).Scan(
+	err := r.stmts.QueryRowContext(ctx, query, orgID, mcpServer, toolName, userID, time.Now()).Scan(
 		&approval.ID, &approval.OrgID, &teamID, &approval.MCPServer, &approval.ToolName,
-		&approval.RequestedBy, &approval.RequestedAt, &approval.Reason, &arguments,
-		&approval.Status, &reviewedBy, &reviewedAt, &approval.ReviewNote, &expiresAt, &approval.TraceID,
+		&approval.RequestedBy, &approval.RequestedAt, &approval.Reason, &arguments, &approval.Redacted,
+		&approval.Status, &reviewedBy, &reviewedAt, &approval.ReviewNote, &expiresAt, &approval.AutoApproval, &approval.TraceID,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -391,8 +506,8 @@ func (r *ToolRepository) GetActiveApproval(ctx context.Context, orgID uuid.UUID,
 	if expiresAt.Valid {
 		approval.ExpiresAt = &expiresAt.Time
 	}
-	if len(arguments) > 0 {
-		json.Unmarshal(arguments, &approval.Arguments)
+	if err := decodeJSON("tool_approvals.arguments", approval.ID, arguments, &approval.Arguments); err != nil {
+		return nil, err
 	}
 
 	return &approval, nil
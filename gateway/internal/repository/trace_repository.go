@@ -129,8 +129,8 @@ func (r *TraceRepository) Get(ctx context.Context, orgID, id uuid.UUID) (*domain
 		trace.TeamID = &tid
 	}
 
-	if len(metadata) > 0 {
-		json.Unmarshal(metadata, &trace.Metadata)
+	if err := decodeJSON("traces.metadata", trace.ID, metadata, &trace.Metadata); err != nil {
+		return nil, err
 	}
 
 	return &trace, nil
@@ -176,8 +176,8 @@ func (r *TraceRepository) GetByTraceID(ctx context.Context, orgID uuid.UUID, tra
 		tid, _ := uuid.Parse(teamID.String)
 		trace.TeamID = &tid
 	}
-	if len(metadata) > 0 {
-		json.Unmarshal(metadata, &trace.Metadata)
+	if err := decodeJSON("traces.metadata", trace.ID, metadata, &trace.Metadata); err != nil {
+		return nil, err
 	}
 
 	// Get spans for this trace
@@ -225,8 +225,8 @@ func (r *TraceRepository) GetSpans(ctx context.Context, traceID string) ([]domai
 			return nil, fmt.Errorf("scan trace span: %w", err)
 		}
 
-		if len(attrs) > 0 {
-			json.Unmarshal(attrs, &span.Attributes)
+		if err := decodeJSON("trace_spans.attributes", span.ID, attrs, &span.Attributes); err != nil {
+			return nil, err
 		}
 
 		spans = append(spans, span)
@@ -273,6 +273,12 @@ func (r *TraceRepository) List(ctx context.Context, filter domain.TraceFilter) (
 		argNum++
 	}
 
+	if filter.TraceID != "" {
+		conditions = append(conditions, fmt.Sprintf("trace_id = $%d", argNum))
+		args = append(args, filter.TraceID)
+		argNum++
+	}
+
 	if filter.StartTime != nil {
 		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", argNum))
 		args = append(args, *filter.StartTime)
@@ -350,8 +356,8 @@ func (r *TraceRepository) List(ctx context.Context, filter domain.TraceFilter) (
 			tid, _ := uuid.Parse(teamID.String)
 			trace.TeamID = &tid
 		}
-		if len(metadata) > 0 {
-			json.Unmarshal(metadata, &trace.Metadata)
+		if err := decodeJSON("traces.metadata", trace.ID, metadata, &trace.Metadata); err != nil {
+			return nil, 0, err
 		}
 
 		traces = append(traces, trace)
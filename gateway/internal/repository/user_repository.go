@@ -13,16 +13,32 @@ import (
 
 // UserRepository handles user and SSO provider persistence.
 type UserRepository struct {
-	db *sql.DB
+	db    *sql.DB
+	stmts *stmtCache
 }
 
 // NewUserRepository creates a new user repository.
 func NewUserRepository(db *sql.DB) *UserRepository {
-	return &UserRepository{db: db}
+	return &UserRepository{db: db, stmts: newStmtCache(db)}
+}
+
+// StatementStats reports how often the repository's prepared statements were
+// reused.
+func (r *UserRepository) StatementStats() StmtCacheStats {
+	return r.stmts.Stats()
+}
+
+// Close releases the repository's prepared statements.
+func (r *UserRepository) Close() error {
+	return r.stmts.Close()
 }
 
 // CreateUser inserts a new user.
 func (r *UserRepository) CreateUser(ctx context.Context, user *domain.User) error {
+	if r.db == nil {
+		return nil
+	}
+
 	query := `
 		INSERT INTO users (
 			id, org_id, email, name, avatar_url, status,
@@ -42,6 +58,10 @@ func (r *UserRepository) CreateUser(ctx context.Context, user *domain.User) erro
 
 // GetUser retrieves a user by ID.
 func (r *UserRepository) GetUser(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	if r.db == nil {
+		return nil, nil
+	}
+
 	query := `
 		SELECT id, org_id, email, name, avatar_url, status,
 			   sso_provider_id, sso_external_id, last_login_at, created_at, updated_at
@@ -52,7 +72,7 @@ func (r *UserRepository) GetUser(ctx context.Context, id uuid.UUID) (*domain.Use
 	var ssoProviderID sql.NullString
 	var lastLoginAt sql.NullTime
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err := r.stmts.QueryRowContext(ctx, query, id).Scan(
 		&user.ID, &user.OrgID, &user.Email, &user.Name, &user.AvatarURL, &user.Status,
 		&ssoProviderID, &user.SSOExternalID, &lastLoginAt, &user.CreatedAt, &user.UpdatedAt,
 	)
@@ -76,6 +96,10 @@ func (r *UserRepository) GetUser(ctx context.Context, id uuid.UUID) (*domain.Use
 
 // GetUserByEmail retrieves a user by email within an organization.
 func (r *UserRepository) GetUserByEmail(ctx context.Context, orgID uuid.UUID, email string) (*domain.User, error) {
+	if r.db == nil {
+		return nil, nil
+	}
+
 	query := `
 		SELECT id, org_id, email, name, avatar_url, status,
 			   sso_provider_id, sso_external_id, last_login_at, created_at, updated_at
@@ -86,7 +110,7 @@ func (r *UserRepository) GetUserByEmail(ctx context.Context, orgID uuid.UUID, em
 	var ssoProviderID sql.NullString
 	var lastLoginAt sql.NullTime
 
-	err := r.db.QueryRowContext(ctx, query, orgID, email).Scan(
+	err := r.stmts.QueryRowContext(ctx, query, orgID, email).Scan(
 		&user.ID, &user.OrgID, &user.Email, &user.Name, &user.AvatarURL, &user.Status,
 		&ssoProviderID, &user.SSOExternalID, &lastLoginAt, &user.CreatedAt, &user.UpdatedAt,
 	)
@@ -110,6 +134,10 @@ func (r *UserRepository) GetUserByEmail(ctx context.Context, orgID uuid.UUID, em
 
 // GetUserBySSOExternalID retrieves a user by SSO external ID.
 func (r *UserRepository) GetUserBySSOExternalID(ctx context.Context, providerID uuid.UUID, externalID string) (*domain.User, error) {
+	if r.db == nil {
+		return nil, nil
+	}
+
 	query := `
 		SELECT id, org_id, email, name, avatar_url, status,
 			   sso_provider_id, sso_external_id, last_login_at, created_at, updated_at
@@ -120,7 +148,7 @@ func (r *UserRepository) GetUserBySSOExternalID(ctx context.Context, providerID
 	var ssoProviderID sql.NullString
 	var lastLoginAt sql.NullTime
 
-	err := r.db.QueryRowContext(ctx, query, providerID, externalID).Scan(
+	err := r.stmts.QueryRowContext(ctx, query, providerID, externalID).Scan(
 		&user.ID, &user.OrgID, &user.Email, &user.Name, &user.AvatarURL, &user.Status,
 		&ssoProviderID, &user.SSOExternalID, &lastLoginAt, &user.CreatedAt, &user.UpdatedAt,
 	)
@@ -144,6 +172,10 @@ func (r *UserRepository) GetUserBySSOExternalID(ctx context.Context, providerID
 
 // UpdateUser updates an existing user.
 func (r *UserRepository) UpdateUser(ctx context.Context, user *domain.User) error {
+	if r.db == nil {
+		return nil
+	}
+
 	query := `
 		UPDATE users SET
 			email = $2, name = $3, avatar_url = $4, status = $5,
@@ -163,9 +195,13 @@ func (r *UserRepository) UpdateUser(ctx context.Context, user *domain.User) erro
 
 // ListUsersByOrg retrieves all users in an organization.
 func (r *UserRepository) ListUsersByOrg(ctx context.Context, orgID uuid.UUID, limit, offset int) ([]domain.User, int64, error) {
+	if r.db == nil {
+		return nil, 0, nil
+	}
+
 	// Count total
 	var total int64
-	if err := r.db.QueryRowContext(ctx,
+	if err := r.stmts.QueryRowContext(ctx,
 		"SELECT COUNT(*) FROM users WHERE org_id = $1", orgID,
 	).Scan(&total); err != nil {
 		return nil, 0, fmt.Errorf("count users: %w", err)
@@ -183,7 +219,7 @@ func (r *UserRepository) ListUsersByOrg(ctx context.Context, orgID uuid.UUID, li
 		ORDER BY created_at DESC
 		LIMIT $2 OFFSET $3`
 
-	rows, err := r.db.QueryContext(ctx, query, orgID, limit, offset)
+	rows, err := r.stmts.QueryContext(ctx, query, orgID, limit, offset)
 	if err != nil {
 		return nil, 0, fmt.Errorf("query users: %w", err)
 	}
@@ -219,6 +255,10 @@ func (r *UserRepository) ListUsersByOrg(ctx context.Context, orgID uuid.UUID, li
 
 // CreateSession creates a new user session.
 func (r *UserRepository) CreateSession(ctx context.Context, session *domain.UserSession) error {
+	if r.db == nil {
+		return nil
+	}
+
 	query := `
 		INSERT INTO user_sessions (
 			id, user_id, org_id, access_token, refresh_token,
@@ -238,6 +278,10 @@ func (r *UserRepository) CreateSession(ctx context.Context, session *domain.User
 
 // GetSession retrieves a session by ID.
 func (r *UserRepository) GetSession(ctx context.Context, id uuid.UUID) (*domain.UserSession, error) {
+	if r.db == nil {
+		return nil, nil
+	}
+
 	query := `
 		SELECT id, user_id, org_id, access_token, refresh_token,
 			   expires_at, last_activity_at, ip_address, user_agent, created_at
@@ -245,7 +289,7 @@ func (r *UserRepository) GetSession(ctx context.Context, id uuid.UUID) (*domain.
 		WHERE id = $1 AND expires_at > NOW()`
 
 	var session domain.UserSession
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err := r.stmts.QueryRowContext(ctx, query, id).Scan(
 		&session.ID, &session.UserID, &session.OrgID, &session.AccessToken, &session.RefreshToken,
 		&session.ExpiresAt, &session.LastActivityAt, &session.IPAddress, &session.UserAgent, &session.CreatedAt,
 	)
@@ -261,6 +305,10 @@ func (r *UserRepository) GetSession(ctx context.Context, id uuid.UUID) (*domain.
 
 // UpdateSessionActivity updates the last activity time of a session.
 func (r *UserRepository) UpdateSessionActivity(ctx context.Context, id uuid.UUID) error {
+	if r.db == nil {
+		return nil
+	}
+
 	_, err := r.db.ExecContext(ctx,
 		"UPDATE user_sessions SET last_activity_at = $2 WHERE id = $1",
 		id, time.Now(),
@@ -274,6 +322,10 @@ func (r *UserRepository) UpdateSessionActivity(ctx context.Context, id uuid.UUID
 
 // DeleteSession deletes a session (logout).
 func (r *UserRepository) DeleteSession(ctx context.Context, id uuid.UUID) error {
+	if r.db == nil {
+		return nil
+	}
+
 	_, err := r.db.ExecContext(ctx, "DELETE FROM user_sessions WHERE id = $1", id)
 	if err != nil {
 		return fmt.Errorf("delete session: %w", err)
@@ -284,6 +336,10 @@ func (r *UserRepository) DeleteSession(ctx context.Context, id uuid.UUID) error
 
 // DeleteExpiredSessions removes all expired sessions.
 func (r *UserRepository) DeleteExpiredSessions(ctx context.Context) (int64, error) {
+	if r.db == nil {
+		return 0, nil
+	}
+
 	result, err := r.db.ExecContext(ctx, "DELETE FROM user_sessions WHERE expires_at < NOW()")
 	if err != nil {
 		return 0, fmt.Errorf("delete expired sessions: %w", err)
@@ -299,6 +355,10 @@ func (r *UserRepository) DeleteExpiredSessions(ctx context.Context) (int64, erro
 
 // CreateSSOProvider creates a new SSO provider.
 func (r *UserRepository) CreateSSOProvider(ctx context.Context, provider *domain.SSOProvider) error {
+	if r.db == nil {
+		return nil
+	}
+
 	scopes, _ := json.Marshal(provider.Scopes)
 	claimMappings, _ := json.Marshal(provider.ClaimMappings)
 	groupMappings, _ := json.Marshal(provider.GroupMappings)
@@ -325,6 +385,10 @@ func (r *UserRepository) CreateSSOProvider(ctx context.Context, provider *domain
 
 // GetSSOProvider retrieves an SSO provider by ID.
 func (r *UserRepository) GetSSOProvider(ctx context.Context, id uuid.UUID) (*domain.SSOProvider, error) {
+	if r.db == nil {
+		return nil, nil
+	}
+
 	query := `
 		SELECT id, org_id, type, name, issuer_url, client_id, client_secret_encrypted,
 			   authorization_url, token_url, userinfo_url, scopes, claim_mappings,
@@ -335,7 +399,7 @@ func (r *UserRepository) GetSSOProvider(ctx context.Context, id uuid.UUID) (*dom
 	var provider domain.SSOProvider
 	var scopes, claimMappings, groupMappings []byte
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err := r.stmts.QueryRowContext(ctx, query, id).Scan(
 		&provider.ID, &provider.OrgID, &provider.Type, &provider.Name, &provider.IssuerURL,
 		&provider.ClientID, &provider.ClientSecretEncrypted, &provider.AuthorizationURL,
 		&provider.TokenURL, &provider.UserInfoURL, &scopes, &claimMappings,
@@ -348,15 +412,25 @@ func (r *UserRepository) GetSSOProvider(ctx context.Context, id uuid.UUID) (*dom
 		return nil, fmt.Errorf("query SSO provider: %w", err)
 	}
 
-	json.Unmarshal(scopes, &provider.Scopes)
-	json.Unmarshal(claimMappings, &provider.ClaimMappings)
-	json.Unmarshal(groupMappings, &provider.GroupMappings)
+	if err := decodeJSON("sso_providers.scopes", provider.ID, scopes, &provider.Scopes); err != nil {
+		return nil, err
+	}
+	if err := decodeJSON("sso_providers.claim_mappings", provider.ID, claimMappings, &provider.ClaimMappings); err != nil {
+		return nil, err
+	}
+	if err := decodeJSON("sso_providers.group_mappings", provider.ID, groupMappings, &provider.GroupMappings); err != nil {
+		return nil, err
+	}
 
 	return &provider, nil
 }
 
 // ListSSOProviders retrieves all SSO providers for an organization.
 func (r *UserRepository) ListSSOProviders(ctx context.Context, orgID uuid.UUID) ([]domain.SSOProvider, error) {
+	if r.db == nil {
+		return nil, nil
+	}
+
 	query := `
 		SELECT id, org_id, type, name, issuer_url, client_id,
 			   authorization_url, token_url, userinfo_url, scopes, claim_mappings,
@@ -365,7 +439,7 @@ func (r *UserRepository) ListSSOProviders(ctx context.Context, orgID uuid.UUID)
 		WHERE org_id = $1
 		ORDER BY created_at DESC`
 
-	rows, err := r.db.QueryContext(ctx, query, orgID)
+	rows, err := r.stmts.QueryContext(ctx, query, orgID)
 	if err != nil {
 		return nil, fmt.Errorf("query SSO providers: %w", err)
 	}
@@ -385,9 +459,15 @@ func (r *UserRepository) ListSSOProviders(ctx context.Context, orgID uuid.UUID)
 			return nil, fmt.Errorf("scan SSO provider: %w", err)
 		}
 
-		json.Unmarshal(scopes, &provider.Scopes)
-		json.Unmarshal(claimMappings, &provider.ClaimMappings)
-		json.Unmarshal(groupMappings, &provider.GroupMappings)
+		if err := decodeJSON("sso_providers.scopes", provider.ID, scopes, &provider.Scopes); err != nil {
+			return nil, err
+		}
+		if err := decodeJSON("sso_providers.claim_mappings", provider.ID, claimMappings, &provider.ClaimMappings); err != nil {
+			return nil, err
+		}
+		if err := decodeJSON("sso_providers.group_mappings", provider.ID, groupMappings, &provider.GroupMappings); err != nil {
+			return nil, err
+		}
 
 		providers = append(providers, provider)
 	}
@@ -397,6 +477,10 @@ func (r *UserRepository) ListSSOProviders(ctx context.Context, orgID uuid.UUID)
 
 // UpdateSSOProvider updates an SSO provider.
 func (r *UserRepository) UpdateSSOProvider(ctx context.Context, provider *domain.SSOProvider) error {
+	if r.db == nil {
+		return nil
+	}
+
 	scopes, _ := json.Marshal(provider.Scopes)
 	claimMappings, _ := json.Marshal(provider.ClaimMappings)
 	groupMappings, _ := json.Marshal(provider.GroupMappings)
@@ -423,6 +507,10 @@ func (r *UserRepository) UpdateSSOProvider(ctx context.Context, provider *domain
 
 // DeleteSSOProvider deletes an SSO provider.
 func (r *UserRepository) DeleteSSOProvider(ctx context.Context, id uuid.UUID) error {
+	if r.db == nil {
+		return nil
+	}
+
 	_, err := r.db.ExecContext(ctx, "DELETE FROM sso_providers WHERE id = $1", id)
 	if err != nil {
 		return fmt.Errorf("delete SSO provider: %w", err)
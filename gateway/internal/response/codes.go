@@ -0,0 +1,156 @@
+package response
+
+import "net/http"
+
+// ErrorCode is a stable, machine-readable error code returned in error responses.
+type ErrorCode string
+
+// Error codes returned by the API. Codes are part of the public contract and
+// must not be renamed; add new codes instead.
+const (
+	CodeInvalidRequest        ErrorCode = "invalid_request"
+	CodeInvalidJSON           ErrorCode = "invalid_json"
+	CodeInvalidBody           ErrorCode = "invalid_body"
+	CodeBodyTooLarge          ErrorCode = "body_too_large"
+	CodeValidationError       ErrorCode = "validation_error"
+	CodeInvalidID             ErrorCode = "invalid_id"
+	CodeInvalidConnectionID   ErrorCode = "invalid_connection_id"
+	CodeInvalidTeamID         ErrorCode = "invalid_team_id"
+	CodeInvalidStartDate      ErrorCode = "invalid_start_date"
+	CodeInvalidEndDate        ErrorCode = "invalid_end_date"
+	CodeInvalidRange          ErrorCode = "invalid_range"
+	CodeInvalidGroupBy        ErrorCode = "invalid_group_by"
+	CodeInvalidCursor         ErrorCode = "invalid_cursor"
+	CodeMissingServer         ErrorCode = "missing_server"
+	CodeBatchTooLarge         ErrorCode = "batch_too_large"
+	CodeBatchCostLimit        ErrorCode = "batch_cost_limit"
+	CodeMissingAuth           ErrorCode = "missing_auth"
+	CodeInvalidAuth           ErrorCode = "invalid_auth"
+	CodeInvalidAPIKey         ErrorCode = "invalid_api_key"
+	CodeInvalidSession        ErrorCode = "invalid_session"
+	CodeInvalidSignature      ErrorCode = "invalid_signature"
+	CodeInvalidResumeToken    ErrorCode = "invalid_resume_token"
+	CodeAuthError             ErrorCode = "auth_error"
+	CodeAuthURLError          ErrorCode = "auth_url_error"
+	CodeProviderDisabled      ErrorCode = "provider_disabled"
+	CodeStateError            ErrorCode = "state_error"
+	CodeForbidden             ErrorCode = "forbidden"
+	CodeIPNotAllowed          ErrorCode = "ip_not_allowed"
+	CodeBuiltinRole           ErrorCode = "builtin_role"
+	CodeConfiguredServer      ErrorCode = "configured_server"
+	CodeNotFound              ErrorCode = "not_found"
+	CodeServerNotFound        ErrorCode = "server_not_found"
+	CodeRoleNotFound          ErrorCode = "role_not_found"
+	CodeMethodNotAllowed      ErrorCode = "method_not_allowed"
+	CodeDuplicateName         ErrorCode = "duplicate_name"
+	CodeSubscriptionLimit     ErrorCode = "subscription_limit"
+	CodeTransportConflict     ErrorCode = "transport_conflict"
+	CodeLibraryInUse          ErrorCode = "library_in_use"
+	CodeIdempotencyInProgress ErrorCode = "idempotency_in_progress"
+	CodeIdempotencyConflict   ErrorCode = "idempotency_conflict"
+	CodeServerUnreachable     ErrorCode = "server_unreachable"
+	CodeEgressDenied          ErrorCode = "egress_denied"
+	CodeBudgetExceeded        ErrorCode = "budget_exceeded"
+	CodeRateLimitExceeded     ErrorCode = "rate_limit_exceeded"
+	CodeTooManyBatches        ErrorCode = "too_many_batches"
+	CodeToolLoopDetected      ErrorCode = "tool_loop_detected"
+	CodeAuthLocked            ErrorCode = "auth_locked"
+	CodeStepUpRequired        ErrorCode = "step_up_required"
+	CodeInjectionDetected     ErrorCode = "injection_detected"
+	CodeApprovalNotGranted    ErrorCode = "approval_not_granted"
+	CodeApprovalExpired       ErrorCode = "approval_expired"
+	CodeApprovalRedacted      ErrorCode = "approval_redacted"
+	CodeGrantFailed           ErrorCode = "grant_failed"
+	CodeAssignmentFailed      ErrorCode = "assignment_failed"
+	CodeTestFailed            ErrorCode = "test_failed"
+	CodeTriggerFailed         ErrorCode = "trigger_failed"
+	CodeStreamingNotSupported ErrorCode = "streaming_not_supported"
+	CodeExportError           ErrorCode = "export_error"
+	CodeDBError               ErrorCode = "db_error"
+	CodeConnectionError       ErrorCode = "connection_error"
+	CodeReloadFailed          ErrorCode = "reload_failed"
+	CodeReloadUnavailable     ErrorCode = "reload_unavailable"
+	CodeSecretsUnavailable    ErrorCode = "secrets_unavailable"
+	CodeOverloaded            ErrorCode = "overloaded"
+	CodeUpstreamRejected      ErrorCode = "upstream_rejected"
+	CodeUpstreamError         ErrorCode = "upstream_error"
+	CodeInternalError         ErrorCode = "internal_error"
+)
+
+// ErrorCodeInfo documents an error code.
+type ErrorCodeInfo struct {
+	Code        ErrorCode `json:"code"`
+	Status      int       `json:"status"`
+	Description string    `json:"description"`
+}
+
+// ErrorCatalog lists every error code with its usual HTTP status.
+var ErrorCatalog = []ErrorCodeInfo{
+	{CodeInvalidRequest, http.StatusBadRequest, "The request is malformed or has invalid parameters"},
+	{CodeInvalidJSON, http.StatusBadRequest, "The request body is not valid JSON"},
+	{CodeInvalidBody, http.StatusBadRequest, "The request body is missing or cannot be decoded"},
+	{CodeBodyTooLarge, http.StatusRequestEntityTooLarge, "The request body is larger than the gateway accepts"},
+	{CodeValidationError, http.StatusBadRequest, "One or more fields failed validation; see details"},
+	{CodeInvalidID, http.StatusBadRequest, "A path identifier is not a valid ID"},
+	{CodeInvalidConnectionID, http.StatusBadRequest, "The agent connection ID is not valid"},
+	{CodeInvalidTeamID, http.StatusBadRequest, "The team_id parameter is not a valid ID"},
+	{CodeInvalidStartDate, http.StatusBadRequest, "The start_date parameter is not a valid date"},
+	{CodeInvalidEndDate, http.StatusBadRequest, "The end_date parameter is not a valid date"},
+	{CodeInvalidRange, http.StatusBadRequest, "The date range is invalid"},
+	{CodeInvalidGroupBy, http.StatusBadRequest, "The group_by parameter names an unsupported dimension"},
+	{CodeInvalidCursor, http.StatusBadRequest, "The pagination cursor is malformed"},
+	{CodeMissingServer, http.StatusBadRequest, "The MCP server name is missing"},
+	{CodeBatchTooLarge, http.StatusBadRequest, "The tool call batch has more calls than allowed"},
+	{CodeBatchCostLimit, http.StatusBadRequest, "The tool call batch's estimated cost exceeds the per-batch limit"},
+	{CodeMissingAuth, http.StatusUnauthorized, "The Authorization header is missing"},
+	{CodeInvalidAuth, http.StatusUnauthorized, "The Authorization header is malformed"},
+	{CodeInvalidAPIKey, http.StatusUnauthorized, "The API key is invalid, expired or revoked"},
+	{CodeInvalidSession, http.StatusUnauthorized, "The session or refresh token is invalid, expired or revoked"},
+	{CodeInvalidSignature, http.StatusUnauthorized, "The callback is unsigned, or its signature or timestamp is invalid"},
+	{CodeInvalidResumeToken, http.StatusUnauthorized, "The resume token does not match the agent connection"},
+	{CodeAuthError, http.StatusBadRequest, "The SSO authentication flow failed"},
+	{CodeAuthURLError, http.StatusInternalServerError, "The SSO authorization URL could not be built"},
+	{CodeProviderDisabled, http.StatusBadRequest, "The SSO provider is disabled"},
+	{CodeStateError, http.StatusInternalServerError, "The SSO state could not be created"},
+	{CodeForbidden, http.StatusForbidden, "The caller lacks permission for this operation"},
+	{CodeIPNotAllowed, http.StatusForbidden, "The API key or organization does not allow requests from the client IP"},
+	{CodeBuiltinRole, http.StatusForbidden, "Built-in roles cannot be modified or deleted"},
+	{CodeConfiguredServer, http.StatusForbidden, "MCP servers configured through the environment cannot be modified or removed"},
+	{CodeEgressDenied, http.StatusForbidden, "The MCP server's destination is not allowed by the egress policy"},
+	{CodeNotFound, http.StatusNotFound, "The requested resource was not found"},
+	{CodeServerNotFound, http.StatusNotFound, "The MCP server is not configured"},
+	{CodeRoleNotFound, http.StatusNotFound, "The role does not exist"},
+	{CodeMethodNotAllowed, http.StatusMethodNotAllowed, "The HTTP method is not allowed for this resource"},
+	{CodeDuplicateName, http.StatusConflict, "A resource with this name already exists"},
+	{CodeSubscriptionLimit, http.StatusConflict, "The agent connection has reached its resource subscription limit"},
+	{CodeTransportConflict, http.StatusConflict, "The agent connection already delivers events over WebSocket"},
+	{CodeLibraryInUse, http.StatusConflict, "The pattern library is referenced by safety policies"},
+	{CodeIdempotencyInProgress, http.StatusConflict, "A request with the same Idempotency-Key is still being processed"},
+	{CodeIdempotencyConflict, http.StatusUnprocessableEntity, "The Idempotency-Key was already used with a different request body"},
+	{CodeServerUnreachable, http.StatusUnprocessableEntity, "The MCP server did not answer at the given URL"},
+	{CodeBudgetExceeded, http.StatusPaymentRequired, "The request would exceed a hard budget cap"},
+	{CodeRateLimitExceeded, http.StatusTooManyRequests, "The rate limit for this API key was exceeded"},
+	{CodeTooManyBatches, http.StatusTooManyRequests, "The agent connection already has the maximum number of tool call batches in flight"},
+	{CodeToolLoopDetected, http.StatusTooManyRequests, "The same tool call was repeated too many times in a short window; the agent may be stuck in a loop"},
+	{CodeAuthLocked, http.StatusTooManyRequests, "Sign-in is temporarily locked after repeated failed attempts"},
+	{CodeStepUpRequired, http.StatusUnauthorized, "The action needs a recent multi-factor sign-in; re-authenticate through SSO and retry with the new session token"},
+	{CodeInjectionDetected, http.StatusBadRequest, "The request was blocked by prompt injection detection"},
+	{CodeApprovalNotGranted, http.StatusConflict, "The tool approval is pending or was denied"},
+	{CodeApprovalExpired, http.StatusConflict, "The tool approval's validity window has passed"},
+	{CodeApprovalRedacted, http.StatusConflict, "The tool approval's arguments were redacted, so its call must be sent again"},
+	{CodeGrantFailed, http.StatusBadRequest, "The permission could not be granted"},
+	{CodeAssignmentFailed, http.StatusBadRequest, "The role could not be assigned"},
+	{CodeTestFailed, http.StatusBadRequest, "The test notification could not be delivered"},
+	{CodeTriggerFailed, http.StatusInternalServerError, "The test alert could not be triggered"},
+	{CodeStreamingNotSupported, http.StatusInternalServerError, "The connection does not support streaming"},
+	{CodeExportError, http.StatusInternalServerError, "The export could not be generated"},
+	{CodeDBError, http.StatusInternalServerError, "A database operation failed"},
+	{CodeConnectionError, http.StatusInternalServerError, "The MCP server connection could not be established"},
+	{CodeReloadFailed, http.StatusInternalServerError, "The configuration could not be reloaded"},
+	{CodeReloadUnavailable, http.StatusServiceUnavailable, "Configuration reload is not enabled"},
+	{CodeSecretsUnavailable, http.StatusServiceUnavailable, "Credentials cannot be stored without SECRETS_ENCRYPTION_KEY"},
+	{CodeOverloaded, http.StatusServiceUnavailable, "The gateway is serving as many requests as it allows; retry after Retry-After seconds"},
+	{CodeUpstreamRejected, http.StatusBadRequest, "The upstream MCP server rejected the request; details give its status and message"},
+	{CodeUpstreamError, http.StatusBadGateway, "The upstream MCP server could not be reached, failed or timed out"},
+	{CodeInternalError, http.StatusInternalServerError, "An unexpected internal error occurred"},
+}
@@ -4,6 +4,7 @@ package response
 import (
 	"encoding/json"
 	"net/http"
+	"time"
 )
 
 // ErrorResponse represents an error response.
@@ -13,7 +14,17 @@ type ErrorResponse struct {
 
 // ErrorDetail contains error details.
 type ErrorDetail struct {
-	Code    string `json:"code"`
+	Code      ErrorCode   `json:"code"`
+	Message   string      `json:"message"`
+	Details   interface{} `json:"details,omitempty"`
+	RequestID string      `json:"request_id,omitempty"`
+	TraceID   string      `json:"trace_id,omitempty"`
+	Timestamp time.Time   `json:"timestamp"`
+}
+
+// FieldError describes a problem with a single request field.
+type FieldError struct {
+	Field   string `json:"field"`
 	Message string `json:"message"`
 }
 
@@ -30,15 +41,36 @@ func WriteJSON(w http.ResponseWriter, status int, data interface{}) {
 }
 
 // WriteError writes an error response.
-func WriteError(w http.ResponseWriter, status int, code string, message string) {
+func WriteError(w http.ResponseWriter, status int, code ErrorCode, message string) {
+	WriteErrorDetails(w, status, code, message, nil)
+}
+
+// WriteErrorDetails writes an error response with additional details. The
+// request and trace IDs are taken from the response headers set by the
+// request ID and trace middleware.
+func WriteErrorDetails(w http.ResponseWriter, status int, code ErrorCode, message string, details interface{}) {
 	WriteJSON(w, status, ErrorResponse{
 		Error: ErrorDetail{
-			Code:    code,
-			Message: message,
+			Code:      code,
+			Message:   message,
+			Details:   details,
+			RequestID: w.Header().Get("X-Request-ID"),
+			TraceID:   w.Header().Get("X-Trace-ID"),
+			Timestamp: time.Now().UTC(),
 		},
 	})
 }
 
+// WriteValidationError writes a 400 validation error listing the offending fields.
+func WriteValidationError(w http.ResponseWriter, message string, fields []FieldError) {
+	WriteErrorDetails(w, http.StatusBadRequest, CodeValidationError, message, fields)
+}
+
+// WriteFieldError writes a 400 validation error for a single field.
+func WriteFieldError(w http.ResponseWriter, field, message string) {
+	WriteValidationError(w, message, []FieldError{{Field: field, Message: message}})
+}
+
 // WriteSuccess writes a success response.
 func WriteSuccess(w http.ResponseWriter, status int, data interface{}) {
 	WriteJSON(w, status, SuccessResponse{
@@ -0,0 +1,89 @@
+package response
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestErrorCatalogIsComplete makes sure every error code declared in
+// codes.go is documented, once, with an error status.
+func TestErrorCatalogIsComplete(t *testing.T) {
+	file, err := parser.ParseFile(token.NewFileSet(), "codes.go", nil, 0)
+	if err != nil {
+		t.Fatalf("parse codes.go: %v", err)
+	}
+	declared := make(map[string]bool)
+	ast.Inspect(file, func(n ast.Node) bool {
+		spec, ok := n.(*ast.ValueSpec)
+		if !ok {
+			return true
+		}
+		if ident, ok := spec.Type.(*ast.Ident); ok && ident.Name == "ErrorCode" {
+			for _, name := range spec.Names {
+				declared[name.Name] = true
+			}
+		}
+		return true
+	})
+
+	documented := make(map[ErrorCode]bool)
+	for _, info := range ErrorCatalog {
+		if documented[info.Code] {
+			t.Errorf("%s is listed twice", info.Code)
+		}
+		documented[info.Code] = true
+		if info.Status < 400 || info.Description == "" {
+			t.Errorf("%s: status %d, description %q", info.Code, info.Status, info.Description)
+		}
+	}
+	if len(documented) != len(declared) {
+		t.Errorf("%d codes declared, %d documented in ErrorCatalog", len(declared), len(documented))
+	}
+}
+
+func TestWriteErrorDetails(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rec.Header().Set("X-Request-ID", "req-1")
+	rec.Header().Set("X-Trace-ID", "trace-1")
+	WriteFieldError(rec, "name", "name is required")
+
+	if rec.Code != http.StatusBadRequest || rec.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("status %d, Content-Type %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	var body struct {
+		Error struct {
+			Code      ErrorCode    `json:"code"`
+			Message   string       `json:"message"`
+			Details   []FieldError `json:"details"`
+			RequestID string       `json:"request_id"`
+			TraceID   string       `json:"trace_id"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	e := body.Error
+	if e.Code != CodeValidationError || e.RequestID != "req-1" || e.TraceID != "trace-1" {
+		t.Errorf("error = %+v", e)
+	}
+	if len(e.Details) != 1 || e.Details[0].Field != "name" {
+		t.Errorf("details = %+v", e.Details)
+	}
+
+	rec = httptest.NewRecorder()
+	WriteError(rec, http.StatusNotFound, CodeNotFound, "missing")
+	var raw map[string]map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &raw); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	for _, field := range []string{"details", "request_id", "trace_id"} {
+		if _, ok := raw["error"][field]; ok {
+			t.Errorf("empty %s written", field)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+// Package ringbuf bounds the in-memory buffers that services keep of their
+// most recent items.
+package ringbuf
+
+// DefaultCapacity is used when a buffer is created without a positive size.
+const DefaultCapacity = 1000
+
+// Ring decides where the next item of a bounded buffer is stored. The buffer
+// itself is an ordinary slice owned by the caller, so it can be ranged over
+// and updated in place. It grows by append until it holds Capacity items;
+// after that each new item overwrites the oldest, so the backing array never
+// grows further. Once the buffer has wrapped its items are no longer in
+// insertion order.
+//
+// A Ring is not safe for concurrent use; callers guard it with the lock that
+// guards the slice.
+type Ring struct {
+	capacity int
+	next     int
+	dropped  int64
+}
+
+// New creates a ring for a buffer of the given capacity.
+func New(capacity int) *Ring {
+	if capacity <= 0 {
+		capacity = DefaultCapacity
+	}
+	return &Ring{capacity: capacity}
+}
+
+// Slot returns the index at which to store the next item of a buffer that
+// holds n items. While the buffer has room the index is n and the caller
+// appends. Once it is full the index is that of the oldest item, which the
+// caller overwrites, and evicted is true.
+func (r *Ring) Slot(n int) (index int, evicted bool) {
+	if n < r.capacity {
+		return n, false
+	}
+	index = r.next
+	r.next = (r.next + 1) % r.capacity
+	r.dropped++
+	return index, true
+}
+
+// Stats describes a buffer holding n items.
+func (r *Ring) Stats(n int) Stats {
+	return Stats{Size: n, Capacity: r.capacity, Dropped: r.dropped}
+}
+
+// Stats reports how full a buffer is and how many items it has evicted.
+type Stats struct {
+	Size     int
+	Capacity int
+	Dropped  int64
+}
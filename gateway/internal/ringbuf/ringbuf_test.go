@@ -0,0 +1,36 @@
+package ringbuf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRingOverwritesOldest(t *testing.T) {
+	r := New(3)
+	var buf []int
+	for item := 1; item <= 7; item++ {
+		if i, evicted := r.Slot(len(buf)); evicted {
+			buf[i] = item
+		} else {
+			buf = append(buf, item)
+		}
+	}
+
+	if want := []int{7, 5, 6}; !reflect.DeepEqual(buf, want) {
+		t.Errorf("buffer = %v, want %v", buf, want)
+	}
+	if cap(buf) > 4 {
+		t.Errorf("buffer grew to capacity %d after filling", cap(buf))
+	}
+	if stats := r.Stats(len(buf)); stats != (Stats{Size: 3, Capacity: 3, Dropped: 4}) {
+		t.Errorf("Stats = %+v, want 3 of 3 held and 4 dropped", stats)
+	}
+}
+
+func TestNewDefaultsCapacity(t *testing.T) {
+	for _, capacity := range []int{0, -1} {
+		if got := New(capacity).Stats(0).Capacity; got != DefaultCapacity {
+			t.Errorf("New(%d) capacity = %d, want %d", capacity, got, DefaultCapacity)
+		}
+	}
+}
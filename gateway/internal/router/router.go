@@ -5,10 +5,12 @@ import (
 	"net/http"
 
 	"github.com/akz4ol/gatewayops/gateway/internal/config"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
 	"github.com/akz4ol/gatewayops/gateway/internal/handler"
+	"github.com/akz4ol/gatewayops/gateway/internal/metrics"
 	"github.com/akz4ol/gatewayops/gateway/internal/middleware"
+	"github.com/akz4ol/gatewayops/gateway/internal/response"
 	"github.com/go-chi/chi/v5"
-	chimiddleware "github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 	"github.com/rs/zerolog"
 )
@@ -21,7 +23,11 @@ type Dependencies struct {
 	RateLimiter       middleware.RateLimiter
 	InjectionDetector middleware.InjectionDetector
 	AuditLogger       middleware.AuditLogger
+	IdempotencyStore  middleware.IdempotencyStore
+	Concurrency       *middleware.ConcurrencyLimiter
+	Metrics           *metrics.Registry
 	MCPHandler        *handler.MCPHandler
+	MCPServerHandler  *handler.MCPServerHandler
 	HealthHandler     *handler.HealthHandler
 	TraceHandler      *handler.TraceHandler
 	CostHandler       *handler.CostHandler
@@ -30,6 +36,7 @@ type Dependencies struct {
 	DocsHandler       *handler.DocsHandler
 	SafetyHandler     *handler.SafetyHandler
 	AuditHandler      *handler.AuditHandler
+	ComplianceHandler *handler.ComplianceHandler
 	AlertHandler      *handler.AlertHandler
 	TelemetryHandler  *handler.TelemetryHandler
 	ApprovalHandler   *handler.ApprovalHandler
@@ -37,54 +44,135 @@ type Dependencies struct {
 	SSOHandler        *handler.SSOHandler
 	UserHandler       *handler.UserHandler
 	SettingsHandler   *handler.SettingsHandler
+	ConfigHandler     *handler.ConfigHandler
+	SecretsHandler    *handler.SecretsHandler
 	AgentHandler      *handler.AgentHandler
+	OpenAIHandler     *handler.OpenAIHandler
+	BudgetHandler     *handler.BudgetHandler
+	StatsHandler      *handler.StatsHandler
+	QuotaHandler      *handler.QuotaHandler
 }
 
 // New creates a new router with all middleware and routes configured.
 func New(deps Dependencies) http.Handler {
 	r := chi.NewRouter()
 
-	// CORS middleware - must be first
-	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"https://gatewayops-dashboard.fly.dev", "http://localhost:3000", "http://localhost:3001"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-Trace-ID"},
-		ExposedHeaders:   []string{"X-MCP-Server", "X-MCP-Duration-Ms", "X-MCP-Cost"},
-		AllowCredentials: true,
-		MaxAge:           300,
-	}))
+	// Validated with the rest of the configuration at startup
+	trustedProxies, err := middleware.ParseCIDRs(deps.Config.Server.TrustedProxies)
+	if err != nil {
+		deps.Logger.Error().Err(err).Msg("Ignoring invalid TRUSTED_PROXIES")
+	}
+	allowances := make([]middleware.RateLimitAllowance, 0, len(deps.Config.RateLimit.Allowlist))
+	for _, a := range deps.Config.RateLimit.Allowlist {
+		allowances = append(allowances, middleware.RateLimitAllowance{
+			Name:           a.Name,
+			APIKeys:        a.APIKeys,
+			ServiceAccount: a.ServiceAccount,
+			CIDRs:          a.CIDRs,
+			Limit:          a.Limit,
+		})
+	}
+	rateLimitAllowlist, err := middleware.NewRateLimitAllowlist(allowances)
+	if err != nil {
+		deps.Logger.Error().Err(err).Msg("Ignoring invalid RATE_LIMIT_ALLOWLIST")
+	}
+
+	// CORS middleware - must be first, so preflight requests are answered
+	// before authentication
+	corsConfig := deps.Config.CORS
+	corsOptions := cors.Options{
+		AllowedOrigins:   corsConfig.AllowedOrigins,
+		AllowedMethods:   corsConfig.AllowedMethods,
+		AllowedHeaders:   corsConfig.AllowedHeaders,
+		ExposedHeaders:   corsConfig.ExposedHeaders,
+		AllowCredentials: corsConfig.AllowCredentials,
+		MaxAge:           int(corsConfig.MaxAge.Seconds()),
+	}
+	if len(corsOptions.AllowedOrigins) == 0 {
+		// The cors package reads an empty list as any origin
+		corsOptions.AllowOriginFunc = func(*http.Request, string) bool { return false }
+	}
+	r.Use(cors.Handler(corsOptions))
 
 	// Global middleware (order matters!)
-	r.Use(chimiddleware.RequestID)                                // 1. Add request ID
-	r.Use(chimiddleware.RealIP)                                   // 2. Get real IP from headers
-	r.Use(middleware.Recoverer(deps.Logger))                      // 3. Recover from panics
-	r.Use(middleware.Logger(deps.Logger))                         // 4. Log requests
-	r.Use(middleware.Trace())                                     // 5. Add trace context
-	r.Use(chimiddleware.Timeout(deps.Config.Server.WriteTimeout)) // 6. Request timeout
+	r.Use(middleware.RequestID(deps.Logger))                   // 1. Add request ID and request logger
+	r.Use(middleware.ClientIP(trustedProxies))                 // 2. Get real IP from trusted proxy headers
+	r.Use(middleware.Recoverer(deps.Logger))                   // 3. Recover from panics
+	r.Use(middleware.Logger(deps.Logger))                      // 4. Log requests
+	r.Use(middleware.Trace())                                  // 5. Add trace context
+	r.Use(middleware.Timeout(deps.Config.Server.WriteTimeout)) // 6. Request timeout
+	if deps.Metrics != nil {
+		r.Use(middleware.Metrics(deps.Metrics)) // 7. Prometheus request metrics
+	}
+	if deps.Concurrency != nil {
+		r.Use(middleware.ConcurrencyLimit(deps.Concurrency, deps.Logger)) // 8. Shed load beyond the concurrency limits
+	}
+	r.Use(middleware.MaxBodySize(int64(deps.Config.Server.MaxRequestBody))) // 9. Limit request body size
+	if compression := deps.Config.Compression; compression.Enabled {
+		r.Use(middleware.Compress(middleware.Compression{ // 10. Compress large responses
+			Level:        compression.Level,
+			MinSize:      compression.MinSize,
+			ContentTypes: compression.ContentTypes,
+		}))
+	}
 
 	// Health endpoints (no auth required)
 	r.Get("/health", deps.HealthHandler.Health)
 	r.Get("/ready", deps.HealthHandler.Ready)
 
+	// Prometheus scrape endpoint (no auth required)
+	if deps.Metrics != nil {
+		r.Method(http.MethodGet, "/metrics", deps.Metrics.Handler())
+	}
+
 	// API Documentation (no auth required)
 	if deps.DocsHandler != nil {
 		r.Get("/docs", deps.DocsHandler.SwaggerUI)
 		r.Get("/openapi.yaml", deps.DocsHandler.OpenAPISpec)
+		r.Get("/v1/errors", deps.DocsHandler.ErrorCodes)
 	}
 
-	// SSO OAuth callbacks (no auth required - part of login flow)
+	// SSO OAuth callbacks (no auth required - part of login flow), rate
+	// limited per client IP
 	if deps.SSOHandler != nil {
-		r.Get("/v1/sso/authorize/{providerID}", deps.SSOHandler.Authorize)
-		r.Get("/v1/sso/callback/{providerID}", deps.SSOHandler.Callback)
-		r.Post("/v1/sso/logout", deps.SSOHandler.Logout)
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.AuthRateLimit(deps.RateLimiter, deps.Logger, deps.Config.Auth.LoginRateLimit))
+			r.Get("/v1/sso/authorize/{providerID}", deps.SSOHandler.Authorize)
+			// The callback is a browser redirect, bound to its login by the
+			// state and nonce. Providers with a webhook secret, and all of
+			// them with WEBHOOK_REQUIRE_SIGNED_SSO_CALLBACKS, must sign it.
+			r.With(middleware.VerifySignature(deps.Config.Webhooks.Secrets, "providerID", deps.Config.Webhooks.SignatureTolerance, deps.Config.Webhooks.RequireSignedSSOCallbacks, deps.Logger)).
+				Get("/v1/sso/callback/{providerID}", deps.SSOHandler.Callback)
+			r.Post("/v1/sso/logout", deps.SSOHandler.Logout)
+			r.Post("/v1/sso/refresh", deps.SSOHandler.RefreshSession)
+		})
+	}
+
+	// Inbound callbacks from integrations (no auth required - verified by
+	// signature against the integration's shared secret)
+	if deps.AlertHandler != nil {
+		r.With(middleware.VerifySignature(deps.Config.Webhooks.Secrets, "integration", deps.Config.Webhooks.SignatureTolerance, true, deps.Logger)).
+			Post("/v1/webhooks/{integration}", deps.AlertHandler.InboundCallback)
+	}
+
+	// Create endpoints accept an Idempotency-Key so retries don't create
+	// duplicates
+	idempotent := func(next http.Handler) http.Handler { return next }
+	if deps.IdempotencyStore != nil {
+		idempotent = middleware.Idempotency(deps.IdempotencyStore, deps.Logger)
 	}
 
 	// API v1 routes
 	r.Route("/v1", func(r chi.Router) {
+		// Every API route acts for the caller's org, resolved here from
+		// the API key or session. Unauthenticated requests are served as
+		// the demo org only in demo mode.
+		r.Use(middleware.Authenticate(deps.AuthStore, deps.AuditLogger, deps.Logger, deps.Config.Server.DemoMode))
+
 		// MCP routes (require authentication)
 		r.Route("/mcp/{server}", func(r chi.Router) {
-			r.Use(middleware.Auth(deps.AuthStore, deps.Logger))        // Authentication
-			r.Use(middleware.RateLimit(deps.RateLimiter, deps.Logger)) // Rate limiting
+			r.Use(middleware.Auth(deps.AuthStore, deps.AuditLogger, deps.Logger))                                                          // Authentication
+			r.Use(middleware.RateLimit(deps.RateLimiter, deps.Logger, deps.Config.DefaultRateLimit, rateLimitAllowlist, deps.AuditLogger)) // Rate limiting
 			if deps.InjectionDetector != nil {
 				r.Use(middleware.Injection(deps.InjectionDetector, deps.Logger)) // Prompt injection detection
 			}
@@ -103,39 +191,47 @@ func New(deps Dependencies) http.Handler {
 			// Prompts
 			r.Post("/prompts/get", deps.MCPHandler.PromptsGet)
 			r.Post("/prompts/list", deps.MCPHandler.PromptsList)
+			r.Get("/prompts", deps.MCPHandler.Prompts)
 		})
 
-		// Dashboard metrics (public for demo - in production, add auth)
+		// Dashboard metrics
 		r.Route("/metrics", func(r chi.Router) {
-			// NOTE: Auth disabled for demo. Enable for production:
-			// r.Use(middleware.Auth(deps.AuthStore, deps.Logger))
-
 			r.Get("/overview", deps.MetricsHandler.Overview)
 			r.Get("/requests-chart", deps.MetricsHandler.RequestsChart)
 			r.Get("/top-servers", deps.MetricsHandler.TopServers)
 			r.Get("/recent-traces", deps.MetricsHandler.RecentTraces)
+			r.Get("/latency", deps.MetricsHandler.Latency)
 		})
 
-		// Traces - public for demo
+		// Aggregate dashboard stats
+		if deps.StatsHandler != nil {
+			r.Get("/stats", deps.StatsHandler.Get)
+		}
+
+		// Remaining quota of the calling API key
+		if deps.QuotaHandler != nil {
+			r.With(middleware.Auth(deps.AuthStore, deps.AuditLogger, deps.Logger)).
+				Get("/me/quota", deps.QuotaHandler.Get)
+		}
+
+		// Traces
 		r.Route("/traces", func(r chi.Router) {
-			// NOTE: Auth disabled for demo
 			r.Get("/", deps.TraceHandler.List)
 			r.Get("/stats", deps.TraceHandler.Stats)
 			r.Get("/{traceID}", deps.TraceHandler.Get)
 		})
 
-		// Costs - public for demo
+		// Costs
 		r.Route("/costs", func(r chi.Router) {
-			// NOTE: Auth disabled for demo
 			r.Get("/summary", deps.CostHandler.Summary)
 			r.Get("/by-team", deps.CostHandler.ByTeam)
 			r.Get("/by-server", deps.CostHandler.ByServer)
 			r.Get("/daily", deps.CostHandler.Daily)
+			r.Get("/report", deps.CostHandler.Report)
 		})
 
-		// API Keys - public for demo
+		// API Keys
 		r.Route("/api-keys", func(r chi.Router) {
-			// NOTE: Auth disabled for demo
 			r.Get("/", deps.APIKeyHandler.List)
 			r.Post("/", deps.APIKeyHandler.Create)
 			r.Get("/{keyID}", deps.APIKeyHandler.Get)
@@ -143,68 +239,129 @@ func New(deps Dependencies) http.Handler {
 			r.Post("/{keyID}/rotate", deps.APIKeyHandler.Rotate)
 		})
 
-		// Safety policies and detection - public for demo
+		// Safety policies and detection
 		if deps.SafetyHandler != nil {
 			r.Route("/safety", func(r chi.Router) {
 				// Policies
 				r.Get("/policies", deps.SafetyHandler.ListPolicies)
-				r.Post("/policies", deps.SafetyHandler.CreatePolicy)
+				r.With(idempotent).Post("/policies", deps.SafetyHandler.CreatePolicy)
 				r.Get("/policies/{policyID}", deps.SafetyHandler.GetPolicy)
 				r.Put("/policies/{policyID}", deps.SafetyHandler.UpdatePolicy)
 				r.Delete("/policies/{policyID}", deps.SafetyHandler.DeletePolicy)
+				r.Post("/policies/{policyID}/restore", deps.SafetyHandler.RestorePolicy)
+
+				// Pattern libraries referenced by policies
+				r.Get("/patterns", deps.SafetyHandler.ListLibraries)
+				r.With(idempotent).Post("/patterns", deps.SafetyHandler.CreateLibrary)
+				r.Get("/patterns/{libraryID}", deps.SafetyHandler.GetLibrary)
+				r.Put("/patterns/{libraryID}", deps.SafetyHandler.UpdateLibrary)
+				r.Delete("/patterns/{libraryID}", deps.SafetyHandler.DeleteLibrary)
 
 				// Detection testing
 				r.Post("/test", deps.SafetyHandler.TestInput)
+				r.Post("/explain", deps.SafetyHandler.ExplainInput)
 
 				// Detections
 				r.Get("/detections", deps.SafetyHandler.ListDetections)
+				r.Get("/detections/stream", deps.SafetyHandler.StreamDetections)
 				r.Get("/summary", deps.SafetyHandler.GetSummary)
+				r.Get("/offenders", deps.SafetyHandler.ListOffenders)
+				r.With(middleware.RequirePermission(domain.PermissionPoliciesRead)).
+					Get("/offenders/top", deps.SafetyHandler.TopOffenders)
 			})
 		}
 
-		// Audit logs - public for demo
+		// Audit logs - require an API key with audit:read
 		if deps.AuditHandler != nil {
 			r.Route("/audit-logs", func(r chi.Router) {
+				r.Use(middleware.Auth(deps.AuthStore, deps.AuditLogger, deps.Logger))
+				r.Use(middleware.RequirePermission(domain.PermissionAuditRead))
+
 				r.Get("/", deps.AuditHandler.List)
 				r.Get("/search", deps.AuditHandler.Search)
-				r.Get("/export", deps.AuditHandler.Export)
+				r.With(middleware.RequirePermission(domain.PermissionAuditExport)).Get("/export", deps.AuditHandler.Export)
 				r.Get("/stats", deps.AuditHandler.Stats)
+				r.Get("/verify", deps.AuditHandler.Verify)
 				r.Get("/{logID}", deps.AuditHandler.Get)
 			})
 		}
 
-		// Alerts - public for demo
+		// Compliance reports - require an API key with audit:read and
+		// audit:export
+		if deps.ComplianceHandler != nil {
+			r.With(
+				middleware.Auth(deps.AuthStore, deps.AuditLogger, deps.Logger),
+				middleware.RequirePermission(domain.PermissionAuditRead),
+				middleware.RequirePermission(domain.PermissionAuditExport),
+			).Get("/compliance/report", deps.ComplianceHandler.Report)
+		}
+
+		// Budgets - changing a spend cap requires settings:admin
+		if deps.BudgetHandler != nil {
+			r.Route("/budgets", func(r chi.Router) {
+				r.Get("/", deps.BudgetHandler.ListBudgets)
+				r.Get("/{budgetID}", deps.BudgetHandler.GetBudget)
+
+				r.Group(func(r chi.Router) {
+					r.Use(middleware.RequirePermission(domain.PermissionSettingsAdmin))
+
+					r.With(idempotent).Post("/", deps.BudgetHandler.CreateBudget)
+					r.Put("/{budgetID}", deps.BudgetHandler.UpdateBudget)
+					r.Delete("/{budgetID}", deps.BudgetHandler.DeleteBudget)
+				})
+			})
+		}
+
+		// Alerts
 		if deps.AlertHandler != nil {
 			r.Route("/alerts", func(r chi.Router) {
 				// Alerts
 				r.Get("/", deps.AlertHandler.ListAlerts)
 				r.Get("/active", deps.AlertHandler.GetActiveAlerts)
+				r.Get("/stream", deps.AlertHandler.StreamAlerts)
 				r.Post("/test", deps.AlertHandler.TriggerTestAlert)
 				r.Post("/{alertID}/acknowledge", deps.AlertHandler.AcknowledgeAlert)
 				r.Post("/{alertID}/resolve", deps.AlertHandler.ResolveAlert)
+				r.Get("/{alertID}/events", deps.AlertHandler.GetAlertHistory)
 
 				// Rules
 				r.Route("/rules", func(r chi.Router) {
 					r.Get("/", deps.AlertHandler.ListRules)
-					r.Post("/", deps.AlertHandler.CreateRule)
+					r.With(idempotent).Post("/", deps.AlertHandler.CreateRule)
 					r.Get("/{ruleID}", deps.AlertHandler.GetRule)
 					r.Put("/{ruleID}", deps.AlertHandler.UpdateRule)
 					r.Delete("/{ruleID}", deps.AlertHandler.DeleteRule)
+					r.Post("/{ruleID}/restore", deps.AlertHandler.RestoreRule)
+					r.Post("/{ruleID}/test-fire", deps.AlertHandler.TestFireRule)
 				})
 
 				// Channels
 				r.Route("/channels", func(r chi.Router) {
 					r.Get("/", deps.AlertHandler.ListChannels)
-					r.Post("/", deps.AlertHandler.CreateChannel)
+					r.With(idempotent).Post("/", deps.AlertHandler.CreateChannel)
 					r.Get("/{channelID}", deps.AlertHandler.GetChannel)
 					r.Put("/{channelID}", deps.AlertHandler.UpdateChannel)
 					r.Delete("/{channelID}", deps.AlertHandler.DeleteChannel)
 					r.Post("/{channelID}/test", deps.AlertHandler.TestChannel)
 				})
+
+				// Inhibit rules - silencing alerts requires alerts:admin
+				r.Route("/inhibit-rules", func(r chi.Router) {
+					r.Get("/", deps.AlertHandler.ListInhibitRules)
+					r.Get("/{inhibitRuleID}", deps.AlertHandler.GetInhibitRule)
+
+					r.Group(func(r chi.Router) {
+						r.Use(middleware.RequirePermission(domain.PermissionAlertsAdmin))
+
+						r.With(idempotent).Post("/", deps.AlertHandler.CreateInhibitRule)
+						r.Put("/{inhibitRuleID}", deps.AlertHandler.UpdateInhibitRule)
+						r.Delete("/{inhibitRuleID}", deps.AlertHandler.DeleteInhibitRule)
+					})
+				})
 			})
 		}
 
-		// Telemetry / OpenTelemetry Export - public for demo
+		// Telemetry / OpenTelemetry Export
 		if deps.TelemetryHandler != nil {
 			r.Route("/telemetry", func(r chi.Router) {
 				// Exporters info
@@ -227,17 +384,25 @@ func New(deps Dependencies) http.Handler {
 			})
 		}
 
-		// Tool Approvals - public for demo
+		// Tool Approvals
 		if deps.ApprovalHandler != nil {
 			r.Route("/approvals", func(r chi.Router) {
 				// Approval requests
 				r.Get("/", deps.ApprovalHandler.ListApprovals)
-				r.Post("/", deps.ApprovalHandler.RequestApproval)
+				r.With(idempotent).Post("/", deps.ApprovalHandler.RequestApproval)
 				r.Get("/pending-count", deps.ApprovalHandler.GetPendingCount)
+				r.Post("/bulk-review", deps.ApprovalHandler.BulkReviewApprovals)
 				r.Get("/{approvalID}", deps.ApprovalHandler.GetApproval)
 				r.Post("/{approvalID}/approve", deps.ApprovalHandler.ApproveRequest)
 				r.Post("/{approvalID}/deny", deps.ApprovalHandler.DenyRequest)
 
+				// Replaying an approved call executes it, so it is authenticated
+				// and rate limited like the MCP routes
+				r.With(
+					middleware.Auth(deps.AuthStore, deps.AuditLogger, deps.Logger),
+					middleware.RateLimit(deps.RateLimiter, deps.Logger, deps.Config.DefaultRateLimit, rateLimitAllowlist, deps.AuditLogger),
+				).Post("/{approvalID}/replay", deps.ApprovalHandler.ReplayApproval)
+
 				// Access check
 				r.Get("/check-access", deps.ApprovalHandler.CheckAccess)
 			})
@@ -245,6 +410,7 @@ func New(deps Dependencies) http.Handler {
 			r.Route("/tool-classifications", func(r chi.Router) {
 				r.Get("/", deps.ApprovalHandler.ListClassifications)
 				r.Post("/", deps.ApprovalHandler.SetClassification)
+				r.Post("/bulk", deps.ApprovalHandler.BulkSetClassifications)
 				r.Get("/{server}/{tool}", deps.ApprovalHandler.GetClassification)
 				r.Delete("/{server}/{tool}", deps.ApprovalHandler.DeleteClassification)
 			})
@@ -256,7 +422,7 @@ func New(deps Dependencies) http.Handler {
 			})
 		}
 
-		// RBAC - Role-Based Access Control - public for demo
+		// RBAC - Role-Based Access Control
 		if deps.RBACHandler != nil {
 			r.Route("/rbac", func(r chi.Router) {
 				// Permissions info
@@ -264,26 +430,36 @@ func New(deps Dependencies) http.Handler {
 				r.Get("/permissions/check", deps.RBACHandler.CheckPermission)
 				r.Get("/me", deps.RBACHandler.GetMyPermissions)
 
-				// Roles
+				// Roles - changing roles or who holds them requires rbac:admin
 				r.Route("/roles", func(r chi.Router) {
 					r.Get("/", deps.RBACHandler.ListRoles)
-					r.Post("/", deps.RBACHandler.CreateRole)
 					r.Get("/{roleID}", deps.RBACHandler.GetRole)
-					r.Put("/{roleID}", deps.RBACHandler.UpdateRole)
-					r.Delete("/{roleID}", deps.RBACHandler.DeleteRole)
 					r.Get("/{roleID}/users", deps.RBACHandler.GetRoleUsers)
+
+					r.Group(func(r chi.Router) {
+						r.Use(middleware.RequirePermission(domain.PermissionRBACAdmin))
+
+						r.With(idempotent).Post("/", deps.RBACHandler.CreateRole)
+						r.Put("/{roleID}", deps.RBACHandler.UpdateRole)
+						r.Delete("/{roleID}", deps.RBACHandler.DeleteRole)
+					})
 				})
 
 				// User role assignments
 				r.Route("/users/{userID}/roles", func(r chi.Router) {
 					r.Get("/", deps.RBACHandler.GetUserRoles)
-					r.Post("/", deps.RBACHandler.AssignRole)
-					r.Delete("/{assignmentID}", deps.RBACHandler.RevokeRole)
+
+					r.Group(func(r chi.Router) {
+						r.Use(middleware.RequirePermission(domain.PermissionRBACAdmin))
+
+						r.Post("/", deps.RBACHandler.AssignRole)
+						r.Delete("/{assignmentID}", deps.RBACHandler.RevokeRole)
+					})
 				})
 			})
 		}
 
-		// Users - public for demo
+		// Users
 		if deps.UserHandler != nil {
 			r.Route("/users", func(r chi.Router) {
 				r.Get("/", deps.UserHandler.ListUsers)
@@ -299,21 +475,28 @@ func New(deps Dependencies) http.Handler {
 			})
 		}
 
-		// SSO Provider Management - public for demo
+		// SSO Provider Management
 		if deps.SSOHandler != nil {
 			r.Route("/sso", func(r chi.Router) {
 				// Provider info
 				r.Get("/providers/supported", deps.SSOHandler.GetSupportedProviders)
 				r.Get("/stats", deps.SSOHandler.GetStats)
+				r.With(middleware.RequirePermission(domain.PermissionSettingsAdmin)).Post("/secrets/rotate", deps.SSOHandler.RotateSecretsKey)
 
-				// Provider management
+				// Provider management - changing how users sign in requires
+				// settings:admin
 				r.Route("/providers", func(r chi.Router) {
 					r.Get("/", deps.SSOHandler.ListProviders)
-					r.Post("/", deps.SSOHandler.CreateProvider)
 					r.Get("/{providerID}", deps.SSOHandler.GetProvider)
-					r.Put("/{providerID}", deps.SSOHandler.UpdateProvider)
-					r.Delete("/{providerID}", deps.SSOHandler.DeleteProvider)
-					r.Post("/{providerID}/test", deps.SSOHandler.TestConnection)
+
+					r.Group(func(r chi.Router) {
+						r.Use(middleware.RequirePermission(domain.PermissionSettingsAdmin))
+
+						r.With(idempotent).Post("/", deps.SSOHandler.CreateProvider)
+						r.Put("/{providerID}", deps.SSOHandler.UpdateProvider)
+						r.Delete("/{providerID}", deps.SSOHandler.DeleteProvider)
+						r.Post("/{providerID}/test", deps.SSOHandler.TestConnection)
+					})
 				})
 
 				// Session management
@@ -325,15 +508,56 @@ func New(deps Dependencies) http.Handler {
 			})
 		}
 
-		// Settings - public for demo
+		// Gateway configuration - require settings:admin, as a reload
+		// changes settings for every organization
+		if deps.ConfigHandler != nil {
+			r.Route("/admin/config", func(r chi.Router) {
+				r.Use(middleware.RequirePermission(domain.PermissionSettingsAdmin))
+
+				r.Post("/validate", deps.ConfigHandler.Validate)
+				r.Post("/reload", deps.ConfigHandler.Reload)
+			})
+		}
+
+		// Encryption key rotation for stored secrets
+		if deps.SecretsHandler != nil {
+			r.With(middleware.RequirePermission(domain.PermissionSettingsAdmin)).
+				Post("/admin/secrets/rotate", deps.SecretsHandler.RotateKey)
+		}
+
+		// MCP servers registered at runtime - require settings:admin
+		if deps.MCPServerHandler != nil {
+			r.Route("/admin/mcp-servers", func(r chi.Router) {
+				r.Use(middleware.RequirePermission(domain.PermissionSettingsAdmin))
+
+				r.Get("/", deps.MCPServerHandler.ListServers)
+				r.With(idempotent).Post("/", deps.MCPServerHandler.RegisterServer)
+				r.Get("/{name}", deps.MCPServerHandler.GetServer)
+				r.Put("/{name}", deps.MCPServerHandler.UpdateServer)
+				r.Delete("/{name}", deps.MCPServerHandler.RemoveServer)
+			})
+		}
+
+		// Settings
 		if deps.SettingsHandler != nil {
 			r.Route("/settings", func(r chi.Router) {
 				r.Get("/", deps.SettingsHandler.GetSettings)
 				r.Put("/", deps.SettingsHandler.UpdateSettings)
+
+				// Typed settings - require an API key with settings:admin
+				r.Route("/keys", func(r chi.Router) {
+					r.Use(middleware.Auth(deps.AuthStore, deps.AuditLogger, deps.Logger))
+					r.Use(middleware.RequirePermission(domain.PermissionSettingsAdmin))
+
+					r.Get("/", deps.SettingsHandler.ListSettingValues)
+					r.Get("/{key}", deps.SettingsHandler.GetSettingValue)
+					r.Put("/{key}", deps.SettingsHandler.SetSettingValue)
+					r.Delete("/{key}", deps.SettingsHandler.ResetSettingValue)
+				})
 			})
 		}
 
-		// Agent Platform API - public for demo
+		// Agent Platform API
 		if deps.AgentHandler != nil {
 			r.Route("/agents", func(r chi.Router) {
 				// Connection management
@@ -344,6 +568,12 @@ func New(deps Dependencies) http.Handler {
 
 				// WebSocket upgrade (handled specially)
 				r.Get("/{connectionID}/ws", deps.AgentHandler.WebSocket)
+
+				// Resource subscriptions and event stream for non-WebSocket agents
+				r.Get("/{connectionID}/events", deps.AgentHandler.Events)
+				r.Get("/{connectionID}/subscriptions", deps.AgentHandler.ListSubscriptions)
+				r.Post("/{connectionID}/subscriptions", deps.AgentHandler.Subscribe)
+				r.Delete("/{connectionID}/subscriptions/{subscriptionID}", deps.AgentHandler.Unsubscribe)
 			})
 
 			// Universal execution endpoint
@@ -353,16 +583,23 @@ func New(deps Dependencies) http.Handler {
 			// OpenAI/LangChain compatible MCP endpoint
 			r.Get("/mcp/tools", deps.AgentHandler.ListTools)
 		}
+
+		// OpenAI-compatible tool execution (requires authentication); each
+		// tool call is rate limited and checked like an MCP tool call
+		if deps.OpenAIHandler != nil {
+			r.With(middleware.Auth(deps.AuthStore, deps.AuditLogger, deps.Logger)).
+				Post("/chat/completions", deps.OpenAIHandler.ChatCompletions)
+		}
 	})
 
 	// 404 handler
 	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
-		handler.WriteError(w, http.StatusNotFound, "not_found", "The requested resource was not found")
+		handler.WriteError(w, http.StatusNotFound, response.CodeNotFound, "The requested resource was not found")
 	})
 
 	// 405 handler
 	r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
-		handler.WriteError(w, http.StatusMethodNotAllowed, "method_not_allowed", "The requested method is not allowed")
+		handler.WriteError(w, http.StatusMethodNotAllowed, response.CodeMethodNotAllowed, "The requested method is not allowed")
 	})
 
 	return r
@@ -0,0 +1,240 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/clock"
+	"github.com/akz4ol/gatewayops/gateway/internal/config"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/handler"
+	"github.com/akz4ol/gatewayops/gateway/internal/mcpserver"
+	"github.com/akz4ol/gatewayops/gateway/internal/middleware"
+	"github.com/akz4ol/gatewayops/gateway/internal/repository"
+	"github.com/akz4ol/gatewayops/gateway/internal/sso"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// newTestRouter builds the router with the default configuration and no
+// handlers, which is enough to exercise the global middleware.
+func newTestRouter(t *testing.T) http.Handler {
+	t.Helper()
+	cfg, err := config.LoadFrom(func(string) string { return "" })
+	if err != nil {
+		t.Fatalf("LoadFrom: %v", err)
+	}
+	return New(Dependencies{Config: cfg, Logger: zerolog.Nop()})
+}
+
+func TestPreflightAllowsDashboardHeaders(t *testing.T) {
+	h := newTestRouter(t)
+
+	tests := []struct {
+		path   string
+		method string
+		header string
+	}{
+		{"/v1/rbac/me", http.MethodGet, "X-Session-Token"},
+		{"/v1/agents/execute", http.MethodPost, "X-Dry-Run"},
+		{"/v1/mcp/filesystem/tools/call", http.MethodPost, "Idempotency-Key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodOptions, tt.path, nil)
+			req.Header.Set("Origin", "http://localhost:3000")
+			req.Header.Set("Access-Control-Request-Method", tt.method)
+			req.Header.Set("Access-Control-Request-Headers", strings.ToLower(tt.header))
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("preflight status = %d, want %d", rec.Code, http.StatusOK)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "http://localhost:3000" {
+				t.Errorf("Access-Control-Allow-Origin = %q", got)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Headers"); !strings.Contains(strings.ToLower(got), strings.ToLower(tt.header)) {
+				t.Errorf("Access-Control-Allow-Headers = %q, want it to allow %s", got, tt.header)
+			}
+		})
+	}
+}
+
+const testAPIKey = "gwo_prd_0123456789abcdef0123456789abcdef"
+
+// keyStore authenticates testAPIKey as a key of orgID with permissions.
+type keyStore struct {
+	orgID       uuid.UUID
+	permissions []string
+}
+
+func (s *keyStore) ValidateAPIKey(ctx context.Context, apiKey string) (*middleware.AuthInfo, error) {
+	if apiKey != testAPIKey {
+		return nil, errors.New("invalid API key")
+	}
+	return &middleware.AuthInfo{OrgID: s.orgID, UserID: uuid.New(), Permissions: s.permissions}, nil
+}
+
+func (s *keyStore) ValidateSession(ctx context.Context, token string) (*middleware.AuthInfo, error) {
+	return nil, errors.New("invalid session")
+}
+
+// serve sends a request, with testAPIKey when keyed, and returns the
+// response.
+func serve(h http.Handler, method, path string, body string, keyed bool) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if keyed {
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAdminRoutesRequirePermission(t *testing.T) {
+	cfg, err := config.LoadFrom(func(string) string { return "" })
+	if err != nil {
+		t.Fatalf("LoadFrom: %v", err)
+	}
+	registry := mcpserver.NewRegistry(cfg, repository.NewMCPServerRepository(nil), nil, nil, zerolog.Nop())
+	deps := Dependencies{
+		Config:           cfg,
+		Logger:           zerolog.Nop(),
+		MCPServerHandler: handler.NewMCPServerHandler(zerolog.Nop(), registry, nil),
+		// Never reached
+		ConfigHandler: &handler.ConfigHandler{},
+		BudgetHandler: &handler.BudgetHandler{},
+		AlertHandler:  &handler.AlertHandler{},
+		RBACHandler:   &handler.RBACHandler{},
+		SSOHandler:    &handler.SSOHandler{},
+	}
+
+	routes := []struct {
+		method string
+		path   string
+		perm   domain.Permission
+	}{
+		{http.MethodGet, "/v1/admin/mcp-servers", domain.PermissionSettingsAdmin},
+		{http.MethodPost, "/v1/admin/mcp-servers", domain.PermissionSettingsAdmin},
+		{http.MethodGet, "/v1/admin/mcp-servers/search", domain.PermissionSettingsAdmin},
+		{http.MethodPut, "/v1/admin/mcp-servers/search", domain.PermissionSettingsAdmin},
+		{http.MethodDelete, "/v1/admin/mcp-servers/search", domain.PermissionSettingsAdmin},
+		{http.MethodPost, "/v1/admin/config/validate", domain.PermissionSettingsAdmin},
+		{http.MethodPost, "/v1/admin/config/reload", domain.PermissionSettingsAdmin},
+		{http.MethodPost, "/v1/budgets", domain.PermissionSettingsAdmin},
+		{http.MethodPut, "/v1/budgets/" + uuid.NewString(), domain.PermissionSettingsAdmin},
+		{http.MethodDelete, "/v1/budgets/" + uuid.NewString(), domain.PermissionSettingsAdmin},
+		{http.MethodPost, "/v1/alerts/inhibit-rules", domain.PermissionAlertsAdmin},
+		{http.MethodPut, "/v1/alerts/inhibit-rules/" + uuid.NewString(), domain.PermissionAlertsAdmin},
+		{http.MethodDelete, "/v1/alerts/inhibit-rules/" + uuid.NewString(), domain.PermissionAlertsAdmin},
+		{http.MethodPost, "/v1/rbac/roles", domain.PermissionRBACAdmin},
+		{http.MethodPut, "/v1/rbac/roles/" + uuid.NewString(), domain.PermissionRBACAdmin},
+		{http.MethodDelete, "/v1/rbac/roles/" + uuid.NewString(), domain.PermissionRBACAdmin},
+		{http.MethodPost, "/v1/rbac/users/" + uuid.NewString() + "/roles", domain.PermissionRBACAdmin},
+		{http.MethodDelete, "/v1/rbac/users/" + uuid.NewString() + "/roles/" + uuid.NewString(), domain.PermissionRBACAdmin},
+		{http.MethodPost, "/v1/sso/providers", domain.PermissionSettingsAdmin},
+		{http.MethodPut, "/v1/sso/providers/" + uuid.NewString(), domain.PermissionSettingsAdmin},
+		{http.MethodDelete, "/v1/sso/providers/" + uuid.NewString(), domain.PermissionSettingsAdmin},
+		{http.MethodPost, "/v1/sso/providers/" + uuid.NewString() + "/test", domain.PermissionSettingsAdmin},
+	}
+
+	admin := []domain.Permission{domain.PermissionSettingsAdmin, domain.PermissionAlertsAdmin, domain.PermissionRBACAdmin}
+	for _, route := range routes {
+		// Every other admin permission, but not the one the route needs
+		permissions := []string{string(domain.PermissionMCPCall), string(domain.PermissionSettingsRead)}
+		for _, perm := range admin {
+			if perm != route.perm {
+				permissions = append(permissions, string(perm))
+			}
+		}
+		deps.AuthStore = &keyStore{orgID: uuid.New(), permissions: permissions}
+		h := New(deps)
+		if rec := serve(h, route.method, route.path, "{}", true); rec.Code != http.StatusForbidden {
+			t.Errorf("%s %s without %s: status %d, want 403", route.method, route.path, route.perm, rec.Code)
+		}
+		if rec := serve(h, route.method, route.path, "{}", false); rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s %s without a key: status %d, want 401", route.method, route.path, rec.Code)
+		}
+	}
+
+	deps.AuthStore = &keyStore{orgID: uuid.New(), permissions: []string{string(domain.PermissionSettingsAdmin)}}
+	if rec := serve(New(deps), http.MethodGet, "/v1/admin/mcp-servers", "", true); rec.Code != http.StatusOK {
+		t.Errorf("listing MCP servers with settings:admin: status %d", rec.Code)
+	}
+}
+
+// allowAll lets every request through the rate limit.
+type allowAll struct{}
+
+func (allowAll) Allow(ctx context.Context, key string, limit int) (bool, int, int, error) {
+	return true, limit, 0, nil
+}
+
+func TestSSOCallbackSignature(t *testing.T) {
+	signed, unsigned := uuid.NewString(), uuid.NewString()
+	cfg, err := config.LoadFrom(func(key string) string {
+		if key == "WEBHOOK_SECRETS" {
+			return `{"` + signed + `":"s3cret"}`
+		}
+		return ""
+	})
+	if err != nil {
+		t.Fatalf("LoadFrom: %v", err)
+	}
+	if cfg.Webhooks.RequireSignedSSOCallbacks {
+		t.Fatal("SSO callbacks from providers without a secret are refused by default")
+	}
+	service := sso.NewService(zerolog.Nop(), []byte("0123456789abcdef0123456789abcdef"), clock.Real, nil, nil, true)
+	deps := Dependencies{
+		Config:      cfg,
+		Logger:      zerolog.Nop(),
+		RateLimiter: allowAll{},
+		SSOHandler:  handler.NewSSOHandler(zerolog.Nop(), service, "", nil, nil, nil),
+	}
+
+	// callback sends the browser redirect from the provider, signed over its
+	// query string with secret unless that is empty
+	callback := func(h http.Handler, providerID, secret string) int {
+		query := "code=abc&state=unknown"
+		req := httptest.NewRequest(http.MethodGet, "/v1/sso/callback/"+providerID+"?"+query, nil)
+		req.Header.Set("Accept", "application/json")
+		if secret != "" {
+			timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+			req.Header.Set(middleware.SignatureHeader, middleware.SignCallback(secret, timestamp, []byte(query)))
+			req.Header.Set(middleware.SignatureTimestampHeader, timestamp)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Past the signature check, the handler refuses the unknown login state
+	// with 400
+	h := New(deps)
+	if code := callback(h, signed, ""); code != http.StatusUnauthorized {
+		t.Errorf("unsigned callback from a provider with a secret: status %d, want 401", code)
+	}
+	if code := callback(h, signed, "guess"); code != http.StatusUnauthorized {
+		t.Errorf("callback signed with the wrong secret: status %d, want 401", code)
+	}
+	if code := callback(h, signed, "s3cret"); code != http.StatusBadRequest {
+		t.Errorf("signed callback: status %d, want it to reach the handler", code)
+	}
+	if code := callback(h, unsigned, ""); code != http.StatusBadRequest {
+		t.Errorf("browser redirect from a provider without a secret: status %d, want it to reach the handler", code)
+	}
+
+	cfg.Webhooks.RequireSignedSSOCallbacks = true
+	if code := callback(New(deps), unsigned, ""); code != http.StatusUnauthorized {
+		t.Errorf("callback from a provider without a secret when signatures are required: status %d, want 401", code)
+	}
+}
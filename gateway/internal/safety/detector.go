@@ -3,41 +3,81 @@ package safety
 
 import (
 	"context"
+	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/akz4ol/gatewayops/gateway/internal/alerting"
 	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/redact"
 	"github.com/akz4ol/gatewayops/gateway/internal/repository"
+	"github.com/akz4ol/gatewayops/gateway/internal/ringbuf"
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 )
 
 // Detector implements prompt injection detection.
 type Detector struct {
-	logger      zerolog.Logger
-	repo        *repository.SafetyRepository
-	policies    map[uuid.UUID]*domain.SafetyPolicy
-	mu          sync.RWMutex
-	detections  []domain.InjectionDetection
-	detectionMu sync.RWMutex
+	logger        zerolog.Logger
+	repo          repository.SafetyStore
+	policies      map[uuid.UUID]*domain.SafetyPolicy
+	deleted       map[uuid.UUID]*domain.SafetyPolicy // Soft-deleted, kept for restore
+	libraries     map[uuid.UUID]*domain.PatternLibrary
+	mu            sync.RWMutex
+	detections    []domain.InjectionDetection
+	detectionRing *ringbuf.Ring
+	detectionMu   sync.RWMutex
+
+	// Running totals by severity; unlike detections these are never trimmed
+	severityCounts map[domain.DetectionSeverity]int64
+
+	// Repeat offender tracking, guarded by detectionMu
+	escalation EscalationPolicy
+	offenders  map[string]*offender
+	alerts     *alerting.Service
+
+	// Scrubs secrets from inputs before they are recorded
+	redactor *redact.Redactor
+
+	// Live subscribers (SSE streams)
+	subscribers map[chan domain.InjectionDetection]struct{}
+	subMu       sync.Mutex
 }
 
-// NewDetector creates a new injection detector.
-func NewDetector(logger zerolog.Logger, repo *repository.SafetyRepository) *Detector {
+// NewDetector creates a new injection detector that keeps up to bufferSize
+// recent detections in memory. Repeat offenders are escalated under
+// escalation and reported through alerts. Inputs are scrubbed by redactor
+// before detections are recorded.
+func NewDetector(logger zerolog.Logger, repo repository.SafetyStore, bufferSize int, escalation EscalationPolicy, alerts *alerting.Service, redactor *redact.Redactor) *Detector {
 	d := &Detector{
-		logger:     logger,
-		repo:       repo,
-		policies:   make(map[uuid.UUID]*domain.SafetyPolicy),
-		detections: make([]domain.InjectionDetection, 0),
+		logger:        logger,
+		repo:          repo,
+		policies:      make(map[uuid.UUID]*domain.SafetyPolicy),
+		deleted:       make(map[uuid.UUID]*domain.SafetyPolicy),
+		libraries:     make(map[uuid.UUID]*domain.PatternLibrary),
+		detections:    make([]domain.InjectionDetection, 0),
+		detectionRing: ringbuf.New(bufferSize),
+
+		severityCounts: make(map[domain.DetectionSeverity]int64),
+
+		escalation: escalation,
+		offenders:  make(map[string]*offender),
+		alerts:     alerts,
+
+		redactor: redactor,
+
+		subscribers: make(map[chan domain.InjectionDetection]struct{}),
 	}
 
 	// Load from database if available
 	if repo != nil {
 		d.loadFromDatabase()
 	} else {
-		// Create default policy
+		// Seed the default library and create the default policy over it
+		d.seedLibraries(defaultOrgID)
 		defaultPolicy := d.createDefaultPolicy()
 		d.policies[defaultPolicy.ID] = defaultPolicy
 	}
@@ -54,16 +94,46 @@ func (d *Detector) loadFromDatabase() {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	demoOrgID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
-	policies, err := d.repo.ListPolicies(ctx, demoOrgID, false)
+	// Load every organization's policies, falling back to the demo org when
+	// organizations cannot be listed
+	orgIDs, err := d.repo.ListOrgIDs(ctx)
 	if err != nil {
-		d.logger.Warn().Err(err).Msg("Failed to load safety policies from database")
-	} else {
+		d.logger.Warn().Err(err).Msg("Failed to list organizations")
+	}
+	if len(orgIDs) == 0 {
+		orgIDs = []uuid.UUID{defaultOrgID}
+	}
+
+	for _, orgID := range orgIDs {
+		libraries, err := d.repo.ListPatternLibraries(ctx, orgID)
+		if err != nil {
+			d.logger.Warn().Err(err).Str("org_id", orgID.String()).Msg("Failed to load pattern libraries from database")
+		} else if len(libraries) == 0 {
+			d.seedLibraries(orgID)
+		}
+		for i := range libraries {
+			d.libraries[libraries[i].ID] = &libraries[i]
+		}
+
+		policies, err := d.repo.ListPolicies(ctx, orgID, false)
+		if err != nil {
+			d.logger.Warn().Err(err).Str("org_id", orgID.String()).Msg("Failed to load safety policies from database")
+			continue
+		}
 		for i := range policies {
 			d.policies[policies[i].ID] = &policies[i]
 		}
-		d.logger.Info().Int("count", len(policies)).Msg("Loaded safety policies from database")
+
+		deleted, err := d.repo.ListDeletedPolicies(ctx, orgID)
+		if err != nil {
+			d.logger.Warn().Err(err).Str("org_id", orgID.String()).Msg("Failed to load deleted safety policies from database")
+			continue
+		}
+		for i := range deleted {
+			d.deleted[deleted[i].ID] = &deleted[i]
+		}
 	}
+	d.logger.Info().Int("count", len(d.policies)).Msg("Loaded safety policies from database")
 
 	// If no policies, create default
 	if len(d.policies) == 0 {
@@ -76,38 +146,89 @@ func (d *Detector) loadFromDatabase() {
 	}
 }
 
-// createDefaultPolicy creates the default safety policy.
+// defaultOrgID owns the default policy.
+var defaultOrgID = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+
+// DefaultPolicyID identifies the default policy, which applies when an org
+// has none of its own and cannot be deleted.
+var DefaultPolicyID = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+
+// seedLibraries gives an organization a library of the default patterns so
+// its policies can reference them rather than copy them.
+func (d *Detector) seedLibraries(orgID uuid.UUID) {
+	now := time.Now()
+	library := &domain.PatternLibrary{
+		ID:          uuid.New(),
+		OrgID:       orgID,
+		Name:        "Default injection patterns",
+		Description: "Common prompt injection and jailbreak phrases",
+		Category:    domain.PatternCategoryInjection,
+		Patterns: domain.SafetyPatterns{
+			Block: domain.DefaultBlockPatterns,
+			Allow: domain.DefaultAllowPatterns,
+		},
+		Version:   1,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if d.repo != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := d.repo.CreatePatternLibrary(ctx, library); err != nil {
+			d.logger.Warn().Err(err).Str("org_id", orgID.String()).Msg("Failed to persist default pattern library")
+		}
+	}
+	d.libraries[library.ID] = library
+}
+
+// createDefaultPolicy creates the default safety policy. It references the
+// default organization's seeded libraries, or inlines the default patterns
+// when there are none.
 func (d *Detector) createDefaultPolicy() *domain.SafetyPolicy {
+	patterns := domain.SafetyPatterns{
+		Block: domain.DefaultBlockPatterns,
+		Allow: domain.DefaultAllowPatterns,
+	}
+	var libraries []uuid.UUID
+	for id, library := range d.libraries {
+		if library.OrgID == defaultOrgID {
+			libraries = append(libraries, id)
+		}
+	}
+	if len(libraries) > 0 {
+		patterns = domain.SafetyPatterns{Libraries: libraries}
+	}
+
 	return &domain.SafetyPolicy{
-		ID:          uuid.MustParse("00000000-0000-0000-0000-000000000001"),
-		OrgID:       uuid.MustParse("00000000-0000-0000-0000-000000000001"),
+		ID:          DefaultPolicyID,
+		OrgID:       defaultOrgID,
 		Name:        "Default Policy",
 		Description: "Default prompt injection detection policy",
 		Sensitivity: domain.SafetySensitivityModerate,
 		Mode:        domain.SafetyModeBlock,
-		Patterns: domain.SafetyPatterns{
-			Block: domain.DefaultBlockPatterns,
-			Allow: domain.DefaultAllowPatterns,
-		},
-		Enabled:   true,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		Patterns:    patterns,
+		Enabled:     true,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
 	}
 }
 
-// Detect checks input for prompt injection attempts.
-func (d *Detector) Detect(input string, opts DetectOptions) domain.DetectionResult {
+// Detect checks input for prompt injection attempts. Enabled shadow policies
+// of the organization are evaluated alongside the enforcing policy; their
+// detections are recorded with the shadow action but never affect the
+// result.
+func (d *Detector) Detect(ctx context.Context, input string, opts DetectOptions) domain.DetectionResult {
 	d.mu.RLock()
 	defer d.mu.RUnlock()
 
-	// Get policy
-	var policy *domain.SafetyPolicy
-	if opts.PolicyID != nil {
-		policy = d.policies[*opts.PolicyID]
-	}
-	if policy == nil {
-		// Use default policy
-		policy = d.policies[uuid.MustParse("00000000-0000-0000-0000-000000000001")]
+	policy := d.policyFor(opts.OrgID, opts.PolicyID)
+
+	// Normalize input for comparison
+	normalizedInput := strings.ToLower(input)
+
+	if !opts.DryRun {
+		d.detectShadow(ctx, normalizedInput, policy, opts)
 	}
 
 	// Skip if policy is disabled
@@ -118,24 +239,69 @@ func (d *Detector) Detect(input string, opts DetectOptions) domain.DetectionResu
 		}
 	}
 
-	// Normalize input for comparison
-	normalizedInput := strings.ToLower(input)
+	result := d.evaluate(normalizedInput, policy)
+	if result.Detected && !opts.DryRun {
+		result = d.recordDetection(ctx, opts, result)
+	}
+	return result
+}
+
+// policyFor returns the policy applied to an organization's input: the
+// requested policy, or the default one. Another organization's policy is
+// never applied. The caller must hold d.mu.
+func (d *Detector) policyFor(orgID uuid.UUID, policyID *uuid.UUID) *domain.SafetyPolicy {
+	if policyID != nil {
+		if p := d.policies[*policyID]; p != nil && p.OrgID == orgID {
+			return p
+		}
+	}
+	return d.policies[DefaultPolicyID]
+}
+
+// detectShadow evaluates the organization's enabled shadow policies, other
+// than the enforcing one, and records what they detect. The caller must hold
+// d.mu.
+func (d *Detector) detectShadow(ctx context.Context, normalizedInput string, enforcing *domain.SafetyPolicy, opts DetectOptions) {
+	for _, policy := range d.policies {
+		if policy == enforcing || policy.OrgID != opts.OrgID || !policy.Enabled || policy.Mode != domain.SafetyModeShadow {
+			continue
+		}
+		if len(policy.MCPServers) > 0 && !containsString(policy.MCPServers, opts.MCPServer) {
+			continue
+		}
+		if result := d.evaluate(normalizedInput, policy); result.Detected {
+			shadowOpts := opts
+			shadowOpts.PolicyID = &policy.ID
+			d.recordDetection(ctx, shadowOpts, result)
+		}
+	}
+}
+
+// evaluate matches normalized input against a policy without recording
+// anything. The caller must hold d.mu.
+func (d *Detector) evaluate(normalizedInput string, policy *domain.SafetyPolicy) domain.DetectionResult {
+	sets := d.patternSets(policy)
 
 	// Check allow patterns first (these override blocks)
-	for _, pattern := range policy.Patterns.Allow {
-		if strings.Contains(normalizedInput, strings.ToLower(pattern)) {
-			return domain.DetectionResult{
-				Detected: false,
-				Action:   domain.SafetyModeLog,
-				Message:  "Input matched allow pattern",
+	for _, set := range sets {
+		for _, pattern := range set.patterns.Allow {
+			if strings.Contains(normalizedInput, strings.ToLower(pattern)) {
+				return domain.DetectionResult{
+					Detected: false,
+					Action:   domain.SafetyModeLog,
+					Message:  "Input matched allow pattern",
+				}
 			}
 		}
 	}
 
 	// Check block patterns
-	for _, pattern := range policy.Patterns.Block {
-		lowerPattern := strings.ToLower(pattern)
-		if strings.Contains(normalizedInput, lowerPattern) {
+	for _, set := range sets {
+		for _, pattern := range set.patterns.Block {
+			lowerPattern := strings.ToLower(pattern)
+			if !strings.Contains(normalizedInput, lowerPattern) {
+				continue
+			}
 			severity := d.determineSeverity(pattern, policy.Sensitivity)
 			result := domain.DetectionResult{
 				Detected:       true,
@@ -146,10 +312,14 @@ func (d *Detector) Detect(input string, opts DetectOptions) domain.DetectionResu
 				Action:         policy.Mode,
 				Message:        "Potential prompt injection detected",
 			}
-
-			// Record detection
-			d.recordDetection(opts, result)
-
+			if set.library != nil {
+				result.Type = set.library.Category.DetectionType()
+				result.LibraryID = &set.library.ID
+				result.LibraryVersion = set.library.Version
+				if result.Type != domain.DetectionTypePromptInjection {
+					result.Message = fmt.Sprintf("Input matched a %s pattern", set.library.Category)
+				}
+			}
 			return result
 		}
 	}
@@ -157,7 +327,6 @@ func (d *Detector) Detect(input string, opts DetectOptions) domain.DetectionResu
 	// Additional heuristic checks for moderate/strict sensitivity
 	if policy.Sensitivity != domain.SafetySensitivityPermissive {
 		if result := d.heuristicCheck(normalizedInput, policy); result.Detected {
-			d.recordDetection(opts, result)
 			return result
 		}
 	}
@@ -168,35 +337,72 @@ func (d *Detector) Detect(input string, opts DetectOptions) domain.DetectionResu
 	}
 }
 
+// requestLogger returns the request-scoped logger carried by ctx, so the
+// detector's logs carry the request ID, falling back to the detector's logger.
+func (d *Detector) requestLogger(ctx context.Context) zerolog.Logger {
+	if l := zerolog.Ctx(ctx); l.GetLevel() != zerolog.Disabled {
+		return *l
+	}
+	return d.logger
+}
+
+// patternSet is a policy's inline patterns or one of the libraries it
+// references.
+type patternSet struct {
+	patterns domain.SafetyPatterns
+	library  *domain.PatternLibrary // Nil for inline patterns
+}
+
+// patternSets returns the patterns a policy applies: its inline patterns,
+// then those of each library it references. References to libraries that no
+// longer exist are skipped. The caller must hold d.mu.
+func (d *Detector) patternSets(policy *domain.SafetyPolicy) []patternSet {
+	sets := []patternSet{{patterns: policy.Patterns}}
+	for _, id := range policy.Patterns.Libraries {
+		if library := d.libraries[id]; library != nil && library.OrgID == policy.OrgID {
+			sets = append(sets, patternSet{patterns: library.Patterns, library: library})
+		}
+	}
+	return sets
+}
+
+// heuristic is a regular expression for a common injection pattern, checked
+// at moderate and strict sensitivity.
+type heuristic struct {
+	pattern  string
+	re       *regexp.Regexp
+	severity domain.DetectionSeverity
+	message  string
+}
+
+func newHeuristic(pattern string, severity domain.DetectionSeverity, message string) heuristic {
+	return heuristic{pattern: pattern, re: regexp.MustCompile(pattern), severity: severity, message: message}
+}
+
+// heuristics are checked in order; the first that matches decides.
+var heuristics = []heuristic{
+	newHeuristic(`(?i)ignore\s+(all\s+)?(your|the|previous)\s+(instructions|rules|guidelines)`, domain.DetectionSeverityHigh, "Instruction override attempt"),
+	newHeuristic(`(?i)(you\s+are|you're)\s+(now|going\s+to\s+be)\s+a`, domain.DetectionSeverityMedium, "Role manipulation attempt"),
+	newHeuristic(`(?i)pretend\s+(to\s+be|that\s+you)`, domain.DetectionSeverityMedium, "Persona injection attempt"),
+	newHeuristic(`(?i)from\s+now\s+on`, domain.DetectionSeverityLow, "Behavioral modification attempt"),
+	newHeuristic(`(?i)\[\s*system\s*\]`, domain.DetectionSeverityHigh, "System prompt injection"),
+	newHeuristic(`(?i)<\s*system\s*>`, domain.DetectionSeverityHigh, "System tag injection"),
+	newHeuristic(`(?i)assistant:\s*\n`, domain.DetectionSeverityMedium, "Role tag injection"),
+	newHeuristic(`(?i)human:\s*\n`, domain.DetectionSeverityMedium, "Role tag injection"),
+}
+
 // heuristicCheck performs additional heuristic-based detection.
 func (d *Detector) heuristicCheck(input string, policy *domain.SafetyPolicy) domain.DetectionResult {
-	// Check for common injection patterns using regex
-	injectionPatterns := []struct {
-		pattern  string
-		severity domain.DetectionSeverity
-		message  string
-	}{
-		{`(?i)ignore\s+(all\s+)?(your|the|previous)\s+(instructions|rules|guidelines)`, domain.DetectionSeverityHigh, "Instruction override attempt"},
-		{`(?i)(you\s+are|you're)\s+(now|going\s+to\s+be)\s+a`, domain.DetectionSeverityMedium, "Role manipulation attempt"},
-		{`(?i)pretend\s+(to\s+be|that\s+you)`, domain.DetectionSeverityMedium, "Persona injection attempt"},
-		{`(?i)from\s+now\s+on`, domain.DetectionSeverityLow, "Behavioral modification attempt"},
-		{`(?i)\[\s*system\s*\]`, domain.DetectionSeverityHigh, "System prompt injection"},
-		{`(?i)<\s*system\s*>`, domain.DetectionSeverityHigh, "System tag injection"},
-		{`(?i)assistant:\s*\n`, domain.DetectionSeverityMedium, "Role tag injection"},
-		{`(?i)human:\s*\n`, domain.DetectionSeverityMedium, "Role tag injection"},
-	}
-
-	for _, p := range injectionPatterns {
-		matched, _ := regexp.MatchString(p.pattern, input)
-		if matched {
+	for _, h := range heuristics {
+		if h.re.MatchString(input) {
 			return domain.DetectionResult{
 				Detected:       true,
 				Type:           domain.DetectionTypePromptInjection,
-				Severity:       p.severity,
-				PatternMatched: p.pattern,
+				Severity:       h.severity,
+				PatternMatched: h.pattern,
 				Confidence:     0.75,
 				Action:         policy.Mode,
-				Message:        p.message,
+				Message:        h.message,
 			}
 		}
 	}
@@ -235,13 +441,23 @@ func (d *Detector) determineSeverity(pattern string, sensitivity domain.SafetySe
 	}
 }
 
-// recordDetection records a detection event.
-func (d *Detector) recordDetection(opts DetectOptions, result domain.DetectionResult) {
+// recordDetection records a detection event, first escalating it if the
+// caller is a repeat offender. Shadow detections are recorded as they are and
+// count towards neither escalation nor the severity totals. It returns the
+// result as recorded.
+func (d *Detector) recordDetection(ctx context.Context, opts DetectOptions, result domain.DetectionResult) domain.DetectionResult {
+	logger := d.requestLogger(ctx)
+
 	d.detectionMu.Lock()
 	defer d.detectionMu.Unlock()
 
-	// Truncate input for storage
-	inputTrunc := opts.Input
+	shadow := result.Action == domain.SafetyModeShadow
+	if !shadow {
+		result = d.escalate(ctx, opts, result)
+	}
+
+	// Redact and truncate input for storage
+	inputTrunc := d.redactor.Text(opts.MCPServer, opts.ToolName, opts.Input, opts.Arguments)
 	if len(inputTrunc) > 500 {
 		inputTrunc = inputTrunc[:500] + "..."
 	}
@@ -270,48 +486,102 @@ func (d *Detector) recordDetection(opts DetectOptions, result domain.DetectionRe
 			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 			defer cancel()
 			if err := d.repo.CreateDetection(ctx, &detection); err != nil {
-				d.logger.Error().Err(err).Msg("Failed to persist injection detection")
+				logger.Error().Err(err).Msg("Failed to persist injection detection")
 			}
 		}()
 	}
 
-	// Keep only last 1000 detections in memory (demo mode)
-	if len(d.detections) >= 1000 {
-		d.detections = d.detections[1:]
+	// Keep only the most recent detections in memory (demo mode)
+	if i, full := d.detectionRing.Slot(len(d.detections)); full {
+		d.detections[i] = detection
+	} else {
+		d.detections = append(d.detections, detection)
+	}
+	if !shadow {
+		d.severityCounts[detection.Severity]++
 	}
-	d.detections = append(d.detections, detection)
+	d.publish(detection)
 
-	d.logger.Warn().
+	logger.Warn().
 		Str("type", string(result.Type)).
 		Str("severity", string(result.Severity)).
 		Str("pattern", result.PatternMatched).
 		Str("action", string(result.Action)).
 		Str("mcp_server", opts.MCPServer).
 		Str("tool", opts.ToolName).
+		Bool("escalated", result.Escalated).
 		Msg("Prompt injection detected")
+
+	return result
+}
+
+// Subscribe returns a channel that receives detections as they are recorded,
+// and a function to cancel the subscription.
+func (d *Detector) Subscribe() (<-chan domain.InjectionDetection, func()) {
+	ch := make(chan domain.InjectionDetection, 64)
+
+	d.subMu.Lock()
+	d.subscribers[ch] = struct{}{}
+	d.subMu.Unlock()
+
+	return ch, func() {
+		d.subMu.Lock()
+		delete(d.subscribers, ch)
+		d.subMu.Unlock()
+	}
 }
 
-// GetPolicies returns all policies.
-func (d *Detector) GetPolicies() []domain.SafetyPolicy {
+// publish delivers a detection to live subscribers without blocking.
+func (d *Detector) publish(detection domain.InjectionDetection) {
+	d.subMu.Lock()
+	defer d.subMu.Unlock()
+
+	for ch := range d.subscribers {
+		select {
+		case ch <- detection:
+		default:
+		}
+	}
+}
+
+// GetPolicies returns an organization's policies, and its soft-deleted ones
+// when includeDeleted is set.
+func (d *Detector) GetPolicies(orgID uuid.UUID, includeDeleted bool) []domain.SafetyPolicy {
 	d.mu.RLock()
 	defer d.mu.RUnlock()
 
-	policies := make([]domain.SafetyPolicy, 0, len(d.policies))
+	policies := make([]domain.SafetyPolicy, 0)
 	for _, p := range d.policies {
-		policies = append(policies, *p)
+		if p.OrgID == orgID {
+			policies = append(policies, *p)
+		}
+	}
+	if includeDeleted {
+		for _, p := range d.deleted {
+			if p.OrgID == orgID {
+				policies = append(policies, *p)
+			}
+		}
 	}
 	return policies
 }
 
-// GetPolicy returns a policy by ID.
-func (d *Detector) GetPolicy(id uuid.UUID) *domain.SafetyPolicy {
+// GetPolicy returns one of an organization's policies by ID.
+func (d *Detector) GetPolicy(orgID, id uuid.UUID) *domain.SafetyPolicy {
 	d.mu.RLock()
 	defer d.mu.RUnlock()
-	return d.policies[id]
+
+	policy, exists := d.policies[id]
+	if !exists || policy.OrgID != orgID {
+		return nil
+	}
+	return policy
 }
 
 // CreatePolicy creates a new policy.
-func (d *Detector) CreatePolicy(input domain.SafetyPolicyInput, orgID, userID uuid.UUID) *domain.SafetyPolicy {
+func (d *Detector) CreatePolicy(ctx context.Context, input domain.SafetyPolicyInput, orgID, userID uuid.UUID) *domain.SafetyPolicy {
+	logger := d.requestLogger(ctx)
+
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
@@ -335,7 +605,7 @@ func (d *Detector) CreatePolicy(input domain.SafetyPolicyInput, orgID, userID uu
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 		if err := d.repo.CreatePolicy(ctx, policy); err != nil {
-			d.logger.Error().Err(err).Msg("Failed to persist safety policy")
+			logger.Error().Err(err).Msg("Failed to persist safety policy")
 		}
 	}
 
@@ -344,12 +614,14 @@ func (d *Detector) CreatePolicy(input domain.SafetyPolicyInput, orgID, userID uu
 }
 
 // UpdatePolicy updates an existing policy.
-func (d *Detector) UpdatePolicy(id uuid.UUID, input domain.SafetyPolicyInput) *domain.SafetyPolicy {
+func (d *Detector) UpdatePolicy(ctx context.Context, orgID, id uuid.UUID, input domain.SafetyPolicyInput) *domain.SafetyPolicy {
+	logger := d.requestLogger(ctx)
+
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
 	policy, exists := d.policies[id]
-	if !exists {
+	if !exists || policy.OrgID != orgID {
 		return nil
 	}
 
@@ -367,38 +639,219 @@ func (d *Detector) UpdatePolicy(id uuid.UUID, input domain.SafetyPolicyInput) *d
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 		if err := d.repo.UpdatePolicy(ctx, policy); err != nil {
-			d.logger.Error().Err(err).Msg("Failed to update safety policy in database")
+			logger.Error().Err(err).Msg("Failed to update safety policy in database")
 		}
 	}
 
 	return policy
 }
 
-// DeletePolicy deletes a policy.
-func (d *Detector) DeletePolicy(id uuid.UUID) bool {
+// DeletePolicy soft-deletes a policy. It can be restored with RestorePolicy.
+func (d *Detector) DeletePolicy(ctx context.Context, orgID, id uuid.UUID) bool {
+	logger := d.requestLogger(ctx)
+
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
 	// Don't allow deleting default policy
-	if id == uuid.MustParse("00000000-0000-0000-0000-000000000001") {
+	if id == DefaultPolicyID {
 		return false
 	}
 
-	if _, exists := d.policies[id]; exists {
+	if policy, exists := d.policies[id]; exists && policy.OrgID == orgID {
 		// Delete from database
 		if d.repo != nil {
 			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 			defer cancel()
 			if err := d.repo.DeletePolicy(ctx, id); err != nil {
-				d.logger.Error().Err(err).Msg("Failed to delete safety policy from database")
+				logger.Error().Err(err).Msg("Failed to delete safety policy from database")
 			}
 		}
+		now := time.Now()
+		policy.DeletedAt = &now
+		d.deleted[id] = policy
 		delete(d.policies, id)
 		return true
 	}
 	return false
 }
 
+// RestorePolicy restores one of an organization's soft-deleted policies with
+// its settings intact.
+func (d *Detector) RestorePolicy(ctx context.Context, orgID, id uuid.UUID) *domain.SafetyPolicy {
+	logger := d.requestLogger(ctx)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	policy, exists := d.deleted[id]
+	if !exists || policy.OrgID != orgID {
+		return nil
+	}
+
+	if d.repo != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := d.repo.RestorePolicy(ctx, id); err != nil {
+			logger.Error().Err(err).Msg("Failed to restore safety policy in database")
+		}
+	}
+	policy.DeletedAt = nil
+	policy.UpdatedAt = time.Now()
+	d.policies[id] = policy
+	delete(d.deleted, id)
+
+	return policy
+}
+
+// GetLibraries returns an organization's pattern libraries.
+func (d *Detector) GetLibraries(orgID uuid.UUID) []domain.PatternLibrary {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	libraries := make([]domain.PatternLibrary, 0)
+	for _, library := range d.libraries {
+		if library.OrgID == orgID {
+			libraries = append(libraries, *library)
+		}
+	}
+	sort.Slice(libraries, func(i, j int) bool {
+		return libraries[i].CreatedAt.Before(libraries[j].CreatedAt)
+	})
+	return libraries
+}
+
+// GetLibrary returns one of an organization's pattern libraries by ID.
+func (d *Detector) GetLibrary(orgID, id uuid.UUID) *domain.PatternLibrary {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	library, exists := d.libraries[id]
+	if !exists || library.OrgID != orgID {
+		return nil
+	}
+	snapshot := *library
+	return &snapshot
+}
+
+// CreateLibrary creates a new pattern library.
+func (d *Detector) CreateLibrary(ctx context.Context, input domain.PatternLibraryInput, orgID, userID uuid.UUID) *domain.PatternLibrary {
+	logger := d.requestLogger(ctx)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	library := &domain.PatternLibrary{
+		ID:          uuid.New(),
+		OrgID:       orgID,
+		Name:        input.Name,
+		Description: input.Description,
+		Category:    input.Category,
+		Patterns:    domain.SafetyPatterns{Block: input.Patterns.Block, Allow: input.Patterns.Allow},
+		Version:     1,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		CreatedBy:   userID,
+	}
+
+	if d.repo != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := d.repo.CreatePatternLibrary(ctx, library); err != nil {
+			logger.Error().Err(err).Msg("Failed to persist pattern library")
+		}
+	}
+
+	d.libraries[library.ID] = library
+	snapshot := *library
+	return &snapshot
+}
+
+// UpdateLibrary replaces a pattern library's patterns and bumps its version.
+// Policies referencing the library apply the new patterns from then on.
+func (d *Detector) UpdateLibrary(ctx context.Context, orgID, id uuid.UUID, input domain.PatternLibraryInput) *domain.PatternLibrary {
+	logger := d.requestLogger(ctx)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	existing, exists := d.libraries[id]
+	if !exists || existing.OrgID != orgID {
+		return nil
+	}
+
+	// Replace rather than mutate, so detections in flight under a read lock
+	// elsewhere never see a half-updated library
+	library := *existing
+	library.Name = input.Name
+	library.Description = input.Description
+	library.Category = input.Category
+	library.Patterns = domain.SafetyPatterns{Block: input.Patterns.Block, Allow: input.Patterns.Allow}
+	library.Version++
+	library.UpdatedAt = time.Now()
+
+	if d.repo != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := d.repo.UpdatePatternLibrary(ctx, &library); err != nil {
+			logger.Error().Err(err).Msg("Failed to update pattern library in database")
+		}
+	}
+
+	d.libraries[id] = &library
+	logger.Info().
+		Str("library_id", id.String()).
+		Int("version", library.Version).
+		Int("policies", len(d.libraryReferences(id))).
+		Msg("Pattern library updated")
+
+	snapshot := library
+	return &snapshot
+}
+
+// DeleteLibrary deletes a pattern library that no policy references. When
+// policies still reference it nothing is deleted and their IDs are returned.
+func (d *Detector) DeleteLibrary(ctx context.Context, orgID, id uuid.UUID) (deleted bool, referencedBy []uuid.UUID) {
+	logger := d.requestLogger(ctx)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	library, exists := d.libraries[id]
+	if !exists || library.OrgID != orgID {
+		return false, nil
+	}
+	if refs := d.libraryReferences(id); len(refs) > 0 {
+		return false, refs
+	}
+
+	if d.repo != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := d.repo.DeletePatternLibrary(ctx, id); err != nil {
+			logger.Error().Err(err).Msg("Failed to delete pattern library from database")
+		}
+	}
+	delete(d.libraries, id)
+	return true, nil
+}
+
+// libraryReferences returns the IDs of the active policies that reference a
+// library. The caller must hold d.mu.
+func (d *Detector) libraryReferences(id uuid.UUID) []uuid.UUID {
+	var refs []uuid.UUID
+	for _, policy := range d.policies {
+		for _, ref := range policy.Patterns.Libraries {
+			if ref == id {
+				refs = append(refs, policy.ID)
+				break
+			}
+		}
+	}
+	return refs
+}
+
 // GetDetections returns recent detections.
 func (d *Detector) GetDetections(filter domain.DetectionFilter) domain.DetectionPage {
 	d.detectionMu.RLock()
@@ -408,6 +861,9 @@ func (d *Detector) GetDetections(filter domain.DetectionFilter) domain.Detection
 	filtered := make([]domain.InjectionDetection, 0)
 	for _, det := range d.detections {
 		// Apply filters
+		if det.OrgID != filter.OrgID {
+			continue
+		}
 		if len(filter.Types) > 0 && !containsType(filter.Types, det.Type) {
 			continue
 		}
@@ -420,19 +876,29 @@ func (d *Detector) GetDetections(filter domain.DetectionFilter) domain.Detection
 		if filter.MCPServer != "" && det.MCPServer != filter.MCPServer {
 			continue
 		}
+		if filter.TraceID != "" && det.TraceID != filter.TraceID {
+			continue
+		}
 		if filter.StartTime != nil && det.CreatedAt.Before(*filter.StartTime) {
 			continue
 		}
 		if filter.EndTime != nil && det.CreatedAt.After(*filter.EndTime) {
 			continue
 		}
+		if !domain.MatchesQuery(filter.Query, det.Input, det.PatternMatched, det.ToolName, det.MCPServer) {
+			continue
+		}
 		filtered = append(filtered, det)
 	}
 
-	// Sort by most recent first (reverse order)
+	// Sort by most recent first; the insertion order is reversed first so
+	// rows sharing a timestamp keep their historical ordering.
 	for i, j := 0, len(filtered)-1; i < j; i, j = i+1, j-1 {
 		filtered[i], filtered[j] = filtered[j], filtered[i]
 	}
+	sort.SliceStable(filtered, func(i, j int) bool {
+		return domain.NewerFirst(filtered[i].CreatedAt, filtered[i].ID, filtered[j].CreatedAt, filtered[j].ID)
+	})
 
 	total := int64(len(filtered))
 	limit := filter.Limit
@@ -444,8 +910,14 @@ func (d *Detector) GetDetections(filter domain.DetectionFilter) domain.Detection
 		offset = 0
 	}
 
-	// Paginate
 	start := offset
+	if filter.Cursor != nil {
+		// Keyset pagination takes precedence over offset.
+		start = domain.CursorStart(len(filtered), filter.Cursor, func(i int) (time.Time, uuid.UUID) {
+			return filtered[i].CreatedAt, filtered[i].ID
+		})
+		offset = 0
+	}
 	if start > len(filtered) {
 		start = len(filtered)
 	}
@@ -454,32 +926,59 @@ func (d *Detector) GetDetections(filter domain.DetectionFilter) domain.Detection
 		end = len(filtered)
 	}
 
-	return domain.DetectionPage{
+	page := domain.DetectionPage{
 		Detections: filtered[start:end],
 		Total:      total,
 		Limit:      limit,
 		Offset:     offset,
 		HasMore:    end < len(filtered),
 	}
+	if page.HasMore && end > start {
+		page.NextCursor = domain.NewCursor(filtered[end-1].CreatedAt, filtered[end-1].ID).Encode()
+	}
+	return page
 }
 
-// GetSummary returns a summary of detections.
-func (d *Detector) GetSummary() domain.SafetySummary {
+// DetectionCounts returns the number of detections recorded since startup,
+// keyed by severity.
+func (d *Detector) DetectionCounts() map[domain.DetectionSeverity]int64 {
+	d.detectionMu.RLock()
+	defer d.detectionMu.RUnlock()
+
+	counts := make(map[domain.DetectionSeverity]int64, len(d.severityCounts))
+	for severity, count := range d.severityCounts {
+		counts[severity] = count
+	}
+	return counts
+}
+
+// BufferStats describes the in-memory detection buffer.
+func (d *Detector) BufferStats() ringbuf.Stats {
+	d.detectionMu.RLock()
+	defer d.detectionMu.RUnlock()
+	return d.detectionRing.Stats(len(d.detections))
+}
+
+// GetSummary returns a summary of an organization's detections.
+func (d *Detector) GetSummary(orgID uuid.UUID) domain.SafetySummary {
 	d.detectionMu.RLock()
 	defer d.detectionMu.RUnlock()
 
 	summary := domain.SafetySummary{
-		TotalDetections: int64(len(d.detections)),
-		ByType:          make(map[string]int64),
-		BySeverity:      make(map[string]int64),
-		ByAction:        make(map[string]int64),
-		TopPatterns:     make([]domain.PatternCount, 0),
-		Period:          "all",
+		ByType:      make(map[string]int64),
+		BySeverity:  make(map[string]int64),
+		ByAction:    make(map[string]int64),
+		TopPatterns: make([]domain.PatternCount, 0),
+		Period:      "all",
 	}
 
 	patternCounts := make(map[string]int64)
 
 	for _, det := range d.detections {
+		if det.OrgID != orgID {
+			continue
+		}
+		summary.TotalDetections++
 		summary.ByType[string(det.Type)]++
 		summary.BySeverity[string(det.Severity)]++
 		summary.ByAction[string(det.ActionTaken)]++
@@ -513,6 +1012,101 @@ func (d *Detector) GetSummary() domain.SafetySummary {
 	return summary
 }
 
+// TopOffenders ranks the API keys, client IPs, MCP servers and tools with the
+// most detections in an organization over a period, up to limit of each. It
+// reads the database when there is one, and the detections in memory
+// otherwise.
+func (d *Detector) TopOffenders(ctx context.Context, orgID uuid.UUID, period string, limit int) (*domain.SafetyOffenders, error) {
+	if d.repo != nil {
+		return d.repo.GetTopOffenders(ctx, orgID, period, limit)
+	}
+
+	p := domain.ParseSafetyPeriod(period)
+	now := time.Now().UTC()
+	since := now.Add(-p.Window)
+
+	type tally struct {
+		offender domain.SafetyOffender
+		requests map[string]bool
+	}
+	apiKeys, ips, servers, tools := map[string]*tally{}, map[string]*tally{}, map[string]*tally{}, map[string]*tally{}
+	count := func(tallies map[string]*tally, key string, det domain.InjectionDetection, request string, offender domain.SafetyOffender) {
+		if key == "" {
+			return
+		}
+		t, ok := tallies[key]
+		if !ok {
+			t = &tally{offender: offender, requests: make(map[string]bool)}
+			tallies[key] = t
+		}
+		if det.CreatedAt.After(t.offender.LastSeen) {
+			t.offender.LastSeen = det.CreatedAt
+		}
+		if t.requests[request] {
+			return
+		}
+		t.requests[request] = true
+		t.offender.Detections++
+		if det.ActionTaken == domain.SafetyModeBlock {
+			t.offender.Blocked++
+		}
+	}
+
+	d.detectionMu.RLock()
+	seen := make(map[string]bool)
+	series := make(map[time.Time]int64)
+	for _, det := range d.detections {
+		if det.OrgID != orgID || det.CreatedAt.Before(since) {
+			continue
+		}
+		request := det.TraceID
+		if request == "" {
+			request = det.ID.String()
+		}
+		if !seen[request] {
+			seen[request] = true
+			series[det.CreatedAt.UTC().Truncate(p.Bucket)]++
+		}
+		if det.APIKeyID != nil {
+			key := det.APIKeyID.String()
+			count(apiKeys, key, det, request, domain.SafetyOffender{Value: key})
+		}
+		count(ips, det.IPAddress, det, request, domain.SafetyOffender{Value: det.IPAddress})
+		count(servers, det.MCPServer, det, request, domain.SafetyOffender{Value: det.MCPServer})
+		if det.ToolName != "" {
+			count(tools, det.MCPServer+"/"+det.ToolName, det, request, domain.SafetyOffender{Value: det.ToolName, MCPServer: det.MCPServer})
+		}
+	}
+	d.detectionMu.RUnlock()
+
+	rank := func(tallies map[string]*tally) []domain.SafetyOffender {
+		ranked := make([]domain.SafetyOffender, 0, len(tallies))
+		for _, t := range tallies {
+			ranked = append(ranked, t.offender)
+		}
+		sort.Slice(ranked, func(i, j int) bool {
+			if ranked[i].Detections != ranked[j].Detections {
+				return ranked[i].Detections > ranked[j].Detections
+			}
+			return ranked[i].LastSeen.After(ranked[j].LastSeen)
+		})
+		if len(ranked) > limit {
+			ranked = ranked[:limit]
+		}
+		return ranked
+	}
+
+	return &domain.SafetyOffenders{
+		Period:  p.Name,
+		APIKeys: rank(apiKeys),
+		IPs:     rank(ips),
+		Servers: rank(servers),
+		Tools:   rank(tools),
+		Bucket:  p.BucketName,
+		Series:  domain.DetectionSeries(since, now, p.Bucket, series),
+	}, nil
+}
+
 // DetectOptions contains options for detection.
 type DetectOptions struct {
 	Input     string
@@ -522,8 +1116,10 @@ type DetectOptions struct {
 	SpanID    string
 	MCPServer string
 	ToolName  string
+	Arguments map[string]interface{} // The call's arguments, if Input came from them, for redaction
 	APIKeyID  *uuid.UUID
 	IPAddress string
+	DryRun    bool // Evaluate only; do not record the detection
 }
 
 // Helper functions
@@ -545,6 +1141,15 @@ func containsSeverity(severities []domain.DetectionSeverity, s domain.DetectionS
 	return false
 }
 
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
 func containsAction(actions []domain.SafetyMode, a domain.SafetyMode) bool {
 	for _, da := range actions {
 		if da == a {
@@ -0,0 +1,142 @@
+package safety
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/google/uuid"
+)
+
+// EscalationPolicy treats a principal that keeps triggering detections as a
+// repeat offender: once it reaches Threshold detections within Window, each
+// further detection is blocked whatever its policy's mode, and an alert is
+// raised when the threshold is first crossed. A zero Threshold disables
+// escalation.
+type EscalationPolicy struct {
+	Threshold int
+	Window    time.Duration
+}
+
+// offender tracks the recent detections of one principal in one organization.
+type offender struct {
+	orgID     uuid.UUID
+	principal string
+	hits      []time.Time // Within the window, oldest first
+	escalated bool        // Threshold reached in the current run of detections
+}
+
+// principal identifies who made a request: its API key, or its client IP
+// when it has none. It returns "" when neither is known.
+func principal(opts DetectOptions) string {
+	if opts.APIKeyID != nil && *opts.APIKeyID != uuid.Nil {
+		return "api_key:" + opts.APIKeyID.String()
+	}
+	if opts.IPAddress != "" {
+		return "ip:" + opts.IPAddress
+	}
+	return ""
+}
+
+// recentHits drops the hits that fall before cutoff.
+func recentHits(hits []time.Time, cutoff time.Time) []time.Time {
+	i := 0
+	for i < len(hits) && hits[i].Before(cutoff) {
+		i++
+	}
+	return hits[i:]
+}
+
+// escalate counts a detection against its principal and, once the principal
+// is a repeat offender, escalates the result to a block. The caller must
+// hold d.detectionMu.
+func (d *Detector) escalate(ctx context.Context, opts DetectOptions, result domain.DetectionResult) domain.DetectionResult {
+	logger := d.requestLogger(ctx)
+
+	who := principal(opts)
+	if d.escalation.Threshold <= 0 || who == "" {
+		return result
+	}
+
+	now := time.Now()
+	cutoff := now.Add(-d.escalation.Window)
+	key := opts.OrgID.String() + "/" + who
+	o, ok := d.offenders[key]
+	if !ok {
+		// Forget principals with no detections left in the window
+		for k, other := range d.offenders {
+			if len(recentHits(other.hits, cutoff)) == 0 {
+				delete(d.offenders, k)
+			}
+		}
+		o = &offender{orgID: opts.OrgID, principal: who}
+		d.offenders[key] = o
+	}
+	o.hits = append(recentHits(o.hits, cutoff), now)
+
+	if len(o.hits) < d.escalation.Threshold {
+		o.escalated = false
+		return result
+	}
+
+	if !o.escalated {
+		o.escalated = true
+		logger.Warn().
+			Str("org_id", opts.OrgID.String()).
+			Str("principal", who).
+			Int("detections", len(o.hits)).
+			Dur("window", d.escalation.Window).
+			Msg("Repeat safety offender escalated to block")
+		if d.alerts != nil {
+			d.alerts.CreateSystemAlert(
+				opts.OrgID,
+				"Safety: repeat offender",
+				domain.AlertMetricInjectionDetected,
+				domain.AlertSeverityCritical,
+				float64(len(o.hits)),
+				float64(d.escalation.Threshold),
+				fmt.Sprintf("%s triggered %d safety detections within %s; further detections are blocked",
+					who, len(o.hits), d.escalation.Window),
+				nil,
+			)
+		}
+	}
+
+	result.Action = domain.SafetyModeBlock
+	result.Escalated = true
+	return result
+}
+
+// OffenderCounts returns the principals in an organization with detections
+// inside the escalation window, most active first.
+func (d *Detector) OffenderCounts(orgID uuid.UUID) []domain.OffenderCount {
+	d.detectionMu.RLock()
+	defer d.detectionMu.RUnlock()
+
+	cutoff := time.Now().Add(-d.escalation.Window)
+	counts := make([]domain.OffenderCount, 0)
+	for _, o := range d.offenders {
+		if o.orgID != orgID {
+			continue
+		}
+		hits := recentHits(o.hits, cutoff)
+		if len(hits) == 0 {
+			continue
+		}
+		counts = append(counts, domain.OffenderCount{
+			Principal:  o.principal,
+			Detections: len(hits),
+			Escalated:  o.escalated && len(hits) >= d.escalation.Threshold,
+			LastSeen:   hits[len(hits)-1],
+		})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Detections != counts[j].Detections {
+			return counts[i].Detections > counts[j].Detections
+		}
+		return counts[i].Principal < counts[j].Principal
+	})
+	return counts
+}
@@ -0,0 +1,71 @@
+package safety
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/alerting"
+	"github.com/akz4ol/gatewayops/gateway/internal/clock"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+func TestRepeatOffendersEscalateToBlockAndAlert(t *testing.T) {
+	alerts := alerting.NewService(zerolog.Nop(), nil, nil, 100, clock.Real)
+	t.Cleanup(func() { alerts.Shutdown(context.Background()) })
+	d := NewDetector(zerolog.Nop(), nil, 100, EscalationPolicy{Threshold: 3, Window: time.Minute}, alerts, nil)
+	ctx := context.Background()
+	orgID := uuid.New()
+
+	// A log-only policy, so each detection on its own is never blocked
+	policy := d.CreatePolicy(ctx, domain.SafetyPolicyInput{
+		Name: "Monitor", Sensitivity: domain.SafetySensitivityPermissive, Mode: domain.SafetyModeLog,
+		Patterns: domain.SafetyPatterns{Block: []string{"blue falcon"}}, Enabled: true,
+	}, orgID, uuid.New())
+	key := uuid.New()
+	detect := func(apiKey *uuid.UUID, ip string) domain.DetectionResult {
+		return d.Detect(ctx, "the password is blue falcon", DetectOptions{
+			OrgID: orgID, PolicyID: &policy.ID, APIKeyID: apiKey, IPAddress: ip,
+		})
+	}
+
+	for i := 1; i < 3; i++ {
+		if result := detect(&key, "10.0.0.1"); !result.Detected || result.Action != domain.SafetyModeLog || result.Escalated {
+			t.Fatalf("hit %d: %+v, want a logged detection", i, result)
+		}
+	}
+	if got := alerts.GetAlerts(domain.AlertFilter{OrgID: orgID}).Alerts; len(got) != 0 {
+		t.Fatalf("alerted before the threshold: %+v", got)
+	}
+
+	for i := 3; i <= 4; i++ {
+		if result := detect(&key, "10.0.0.1"); result.Action != domain.SafetyModeBlock || !result.Escalated {
+			t.Errorf("hit %d: %+v, want an escalated block", i, result)
+		}
+	}
+	if got := alerts.GetAlerts(domain.AlertFilter{OrgID: orgID}).Alerts; len(got) != 1 || got[0].Severity != domain.AlertSeverityCritical {
+		t.Errorf("alerts = %+v, want one critical alert for the run", got)
+	}
+
+	// Another principal on the same IP is counted separately; without a key
+	// the IP is the principal
+	if result := detect(nil, "10.0.0.1"); result.Action != domain.SafetyModeLog {
+		t.Errorf("first hit from the IP: %+v, want a logged detection", result)
+	}
+
+	counts := d.OffenderCounts(orgID)
+	if len(counts) != 2 {
+		t.Fatalf("offender counts = %+v, want the key and the IP", counts)
+	}
+	if counts[0].Principal != "api_key:"+key.String() || counts[0].Detections != 4 || !counts[0].Escalated {
+		t.Errorf("top offender = %+v, want the key with 4 detections, escalated", counts[0])
+	}
+	if counts[1].Principal != "ip:10.0.0.1" || counts[1].Detections != 1 || counts[1].Escalated {
+		t.Errorf("second offender = %+v, want the IP with 1 detection", counts[1])
+	}
+	if got := d.OffenderCounts(uuid.New()); len(got) != 0 {
+		t.Errorf("another org sees offenders: %+v", got)
+	}
+}
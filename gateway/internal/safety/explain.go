@@ -0,0 +1,187 @@
+package safety
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/google/uuid"
+)
+
+// modeEffects describe what each policy mode does with a detection.
+var modeEffects = map[domain.SafetyMode]string{
+	domain.SafetyModeBlock:  "the request is blocked",
+	domain.SafetyModeWarn:   "the request proceeds and the detection is recorded",
+	domain.SafetyModeLog:    "the detection is logged only",
+	domain.SafetyModeShadow: "the detection is recorded as what the policy would have blocked",
+}
+
+// Explain shows how a policy, or the default policy when policyID is nil,
+// treats input: every allow, block and heuristic match with its offsets, and
+// the decision Detect would reach with the reasons for it. Nothing is
+// recorded, and repeat offenders are not escalated.
+func (d *Detector) Explain(orgID uuid.UUID, policyID *uuid.UUID, input string) domain.SafetyExplanation {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	explanation := domain.SafetyExplanation{
+		Matches: make([]domain.SafetyMatch, 0),
+		Result:  domain.DetectionResult{Detected: false, Action: domain.SafetyModeLog},
+	}
+
+	policy := d.policyFor(orgID, policyID)
+	if policy == nil {
+		explanation.Reasoning = []string{"No policy applies, so input is not checked"}
+		return explanation
+	}
+
+	id := policy.ID
+	explanation.PolicyID = &id
+	explanation.PolicyName = policy.Name
+	explanation.PolicyEnabled = policy.Enabled
+	explanation.Mode = policy.Mode
+	explanation.Sensitivity = policy.Sensitivity
+	explanation.HeuristicsApplied = policy.Sensitivity != domain.SafetySensitivityPermissive
+
+	normalizedInput := strings.ToLower(input)
+	explanation.Matches = d.matches(input, normalizedInput, policy, explanation.HeuristicsApplied)
+
+	if !policy.Enabled {
+		explanation.Reasoning = []string{fmt.Sprintf("Policy %q is disabled, so input is not checked", policy.Name)}
+		return explanation
+	}
+
+	explanation.Result = d.evaluate(normalizedInput, policy)
+	explanation.Reasoning = reasoning(explanation)
+	return explanation
+}
+
+// matches returns every place a policy's checks match input: allow
+// patterns, then block patterns, then heuristics when they apply. The caller
+// must hold d.mu.
+func (d *Detector) matches(input, normalizedInput string, policy *domain.SafetyPolicy, heuristicsApplied bool) []domain.SafetyMatch {
+	matches := make([]domain.SafetyMatch, 0)
+	sets := d.patternSets(policy)
+
+	for _, set := range sets {
+		for _, pattern := range set.patterns.Allow {
+			base := patternMatch(domain.SafetyMatchAllow, pattern, set)
+			matches = append(matches, occurrences(base, input, normalizedInput, strings.ToLower(pattern))...)
+		}
+	}
+
+	for _, set := range sets {
+		for _, pattern := range set.patterns.Block {
+			base := patternMatch(domain.SafetyMatchBlock, pattern, set)
+			base.Severity = d.determineSeverity(pattern, policy.Sensitivity)
+			matches = append(matches, occurrences(base, input, normalizedInput, strings.ToLower(pattern))...)
+		}
+	}
+
+	if heuristicsApplied {
+		for _, h := range heuristics {
+			for _, loc := range h.re.FindAllStringIndex(normalizedInput, -1) {
+				matches = append(matches, domain.SafetyMatch{
+					Kind:     domain.SafetyMatchHeuristic,
+					Pattern:  h.pattern,
+					Start:    loc[0],
+					End:      loc[1],
+					Text:     matchedText(input, normalizedInput, loc[0], loc[1]),
+					Type:     domain.DetectionTypePromptInjection,
+					Severity: h.severity,
+					Message:  h.message,
+				})
+			}
+		}
+	}
+
+	return matches
+}
+
+// patternMatch returns the fields shared by every match of a pattern from
+// set.
+func patternMatch(kind domain.SafetyMatchKind, pattern string, set patternSet) domain.SafetyMatch {
+	match := domain.SafetyMatch{Kind: kind, Pattern: pattern}
+	if kind == domain.SafetyMatchBlock {
+		match.Type = domain.DetectionTypePromptInjection
+	}
+	if set.library != nil {
+		match.LibraryID = &set.library.ID
+		match.LibraryVersion = set.library.Version
+		if kind == domain.SafetyMatchBlock {
+			match.Type = set.library.Category.DetectionType()
+		}
+	}
+	return match
+}
+
+// occurrences returns a match for each place the lowercased pattern occurs
+// in the normalized input.
+func occurrences(base domain.SafetyMatch, input, normalizedInput, pattern string) []domain.SafetyMatch {
+	var matches []domain.SafetyMatch
+	if pattern == "" {
+		return matches
+	}
+	for offset := 0; ; {
+		i := strings.Index(normalizedInput[offset:], pattern)
+		if i < 0 {
+			return matches
+		}
+		match := base
+		match.Start = offset + i
+		match.End = match.Start + len(pattern)
+		match.Text = matchedText(input, normalizedInput, match.Start, match.End)
+		matches = append(matches, match)
+		offset = match.End
+	}
+}
+
+// matchedText returns the input that a match covers, as written when
+// lowercasing kept offsets in place, and as lowercased otherwise.
+func matchedText(input, normalizedInput string, start, end int) string {
+	if len(input) == len(normalizedInput) {
+		return input[start:end]
+	}
+	return normalizedInput[start:end]
+}
+
+// reasoning explains the decision an enabled policy reached.
+func reasoning(e domain.SafetyExplanation) []string {
+	counts := make(map[domain.SafetyMatchKind]int)
+	var firstAllow string
+	for _, match := range e.Matches {
+		if match.Kind == domain.SafetyMatchAllow && counts[match.Kind] == 0 {
+			firstAllow = match.Pattern
+		}
+		counts[match.Kind]++
+	}
+	others := counts[domain.SafetyMatchBlock] + counts[domain.SafetyMatchHeuristic]
+
+	var reasons []string
+	switch {
+	case firstAllow != "":
+		reasons = append(reasons, fmt.Sprintf("Allow pattern %q matched, so input is allowed", firstAllow))
+		if others > 0 {
+			reasons = append(reasons, fmt.Sprintf("Allow patterns override the %d block and heuristic matches", others))
+		}
+
+	case e.Result.Detected:
+		kind := "Block pattern"
+		if counts[domain.SafetyMatchBlock] == 0 {
+			kind = "No block pattern matched; heuristic"
+		}
+		reasons = append(reasons, fmt.Sprintf("%s %q matched: %s (%s severity)", kind, e.Result.PatternMatched, e.Result.Message, e.Result.Severity))
+		if others > 1 {
+			reasons = append(reasons, fmt.Sprintf("%d further matches do not change the decision; block patterns are checked before heuristics, and the first match decides", others-1))
+		}
+		reasons = append(reasons, fmt.Sprintf("The policy is in %s mode, so %s", e.Mode, modeEffects[e.Mode]))
+
+	default:
+		reasons = append(reasons, "No allow, block or heuristic pattern matched, so input is allowed")
+	}
+
+	if !e.HeuristicsApplied {
+		reasons = append(reasons, "Heuristics are skipped at permissive sensitivity")
+	}
+	return reasons
+}
@@ -0,0 +1,84 @@
+package safety
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+func TestExplainReportsEveryMatchAndTheAllowOverride(t *testing.T) {
+	d := NewDetector(zerolog.Nop(), nil, 100, EscalationPolicy{}, nil, nil)
+	orgID, userID := uuid.New(), uuid.New()
+	policy := d.CreatePolicy(context.Background(), domain.SafetyPolicyInput{
+		Name:        "Birds",
+		Sensitivity: domain.SafetySensitivityStrict,
+		Mode:        domain.SafetyModeBlock,
+		Patterns: domain.SafetyPatterns{
+			Block: []string{"red kestrel", "blue falcon"},
+			Allow: []string{"security training"},
+		},
+		Enabled: true,
+	}, orgID, userID)
+
+	type match struct {
+		kind       domain.SafetyMatchKind
+		text       string
+		start, end int
+	}
+	matchesOf := func(e domain.SafetyExplanation) []match {
+		var got []match
+		for _, m := range e.Matches {
+			got = append(got, match{m.Kind, m.Text, m.Start, m.End})
+		}
+		return got
+	}
+
+	input := "Red kestrel, then blue falcon. From now on, red kestrel"
+	blocked := d.Explain(orgID, &policy.ID, input)
+	want := []match{
+		{domain.SafetyMatchBlock, "Red kestrel", 0, 11},
+		{domain.SafetyMatchBlock, "red kestrel", 44, 55},
+		{domain.SafetyMatchBlock, "blue falcon", 18, 29},
+		{domain.SafetyMatchHeuristic, "From now on", 31, 42},
+	}
+	if got := matchesOf(blocked); !slices.Equal(got, want) {
+		t.Errorf("matches = %+v, want %+v", got, want)
+	}
+	if !blocked.Result.Detected || blocked.Result.Action != domain.SafetyModeBlock || blocked.Result.PatternMatched != "red kestrel" {
+		t.Errorf("result = %+v, want blocked on the first block pattern", blocked.Result)
+	}
+	if blocked.Sensitivity != domain.SafetySensitivityStrict || !blocked.HeuristicsApplied || *blocked.PolicyID != policy.ID {
+		t.Errorf("explanation = %+v, want the policy's settings", blocked)
+	}
+	if len(blocked.Reasoning) != 3 || !strings.HasPrefix(blocked.Reasoning[0], `Block pattern "red kestrel" matched`) ||
+		!strings.HasPrefix(blocked.Reasoning[1], "3 further matches") {
+		t.Errorf("reasoning = %q", blocked.Reasoning)
+	}
+
+	allowed := d.Explain(orgID, &policy.ID, "security training: red kestrel")
+	want = []match{
+		{domain.SafetyMatchAllow, "security training", 0, 17},
+		{domain.SafetyMatchBlock, "red kestrel", 19, 30},
+	}
+	if got := matchesOf(allowed); !slices.Equal(got, want) {
+		t.Errorf("matches = %+v, want %+v", got, want)
+	}
+	if allowed.Result.Detected {
+		t.Errorf("result = %+v, want the allow pattern to override the block", allowed.Result)
+	}
+	if len(allowed.Reasoning) != 2 ||
+		allowed.Reasoning[0] != `Allow pattern "security training" matched, so input is allowed` ||
+		allowed.Reasoning[1] != "Allow patterns override the 1 block and heuristic matches" {
+		t.Errorf("reasoning = %q", allowed.Reasoning)
+	}
+
+	// Explaining is not detecting
+	if page := d.GetDetections(domain.DetectionFilter{OrgID: orgID, Limit: 10}); page.Total != 0 {
+		t.Errorf("Explain recorded %d detections", page.Total)
+	}
+}
@@ -0,0 +1,335 @@
+package secretbox
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrNoDataKey is returned when opening data sealed under an organization's
+// data key that no longer exists, as after RevokeOrgKeys.
+var ErrNoDataKey = errors.New("secretbox: data key not found")
+
+// envelopeMagic starts data sealed by an Envelope, followed by the version of
+// the data key it was sealed under. The zero byte keeps it from being
+// mistaken for a plaintext secret stored before envelope encryption.
+var envelopeMagic = []byte{0, 'g', 'w', 'e'}
+
+const envelopeHeaderSize = 4 + 4
+
+// KeyWrapper encrypts data keys under a master key it holds. *Box and
+// *Keyring are the local implementations, keeping the master key in process;
+// a cloud KMS can be used instead by implementing it.
+type KeyWrapper interface {
+	WrapKey(ctx context.Context, key []byte) ([]byte, error)
+	UnwrapKey(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// staleWrapper is a KeyWrapper that can tell which wrapped keys are not
+// under its current master key, such as a *Keyring.
+type staleWrapper interface {
+	Stale(wrapped []byte) bool
+}
+
+// DataKey is one of an organization's data keys, as stored: wrapped under
+// the master key.
+type DataKey struct {
+	Version   uint32
+	Wrapped   []byte
+	CreatedAt time.Time
+}
+
+// KeyStore keeps the wrapped data keys of each organization. Implementations
+// must be safe for concurrent use.
+type KeyStore interface {
+	// DataKeys returns the organization's keys, oldest first.
+	DataKeys(ctx context.Context, orgID uuid.UUID) ([]DataKey, error)
+	AddDataKey(ctx context.Context, orgID uuid.UUID, key DataKey) error
+	// ReplaceDataKey stores key in place of the organization's key of the
+	// same version.
+	ReplaceDataKey(ctx context.Context, orgID uuid.UUID, key DataKey) error
+	DeleteDataKeys(ctx context.Context, orgID uuid.UUID) error
+	// Orgs returns the organizations that have keys.
+	Orgs(ctx context.Context) ([]uuid.UUID, error)
+}
+
+// MemoryKeyStore is a KeyStore in process memory; keys are lost on restart.
+type MemoryKeyStore struct {
+	mu   sync.RWMutex
+	keys map[uuid.UUID][]DataKey
+}
+
+// NewMemoryKeyStore creates an empty in-memory key store.
+func NewMemoryKeyStore() *MemoryKeyStore {
+	return &MemoryKeyStore{keys: make(map[uuid.UUID][]DataKey)}
+}
+
+// DataKeys implements KeyStore.
+func (s *MemoryKeyStore) DataKeys(ctx context.Context, orgID uuid.UUID) ([]DataKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]DataKey(nil), s.keys[orgID]...), nil
+}
+
+// AddDataKey implements KeyStore.
+func (s *MemoryKeyStore) AddDataKey(ctx context.Context, orgID uuid.UUID, key DataKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[orgID] = append(s.keys[orgID], key)
+	return nil
+}
+
+// ReplaceDataKey implements KeyStore.
+func (s *MemoryKeyStore) ReplaceDataKey(ctx context.Context, orgID uuid.UUID, key DataKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, stored := range s.keys[orgID] {
+		if stored.Version == key.Version {
+			s.keys[orgID][i] = key
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: version %d", ErrNoDataKey, key.Version)
+}
+
+// Orgs implements KeyStore.
+func (s *MemoryKeyStore) Orgs(ctx context.Context) ([]uuid.UUID, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	orgs := make([]uuid.UUID, 0, len(s.keys))
+	for orgID := range s.keys {
+		orgs = append(orgs, orgID)
+	}
+	return orgs, nil
+}
+
+// DeleteDataKeys implements KeyStore.
+func (s *MemoryKeyStore) DeleteDataKeys(ctx context.Context, orgID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.keys, orgID)
+	return nil
+}
+
+// Envelope seals each organization's secrets under a data key of its own,
+// created on first use and stored wrapped under a master key. An
+// organization's key can be rotated or revoked without touching any other
+// organization's secrets, and a secret sealed for one organization cannot be
+// opened as another's.
+type Envelope struct {
+	wrapper KeyWrapper
+	store   KeyStore
+
+	mu   sync.Mutex
+	orgs map[uuid.UUID]*orgKeys // Unwrapped keys, loaded on first use
+}
+
+// orgKeys are the unwrapped data keys of one organization.
+type orgKeys struct {
+	current uint32
+	boxes   map[uint32]*Box
+}
+
+// NewEnvelope creates an envelope whose data keys are wrapped by wrapper and
+// kept in store.
+func NewEnvelope(wrapper KeyWrapper, store KeyStore) *Envelope {
+	return &Envelope{
+		wrapper: wrapper,
+		store:   store,
+		orgs:    make(map[uuid.UUID]*orgKeys),
+	}
+}
+
+// Seal encrypts plaintext under the organization's current data key.
+func (e *Envelope) Seal(ctx context.Context, orgID uuid.UUID, plaintext []byte) ([]byte, error) {
+	e.mu.Lock()
+	keys, err := e.load(ctx, orgID)
+	if err == nil && keys.current == 0 {
+		_, err = e.addKey(ctx, orgID, keys)
+	}
+	var version uint32
+	var box *Box
+	if err == nil {
+		version, box = keys.current, keys.boxes[keys.current]
+	}
+	e.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	sealed, err := box.seal(plaintext, orgID[:])
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, envelopeHeaderSize+len(sealed))
+	out = append(out, envelopeMagic...)
+	out = binary.BigEndian.AppendUint32(out, version)
+	return append(out, sealed...), nil
+}
+
+// Open decrypts data sealed by Seal for the organization. Data without the
+// envelope header was stored before envelope encryption and is returned as
+// is. stale reports that the data is not sealed under the organization's
+// current key, so it should be sealed again and stored in its place.
+func (e *Envelope) Open(ctx context.Context, orgID uuid.UUID, sealed []byte) (plaintext []byte, stale bool, err error) {
+	if !IsSealed(sealed) {
+		return sealed, true, nil
+	}
+	version := binary.BigEndian.Uint32(sealed[len(envelopeMagic):envelopeHeaderSize])
+
+	e.mu.Lock()
+	keys, err := e.load(ctx, orgID)
+	if err == nil && keys.boxes[version] == nil {
+		// Another instance may have rotated the key since it was loaded
+		delete(e.orgs, orgID)
+		keys, err = e.load(ctx, orgID)
+	}
+	var box *Box
+	if err == nil {
+		box = keys.boxes[version]
+		stale = version != keys.current
+	}
+	e.mu.Unlock()
+	if err != nil {
+		return nil, false, err
+	}
+	if box == nil {
+		return nil, false, fmt.Errorf("%w: version %d", ErrNoDataKey, version)
+	}
+
+	plaintext, err = box.open(sealed[envelopeHeaderSize:], orgID[:])
+	if err != nil {
+		return nil, false, err
+	}
+	return plaintext, stale, nil
+}
+
+// RotateOrgKey gives the organization a new data key, used by Seal from then
+// on, and returns its version. Older keys are kept, so secrets sealed under
+// them can still be opened until they are sealed again.
+func (e *Envelope) RotateOrgKey(ctx context.Context, orgID uuid.UUID) (uint32, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	keys, err := e.load(ctx, orgID)
+	if err != nil {
+		return 0, err
+	}
+	return e.addKey(ctx, orgID, keys)
+}
+
+// RevokeOrgKeys deletes the organization's data keys, so nothing sealed for
+// it can be opened again. Its next secret is sealed under a new key.
+func (e *Envelope) RevokeOrgKeys(ctx context.Context, orgID uuid.UUID) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	delete(e.orgs, orgID)
+	return e.store.DeleteDataKeys(ctx, orgID)
+}
+
+// Reencrypt wraps every organization's data keys again under the wrapper's
+// current master key, returning how many were rewrapped. When the wrapper
+// can tell, keys already under the current master key are left alone. The
+// secrets sealed under the data keys are unchanged.
+func (e *Envelope) Reencrypt(ctx context.Context) (int, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	orgs, err := e.store.Orgs(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("secretbox: list organizations: %w", err)
+	}
+	stale, _ := e.wrapper.(staleWrapper)
+	count := 0
+	for _, orgID := range orgs {
+		stored, err := e.store.DataKeys(ctx, orgID)
+		if err != nil {
+			return count, fmt.Errorf("secretbox: load data keys: %w", err)
+		}
+		for _, dataKey := range stored {
+			if stale != nil && !stale.Stale(dataKey.Wrapped) {
+				continue
+			}
+			raw, err := e.wrapper.UnwrapKey(ctx, dataKey.Wrapped)
+			if err != nil {
+				return count, fmt.Errorf("secretbox: unwrap data key version %d: %w", dataKey.Version, err)
+			}
+			if dataKey.Wrapped, err = e.wrapper.WrapKey(ctx, raw); err != nil {
+				return count, fmt.Errorf("secretbox: wrap data key: %w", err)
+			}
+			if err := e.store.ReplaceDataKey(ctx, orgID, dataKey); err != nil {
+				return count, fmt.Errorf("secretbox: store data key: %w", err)
+			}
+			count++
+		}
+	}
+	return count, nil
+}
+
+// IsSealed reports whether data was sealed by an Envelope.
+func IsSealed(data []byte) bool {
+	return len(data) > envelopeHeaderSize && bytes.HasPrefix(data, envelopeMagic)
+}
+
+// load returns the organization's unwrapped keys, reading them from the
+// store when they are not cached. The caller must hold e.mu.
+func (e *Envelope) load(ctx context.Context, orgID uuid.UUID) (*orgKeys, error) {
+	if keys, ok := e.orgs[orgID]; ok {
+		return keys, nil
+	}
+
+	stored, err := e.store.DataKeys(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("secretbox: load data keys: %w", err)
+	}
+	keys := &orgKeys{boxes: make(map[uint32]*Box, len(stored))}
+	for _, dataKey := range stored {
+		raw, err := e.wrapper.UnwrapKey(ctx, dataKey.Wrapped)
+		if err != nil {
+			return nil, fmt.Errorf("secretbox: unwrap data key version %d: %w", dataKey.Version, err)
+		}
+		box, err := New(raw)
+		if err != nil {
+			return nil, err
+		}
+		keys.boxes[dataKey.Version] = box
+		if dataKey.Version > keys.current {
+			keys.current = dataKey.Version
+		}
+	}
+	e.orgs[orgID] = keys
+	return keys, nil
+}
+
+// addKey creates, wraps and stores a new data key for the organization and
+// makes it current. The caller must hold e.mu.
+func (e *Envelope) addKey(ctx context.Context, orgID uuid.UUID, keys *orgKeys) (uint32, error) {
+	raw := make([]byte, KeySize)
+	if _, err := rand.Read(raw); err != nil {
+		return 0, fmt.Errorf("secretbox: generate data key: %w", err)
+	}
+	wrapped, err := e.wrapper.WrapKey(ctx, raw)
+	if err != nil {
+		return 0, fmt.Errorf("secretbox: wrap data key: %w", err)
+	}
+	box, err := New(raw)
+	if err != nil {
+		return 0, err
+	}
+
+	version := keys.current + 1
+	if err := e.store.AddDataKey(ctx, orgID, DataKey{Version: version, Wrapped: wrapped, CreatedAt: time.Now().UTC()}); err != nil {
+		return 0, fmt.Errorf("secretbox: store data key: %w", err)
+	}
+	keys.boxes[version] = box
+	keys.current = version
+	return version, nil
+}
@@ -0,0 +1,139 @@
+package secretbox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestEnvelopeSealOpen(t *testing.T) {
+	ctx := context.Background()
+	e := NewEnvelope(newTestBox(t), NewMemoryKeyStore())
+	orgID := uuid.New()
+
+	sealed, err := e.Seal(ctx, orgID, []byte("client-secret"))
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	if !IsSealed(sealed) {
+		t.Fatal("IsSealed = false for sealed data")
+	}
+
+	plaintext, stale, err := e.Open(ctx, orgID, sealed)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if string(plaintext) != "client-secret" || stale {
+		t.Errorf("Open = %q, stale %v; want the secret, not stale", plaintext, stale)
+	}
+
+	if _, _, err := e.Open(ctx, uuid.New(), sealed); err == nil {
+		t.Error("another organization opened the secret")
+	}
+}
+
+func TestEnvelopeOpenUnsealedData(t *testing.T) {
+	e := NewEnvelope(newTestBox(t), NewMemoryKeyStore())
+	legacy := []byte("stored-before-encryption")
+	if IsSealed(legacy) {
+		t.Fatal("IsSealed = true for plain data")
+	}
+
+	plaintext, stale, err := e.Open(context.Background(), uuid.New(), legacy)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if !bytes.Equal(plaintext, legacy) || !stale {
+		t.Errorf("Open = %q, stale %v; want the data as is and stale", plaintext, stale)
+	}
+}
+
+func TestEnvelopeRotateOrgKey(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryKeyStore()
+	master := newTestBox(t)
+	e := NewEnvelope(master, store)
+	orgID, otherID := uuid.New(), uuid.New()
+
+	old, err := e.Seal(ctx, orgID, []byte("old"))
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	peer := NewEnvelope(master, store)
+	if _, _, err := peer.Open(ctx, orgID, old); err != nil {
+		t.Fatalf("Open on a second instance: %v", err)
+	}
+	other, err := e.Seal(ctx, otherID, []byte("other"))
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+
+	version, err := e.RotateOrgKey(ctx, orgID)
+	if err != nil {
+		t.Fatalf("RotateOrgKey: %v", err)
+	}
+	if version != 2 {
+		t.Errorf("RotateOrgKey = version %d, want 2", version)
+	}
+
+	fresh, err := e.Seal(ctx, orgID, []byte("new"))
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	// An instance sharing the store that loaded the keys before the
+	// rotation finds the new key when it meets a secret sealed under it
+	if _, _, err := peer.Open(ctx, orgID, fresh); err != nil {
+		t.Errorf("Open on an instance that loaded the keys before rotation: %v", err)
+	}
+	tests := []struct {
+		name      string
+		orgID     uuid.UUID
+		sealed    []byte
+		want      string
+		wantStale bool
+	}{
+		{"sealed before rotation", orgID, old, "old", true},
+		{"sealed after rotation", orgID, fresh, "new", false},
+		{"another organization", otherID, other, "other", false},
+	}
+	for _, tt := range tests {
+		plaintext, stale, err := e.Open(ctx, tt.orgID, tt.sealed)
+		if err != nil {
+			t.Errorf("%s: Open: %v", tt.name, err)
+			continue
+		}
+		if string(plaintext) != tt.want || stale != tt.wantStale {
+			t.Errorf("%s: Open = %q, stale %v; want %q, stale %v", tt.name, plaintext, stale, tt.want, tt.wantStale)
+		}
+	}
+}
+
+func TestEnvelopeRevokeOrgKeys(t *testing.T) {
+	ctx := context.Background()
+	e := NewEnvelope(newTestBox(t), NewMemoryKeyStore())
+	orgID, otherID := uuid.New(), uuid.New()
+
+	revoked, _ := e.Seal(ctx, orgID, []byte("revoked"))
+	kept, _ := e.Seal(ctx, otherID, []byte("kept"))
+	if err := e.RevokeOrgKeys(ctx, orgID); err != nil {
+		t.Fatalf("RevokeOrgKeys: %v", err)
+	}
+
+	if _, _, err := e.Open(ctx, orgID, revoked); !errors.Is(err, ErrNoDataKey) {
+		t.Errorf("Open after revoking: err = %v, want ErrNoDataKey", err)
+	}
+	if plaintext, _, err := e.Open(ctx, otherID, kept); err != nil || string(plaintext) != "kept" {
+		t.Errorf("another organization's secret: %q, %v", plaintext, err)
+	}
+
+	sealed, err := e.Seal(ctx, orgID, []byte("after"))
+	if err != nil {
+		t.Fatalf("Seal after revoking: %v", err)
+	}
+	if plaintext, _, err := e.Open(ctx, orgID, sealed); err != nil || string(plaintext) != "after" {
+		t.Errorf("secret sealed after revoking: %q, %v", plaintext, err)
+	}
+}
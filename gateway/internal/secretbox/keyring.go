@@ -0,0 +1,101 @@
+package secretbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+)
+
+// keyringMagic starts data sealed by a Keyring, followed by the version of
+// the key it was sealed under. Data sealed by a Box before keyrings has no
+// header.
+var keyringMagic = []byte{0, 'g', 'w', 'k'}
+
+const keyringHeaderSize = 4 + 4
+
+// Keyring seals data under its current key and opens data sealed under any
+// of its keys, so the key can be rotated: a new key is added as current, the
+// old one is kept while data sealed under it is re-encrypted, then dropped.
+type Keyring struct {
+	current uint32
+	boxes   map[uint32]*Box
+}
+
+// NewKeyring creates a keyring from keys by version, sealing under the
+// current version.
+func NewKeyring(current uint32, keys map[uint32]*Box) (*Keyring, error) {
+	if current == 0 {
+		return nil, fmt.Errorf("secretbox: key versions start at 1")
+	}
+	if keys[current] == nil {
+		return nil, fmt.Errorf("secretbox: no key for current version %d", current)
+	}
+	boxes := make(map[uint32]*Box, len(keys))
+	for version, box := range keys {
+		boxes[version] = box
+	}
+	return &Keyring{current: current, boxes: boxes}, nil
+}
+
+// Current returns the version new data is sealed under.
+func (k *Keyring) Current() uint32 {
+	return k.current
+}
+
+// Seal encrypts plaintext under the current key, recording its version.
+func (k *Keyring) Seal(plaintext []byte) ([]byte, error) {
+	sealed, err := k.boxes[k.current].Seal(plaintext)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, keyringHeaderSize+len(sealed))
+	out = append(out, keyringMagic...)
+	out = binary.BigEndian.AppendUint32(out, k.current)
+	return append(out, sealed...), nil
+}
+
+// Open decrypts data produced by Seal under any key of the keyring. Data
+// sealed by a Box, without a version, is tried under every key.
+func (k *Keyring) Open(sealed []byte) ([]byte, error) {
+	if version, ok := k.Version(sealed); ok {
+		box := k.boxes[version]
+		if box == nil {
+			return nil, fmt.Errorf("%w: key version %d is not in the keyring", ErrDecrypt, version)
+		}
+		return box.Open(sealed[keyringHeaderSize:])
+	}
+	for _, box := range k.boxes {
+		if plaintext, err := box.Open(sealed); err == nil {
+			return plaintext, nil
+		}
+	}
+	return nil, ErrDecrypt
+}
+
+// Stale reports whether data is not sealed under the current key, and so
+// should be re-encrypted before the key it is sealed under is dropped.
+func (k *Keyring) Stale(sealed []byte) bool {
+	version, ok := k.Version(sealed)
+	return !ok || version != k.current
+}
+
+// Version returns the version of the key data was sealed under, or false
+// for data sealed without one.
+func (k *Keyring) Version(sealed []byte) (uint32, bool) {
+	if len(sealed) <= keyringHeaderSize || !bytes.HasPrefix(sealed, keyringMagic) {
+		return 0, false
+	}
+	return binary.BigEndian.Uint32(sealed[len(keyringMagic):keyringHeaderSize]), true
+}
+
+// WrapKey seals a data key under the current key, making a Keyring a
+// KeyWrapper whose master key can be rotated.
+func (k *Keyring) WrapKey(ctx context.Context, key []byte) ([]byte, error) {
+	return k.Seal(key)
+}
+
+// UnwrapKey opens a data key sealed by WrapKey.
+func (k *Keyring) UnwrapKey(ctx context.Context, wrapped []byte) ([]byte, error) {
+	return k.Open(wrapped)
+}
@@ -0,0 +1,124 @@
+package secretbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestKeyringRotation(t *testing.T) {
+	v1, v2 := newTestBox(t), newTestBox(t)
+	legacy, err := v1.Seal([]byte("legacy"))
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	before, _ := NewKeyring(1, map[uint32]*Box{1: v1})
+	old, err := before.Seal([]byte("old"))
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+
+	rotated, err := NewKeyring(2, map[uint32]*Box{1: v1, 2: v2})
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	fresh, err := rotated.Seal([]byte("new"))
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	if version, ok := rotated.Version(fresh); !ok || version != 2 {
+		t.Errorf("Version = %d, %v; want 2", version, ok)
+	}
+
+	tests := []struct {
+		name      string
+		sealed    []byte
+		want      string
+		wantStale bool
+	}{
+		{"sealed by a Box", legacy, "legacy", true},
+		{"sealed under the old key", old, "old", true},
+		{"sealed under the current key", fresh, "new", false},
+	}
+	for _, tt := range tests {
+		plaintext, err := rotated.Open(tt.sealed)
+		if err != nil {
+			t.Errorf("%s: Open: %v", tt.name, err)
+			continue
+		}
+		if string(plaintext) != tt.want {
+			t.Errorf("%s: Open = %q, want %q", tt.name, plaintext, tt.want)
+		}
+		if stale := rotated.Stale(tt.sealed); stale != tt.wantStale {
+			t.Errorf("%s: Stale = %v, want %v", tt.name, stale, tt.wantStale)
+		}
+	}
+
+	// Once the old key is dropped, data still sealed under it is lost
+	dropped, _ := NewKeyring(2, map[uint32]*Box{2: v2})
+	if _, err := dropped.Open(old); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("Open under a dropped key: err = %v, want ErrDecrypt", err)
+	}
+}
+
+func TestNewKeyringRejectsBadVersions(t *testing.T) {
+	box := newTestBox(t)
+	if _, err := NewKeyring(0, map[uint32]*Box{0: box}); err == nil {
+		t.Error("NewKeyring accepted version 0")
+	}
+	if _, err := NewKeyring(2, map[uint32]*Box{1: box}); err == nil {
+		t.Error("NewKeyring accepted a current version without a key")
+	}
+}
+
+// TestEnvelopeReencrypt rotates the master key of an envelope's keyring and
+// checks that, once Reencrypt has run, the old master key can be dropped
+// without losing any organization's secrets.
+func TestEnvelopeReencrypt(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryKeyStore()
+	v1, v2 := newTestBox(t), newTestBox(t)
+
+	before, _ := NewKeyring(1, map[uint32]*Box{1: v1})
+	e := NewEnvelope(before, store)
+	orgs := []uuid.UUID{uuid.New(), uuid.New()}
+	sealed := make(map[uuid.UUID][]byte)
+	for _, orgID := range orgs {
+		s, err := e.Seal(ctx, orgID, []byte("secret of "+orgID.String()))
+		if err != nil {
+			t.Fatalf("Seal: %v", err)
+		}
+		sealed[orgID] = s
+	}
+	if _, err := e.RotateOrgKey(ctx, orgs[0]); err != nil {
+		t.Fatalf("RotateOrgKey: %v", err)
+	}
+
+	rotated, _ := NewKeyring(2, map[uint32]*Box{1: v1, 2: v2})
+	e = NewEnvelope(rotated, store)
+	count, err := e.Reencrypt(ctx)
+	if err != nil {
+		t.Fatalf("Reencrypt: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("Reencrypt rewrapped %d data keys, want 3", count)
+	}
+	if count, err := e.Reencrypt(ctx); err != nil || count != 0 {
+		t.Errorf("second Reencrypt = %d, %v; want nothing left to rewrap", count, err)
+	}
+
+	after, _ := NewKeyring(2, map[uint32]*Box{2: v2})
+	e = NewEnvelope(after, store)
+	for _, orgID := range orgs {
+		plaintext, _, err := e.Open(ctx, orgID, sealed[orgID])
+		if err != nil {
+			t.Errorf("Open without the old master key: %v", err)
+			continue
+		}
+		if string(plaintext) != "secret of "+orgID.String() {
+			t.Errorf("Open = %q", plaintext)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+// Package secretbox encrypts credentials the gateway stores, such as the
+// outbound auth of registered MCP servers, with AES-256-GCM. Secrets that
+// belong to an organization, such as SSO client secrets, are sealed by an
+// Envelope under a data key of the organization's own.
+package secretbox
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// KeySize is the size in bytes of an AES-256 key.
+const KeySize = 32
+
+// ErrDecrypt is returned when sealed data is corrupt or was sealed under a
+// different key.
+var ErrDecrypt = errors.New("secretbox: decryption failed")
+
+// Box seals and opens data under one key.
+type Box struct {
+	aead cipher.AEAD
+}
+
+// New creates a box with a 32-byte key.
+func New(key []byte) (*Box, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("secretbox: key must be %d bytes, got %d", KeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Box{aead: aead}, nil
+}
+
+// NewFromHex creates a box with a key given as 64 hex characters.
+func NewFromHex(key string) (*Box, error) {
+	raw, err := hex.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("secretbox: key is not hex: %w", err)
+	}
+	return New(raw)
+}
+
+// Seal encrypts plaintext under a random nonce, which is prepended to the
+// result.
+func (b *Box) Seal(plaintext []byte) ([]byte, error) {
+	return b.seal(plaintext, nil)
+}
+
+// Open decrypts data produced by Seal.
+func (b *Box) Open(sealed []byte) ([]byte, error) {
+	return b.open(sealed, nil)
+}
+
+// WrapKey seals a data key, making a Box a KeyWrapper whose master key is
+// its own.
+func (b *Box) WrapKey(ctx context.Context, key []byte) ([]byte, error) {
+	return b.Seal(key)
+}
+
+// UnwrapKey opens a data key sealed by WrapKey.
+func (b *Box) UnwrapKey(ctx context.Context, wrapped []byte) ([]byte, error) {
+	return b.Open(wrapped)
+}
+
+// seal encrypts plaintext bound to additional data, which must be given
+// again to open it.
+func (b *Box) seal(plaintext, additional []byte) ([]byte, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("secretbox: generate nonce: %w", err)
+	}
+	return b.aead.Seal(nonce, nonce, plaintext, additional), nil
+}
+
+func (b *Box) open(sealed, additional []byte) ([]byte, error) {
+	size := b.aead.NonceSize()
+	if len(sealed) < size {
+		return nil, ErrDecrypt
+	}
+	plaintext, err := b.aead.Open(nil, sealed[:size], sealed[size:], additional)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
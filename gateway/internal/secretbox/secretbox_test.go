@@ -0,0 +1,86 @@
+package secretbox
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func newTestBox(t *testing.T) *Box {
+	t.Helper()
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	box, err := New(key)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return box
+}
+
+func TestBoxSealOpen(t *testing.T) {
+	box := newTestBox(t)
+	plaintext := []byte("Bearer mcp-token")
+
+	sealed, err := box.Seal(plaintext)
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	if bytes.Contains(sealed, plaintext) {
+		t.Fatal("sealed data contains the plaintext")
+	}
+	again, _ := box.Seal(plaintext)
+	if bytes.Equal(sealed, again) {
+		t.Error("sealing twice gave the same output; nonces are not random")
+	}
+
+	opened, err := box.Open(sealed)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if !bytes.Equal(opened, plaintext) {
+		t.Errorf("Open = %q, want %q", opened, plaintext)
+	}
+}
+
+func TestBoxOpenRejectsTamperedData(t *testing.T) {
+	box := newTestBox(t)
+	sealed, err := box.Seal([]byte("secret"))
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+
+	flipped := append([]byte(nil), sealed...)
+	flipped[len(flipped)-1] ^= 1
+
+	tests := map[string][]byte{
+		"flipped bit": flipped,
+		"truncated":   sealed[:len(sealed)-1],
+		"too short":   sealed[:4],
+		"empty":       nil,
+	}
+	for name, data := range tests {
+		if _, err := box.Open(data); !errors.Is(err, ErrDecrypt) {
+			t.Errorf("%s: err = %v, want ErrDecrypt", name, err)
+		}
+	}
+
+	if _, err := newTestBox(t).Open(sealed); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("another key: err = %v, want ErrDecrypt", err)
+	}
+}
+
+func TestNewRejectsBadKeys(t *testing.T) {
+	if _, err := New(make([]byte, 16)); err == nil {
+		t.Error("New accepted a 16-byte key")
+	}
+	if _, err := NewFromHex("not hex"); err == nil {
+		t.Error("NewFromHex accepted a key that is not hex")
+	}
+	if _, err := NewFromHex(strings.Repeat("ab", KeySize)); err != nil {
+		t.Errorf("NewFromHex with 64 hex characters: %v", err)
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -16,24 +18,81 @@ import (
 
 // Server represents the HTTP server.
 type Server struct {
-	httpServer *http.Server
-	config     *config.Config
-	logger     zerolog.Logger
+	httpServer    *http.Server
+	config        *config.Config
+	logger        zerolog.Logger
+	inFlight      int64
+	shutdownHooks []shutdownHook
+	reloadHooks   []func()
+}
+
+// shutdownHook is a named cleanup function run after HTTP requests have drained.
+type shutdownHook struct {
+	name string
+	fn   func(ctx context.Context) error
 }
 
 // New creates a new HTTP server.
 func New(cfg *config.Config, handler http.Handler, logger zerolog.Logger) *Server {
-	return &Server{
-		httpServer: &http.Server{
-			Addr:         ":" + cfg.Server.Port,
-			Handler:      handler,
-			ReadTimeout:  cfg.Server.ReadTimeout,
-			WriteTimeout: cfg.Server.WriteTimeout,
-			IdleTimeout:  cfg.Server.IdleTimeout,
-		},
+	s := &Server{
 		config: cfg,
 		logger: logger,
 	}
+	s.httpServer = &http.Server{
+		Addr:         ":" + cfg.Server.Port,
+		Handler:      s.trackInFlight(handler),
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
+	}
+	return s
+}
+
+// OnShutdown registers a cleanup function that runs, in registration order,
+// after in-flight HTTP requests have drained.
+func (s *Server) OnShutdown(name string, fn func(ctx context.Context) error) {
+	s.shutdownHooks = append(s.shutdownHooks, shutdownHook{name: name, fn: fn})
+}
+
+// OnReload registers a function that runs when the process receives SIGHUP.
+func (s *Server) OnReload(fn func()) {
+	s.reloadHooks = append(s.reloadHooks, fn)
+}
+
+// InFlight returns the number of HTTP requests currently being served.
+func (s *Server) InFlight() int64 {
+	return atomic.LoadInt64(&s.inFlight)
+}
+
+// trackInFlight counts requests currently being served. Upgrade requests
+// are not counted: their connections are hijacked, so draining does not wait
+// for them.
+func (s *Server) trackInFlight(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isUpgrade(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		atomic.AddInt64(&s.inFlight, 1)
+		defer atomic.AddInt64(&s.inFlight, -1)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isUpgrade reports whether r asks to switch protocols, as a WebSocket
+// handshake does.
+func isUpgrade(r *http.Request) bool {
+	if r.Header.Get("Upgrade") == "" {
+		return false
+	}
+	for _, value := range r.Header.Values("Connection") {
+		for _, token := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(token), "upgrade") {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 // Start starts the HTTP server and blocks until shutdown.
@@ -55,41 +114,105 @@ func (s *Server) Start() error {
 	shutdown := make(chan os.Signal, 1)
 	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
 
-	// Block until we receive a signal or an error
-	select {
-	case err := <-serverErrors:
-		if err != http.ErrServerClosed {
-			return fmt.Errorf("server error: %w", err)
-		}
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	defer signal.Stop(reload)
 
-	case sig := <-shutdown:
-		s.logger.Info().
-			Str("signal", sig.String()).
-			Msg("Received shutdown signal")
+	// Block until we receive a shutdown signal or an error
+	for {
+		select {
+		case err := <-serverErrors:
+			if err != http.ErrServerClosed {
+				return fmt.Errorf("server error: %w", err)
+			}
+			return nil
 
-		// Give outstanding requests time to complete
-		ctx, cancel := context.WithTimeout(context.Background(), s.config.Server.ShutdownTimeout)
-		defer cancel()
+		case <-reload:
+			s.logger.Info().Msg("Received SIGHUP, reloading configuration")
+			for _, fn := range s.reloadHooks {
+				fn()
+			}
 
-		if err := s.httpServer.Shutdown(ctx); err != nil {
-			s.logger.Error().
-				Err(err).
-				Msg("Graceful shutdown failed, forcing shutdown")
+		case sig := <-shutdown:
+			s.logger.Info().
+				Str("signal", sig.String()).
+				Msg("Received shutdown signal")
 
-			if err := s.httpServer.Close(); err != nil {
-				return fmt.Errorf("force close failed: %w", err)
+			// Give outstanding requests time to complete
+			ctx, cancel := context.WithTimeout(context.Background(), s.config.Server.ShutdownTimeout)
+			defer cancel()
+
+			if err := s.drain(ctx); err != nil {
+				return err
 			}
+
+			s.logger.Info().Msg("Server shutdown complete")
+			return nil
 		}
+	}
+}
 
-		s.logger.Info().Msg("Server shutdown complete")
+// Shutdown gracefully shuts down the server.
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.drain(ctx)
+}
+
+// drain stops accepting new connections, waits for in-flight requests to
+// complete within ctx and then runs the registered shutdown hooks. The hooks
+// get their own budget, so a drain that used up ctx still lets them finish.
+func (s *Server) drain(ctx context.Context) error {
+	s.logger.Info().
+		Int64("in_flight", s.InFlight()).
+		Dur("timeout", s.config.Server.ShutdownTimeout).
+		Msg("Draining in-flight requests")
+
+	done := make(chan struct{})
+	go s.logDrainProgress(done)
+
+	err := s.httpServer.Shutdown(ctx)
+	close(done)
+
+	if err != nil {
+		s.logger.Error().
+			Err(err).
+			Int64("in_flight", s.InFlight()).
+			Msg("Graceful shutdown failed, forcing shutdown")
+
+		if err := s.httpServer.Close(); err != nil {
+			return fmt.Errorf("force close failed: %w", err)
+		}
+	} else {
+		s.logger.Info().Msg("In-flight requests drained")
+	}
+
+	hookCtx, cancel := context.WithTimeout(context.Background(), s.config.Server.HookTimeout)
+	defer cancel()
+	for _, hook := range s.shutdownHooks {
+		if err := hook.fn(hookCtx); err != nil {
+			s.logger.Error().Err(err).Str("hook", hook.name).Msg("Shutdown hook failed")
+			continue
+		}
+		s.logger.Info().Str("hook", hook.name).Msg("Shutdown hook complete")
 	}
 
 	return nil
 }
 
-// Shutdown gracefully shuts down the server.
-func (s *Server) Shutdown(ctx context.Context) error {
-	return s.httpServer.Shutdown(ctx)
+// logDrainProgress periodically logs the number of requests still in flight.
+func (s *Server) logDrainProgress(done <-chan struct{}) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			s.logger.Info().
+				Int64("in_flight", s.InFlight()).
+				Msg("Waiting for in-flight requests")
+		}
+	}
 }
 
 // Ready checks if the server is ready to accept requests.
@@ -0,0 +1,151 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/config"
+	"github.com/rs/zerolog"
+)
+
+// startTestServer serves handler on a loopback port and returns its URL.
+func startTestServer(t *testing.T, handler http.Handler, shutdownTimeout time.Duration) (*Server, string) {
+	t.Helper()
+	cfg := &config.Config{Server: config.ServerConfig{ShutdownTimeout: shutdownTimeout, HookTimeout: time.Second}}
+	s := New(cfg, handler, zerolog.Nop())
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go s.httpServer.Serve(ln)
+	return s, "http://" + ln.Addr().String()
+}
+
+func TestDrainWaitsForRequestsThenRunsHooksInOrder(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	s, url := startTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusNoContent)
+	}), 5*time.Second)
+
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+	hook := func(name string) func(context.Context) error {
+		return func(ctx context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if ctx.Err() != nil {
+				t.Errorf("hook %s got an expired context", name)
+			}
+			order = append(order, name)
+			return nil
+		}
+	}
+	s.OnShutdown("first", hook("first"))
+	s.OnShutdown("second", hook("second"))
+	s.OnShutdown("third", hook("third"))
+
+	status := make(chan int, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			status <- 0
+			return
+		}
+		resp.Body.Close()
+		status <- resp.StatusCode
+	}()
+	<-started
+	if n := s.InFlight(); n != 1 {
+		t.Fatalf("in flight = %d, want 1", n)
+	}
+
+	drained := make(chan error, 1)
+	go func() { drained <- s.Shutdown(context.Background()) }()
+
+	select {
+	case <-drained:
+		t.Fatal("shutdown finished while a request was in flight")
+	case <-time.After(50 * time.Millisecond):
+	}
+	mu.Lock()
+	if len(order) != 0 {
+		t.Errorf("hooks ran before the request drained: %v", order)
+	}
+	mu.Unlock()
+
+	close(release)
+	if err := <-drained; err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if got := <-status; got != http.StatusNoContent {
+		t.Errorf("in-flight request finished with status %d, want 204", got)
+	}
+	if len(order) != 3 || order[0] != "first" || order[1] != "second" || order[2] != "third" {
+		t.Errorf("hooks ran in order %v, want registration order", order)
+	}
+}
+
+func TestHooksGetTheirOwnBudgetAfterAnExpiredDrain(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	s, url := startTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	}), 20*time.Millisecond)
+
+	var hookErr error
+	s.OnShutdown("cleanup", func(ctx context.Context) error {
+		hookErr = ctx.Err()
+		return nil
+	})
+
+	go http.Get(url)
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if hookErr != nil {
+		t.Errorf("hook context = %v after the drain timed out, want its own budget", hookErr)
+	}
+}
+
+func TestTrackInFlightSkipsUpgradedRequests(t *testing.T) {
+	s := &Server{}
+	var seen int64
+	h := s.trackInFlight(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = s.InFlight()
+	}))
+
+	for _, tt := range []struct {
+		name       string
+		connection string
+		upgrade    string
+		want       int64
+	}{
+		{"plain request", "keep-alive", "", 1},
+		{"websocket handshake", "Upgrade", "websocket", 0},
+		{"upgrade among connection tokens", "keep-alive, upgrade", "websocket", 0},
+		{"upgrade header without connection token", "keep-alive", "websocket", 1},
+	} {
+		req, _ := http.NewRequest(http.MethodGet, "/v1/agents/connect", nil)
+		req.Header.Set("Connection", tt.connection)
+		if tt.upgrade != "" {
+			req.Header.Set("Upgrade", tt.upgrade)
+		}
+		h.ServeHTTP(nil, req)
+		if seen != tt.want {
+			t.Errorf("%s: in flight while serving = %d, want %d", tt.name, seen, tt.want)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package siem
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+)
+
+// Message formats.
+const (
+	FormatCEF  = "cef"  // ArcSight Common Event Format
+	FormatLEEF = "leef" // IBM QRadar Log Event Extended Format
+)
+
+// Identity of the events' source in CEF and LEEF headers.
+const (
+	vendor         = "GatewayOps"
+	product        = "Gateway"
+	productVersion = "1.0"
+	appName        = "gatewayops"
+)
+
+// facilityAuth is the syslog facility of security events.
+const facilityAuth = 4
+
+// severityRanks maps detection severities to the 0-10 scale of CEF and
+// LEEF, and to syslog severities.
+var severityRanks = map[domain.DetectionSeverity]struct {
+	scale  int
+	syslog int
+}{
+	domain.DetectionSeverityLow:      {3, 5},  // notice
+	domain.DetectionSeverityMedium:   {5, 4},  // warning
+	domain.DetectionSeverityHigh:     {8, 3},  // error
+	domain.DetectionSeverityCritical: {10, 2}, // critical
+}
+
+// Format renders a detection as a message in format: CEF, or LEEF.
+func Format(format string, detection domain.InjectionDetection) string {
+	if format == FormatLEEF {
+		return formatLEEF(detection)
+	}
+	return formatCEF(detection)
+}
+
+// formatCEF renders a detection as a CEF message.
+func formatCEF(d domain.InjectionDetection) string {
+	header := []string{
+		"CEF:0",
+		cefHeader(vendor),
+		cefHeader(product),
+		cefHeader(productVersion),
+		cefHeader(string(d.Type)),
+		cefHeader(detectionName(d.Type)),
+		strconv.Itoa(severityRanks[d.Severity].scale),
+	}
+
+	var ext []string
+	add := func(key, value string) {
+		if value != "" {
+			ext = append(ext, key+"="+cefExtension(value))
+		}
+	}
+	add("rt", strconv.FormatInt(d.CreatedAt.UnixMilli(), 10))
+	add("externalId", d.ID.String())
+	add("act", string(d.ActionTaken))
+	custom := func(n int, label, value string) {
+		if value != "" {
+			add(fmt.Sprintf("cs%dLabel", n), label)
+			add(fmt.Sprintf("cs%d", n), value)
+		}
+	}
+	custom(1, "pattern", d.PatternMatched)
+	custom(2, "mcpServer", d.MCPServer)
+	custom(3, "tool", d.ToolName)
+	custom(4, "traceId", d.TraceID)
+	custom(5, "orgId", d.OrgID.String())
+	add("suser", principal(d))
+	add("src", d.IPAddress)
+
+	return strings.Join(header, "|") + "|" + strings.Join(ext, " ")
+}
+
+// formatLEEF renders a detection as a LEEF 1.0 message, its attributes
+// separated by tabs.
+func formatLEEF(d domain.InjectionDetection) string {
+	header := []string{
+		"LEEF:1.0",
+		leefHeader(vendor),
+		leefHeader(product),
+		leefHeader(productVersion),
+		leefHeader(string(d.Type)),
+	}
+
+	var attrs []string
+	add := func(key, value string) {
+		if value != "" {
+			attrs = append(attrs, key+"="+leefAttribute(value))
+		}
+	}
+	add("devTime", d.CreatedAt.UTC().Format("Jan 02 2006 15:04:05.000 UTC"))
+	add("devTimeFormat", "MMM dd yyyy HH:mm:ss.SSS z")
+	add("cat", string(d.Type))
+	add("sev", strconv.Itoa(severityRanks[d.Severity].scale))
+	add("externalId", d.ID.String())
+	add("action", string(d.ActionTaken))
+	add("pattern", d.PatternMatched)
+	add("mcpServer", d.MCPServer)
+	add("tool", d.ToolName)
+	add("traceId", d.TraceID)
+	add("orgId", d.OrgID.String())
+	add("usrName", principal(d))
+	add("src", d.IPAddress)
+
+	return strings.Join(header, "|") + "|" + strings.Join(attrs, "\t")
+}
+
+// syslogLine wraps a message in an RFC 5424 syslog header.
+func syslogLine(hostname string, detection domain.InjectionDetection, message string) string {
+	severity, ok := severityRanks[detection.Severity]
+	if !ok {
+		severity.syslog = 4
+	}
+	if hostname == "" {
+		hostname = "-"
+	}
+	return fmt.Sprintf("<%d>1 %s %s %s - %s - %s",
+		facilityAuth*8+severity.syslog,
+		detection.CreatedAt.UTC().Format(time.RFC3339Nano),
+		hostname, appName, string(detection.Type), message)
+}
+
+// detectionNames are the readable names of detection types.
+var detectionNames = map[domain.DetectionType]string{
+	domain.DetectionTypePromptInjection: "Prompt injection detected",
+	domain.DetectionTypePII:             "PII detected",
+	domain.DetectionTypeSecret:          "Secret detected",
+	domain.DetectionTypeMalicious:       "Malicious content detected",
+}
+
+// detectionName returns a readable name for a detection type.
+func detectionName(t domain.DetectionType) string {
+	if name, ok := detectionNames[t]; ok {
+		return name
+	}
+	return string(t) + " detected"
+}
+
+// principal identifies who made the request: its API key, or nothing for
+// requests without one.
+func principal(d domain.InjectionDetection) string {
+	if d.APIKeyID != nil {
+		return d.APIKeyID.String()
+	}
+	return ""
+}
+
+var (
+	cefHeaderEscaper    = strings.NewReplacer(`\`, `\\`, "|", `\|`, "\r", " ", "\n", " ")
+	cefExtensionEscaper = strings.NewReplacer(`\`, `\\`, "=", `\=`, "\r", `\r`, "\n", `\n`)
+	leefHeaderEscaper   = strings.NewReplacer(`\`, `\\`, "|", `\|`, "\r", " ", "\n", " ")
+	leefAttrEscaper     = strings.NewReplacer("\t", " ", "\r", " ", "\n", " ")
+)
+
+func cefHeader(value string) string     { return cefHeaderEscaper.Replace(value) }
+func cefExtension(value string) string  { return cefExtensionEscaper.Replace(value) }
+func leefHeader(value string) string    { return leefHeaderEscaper.Replace(value) }
+func leefAttribute(value string) string { return leefAttrEscaper.Replace(value) }
@@ -0,0 +1,83 @@
+package siem
+
+import (
+	"testing"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/google/uuid"
+)
+
+func testDetection() domain.InjectionDetection {
+	apiKey := uuid.MustParse("22222222-2222-2222-2222-222222222222")
+	return domain.InjectionDetection{
+		ID:             uuid.MustParse("11111111-1111-1111-1111-111111111111"),
+		OrgID:          uuid.MustParse("33333333-3333-3333-3333-333333333333"),
+		TraceID:        "trace-1",
+		Type:           domain.DetectionTypePromptInjection,
+		Severity:       domain.DetectionSeverityHigh,
+		PatternMatched: `ignore|previous=all\`,
+		ActionTaken:    domain.SafetyModeBlock,
+		MCPServer:      "filesystem",
+		ToolName:       "read_file",
+		APIKeyID:       &apiKey,
+		IPAddress:      "192.0.2.7",
+		CreatedAt:      time.Date(2026, 1, 2, 3, 4, 5, 6000000, time.UTC),
+	}
+}
+
+func TestFormatCEF(t *testing.T) {
+	want := `CEF:0|GatewayOps|Gateway|1.0|prompt_injection|Prompt injection detected|8|` +
+		`rt=1767323045006 externalId=11111111-1111-1111-1111-111111111111 act=block ` +
+		`cs1Label=pattern cs1=ignore|previous\=all\\ cs2Label=mcpServer cs2=filesystem ` +
+		`cs3Label=tool cs3=read_file cs4Label=traceId cs4=trace-1 ` +
+		`cs5Label=orgId cs5=33333333-3333-3333-3333-333333333333 ` +
+		`suser=22222222-2222-2222-2222-222222222222 src=192.0.2.7`
+	if got := Format(FormatCEF, testDetection()); got != want {
+		t.Errorf("CEF =\n%s\nwant\n%s", got, want)
+	}
+
+	// Fields left empty are left out; header fields escape pipes
+	d := testDetection()
+	d.Type = "custom|type"
+	d.PatternMatched, d.APIKeyID, d.IPAddress = "", nil, ""
+	want = `CEF:0|GatewayOps|Gateway|1.0|custom\|type|custom\|type detected|8|` +
+		`rt=1767323045006 externalId=11111111-1111-1111-1111-111111111111 act=block ` +
+		`cs2Label=mcpServer cs2=filesystem cs3Label=tool cs3=read_file cs4Label=traceId cs4=trace-1 ` +
+		`cs5Label=orgId cs5=33333333-3333-3333-3333-333333333333`
+	if got := Format(FormatCEF, d); got != want {
+		t.Errorf("CEF =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestFormatLEEF(t *testing.T) {
+	d := testDetection()
+	d.PatternMatched = "ignore\tprevious\ninstructions"
+	want := "LEEF:1.0|GatewayOps|Gateway|1.0|prompt_injection|" +
+		"devTime=Jan 02 2026 03:04:05.006 UTC\tdevTimeFormat=MMM dd yyyy HH:mm:ss.SSS z\t" +
+		"cat=prompt_injection\tsev=8\texternalId=11111111-1111-1111-1111-111111111111\taction=block\t" +
+		"pattern=ignore previous instructions\tmcpServer=filesystem\ttool=read_file\ttraceId=trace-1\t" +
+		"orgId=33333333-3333-3333-3333-333333333333\tusrName=22222222-2222-2222-2222-222222222222\tsrc=192.0.2.7"
+	if got := Format(FormatLEEF, d); got != want {
+		t.Errorf("LEEF =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestSyslogLine(t *testing.T) {
+	tests := []struct {
+		severity domain.DetectionSeverity
+		hostname string
+		want     string
+	}{
+		{domain.DetectionSeverityCritical, "gw-1", "<34>1 2026-01-02T03:04:05.006Z gw-1 gatewayops - prompt_injection - msg"},
+		{domain.DetectionSeverityLow, "", "<37>1 2026-01-02T03:04:05.006Z - gatewayops - prompt_injection - msg"},
+		{"unknown", "gw-1", "<36>1 2026-01-02T03:04:05.006Z gw-1 gatewayops - prompt_injection - msg"},
+	}
+	for _, tt := range tests {
+		d := testDetection()
+		d.Severity = tt.severity
+		if got := syslogLine(tt.hostname, d, "msg"); got != tt.want {
+			t.Errorf("%s: syslog line = %q, want %q", tt.severity, got, tt.want)
+		}
+	}
+}
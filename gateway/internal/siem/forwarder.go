@@ -0,0 +1,174 @@
+// Package siem forwards injection detections to a SIEM as CEF or LEEF
+// messages over syslog, so that safety events reach existing SOC tooling.
+package siem
+
+import (
+	"context"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/config"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/rs/zerolog"
+)
+
+// sendTimeout bounds connecting to the collector and writing one message.
+const sendTimeout = 5 * time.Second
+
+// Forwarder sends detections to a syslog collector. Detections are queued
+// while the collector is unreachable, up to the buffer size, beyond which
+// the oldest are dropped; sending is retried after the retry interval.
+type Forwarder struct {
+	cfg      config.SIEMConfig
+	hostname string
+	logger   zerolog.Logger
+
+	// Owned by the run loop
+	queue   []domain.InjectionDetection
+	dropped int
+	conn    net.Conn
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewForwarder creates a forwarder to the collector in cfg.
+func NewForwarder(cfg config.SIEMConfig, logger zerolog.Logger) *Forwarder {
+	hostname, _ := os.Hostname()
+	return &Forwarder{
+		cfg:      cfg,
+		hostname: hostname,
+		logger:   logger,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start forwards the detections received on detections until Shutdown,
+// then calls cancel to end the subscription.
+func (f *Forwarder) Start(detections <-chan domain.InjectionDetection, cancel func()) {
+	go f.run(detections, cancel)
+	f.logger.Info().
+		Str("address", f.cfg.Address).
+		Str("network", f.cfg.Network).
+		Str("format", f.cfg.Format).
+		Msg("SIEM forwarding started")
+}
+
+// Shutdown makes a last attempt to send the queued detections and closes
+// the connection to the collector.
+func (f *Forwarder) Shutdown(ctx context.Context) error {
+	f.stopOnce.Do(func() { close(f.stop) })
+	select {
+	case <-f.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (f *Forwarder) run(detections <-chan domain.InjectionDetection, cancel func()) {
+	defer close(f.done)
+	defer cancel()
+	defer f.disconnect()
+
+	var retry <-chan time.Time
+	for {
+		select {
+		case detection := <-detections:
+			f.enqueue(detection)
+		case <-retry:
+			retry = nil
+		case <-f.stop:
+			f.drain(detections)
+			if retry == nil {
+				f.flush()
+			}
+			if len(f.queue) > 0 {
+				f.logger.Warn().Int("detections", len(f.queue)).Msg("Detections not forwarded to SIEM at shutdown")
+			}
+			return
+		}
+
+		if retry == nil && !f.flush() {
+			retry = time.After(f.cfg.RetryInterval)
+		}
+	}
+}
+
+// drain queues the detections already received on the subscription.
+func (f *Forwarder) drain(detections <-chan domain.InjectionDetection) {
+	for {
+		select {
+		case detection := <-detections:
+			f.enqueue(detection)
+		default:
+			return
+		}
+	}
+}
+
+// enqueue adds a detection to the queue, dropping the oldest when it is full.
+func (f *Forwarder) enqueue(detection domain.InjectionDetection) {
+	if len(f.queue) >= f.cfg.BufferSize {
+		f.queue = f.queue[1:]
+		f.dropped++
+	}
+	f.queue = append(f.queue, detection)
+}
+
+// flush sends the queued detections in order, reporting whether all were
+// sent. The detection that failed stays queued for the next attempt.
+func (f *Forwarder) flush() bool {
+	for len(f.queue) > 0 {
+		if err := f.send(f.queue[0]); err != nil {
+			f.logger.Error().
+				Err(err).
+				Str("address", f.cfg.Address).
+				Int("queued", len(f.queue)).
+				Dur("retry_in", f.cfg.RetryInterval).
+				Msg("Failed to forward detection to SIEM")
+			f.disconnect()
+			return false
+		}
+		f.queue = f.queue[1:]
+	}
+
+	if f.dropped > 0 {
+		f.logger.Warn().Int("dropped", f.dropped).Msg("SIEM buffer was full; oldest detections were not forwarded")
+		f.dropped = 0
+	}
+	f.queue = nil
+	return true
+}
+
+// send writes one detection to the collector, connecting first if needed.
+// Over TCP each message ends with a newline.
+func (f *Forwarder) send(detection domain.InjectionDetection) error {
+	if f.conn == nil {
+		conn, err := net.DialTimeout(f.cfg.Network, f.cfg.Address, sendTimeout)
+		if err != nil {
+			return err
+		}
+		f.conn = conn
+	}
+
+	line := syslogLine(f.hostname, detection, Format(f.cfg.Format, detection))
+	if f.cfg.Network == "tcp" {
+		line += "\n"
+	}
+	f.conn.SetWriteDeadline(time.Now().Add(sendTimeout))
+	_, err := f.conn.Write([]byte(line))
+	return err
+}
+
+// disconnect closes the connection to the collector, if open.
+func (f *Forwarder) disconnect() {
+	if f.conn != nil {
+		f.conn.Close()
+		f.conn = nil
+	}
+}
@@ -0,0 +1,121 @@
+package siem
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/config"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/rs/zerolog"
+)
+
+// collector accepts syslog connections on ln and sends each line received.
+func collector(t *testing.T, ln net.Listener) <-chan string {
+	t.Helper()
+	lines := make(chan string, 16)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				scanner := bufio.NewScanner(conn)
+				for scanner.Scan() {
+					lines <- scanner.Text()
+				}
+			}()
+		}
+	}()
+	return lines
+}
+
+func startForwarder(t *testing.T, cfg config.SIEMConfig) chan<- domain.InjectionDetection {
+	t.Helper()
+	f := NewForwarder(cfg, zerolog.Nop())
+	detections := make(chan domain.InjectionDetection)
+	f.Start(detections, func() {})
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		if err := f.Shutdown(ctx); err != nil {
+			t.Errorf("Shutdown: %v", err)
+		}
+	})
+	return detections
+}
+
+func receive(t *testing.T, lines <-chan string) string {
+	t.Helper()
+	select {
+	case line := <-lines:
+		return line
+	case <-time.After(2 * time.Second):
+		t.Fatal("collector received nothing")
+		return ""
+	}
+}
+
+func TestForwarderSendsOverTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	lines := collector(t, ln)
+
+	detections := startForwarder(t, config.SIEMConfig{Address: ln.Addr().String(), Network: "tcp", Format: FormatLEEF, BufferSize: 10, RetryInterval: time.Second})
+	d := testDetection()
+	detections <- d
+	d.TraceID = "trace-2"
+	detections <- d
+
+	for _, trace := range []string{"trace-1", "trace-2"} {
+		line := receive(t, lines)
+		if !strings.HasPrefix(line, "<35>1 ") || !strings.Contains(line, "LEEF:1.0|") || !strings.Contains(line, "traceId="+trace) {
+			t.Errorf("line = %q, want a LEEF syslog message for %s", line, trace)
+		}
+	}
+}
+
+// TestForwarderQueuesWhileCollectorDown sends detections while nothing
+// listens, then starts the collector: the detections that fit the buffer
+// arrive in order, the oldest ones having been dropped.
+func TestForwarderQueuesWhileCollectorDown(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	detections := startForwarder(t, config.SIEMConfig{Address: addr, Network: "tcp", Format: FormatCEF, BufferSize: 2, RetryInterval: 20 * time.Millisecond})
+	for _, trace := range []string{"trace-1", "trace-2", "trace-3"} {
+		d := testDetection()
+		d.TraceID = trace
+		detections <- d
+	}
+
+	ln, err = net.Listen("tcp", addr)
+	if err != nil {
+		t.Skipf("port %s was taken in the meantime: %v", addr, err)
+	}
+	defer ln.Close()
+	lines := collector(t, ln)
+
+	for _, trace := range []string{"trace-2", "trace-3"} {
+		if line := receive(t, lines); !strings.Contains(line, "cs4="+trace+" ") {
+			t.Errorf("line = %q, want the detection of %s", line, trace)
+		}
+	}
+	select {
+	case line := <-lines:
+		t.Errorf("unexpected line %q", line)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,287 @@
+package sso
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/alerting"
+	"github.com/akz4ol/gatewayops/gateway/internal/clock"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// minTravelDistance is the distance in km below which two logins are
+	// never impossible travel, since IP geolocation is rarely more precise.
+	minTravelDistance = 100
+	// maxLoginHistory is the most logins remembered per user.
+	maxLoginHistory = 50
+	earthRadiusKm   = 6371
+)
+
+// Location is where an IP address is, in degrees.
+type Location struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// GeoResolver locates IP addresses. Implementations must be safe for
+// concurrent use.
+type GeoResolver interface {
+	// Locate returns where ip is, or false when it is not known.
+	Locate(ip string) (Location, bool)
+}
+
+// CIDRGeoResolver locates addresses from a fixed table of CIDR blocks, so no
+// lookup leaves the gateway. The most specific block containing an address
+// wins.
+type CIDRGeoResolver struct {
+	blocks []geoBlock // Most specific first
+}
+
+type geoBlock struct {
+	network  *net.IPNet
+	location Location
+}
+
+// NewCIDRGeoResolver creates a resolver from a table of CIDR blocks.
+func NewCIDRGeoResolver(table map[string]Location) (*CIDRGeoResolver, error) {
+	r := &CIDRGeoResolver{blocks: make([]geoBlock, 0, len(table))}
+	for cidr, location := range table {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("geo location %q: %w", cidr, err)
+		}
+		r.blocks = append(r.blocks, geoBlock{network: network, location: location})
+	}
+	sort.Slice(r.blocks, func(i, j int) bool {
+		oi, _ := r.blocks[i].network.Mask.Size()
+		oj, _ := r.blocks[j].network.Mask.Size()
+		return oi > oj
+	})
+	return r, nil
+}
+
+// Locate implements GeoResolver.
+func (r *CIDRGeoResolver) Locate(ip string) (Location, bool) {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return Location{}, false
+	}
+	for _, block := range r.blocks {
+		if block.network.Contains(addr) {
+			return block.location, true
+		}
+	}
+	return Location{}, false
+}
+
+// AnomalyPolicy decides which SSO logins are flagged. Each check is off at
+// its zero value.
+type AnomalyPolicy struct {
+	MaxTravelSpeed  float64       // km/h between consecutive located logins above which travel is impossible
+	NewDevice       bool          // Flag logins from a user agent the user has not logged in with before
+	MaxSessions     int           // Active sessions a user may have; a login beyond them is flagged
+	StepUp          bool          // Flagged logins must have been made with MFA
+	StepUpACRValues []string      // acr values that count as MFA, besides MFA amr methods
+	History         time.Duration // How long a user's logins are remembered
+}
+
+// loginRecord is one remembered login of a user.
+type loginRecord struct {
+	at        time.Time
+	ip        string
+	location  *Location
+	userAgent string
+}
+
+// AnomalyDetector compares each SSO login with the user's recent ones and
+// raises an alert for suspicious patterns: impossible travel, a new device,
+// or too many concurrent sessions. It is safe for concurrent use.
+type AnomalyDetector struct {
+	policy AnomalyPolicy
+	geo    GeoResolver
+	logger zerolog.Logger
+	alerts *alerting.Service
+	clock  clock.Clock
+
+	mu     sync.Mutex
+	logins map[uuid.UUID][]loginRecord // Oldest first
+}
+
+// NewAnomalyDetector creates a detector enforcing policy. Without geo,
+// impossible travel is not detected; without alerts, anomalies are only
+// logged.
+func NewAnomalyDetector(policy AnomalyPolicy, geo GeoResolver, logger zerolog.Logger, alerts *alerting.Service, clk clock.Clock) *AnomalyDetector {
+	return &AnomalyDetector{
+		policy: policy,
+		geo:    geo,
+		logger: logger,
+		alerts: alerts,
+		clock:  clk,
+		logins: make(map[uuid.UUID][]loginRecord),
+	}
+}
+
+// Check compares a login by user from ip and userAgent with the user's
+// recent logins, remembers it, and raises an alert for each anomaly found.
+// activeSessions is the number of sessions the user already has.
+func (d *AnomalyDetector) Check(user *domain.User, ip, userAgent string, activeSessions int) []domain.LoginAnomaly {
+	if d == nil {
+		return nil
+	}
+
+	now := d.clock.Now()
+	login := loginRecord{at: now, ip: ip, userAgent: userAgent}
+	if d.geo != nil && ip != "" {
+		if location, ok := d.geo.Locate(ip); ok {
+			login.location = &location
+		}
+	}
+
+	d.mu.Lock()
+	history := d.recentLogins(user.ID, now)
+	anomalies := make([]domain.LoginAnomaly, 0)
+	if anomaly, ok := d.impossibleTravel(history, login); ok {
+		anomalies = append(anomalies, anomaly)
+	}
+	if anomaly, ok := d.newDevice(history, login); ok {
+		anomalies = append(anomalies, anomaly)
+	}
+	if d.policy.MaxSessions > 0 && activeSessions >= d.policy.MaxSessions {
+		anomalies = append(anomalies, domain.LoginAnomaly{
+			Type:    domain.LoginAnomalyConcurrentSessions,
+			Message: fmt.Sprintf("login would give the user %d active sessions, more than the %d allowed", activeSessions+1, d.policy.MaxSessions),
+		})
+	}
+	history = append(history, login)
+	if len(history) > maxLoginHistory {
+		history = history[len(history)-maxLoginHistory:]
+	}
+	d.logins[user.ID] = history
+	d.mu.Unlock()
+
+	for _, anomaly := range anomalies {
+		d.raise(user, ip, anomaly)
+	}
+	return anomalies
+}
+
+// RequiresStepUp reports whether a login with anomalies must be repeated
+// with MFA: the policy asks for it and the login's claims show no MFA.
+func (d *AnomalyDetector) RequiresStepUp(anomalies []domain.LoginAnomaly, claims *domain.OIDCClaims) bool {
+	if d == nil || !d.policy.StepUp || len(anomalies) == 0 {
+		return false
+	}
+	session := domain.UserSession{ACR: claims.ACR, AMR: claims.AMR}
+	return !session.MFAVerified(d.policy.StepUpACRValues)
+}
+
+// recentLogins returns the user's logins within the history window, and
+// forgets users with none left. The caller must hold d.mu.
+func (d *AnomalyDetector) recentLogins(userID uuid.UUID, now time.Time) []loginRecord {
+	cutoff := now.Add(-d.policy.History)
+	if _, ok := d.logins[userID]; !ok {
+		for id, logins := range d.logins {
+			if len(logins) == 0 || logins[len(logins)-1].at.Before(cutoff) {
+				delete(d.logins, id)
+			}
+		}
+	}
+	logins := d.logins[userID]
+	i := 0
+	for i < len(logins) && logins[i].at.Before(cutoff) {
+		i++
+	}
+	return logins[i:]
+}
+
+// impossibleTravel compares a login with the user's last located login: the
+// user cannot have covered the distance between them in the time between
+// them.
+func (d *AnomalyDetector) impossibleTravel(history []loginRecord, login loginRecord) (domain.LoginAnomaly, bool) {
+	if d.policy.MaxTravelSpeed <= 0 || login.location == nil {
+		return domain.LoginAnomaly{}, false
+	}
+	for i := len(history) - 1; i >= 0; i-- {
+		previous := history[i]
+		if previous.location == nil {
+			continue
+		}
+		distance := distanceKm(*previous.location, *login.location)
+		if distance < minTravelDistance {
+			return domain.LoginAnomaly{}, false
+		}
+		hours := login.at.Sub(previous.at).Hours()
+		if hours > 0 && distance/hours <= d.policy.MaxTravelSpeed {
+			return domain.LoginAnomaly{}, false
+		}
+		return domain.LoginAnomaly{
+			Type: domain.LoginAnomalyImpossibleTravel,
+			Message: fmt.Sprintf("login from %s is %.0f km from the login from %s %s earlier",
+				login.ip, distance, previous.ip, login.at.Sub(previous.at).Round(time.Second)),
+		}, true
+	}
+	return domain.LoginAnomaly{}, false
+}
+
+// newDevice reports a login from a user agent none of the user's recent
+// logins used. A user's first login is not flagged.
+func (d *AnomalyDetector) newDevice(history []loginRecord, login loginRecord) (domain.LoginAnomaly, bool) {
+	if !d.policy.NewDevice || len(history) == 0 || login.userAgent == "" {
+		return domain.LoginAnomaly{}, false
+	}
+	for _, previous := range history {
+		if strings.EqualFold(previous.userAgent, login.userAgent) {
+			return domain.LoginAnomaly{}, false
+		}
+	}
+	return domain.LoginAnomaly{
+		Type:    domain.LoginAnomalyNewDevice,
+		Message: fmt.Sprintf("login from a device not seen before: %s", login.userAgent),
+	}, true
+}
+
+// raise logs an anomaly and alerts on it through the organization's
+// alerting channels.
+func (d *AnomalyDetector) raise(user *domain.User, ip string, anomaly domain.LoginAnomaly) {
+	d.logger.Warn().
+		Str("org_id", user.OrgID.String()).
+		Str("user_id", user.ID.String()).
+		Str("ip", ip).
+		Str("anomaly", string(anomaly.Type)).
+		Msg("Anomalous SSO login")
+	if d.alerts == nil {
+		return
+	}
+
+	severity := domain.AlertSeverityWarning
+	if anomaly.Type == domain.LoginAnomalyImpossibleTravel {
+		severity = domain.AlertSeverityCritical
+	}
+	d.alerts.CreateSystemAlert(
+		user.OrgID,
+		"SSO: anomalous login",
+		domain.AlertMetricLoginAnomaly,
+		severity,
+		1,
+		1,
+		fmt.Sprintf("%s: %s", user.Email, anomaly.Message),
+		nil,
+	)
+}
+
+// distanceKm returns the great-circle distance between two locations.
+func distanceKm(a, b Location) float64 {
+	lat1, lat2 := a.Latitude*math.Pi/180, b.Latitude*math.Pi/180
+	dLat := lat2 - lat1
+	dLon := (b.Longitude - a.Longitude) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
@@ -0,0 +1,73 @@
+package sso
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/alerting"
+	"github.com/akz4ol/gatewayops/gateway/internal/clock"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// stubGeo locates a fixed set of addresses.
+type stubGeo map[string]Location
+
+func (g stubGeo) Locate(ip string) (Location, bool) {
+	location, ok := g[ip]
+	return location, ok
+}
+
+func TestLoginsFromFarApartRaiseAnAnomaly(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC))
+	alerts := alerting.NewService(zerolog.Nop(), nil, nil, 100, clk)
+	t.Cleanup(func() { alerts.Shutdown(context.Background()) })
+	geo := stubGeo{
+		"198.51.100.1": {Latitude: 40.71, Longitude: -74.01}, // New York
+		"198.51.100.2": {Latitude: 40.73, Longitude: -73.99}, // New York, another exchange
+		"203.0.113.9":  {Latitude: 51.51, Longitude: -0.13},  // London, about 5570 km away
+	}
+	d := NewAnomalyDetector(AnomalyPolicy{MaxTravelSpeed: 1000, StepUp: true, History: 24 * time.Hour}, geo, zerolog.Nop(), alerts, clk)
+	user := &domain.User{ID: uuid.New(), OrgID: uuid.New(), Email: "ada@example.com"}
+
+	if got := d.Check(user, "198.51.100.1", "laptop", 0); len(got) != 0 {
+		t.Fatalf("first login flagged: %+v", got)
+	}
+	clk.Advance(10 * time.Minute)
+	if got := d.Check(user, "198.51.100.2", "laptop", 1); len(got) != 0 {
+		t.Fatalf("login from the same city flagged: %+v", got)
+	}
+
+	clk.Advance(10 * time.Minute)
+	anomalies := d.Check(user, "203.0.113.9", "laptop", 1)
+	if len(anomalies) != 1 || anomalies[0].Type != domain.LoginAnomalyImpossibleTravel {
+		t.Fatalf("anomalies = %+v, want impossible travel", anomalies)
+	}
+	if !strings.HasPrefix(anomalies[0].Message, "login from 203.0.113.9 is 55") || !strings.HasSuffix(anomalies[0].Message, "from 198.51.100.2 10m0s earlier") {
+		t.Errorf("message = %q", anomalies[0].Message)
+	}
+
+	page := alerts.GetAlerts(domain.AlertFilter{OrgID: user.OrgID})
+	if len(page.Alerts) != 1 {
+		t.Fatalf("%d alerts, want 1", len(page.Alerts))
+	}
+	if alert := page.Alerts[0]; alert.Severity != domain.AlertSeverityCritical || !strings.HasPrefix(alert.Message, "ada@example.com: login from 203.0.113.9") {
+		t.Errorf("alert = %s %q, want a critical alert naming the user", alert.Severity, alert.Message)
+	}
+
+	if !d.RequiresStepUp(anomalies, &domain.OIDCClaims{}) {
+		t.Error("flagged login without MFA was not sent back for step-up")
+	}
+	if d.RequiresStepUp(anomalies, &domain.OIDCClaims{AMR: []string{"mfa"}}) {
+		t.Error("flagged login made with MFA was sent back for step-up")
+	}
+
+	// A day later the flight is plausible
+	clk.Advance(24 * time.Hour)
+	if got := d.Check(user, "198.51.100.1", "laptop", 0); len(got) != 0 {
+		t.Errorf("login after a plausible trip flagged: %+v", got)
+	}
+}
@@ -0,0 +1,178 @@
+package sso
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/google/uuid"
+)
+
+// Canonical claims that NormalizeClaims looks for under provider-specific
+// names. A provider's claim mappings use these as keys.
+const (
+	claimEmail  = "email"
+	claimName   = "name"
+	claimGroups = "groups"
+)
+
+// defaultClaimNames are the raw claims read for each canonical claim, in
+// order of preference, by providers without their own entry.
+var defaultClaimNames = map[string][]string{
+	claimEmail:  {"email", "preferred_username"},
+	claimName:   {"name"},
+	claimGroups: {"groups"},
+}
+
+// providerClaimNames override defaultClaimNames for providers that put
+// claims elsewhere. Azure AD leaves email unset for many accounts but always
+// sends the sign-in name, and sends app roles rather than groups when group
+// claims are not configured. Okta custom authorization servers may only send
+// the login.
+var providerClaimNames = map[domain.SSOProviderType]map[string][]string{
+	domain.SSOProviderAzureAD: {
+		claimEmail:  {"email", "preferred_username", "upn", "unique_name"},
+		claimGroups: {"groups", "roles"},
+	},
+	domain.SSOProviderOkta: {
+		claimEmail: {"email", "preferred_username", "login"},
+	},
+}
+
+// NormalizeClaims maps a raw claim set, from an ID token or userinfo
+// response, into canonical OIDCClaims. It smooths over where providers of
+// each type put the email address, name and groups: a claim named in the
+// provider's claim mappings is read first, then the provider type's usual
+// claims. Groups sent as object IDs, as Azure AD does, are replaced by their
+// name in the provider's group names where it has one.
+func NormalizeClaims(provider *domain.SSOProvider, raw map[string]interface{}) *domain.OIDCClaims {
+	claims := &domain.OIDCClaims{
+		Subject:       stringClaim(raw, "sub"),
+		EmailVerified: boolClaim(raw, "email_verified"),
+		Picture:       stringClaim(raw, "picture"),
+		ACR:           stringClaim(raw, "acr"),
+		AMR:           stringsClaim(raw, "amr"),
+		AuthTime:      intClaim(raw, "auth_time"),
+		Nonce:         stringClaim(raw, "nonce"),
+	}
+
+	for _, name := range claimNames(provider, claimEmail) {
+		if value := stringClaim(raw, name); strings.Contains(value, "@") {
+			claims.Email = value
+			break
+		}
+	}
+
+	for _, name := range claimNames(provider, claimName) {
+		if claims.Name = stringClaim(raw, name); claims.Name != "" {
+			break
+		}
+	}
+	if claims.Name == "" {
+		claims.Name = strings.TrimSpace(stringClaim(raw, "given_name") + " " + stringClaim(raw, "family_name"))
+	}
+	if claims.Name == "" {
+		claims.Name = claims.Email
+	}
+
+	for _, name := range claimNames(provider, claimGroups) {
+		if groups := stringsClaim(raw, name); len(groups) > 0 {
+			claims.Groups = append([]string(nil), groups...)
+			break
+		}
+	}
+	if claims.Groups == nil && provider.Type == domain.SSOProviderAuth0 {
+		// Auth0 only passes custom claims under a namespace URL
+		for name := range raw {
+			if strings.HasSuffix(name, "/groups") || strings.HasSuffix(name, "/roles") {
+				claims.Groups = append(claims.Groups, stringsClaim(raw, name)...)
+			}
+		}
+	}
+	for i, group := range claims.Groups {
+		if _, err := uuid.Parse(group); err != nil {
+			continue
+		}
+		if name, ok := provider.GroupNames[group]; ok {
+			claims.Groups[i] = name
+		}
+	}
+
+	return claims
+}
+
+// GroupsOverage reports whether a raw Azure AD claim set left its groups out
+// because the user is in too many of them; they must then be read from
+// Microsoft Graph.
+func GroupsOverage(raw map[string]interface{}) bool {
+	names, ok := raw["_claim_names"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	_, ok = names[claimGroups]
+	return ok
+}
+
+// claimNames returns the raw claims to read for a canonical claim, in order
+// of preference.
+func claimNames(provider *domain.SSOProvider, canonical string) []string {
+	names := defaultClaimNames[canonical]
+	if overrides, ok := providerClaimNames[provider.Type][canonical]; ok {
+		names = overrides
+	}
+	if mapped := provider.ClaimMappings[canonical]; mapped != "" && mapped != canonical {
+		names = append([]string{mapped}, names...)
+	}
+	return names
+}
+
+func stringClaim(raw map[string]interface{}, name string) string {
+	value, _ := raw[name].(string)
+	return strings.TrimSpace(value)
+}
+
+// boolClaim reads a boolean claim, which some providers send as a string.
+func boolClaim(raw map[string]interface{}, name string) bool {
+	switch value := raw[name].(type) {
+	case bool:
+		return value
+	case string:
+		return strings.EqualFold(value, "true")
+	}
+	return false
+}
+
+// intClaim reads a numeric claim, decoded either as a float or as a
+// json.Number.
+func intClaim(raw map[string]interface{}, name string) int64 {
+	switch value := raw[name].(type) {
+	case float64:
+		return int64(value)
+	case json.Number:
+		n, _ := value.Int64()
+		return n
+	}
+	return 0
+}
+
+// stringsClaim reads a list claim, which some providers send as a single
+// string when it has one entry.
+func stringsClaim(raw map[string]interface{}, name string) []string {
+	switch value := raw[name].(type) {
+	case []string:
+		return value
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, v := range value {
+			if s, ok := v.(string); ok && s != "" {
+				values = append(values, s)
+			}
+		}
+		return values
+	case string:
+		if value != "" {
+			return []string{value}
+		}
+	}
+	return nil
+}
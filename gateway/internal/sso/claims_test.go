@@ -0,0 +1,89 @@
+package sso
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+)
+
+func TestNormalizeClaims(t *testing.T) {
+	const (
+		engineering = "6f1c2d3e-4b5a-4c6d-8e9f-0a1b2c3d4e5f"
+		unnamed     = "9a8b7c6d-5e4f-4a3b-9c2d-1e0f9a8b7c6d"
+	)
+	tests := []struct {
+		name     string
+		provider domain.SSOProvider
+		raw      string
+		want     domain.OIDCClaims
+	}{
+		{
+			name: "azure ad without email",
+			provider: domain.SSOProvider{
+				Type:       domain.SSOProviderAzureAD,
+				GroupNames: map[string]string{engineering: "Engineering"},
+			},
+			raw: `{"sub":"AAAAbbbb","oid":"1234","preferred_username":"Ada Lovelace","upn":"ada@contoso.onmicrosoft.com",
+				"name":"Ada Lovelace","groups":["` + engineering + `","` + unnamed + `"],"amr":["pwd","mfa"]}`,
+			want: domain.OIDCClaims{
+				Subject: "AAAAbbbb",
+				Email:   "ada@contoso.onmicrosoft.com",
+				Name:    "Ada Lovelace",
+				Groups:  []string{"Engineering", unnamed},
+				AMR:     []string{"pwd", "mfa"},
+			},
+		},
+		{
+			name:     "azure ad app roles",
+			provider: domain.SSOProvider{Type: domain.SSOProviderAzureAD},
+			raw:      `{"sub":"s","unique_name":"grace@contoso.com","given_name":"Grace","family_name":"Hopper","roles":["Gateway.Admin"]}`,
+			want:     domain.OIDCClaims{Subject: "s", Email: "grace@contoso.com", Name: "Grace Hopper", Groups: []string{"Gateway.Admin"}},
+		},
+		{
+			name:     "okta login",
+			provider: domain.SSOProvider{Type: domain.SSOProviderOkta},
+			raw:      `{"sub":"00u1","login":"alan@example.com","email_verified":"true","groups":["Everyone"]}`,
+			want:     domain.OIDCClaims{Subject: "00u1", Email: "alan@example.com", EmailVerified: true, Name: "alan@example.com", Groups: []string{"Everyone"}},
+		},
+		{
+			name: "claim mapping first",
+			provider: domain.SSOProvider{
+				Type:          domain.SSOProviderGenericOIDC,
+				ClaimMappings: map[string]string{"email": "mail", "groups": "teams"},
+			},
+			raw:  `{"sub":"x","email":"old@example.com","mail":"new@example.com","name":"X","teams":["platform"],"groups":["ignored"]}`,
+			want: domain.OIDCClaims{Subject: "x", Email: "new@example.com", Name: "X", Groups: []string{"platform"}},
+		},
+		{
+			name:     "auth0 namespaced groups",
+			provider: domain.SSOProvider{Type: domain.SSOProviderAuth0},
+			raw:      `{"sub":"auth0|1","email":"k@example.com","name":"K","https://gatewayops.io/groups":["ops"]}`,
+			want:     domain.OIDCClaims{Subject: "auth0|1", Email: "k@example.com", Name: "K", Groups: []string{"ops"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var raw map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.raw), &raw); err != nil {
+				t.Fatalf("raw claims: %v", err)
+			}
+			if got := NormalizeClaims(&tt.provider, raw); !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("claims = %+v\nwant %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGroupsOverage(t *testing.T) {
+	var overage, normal map[string]interface{}
+	json.Unmarshal([]byte(`{"_claim_names":{"groups":"src1"},"_claim_sources":{"src1":{"endpoint":"https://graph.windows.net/"}}}`), &overage)
+	json.Unmarshal([]byte(`{"groups":["a"]}`), &normal)
+	if !GroupsOverage(overage) {
+		t.Error("overage claim set not recognized")
+	}
+	if GroupsOverage(normal) {
+		t.Error("claim set with groups reported as an overage")
+	}
+}
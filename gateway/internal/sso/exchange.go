@@ -0,0 +1,104 @@
+package sso
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+)
+
+// maxTokenResponse caps the body read from a provider's token endpoint.
+const maxTokenResponse = 1 << 20
+
+// ErrInvalidIDToken is returned when a provider's token response carries no
+// ID token, or one that cannot be read or was not issued to this client.
+var ErrInvalidIDToken = errors.New("invalid id token")
+
+// tokenResponse is a provider's answer to an authorization code exchange.
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	IDToken      string `json:"id_token"`
+}
+
+// requestTokens exchanges an authorization code at the provider's token
+// endpoint, authenticating with the client secret.
+func (s *Service) requestTokens(ctx context.Context, provider *domain.SSOProvider, clientSecret, code, redirectURI string) (*tokenResponse, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"client_id":     {provider.ClientID},
+		"client_secret": {clientSecret},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, provider.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxTokenResponse))
+	if err != nil {
+		return nil, fmt.Errorf("read token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token endpoint returned %d", resp.StatusCode)
+	}
+
+	var tokens tokenResponse
+	if err := json.Unmarshal(body, &tokens); err != nil {
+		return nil, fmt.Errorf("decode token response: %w", err)
+	}
+	return &tokens, nil
+}
+
+// idTokenClaims returns the claims of an ID token received from the token
+// endpoint. Its signature is not checked: the token came straight from the
+// provider over TLS, which OpenID Connect Core (3.1.3.7) accepts in its
+// place. It must still be issued to clientID and not have expired.
+func idTokenClaims(idToken, clientID string, now time.Time) (map[string]interface{}, error) {
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidIDToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidIDToken
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(payload, &raw); err != nil {
+		return nil, ErrInvalidIDToken
+	}
+
+	audience := false
+	for _, aud := range stringsClaim(raw, "aud") {
+		if aud == clientID {
+			audience = true
+			break
+		}
+	}
+	if !audience {
+		return nil, fmt.Errorf("%w: issued to another client", ErrInvalidIDToken)
+	}
+	if exp := intClaim(raw, "exp"); exp == 0 || !now.Before(time.Unix(exp, 0)) {
+		return nil, fmt.Errorf("%w: expired", ErrInvalidIDToken)
+	}
+	return raw, nil
+}
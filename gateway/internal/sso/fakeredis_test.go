@@ -0,0 +1,157 @@
+package sso
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/database"
+	"github.com/redis/go-redis/v9"
+)
+
+// fakeRedis is a minimal RESP server holding strings with their TTLs. It
+// answers SET, GET, GETDEL, EXISTS and DEL, and rejects everything else, so
+// clients fall back to RESP2. While failing it rejects every command.
+type fakeRedis struct {
+	mu      sync.Mutex
+	data    map[string]string
+	ttls    map[string]time.Duration // TTL each key was last set with
+	failing bool
+}
+
+// newFakeRedis starts a fake Redis server for the test and returns a client
+// connected to it.
+func newFakeRedis(t *testing.T) (*fakeRedis, *database.Redis) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	f := &fakeRedis{data: make(map[string]string), ttls: make(map[string]time.Duration)}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+
+	client := redis.NewClient(&redis.Options{Addr: ln.Addr().String(), DisableIndentity: true})
+	t.Cleanup(func() {
+		client.Close()
+		ln.Close()
+	})
+	return f, &database.Redis{Client: client}
+}
+
+func (f *fakeRedis) ttl(key string) time.Duration {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.ttls[key]
+}
+
+func (f *fakeRedis) setFailing(failing bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failing = failing
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		if _, err := io.WriteString(conn, f.exec(args)); err != nil {
+			return
+		}
+	}
+}
+
+func (f *fakeRedis) exec(args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.failing {
+		return "-ERR server unavailable\r\n"
+	}
+	switch strings.ToUpper(args[0]) {
+	case "SET":
+		f.data[args[1]] = args[2]
+		f.ttls[args[1]] = 0
+		for i := 3; i+1 < len(args); i += 2 {
+			n, _ := strconv.Atoi(args[i+1])
+			switch strings.ToUpper(args[i]) {
+			case "EX":
+				f.ttls[args[1]] = time.Duration(n) * time.Second
+			case "PX":
+				f.ttls[args[1]] = time.Duration(n) * time.Millisecond
+			}
+		}
+		return "+OK\r\n"
+	case "GET", "GETDEL":
+		v, ok := f.data[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		if strings.EqualFold(args[0], "GETDEL") {
+			delete(f.data, args[1])
+		}
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+	case "EXISTS", "DEL":
+		n := 0
+		for _, key := range args[1:] {
+			if _, ok := f.data[key]; ok {
+				n++
+				if strings.EqualFold(args[0], "DEL") {
+					delete(f.data, key)
+				}
+			}
+		}
+		return fmt.Sprintf(":%d\r\n", n)
+	default:
+		return "-ERR unknown command '" + args[0] + "'\r\n"
+	}
+}
+
+// readCommand reads one RESP array of bulk strings.
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		return nil, fmt.Errorf("unexpected %q", line)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		header, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(header[1:]))
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
@@ -0,0 +1,81 @@
+package sso
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/clock"
+	"github.com/akz4ol/gatewayops/gateway/internal/database"
+)
+
+// revocationStore remembers session tokens revoked before they expire,
+// keyed by token ID. In Redis each revocation lives for the rest of its
+// token's life, so every instance sharing the signing key refuses the token;
+// without Redis they are kept in memory and hold for this instance only.
+type revocationStore struct {
+	redis *database.Redis
+	clock clock.Clock
+
+	mu    sync.Mutex
+	local map[string]time.Time // token ID -> token expiry
+}
+
+func newRevocationStore(redis *database.Redis, clk clock.Clock) *revocationStore {
+	return &revocationStore{
+		redis: redis,
+		clock: clk,
+		local: make(map[string]time.Time),
+	}
+}
+
+func (s *revocationStore) useRedis() bool {
+	return s.redis != nil && s.redis.Client != nil
+}
+
+// revoke records that the token is revoked until it would have expired.
+// Revocations that have lapsed are forgotten.
+func (s *revocationStore) revoke(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	if tokenID == "" {
+		return nil
+	}
+	ttl := expiresAt.Sub(s.clock.Now())
+	if ttl <= 0 {
+		return nil // Already expired; no one can use it
+	}
+
+	if s.useRedis() {
+		if err := s.redis.Set(ctx, "sso_revoked:"+tokenID, 1, ttl); err != nil {
+			return fmt.Errorf("revoke session token: %w", err)
+		}
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.clock.Now()
+	for id, expiry := range s.local {
+		if now.After(expiry) {
+			delete(s.local, id)
+		}
+	}
+	s.local[tokenID] = expiresAt
+	return nil
+}
+
+// isRevoked reports whether the token has been revoked.
+func (s *revocationStore) isRevoked(ctx context.Context, tokenID string) (bool, error) {
+	if s.useRedis() {
+		n, err := s.redis.Exists(ctx, "sso_revoked:"+tokenID)
+		if err != nil {
+			return false, fmt.Errorf("check session token revocation: %w", err)
+		}
+		return n > 0, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, revoked := s.local[tokenID]
+	return revoked, nil
+}
@@ -0,0 +1,101 @@
+package sso
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// ErrNoSecretsKey is returned when client secrets cannot be re-encrypted
+// because no encryption key is configured.
+var ErrNoSecretsKey = errors.New("SECRETS_ENCRYPTION_KEY is not set, so client secrets are not encrypted")
+
+// ClientSecret returns the client secret of a provider. A secret stored
+// before envelope encryption, or under an older key of the organization, is
+// sealed again under its current key on the way.
+func (s *Service) ClientSecret(ctx context.Context, providerID uuid.UUID) (string, error) {
+	s.mu.RLock()
+	provider := s.providers[providerID]
+	var orgID uuid.UUID
+	var stored []byte
+	if provider != nil {
+		orgID, stored = provider.OrgID, provider.ClientSecretEncrypted
+	}
+	s.mu.RUnlock()
+
+	if provider == nil {
+		return "", fmt.Errorf("provider not found")
+	}
+	if s.secrets == nil || len(stored) == 0 {
+		return string(stored), nil
+	}
+
+	secret, stale, err := s.secrets.Open(ctx, orgID, stored)
+	if err != nil {
+		return "", err
+	}
+	if stale {
+		resealed, err := s.secrets.Seal(ctx, orgID, secret)
+		if err != nil {
+			return "", err
+		}
+		s.mu.Lock()
+		// Leave a secret changed in the meantime alone
+		if bytes.Equal(provider.ClientSecretEncrypted, stored) {
+			provider.ClientSecretEncrypted = resealed
+		}
+		s.mu.Unlock()
+		s.logger.Info().
+			Str("provider_id", providerID.String()).
+			Str("org_id", orgID.String()).
+			Msg("SSO client secret re-encrypted under the organization's current key")
+	}
+	return string(secret), nil
+}
+
+// RotateSecretsKey gives the organization a new key for its client secrets
+// and re-encrypts them under it, returning how many were re-encrypted. Other
+// organizations' secrets are not touched.
+func (s *Service) RotateSecretsKey(ctx context.Context, orgID uuid.UUID) (int, error) {
+	if s.secrets == nil {
+		return 0, ErrNoSecretsKey
+	}
+	version, err := s.secrets.RotateOrgKey(ctx, orgID)
+	if err != nil {
+		return 0, err
+	}
+
+	s.mu.RLock()
+	var ids []uuid.UUID
+	for id, provider := range s.providers {
+		if provider.OrgID == orgID && len(provider.ClientSecretEncrypted) > 0 {
+			ids = append(ids, id)
+		}
+	}
+	s.mu.RUnlock()
+
+	for _, id := range ids {
+		if _, err := s.ClientSecret(ctx, id); err != nil {
+			return 0, fmt.Errorf("re-encrypt client secret of provider %s: %w", id, err)
+		}
+	}
+
+	s.logger.Info().
+		Str("org_id", orgID.String()).
+		Uint32("key_version", version).
+		Int("secrets", len(ids)).
+		Msg("SSO client secret key rotated")
+	return len(ids), nil
+}
+
+// sealClientSecret encrypts a client secret for storage under the
+// organization's key.
+func (s *Service) sealClientSecret(ctx context.Context, orgID uuid.UUID, secret string) ([]byte, error) {
+	if s.secrets == nil || secret == "" {
+		return []byte(secret), nil
+	}
+	return s.secrets.Seal(ctx, orgID, []byte(secret))
+}
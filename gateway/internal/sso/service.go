@@ -2,36 +2,79 @@
 package sso
 
 import (
+	"context"
 	"crypto/rand"
+	"crypto/subtle"
 	"encoding/base64"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"net/http"
 	"sync"
 	"time"
 
+	"github.com/akz4ol/gatewayops/gateway/internal/clock"
+	"github.com/akz4ol/gatewayops/gateway/internal/database"
 	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/akz4ol/gatewayops/gateway/internal/secretbox"
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 )
 
+// sessionTTL is how long a session token is valid before it must be refreshed.
+const sessionTTL = 24 * time.Hour
+
 // Service manages SSO providers, authentication, and sessions.
 type Service struct {
-	logger    zerolog.Logger
-	providers map[uuid.UUID]*domain.SSOProvider
-	states    map[string]*domain.AuthState // keyed by state value
-	sessions  map[uuid.UUID]*domain.UserSession
-	users     map[uuid.UUID]*domain.User
-	mu        sync.RWMutex
+	logger     zerolog.Logger
+	signingKey []byte // HMAC key for session tokens
+	clock      clock.Clock
+	secrets    *secretbox.Envelope // Seals client secrets per organization; nil stores them as given
+	providers  map[uuid.UUID]*domain.SSOProvider
+	states     *stateStore
+	sessions   map[uuid.UUID]*domain.UserSession
+	users      map[uuid.UUID]*domain.User
+	mu         sync.RWMutex
+
+	// Session tokens are validated from their signature alone; only tokens
+	// revoked before they expire are remembered
+	revocations *revocationStore
+
+	refreshTokens map[string]uuid.UUID // refresh token -> session ID
+
+	demoMode   bool         // Simulate code exchanges instead of calling providers
+	httpClient *http.Client // Calls providers' token endpoints
 }
 
-// NewService creates a new SSO service.
-func NewService(logger zerolog.Logger) *Service {
+// NewService creates a new SSO service that signs session tokens with
+// signingKey. Without a key a random one is generated, so sessions do not
+// survive a restart and cannot be shared between instances. Login states
+// and session token revocations are kept in redis, so any instance can
+// complete a login and refuses a revoked token, or in memory without it.
+// Session and login state expiry are judged by clk. Provider
+// client secrets are sealed by secrets under a key of the provider's
+// organization. In demoMode authorization codes are not exchanged with the
+// provider; the demo user's claims are made up instead.
+func NewService(logger zerolog.Logger, signingKey []byte, clk clock.Clock, redis *database.Redis, secrets *secretbox.Envelope, demoMode bool) *Service {
+	if len(signingKey) == 0 {
+		signingKey = make([]byte, 32)
+		rand.Read(signingKey)
+		logger.Warn().Msg("SESSION_SIGNING_KEY is not set; using a random key, so sessions are lost on restart")
+	}
+
 	s := &Service{
-		logger:    logger,
-		providers: make(map[uuid.UUID]*domain.SSOProvider),
-		states:    make(map[string]*domain.AuthState),
-		sessions:  make(map[uuid.UUID]*domain.UserSession),
-		users:     make(map[uuid.UUID]*domain.User),
+		logger:        logger,
+		signingKey:    signingKey,
+		clock:         clk,
+		secrets:       secrets,
+		providers:     make(map[uuid.UUID]*domain.SSOProvider),
+		states:        newStateStore(redis, clk),
+		sessions:      make(map[uuid.UUID]*domain.UserSession),
+		users:         make(map[uuid.UUID]*domain.User),
+		revocations:   newRevocationStore(redis, clk),
+		refreshTokens: make(map[string]uuid.UUID),
+		demoMode:      demoMode,
+		httpClient:    &http.Client{Timeout: 10 * time.Second},
 	}
 
 	// Create demo provider and user
@@ -68,8 +111,8 @@ func (s *Service) createDemoData() {
 			"Viewers":    "viewer",
 		},
 		Enabled:   true,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		CreatedAt: s.clock.Now(),
+		UpdatedAt: s.clock.Now(),
 	}
 	s.providers[oktaProvider.ID] = oktaProvider
 
@@ -85,9 +128,15 @@ func (s *Service) createDemoData() {
 		TokenURL:         "https://login.microsoftonline.com/demo-tenant/oauth2/v2.0/token",
 		UserInfoURL:      "https://graph.microsoft.com/oidc/userinfo",
 		Scopes:           []string{"openid", "profile", "email"},
-		Enabled:          false,
-		CreatedAt:        time.Now(),
-		UpdatedAt:        time.Now(),
+		GroupMappings: map[string]string{
+			"Developers": "developer",
+		},
+		GroupNames: map[string]string{
+			"5f2c7a4e-1b3d-4c8e-9a6f-0d2e8b7c1a94": "Developers",
+		},
+		Enabled:   false,
+		CreatedAt: s.clock.Now(),
+		UpdatedAt: s.clock.Now(),
 	}
 	s.providers[azureProvider.ID] = azureProvider
 
@@ -104,8 +153,8 @@ func (s *Service) createDemoData() {
 		UserInfoURL:      "https://openidconnect.googleapis.com/v1/userinfo",
 		Scopes:           []string{"openid", "profile", "email"},
 		Enabled:          true, // Enable for demo
-		CreatedAt:        time.Now(),
-		UpdatedAt:        time.Now(),
+		CreatedAt:        s.clock.Now(),
+		UpdatedAt:        s.clock.Now(),
 	}
 	s.providers[googleProvider.ID] = googleProvider
 
@@ -122,13 +171,13 @@ func (s *Service) createDemoData() {
 		UserInfoURL:      "https://demo.auth0.com/userinfo",
 		Scopes:           []string{"openid", "profile", "email"},
 		Enabled:          true, // Enable for demo
-		CreatedAt:        time.Now(),
-		UpdatedAt:        time.Now(),
+		CreatedAt:        s.clock.Now(),
+		UpdatedAt:        s.clock.Now(),
 	}
 	s.providers[auth0Provider.ID] = auth0Provider
 
 	// Demo user
-	now := time.Now()
+	now := s.clock.Now()
 	s.users[demoUserID] = &domain.User{
 		ID:          demoUserID,
 		OrgID:       orgID,
@@ -136,8 +185,8 @@ func (s *Service) createDemoData() {
 		Name:        "Demo Admin",
 		Status:      domain.UserStatusActive,
 		LastLoginAt: &now,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		CreatedAt:   s.clock.Now(),
+		UpdatedAt:   s.clock.Now(),
 	}
 }
 
@@ -162,6 +211,18 @@ func (s *Service) GetProvider(id uuid.UUID) *domain.SSOProvider {
 	return s.providers[id]
 }
 
+// GetOrgProvider returns one of an organization's SSO providers by ID.
+func (s *Service) GetOrgProvider(orgID, id uuid.UUID) *domain.SSOProvider {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	provider, exists := s.providers[id]
+	if !exists || provider.OrgID != orgID {
+		return nil
+	}
+	return provider
+}
+
 // GetProviderByType returns an SSO provider by type.
 func (s *Service) GetProviderByType(orgID uuid.UUID, providerType domain.SSOProviderType) *domain.SSOProvider {
 	s.mu.RLock()
@@ -176,7 +237,12 @@ func (s *Service) GetProviderByType(orgID uuid.UUID, providerType domain.SSOProv
 }
 
 // CreateProvider creates a new SSO provider.
-func (s *Service) CreateProvider(input domain.SSOProviderInput, orgID uuid.UUID) *domain.SSOProvider {
+func (s *Service) CreateProvider(ctx context.Context, input domain.SSOProviderInput, orgID uuid.UUID) (*domain.SSOProvider, error) {
+	clientSecret, err := s.sealClientSecret(ctx, orgID, input.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -195,16 +261,17 @@ func (s *Service) CreateProvider(input domain.SSOProviderInput, orgID uuid.UUID)
 		Name:                  input.Name,
 		IssuerURL:             input.IssuerURL,
 		ClientID:              input.ClientID,
-		ClientSecretEncrypted: []byte(input.ClientSecret), // In production, encrypt this
+		ClientSecretEncrypted: clientSecret,
 		AuthorizationURL:      authURL,
 		TokenURL:              tokenURL,
 		UserInfoURL:           userInfoURL,
 		Scopes:                input.Scopes,
 		ClaimMappings:         input.ClaimMappings,
 		GroupMappings:         input.GroupMappings,
+		GroupNames:            input.GroupNames,
 		Enabled:               input.Enabled,
-		CreatedAt:             time.Now(),
-		UpdatedAt:             time.Now(),
+		CreatedAt:             s.clock.Now(),
+		UpdatedAt:             s.clock.Now(),
 	}
 
 	s.providers[provider.ID] = provider
@@ -215,7 +282,7 @@ func (s *Service) CreateProvider(input domain.SSOProviderInput, orgID uuid.UUID)
 		Str("name", provider.Name).
 		Msg("SSO provider created")
 
-	return provider
+	return provider, nil
 }
 
 func (s *Service) getProviderURLs(providerType domain.SSOProviderType, issuerURL string) (authURL, tokenURL, userInfoURL string) {
@@ -248,14 +315,23 @@ func (s *Service) getProviderURLs(providerType domain.SSOProviderType, issuerURL
 	return
 }
 
-// UpdateProvider updates an existing SSO provider.
-func (s *Service) UpdateProvider(id uuid.UUID, input domain.SSOProviderInput) *domain.SSOProvider {
+// UpdateProvider updates an existing SSO provider. It returns nil for a
+// provider not in the organization.
+func (s *Service) UpdateProvider(ctx context.Context, orgID, id uuid.UUID, input domain.SSOProviderInput) (*domain.SSOProvider, error) {
+	var clientSecret []byte
+	if input.ClientSecret != "" {
+		var err error
+		if clientSecret, err = s.sealClientSecret(ctx, orgID, input.ClientSecret); err != nil {
+			return nil, err
+		}
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	provider, exists := s.providers[id]
-	if !exists {
-		return nil
+	if !exists || provider.OrgID != orgID {
+		return nil, nil
 	}
 
 	if input.Name != "" {
@@ -270,8 +346,8 @@ func (s *Service) UpdateProvider(id uuid.UUID, input domain.SSOProviderInput) *d
 	if input.ClientID != "" {
 		provider.ClientID = input.ClientID
 	}
-	if input.ClientSecret != "" {
-		provider.ClientSecretEncrypted = []byte(input.ClientSecret)
+	if clientSecret != nil {
+		provider.ClientSecretEncrypted = clientSecret
 	}
 	if len(input.Scopes) > 0 {
 		provider.Scopes = input.Scopes
@@ -282,22 +358,25 @@ func (s *Service) UpdateProvider(id uuid.UUID, input domain.SSOProviderInput) *d
 	if input.GroupMappings != nil {
 		provider.GroupMappings = input.GroupMappings
 	}
+	if input.GroupNames != nil {
+		provider.GroupNames = input.GroupNames
+	}
 	provider.Enabled = input.Enabled
-	provider.UpdatedAt = time.Now()
+	provider.UpdatedAt = s.clock.Now()
 
 	s.logger.Info().
 		Str("provider_id", id.String()).
 		Msg("SSO provider updated")
 
-	return provider
+	return provider, nil
 }
 
 // DeleteProvider deletes an SSO provider.
-func (s *Service) DeleteProvider(id uuid.UUID) bool {
+func (s *Service) DeleteProvider(orgID, id uuid.UUID) bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if _, exists := s.providers[id]; !exists {
+	if provider, exists := s.providers[id]; !exists || provider.OrgID != orgID {
 		return false
 	}
 
@@ -312,9 +391,6 @@ func (s *Service) DeleteProvider(id uuid.UUID) bool {
 
 // GenerateAuthState generates OAuth state for CSRF protection.
 func (s *Service) GenerateAuthState(providerID uuid.UUID, redirectURL string) (*domain.AuthState, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
 	// Generate random state and nonce
 	stateBytes := make([]byte, 32)
 	if _, err := rand.Read(stateBytes); err != nil {
@@ -330,34 +406,43 @@ func (s *Service) GenerateAuthState(providerID uuid.UUID, redirectURL string) (*
 		Nonce:       hex.EncodeToString(nonceBytes),
 		RedirectURL: redirectURL,
 		ProviderID:  providerID,
-		ExpiresAt:   time.Now().Add(10 * time.Minute),
+		ExpiresAt:   s.clock.Now().Add(authStateTTL),
 	}
 
-	s.states[state.State] = state
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.states.save(ctx, state); err != nil {
+		return nil, err
+	}
 
 	return state, nil
 }
 
-// ValidateAuthState validates and consumes an OAuth state.
+// ValidateAuthState validates and consumes an OAuth state, which may have
+// been generated by another instance.
 func (s *Service) ValidateAuthState(stateValue string) (*domain.AuthState, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
-	state, exists := s.states[stateValue]
-	if !exists {
-		return nil, fmt.Errorf("invalid state")
+	// Consuming the state makes it one-time use
+	state, err := s.states.consume(ctx, stateValue)
+	if err != nil {
+		return nil, err
 	}
 
-	// Delete state (one-time use)
-	delete(s.states, stateValue)
-
-	if time.Now().After(state.ExpiresAt) {
+	if s.clock.Now().After(state.ExpiresAt) {
 		return nil, fmt.Errorf("state expired")
 	}
 
 	return state, nil
 }
 
+// PurgeExpiredStates removes OAuth states that expired without being used,
+// returning how many were removed. States in Redis expire on their own.
+func (s *Service) PurgeExpiredStates() int64 {
+	return s.states.purgeExpired()
+}
+
 // GetAuthorizationURL returns the OAuth authorization URL for a provider.
 func (s *Service) GetAuthorizationURL(providerID uuid.UUID, state *domain.AuthState, callbackURL string) (string, error) {
 	s.mu.RLock()
@@ -396,9 +481,16 @@ func joinScopes(scopes []string) string {
 	return result
 }
 
-// ExchangeCode exchanges an authorization code for tokens.
+// ErrNonceMismatch is returned when an ID token does not carry the nonce of
+// the login it is presented for, as when a token from another login is
+// replayed.
+var ErrNonceMismatch = errors.New("id token nonce does not match the login")
+
+// ExchangeCode exchanges an authorization code for tokens at the provider's
+// token endpoint. nonce is the one issued with the login's state; the ID
+// token in the provider's response must carry it.
 // In demo mode, this simulates the exchange.
-func (s *Service) ExchangeCode(providerID uuid.UUID, code string, redirectURI string) (*domain.TokenPair, *domain.OIDCClaims, error) {
+func (s *Service) ExchangeCode(providerID uuid.UUID, code, redirectURI, nonce string) (*domain.TokenPair, *domain.OIDCClaims, error) {
 	s.mu.RLock()
 	provider := s.providers[providerID]
 	s.mu.RUnlock()
@@ -407,63 +499,136 @@ func (s *Service) ExchangeCode(providerID uuid.UUID, code string, redirectURI st
 		return nil, nil, fmt.Errorf("provider not found")
 	}
 
-	// In demo mode, simulate token exchange
-	// In production, this would make HTTP calls to the provider's token endpoint
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
 
-	s.logger.Info().
-		Str("provider_id", providerID.String()).
-		Str("code", code[:min(8, len(code))]+"...").
-		Msg("Demo: simulating token exchange")
+	// The client secret authenticates the token request, so a provider whose
+	// organization's key was revoked can no longer complete logins
+	clientSecret, err := s.ClientSecret(ctx, providerID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("client secret: %w", err)
+	}
 
-	// Generate demo tokens
-	accessToken := generateDemoToken("access")
-	refreshToken := generateDemoToken("refresh")
+	var tokenPair *domain.TokenPair
+	var raw map[string]interface{}
+	if s.demoMode {
+		s.logger.Info().
+			Str("provider_id", providerID.String()).
+			Str("code", code[:min(8, len(code))]+"...").
+			Msg("Demo: simulating token exchange")
 
-	tokenPair := &domain.TokenPair{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		TokenType:    "Bearer",
-		ExpiresIn:    3600,
-		ExpiresAt:    time.Now().Add(time.Hour),
+		tokenPair = &domain.TokenPair{
+			AccessToken:  generateDemoToken("access"),
+			RefreshToken: generateDemoToken("refresh"),
+			TokenType:    "Bearer",
+			ExpiresIn:    3600,
+			ExpiresAt:    s.clock.Now().Add(time.Hour),
+		}
+		// Simulate the provider's claims for a login with password and
+		// one-time code, in the shape that provider sends them
+		raw = demoRawClaims(provider.Type, s.clock.Now(), nonce)
+	} else {
+		tokens, err := s.requestTokens(ctx, provider, clientSecret, code, redirectURI)
+		if err != nil {
+			return nil, nil, err
+		}
+		if raw, err = idTokenClaims(tokens.IDToken, provider.ClientID, s.clock.Now()); err != nil {
+			return nil, nil, err
+		}
+		tokenPair = &domain.TokenPair{
+			AccessToken:  tokens.AccessToken,
+			RefreshToken: tokens.RefreshToken,
+			TokenType:    tokens.TokenType,
+			ExpiresIn:    tokens.ExpiresIn,
+			ExpiresAt:    s.clock.Now().Add(time.Duration(tokens.ExpiresIn) * time.Second),
+		}
 	}
 
-	// Simulate OIDC claims
-	claims := &domain.OIDCClaims{
-		Subject:       "demo-user-" + uuid.New().String()[:8],
-		Email:         "user@demo.gatewayops.io",
-		EmailVerified: true,
-		Name:          "Demo User",
-		Groups:        []string{"Developers"},
+	if err := verifyNonce(raw, nonce); err != nil {
+		return nil, nil, err
+	}
+	if GroupsOverage(raw) {
+		s.logger.Warn().
+			Str("provider_id", providerID.String()).
+			Msg("Provider left groups out of the token; they are not read from Microsoft Graph")
 	}
+	claims := NormalizeClaims(provider, raw)
 
 	return tokenPair, claims, nil
 }
 
+// verifyNonce checks that an ID token's claims carry the nonce issued for
+// the login.
+func verifyNonce(raw map[string]interface{}, nonce string) error {
+	got := stringClaim(raw, "nonce")
+	if nonce == "" || subtle.ConstantTimeCompare([]byte(got), []byte(nonce)) != 1 {
+		return ErrNonceMismatch
+	}
+	return nil
+}
+
+// demoRawClaims returns the claims a provider of the given type sends for the
+// demo user, echoing the login's nonce. Azure AD sends no email claim for the
+// account and identifies groups by object ID.
+func demoRawClaims(providerType domain.SSOProviderType, now time.Time, nonce string) map[string]interface{} {
+	raw := map[string]interface{}{
+		"nonce":          nonce,
+		"sub":            "demo-user-" + uuid.New().String()[:8],
+		"email":          "user@demo.gatewayops.io",
+		"email_verified": true,
+		"name":           "Demo User",
+		"groups":         []interface{}{"Developers"},
+		"amr":            []interface{}{"pwd", "otp"},
+		"auth_time":      float64(now.Unix()),
+	}
+	if providerType == domain.SSOProviderAzureAD {
+		delete(raw, "email")
+		delete(raw, "email_verified")
+		raw["preferred_username"] = "user@demo.gatewayops.io"
+		raw["groups"] = []interface{}{"5f2c7a4e-1b3d-4c8e-9a6f-0d2e8b7c1a94"}
+		raw["amr"] = []interface{}{"pwd", "mfa"}
+	}
+	return raw
+}
+
 func generateDemoToken(prefix string) string {
 	b := make([]byte, 32)
 	rand.Read(b)
 	return fmt.Sprintf("%s_%s", prefix, base64.URLEncoding.EncodeToString(b))
 }
 
-// CreateSession creates a new user session.
-func (s *Service) CreateSession(user *domain.User, ipAddress, userAgent string) *domain.UserSession {
+// CreateSession creates a new user session. The session records how and when
+// the user authenticated, taken from the login's OIDC claims; without an
+// auth_time claim the user is taken to have authenticated just now.
+func (s *Service) CreateSession(user *domain.User, claims *domain.OIDCClaims, ipAddress, userAgent string) *domain.UserSession {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	now := s.clock.Now()
+	authTime := now
+	if claims.AuthTime > 0 {
+		authTime = time.Unix(claims.AuthTime, 0)
+	}
 	session := &domain.UserSession{
 		ID:             uuid.New(),
 		UserID:         user.ID,
 		OrgID:          user.OrgID,
-		AccessToken:    generateDemoToken("session"),
 		RefreshToken:   generateDemoToken("refresh"),
-		ExpiresAt:      time.Now().Add(24 * time.Hour),
-		LastActivityAt: time.Now(),
+		ACR:            claims.ACR,
+		AMR:            claims.AMR,
+		AuthTime:       authTime,
+		LastActivityAt: now,
 		IPAddress:      ipAddress,
 		UserAgent:      userAgent,
-		CreatedAt:      time.Now(),
+		CreatedAt:      now,
+	}
+	if err := s.issueToken(session, now); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to sign session token")
+		return nil
 	}
 
 	s.sessions[session.ID] = session
+	s.refreshTokens[session.RefreshToken] = session.ID
 
 	s.logger.Info().
 		Str("session_id", session.ID.String()).
@@ -480,56 +645,170 @@ func (s *Service) GetSession(id uuid.UUID) *domain.UserSession {
 	return s.sessions[id]
 }
 
-// ValidateSession validates a session token.
+// issueToken signs a new access token for the session, valid for sessionTTL
+// from now. The caller must hold s.mu.
+func (s *Service) issueToken(session *domain.UserSession, now time.Time) error {
+	claims := sessionClaims{
+		SessionID: session.ID,
+		UserID:    session.UserID,
+		OrgID:     session.OrgID,
+		TokenID:   uuid.New().String(),
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(sessionTTL).Unix(),
+		ACR:       session.ACR,
+		AMR:       session.AMR,
+		AuthTime:  session.AuthTime.Unix(),
+	}
+	token, err := signToken(s.signingKey, claims)
+	if err != nil {
+		return err
+	}
+	session.AccessToken = token
+	session.TokenID = claims.TokenID
+	session.ExpiresAt = time.Unix(claims.ExpiresAt, 0)
+	return nil
+}
+
+// revokeToken records that a session token is revoked until it would have
+// expired. It makes a Redis call, so callers must not hold s.mu.
+func (s *Service) revokeToken(tokenID string, expiresAt time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return s.revocations.revoke(ctx, tokenID, expiresAt)
+}
+
+// verifyToken returns the claims of a session token whose signature and
+// expiry are valid and which has not been revoked, or ErrInvalidToken,
+// ErrExpiredToken or ErrRevokedToken. A token whose revocation cannot be
+// checked is refused.
+func (s *Service) verifyToken(token string) (*sessionClaims, error) {
+	claims, err := parseToken(s.signingKey, token, s.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	revoked, err := s.revocations.isRevoked(ctx, claims.TokenID)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to check session token revocation")
+		return nil, ErrRevokedToken
+	}
+	if revoked {
+		return nil, ErrRevokedToken
+	}
+	return claims, nil
+}
+
+// ValidateSession verifies a session token's signature and expiry and that
+// it has not been revoked. Sessions created by another instance are
+// described from the token's claims; the user is nil if this instance does
+// not know them.
 func (s *Service) ValidateSession(token string) (*domain.UserSession, *domain.User) {
+	claims, err := s.verifyToken(token)
+	if err != nil {
+		return nil, nil
+	}
+
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	for _, session := range s.sessions {
-		if session.AccessToken == token {
-			if time.Now().After(session.ExpiresAt) {
-				return nil, nil // Expired
-			}
-			user := s.users[session.UserID]
-			return session, user
+	session, ok := s.sessions[claims.SessionID]
+	if !ok {
+		session = &domain.UserSession{
+			ID:        claims.SessionID,
+			UserID:    claims.UserID,
+			OrgID:     claims.OrgID,
+			TokenID:   claims.TokenID,
+			ACR:       claims.ACR,
+			AMR:       claims.AMR,
+			AuthTime:  time.Unix(claims.AuthTime, 0),
+			ExpiresAt: time.Unix(claims.ExpiresAt, 0),
+			CreatedAt: time.Unix(claims.IssuedAt, 0),
 		}
 	}
-	return nil, nil
+	return session, s.users[claims.UserID]
 }
 
-// RefreshSession refreshes an expired session.
-func (s *Service) RefreshSession(refreshToken string) *domain.UserSession {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+// RefreshSession issues a new access token for the session holding the
+// refresh token. The token it replaces is revoked first; if the revocation
+// cannot be recorded the session keeps its token and the error is returned.
+// The session is nil if the refresh token is unknown.
+func (s *Service) RefreshSession(refreshToken string) (*domain.UserSession, error) {
+	s.mu.RLock()
+	session, ok := s.sessions[s.refreshTokens[refreshToken]]
+	var tokenID string
+	var expiresAt time.Time
+	if ok {
+		tokenID, expiresAt = session.TokenID, session.ExpiresAt
+	}
+	s.mu.RUnlock()
+	if !ok {
+		return nil, nil
+	}
 
-	for _, session := range s.sessions {
-		if session.RefreshToken == refreshToken {
-			// Generate new tokens
-			session.AccessToken = generateDemoToken("session")
-			session.ExpiresAt = time.Now().Add(24 * time.Hour)
-			session.LastActivityAt = time.Now()
-			return session
-		}
+	if err := s.revokeToken(tokenID, expiresAt); err != nil {
+		return nil, err
 	}
-	return nil
-}
 
-// RevokeSession revokes a session.
-func (s *Service) RevokeSession(id uuid.UUID) bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if _, exists := s.sessions[id]; !exists {
-		return false
+	// Revoked or refreshed by someone else while the lock was released
+	if s.sessions[session.ID] != session || session.TokenID != tokenID {
+		return nil, nil
 	}
 
-	delete(s.sessions, id)
+	now := s.clock.Now()
+	if err := s.issueToken(session, now); err != nil {
+		return nil, fmt.Errorf("sign session token: %w", err)
+	}
+	session.LastActivityAt = now
+	return session, nil
+}
+
+// RevokeSession revokes a session. It reports false if there is no such
+// session, and keeps the session if its token's revocation cannot be
+// recorded.
+func (s *Service) RevokeSession(id uuid.UUID) (bool, error) {
+	for {
+		s.mu.RLock()
+		session, exists := s.sessions[id]
+		var tokenID string
+		var expiresAt time.Time
+		if exists {
+			tokenID, expiresAt = session.TokenID, session.ExpiresAt
+		}
+		s.mu.RUnlock()
+		if !exists {
+			return false, nil
+		}
+
+		if err := s.revokeToken(tokenID, expiresAt); err != nil {
+			return false, err
+		}
+
+		s.mu.Lock()
+		if s.sessions[id] != session {
+			s.mu.Unlock()
+			return false, nil
+		}
+		if session.TokenID != tokenID {
+			// Refreshed meanwhile; its new token must be revoked too
+			s.mu.Unlock()
+			continue
+		}
+		delete(s.refreshTokens, session.RefreshToken)
+		delete(s.sessions, id)
+		s.mu.Unlock()
+		break
+	}
 
 	s.logger.Info().
 		Str("session_id", id.String()).
 		Msg("Session revoked")
 
-	return true
+	return true, nil
 }
 
 // ListUserSessions returns all active sessions for a user.
@@ -539,22 +818,33 @@ func (s *Service) ListUserSessions(userID uuid.UUID) []domain.UserSession {
 
 	sessions := make([]domain.UserSession, 0)
 	for _, session := range s.sessions {
-		if session.UserID == userID && time.Now().Before(session.ExpiresAt) {
+		if session.UserID == userID && s.clock.Now().Before(session.ExpiresAt) {
 			sessions = append(sessions, *session)
 		}
 	}
 	return sessions
 }
 
-// RevokeAllUserSessions revokes all sessions for a user.
-func (s *Service) RevokeAllUserSessions(userID uuid.UUID) int {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	count := 0
+// RevokeAllUserSessions revokes all sessions for a user and returns how
+// many it revoked. It stops at the first session whose token's revocation
+// cannot be recorded.
+func (s *Service) RevokeAllUserSessions(userID uuid.UUID) (int, error) {
+	s.mu.RLock()
+	var ids []uuid.UUID
 	for id, session := range s.sessions {
 		if session.UserID == userID {
-			delete(s.sessions, id)
+			ids = append(ids, id)
+		}
+	}
+	s.mu.RUnlock()
+
+	count := 0
+	for _, id := range ids {
+		revoked, err := s.RevokeSession(id)
+		if err != nil {
+			return count, err
+		}
+		if revoked {
 			count++
 		}
 	}
@@ -564,7 +854,7 @@ func (s *Service) RevokeAllUserSessions(userID uuid.UUID) int {
 		Int("sessions_revoked", count).
 		Msg("All user sessions revoked")
 
-	return count
+	return count, nil
 }
 
 // GetOrCreateUser gets or creates a user from OIDC claims.
@@ -576,7 +866,7 @@ func (s *Service) GetOrCreateUser(orgID uuid.UUID, providerID uuid.UUID, claims
 	for _, user := range s.users {
 		if user.SSOExternalID == claims.Subject && user.SSOProviderID != nil && *user.SSOProviderID == providerID {
 			// Update last login
-			now := time.Now()
+			now := s.clock.Now()
 			user.LastLoginAt = &now
 			user.UpdatedAt = now
 			return user
@@ -589,7 +879,7 @@ func (s *Service) GetOrCreateUser(orgID uuid.UUID, providerID uuid.UUID, claims
 			// Link SSO
 			user.SSOProviderID = &providerID
 			user.SSOExternalID = claims.Subject
-			now := time.Now()
+			now := s.clock.Now()
 			user.LastLoginAt = &now
 			user.UpdatedAt = now
 			return user
@@ -597,7 +887,7 @@ func (s *Service) GetOrCreateUser(orgID uuid.UUID, providerID uuid.UUID, claims
 	}
 
 	// Create new user
-	now := time.Now()
+	now := s.clock.Now()
 	user := &domain.User{
 		ID:            uuid.New(),
 		OrgID:         orgID,
@@ -629,27 +919,47 @@ func (s *Service) GetUser(id uuid.UUID) *domain.User {
 	return s.users[id]
 }
 
-// ProviderStats returns statistics about SSO providers.
-func (s *Service) ProviderStats() map[string]interface{} {
+// ProviderStats returns statistics about an organization's SSO providers,
+// sessions and users.
+func (s *Service) ProviderStats(orgID uuid.UUID) map[string]interface{} {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
+	providerCount := 0
 	enabledCount := 0
 	byType := make(map[string]int)
 
 	for _, p := range s.providers {
+		if p.OrgID != orgID {
+			continue
+		}
+		providerCount++
 		byType[string(p.Type)]++
 		if p.Enabled {
 			enabledCount++
 		}
 	}
 
+	now := s.clock.Now()
+	sessionCount := 0
+	for _, session := range s.sessions {
+		if session.OrgID == orgID && now.Before(session.ExpiresAt) {
+			sessionCount++
+		}
+	}
+	userCount := 0
+	for _, user := range s.users {
+		if user.OrgID == orgID {
+			userCount++
+		}
+	}
+
 	return map[string]interface{}{
-		"total_providers":   len(s.providers),
+		"total_providers":   providerCount,
 		"enabled_providers": enabledCount,
 		"by_type":           byType,
-		"active_sessions":   len(s.sessions),
-		"total_users":       len(s.users),
+		"active_sessions":   sessionCount,
+		"total_users":       userCount,
 	}
 }
 
@@ -0,0 +1,301 @@
+package sso
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/clock"
+	"github.com/akz4ol/gatewayops/gateway/internal/database"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+var testSigningKey = []byte("0123456789abcdef0123456789abcdef")
+
+func newTestService(t *testing.T, clk clock.Clock, redis *database.Redis) *Service {
+	t.Helper()
+	return NewService(zerolog.Nop(), testSigningKey, clk, redis, nil, true)
+}
+
+func newTestSession(t *testing.T, s *Service) *domain.UserSession {
+	t.Helper()
+	user := s.GetUser(uuid.MustParse("00000000-0000-0000-0000-000000000001"))
+	if user == nil {
+		t.Fatal("demo user missing")
+	}
+	session := s.CreateSession(user, &domain.OIDCClaims{}, "192.0.2.1", "test")
+	if session == nil {
+		t.Fatal("CreateSession returned nil")
+	}
+	return session
+}
+
+func TestVerifyToken(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	s := newTestService(t, clk, nil)
+	session := newTestSession(t, s)
+
+	if _, err := s.verifyToken(session.AccessToken); err != nil {
+		t.Fatalf("valid token: %v", err)
+	}
+	if got, _ := s.ValidateSession(session.AccessToken); got == nil || got.ID != session.ID {
+		t.Fatalf("ValidateSession = %v, want session %s", got, session.ID)
+	}
+
+	// Re-signing the payload with another key, or changing it, breaks the
+	// signature
+	parts := strings.Split(session.AccessToken, ".")
+	tampered := parts[0] + "." + parts[1] + "x." + parts[2]
+	if _, err := s.verifyToken(tampered); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("tampered token: err = %v, want ErrInvalidToken", err)
+	}
+	other := NewService(zerolog.Nop(), []byte("another-signing-key-of-32-bytes!"), clk, nil, nil, true)
+	if _, err := other.verifyToken(session.AccessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("token signed with another key: err = %v, want ErrInvalidToken", err)
+	}
+
+	if revoked, err := s.RevokeSession(session.ID); !revoked || err != nil {
+		t.Fatalf("RevokeSession = %v, %v", revoked, err)
+	}
+	if _, err := s.verifyToken(session.AccessToken); !errors.Is(err, ErrRevokedToken) {
+		t.Errorf("revoked token: err = %v, want ErrRevokedToken", err)
+	}
+	if got, _ := s.ValidateSession(session.AccessToken); got != nil {
+		t.Error("ValidateSession accepted a revoked token")
+	}
+}
+
+func TestVerifyTokenExpired(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	s := newTestService(t, clk, nil)
+	session := newTestSession(t, s)
+
+	clk.Advance(sessionTTL)
+	if _, err := s.verifyToken(session.AccessToken); !errors.Is(err, ErrExpiredToken) {
+		t.Errorf("expired token: err = %v, want ErrExpiredToken", err)
+	}
+}
+
+func TestRefreshRevokesReplacedToken(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	s := newTestService(t, clk, nil)
+	session := newTestSession(t, s)
+	oldToken := session.AccessToken
+
+	clk.Advance(time.Minute)
+	refreshed, err := s.RefreshSession(session.RefreshToken)
+	if refreshed == nil || err != nil {
+		t.Fatalf("RefreshSession = %v, %v", refreshed, err)
+	}
+	if _, err := s.verifyToken(oldToken); !errors.Is(err, ErrRevokedToken) {
+		t.Errorf("replaced token: err = %v, want ErrRevokedToken", err)
+	}
+	if _, err := s.verifyToken(refreshed.AccessToken); err != nil {
+		t.Errorf("refreshed token: %v", err)
+	}
+}
+
+func TestRevocationSharedThroughRedis(t *testing.T) {
+	fake, redis := newFakeRedis(t)
+	clk := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	a := newTestService(t, clk, redis)
+	b := newTestService(t, clk, redis)
+
+	session := newTestSession(t, a)
+	if _, err := b.verifyToken(session.AccessToken); err != nil {
+		t.Fatalf("token from another instance: %v", err)
+	}
+
+	clk.Advance(time.Hour)
+	if _, err := a.RevokeSession(session.ID); err != nil {
+		t.Fatalf("RevokeSession: %v", err)
+	}
+	if _, err := b.verifyToken(session.AccessToken); !errors.Is(err, ErrRevokedToken) {
+		t.Errorf("token revoked on another instance: err = %v, want ErrRevokedToken", err)
+	}
+
+	// The revocation lasts for the rest of the token's life
+	if got, want := fake.ttl("sso_revoked:"+session.TokenID), sessionTTL-time.Hour; got != want {
+		t.Errorf("revocation TTL = %s, want %s", got, want)
+	}
+}
+
+func TestRevocationFailureKeepsTheSession(t *testing.T) {
+	fake, redis := newFakeRedis(t)
+	clk := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	s := newTestService(t, clk, redis)
+	session := newTestSession(t, s)
+	oldToken := session.AccessToken
+
+	fake.setFailing(true)
+	if revoked, err := s.RevokeSession(session.ID); revoked || err == nil {
+		t.Errorf("RevokeSession with Redis down = %v, %v; want an error", revoked, err)
+	}
+	if s.GetSession(session.ID) == nil {
+		t.Error("session dropped although its token was not revoked")
+	}
+	if refreshed, err := s.RefreshSession(session.RefreshToken); refreshed != nil || err == nil {
+		t.Errorf("RefreshSession with Redis down = %v, %v; want an error", refreshed, err)
+	}
+	if session.AccessToken != oldToken {
+		t.Error("session got a new token although the old one was not revoked")
+	}
+	if n, err := s.RevokeAllUserSessions(session.UserID); n != 0 || err == nil {
+		t.Errorf("RevokeAllUserSessions with Redis down = %d, %v; want an error", n, err)
+	}
+
+	fake.setFailing(false)
+	if revoked, err := s.RevokeSession(session.ID); !revoked || err != nil {
+		t.Fatalf("RevokeSession after Redis recovered = %v, %v", revoked, err)
+	}
+	if _, err := s.verifyToken(oldToken); !errors.Is(err, ErrRevokedToken) {
+		t.Errorf("revoked token: err = %v, want ErrRevokedToken", err)
+	}
+}
+
+func TestExchangeCodeChecksNonce(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	s := newTestService(t, clk, nil)
+	provider, err := s.CreateProvider(context.Background(), domain.SSOProviderInput{
+		Type:         domain.SSOProviderOkta,
+		Name:         "Okta",
+		IssuerURL:    "https://example.okta.com",
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Enabled:      true,
+	}, uuid.New())
+	if err != nil {
+		t.Fatalf("CreateProvider: %v", err)
+	}
+
+	state, err := s.GenerateAuthState(provider.ID, "/dashboard")
+	if err != nil {
+		t.Fatalf("GenerateAuthState: %v", err)
+	}
+	authURL, err := s.GetAuthorizationURL(provider.ID, state, "https://gateway.example.com/v1/sso/callback")
+	if err != nil {
+		t.Fatalf("GetAuthorizationURL: %v", err)
+	}
+	if !strings.Contains(authURL, "nonce="+state.Nonce) {
+		t.Errorf("authorization URL %s does not carry the login's nonce", authURL)
+	}
+
+	_, claims, err := s.ExchangeCode(provider.ID, "code", "https://gateway.example.com/v1/sso/callback", state.Nonce)
+	if err != nil {
+		t.Fatalf("ExchangeCode: %v", err)
+	}
+	if claims.Nonce != state.Nonce {
+		t.Errorf("claims nonce = %q, want %q", claims.Nonce, state.Nonce)
+	}
+	if _, _, err := s.ExchangeCode(provider.ID, "code", "https://gateway.example.com/v1/sso/callback", ""); !errors.Is(err, ErrNonceMismatch) {
+		t.Errorf("exchange without a nonce: err = %v, want ErrNonceMismatch", err)
+	}
+}
+
+// tokenEndpoint serves a provider's token endpoint, answering every code
+// exchange with an ID token carrying claims.
+func tokenEndpoint(t *testing.T, claims map[string]interface{}) *httptest.Server {
+	t.Helper()
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idToken := "eyJhbGciOiJSUzI1NiJ9." + base64.RawURLEncoding.EncodeToString(payload) + ".c2lnbmF0dXJl"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/token" || r.PostFormValue("code") != "code" || r.PostFormValue("client_secret") != "secret" {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "provider-access",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token":     idToken,
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestExchangeCodeVerifiesTheProvidersIDToken(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	exp := float64(clk.Now().Add(time.Hour).Unix())
+	callback := "https://gateway.example.com/v1/sso/callback"
+
+	tests := []struct {
+		name    string
+		claims  map[string]interface{}
+		nonce   string
+		wantErr error
+	}{
+		{name: "matching nonce", claims: map[string]interface{}{"sub": "u1", "aud": "client", "exp": exp, "nonce": "n-0123"}, nonce: "n-0123"},
+		{name: "another login's nonce", claims: map[string]interface{}{"sub": "u1", "aud": "client", "exp": exp, "nonce": "n-4567"}, nonce: "n-0123", wantErr: ErrNonceMismatch},
+		{name: "no nonce", claims: map[string]interface{}{"sub": "u1", "aud": "client", "exp": exp}, nonce: "n-0123", wantErr: ErrNonceMismatch},
+		{name: "issued to another client", claims: map[string]interface{}{"sub": "u1", "aud": []string{"other"}, "exp": exp, "nonce": "n-0123"}, nonce: "n-0123", wantErr: ErrInvalidIDToken},
+		{name: "expired", claims: map[string]interface{}{"sub": "u1", "aud": "client", "exp": exp - 7200, "nonce": "n-0123"}, nonce: "n-0123", wantErr: ErrInvalidIDToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := tokenEndpoint(t, tt.claims)
+			s := NewService(zerolog.Nop(), testSigningKey, clk, nil, nil, false)
+			provider, err := s.CreateProvider(context.Background(), domain.SSOProviderInput{
+				Type:         domain.SSOProviderGenericOIDC,
+				Name:         "OIDC",
+				IssuerURL:    srv.URL,
+				ClientID:     "client",
+				ClientSecret: "secret",
+				Enabled:      true,
+			}, uuid.New())
+			if err != nil {
+				t.Fatalf("CreateProvider: %v", err)
+			}
+
+			tokens, claims, err := s.ExchangeCode(provider.ID, "code", callback, tt.nonce)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ExchangeCode: %v", err)
+			}
+			if claims.Subject != "u1" || claims.Nonce != tt.nonce || tokens.AccessToken != "provider-access" {
+				t.Errorf("claims = %+v, tokens = %+v; want the provider's", claims, tokens)
+			}
+		})
+	}
+}
+
+func TestVerifyNonce(t *testing.T) {
+	tests := []struct {
+		name    string
+		claim   interface{}
+		nonce   string
+		wantErr bool
+	}{
+		{name: "matching", claim: "n-0123", nonce: "n-0123"},
+		{name: "another login's", claim: "n-4567", nonce: "n-0123", wantErr: true},
+		{name: "missing from the token", nonce: "n-0123", wantErr: true},
+		{name: "not a string", claim: 123, nonce: "123", wantErr: true},
+		{name: "none issued", claim: "", nonce: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		raw := map[string]interface{}{"sub": "user"}
+		if tt.claim != nil {
+			raw["nonce"] = tt.claim
+		}
+		err := verifyNonce(raw, tt.nonce)
+		if gotErr := errors.Is(err, ErrNonceMismatch); gotErr != tt.wantErr {
+			t.Errorf("%s: err = %v, want mismatch %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package sso
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/clock"
+	"github.com/akz4ol/gatewayops/gateway/internal/database"
+	"github.com/akz4ol/gatewayops/gateway/internal/domain"
+	"github.com/redis/go-redis/v9"
+)
+
+// authStateTTL is how long a login may take between starting and the
+// provider's callback.
+const authStateTTL = 10 * time.Minute
+
+// errInvalidState is returned for a state that was never issued, has
+// already been used, or has expired from Redis.
+var errInvalidState = errors.New("invalid state")
+
+// stateStore keeps OAuth login states in Redis, so a login started on one
+// instance can finish on another; without Redis they are kept in memory.
+// Each state can be consumed once.
+type stateStore struct {
+	redis *database.Redis
+	clock clock.Clock
+
+	mu    sync.Mutex
+	local map[string]*domain.AuthState // keyed by state value
+}
+
+func newStateStore(redis *database.Redis, clk clock.Clock) *stateStore {
+	return &stateStore{
+		redis: redis,
+		clock: clk,
+		local: make(map[string]*domain.AuthState),
+	}
+}
+
+func (s *stateStore) useRedis() bool {
+	return s.redis != nil && s.redis.Client != nil
+}
+
+// save stores a new state until it expires.
+func (s *stateStore) save(ctx context.Context, state *domain.AuthState) error {
+	if s.useRedis() {
+		raw, err := json.Marshal(state)
+		if err != nil {
+			return err
+		}
+		if err := s.redis.Set(ctx, "sso_state:"+state.State, raw, authStateTTL); err != nil {
+			return fmt.Errorf("save auth state: %w", err)
+		}
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.local[state.State] = state
+	return nil
+}
+
+// consume removes a state and returns it. Over Redis the read and delete
+// are one atomic GETDEL, so two instances cannot both accept a state.
+func (s *stateStore) consume(ctx context.Context, value string) (*domain.AuthState, error) {
+	if s.useRedis() {
+		raw, err := s.redis.GetDel(ctx, "sso_state:"+value)
+		if errors.Is(err, redis.Nil) {
+			return nil, errInvalidState
+		}
+		if err != nil {
+			return nil, fmt.Errorf("consume auth state: %w", err)
+		}
+		var state domain.AuthState
+		if err := json.Unmarshal([]byte(raw), &state); err != nil {
+			return nil, fmt.Errorf("decode auth state: %w", err)
+		}
+		return &state, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, exists := s.local[value]
+	if !exists {
+		return nil, errInvalidState
+	}
+	delete(s.local, value)
+	return state, nil
+}
+
+// purgeExpired removes the in-memory states that expired without being
+// used, returning how many were removed. Redis expires its states itself.
+func (s *stateStore) purgeExpired() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	var count int64
+	for value, state := range s.local {
+		if now.After(state.ExpiresAt) {
+			delete(s.local, value)
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,42 @@
+package sso
+
+import (
+	"testing"
+	"time"
+
+	"github.com/akz4ol/gatewayops/gateway/internal/clock"
+	"github.com/google/uuid"
+)
+
+func TestAuthStateValidatesOnAnotherInstanceOnce(t *testing.T) {
+	fake, redis := newFakeRedis(t)
+	clk := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	a := newTestService(t, clk, redis)
+	b := newTestService(t, clk, redis)
+
+	providerID := uuid.New()
+	state, err := a.GenerateAuthState(providerID, "https://app.example.com/done")
+	if err != nil {
+		t.Fatalf("GenerateAuthState: %v", err)
+	}
+	if got := fake.ttl("sso_state:" + state.State); got != authStateTTL {
+		t.Errorf("state TTL = %s, want %s", got, authStateTTL)
+	}
+
+	got, err := b.ValidateAuthState(state.State)
+	if err != nil {
+		t.Fatalf("state from another instance: %v", err)
+	}
+	if got.Nonce != state.Nonce || got.ProviderID != providerID || got.RedirectURL != state.RedirectURL {
+		t.Errorf("validated state = %+v, want %+v", got, state)
+	}
+
+	for name, s := range map[string]*Service{"issuing": a, "validating": b} {
+		if _, err := s.ValidateAuthState(state.State); err == nil {
+			t.Errorf("%s instance accepted a used state", name)
+		}
+	}
+	if _, err := b.ValidateAuthState("never-issued"); err == nil {
+		t.Error("accepted a state that was never issued")
+	}
+}
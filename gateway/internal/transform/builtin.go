@@ -0,0 +1,195 @@
+package transform
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// Built-in transform types.
+const (
+	TypeHeaderAdd     = "header_add"      // Set Header to Value
+	TypeHeaderRemove  = "header_remove"   // Delete Header
+	TypeArgDefault    = "arg_default"     // Set argument Field to Value when absent
+	TypeArgPathPrefix = "arg_path_prefix" // Confine path argument Field under Value
+	TypeRedact        = "redact"          // Replace body field Field with a placeholder
+)
+
+// Redacted replaces the value of redacted fields.
+const Redacted = "[REDACTED]"
+
+func init() {
+	Register(TypeHeaderAdd, newHeaderAdd)
+	Register(TypeHeaderRemove, newHeaderRemove)
+	Register(TypeArgDefault, newArgDefault)
+	Register(TypeArgPathPrefix, newArgPathPrefix)
+	Register(TypeRedact, newRedact)
+}
+
+type headerAdd struct {
+	name, value string
+}
+
+func newHeaderAdd(spec Spec) (Transform, error) {
+	if spec.Header == "" {
+		return nil, errors.New("header is required")
+	}
+	value, ok := spec.Value.(string)
+	if !ok {
+		return nil, errors.New("value must be a string")
+	}
+	return &headerAdd{name: spec.Header, value: value}, nil
+}
+
+func (t *headerAdd) Apply(msg *Message) error {
+	if msg.Header != nil {
+		msg.Header.Set(t.name, t.value)
+	}
+	return nil
+}
+
+type headerRemove struct {
+	name string
+}
+
+func newHeaderRemove(spec Spec) (Transform, error) {
+	if spec.Header == "" {
+		return nil, errors.New("header is required")
+	}
+	return &headerRemove{name: http.CanonicalHeaderKey(spec.Header)}, nil
+}
+
+func (t *headerRemove) Apply(msg *Message) error {
+	if msg.Header != nil {
+		msg.Header.Del(t.name)
+	}
+	return nil
+}
+
+// arguments returns the tool call arguments of a request body, creating them
+// if create is set.
+func arguments(msg *Message, create bool) map[string]interface{} {
+	if msg.Body == nil {
+		return nil
+	}
+	args, ok := msg.Body["arguments"].(map[string]interface{})
+	if !ok && create {
+		args = make(map[string]interface{})
+		msg.Body["arguments"] = args
+	}
+	return args
+}
+
+type argDefault struct {
+	field string
+	value interface{}
+}
+
+func newArgDefault(spec Spec) (Transform, error) {
+	if spec.Phase != PhaseRequest {
+		return nil, errors.New("only applies to requests")
+	}
+	if spec.Field == "" {
+		return nil, errors.New("field is required")
+	}
+	if spec.Value == nil {
+		return nil, errors.New("value is required")
+	}
+	return &argDefault{field: spec.Field, value: spec.Value}, nil
+}
+
+func (t *argDefault) Apply(msg *Message) error {
+	args := arguments(msg, true)
+	if args == nil {
+		return nil
+	}
+	if _, ok := args[t.field]; !ok {
+		args[t.field] = t.value
+	}
+	return nil
+}
+
+type argPathPrefix struct {
+	field, prefix string
+}
+
+func newArgPathPrefix(spec Spec) (Transform, error) {
+	if spec.Phase != PhaseRequest {
+		return nil, errors.New("only applies to requests")
+	}
+	if spec.Field == "" {
+		return nil, errors.New("field is required")
+	}
+	prefix, ok := spec.Value.(string)
+	if !ok || !strings.HasPrefix(prefix, "/") {
+		return nil, errors.New("value must be an absolute path")
+	}
+	return &argPathPrefix{field: spec.Field, prefix: path.Clean(prefix)}, nil
+}
+
+// Apply rewrites the path argument to lie under the prefix. The path is
+// cleaned as if rooted first, so ".." cannot climb out of the prefix, and a
+// path already under the prefix is kept.
+func (t *argPathPrefix) Apply(msg *Message) error {
+	args := arguments(msg, false)
+	if args == nil {
+		return nil
+	}
+	value, ok := args[t.field]
+	if !ok {
+		return nil
+	}
+	p, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("argument %q must be a string path", t.field)
+	}
+
+	cleaned := path.Clean("/" + p)
+	if t.prefix == "/" || cleaned == t.prefix || strings.HasPrefix(cleaned, t.prefix+"/") {
+		args[t.field] = cleaned
+		return nil
+	}
+	args[t.field] = path.Join(t.prefix, cleaned)
+	return nil
+}
+
+type redact struct {
+	path []string
+}
+
+func newRedact(spec Spec) (Transform, error) {
+	if spec.Field == "" {
+		return nil, errors.New("field is required")
+	}
+	return &redact{path: strings.Split(spec.Field, ".")}, nil
+}
+
+// Apply replaces the field at the dotted path. Arrays along the path are
+// descended element by element, so "content.text" covers every content block.
+func (t *redact) Apply(msg *Message) error {
+	if msg.Body != nil {
+		redactPath(msg.Body, t.path)
+	}
+	return nil
+}
+
+func redactPath(v interface{}, segments []string) {
+	switch node := v.(type) {
+	case map[string]interface{}:
+		child, ok := node[segments[0]]
+		if !ok {
+			return
+		}
+		if len(segments) == 1 {
+			node[segments[0]] = Redacted
+			return
+		}
+		redactPath(child, segments[1:])
+	case []interface{}:
+		for _, elem := range node {
+			redactPath(elem, segments)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+// Package transform rewrites MCP requests and responses as they pass through
+// the gateway.
+package transform
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// Phase is the point in an MCP call at which a transform runs.
+type Phase string
+
+const (
+	PhaseRequest  Phase = "request"  // Before the call is sent to the MCP server
+	PhaseResponse Phase = "response" // Before the result is returned to the client
+)
+
+// Spec configures one transform in a server's pipeline.
+type Spec struct {
+	Type   string      `json:"type"`
+	Phase  Phase       `json:"phase,omitempty"` // Defaults to request
+	Tool   string      `json:"tool,omitempty"`  // Empty applies to every tool
+	Header string      `json:"header,omitempty"`
+	Field  string      `json:"field,omitempty"`
+	Value  interface{} `json:"value,omitempty"`
+}
+
+// Message is an MCP request or response passing through a pipeline.
+type Message struct {
+	Tool   string
+	Header http.Header
+	// Body is the decoded JSON body, or nil if the body is not a JSON object.
+	Body map[string]interface{}
+}
+
+// Transform modifies a message in place.
+type Transform interface {
+	Apply(msg *Message) error
+}
+
+// Factory builds a transform from its spec, rejecting specs it cannot
+// honour.
+type Factory func(spec Spec) (Transform, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Factory)
+)
+
+// Register makes a transform type available to pipelines. It panics if the
+// type is already registered.
+func Register(typ string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, ok := registry[typ]; ok {
+		panic("transform: type already registered: " + typ)
+	}
+	registry[typ] = factory
+}
+
+// Types returns the registered transform types.
+func Types() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	types := make([]string, 0, len(registry))
+	for typ := range registry {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+	return types
+}
+
+type step struct {
+	phase     Phase
+	tool      string
+	transform Transform
+}
+
+// Pipeline applies an ordered list of transforms.
+type Pipeline struct {
+	steps []step
+}
+
+// New builds a pipeline from specs, in order.
+func New(specs []Spec) (*Pipeline, error) {
+	p := &Pipeline{}
+	for i, spec := range specs {
+		if spec.Phase == "" {
+			spec.Phase = PhaseRequest
+		}
+		if spec.Phase != PhaseRequest && spec.Phase != PhaseResponse {
+			return nil, fmt.Errorf("transform %d: phase %q must be request or response", i, spec.Phase)
+		}
+
+		registryMu.RLock()
+		factory, ok := registry[spec.Type]
+		registryMu.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("transform %d: unknown type %q", i, spec.Type)
+		}
+
+		t, err := factory(spec)
+		if err != nil {
+			return nil, fmt.Errorf("transform %d (%s): %w", i, spec.Type, err)
+		}
+		p.steps = append(p.steps, step{phase: spec.Phase, tool: spec.Tool, transform: t})
+	}
+	return p, nil
+}
+
+// Apply runs the transforms for phase that are scoped to tool over header
+// and body, returning the rewritten body. The body is only re-encoded when a
+// transform runs, so it passes through untouched otherwise.
+func (p *Pipeline) Apply(phase Phase, tool string, header http.Header, body []byte) ([]byte, error) {
+	var steps []step
+	for _, s := range p.steps {
+		if s.phase == phase && (s.tool == "" || s.tool == tool) {
+			steps = append(steps, s)
+		}
+	}
+	if len(steps) == 0 {
+		return body, nil
+	}
+
+	msg := &Message{Tool: tool, Header: header}
+	if len(bytes.TrimSpace(body)) > 0 {
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+		var decoded map[string]interface{}
+		if err := dec.Decode(&decoded); err == nil {
+			msg.Body = decoded
+		}
+	}
+
+	for _, s := range steps {
+		if err := s.transform.Apply(msg); err != nil {
+			return nil, err
+		}
+	}
+
+	if msg.Body == nil {
+		return body, nil
+	}
+	return json.Marshal(msg.Body)
+}
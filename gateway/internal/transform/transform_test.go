@@ -0,0 +1,145 @@
+package transform
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func mustPipeline(t *testing.T, specs ...Spec) *Pipeline {
+	t.Helper()
+	p, err := New(specs)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return p
+}
+
+// apply runs a request body through p and returns the decoded result.
+func apply(t *testing.T, p *Pipeline, phase Phase, tool, body string) map[string]interface{} {
+	t.Helper()
+	out, err := p.Apply(phase, tool, http.Header{}, []byte(body))
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(out, &decoded); err != nil {
+		t.Fatalf("decode %s: %v", out, err)
+	}
+	return decoded
+}
+
+func TestArgPathPrefix(t *testing.T) {
+	p := mustPipeline(t, Spec{Type: TypeArgPathPrefix, Field: "path", Value: "/srv/data/"})
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"reports/q1.csv", "/srv/data/reports/q1.csv"},
+		{"/etc/passwd", "/srv/data/etc/passwd"},
+		{"../../etc/passwd", "/srv/data/etc/passwd"},
+		{"/srv/data/reports/../q1.csv", "/srv/data/q1.csv"},
+		{"/srv/data", "/srv/data"},
+		{"/srv/database", "/srv/data/srv/database"},
+	}
+	for _, tt := range tests {
+		body := `{"name":"read_file","arguments":{"path":` + quote(tt.path) + `}}`
+		got := apply(t, p, PhaseRequest, "read_file", body)
+		if path := got["arguments"].(map[string]interface{})["path"]; path != tt.want {
+			t.Errorf("path %q rewritten to %q, want %q", tt.path, path, tt.want)
+		}
+	}
+
+	if _, err := p.Apply(PhaseRequest, "read_file", nil, []byte(`{"arguments":{"path":7}}`)); err == nil {
+		t.Error("Apply accepted a path that is not a string")
+	}
+}
+
+func TestPipelineScopesAndOrder(t *testing.T) {
+	p := mustPipeline(t,
+		Spec{Type: TypeArgDefault, Tool: "search", Field: "limit", Value: 10},
+		Spec{Type: TypeArgDefault, Field: "source", Value: "gateway"},
+		Spec{Type: TypeArgDefault, Field: "source", Value: "ignored"},
+		Spec{Type: TypeHeaderAdd, Header: "X-Tenant", Value: "acme"},
+		Spec{Type: TypeHeaderRemove, Header: "authorization"},
+		Spec{Type: TypeRedact, Phase: PhaseResponse, Field: "content.text"},
+	)
+
+	header := http.Header{"Authorization": {"Bearer secret"}}
+	out, err := p.Apply(PhaseRequest, "search", header, []byte(`{"arguments":{"limit":5,"q":"x"}}`))
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	var request map[string]interface{}
+	json.Unmarshal(out, &request)
+	wantArgs := map[string]interface{}{"limit": float64(5), "q": "x", "source": "gateway"}
+	if args := request["arguments"]; !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("arguments = %v, want %v", args, wantArgs)
+	}
+	if header.Get("X-Tenant") != "acme" || header.Get("Authorization") != "" {
+		t.Errorf("headers = %v, want X-Tenant set and Authorization removed", header)
+	}
+
+	// Tool-scoped steps skip other tools; arguments are created when absent
+	other := apply(t, p, PhaseRequest, "fetch", `{"name":"fetch"}`)
+	if args := other["arguments"]; !reflect.DeepEqual(args, map[string]interface{}{"source": "gateway"}) {
+		t.Errorf("arguments for another tool = %v", args)
+	}
+
+	response := apply(t, p, PhaseResponse, "search", `{"content":[{"type":"text","text":"a"},{"type":"image"}],"isError":false}`)
+	wantContent := []interface{}{
+		map[string]interface{}{"type": "text", "text": Redacted},
+		map[string]interface{}{"type": "image"},
+	}
+	if !reflect.DeepEqual(response["content"], wantContent) {
+		t.Errorf("response content = %v, want %v", response["content"], wantContent)
+	}
+}
+
+func TestPipelinePassesBodyThrough(t *testing.T) {
+	p := mustPipeline(t, Spec{Type: TypeRedact, Phase: PhaseResponse, Field: "secret"})
+
+	// No step for the phase: the bytes are returned as they came
+	body := []byte(`{ "big": 12345678901234567890, "secret": "s" }`)
+	if out, _ := p.Apply(PhaseRequest, "t", nil, body); string(out) != string(body) {
+		t.Errorf("request body = %s, want it untouched", out)
+	}
+	// A body that is not a JSON object is left alone too
+	for _, raw := range []string{`[1,2]`, `not json`, ``} {
+		if out, err := p.Apply(PhaseResponse, "t", nil, []byte(raw)); err != nil || string(out) != raw {
+			t.Errorf("Apply(%q) = %q, %v; want it untouched", raw, out, err)
+		}
+	}
+	// Large numbers survive being decoded and encoded again
+	if out, _ := p.Apply(PhaseResponse, "t", nil, body); string(out) != `{"big":12345678901234567890,"secret":"[REDACTED]"}` {
+		t.Errorf("response body = %s", out)
+	}
+}
+
+func TestNewRejectsBadSpecs(t *testing.T) {
+	tests := []struct {
+		name string
+		spec Spec
+	}{
+		{"unknown type", Spec{Type: "rewrite"}},
+		{"unknown phase", Spec{Type: TypeRedact, Phase: "both", Field: "x"}},
+		{"header without name", Spec{Type: TypeHeaderAdd, Value: "v"}},
+		{"header value not a string", Spec{Type: TypeHeaderAdd, Header: "X", Value: 1}},
+		{"default on responses", Spec{Type: TypeArgDefault, Phase: PhaseResponse, Field: "f", Value: 1}},
+		{"default without value", Spec{Type: TypeArgDefault, Field: "f"}},
+		{"relative prefix", Spec{Type: TypeArgPathPrefix, Field: "path", Value: "data"}},
+		{"redact without field", Spec{Type: TypeRedact}},
+	}
+	for _, tt := range tests {
+		if _, err := New([]Spec{tt.spec}); err == nil {
+			t.Errorf("%s: New accepted %+v", tt.name, tt.spec)
+		}
+	}
+}
+
+func quote(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
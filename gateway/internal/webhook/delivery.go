@@ -0,0 +1,129 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DeliveryError describes a failed webhook delivery. Retryable failures
+// (rate limiting, server errors, network errors) may succeed if sent again;
+// permanent failures (bad URL, revoked webhook, malformed payload) will not.
+type DeliveryError struct {
+	StatusCode int
+	RetryAfter time.Duration
+	Retryable  bool
+	Attempts   int
+	Err        error
+}
+
+func (e *DeliveryError) Error() string {
+	kind := "permanent"
+	if e.Retryable {
+		kind = "retryable"
+	}
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("%s delivery failure after %d attempt(s): status %d", kind, e.Attempts, e.StatusCode)
+	}
+	return fmt.Sprintf("%s delivery failure after %d attempt(s): %v", kind, e.Attempts, e.Err)
+}
+
+func (e *DeliveryError) Unwrap() error {
+	return e.Err
+}
+
+// IsRetryable reports whether err is a delivery failure worth retrying later.
+func IsRetryable(err error) bool {
+	var deliveryErr *DeliveryError
+	return errors.As(err, &deliveryErr) && deliveryErr.Retryable
+}
+
+// retryableStatus reports whether an HTTP status indicates a transient failure.
+func retryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status == http.StatusRequestTimeout || status >= 500
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP
+// date. It returns 0 when the header is absent or invalid.
+func parseRetryAfter(header string, now time.Time) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(header); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+// backoff returns the exponential delay before retry attempt n (1-based).
+func backoff(base, max time.Duration, attempt int) time.Duration {
+	delay := base << uint(attempt-1)
+	if delay <= 0 || delay > max {
+		return max
+	}
+	return delay
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// rateLimiter spaces requests to each destination at least interval apart and
+// lets a destination be paused when it asks us to back off.
+type rateLimiter struct {
+	interval time.Duration
+	next     map[string]time.Time
+	mu       sync.Mutex
+}
+
+func newRateLimiter(interval time.Duration) *rateLimiter {
+	return &rateLimiter{
+		interval: interval,
+		next:     make(map[string]time.Time),
+	}
+}
+
+// wait blocks until a request to key may be sent and reserves that slot.
+func (l *rateLimiter) wait(ctx context.Context, key string) error {
+	l.mu.Lock()
+	now := time.Now()
+	at := l.next[key]
+	if at.Before(now) {
+		at = now
+	}
+	l.next[key] = at.Add(l.interval)
+	l.mu.Unlock()
+
+	return sleepContext(ctx, time.Until(at))
+}
+
+// pause delays all further requests to key by at least d.
+func (l *rateLimiter) pause(key string, d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if until := time.Now().Add(d); until.After(l.next[key]) {
+		l.next[key] = until
+	}
+}
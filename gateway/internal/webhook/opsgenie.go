@@ -0,0 +1,132 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Opsgenie API endpoints by account region.
+const (
+	OpsgenieUSURL = "https://api.opsgenie.com"
+	OpsgenieEUURL = "https://api.eu.opsgenie.com"
+)
+
+// opsgenieMessageLimit is the maximum length Opsgenie accepts for a message.
+const opsgenieMessageLimit = 130
+
+// OpsgenieClient handles Opsgenie Alert API notifications.
+type OpsgenieClient struct {
+	httpClient *http.Client
+}
+
+// NewOpsgenieClient creates a new Opsgenie client.
+func NewOpsgenieClient() *OpsgenieClient {
+	return &OpsgenieClient{
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// OpsgenieBaseURL returns the API endpoint for a region ("us" or "eu").
+func OpsgenieBaseURL(region string) (string, error) {
+	switch strings.ToLower(region) {
+	case "", "us":
+		return OpsgenieUSURL, nil
+	case "eu":
+		return OpsgenieEUURL, nil
+	default:
+		return "", fmt.Errorf("unknown opsgenie region %q", region)
+	}
+}
+
+// OpsgenieAlert represents an alert to create in Opsgenie.
+type OpsgenieAlert struct {
+	Message     string            `json:"message"`
+	Alias       string            `json:"alias,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Priority    string            `json:"priority,omitempty"` // P1 (highest) to P5
+	Source      string            `json:"source,omitempty"`
+	Entity      string            `json:"entity,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+	Details     map[string]string `json:"details,omitempty"`
+}
+
+// opsgenieClose is the body of a close request.
+type opsgenieClose struct {
+	Source string `json:"source,omitempty"`
+	Note   string `json:"note,omitempty"`
+}
+
+// OpsgeniePriority maps an alert severity to an Opsgenie priority.
+func OpsgeniePriority(severity string) string {
+	switch severity {
+	case "critical":
+		return "P1"
+	case "warning":
+		return "P3"
+	case "info":
+		return "P5"
+	default:
+		return "P3"
+	}
+}
+
+// CreateAlert creates an alert. Opsgenie deduplicates open alerts that share
+// an alias.
+func (c *OpsgenieClient) CreateAlert(ctx context.Context, baseURL, apiKey string, alert OpsgenieAlert) error {
+	if len(alert.Message) > opsgenieMessageLimit {
+		alert.Message = alert.Message[:opsgenieMessageLimit-3] + "..."
+	}
+	return c.send(ctx, baseURL+"/v2/alerts", apiKey, alert)
+}
+
+// CloseAlert closes the open alert with the given alias.
+func (c *OpsgenieClient) CloseAlert(ctx context.Context, baseURL, apiKey, alias, note string) error {
+	endpoint := fmt.Sprintf("%s/v2/alerts/%s/close?identifierType=alias", baseURL, url.PathEscape(alias))
+	return c.send(ctx, endpoint, apiKey, opsgenieClose{Source: "gatewayops", Note: note})
+}
+
+// send posts a request to the Opsgenie API.
+func (c *OpsgenieClient) send(ctx context.Context, endpoint, apiKey string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "GenieKey "+apiKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return &DeliveryError{Retryable: true, Attempts: 1, Err: fmt.Errorf("send request: %w", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		var ogResp struct {
+			Message string `json:"message"`
+		}
+		json.NewDecoder(resp.Body).Decode(&ogResp)
+		return &DeliveryError{
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+			Retryable:  retryableStatus(resp.StatusCode),
+			Attempts:   1,
+			Err:        fmt.Errorf("opsgenie error: %s (status %d)", ogResp.Message, resp.StatusCode),
+		}
+	}
+
+	return nil
+}
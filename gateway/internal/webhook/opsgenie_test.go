@@ -0,0 +1,116 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOpsgenieCreateAndClose(t *testing.T) {
+	type request struct {
+		path, query, auth string
+		body              map[string]interface{}
+	}
+	var got []request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		got = append(got, request{r.URL.EscapedPath(), r.URL.RawQuery, r.Header.Get("Authorization"), body})
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	c := NewOpsgenieClient()
+	ctx := context.Background()
+	err := c.CreateAlert(ctx, srv.URL, "key", OpsgenieAlert{
+		Message:  strings.Repeat("m", 200),
+		Alias:    "rule/1",
+		Priority: OpsgeniePriority("critical"),
+		Details:  map[string]string{"value": "12.00", "threshold": "5.00"},
+	})
+	if err != nil {
+		t.Fatalf("CreateAlert: %v", err)
+	}
+	if err := c.CloseAlert(ctx, srv.URL, "key", "rule/1", "resolved"); err != nil {
+		t.Fatalf("CloseAlert: %v", err)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("%d requests, want 2", len(got))
+	}
+	create, closeReq := got[0], got[1]
+	if create.path != "/v2/alerts" || create.auth != "GenieKey key" {
+		t.Errorf("create sent to %s with %q", create.path, create.auth)
+	}
+	if msg, _ := create.body["message"].(string); len(msg) != opsgenieMessageLimit || !strings.HasSuffix(msg, "...") {
+		t.Errorf("message of %d bytes, want it truncated to %d", len(msg), opsgenieMessageLimit)
+	}
+	if create.body["alias"] != "rule/1" || create.body["priority"] != "P1" {
+		t.Errorf("alias = %v, priority = %v", create.body["alias"], create.body["priority"])
+	}
+	if details, _ := create.body["details"].(map[string]interface{}); details["threshold"] != "5.00" {
+		t.Errorf("details = %v", create.body["details"])
+	}
+	if closeReq.path != "/v2/alerts/rule%2F1/close" || closeReq.query != "identifierType=alias" {
+		t.Errorf("close sent to %s?%s", closeReq.path, closeReq.query)
+	}
+	if closeReq.body["note"] != "resolved" {
+		t.Errorf("close note = %v", closeReq.body["note"])
+	}
+}
+
+func TestOpsgenieErrors(t *testing.T) {
+	tests := []struct {
+		status    int
+		wantRetry bool
+	}{
+		{http.StatusUnauthorized, false},
+		{http.StatusUnprocessableEntity, false},
+		{http.StatusTooManyRequests, true},
+		{http.StatusServiceUnavailable, true},
+	}
+	for _, tt := range tests {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+			w.Write([]byte(`{"message":"rejected"}`))
+		}))
+		err := NewOpsgenieClient().CreateAlert(context.Background(), srv.URL, "key", OpsgenieAlert{Message: "m"})
+		srv.Close()
+
+		if err == nil || !strings.Contains(err.Error(), "status") {
+			t.Errorf("status %d: err = %v", tt.status, err)
+			continue
+		}
+		if IsRetryable(err) != tt.wantRetry {
+			t.Errorf("status %d: IsRetryable = %v, want %v", tt.status, IsRetryable(err), tt.wantRetry)
+		}
+	}
+}
+
+func TestOpsgenieRegions(t *testing.T) {
+	tests := []struct {
+		region  string
+		want    string
+		wantErr bool
+	}{
+		{"", OpsgenieUSURL, false},
+		{"us", OpsgenieUSURL, false},
+		{"EU", OpsgenieEUURL, false},
+		{"apac", "", true},
+	}
+	for _, tt := range tests {
+		got, err := OpsgenieBaseURL(tt.region)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("OpsgenieBaseURL(%q) = %q, %v", tt.region, got, err)
+		}
+	}
+
+	for severity, want := range map[string]string{"critical": "P1", "warning": "P3", "info": "P5", "": "P3"} {
+		if got := OpsgeniePriority(severity); got != want {
+			t.Errorf("OpsgeniePriority(%q) = %s, want %s", severity, got, want)
+		}
+	}
+}
@@ -10,9 +10,25 @@ import (
 	"time"
 )
 
-// SlackClient handles Slack webhook notifications.
+const (
+	// slackMaxAttempts bounds delivery attempts per message.
+	slackMaxAttempts = 4
+	// slackMinInterval spaces messages to a single webhook; Slack allows
+	// roughly one message per second per incoming webhook.
+	slackMinInterval = time.Second
+	slackBaseBackoff = 500 * time.Millisecond
+	slackMaxBackoff  = 30 * time.Second
+)
+
+// SlackClient handles Slack webhook notifications. Deliveries to the same
+// webhook are rate limited client-side, and rate-limited or failed deliveries
+// are retried with backoff honoring Slack's Retry-After header.
 type SlackClient struct {
-	httpClient *http.Client
+	httpClient  *http.Client
+	limiter     *rateLimiter
+	maxAttempts int
+	baseBackoff time.Duration
+	maxBackoff  time.Duration
 }
 
 // NewSlackClient creates a new Slack webhook client.
@@ -21,6 +37,10 @@ func NewSlackClient() *SlackClient {
 		httpClient: &http.Client{
 			Timeout: 10 * time.Second,
 		},
+		limiter:     newRateLimiter(slackMinInterval),
+		maxAttempts: slackMaxAttempts,
+		baseBackoff: slackBaseBackoff,
+		maxBackoff:  slackMaxBackoff,
 	}
 }
 
@@ -126,28 +146,72 @@ func (c *SlackClient) SendResolution(ctx context.Context, webhookURL string, tit
 	return c.sendWebhook(ctx, webhookURL, slackMessage)
 }
 
-// sendWebhook sends a message to a Slack webhook URL.
+// sendWebhook sends a message to a Slack webhook URL, retrying retryable
+// failures. The returned error is a *DeliveryError unless the message could
+// not be encoded or the context ended.
 func (c *SlackClient) sendWebhook(ctx context.Context, webhookURL string, message SlackMessage) error {
 	body, err := json.Marshal(message)
 	if err != nil {
 		return fmt.Errorf("marshal message: %w", err)
 	}
 
+	var lastErr *DeliveryError
+	for attempt := 1; attempt <= c.maxAttempts; attempt++ {
+		if attempt > 1 {
+			delay := backoff(c.baseBackoff, c.maxBackoff, attempt-1)
+			if lastErr.RetryAfter > delay {
+				delay = lastErr.RetryAfter
+			}
+			if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+				return lastErr
+			}
+			if err := sleepContext(ctx, delay); err != nil {
+				return lastErr
+			}
+		}
+
+		if err := c.limiter.wait(ctx, webhookURL); err != nil {
+			return err
+		}
+
+		lastErr = c.post(ctx, webhookURL, body)
+		if lastErr == nil {
+			return nil
+		}
+		lastErr.Attempts = attempt
+		if lastErr.RetryAfter > 0 {
+			c.limiter.pause(webhookURL, lastErr.RetryAfter)
+		}
+		if !lastErr.Retryable {
+			return lastErr
+		}
+	}
+
+	return lastErr
+}
+
+// post performs a single delivery attempt.
+func (c *SlackClient) post(ctx context.Context, webhookURL string, body []byte) *DeliveryError {
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(body))
 	if err != nil {
-		return fmt.Errorf("create request: %w", err)
+		return &DeliveryError{Err: fmt.Errorf("create request: %w", err)}
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("send request: %w", err)
+		return &DeliveryError{Retryable: ctx.Err() == nil, Err: fmt.Errorf("send request: %w", err)}
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
-		return fmt.Errorf("slack returned status %d", resp.StatusCode)
+		return &DeliveryError{
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+			Retryable:  retryableStatus(resp.StatusCode),
+			Err:        fmt.Errorf("slack returned status %d", resp.StatusCode),
+		}
 	}
 
 	return nil
@@ -0,0 +1,186 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestSlackClient returns a client with delays short enough for tests.
+func newTestSlackClient() *SlackClient {
+	c := NewSlackClient()
+	c.limiter = newRateLimiter(0)
+	c.baseBackoff = time.Millisecond
+	c.maxBackoff = 10 * time.Millisecond
+	return c
+}
+
+// slackServer answers successive requests with the given statuses, then 200,
+// and records when each request arrived.
+func slackServer(t *testing.T, retryAfter string, statuses ...int) (*httptest.Server, func() []time.Time) {
+	t.Helper()
+	var mu sync.Mutex
+	var arrivals []time.Time
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		mu.Lock()
+		n := len(arrivals)
+		arrivals = append(arrivals, time.Now())
+		mu.Unlock()
+		if n < len(statuses) {
+			if statuses[n] == http.StatusTooManyRequests && retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(statuses[n])
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]time.Time(nil), arrivals...)
+	}
+}
+
+func TestSlackRetriesTransientFailures(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		wantErr      bool
+		wantRequests int
+		wantStatus   int
+		wantRetry    bool
+	}{
+		{name: "success", wantRequests: 1},
+		{name: "server error then success", statuses: []int{500, 502}, wantRequests: 3},
+		{name: "rate limited then success", statuses: []int{429}, wantRequests: 2},
+		{name: "bad request", statuses: []int{400}, wantErr: true, wantRequests: 1, wantStatus: 400},
+		{name: "revoked webhook", statuses: []int{404}, wantErr: true, wantRequests: 1, wantStatus: 404},
+		{name: "attempts exhausted", statuses: []int{503, 503, 503, 503, 503}, wantErr: true, wantRequests: slackMaxAttempts, wantStatus: 503, wantRetry: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, arrivals := slackServer(t, "", tt.statuses...)
+			err := newTestSlackClient().TestWebhook(context.Background(), srv.URL)
+
+			if got := len(arrivals()); got != tt.wantRequests {
+				t.Errorf("%d requests, want %d", got, tt.wantRequests)
+			}
+			if !tt.wantErr {
+				if err != nil {
+					t.Errorf("TestWebhook: %v", err)
+				}
+				return
+			}
+			var deliveryErr *DeliveryError
+			if !errors.As(err, &deliveryErr) {
+				t.Fatalf("err = %v, want a *DeliveryError", err)
+			}
+			if deliveryErr.StatusCode != tt.wantStatus || deliveryErr.Attempts != tt.wantRequests {
+				t.Errorf("err = %+v, want status %d after %d attempts", deliveryErr, tt.wantStatus, tt.wantRequests)
+			}
+			if IsRetryable(err) != tt.wantRetry {
+				t.Errorf("IsRetryable = %v, want %v", IsRetryable(err), tt.wantRetry)
+			}
+		})
+	}
+}
+
+func TestSlackHonorsRetryAfter(t *testing.T) {
+	srv, arrivals := slackServer(t, "1", http.StatusTooManyRequests)
+	if err := newTestSlackClient().TestWebhook(context.Background(), srv.URL); err != nil {
+		t.Fatalf("TestWebhook: %v", err)
+	}
+	got := arrivals()
+	if len(got) != 2 {
+		t.Fatalf("%d requests, want 2", len(got))
+	}
+	if wait := got[1].Sub(got[0]); wait < 900*time.Millisecond {
+		t.Errorf("retried after %s, want at least the 1s Retry-After", wait)
+	}
+}
+
+func TestSlackGivesUpBeforeDeadline(t *testing.T) {
+	srv, arrivals := slackServer(t, "60", http.StatusTooManyRequests)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	start := time.Now()
+	err := newTestSlackClient().TestWebhook(ctx, srv.URL)
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("waited %s for a Retry-After past the deadline", elapsed)
+	}
+	if !IsRetryable(err) {
+		t.Errorf("err = %v, want the retryable rate limit failure", err)
+	}
+	if n := len(arrivals()); n != 1 {
+		t.Errorf("%d requests, want 1", n)
+	}
+}
+
+func TestSlackRateLimitsPerWebhook(t *testing.T) {
+	srv, arrivals := slackServer(t, "")
+	c := newTestSlackClient()
+	c.limiter = newRateLimiter(100 * time.Millisecond)
+
+	for i := 0; i < 3; i++ {
+		if err := c.TestWebhook(context.Background(), srv.URL); err != nil {
+			t.Fatalf("TestWebhook: %v", err)
+		}
+	}
+	got := arrivals()
+	for i := 1; i < len(got); i++ {
+		if gap := got[i].Sub(got[i-1]); gap < 90*time.Millisecond {
+			t.Errorf("messages %d and %d sent %s apart, want at least the interval", i-1, i, gap)
+		}
+	}
+
+	// Another webhook has its own budget
+	other, _ := slackServer(t, "")
+	start := time.Now()
+	if err := c.TestWebhook(context.Background(), other.URL); err != nil {
+		t.Fatalf("TestWebhook: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("first message to another webhook waited %s", elapsed)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		header string
+		want   time.Duration
+	}{
+		{"", 0},
+		{"5", 5 * time.Second},
+		{"-1", 0},
+		{"soon", 0},
+		{now.Add(30 * time.Second).Format(http.TimeFormat), 30 * time.Second},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.header, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	base, max := 500*time.Millisecond, 3*time.Second
+	want := []time.Duration{500 * time.Millisecond, time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second}
+	for i, w := range want {
+		if got := backoff(base, max, i+1); got != w {
+			t.Errorf("backoff(attempt %d) = %s, want %s", i+1, got, w)
+		}
+	}
+	if got := backoff(base, max, 80); got != max {
+		t.Errorf("backoff past overflow = %s, want the maximum", got)
+	}
+}
@@ -11,6 +11,8 @@ export interface ConnectOptions {
   transport?: 'http' | 'websocket' | 'sse';
   callbackUrl?: string;
   metadata?: Record<string, unknown>;
+  /** Lowers the gateway's cap on what this connection may spend, in USD */
+  costCapUsd?: number;
 }
 
 export interface ConnectionInfo {
@@ -22,9 +24,15 @@ export interface ConnectionInfo {
 
 export interface ServerInfo {
   name: string;
-  description?: string;
   tools: number;
-  resources: number;
+  available_tools: ToolInfo[];
+  tools_unavailable?: boolean;
+}
+
+export interface ToolInfo {
+  name: string;
+  classification: 'safe' | 'sensitive' | 'dangerous';
+  requires_approval: boolean;
 }
 
 export interface RateLimits {
@@ -181,6 +189,7 @@ export class AgentsClient {
         transport: options.transport || 'http',
         callback_url: options.callbackUrl,
         metadata: options.metadata,
+        cost_cap_usd: options.costCapUsd,
       },
     });
 